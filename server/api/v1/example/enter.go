@@ -9,6 +9,8 @@ type ApiGroup struct {
 	MerUserApi
 	SysUserConfigApi
 	MerPayOrderApi
+	MerPaySuspenseApi
+	RiskRuleHitApi
 }
 
 var (
@@ -18,4 +20,6 @@ var (
 	merUserService               = service.ServiceGroupApp.ExampleServiceGroup.MerUserService
 	sysUserConfigService         = service.ServiceGroupApp.ExampleServiceGroup.SysUserConfigService
 	merPayOrderService           = service.ServiceGroupApp.ExampleServiceGroup.MerPayOrderService
+	merPaySuspenseService        = service.ServiceGroupApp.ExampleServiceGroup.MerPaySuspenseService
+	riskRuleService              = service.ServiceGroupApp.ExampleServiceGroup.RiskRuleService
 )
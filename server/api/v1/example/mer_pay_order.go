@@ -1,14 +1,19 @@
 package example
 
 import (
-	"fmt"
+	"errors"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
 	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
 	exampleRes "github.com/flipped-aurora/gin-vue-admin/server/model/example/response"
+	payTask "github.com/flipped-aurora/gin-vue-admin/server/task"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 	"strconv"
 )
 
@@ -130,7 +135,11 @@ func (merPayOrderApi *MerPayOrderApi) FindMerPayOrder(c *gin.Context) {
 	// 创建业务用Context
 	ctx := c.Request.Context()
 
-	id := c.Query("id")
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		response.FailWithMessage("订单ID格式错误", c)
+		return
+	}
 	remerPayOrder, err := merPayOrderService.GetMerPayOrder(ctx, id)
 	if err != nil {
 		global.GVA_LOG.Error("查询失败!", zap.Error(err))
@@ -173,11 +182,18 @@ func (merPayOrderApi *MerPayOrderApi) GetMerPayOrderState(c *gin.Context) {
 		return
 	}
 
+	// 沙箱token与正式token只能查询各自模式下的订单
+	if remerPayOrder.IsSandbox() != middleware.IsSandboxRequest(c) {
+		response.FailWithMessage("订单不存在", c)
+		return
+	}
+
 	// 创建限制字段的响应结构
 	limitedResponse := exampleRes.MerPayOrderStateResponse{
 		State:          remerPayOrder.State,
 		RequestAmmount: remerPayOrder.RequestAmmount,
 		Ammount:        remerPayOrder.Ammount,
+		Currency:       remerPayOrder.Currency,
 		PayTime:        remerPayOrder.PayTime,
 	}
 
@@ -189,83 +205,68 @@ func (merPayOrderApi *MerPayOrderApi) CancelMerPayOrder(c *gin.Context) {
 	ctx := c.Request.Context()
 	id := c.Query("id")
 
-	// 从gin上下文中获取sys_user_id（由PermanentTokenAuth中间件设置）
-	var sysUserIdPtr *int64
-	if sysUserIdValue, exists := c.Get("sys_user_id"); exists {
-		if sysUserId, ok := sysUserIdValue.(uint); ok {
-			sysUserIdInt64 := int64(sysUserId)
-			sysUserIdPtr = &sysUserIdInt64
-		} else {
-			global.GVA_LOG.Error("sys_user_id 类型转换失败")
-			response.FailWithMessage("用户ID获取失败", c)
-			return
-		}
-	} else {
+	// 请求用户由 PermanentTokenAuth 写入，经 WithSysUserID 注入 ctx 后按数据权限过滤订单
+	if utils.ContextUserID(ctx) == 0 {
 		global.GVA_LOG.Error("未找到sys_user_id")
 		response.FailWithMessage("order user not found", c)
 		return
 	}
-
-	var merPayOrder example.MerPayOrder
-	merPayOrder.SysUserId = sysUserIdPtr
-	if idInt64, err := strconv.ParseInt(id, 10, 64); err == nil {
-		merPayOrder.Id = &idInt64
-	} else {
+	orderId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
 		global.GVA_LOG.Error("订单ID格式错误", zap.Error(err))
 		response.FailWithMessage("订单ID格式错误", c)
 		return
 	}
-	// 先查询订单获取完整信息，用于构建Redis键
-	remerPayOrder, err := merPayOrderService.GetMerPayOrder(ctx, id)
+	// 先查询订单获取完整信息，用于构建Redis键；无权访问的订单与不存在的订单返回相同结果
+	remerPayOrder, err := merPayOrderService.GetScopedMerPayOrder(ctx, orderId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.FailWithMessage("订单不存在", c)
+		return
+	}
 	if err != nil {
 		global.GVA_LOG.Error("查询订单失败!", zap.Error(err))
 		response.FailWithMessage("查询订单失败:"+err.Error(), c)
 		return
 	}
-
-	// 检查订单是否属于当前用户
-	if remerPayOrder.SysUserId == nil || *remerPayOrder.SysUserId != *sysUserIdPtr {
-		global.GVA_LOG.Error("订单不属于当前用户")
-		response.FailWithMessage("无权限操作此订单", c)
+	if remerPayOrder.IsSandbox() != middleware.IsSandboxRequest(c) {
+		response.FailWithMessage("订单不存在", c)
 		return
 	}
 
 	// 检查订单状态，避免重复取消
-	if remerPayOrder.State != nil && remerPayOrder.State == global.MER_PAY_ORDER_CANCELED {
+	if remerPayOrder.State != nil && *remerPayOrder.State == *global.MER_PAY_ORDER_CANCELED {
 		response.OkWithDetailed(gin.H{
 			"success": true,
 		}, "订单已经是取消状态", c)
 		return
 	}
 
-	// 构建 Redis 键：pay_amount_used:userID:amount
 	// 检查指针是否为nil，防止panic
-	if sysUserIdPtr == nil || remerPayOrder.Ammount == nil {
-		global.GVA_LOG.Error("构建Redis键失败: sysUserIdPtr或Ammount为nil")
+	if remerPayOrder.MerId == nil || remerPayOrder.RequestAmmount == nil {
+		global.GVA_LOG.Error("构建Redis键失败: MerId或RequestAmmount为nil")
 		response.FailWithMessage("订单信息不完整", c)
 		return
 	}
 
-	// 将decimal转换为字符串格式
-	amountStr := remerPayOrder.Ammount.String()
-	redisKey := fmt.Sprintf("%s:%d:%s", global.PAY_AMOUNT_USED_KEY, *sysUserIdPtr, amountStr)
-
-	// 更新订单状态为取消
-	merPayOrder.State = global.MER_PAY_ORDER_CANCELED
-	err = merPayOrderService.UpdateMerPayOrder(ctx, merPayOrder)
+	// 仅待支付的订单可以取消，与监控任务确认支付并发时以先完成状态变更的一方为准
+	canceled, err := merPayOrderService.CancelMerPayOrder(ctx, orderId)
 	if err != nil {
 		global.GVA_LOG.Error("更新订单状态失败!", zap.Error(err))
 		response.FailWithMessage("取消订单失败:"+err.Error(), c)
 		return
 	}
+	if !canceled {
+		response.FailWithMessage("订单已支付或已超时，无法取消", c)
+		return
+	}
 
-	// 删除Redis中的键和值
-	deletedCount, err := global.GVA_REDIS.Del(ctx, redisKey).Result()
-	if err != nil {
-		global.GVA_LOG.Error("删除Redis键失败!", zap.Error(err))
+	// 移出过期队列并释放金额占用，仅删除仍属于该订单的占用键
+	if err := payTask.CancelOrderExpire(ctx, *remerPayOrder.Id); err != nil {
+		global.GVA_LOG.Error("移出过期队列失败!", zap.Error(err))
+	}
+	if err := payTask.ReleaseOrderSlot(ctx, &remerPayOrder); err != nil {
+		global.GVA_LOG.Error("释放金额占用失败!", zap.Error(err))
 		// 即使Redis删除失败，也不影响订单取消的成功
-	} else {
-		global.GVA_LOG.Info("成功删除Redis键", zap.String("key", redisKey), zap.Int64("deletedCount", deletedCount))
 	}
 
 	response.OkWithDetailed(gin.H{
@@ -0,0 +1,115 @@
+package example
+
+import (
+	"strconv"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
+	payTask "github.com/flipped-aurora/gin-vue-admin/server/task"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type MerPaySuspenseApi struct{}
+
+// GetMerPaySuspenseList 分页获取挂账收款列表
+// @Tags MerPaySuspense
+// @Summary 分页获取挂账收款列表
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query exampleReq.MerPaySuspenseSearch true "分页获取挂账收款列表"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /merPaySuspense/getMerPaySuspenseList [get]
+func (merPaySuspenseApi *MerPaySuspenseApi) GetMerPaySuspenseList(c *gin.Context) {
+	// 创建业务用Context
+	ctx := c.Request.Context()
+
+	var pageInfo exampleReq.MerPaySuspenseSearch
+	err := c.ShouldBindQuery(&pageInfo)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := merPaySuspenseService.GetMerPaySuspenseInfoList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// MatchMerPaySuspense 将挂账收款人工匹配到失败订单
+// @Tags MerPaySuspense
+// @Summary 人工匹配挂账收款
+// @Description 将一笔挂账收款关联到已失败的订单，订单转为已支付并向商户发送支付成功回调，同时写入匹配记录
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body exampleReq.MatchSuspenseReq true "挂账收款ID、订单ID与匹配说明"
+// @Success 200 {object} response.Response{msg=string} "匹配成功"
+// @Router /merPaySuspense/matchMerPaySuspense [post]
+func (merPaySuspenseApi *MerPaySuspenseApi) MatchMerPaySuspense(c *gin.Context) {
+	// 创建业务用Context
+	ctx := c.Request.Context()
+
+	var req exampleReq.MatchSuspenseReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	operatorId := int64(utils.GetUserID(c))
+	payOrder, err := merPaySuspenseService.MatchSuspense(ctx, req, operatorId, c.ClientIP())
+	if err != nil {
+		global.GVA_LOG.Error("匹配挂账收款失败!",
+			zap.Int64("suspenseId", req.SuspenseId),
+			zap.Int64("orderId", req.OrderId),
+			zap.Error(err))
+		response.FailWithMessage("匹配失败:"+err.Error(), c)
+		return
+	}
+
+	global.GVA_LOG.Info("挂账收款已人工匹配订单",
+		zap.Int64("suspenseId", req.SuspenseId),
+		zap.Int64("orderId", req.OrderId),
+		zap.Int64("operatorId", operatorId))
+
+	payTask.NotifyOrderPaid(ctx, &payOrder, "")
+	response.OkWithMessage("匹配成功", c)
+}
+
+// GetMerPayMatchLogs 获取订单的人工匹配记录
+// @Tags MerPaySuspense
+// @Summary 获取订单的人工匹配记录
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param orderId query int true "订单ID"
+// @Success 200 {object} response.Response{data=[]example.MerPayMatchLog,msg=string} "获取成功"
+// @Router /merPaySuspense/getMerPayMatchLogs [get]
+func (merPaySuspenseApi *MerPaySuspenseApi) GetMerPayMatchLogs(c *gin.Context) {
+	// 创建业务用Context
+	ctx := c.Request.Context()
+
+	orderId, err := strconv.ParseInt(c.Query("orderId"), 10, 64)
+	if err != nil {
+		response.FailWithMessage("订单ID格式错误", c)
+		return
+	}
+	list, err := merPaySuspenseService.GetMerPayMatchLogs(ctx, orderId)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithData(list, c)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/shopspring/decimal"
-	"math"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -17,70 +16,30 @@ import (
 	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
 	exampleRes "github.com/flipped-aurora/gin-vue-admin/server/model/example/response"
 	payReq "github.com/flipped-aurora/gin-vue-admin/server/model/pay/request"
+	exampleService "github.com/flipped-aurora/gin-vue-admin/server/service/example"
 	payTask "github.com/flipped-aurora/gin-vue-admin/server/task"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/pay/sandbox"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 type MerUserApi struct{}
 
-// checkAmountRangeConflict 检查指定金额范围内是否存在冲突的 Redis 键
-// 输入金额如 5.00，检查 5.01-5.99 范围内是否有键存在
-// 返回 true 表示存在冲突，false 表示可以使用
-func (merUserApi *MerUserApi) checkAmountRangeConflict(ctx context.Context, userID uint, inputAmount int64) (bool, error) {
-	// 计算检查范围：输入金额的下一分到下一元的前一分
-	// 例如：输入 5.00，检查 5.01 到 5.99
-	baseAmount := float64(inputAmount)
-	startAmount := math.Floor(baseAmount*100+1) / 100 // 5.01
-	endAmount := math.Floor(baseAmount) + 0.99        // 5.99
-
-	// 构建 Redis 键的模式
-	keyPattern := fmt.Sprintf("%s:%d:*", global.PAY_AMOUNT_USED_KEY, userID)
-
-	// 使用 SCAN 命令遍历匹配的键
-	iter := global.GVA_REDIS.Scan(ctx, 0, keyPattern, 100).Iterator()
-
-	for iter.Next(ctx) {
-		key := iter.Val()
-
-		// 从键中提取金额部分
-		parts := strings.Split(key, ":")
-		if len(parts) < 3 {
-			continue
-		}
-
-		amountStr := parts[2]
-		amount, err := strconv.ParseFloat(amountStr, 64)
-		if err != nil {
-			continue
-		}
-
-		// 检查是否在冲突范围内
-		if amount >= startAmount && amount <= endAmount {
-			return true, nil // 发现冲突
-		}
-	}
-
-	if err := iter.Err(); err != nil {
-		return false, fmt.Errorf("Redis SCAN 操作失败: %w", err)
-	}
-
-	return false, nil // 无冲突
-}
-
 // findAvailableAmount 查找指定金额范围内未被占用的金额
-// inputAmount: 输入的基础金额（单位: 元）
-// minDecimalAmount, maxDecimalAmount: 小数部分范围（如 1-99 表示 0.01-0.99）
-// 例如：输入 500（5.00元），在 5.01-5.99 范围内查找未被占用的金额
-// 返回可用的金额，如果全部被占用则返回 0
-func (merUserApi *MerUserApi) findAvailableAmount(ctx context.Context, userID uint, inputAmount int64, maxDecimalAmount, minDecimalAmount int32) (decimal.Decimal, error) {
-	// 将输入金额转换为正确的浮点数格式
-	// inputAmount 单位: 元
-	baseAmount := decimal.NewFromInt(inputAmount)
+// baseAmount: 输入的基础金额（主货币单位），与尾数相加时统一换算为最小货币单位，避免浮点运算
+// minDecimalAmount, maxDecimalAmount: 尾数范围（最小货币单位，如 1-99 表示 0.01-0.99 元）
+// 例如：输入 5（5.00元），在 5.01-5.99 范围内查找未被占用的金额
+// blacklist: 金额黑名单，命中的金额不参与分配
+// 返回可用的金额，如果全部被占用或命中黑名单则返回 0，blocked 为首个命中的黑名单规则
+func (merUserApi *MerUserApi) findAvailableAmount(ctx context.Context, merUserId int64, baseAmount decimal.Decimal, currency string, maxDecimalAmount, minDecimalAmount int32, blacklist []utils.AmountPattern) (available decimal.Decimal, blocked *utils.AmountPattern, err error) {
+	baseMinor, err := utils.ToMinorUnits(baseAmount, currency)
+	if err != nil {
+		return decimal.Zero, nil, err
+	}
 
 	// 构建 Redis 键的模式
-	keyPattern := fmt.Sprintf("%s:%d:*", global.PAY_AMOUNT_USED_KEY, userID)
+	keyPattern := fmt.Sprintf("%s:%d:*", global.PAY_AMOUNT_USED_KEY, merUserId)
 
 	// 获取所有已占用的金额
 	occupiedAmounts := make(map[string]bool)
@@ -100,55 +59,63 @@ func (merUserApi *MerUserApi) findAvailableAmount(ctx context.Context, userID ui
 	}
 
 	if err := iter.Err(); err != nil {
-		return decimal.Zero, fmt.Errorf("Redis SCAN 操作失败: %w", err)
+		return decimal.Zero, nil, fmt.Errorf("Redis SCAN 操作失败: %w", err)
 	}
 
 	// 收集所有可用的金额
 	var availableAmounts []decimal.Decimal
 
-	// 在指定的小数范围内查找所有未被占用的金额
+	// 在指定的尾数范围内查找所有未被占用的金额
 	// 例如：baseAmount = 5.00, minDecimalAmount = 1, maxDecimalAmount = 99
 	// 会检查 5.01, 5.02, ..., 5.99
 	for cent := minDecimalAmount; cent <= maxDecimalAmount; cent++ {
-		candidateAmount := baseAmount.Add(
-			decimal.NewFromInt(int64(cent)).Div(decimal.NewFromInt(100)),
-		)
-		candidateAmountStr := candidateAmount.StringFixed(2)
+		candidateAmount, err := utils.FromMinorUnits(baseMinor+int64(cent), currency)
+		if err != nil {
+			return decimal.Zero, nil, err
+		}
 
-		if !occupiedAmounts[candidateAmountStr] {
-			availableAmounts = append(availableAmounts, candidateAmount)
+		if occupiedAmounts[utils.FormatAmount(candidateAmount, currency)] {
+			continue
+		}
+		// 顾客按分配后的金额付款，金额黑名单作用于分配后的金额
+		if pattern, ok := utils.MatchAmountPatterns(blacklist, candidateAmount, currency); ok {
+			if blocked == nil {
+				blocked = &pattern
+			}
+			continue
 		}
+		availableAmounts = append(availableAmounts, candidateAmount)
 	}
 
 	// 如果当前范围有可用金额，随机选择一个
 	if len(availableAmounts) > 0 {
 		randomIndex := rand.Intn(len(availableAmounts))
-		return availableAmounts[randomIndex], nil
+		return availableAmounts[randomIndex], nil, nil
 	}
 
-	return decimal.Zero, nil // 全部被占用
+	return decimal.Zero, blocked, nil // 全部被占用或命中金额黑名单
 }
 
-// checkAmountRangeConflictOptimized 优化版本：使用更精确的范围检查
-func (merUserApi *MerUserApi) checkAmountRangeConflictOptimized(ctx context.Context, userID uint, inputCents int64) (bool, error) {
-	// 将金额转换为分（避免浮点数精度问题）
-
-	// 计算检查范围（分为单位）
-	startCents := inputCents + 1
-	endCents := (inputCents/100)*100 + 99 // 同一元内的最大值
-
-	// 如果输入已经是 x.99，则检查下一元的范围
-	if inputCents%100 == 99 {
-		startCents = inputCents + 2 // 跳到下一元的 .01
-		endCents = startCents + 98  // 到下一元的 .99
+// checkAmountRangeConflict 检查同一主单位内（如 5.01-5.99）是否已有金额被占用
+// 金额统一换算为最小货币单位比较，避免浮点精度问题
+func (merUserApi *MerUserApi) checkAmountRangeConflict(ctx context.Context, merUserId int64, inputAmount decimal.Decimal, currency string) (bool, error) {
+	inputMinor, err := utils.ToMinorUnits(inputAmount, currency)
+	if err != nil {
+		return false, err
+	}
+	unit, err := utils.ToMinorUnits(decimal.NewFromInt(1), currency)
+	if err != nil {
+		return false, err
 	}
 
-	keyPattern := fmt.Sprintf("%s:%d:*", global.PAY_AMOUNT_USED_KEY, userID)
+	// 计算检查范围（最小货币单位）
+	startMinor := inputMinor + 1
+	endMinor := (inputMinor/unit)*unit + unit - 1 // 同一主单位内的最大值
+
+	keyPattern := fmt.Sprintf("%s:%d:*", global.PAY_AMOUNT_USED_KEY, merUserId)
 
 	// 使用批量检查，减少网络往返
 	var cursor uint64
-	var conflictFound bool
-
 	for {
 		keys, nextCursor, err := global.GVA_REDIS.Scan(ctx, cursor, keyPattern, 50).Result()
 		if err != nil {
@@ -161,28 +128,26 @@ func (merUserApi *MerUserApi) checkAmountRangeConflictOptimized(ctx context.Cont
 				continue
 			}
 
-			amountStr := parts[2]
-			amount, err := strconv.ParseFloat(amountStr, 64)
+			amount, err := decimal.NewFromString(parts[2])
+			if err != nil {
+				continue
+			}
+			amountMinor, err := utils.ToMinorUnits(amount, currency)
 			if err != nil {
 				continue
 			}
-
-			amountCents := int64(math.Round(amount * 100))
 
 			// 检查是否在冲突范围内
-			if amountCents >= startCents && amountCents <= endCents {
-				conflictFound = true
-				break
+			if amountMinor >= startMinor && amountMinor <= endMinor {
+				return true, nil
 			}
 		}
 
-		if conflictFound || nextCursor == 0 {
-			break
+		if nextCursor == 0 {
+			return false, nil
 		}
 		cursor = nextCursor
 	}
-
-	return conflictFound, nil
 }
 
 // CreateMerUser 创建merUser表
@@ -419,6 +384,11 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	reqParms.Currency = utils.NormalizeCurrency(reqParms.Currency)
+	if err := utils.ValidateAmount(reqParms.PayAmmount, reqParms.Currency); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
 	//get user config
 	sysUserConfig, err := sysUserConfigService.GetConfigBySysUserID(ctx, int64(userID))
 	if err != nil {
@@ -432,8 +402,23 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 		return
 	}
 
+	// 风控：下单频率，须在选取商户与占用金额之前执行
+	riskContext := exampleService.OrderRiskContext{
+		SysUserId: int64(userID),
+		ClientIp:  c.ClientIP(),
+		KeyId:     middleware.GetRequestKeyID(c),
+		Amount:    reqParms.PayAmmount,
+		Currency:  reqParms.Currency,
+	}
+	err = riskRuleService.CheckOrderRequest(ctx, sysUserConfig, riskContext)
+	if err != nil {
+		response.StdFail(c, err.Error())
+		return
+	}
+
 	//get mer data
-	merUserList, err := merUserService.GetNomalMerUser(ctx, reqParms, userID)
+	// 沙箱token只能使用沙箱商户，正式token只能使用正式商户
+	merUserList, err := merUserService.GetNomalMerUser(ctx, reqParms, userID, middleware.IsSandboxRequest(c))
 	if err != nil {
 		global.GVA_LOG.Error("获取普通商户用户失败!", zap.Error(err))
 		response.StdFail(c, "获取普通商户用户失败:"+err.Error())
@@ -461,17 +446,23 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 		return
 	}
 
+	// 风控：商户待支付订单数上限
+	if err := riskRuleService.CheckMerchantPending(ctx, sysUserConfig, int64(userID), *currentMerUser.Id); err != nil {
+		response.StdFail(c, err.Error())
+		return
+	}
+
 	if currentMerUser.MaxAmount == nil || currentMerUser.MinAmount == nil {
 		response.StdFail(c, "商户请求金额范围未配置")
 		return
 	}
-	if reqParms.PayAmmount > *currentMerUser.MaxAmount || reqParms.PayAmmount < *currentMerUser.MinAmount {
+	if reqParms.PayAmmount.GreaterThan(*currentMerUser.MaxAmount) || reqParms.PayAmmount.LessThan(*currentMerUser.MinAmount) {
 		response.StdFail(c, "金额不符合设置的范围")
 		return
 	}
 
 	//// 检查金额范围冲突：防止相近金额的重复支付
-	//hasConflict, err := merUserApi.checkAmountRangeConflictOptimized(ctx, userID, reqParms.PayAmmount)
+	//hasConflict, err := merUserApi.checkAmountRangeConflict(ctx, *currentMerUser.Id, reqParms.PayAmmount, reqParms.Currency)
 	//if err != nil {
 	//	global.GVA_LOG.Error("检查金额冲突失败!", zap.Error(err))
 	//	response.StdFail(c, "该金额已被占用，请稍后重试")
@@ -495,27 +486,35 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 		minDecimalAmount = 1 // 默认值
 	}
 
-	availableAmount, err := merUserApi.findAvailableAmount(ctx, userID, reqParms.PayAmmount, maxDecimalAmount, minDecimalAmount)
+	// 金额占用按商户维度记录，与监控任务扫描的键保持一致
+	// 风控：金额黑名单，分配金额时跳过命中的金额
+	blacklist := riskRuleService.AmountBlacklist(sysUserConfig, int64(userID))
+	availableAmount, blockedPattern, err := merUserApi.findAvailableAmount(ctx, *currentMerUser.Id, reqParms.PayAmmount, reqParms.Currency, maxDecimalAmount, minDecimalAmount, blacklist)
 	if err != nil {
 		global.GVA_LOG.Error("查找可用金额失败!", zap.Error(err))
 		response.StdFail(c, "系统繁忙，请稍后重试")
 		return
 	}
 
-	if availableAmount == decimal.Zero {
-		response.StdFail(c, fmt.Sprintf("金额 %.2f 及其相近金额范围内的所有金额都已被占用，请稍后重试或使用其他金额", float64(reqParms.PayAmmount)))
+	if availableAmount.IsZero() && blockedPattern != nil {
+		response.StdFail(c, riskRuleService.RejectBlockedAmount(ctx, riskContext, *blockedPattern).Error())
+		return
+	}
+	if availableAmount.IsZero() {
+		response.StdFail(c, fmt.Sprintf("金额 %s 及其相近金额范围内的所有金额都已被占用，请稍后重试或使用其他金额", utils.FormatAmount(reqParms.PayAmmount, reqParms.Currency)))
 		return
 	}
 
 	// 使用找到的可用金额
 	paymentQrCodeResponse.Amount = &availableAmount
+	paymentQrCodeResponse.Currency = &reqParms.Currency
 	global.GVA_LOG.Info(
 		"自动调整金额",
-		zap.Float64("原金额", float64(reqParms.PayAmmount)),
-		zap.String("调整后金额", availableAmount.String()),
+		zap.String("原金额", utils.FormatAmount(reqParms.PayAmmount, reqParms.Currency)),
+		zap.String("调整后金额", utils.FormatAmount(availableAmount, reqParms.Currency)),
+		zap.String("币种", reqParms.Currency),
 	)
 
-	currentMerUser = merUserList[rand.Intn(len(merUserList))]
 	currentTimeStr := utils.GetCurrentTimeStr()
 	paymentQrCodeResponse.QrcodeCode = currentMerUser.QrCode
 	paymentQrCodeResponse.CreateTime = &currentTimeStr
@@ -525,7 +524,15 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 
 	//payCreateTime := utils.GetCurrentTimeStr()
 
-	amount := decimal.NewFromInt(reqParms.PayAmmount)
+	var ttl time.Duration
+	if reqParms.Expires == 0 {
+		ttl = time.Duration(5) * time.Minute
+	} else {
+		ttl = time.Duration(reqParms.Expires) * time.Second
+	}
+	expires := int64(ttl / time.Second)
+
+	amount := reqParms.PayAmmount
 	payOrder := example.MerPayOrder{
 		MerId:          currentMerUser.Id,
 		MerName:        currentMerUser.MerName,
@@ -534,6 +541,9 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 		Ammount:        &amount,
 		RequestAmmount: paymentQrCodeResponse.Amount,
 		OrderId:        paymentQrCodeResponse.OrderId,
+		Expires:        &expires,
+		Currency:       &reqParms.Currency,
+		CallbackUrl:    &reqParms.CallbackUrl,
 	}
 	err = merPayOrderService.CreateMerPayOrder(ctx, &payOrder)
 	if err != nil {
@@ -546,21 +556,19 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 	global.GVA_LOG.Info("支付订单创建成功",
 		zap.Int64("订单ID", *payOrder.Id),
 		zap.String("订单号", *payOrder.OrderId))
+	// 未能占用金额时订单不会进入过期队列，直接标记为失败，避免遗留永远待支付的订单
+	failOrder := func() {
+		if _, err := merPayOrderService.ExpireMerPayOrder(ctx, *payOrder.Id); err != nil {
+			global.GVA_LOG.Error("标记支付订单失败出错!", zap.Int64("订单ID", *payOrder.Id), zap.Error(err))
+		}
+	}
 
 	// 将订单上下文写入 Redis，便于后续查询与校验
 	if paymentQrCodeResponse.OrderId != nil {
-		// 确保 Amount 不为空
-		amountStr := availableAmount.String()
+		// 金额按币种精度格式化，保证占用键与渠道对账使用同一表示
+		amountStr := utils.FormatAmount(availableAmount, reqParms.Currency)
 
-		var ttl time.Duration
-		if reqParms.Expires == 0 {
-			ttl = time.Duration(5) * time.Minute
-		} else {
-			ttl = time.Duration(reqParms.Expires) * time.Second
-		}
-		startTime, endTime := utils.GetTimeRange(ttl)
-
-		taskRedisKey := fmt.Sprintf("%s:%d:%s", global.PAY_SELECT_TASK_KEY, currentMerUser.Id)
+		taskRedisKey := fmt.Sprintf("%s:%d", global.PAY_SELECT_TASK_KEY, *currentMerUser.Id)
 		if ok, _ := global.GVA_REDIS.Exists(ctx, taskRedisKey).Result(); ok == 1 {
 			global.GVA_LOG.Warn("用户已在任务队列中", zap.Int64("currentMerUser.Id", *currentMerUser.Id))
 			//response.StdFail(c, fmt.Sprintf("用户 %d 已在处理中，请稍后重试", userID))
@@ -569,6 +577,7 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 			ok, err := global.GVA_REDIS.SetNX(ctx, taskRedisKey, 1, ttl).Result()
 			if err != nil {
 				global.GVA_LOG.Error("缓存订单上下文失败!", zap.Error(err))
+				failOrder()
 				response.StdFail(c, "系统繁忙，请稍后重试")
 				return
 			} else if ok {
@@ -578,16 +587,24 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 		}
 
 		// 构建 Redis 键：pay_amount_used:userID:amount
-		redisKey := fmt.Sprintf("%s:%d:%s", global.PAY_AMOUNT_USED_KEY, currentMerUser.Id, amountStr)
-		ok, err := global.GVA_REDIS.SetNX(ctx, redisKey, payOrder.Id, ttl).Result()
+		// 占用键在订单超时后继续保留一个宽限期，用于匹配迟到的支付
+		redisKey := fmt.Sprintf("%s:%d:%s", global.PAY_AMOUNT_USED_KEY, *currentMerUser.Id, amountStr)
+		ok, err := global.GVA_REDIS.SetNX(ctx, redisKey, payOrder.Id, ttl+payTask.LATE_PAYMENT_GRACE).Result()
 		if err != nil {
 			global.GVA_LOG.Error("缓存订单上下文失败!", zap.Error(err))
+			failOrder()
 			response.StdFail(c, "系统繁忙，请稍后重试")
 			return
 		} else if !ok {
+			failOrder()
 			response.StdFail(c, fmt.Sprintf("金额 %s 及其相近金额范围内的所有金额都已被占用，请稍后重试或使用其他金额", amountStr))
 			return
 		} else {
+			// 加入过期延迟队列，入队失败时由监控任务补偿
+			if err := payTask.ScheduleOrderExpire(ctx, *payOrder.Id, payOrder.ExpireAt()); err != nil {
+				global.GVA_LOG.Error("订单加入过期队列失败!", zap.Int64("订单ID", *payOrder.Id), zap.Error(err))
+			}
+
 			// Redis SetNX 成功，启动或获取 meruser 的全局监控任务
 			merUserTask := payTask.GlobalTaskManager.StartMerUserTask(
 				*currentMerUser.Id,
@@ -611,17 +628,19 @@ func (merUserApi *MerUserApi) GetPayQrCode(c *gin.Context) {
 // GeneratePermanentToken 生成永久token（每个用户只能有一个有效token，生成新token会删除旧token）
 // @Tags MerUser
 // @Summary 生成永久token（单一token机制）
-// @Description 为用户生成永久token，每个用户只能拥有一个有效的永久token。生成新token时会自动删除所有旧token。
+// @Description 为用户生成永久token，正式与沙箱模式下每个用户各只能拥有一个有效的永久token。生成新token时会自动删除同一模式下的旧token。
 // @Security ApiKeyAuth
 // @Accept application/json
 // @Produce application/json
+// @Param sandbox query bool false "是否生成沙箱token"
 // @Success 200 {object} response.Response{data=object,msg=string} "生成成功，旧token已删除"
 // @Router /merUser/generatePermanentToken [post]
 func (merUserApi *MerUserApi) GeneratePermanentToken(c *gin.Context) {
 	userID := utils.GetUserID(c)
+	sandboxMode, _ := strconv.ParseBool(c.Query("sandbox"))
 
-	// 生成永久token（会自动删除旧token）
-	token, err := utils.GeneratePermanentToken(userID)
+	// 生成永久token（会自动删除同一模式下的旧token）
+	token, err := utils.GeneratePermanentToken(userID, sandboxMode)
 	if err != nil {
 		global.GVA_LOG.Error("生成永久token失败!", zap.Error(err))
 		response.FailWithMessage("生成永久token失败:"+err.Error(), c)
@@ -631,6 +650,7 @@ func (merUserApi *MerUserApi) GeneratePermanentToken(c *gin.Context) {
 	response.OkWithDetailed(gin.H{
 		"token":      token,
 		"user_id":    userID,
+		"sandbox":    sandboxMode,
 		"created_at": time.Now().Unix(),
 		"notice":     "新token已生成，所有旧token已自动删除",
 	}, "生成成功，旧token已删除", c)
@@ -691,3 +711,47 @@ func (merUserApi *MerUserApi) RevokePermanentToken(c *gin.Context) {
 
 	response.OkWithMessage("删除成功", c)
 }
+
+// SandboxPay 沙箱模拟支付
+// @Tags MerUser
+// @Summary 向沙箱商户模拟一笔收款，触发订单监控的支付成功流程
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body exampleReq.SandboxPayReq true "沙箱商户ID与支付金额"
+// @Success 200 {object} response.Response{data=sandbox.Payment,msg=string} "模拟支付成功"
+// @Router /merUser/sandboxPay [post]
+func (merUserApi *MerUserApi) SandboxPay(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req exampleReq.SandboxPayReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+
+	merUser, err := merUserService.GetMerUser(ctx, strconv.FormatInt(req.MerId, 10))
+	if err != nil {
+		global.GVA_LOG.Error("查询商户失败!", zap.Error(err))
+		response.FailWithMessage("商户不存在", c)
+		return
+	}
+	// 只能对自己名下的商户发起模拟支付
+	if merUser.SysUserId == nil || *merUser.SysUserId != int64(utils.GetUserID(c)) {
+		response.FailWithMessage("无权操作该商户", c)
+		return
+	}
+	if !merUser.IsSandbox() {
+		response.FailWithMessage("仅沙箱商户支持模拟支付", c)
+		return
+	}
+
+	payment, err := sandbox.Default.PayFrom(c.Request.Context(), req.MerId, req.Amount, req.Payer, time.Now())
+	if err != nil {
+		global.GVA_LOG.Error("模拟支付失败!", zap.Error(err))
+		response.FailWithMessage("模拟支付失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(payment, "模拟支付成功", c)
+}
@@ -0,0 +1,44 @@
+package example
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RiskRuleHitApi struct{}
+
+// GetRiskRuleHitList 分页获取风控命中记录
+// @Tags RiskRuleHit
+// @Summary 分页获取风控命中记录
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data query exampleReq.RiskRuleHitSearch true "分页获取风控命中记录"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /riskRuleHit/getRiskRuleHitList [get]
+func (riskRuleHitApi *RiskRuleHitApi) GetRiskRuleHitList(c *gin.Context) {
+	// 创建业务用Context
+	ctx := c.Request.Context()
+
+	var pageInfo exampleReq.RiskRuleHitSearch
+	err := c.ShouldBindQuery(&pageInfo)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := riskRuleService.GetRiskRuleHitInfoList(ctx, pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
@@ -23,6 +23,7 @@ type ApiGroup struct {
 	AutoCodeTemplateApi
 	SysParamsApi
 	SysVersionApi
+	SysMigrationApi
 }
 
 var (
@@ -46,4 +47,13 @@ var (
 	autoCodeHistoryService  = service.ServiceGroupApp.SystemServiceGroup.AutoCodeHistory
 	autoCodeTemplateService = service.ServiceGroupApp.SystemServiceGroup.AutoCodeTemplate
 	sysVersionService       = service.ServiceGroupApp.SystemServiceGroup.SysVersionService
+	userTotpService         = service.ServiceGroupApp.SystemServiceGroup.UserTotpService
+	userSessionService      = service.ServiceGroupApp.SystemServiceGroup.UserSessionService
+	loginGuardService       = service.ServiceGroupApp.SystemServiceGroup.LoginGuardService
+	oidcService             = service.ServiceGroupApp.SystemServiceGroup.OidcService
+	dataRuleService         = service.ServiceGroupApp.SystemServiceGroup.DataRuleService
+	exportJobService        = service.ServiceGroupApp.SystemServiceGroup.ExportJobService
+	exportScheduleService   = service.ServiceGroupApp.SystemServiceGroup.ExportScheduleService
+	auditLogService         = service.ServiceGroupApp.SystemServiceGroup.AuditLogService
+	migrationService        = service.ServiceGroupApp.SystemServiceGroup.MigrationService
 )
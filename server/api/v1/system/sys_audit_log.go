@@ -0,0 +1,89 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetAuditLogList
+// @Tags      SysAuditLog
+// @Summary   分页获取审计记录
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     systemReq.SysAuditLogSearch                             true  "页码, 每页大小, 搜索条件"
+// @Success   200   {object}  response.Response{data=response.PageResult,msg=string}  "分页获取审计记录"
+// @Router    /sysAuditLog/getAuditLogList [get]
+func (s *OperationRecordApi) GetAuditLogList(c *gin.Context) {
+	var pageInfo systemReq.SysAuditLogSearch
+	err := c.ShouldBindQuery(&pageInfo)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := auditLogService.GetAuditLogList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// VerifyAuditLogs
+// @Tags      SysAuditLog
+// @Summary   校验审计链，返回缺失或被修改的记录以及链尾序号与哈希，传入外部保存的校验点时同时比对
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  query     systemReq.AuditCheckpoint                                          false  "外部保存的校验点"
+// @Success   200   {object}  response.Response{data=systemRes.AuditVerifyResult,msg=string}  "校验结果"
+// @Router    /sysAuditLog/verifyAuditLogs [get]
+func (s *OperationRecordApi) VerifyAuditLogs(c *gin.Context) {
+	var anchor systemReq.AuditCheckpoint
+	if err := c.ShouldBindQuery(&anchor); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	result, err := auditLogService.VerifyAuditLogs(&anchor)
+	if errors.Is(err, systemService.ErrAuditHashKey) {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err != nil {
+		global.GVA_LOG.Error("校验失败!", zap.Error(err))
+		response.FailWithMessage("校验失败", c)
+		return
+	}
+	if !result.Valid {
+		global.GVA_LOG.Warn("审计链校验未通过", zap.Int("problems", len(result.Problems)))
+	}
+	response.OkWithDetailed(result, "校验完成", c)
+}
+
+// CreateAuditCheckpoint
+// @Tags      SysAuditLog
+// @Summary   校验审计链后保存链尾校验点，返回的校验点需另行保存，校验时传入以发现链尾被删除
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=system.SysAuditCheckpoint,msg=string}  "创建成功"
+// @Router    /sysAuditLog/createAuditCheckpoint [post]
+func (s *OperationRecordApi) CreateAuditCheckpoint(c *gin.Context) {
+	checkpoint, err := auditLogService.CreateAuditCheckpoint()
+	if err != nil {
+		global.GVA_LOG.Error("创建校验点失败!", zap.Error(err))
+		response.FailWithMessage("创建校验点失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(checkpoint, "创建成功", c)
+}
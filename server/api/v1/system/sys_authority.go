@@ -4,6 +4,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
 	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 
@@ -150,6 +151,34 @@ func (a *AuthorityApi) UpdateAuthority(c *gin.Context) {
 	response.OkWithDetailed(systemRes.SysAuthorityResponse{Authority: authority}, "更新成功", c)
 }
 
+// SetRequire2FA
+// @Tags      Authority
+// @Summary   设置角色是否强制两步验证
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.SetRequire2FA        true  "角色ID, 是否强制"
+// @Success   200   {object}  response.Response{msg=string}  "设置角色是否强制两步验证"
+// @Router    /authority/setRequire2FA [post]
+func (a *AuthorityApi) SetRequire2FA(c *gin.Context) {
+	var req systemReq.SetRequire2FA
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.AuthorityId == 0 {
+		response.FailWithMessage("角色ID不能为空", c)
+		return
+	}
+	if err = authorityService.SetRequire2FA(req.AuthorityId, req.Require2FA); err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("设置成功", c)
+}
+
 // GetAuthorityList
 // @Tags      Authority
 // @Summary   分页获取角色列表
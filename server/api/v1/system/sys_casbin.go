@@ -67,3 +67,64 @@ func (cas *CasbinApi) GetPolicyPathByAuthorityId(c *gin.Context) {
 	paths := casbinService.GetPolicyPathByAuthorityId(casbin.AuthorityId)
 	response.OkWithDetailed(systemRes.PolicyPathResponse{Paths: paths}, "获取成功", c)
 }
+
+// SimulateCasbin
+// @Tags      Casbin
+// @Summary   模拟角色访问接口，说明放行或拒绝的原因
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.CasbinSimulate                                               true  "角色ID, 路径, 方法"
+// @Success   200   {object}  response.Response{data=systemRes.CasbinSimulateResponse,msg=string}  "模拟结果,包括命中策略、相近策略与可访问的角色"
+// @Router    /casbin/simulateCasbin [post]
+func (cas *CasbinApi) SimulateCasbin(c *gin.Context) {
+	var req request.CasbinSimulate
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	err = utils.Verify(req, utils.CasbinSimulateVerify)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	result, err := casbinService.SimulateCasbin(req.AuthorityId, req.Path, req.Method)
+	if err != nil {
+		global.GVA_LOG.Error("模拟失败!", zap.Error(err))
+		response.FailWithMessage("模拟失败", c)
+		return
+	}
+	response.OkWithDetailed(result, "获取成功", c)
+}
+
+// DiffCasbin
+// @Tags      Casbin
+// @Summary   预览更新角色api权限产生的变更
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.CasbinInReceive                                          true  "权限id, 权限模型列表"
+// @Success   200   {object}  response.Response{data=systemRes.CasbinDiffResponse,msg=string}  "新增、删除与不变的权限"
+// @Router    /casbin/diffCasbin [post]
+func (cas *CasbinApi) DiffCasbin(c *gin.Context) {
+	var cmr request.CasbinInReceive
+	err := c.ShouldBindJSON(&cmr)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	err = utils.Verify(cmr, utils.AuthorityIdVerify)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	adminAuthorityID := utils.GetUserAuthorityId(c)
+	diff, err := casbinService.DiffCasbin(adminAuthorityID, cmr.AuthorityId, cmr.CasbinInfos)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败，"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(diff, "获取成功", c)
+}
@@ -0,0 +1,82 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetDataScopeResources
+// @Tags      Authority
+// @Summary   获取可配置行级数据权限的资源及字段
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]systemService.DataScopeResource,msg=string}  "资源列表"
+// @Router    /authority/getDataScopeResources [get]
+func (a *AuthorityApi) GetDataScopeResources(c *gin.Context) {
+	response.OkWithDetailed(dataRuleService.GetResources(), "获取成功", c)
+}
+
+// GetDataRules
+// @Tags      Authority
+// @Summary   获取角色行级数据权限规则
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  query     request.GetAuthorityId                                 true  "角色ID"
+// @Success   200   {object}  response.Response{data=[]system.SysDataRule,msg=string}  "规则列表"
+// @Router    /authority/getDataRules [get]
+func (a *AuthorityApi) GetDataRules(c *gin.Context) {
+	var req request.GetAuthorityId
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	err = utils.Verify(req, utils.AuthorityIdVerify)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, err := dataRuleService.GetDataRules(req.AuthorityId)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// SetDataRules
+// @Tags      Authority
+// @Summary   设置角色行级数据权限规则，整体替换
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.SetDataRules         true  "角色ID, 规则列表"
+// @Success   200   {object}  response.Response{msg=string}  "设置角色行级数据权限规则"
+// @Router    /authority/setDataRules [post]
+func (a *AuthorityApi) SetDataRules(c *gin.Context) {
+	var req systemReq.SetDataRules
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	err = utils.Verify(req, utils.AuthorityIdVerify)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	adminAuthorityID := utils.GetUserAuthorityId(c)
+	err = dataRuleService.SetDataRules(adminAuthorityID, req.AuthorityId, req.Rules)
+	if err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败，"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("设置成功", c)
+}
@@ -1,6 +1,13 @@
 package system
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
@@ -8,6 +15,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// dictionaryImportMaxSize 字典导入文件大小上限
+const dictionaryImportMaxSize = 10 << 20
+
 type DictionaryApi struct{}
 
 // CreateSysDictionary
@@ -127,3 +137,81 @@ func (s *DictionaryApi) GetSysDictionaryList(c *gin.Context) {
 	}
 	response.OkWithDetailed(list, "获取成功", c)
 }
+
+// ExportSysDictionary
+// @Tags      SysDictionary
+// @Summary   导出字典及字典项，未指定ID时导出全部字典
+// @Security  ApiKeyAuth
+// @Produce   application/octet-stream
+// @Param     IDs[]   query     []uint  false  "字典ID"
+// @Param     format  query     string  false  "导出格式 json/yaml，默认json"
+// @Success   200     {file}    file    "字典文件"
+// @Router    /sysDictionary/exportSysDictionary [get]
+func (s *DictionaryApi) ExportSysDictionary(c *gin.Context) {
+	var ids []uint
+	for _, v := range c.QueryArray("IDs[]") {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			response.FailWithMessage("字典ID格式错误", c)
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+	format := c.DefaultQuery("format", "json")
+	data, err := dictionaryService.ExportSysDictionaries(ids, format)
+	if err != nil {
+		global.GVA_LOG.Error("导出失败!", zap.Error(err))
+		response.FailWithMessage("导出失败:"+err.Error(), c)
+		return
+	}
+	contentType := "application/json"
+	if format != "json" {
+		format = "yaml"
+		contentType = "application/x-yaml"
+	}
+	filename := fmt.Sprintf("dictionaries_%s.%s", time.Now().Format("20060102150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", strconv.Itoa(len(data)))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportSysDictionary
+// @Tags      SysDictionary
+// @Summary   导入字典及字典项，overwrite=true 时替换已存在字典的全部字典项
+// @Security  ApiKeyAuth
+// @accept    multipart/form-data
+// @Produce   application/json
+// @Param     file       formData  file    true   "字典文件(json/yaml)"
+// @Param     overwrite  query     bool    false  "覆盖已存在的字典"
+// @Success   200        {object}  response.Response{data=map[string]int,msg=string}  "导入字典"
+// @Router    /sysDictionary/importSysDictionary [post]
+func (s *DictionaryApi) ImportSysDictionary(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		global.GVA_LOG.Error("文件获取失败!", zap.Error(err))
+		response.FailWithMessage("文件获取失败", c)
+		return
+	}
+	if header.Size > dictionaryImportMaxSize {
+		response.FailWithMessage("文件不能超过10MB", c)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		response.FailWithMessage("文件读取失败", c)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, dictionaryImportMaxSize))
+	if err != nil {
+		response.FailWithMessage("文件读取失败", c)
+		return
+	}
+	count, err := dictionaryService.ImportSysDictionaries(data, filepath.Ext(header.Filename), c.Query("overwrite") == "true")
+	if err != nil {
+		global.GVA_LOG.Error("导入失败!", zap.Error(err))
+		response.FailWithMessage("导入失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(gin.H{"count": count}, "导入成功", c)
+}
@@ -1,6 +1,8 @@
 package system
 
 import (
+	"errors"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
@@ -8,6 +10,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type DictionaryDetailApi struct{}
@@ -31,7 +34,7 @@ func (s *DictionaryDetailApi) CreateSysDictionaryDetail(c *gin.Context) {
 	err = dictionaryDetailService.CreateSysDictionaryDetail(detail)
 	if err != nil {
 		global.GVA_LOG.Error("创建失败!", zap.Error(err))
-		response.FailWithMessage("创建失败", c)
+		response.FailWithMessage("创建失败:"+err.Error(), c)
 		return
 	}
 	response.OkWithMessage("创建成功", c)
@@ -56,7 +59,7 @@ func (s *DictionaryDetailApi) DeleteSysDictionaryDetail(c *gin.Context) {
 	err = dictionaryDetailService.DeleteSysDictionaryDetail(detail)
 	if err != nil {
 		global.GVA_LOG.Error("删除失败!", zap.Error(err))
-		response.FailWithMessage("删除失败", c)
+		response.FailWithMessage("删除失败:"+err.Error(), c)
 		return
 	}
 	response.OkWithMessage("删除成功", c)
@@ -81,7 +84,7 @@ func (s *DictionaryDetailApi) UpdateSysDictionaryDetail(c *gin.Context) {
 	err = dictionaryDetailService.UpdateSysDictionaryDetail(&detail)
 	if err != nil {
 		global.GVA_LOG.Error("更新失败!", zap.Error(err))
-		response.FailWithMessage("更新失败", c)
+		response.FailWithMessage("更新失败:"+err.Error(), c)
 		return
 	}
 	response.OkWithMessage("更新成功", c)
@@ -146,3 +149,63 @@ func (s *DictionaryDetailApi) GetSysDictionaryDetailList(c *gin.Context) {
 		PageSize: pageInfo.PageSize,
 	}, "获取成功", c)
 }
+
+// GetDictionaryTree
+// @Tags      SysDictionaryDetail
+// @Summary   按层级获取字典项，locale 为空时取 Accept-Language
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     type    query     string  true   "字典type"
+// @Param     locale  query     string  false  "语言，如 en-US"
+// @Success   200     {object}  response.Response{data=[]systemRes.DictionaryTreeNode,msg=string}  "字典项树"
+// @Router    /sysDictionaryDetail/getDictionaryTree [get]
+func (s *DictionaryDetailApi) GetDictionaryTree(c *gin.Context) {
+	t := c.Query("type")
+	if t == "" {
+		response.FailWithMessage("字典type不能为空", c)
+		return
+	}
+	locale := c.Query("locale")
+	if locale == "" {
+		locale = c.GetHeader("Accept-Language")
+	}
+	tree, err := dictionaryDetailService.GetDictionaryTree(t, locale)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.FailWithMessage("字典未创建或未开启", c)
+			return
+		}
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(tree, "获取成功", c)
+}
+
+// LookupDictionaries
+// @Tags      SysDictionaryDetail
+// @Summary   批量翻译字典值，values 中字典值列表为空时返回该字典全部字典项
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.DictionaryLookup  true  "语言与按字典type分组的字典值"
+// @Success   200   {object}  response.Response{data=map[string]map[string]string,msg=string}  "字典type -> 字典值 -> 展示值"
+// @Router    /sysDictionaryDetail/lookupDictionaries [post]
+func (s *DictionaryDetailApi) LookupDictionaries(c *gin.Context) {
+	var req request.DictionaryLookup
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.Locale == "" {
+		req.Locale = c.GetHeader("Accept-Language")
+	}
+	result, err := dictionaryDetailService.LookupDictionaries(req.Locale, req.Values)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(result, "获取成功", c)
+}
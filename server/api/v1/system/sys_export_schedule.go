@@ -0,0 +1,222 @@
+package system
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateExportSchedule 创建定时报表
+// @Tags SysExportTemplate
+// @Summary 为导出模板创建定时报表，按 cron 表达式生成并投递
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data body system.SysExportSchedule true "模板标识、cron表达式、导出参数、投递方式与收件人"
+// @Success 200 {object} response.Response{data=system.SysExportSchedule,msg=string} "创建成功"
+// @Router /sysExportTemplate/createExportSchedule [post]
+func (sysExportTemplateApi *SysExportTemplateApi) CreateExportSchedule(c *gin.Context) {
+	var schedule system.SysExportSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	schedule.ID = 0
+	schedule.CreatedBy = utils.GetUserID(c)
+	if err := exportScheduleService.CreateExportSchedule(&schedule); err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(schedule, "创建成功", c)
+}
+
+// UpdateExportSchedule 更新定时报表
+// @Tags SysExportTemplate
+// @Summary 更新定时报表
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data body system.SysExportSchedule true "定时报表"
+// @Success 200 {object} response.Response{msg=string} "更新成功"
+// @Router /sysExportTemplate/updateExportSchedule [put]
+func (sysExportTemplateApi *SysExportTemplateApi) UpdateExportSchedule(c *gin.Context) {
+	var schedule system.SysExportSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := exportScheduleService.UpdateExportSchedule(&schedule); err != nil {
+		global.GVA_LOG.Error("更新失败!", zap.Error(err))
+		response.FailWithMessage("更新失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// DeleteExportSchedule 删除定时报表
+// @Tags SysExportTemplate
+// @Summary 删除定时报表，执行记录保留
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data body request.GetById true "定时报表ID"
+// @Success 200 {object} response.Response{msg=string} "删除成功"
+// @Router /sysExportTemplate/deleteExportSchedule [delete]
+func (sysExportTemplateApi *SysExportTemplateApi) DeleteExportSchedule(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := exportScheduleService.DeleteExportSchedule(req.Uint()); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// GetExportScheduleList 分页获取定时报表
+// @Tags SysExportTemplate
+// @Summary 分页获取定时报表，可按模板筛选
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data query systemReq.SysExportScheduleSearch true "模板标识, 页码, 每页大小"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /sysExportTemplate/getExportScheduleList [get]
+func (sysExportTemplateApi *SysExportTemplateApi) GetExportScheduleList(c *gin.Context) {
+	var pageInfo systemReq.SysExportScheduleSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if pageInfo.Page <= 0 {
+		pageInfo.Page = 1
+	}
+	if pageInfo.PageSize <= 0 || pageInfo.PageSize > 100 {
+		pageInfo.PageSize = 10
+	}
+	list, total, err := exportScheduleService.GetExportScheduleList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// RunExportSchedule 立即执行定时报表
+// @Tags SysExportTemplate
+// @Summary 立即执行一次定时报表，结果可在执行记录中查看
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data body request.GetById true "定时报表ID"
+// @Success 200 {object} response.Response{data=system.SysExportScheduleRun,msg=string} "已开始执行"
+// @Router /sysExportTemplate/runExportSchedule [post]
+func (sysExportTemplateApi *SysExportTemplateApi) RunExportSchedule(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	run, err := exportScheduleService.RunExportSchedule(req.Uint())
+	if err != nil {
+		global.GVA_LOG.Error("执行失败!", zap.Error(err))
+		response.FailWithMessage("执行失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(run, "已开始执行", c)
+}
+
+// GetExportScheduleRunList 分页获取定时报表的执行记录
+// @Tags SysExportTemplate
+// @Summary 分页获取定时报表的执行记录
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data query systemReq.SysExportScheduleRunSearch true "定时报表ID, 页码, 每页大小"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /sysExportTemplate/getExportScheduleRunList [get]
+func (sysExportTemplateApi *SysExportTemplateApi) GetExportScheduleRunList(c *gin.Context) {
+	var pageInfo systemReq.SysExportScheduleRunSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if pageInfo.Page <= 0 {
+		pageInfo.Page = 1
+	}
+	if pageInfo.PageSize <= 0 || pageInfo.PageSize > 100 {
+		pageInfo.PageSize = 10
+	}
+	list, total, err := exportScheduleService.GetExportScheduleRunList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// DownloadExportScheduleRun 下载保存到对象存储的定时报表文件
+// @Tags SysExportTemplate
+// @Summary 下载保存到对象存储的定时报表文件
+// @Security ApiKeyAuth
+// @Produce application/octet-stream
+// @Param id query int true "执行记录ID"
+// @Router /sysExportTemplate/downloadExportScheduleRun [get]
+func (sysExportTemplateApi *SysExportTemplateApi) DownloadExportScheduleRun(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	run, err := exportScheduleService.GetExportScheduleRun(req.Uint())
+	if err != nil || run.FileKey == "" {
+		response.FailWithMessage("文件不存在", c)
+		return
+	}
+	sendExportScheduleFile(c, run)
+}
+
+// DownloadExportScheduleFile 通过通知邮件中的签名链接下载定时报表文件
+// @Tags SysExportTemplate
+// @Summary 通过签名链接下载定时报表文件
+// @Produce application/octet-stream
+// @Param token query string true "下载令牌"
+// @Router /sysExportTemplate/downloadExportScheduleFile [get]
+func (sysExportTemplateApi *SysExportTemplateApi) DownloadExportScheduleFile(c *gin.Context) {
+	run, err := exportScheduleService.ResolveDownload(c.Query("token"))
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	sendExportScheduleFile(c, run)
+}
+
+func sendExportScheduleFile(c *gin.Context, run system.SysExportScheduleRun) {
+	format := strings.TrimPrefix(filepath.Ext(run.FileName), ".")
+	sendPrivateFile(c, run.FileKey, run.FileName, systemService.ExportContentType(format), run.Size)
+}
@@ -1,6 +1,7 @@
 package system
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -12,8 +13,11 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/service"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/upload"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -45,6 +49,9 @@ func init() {
 	go cleanupExpiredTokens()
 }
 
+// importErrorPreview 导入结果中直接返回的错误条数，完整列表通过错误报告下载
+const importErrorPreview = 100
+
 type SysExportTemplateApi struct {
 }
 
@@ -75,7 +82,7 @@ func (sysExportTemplateApi *SysExportTemplateApi) CreateSysExportTemplate(c *gin
 	}
 	if err := sysExportTemplateService.CreateSysExportTemplate(&sysExportTemplate); err != nil {
 		global.GVA_LOG.Error("创建失败!", zap.Error(err))
-		response.FailWithMessage("创建失败", c)
+		response.FailWithMessage("创建失败:"+err.Error(), c)
 	} else {
 		response.OkWithMessage("创建成功", c)
 	}
@@ -154,7 +161,7 @@ func (sysExportTemplateApi *SysExportTemplateApi) UpdateSysExportTemplate(c *gin
 	}
 	if err := sysExportTemplateService.UpdateSysExportTemplate(sysExportTemplate); err != nil {
 		global.GVA_LOG.Error("更新失败!", zap.Error(err))
-		response.FailWithMessage("更新失败", c)
+		response.FailWithMessage("更新失败:"+err.Error(), c)
 	} else {
 		response.OkWithMessage("更新成功", c)
 	}
@@ -293,14 +300,27 @@ func (sysExportTemplateApi *SysExportTemplateApi) ExportExcelByToken(c *gin.Cont
 	delete(exportTokenExpiration, token)
 	tokenMutex.Unlock()
 
-	// 导出
-	if file, name, err := sysExportTemplateService.ExportExcel(templateID, queryParams); err != nil {
+	format, err := systemService.NormalizeExportFormat(queryParams.Get("format"))
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	query, err := sysExportTemplateService.PrepareExport(templateID, queryParams)
+	if err != nil {
 		global.GVA_LOG.Error("获取失败!", zap.Error(err))
 		response.FailWithMessage("获取失败", c)
-	} else {
-		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", name+utils.RandomString(6)+".xlsx"))
-		c.Header("success", "true")
-		c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", file.Bytes())
+		return
+	}
+
+	// 边查询边写出，响应头发出后出错只能中断连接
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", query.Name+utils.RandomString(6)+"."+format))
+	c.Header("Content-Type", systemService.ExportContentType(format))
+	c.Header("success", "true")
+	c.Status(http.StatusOK)
+	if _, err = query.Write(c.Writer, format, nil); err != nil {
+		global.GVA_LOG.Error("导出失败!", zap.Error(err))
+		_ = c.Error(err)
+		c.Abort()
 	}
 }
 
@@ -402,10 +422,14 @@ func (sysExportTemplateApi *SysExportTemplateApi) ExportTemplateByToken(c *gin.C
 
 // ImportExcel 导入表格
 // @Tags SysImportTemplate
-// @Summary 导入表格
+// @Summary 导入表格，逐行校验，被拒绝的行可通过错误报告下载；dryRun=true 时只校验不写入
 // @Security ApiKeyAuth
-// @accept application/json
+// @accept multipart/form-data
 // @Produce application/json
+// @Param templateID query string true "模板标识"
+// @Param dryRun query bool false "试运行"
+// @Param file formData file true "Excel文件"
+// @Success 200 {object} response.Response{data=systemRes.ImportResult,msg=string} "导入结果"
 // @Router /sysExportTemplate/importExcel [post]
 func (sysExportTemplateApi *SysExportTemplateApi) ImportExcel(c *gin.Context) {
 	templateID := c.Query("templateID")
@@ -419,10 +443,184 @@ func (sysExportTemplateApi *SysExportTemplateApi) ImportExcel(c *gin.Context) {
 		response.FailWithMessage("文件获取失败", c)
 		return
 	}
-	if err := sysExportTemplateService.ImportExcel(templateID, file); err != nil {
+	dryRun := c.Query("dryRun") == "true"
+	result, err := sysExportTemplateService.ImportExcel(templateID, file, dryRun)
+	if err != nil {
 		global.GVA_LOG.Error(err.Error(), zap.Error(err))
 		response.FailWithMessage(err.Error(), c)
-	} else {
-		response.OkWithMessage("导入成功", c)
+		return
+	}
+	if len(result.Errors) > 0 {
+		// 完整的错误列表通过一次性链接下载，响应中只返回前若干条
+		token := utils.RandomString(32)
+		tokenMutex.Lock()
+		exportTokenCache[token] = map[string]interface{}{"importErrors": result.Errors}
+		exportTokenExpiration[token] = time.Now().Add(30 * time.Minute)
+		tokenMutex.Unlock()
+		result.ErrorReportUrl = fmt.Sprintf("/sysExportTemplate/importErrorReportByToken?token=%s", token)
+		if len(result.Errors) > importErrorPreview {
+			result.Errors = result.Errors[:importErrorPreview]
+		}
+	}
+	msg := "导入完成"
+	if dryRun {
+		msg = "校验完成"
+	}
+	response.OkWithDetailed(result, msg, c)
+}
+
+// ImportErrorReportByToken 下载导入错误报告
+// @Tags SysImportTemplate
+// @Summary 下载导入错误报告
+// @Produce application/octet-stream
+// @Param token query string true "一次性token"
+// @Router /sysExportTemplate/importErrorReportByToken [get]
+func (sysExportTemplateApi *SysExportTemplateApi) ImportErrorReportByToken(c *gin.Context) {
+	token := c.Query("token")
+	tokenMutex.Lock()
+	exportParamsRaw, exists := exportTokenCache[token]
+	expiry := exportTokenExpiration[token]
+	delete(exportTokenCache, token)
+	delete(exportTokenExpiration, token)
+	tokenMutex.Unlock()
+
+	exportParams, _ := exportParamsRaw.(map[string]interface{})
+	importErrors, ok := exportParams["importErrors"].([]systemRes.ImportError)
+	if !exists || !ok || time.Now().After(expiry) {
+		response.FailWithMessage("token无效或已过期", c)
+		return
+	}
+	file, err := sysExportTemplateService.ImportErrorWorkbook(importErrors)
+	if err != nil {
+		global.GVA_LOG.Error("生成错误报告失败!", zap.Error(err))
+		response.FailWithMessage("生成错误报告失败", c)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=import-errors.xlsx")
+	c.Header("success", "true")
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", file.Bytes())
+}
+
+// CreateExportJob 创建异步导出任务
+// @Tags SysExportTemplate
+// @Summary 创建异步导出任务，完成后可在任务列表下载或通过邮件获取下载链接
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data body systemReq.CreateExportJob true "模板标识、导出参数与格式"
+// @Success 200 {object} response.Response{data=system.SysExportJob,msg=string} "创建成功"
+// @Router /sysExportTemplate/createExportJob [post]
+func (sysExportTemplateApi *SysExportTemplateApi) CreateExportJob(c *gin.Context) {
+	var req systemReq.CreateExportJob
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.TemplateID == "" {
+		response.FailWithMessage("模板ID不能为空", c)
+		return
+	}
+	job, err := exportJobService.CreateExportJob(utils.GetUserID(c), req.TemplateID, req.Params, req.Format)
+	if err != nil {
+		global.GVA_LOG.Error("创建导出任务失败!", zap.Error(err))
+		response.FailWithMessage("创建导出任务失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(job, "创建成功", c)
+}
+
+// GetExportJobList 分页获取当前用户的导出任务
+// @Tags SysExportTemplate
+// @Summary 分页获取当前用户的导出任务
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data query request.PageInfo true "页码, 每页大小"
+// @Success 200 {object} response.Response{data=response.PageResult,msg=string} "获取成功"
+// @Router /sysExportTemplate/getExportJobList [get]
+func (sysExportTemplateApi *SysExportTemplateApi) GetExportJobList(c *gin.Context) {
+	var pageInfo request.PageInfo
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if pageInfo.Page <= 0 {
+		pageInfo.Page = 1
+	}
+	if pageInfo.PageSize <= 0 || pageInfo.PageSize > 100 {
+		pageInfo.PageSize = 10
+	}
+	list, total, err := exportJobService.GetExportJobList(utils.GetUserID(c), pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	jobs := make([]systemRes.SysExportJobResponse, 0, len(list))
+	for i := range list {
+		jobs = append(jobs, systemRes.SysExportJobResponse{SysExportJob: list[i], DownloadUrl: exportJobService.DownloadUrl(&list[i])})
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     jobs,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// FindExportJob 获取导出任务进度
+// @Tags SysExportTemplate
+// @Summary 获取导出任务进度，完成后返回有时效的下载地址
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param id query int true "任务ID"
+// @Success 200 {object} response.Response{data=systemRes.SysExportJobResponse,msg=string} "获取成功"
+// @Router /sysExportTemplate/findExportJob [get]
+func (sysExportTemplateApi *SysExportTemplateApi) FindExportJob(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	job, err := exportJobService.GetExportJob(utils.GetUserID(c), req.Uint())
+	if err != nil {
+		response.FailWithMessage("导出任务不存在", c)
+		return
+	}
+	response.OkWithData(systemRes.SysExportJobResponse{SysExportJob: job, DownloadUrl: exportJobService.DownloadUrl(&job)}, c)
+}
+
+// DownloadExportJob 通过签名链接下载异步导出文件
+// @Tags SysExportTemplate
+// @Summary 通过签名链接下载异步导出文件，链接过期后文件会被删除
+// @Produce application/octet-stream
+// @Param token query string true "下载令牌"
+// @Router /sysExportTemplate/downloadExportJob [get]
+func (sysExportTemplateApi *SysExportTemplateApi) DownloadExportJob(c *gin.Context) {
+	job, err := exportJobService.ResolveDownload(c.Query("token"))
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	sendPrivateFile(c, job.FileKey, systemService.ExportFileName(&job), systemService.ExportContentType(job.Format), job.Size)
+}
+
+// sendPrivateFile 从对象存储读取私有文件并作为附件返回，文件可以由任一实例生成
+func sendPrivateFile(c *gin.Context, key, name, contentType string, size int64) {
+	body, err := upload.OpenPrivate(key)
+	if err != nil {
+		if !errors.Is(err, upload.ErrPrivateFileNotFound) {
+			global.GVA_LOG.Error("读取私有文件失败!", zap.String("key", key), zap.Error(err))
+		}
+		response.FailWithMessage(upload.ErrPrivateFileNotFound.Error(), c)
+		return
+	}
+	defer body.Close()
+	if size <= 0 {
+		size = -1
 	}
+	c.DataFromReader(http.StatusOK, size, contentType, body, map[string]string{
+		"Content-Disposition": "attachment; filename*=UTF-8''" + url.PathEscape(name),
+	})
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -21,6 +22,12 @@ type JwtApi struct{}
 // @Router    /jwt/jsonInBlacklist [post]
 func (j *JwtApi) JsonInBlacklist(c *gin.Context) {
 	token := utils.GetToken(c)
+	// 退出登录同时吊销会话，刷新令牌随之失效
+	if claims := utils.GetUserInfo(c); claims != nil && claims.BaseClaims.SessionId != "" {
+		if err := userSessionService.RevokeSession(claims.BaseClaims.SessionId, systemService.SessionRevokeLogout); err != nil {
+			global.GVA_LOG.Error("吊销会话失败!", zap.Error(err))
+		}
+	}
 	jwt := system.JwtBlacklist{Jwt: token}
 	err := jwtService.JsonInBlacklist(jwt)
 	if err != nil {
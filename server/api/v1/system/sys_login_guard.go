@@ -0,0 +1,77 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UnlockLogin
+// @Tags      SysUser
+// @Summary   管理员解除账号或IP的登录锁定
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.UnlockLogin          true  "用户ID或IP"
+// @Success   200   {object}  response.Response{msg=string}  "解锁成功"
+// @Router    /user/unlockLogin [post]
+func (b *BaseApi) UnlockLogin(c *gin.Context) {
+	var req systemReq.UnlockLogin
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.ID == 0 && req.Ip == "" {
+		response.FailWithMessage("用户ID与IP不能同时为空", c)
+		return
+	}
+	ctx := c.Request.Context()
+	if req.ID != 0 {
+		user, err := userService.FindUserById(int(req.ID))
+		if err != nil {
+			global.GVA_LOG.Error("解锁失败!", zap.Error(err))
+			response.FailWithMessage("用户不存在", c)
+			return
+		}
+		loginGuardService.Unlock(ctx, user.Username)
+	}
+	if req.Ip != "" {
+		loginGuardService.UnlockIp(ctx, req.Ip)
+	}
+	global.GVA_LOG.Warn("管理员解除登录锁定", zap.Uint("operator", utils.GetUserID(c)), zap.Uint("userId", req.ID), zap.String("ip", req.Ip))
+	response.OkWithMessage("解锁成功", c)
+}
+
+// GetLoginLogList
+// @Tags      SysUser
+// @Summary   分页获取登录记录
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.SysLoginLogSearch                             true  "用户ID, 用户名, IP, 是否成功, 页码, 每页大小"
+// @Success   200   {object}  response.Response{data=response.PageResult,msg=string}  "分页获取登录记录"
+// @Router    /user/getLoginLogList [post]
+func (b *BaseApi) GetLoginLogList(c *gin.Context) {
+	var pageInfo systemReq.SysLoginLogSearch
+	if err := c.ShouldBindJSON(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(pageInfo.PageInfo, utils.PageInfoVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := loginGuardService.GetLoginLogList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
@@ -0,0 +1,98 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SysMigrationApi struct{}
+
+// GetMigrationStatus 获取数据库迁移状态
+// @Tags      SysMigration
+// @Summary   获取全部迁移的执行状态
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]systemRes.MigrationStatus,msg=string}  "获取成功"
+// @Router    /sysMigration/getMigrationStatus [get]
+func (s *SysMigrationApi) GetMigrationStatus(c *gin.Context) {
+	list, err := migrationService.MigrationStatus(c.Request.Context())
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// Migrate 执行数据库迁移
+// @Tags      SysMigration
+// @Summary   执行待执行的迁移，可指定执行到的版本
+// @Security  ApiKeyAuth
+// @Accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.MigrateRequest                     true  "目标版本，为空时执行全部"
+// @Success   200   {object}  response.Response{data=[]string,msg=string}  "返回本次执行的迁移版本"
+// @Router    /sysMigration/migrate [post]
+func (s *SysMigrationApi) Migrate(c *gin.Context) {
+	var req systemReq.MigrateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	applied, err := migrationService.Migrate(c.Request.Context(), req.Target)
+	freshErr := freshCasbinAfterMigration(applied)
+	if err != nil {
+		global.GVA_LOG.Error("迁移失败!", zap.Strings("applied", applied), zap.Error(err))
+		response.FailWithDetailed(applied, "迁移失败:"+err.Error(), c)
+		return
+	}
+	if freshErr != nil {
+		response.FailWithDetailed(applied, "迁移成功，权限刷新失败:"+freshErr.Error(), c)
+		return
+	}
+	response.OkWithDetailed(applied, "迁移成功", c)
+}
+
+// RollbackMigration 回滚数据库迁移
+// @Tags      SysMigration
+// @Summary   按执行顺序倒序回滚迁移
+// @Security  ApiKeyAuth
+// @Accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.RollbackMigrationRequest           true  "回滚个数或目标版本"
+// @Success   200   {object}  response.Response{data=[]string,msg=string}  "返回本次回滚的迁移版本"
+// @Router    /sysMigration/rollbackMigration [post]
+func (s *SysMigrationApi) RollbackMigration(c *gin.Context) {
+	var req systemReq.RollbackMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	rolledBack, err := migrationService.RollbackMigrations(c.Request.Context(), req.Steps, req.Target)
+	freshErr := freshCasbinAfterMigration(rolledBack)
+	if err != nil {
+		global.GVA_LOG.Error("回滚失败!", zap.Strings("rolledBack", rolledBack), zap.Error(err))
+		response.FailWithDetailed(rolledBack, "回滚失败:"+err.Error(), c)
+		return
+	}
+	if freshErr != nil {
+		response.FailWithDetailed(rolledBack, "回滚成功，权限刷新失败:"+freshErr.Error(), c)
+		return
+	}
+	response.OkWithDetailed(rolledBack, "回滚成功", c)
+}
+
+// freshCasbinAfterMigration 迁移会直接写入或删除 casbin 规则，有迁移执行或回滚时重新加载权限
+func freshCasbinAfterMigration(versions []string) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	err := casbinService.FreshCasbin()
+	if err != nil {
+		global.GVA_LOG.Error("迁移后刷新权限失败!", zap.Error(err))
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OidcAuthorize
+// @Tags     Base
+// @Summary  获取单点登录跳转地址
+// @Produce   application/json
+// @Success  200  {object}  response.Response{data=systemRes.OidcAuthorizeResponse,msg=string}  "身份提供方授权地址"
+// @Router   /base/oidcAuthorize [get]
+func (b *BaseApi) OidcAuthorize(c *gin.Context) {
+	url, err := oidcService.AuthorizeURL(c.Request.Context(), systemService.OidcPurposeLogin, 0)
+	if err != nil {
+		b.oidcFail(c, "获取单点登录地址失败", err)
+		return
+	}
+	response.OkWithDetailed(systemRes.OidcAuthorizeResponse{Url: url}, "获取成功", c)
+}
+
+// OidcCallback
+// @Tags     Base
+// @Summary  单点登录回调，使用授权码完成登录
+// @Produce   application/json
+// @Param    data  body      systemReq.OidcCallback                                      true  "授权码, state"
+// @Success  200   {object}  response.Response{data=systemRes.LoginResponse,msg=string}  "返回包括用户信息,token,过期时间"
+// @Router   /base/oidcCallback [post]
+func (b *BaseApi) OidcCallback(c *gin.Context) {
+	var req systemReq.OidcCallback
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	user, err := oidcService.Callback(c.Request.Context(), req.Code, req.State)
+	if err != nil {
+		b.oidcFail(c, "单点登录失败", err)
+		return
+	}
+	if user.Enable != 1 {
+		loginGuardService.RecordLogin(user.Username, c.ClientIP(), c.Request.UserAgent(), false, systemService.LoginReasonDisabled)
+		response.FailWithMessage("用户被禁止登录", c)
+		return
+	}
+	b.completeLogin(c, user)
+}
+
+// OidcBind
+// @Tags      SysUser
+// @Summary   获取绑定外部账号的跳转地址，授权完成后登录状态下调用 /user/oidcBindCallback 完成绑定
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=systemRes.OidcAuthorizeResponse,msg=string}  "身份提供方授权地址"
+// @Router    /user/oidcBind [post]
+func (b *BaseApi) OidcBind(c *gin.Context) {
+	url, err := oidcService.AuthorizeURL(c.Request.Context(), systemService.OidcPurposeBind, utils.GetUserID(c))
+	if err != nil {
+		b.oidcFail(c, "获取绑定地址失败", err)
+		return
+	}
+	response.OkWithDetailed(systemRes.OidcAuthorizeResponse{Url: url}, "获取成功", c)
+}
+
+// OidcBindCallback
+// @Tags      SysUser
+// @Summary   绑定外部账号的回调，仅发起绑定的用户本人可以完成
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.OidcCallback         true  "授权码, state"
+// @Success   200   {object}  response.Response{msg=string}  "绑定外部账号"
+// @Router    /user/oidcBindCallback [post]
+func (b *BaseApi) OidcBindCallback(c *gin.Context) {
+	var req systemReq.OidcCallback
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := oidcService.BindCallback(c.Request.Context(), utils.GetUserID(c), req.Code, req.State); err != nil {
+		b.oidcFail(c, "绑定失败", err)
+		return
+	}
+	response.OkWithMessage("绑定成功", c)
+}
+
+// GetOidcBindings
+// @Tags      SysUser
+// @Summary   获取自身绑定的外部账号
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]system.SysUserOidc,msg=string}  "绑定列表"
+// @Router    /user/getOidcBindings [get]
+func (b *BaseApi) GetOidcBindings(c *gin.Context) {
+	list, err := oidcService.GetBindings(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// OidcUnbind
+// @Tags      SysUser
+// @Summary   解除外部账号绑定
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "绑定ID"
+// @Success   200   {object}  response.Response{msg=string}  "解除绑定"
+// @Router    /user/oidcUnbind [post]
+func (b *BaseApi) OidcUnbind(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := oidcService.Unbind(utils.GetUserID(c), req.Uint()); err != nil {
+		response.FailWithMessage("解除绑定失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("解除绑定成功", c)
+}
+
+// oidcFail 业务错误直接提示，身份提供方通信等其他错误记录日志后返回通用提示
+func (b *BaseApi) oidcFail(c *gin.Context, msg string, err error) {
+	for _, known := range []error{
+		systemService.ErrOidcDisabled,
+		systemService.ErrOidcStateInvalid,
+		systemService.ErrOidcUserNotLinked,
+		systemService.ErrOidcNoAuthority,
+		systemService.ErrOidcAlreadyLinked,
+	} {
+		if errors.Is(err, known) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+	}
+	global.GVA_LOG.Error(msg+"!", zap.Error(err))
+	response.FailWithMessage(msg, c)
+}
@@ -122,3 +122,54 @@ func (s *OperationRecordApi) GetSysOperationRecordList(c *gin.Context) {
 		PageSize: pageInfo.PageSize,
 	}, "获取成功", c)
 }
+
+// GetOperationArchiveList
+// @Tags      SysOperationRecord
+// @Summary   分页获取操作记录归档文件
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     request.PageInfo                                        true  "页码, 每页大小"
+// @Success   200   {object}  response.Response{data=response.PageResult,msg=string}  "分页获取操作记录归档文件"
+// @Router    /sysOperationRecord/getOperationArchiveList [get]
+func (s *OperationRecordApi) GetOperationArchiveList(c *gin.Context) {
+	var pageInfo request.PageInfo
+	err := c.ShouldBindQuery(&pageInfo)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := operationRecordService.GetOperationArchiveList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// DownloadOperationArchive
+// @Tags      SysOperationRecord
+// @Summary   下载操作记录归档文件
+// @Security  ApiKeyAuth
+// @Produce   application/octet-stream
+// @Param     id  query  int  true  "归档文件ID"
+// @Router    /sysOperationRecord/downloadOperationArchive [get]
+func (s *OperationRecordApi) DownloadOperationArchive(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	archive, err := operationRecordService.GetOperationArchive(req.Uint())
+	if err != nil {
+		response.FailWithMessage("文件不存在", c)
+		return
+	}
+	sendPrivateFile(c, archive.FileKey, archive.Name, "application/gzip", archive.Size)
+}
@@ -2,6 +2,7 @@ package system
 
 import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
@@ -120,6 +121,56 @@ func (sysParamsApi *SysParamsApi) FindSysParams(c *gin.Context) {
 	response.OkWithData(resysParams, c)
 }
 
+// GetSysParamsHistory 获取参数变更历史
+// @Tags SysParams
+// @Summary 获取参数变更历史
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param ID query uint true "参数ID"
+// @Success 200 {object} response.Response{data=[]system.SysParamsHistory,msg=string} "获取成功"
+// @Router /sysParams/getSysParamsHistory [get]
+func (sysParamsApi *SysParamsApi) GetSysParamsHistory(c *gin.Context) {
+	var req request.GetById
+	err := c.ShouldBindQuery(&req)
+	if err != nil || req.ID == 0 {
+		response.FailWithMessage("参数ID不能为空", c)
+		return
+	}
+	list, err := sysParamsService.GetSysParamsHistory(req.Uint())
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// RollbackSysParams 回滚参数到指定版本
+// @Tags SysParams
+// @Summary 回滚参数到指定版本
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data body systemReq.SysParamsRollback true "参数ID, 目标版本"
+// @Success 200 {object} response.Response{msg=string} "回滚成功"
+// @Router /sysParams/rollbackSysParams [post]
+func (sysParamsApi *SysParamsApi) RollbackSysParams(c *gin.Context) {
+	var req systemReq.SysParamsRollback
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	err = sysParamsService.RollbackSysParams(req.ID, req.Version)
+	if err != nil {
+		global.GVA_LOG.Error("回滚失败!", zap.Error(err))
+		response.FailWithMessage("回滚失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("回滚成功", c)
+}
+
 // GetSysParamsList 分页获取参数列表
 // @Tags SysParams
 // @Summary 分页获取参数列表
@@ -2,6 +2,7 @@ package system
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
 	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/service/example"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -40,28 +42,37 @@ func (b *BaseApi) Login(c *gin.Context) {
 	}
 
 	key := c.ClientIP()
-	// 判断验证码是否开启
-	openCaptcha := global.GVA_CONFIG.Captcha.OpenCaptcha               // 是否开启防爆次数
-	openCaptchaTimeOut := global.GVA_CONFIG.Captcha.OpenCaptchaTimeOut // 缓存超时时间
-	v, ok := global.BlackCache.Get(key)
-	if !ok {
-		global.BlackCache.Set(key, 1, time.Second*time.Duration(openCaptchaTimeOut))
+	ctx := c.Request.Context()
+	// 账号或IP处于锁定期时直接拒绝，不消耗验证码也不校验密码
+	if err := loginGuardService.CheckLocked(ctx, l.Username, key); err != nil {
+		loginGuardService.RecordLogin(l.Username, key, c.Request.UserAgent(), false, systemService.LoginReasonLocked)
+		response.FailWithMessage(err.Error(), c)
+		return
 	}
-
-	var oc bool = openCaptcha == 0 || openCaptcha < interfaceToInt(v)
-	if oc && (l.Captcha == "" || l.CaptchaId == "" || !store.Verify(l.CaptchaId, l.Captcha, true)) {
-		// 验证码次数+1
-		global.BlackCache.Increment(key, 1)
+	if !verifyLoginCaptcha(key, l.Captcha, l.CaptchaId) {
+		loginGuardService.RecordLogin(l.Username, key, c.Request.UserAgent(), false, systemService.LoginReasonCaptcha)
 		response.FailWithMessage("验证码错误", c)
 		return
 	}
 
 	u := &system.SysUser{Username: l.Username, Password: l.Password}
 	user, err := userService.Login(u)
+	if err != nil && isLdapServiceError(err) {
+		// 目录不可用、账号未开通或未分配角色不是密码错误，不计入失败次数
+		global.GVA_LOG.Error("登陆失败! 目录服务登录失败!", zap.Error(err))
+		loginGuardService.RecordLogin(l.Username, key, c.Request.UserAgent(), false, systemService.LoginReasonLdap)
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
 	if err != nil {
 		global.GVA_LOG.Error("登陆失败! 用户名不存在或者密码错误!", zap.Error(err))
 		// 验证码次数+1
 		global.BlackCache.Increment(key, 1)
+		loginGuardService.RecordLogin(l.Username, key, c.Request.UserAgent(), false, systemService.LoginReasonPassword)
+		if locked := loginGuardService.RecordFailure(ctx, l.Username, key); locked > 0 {
+			response.FailWithMessage((&systemService.LoginLockedError{Remaining: locked}).Error(), c)
+			return
+		}
 		response.FailWithMessage("用户名不存在或者密码错误", c)
 		return
 	}
@@ -69,63 +80,224 @@ func (b *BaseApi) Login(c *gin.Context) {
 		global.GVA_LOG.Error("登陆失败! 用户被禁止登录!")
 		// 验证码次数+1
 		global.BlackCache.Increment(key, 1)
+		loginGuardService.RecordLogin(user.Username, key, c.Request.UserAgent(), false, systemService.LoginReasonDisabled)
 		response.FailWithMessage("用户被禁止登录", c)
 		return
 	}
+	// 管理员重置后首次登录或密码已过期：不签发token，要求先修改密码
+	if userService.PasswordChangeRequired(user) {
+		response.OkWithDetailed(systemRes.LoginPasswordResponse{
+			NeedChangePassword: true,
+			Expired:            !user.MustChangePassword,
+		}, "请修改密码后重新登录", c)
+		return
+	}
+	b.completeLogin(c, user)
+}
+
+// verifyLoginCaptcha 按IP的失败次数判断是否需要验证码，需要时校验验证码，校验失败时验证码次数+1
+func verifyLoginCaptcha(key, captcha, captchaId string) bool {
+	openCaptcha := global.GVA_CONFIG.Captcha.OpenCaptcha               // 是否开启防爆次数
+	openCaptchaTimeOut := global.GVA_CONFIG.Captcha.OpenCaptchaTimeOut // 缓存超时时间
+	v, ok := global.BlackCache.Get(key)
+	if !ok {
+		global.BlackCache.Set(key, 1, time.Second*time.Duration(openCaptchaTimeOut))
+	}
+
+	var oc bool = openCaptcha == 0 || openCaptcha < interfaceToInt(v)
+	if oc && (captcha == "" || captchaId == "" || !store.Verify(captchaId, captcha, true)) {
+		global.BlackCache.Increment(key, 1)
+		return false
+	}
+	return true
+}
+
+// isLdapServiceError 目录服务相关且需要提示给用户的错误
+func isLdapServiceError(err error) bool {
+	return errors.Is(err, systemService.ErrLdapUnavailable) ||
+		errors.Is(err, systemService.ErrLdapNotProvisioned) ||
+		errors.Is(err, systemService.ErrLdapNoAuthority)
+}
+
+// completeLogin 密码校验通过后的登录流程：需要两步验证时返回登录凭证，否则直接签发token
+func (b *BaseApi) completeLogin(c *gin.Context, user *system.SysUser) {
+	ctx := c.Request.Context()
+	// 已绑定两步验证或角色强制要求时，先返回登录凭证，通过第二步校验后再签发token
+	enabled, err := userTotpService.IsEnabled(user.ID)
+	if err != nil {
+		global.GVA_LOG.Error("查询两步验证状态失败!", zap.Error(err))
+		response.FailWithMessage("登录失败", c)
+		return
+	}
+	if required := userTotpService.IsRequired(user); enabled || required {
+		totpToken, err := userTotpService.CreateLoginChallenge(ctx, user.ID)
+		if err != nil {
+			global.GVA_LOG.Error("创建两步验证登录凭证失败!", zap.Error(err))
+			response.FailWithMessage("登录失败", c)
+			return
+		}
+		response.OkWithDetailed(systemRes.LoginTotpResponse{
+			NeedTotp:   true,
+			NeedEnroll: !enabled,
+			TotpToken:  totpToken,
+		}, "请输入两步验证码", c)
+		return
+	}
 	b.TokenNext(c, *user)
 }
 
+// ChangeExpiredPassword
+// @Tags     Base
+// @Summary  登录时修改已过期或被管理员重置的密码，修改成功后继续登录
+// @Produce   application/json
+// @Param    data  body      systemReq.ChangeExpiredPassword                             true  "用户名, 当前密码, 新密码, 验证码"
+// @Success  200   {object}  response.Response{data=systemRes.LoginResponse,msg=string}  "返回包括用户信息,token,过期时间"
+// @Router   /base/changeExpiredPassword [post]
+func (b *BaseApi) ChangeExpiredPassword(c *gin.Context) {
+	var req systemReq.ChangeExpiredPassword
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.ChangeExpiredPasswordVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	ip, ctx := c.ClientIP(), c.Request.Context()
+	// 与登录相同的锁定与验证码校验，避免通过此接口绕过防爆破
+	if err := loginGuardService.CheckLocked(ctx, req.Username, ip); err != nil {
+		loginGuardService.RecordLogin(req.Username, ip, c.Request.UserAgent(), false, systemService.LoginReasonLocked)
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if !verifyLoginCaptcha(ip, req.Captcha, req.CaptchaId) {
+		loginGuardService.RecordLogin(req.Username, ip, c.Request.UserAgent(), false, systemService.LoginReasonCaptcha)
+		response.FailWithMessage("验证码错误", c)
+		return
+	}
+	user, err := userService.Login(&system.SysUser{Username: req.Username, Password: req.Password})
+	if err != nil {
+		// 验证码次数+1
+		global.BlackCache.Increment(ip, 1)
+		loginGuardService.RecordLogin(req.Username, ip, c.Request.UserAgent(), false, systemService.LoginReasonPassword)
+		if locked := loginGuardService.RecordFailure(ctx, req.Username, ip); locked > 0 {
+			response.FailWithMessage((&systemService.LoginLockedError{Remaining: locked}).Error(), c)
+			return
+		}
+		response.FailWithMessage("用户名不存在或者密码错误", c)
+		return
+	}
+	if user.Enable != 1 {
+		// 验证码次数+1
+		global.BlackCache.Increment(ip, 1)
+		loginGuardService.RecordLogin(user.Username, ip, c.Request.UserAgent(), false, systemService.LoginReasonDisabled)
+		response.FailWithMessage("用户被禁止登录", c)
+		return
+	}
+	// 仅允许密码已过期或被重置的账号使用，其余账号须走完整的登录流程（包括两步验证）
+	if !userService.PasswordChangeRequired(user) {
+		response.FailWithMessage("当前密码无需修改，请直接登录", c)
+		return
+	}
+	err = userService.ChangePassword(&system.SysUser{GVA_MODEL: global.GVA_MODEL{ID: user.ID}, Password: req.Password}, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, utils.ErrPasswordPolicy) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		global.GVA_LOG.Error("修改密码失败!", zap.Error(err))
+		response.FailWithMessage("修改密码失败", c)
+		return
+	}
+	user.MustChangePassword = false
+	b.completeLogin(c, user)
+}
+
 // TokenNext 登录以后签发jwt
+// 每次登录创建一个会话，返回短期访问令牌与可轮换的刷新令牌
+// 全部登录因素校验通过后才会调用，此时清除账号的失败次数
 func (b *BaseApi) TokenNext(c *gin.Context, user system.SysUser) {
-	token, claims, err := utils.LoginToken(&user)
+	loginGuardService.RecordSuccess(c.Request.Context(), user.Username)
+	session, refreshToken, err := userSessionService.CreateSession(user.ID, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
-		global.GVA_LOG.Error("获取token失败!", zap.Error(err))
+		global.GVA_LOG.Error("创建登录会话失败!", zap.Error(err))
 		response.FailWithMessage("获取token失败", c)
 		return
 	}
-	if !global.GVA_CONFIG.System.UseMultipoint {
-		utils.SetToken(c, token, int(claims.RegisteredClaims.ExpiresAt.Unix()-time.Now().Unix()))
-		response.OkWithDetailed(systemRes.LoginResponse{
-			User:      user,
-			Token:     token,
-			ExpiresAt: claims.RegisteredClaims.ExpiresAt.Unix() * 1000,
-		}, "登录成功", c)
+	loginGuardService.RecordLogin(user.Username, c.ClientIP(), c.Request.UserAgent(), true, "")
+	b.issueToken(c, user, session, refreshToken, "登录成功")
+}
+
+// issueToken 为会话签发访问令牌，多点登录拦截开启时作废该用户此前的令牌与会话
+func (b *BaseApi) issueToken(c *gin.Context, user system.SysUser, session system.SysUserSession, refreshToken, msg string) {
+	ctx := c.Request.Context()
+	token, claims, err := utils.LoginToken(&user, session.SessionId, userSessionService.SessionVersion(ctx, user.UUID))
+	if err != nil {
+		global.GVA_LOG.Error("获取token失败!", zap.Error(err))
+		response.FailWithMessage("获取token失败", c)
 		return
 	}
-
-	if jwtStr, err := jwtService.GetRedisJWT(user.Username); err == redis.Nil {
-		if err := utils.SetRedisJWT(token, user.Username); err != nil {
+	userSessionService.RegisterSession(ctx, user.UUID, session)
+	if global.GVA_CONFIG.System.UseMultipoint {
+		jwtStr, err := jwtService.GetRedisJWT(user.Username)
+		if err != nil && err != redis.Nil {
 			global.GVA_LOG.Error("设置登录状态失败!", zap.Error(err))
 			response.FailWithMessage("设置登录状态失败", c)
 			return
 		}
-		utils.SetToken(c, token, int(claims.RegisteredClaims.ExpiresAt.Unix()-time.Now().Unix()))
-		response.OkWithDetailed(systemRes.LoginResponse{
-			User:      user,
-			Token:     token,
-			ExpiresAt: claims.RegisteredClaims.ExpiresAt.Unix() * 1000,
-		}, "登录成功", c)
-	} else if err != nil {
-		global.GVA_LOG.Error("设置登录状态失败!", zap.Error(err))
-		response.FailWithMessage("设置登录状态失败", c)
-	} else {
-		var blackJWT system.JwtBlacklist
-		blackJWT.Jwt = jwtStr
-		if err := jwtService.JsonInBlacklist(blackJWT); err != nil {
-			response.FailWithMessage("jwt作废失败", c)
-			return
+		if err == nil && jwtStr != "" {
+			if err := jwtService.JsonInBlacklist(system.JwtBlacklist{Jwt: jwtStr}); err != nil {
+				response.FailWithMessage("jwt作废失败", c)
+				return
+			}
 		}
-		if err := utils.SetRedisJWT(token, user.GetUsername()); err != nil {
+		if err := userSessionService.RevokeUserSessions(user.ID, session.SessionId, systemService.SessionRevokeManual); err != nil {
+			global.GVA_LOG.Error("作废其他会话失败!", zap.Error(err))
+		}
+		if err := utils.SetRedisJWT(token, user.Username); err != nil {
+			global.GVA_LOG.Error("设置登录状态失败!", zap.Error(err))
 			response.FailWithMessage("设置登录状态失败", c)
 			return
 		}
-		utils.SetToken(c, token, int(claims.RegisteredClaims.ExpiresAt.Unix()-time.Now().Unix()))
-		response.OkWithDetailed(systemRes.LoginResponse{
-			User:      user,
-			Token:     token,
-			ExpiresAt: claims.RegisteredClaims.ExpiresAt.Unix() * 1000,
-		}, "登录成功", c)
 	}
+	utils.SetToken(c, token, int(claims.RegisteredClaims.ExpiresAt.Unix()-time.Now().Unix()))
+	response.OkWithDetailed(systemRes.LoginResponse{
+		User:             user,
+		Token:            token,
+		ExpiresAt:        claims.RegisteredClaims.ExpiresAt.Unix() * 1000,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt.Unix() * 1000,
+	}, msg, c)
+}
+
+// RefreshToken
+// @Tags     Base
+// @Summary  使用刷新令牌换取新的访问令牌
+// @Produce   application/json
+// @Param    data  body      systemReq.RefreshToken                                      true  "刷新令牌"
+// @Success  200   {object}  response.Response{data=systemRes.LoginResponse,msg=string}  "返回新的访问令牌与刷新令牌"
+// @Router   /base/refresh [post]
+func (b *BaseApi) RefreshToken(c *gin.Context) {
+	var req systemReq.RefreshToken
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		response.NoAuth("刷新令牌不能为空", c)
+		return
+	}
+	session, refreshToken, err := userSessionService.Refresh(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		if !errors.Is(err, systemService.ErrRefreshTokenInvalid) && !errors.Is(err, systemService.ErrRefreshTokenReused) {
+			global.GVA_LOG.Error("刷新令牌失败!", zap.Error(err))
+		}
+		response.NoAuth(err.Error(), c)
+		return
+	}
+	user, err := userService.GetLoginUser(session.UserId)
+	if err != nil || user.Enable != 1 {
+		_ = userSessionService.RevokeSession(session.SessionId, systemService.SessionRevokeManual)
+		response.NoAuth("用户不存在或已被禁止登录", c)
+		return
+	}
+	b.issueToken(c, *user, session, refreshToken, "刷新成功")
 }
 
 // Register
@@ -157,7 +329,11 @@ func (b *BaseApi) Register(c *gin.Context) {
 	userReturn, err := userService.Register(*user)
 	if err != nil {
 		global.GVA_LOG.Error("注册失败!", zap.Error(err))
-		response.FailWithDetailed(systemRes.SysUserResponse{User: userReturn}, "注册失败", c)
+		msg := "注册失败"
+		if errors.Is(err, utils.ErrPasswordPolicy) {
+			msg = err.Error()
+		}
+		response.FailWithDetailed(systemRes.SysUserResponse{User: userReturn}, msg, c)
 		return
 	}
 	
@@ -197,6 +373,10 @@ func (b *BaseApi) ChangePassword(c *gin.Context) {
 	err = userService.ChangePassword(u, req.NewPassword)
 	if err != nil {
 		global.GVA_LOG.Error("修改失败!", zap.Error(err))
+		if errors.Is(err, utils.ErrPasswordPolicy) || errors.Is(err, systemService.ErrLdapManagedPassword) {
+			response.FailWithMessage("修改失败，"+err.Error(), c)
+			return
+		}
 		response.FailWithMessage("修改失败，原密码与当前账户不符", c)
 		return
 	}
@@ -265,8 +445,14 @@ func (b *BaseApi) SetUserAuthority(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	// 角色变更后使该用户其他设备的访问令牌失效，刷新时按新角色重新签发；当前请求直接换发新令牌
+	version, err := userSessionService.BumpSessionVersion(c.Request.Context(), userID)
+	if err != nil {
+		global.GVA_LOG.Error("更新会话版本失败!", zap.Error(err))
+	}
 	claims := utils.GetUserInfo(c)
 	claims.AuthorityId = sua.AuthorityId
+	claims.SessionVersion = version
 	token, err := utils.NewJWT().CreateToken(*claims)
 	if err != nil {
 		global.GVA_LOG.Error("修改失败!", zap.Error(err))
@@ -302,6 +488,9 @@ func (b *BaseApi) SetUserAuthorities(c *gin.Context) {
 		response.FailWithMessage("修改失败", c)
 		return
 	}
+	if _, err = userSessionService.BumpSessionVersion(c.Request.Context(), sua.ID); err != nil {
+		global.GVA_LOG.Error("更新会话版本失败!", zap.Error(err))
+	}
 	response.OkWithMessage("修改成功", c)
 }
 
@@ -337,6 +526,9 @@ func (b *BaseApi) DeleteUser(c *gin.Context) {
 		response.FailWithMessage("删除失败", c)
 		return
 	}
+	if err = userSessionService.KillAllSessions(c.Request.Context(), reqId.Uint(), systemService.SessionRevokeKill); err != nil {
+		global.GVA_LOG.Error("下线已删除用户的会话失败!", zap.Error(err))
+	}
 	response.OkWithMessage("删除成功", c)
 }
 
@@ -361,6 +553,18 @@ func (b *BaseApi) SetUserInfo(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	before, err := userService.FindUserById(int(user.ID))
+	if err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败", c)
+		return
+	}
+	authoritiesChanged, err := userService.AuthoritiesChanged(user.ID, user.AuthorityIds)
+	if err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败", c)
+		return
+	}
 	if len(user.AuthorityIds) != 0 {
 		authorityID := utils.GetUserAuthorityId(c)
 		err = userService.SetUserAuthorities(authorityID, user.ID, user.AuthorityIds)
@@ -385,6 +589,16 @@ func (b *BaseApi) SetUserInfo(c *gin.Context) {
 		response.FailWithMessage("设置失败", c)
 		return
 	}
+	// 冻结用户立即下线全部会话；启用状态或角色变化时使已签发的访问令牌失效，刷新后按最新信息签发
+	switch {
+	case user.Enable == 2 && before.Enable != 2:
+		err = userSessionService.KillAllSessions(c.Request.Context(), user.ID, systemService.SessionRevokeKill)
+	case user.Enable != before.Enable || authoritiesChanged:
+		_, err = userSessionService.BumpSessionVersion(c.Request.Context(), user.ID)
+	}
+	if err != nil {
+		global.GVA_LOG.Error("更新用户会话失败!", zap.Error(err))
+	}
 	response.OkWithMessage("设置成功", c)
 }
 
@@ -405,6 +619,12 @@ func (b *BaseApi) SetSelfInfo(c *gin.Context) {
 		return
 	}
 	user.ID = utils.GetUserID(c)
+	before, err := userService.FindUserById(int(user.ID))
+	if err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败", c)
+		return
+	}
 	err = userService.SetSelfInfo(system.SysUser{
 		GVA_MODEL: global.GVA_MODEL{
 			ID: user.ID,
@@ -420,6 +640,12 @@ func (b *BaseApi) SetSelfInfo(c *gin.Context) {
 		response.FailWithMessage("设置失败", c)
 		return
 	}
+	// 冻结自身账号时立即下线全部会话
+	if user.Enable == 2 && before.Enable != 2 {
+		if err = userSessionService.KillAllSessions(c.Request.Context(), user.ID, systemService.SessionRevokeKill); err != nil {
+			global.GVA_LOG.Error("更新用户会话失败!", zap.Error(err))
+		}
+	}
 	response.OkWithMessage("设置成功", c)
 }
 
@@ -473,8 +699,8 @@ func (b *BaseApi) GetUserInfo(c *gin.Context) {
 // @Summary   重置用户密码
 // @Security  ApiKeyAuth
 // @Produce  application/json
-// @Param     data  body      system.SysUser                 true  "ID"
-// @Success   200   {object}  response.Response{msg=string}  "重置用户密码"
+// @Param     data  body      systemReq.ResetPassword                                            true  "ID, 密码（为空时生成随机密码）"
+// @Success   200   {object}  response.Response{data=systemRes.ResetPasswordResponse,msg=string}  "重置用户密码"
 // @Router    /user/resetPassword [post]
 func (b *BaseApi) ResetPassword(c *gin.Context) {
 	var rps systemReq.ResetPassword
@@ -483,11 +709,19 @@ func (b *BaseApi) ResetPassword(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
-	err = userService.ResetPassword(rps.ID, rps.Password)
+	password, err := userService.ResetPassword(rps.ID, rps.Password)
 	if err != nil {
 		global.GVA_LOG.Error("重置失败!", zap.Error(err))
 		response.FailWithMessage("重置失败"+err.Error(), c)
 		return
 	}
-	response.OkWithMessage("重置成功", c)
+	// 已登录的会话全部下线，用户需使用新密码重新登录
+	if err := userSessionService.KillAllSessions(c.Request.Context(), rps.ID, systemService.SessionRevokeKill); err != nil {
+		global.GVA_LOG.Error("下线用户会话失败!", zap.Error(err))
+	}
+	var res systemRes.ResetPasswordResponse
+	if rps.Password == "" {
+		res.Password = password
+	}
+	response.OkWithDetailed(res, "重置成功", c)
 }
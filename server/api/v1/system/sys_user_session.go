@@ -0,0 +1,114 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetSessions
+// @Tags      SysUser
+// @Summary   获取自身登录设备列表
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]systemService.SessionEntry,msg=string}  "登录设备列表"
+// @Router    /user/getSessions [get]
+func (b *BaseApi) GetSessions(c *gin.Context) {
+	claims := utils.GetUserInfo(c)
+	if claims == nil {
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	list, err := userSessionService.ListSessions(c.Request.Context(), claims.BaseClaims.ID, claims.BaseClaims.SessionId)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// RevokeSession
+// @Tags      SysUser
+// @Summary   下线自身的某个登录设备
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.RevokeSession        true  "会话ID"
+// @Success   200   {object}  response.Response{msg=string}  "下线成功"
+// @Router    /user/revokeSession [post]
+func (b *BaseApi) RevokeSession(c *gin.Context) {
+	var req systemReq.RevokeSession
+	if err := c.ShouldBindJSON(&req); err != nil || req.SessionId == "" {
+		response.FailWithMessage("会话ID不能为空", c)
+		return
+	}
+	if err := userSessionService.RevokeUserSession(utils.GetUserID(c), req.SessionId); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("下线成功", c)
+}
+
+// GetUserSessions
+// @Tags      SysUser
+// @Summary   管理员查看用户的在线会话
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      request.GetById                                                  true  "用户ID"
+// @Success   200   {object}  response.Response{data=[]systemService.SessionEntry,msg=string}  "在线会话列表"
+// @Router    /user/getUserSessions [post]
+func (b *BaseApi) GetUserSessions(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, err := userSessionService.ListSessions(c.Request.Context(), req.Uint(), "")
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(list, "获取成功", c)
+}
+
+// KillUserSession
+// @Tags      SysUser
+// @Summary   管理员强制下线用户的某个会话，未指定会话ID时下线全部会话
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.KillUserSession      true  "用户ID, 会话ID"
+// @Success   200   {object}  response.Response{msg=string}  "下线成功"
+// @Router    /user/killUserSession [post]
+func (b *BaseApi) KillUserSession(c *gin.Context) {
+	var req systemReq.KillUserSession
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.ID == 0 {
+		response.FailWithMessage("用户ID不能为空", c)
+		return
+	}
+	var err error
+	if req.SessionId == "" {
+		err = userSessionService.KillAllSessions(c.Request.Context(), req.ID, systemService.SessionRevokeKill)
+	} else {
+		err = userSessionService.KillSession(c.Request.Context(), req.ID, req.SessionId)
+	}
+	if err != nil {
+		global.GVA_LOG.Error("下线失败!", zap.Error(err))
+		response.FailWithMessage("下线失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("下线成功", c)
+}
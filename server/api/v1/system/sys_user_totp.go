@@ -0,0 +1,257 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LoginTotp
+// @Tags     Base
+// @Summary  两步验证登录
+// @Produce   application/json
+// @Param    data  body      systemReq.LoginTotp                                         true  "登录凭证, 动态码或恢复码"
+// @Success  200   {object}  response.Response{data=systemRes.LoginResponse,msg=string}  "返回包括用户信息,token,过期时间"
+// @Router   /base/loginTotp [post]
+func (b *BaseApi) LoginTotp(c *gin.Context) {
+	var req systemReq.LoginTotp
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	ctx := c.Request.Context()
+	userId, err := userTotpService.CheckLoginChallenge(ctx, req.TotpToken)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	user, err := userService.GetLoginUser(userId)
+	if err != nil {
+		global.GVA_LOG.Error("获取用户信息失败!", zap.Error(err))
+		response.FailWithMessage("登录失败", c)
+		return
+	}
+	if user.Enable != 1 {
+		userTotpService.ClearLoginChallenge(ctx, req.TotpToken)
+		response.FailWithMessage("用户被禁止登录", c)
+		return
+	}
+
+	if err := loginGuardService.CheckLocked(ctx, user.Username, c.ClientIP()); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+
+	enabled, err := userTotpService.IsEnabled(user.ID)
+	if err != nil {
+		global.GVA_LOG.Error("查询两步验证状态失败!", zap.Error(err))
+		response.FailWithMessage("登录失败", c)
+		return
+	}
+	if enabled {
+		usedRecovery, err := userTotpService.Verify(user.ID, req.Code)
+		if err != nil {
+			// 动态码错误同样计入账号失败次数
+			b.totpLoginFailed(c, user.Username, err)
+			return
+		}
+		if usedRecovery {
+			global.GVA_LOG.Warn("用户使用恢复码登录", zap.String("username", user.Username), zap.String("ip", c.ClientIP()))
+		}
+	} else {
+		// 角色强制要求但尚未绑定：通过 /base/totpEnroll 获取二维码后，首次输入动态码即完成绑定
+		if !userTotpService.IsRequired(user) {
+			response.FailWithMessage(systemService.ErrTotpNotEnabled.Error(), c)
+			return
+		}
+		if err := userTotpService.Enable(user.ID, req.Code); err != nil {
+			b.totpLoginFailed(c, user.Username, err)
+			return
+		}
+	}
+	userTotpService.ClearLoginChallenge(ctx, req.TotpToken)
+	b.TokenNext(c, *user)
+}
+
+// totpLoginFailed 动态码错误与密码错误计入同一账号与IP的失败次数，达到上限时同样锁定
+func (b *BaseApi) totpLoginFailed(c *gin.Context, username string, err error) {
+	if !errors.Is(err, systemService.ErrTotpInvalidCode) {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	ip := c.ClientIP()
+	loginGuardService.RecordLogin(username, ip, c.Request.UserAgent(), false, systemService.LoginReasonTotp)
+	if locked := loginGuardService.RecordFailure(c.Request.Context(), username, ip); locked > 0 {
+		response.FailWithMessage((&systemService.LoginLockedError{Remaining: locked}).Error(), c)
+		return
+	}
+	response.FailWithMessage(err.Error(), c)
+}
+
+// TotpEnroll
+// @Tags     Base
+// @Summary  登录时绑定两步验证（角色强制要求且尚未绑定）
+// @Produce   application/json
+// @Param    data  body      systemReq.LoginTotp                                           true  "登录凭证"
+// @Success  200   {object}  response.Response{data=systemService.TotpSetup,msg=string}  "返回密钥、绑定地址与恢复码"
+// @Router   /base/totpEnroll [post]
+func (b *BaseApi) TotpEnroll(c *gin.Context) {
+	var req systemReq.LoginTotp
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	userId, err := userTotpService.CheckLoginChallenge(c.Request.Context(), req.TotpToken)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	user, err := userService.GetLoginUser(userId)
+	if err != nil {
+		global.GVA_LOG.Error("获取用户信息失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	if !userTotpService.IsRequired(user) {
+		response.FailWithMessage("请登录后在个人中心绑定两步验证", c)
+		return
+	}
+	setup, err := userTotpService.Setup(user)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(setup, "获取成功", c)
+}
+
+// GetTotpStatus
+// @Tags      SysUser
+// @Summary   获取自身两步验证状态
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=systemRes.TotpStatusResponse,msg=string}  "两步验证状态"
+// @Router    /user/getTotpStatus [get]
+func (b *BaseApi) GetTotpStatus(c *gin.Context) {
+	user, err := userService.GetLoginUser(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	enabled, err := userTotpService.IsEnabled(user.ID)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	status := systemRes.TotpStatusResponse{Enabled: enabled, Required: userTotpService.IsRequired(user)}
+	if enabled {
+		status.RemainingRecoveryCodes, _ = userTotpService.RemainingRecoveryCodes(user.ID)
+	}
+	response.OkWithDetailed(status, "获取成功", c)
+}
+
+// SetupTotp
+// @Tags      SysUser
+// @Summary   生成两步验证绑定二维码与恢复码
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=systemService.TotpSetup,msg=string}  "返回密钥、绑定地址与恢复码"
+// @Router    /user/setupTotp [post]
+func (b *BaseApi) SetupTotp(c *gin.Context) {
+	user, err := userService.GetLoginUser(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	setup, err := userTotpService.Setup(user)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(setup, "获取成功", c)
+}
+
+// EnableTotp
+// @Tags      SysUser
+// @Summary   确认动态码并启用两步验证
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.TotpCode             true  "动态码"
+// @Success   200   {object}  response.Response{msg=string}  "启用两步验证"
+// @Router    /user/enableTotp [post]
+func (b *BaseApi) EnableTotp(c *gin.Context) {
+	var req systemReq.TotpCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := userTotpService.Enable(utils.GetUserID(c), req.Code); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("启用成功", c)
+}
+
+// DisableTotp
+// @Tags      SysUser
+// @Summary   关闭两步验证
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.TotpCode             true  "动态码或恢复码"
+// @Success   200   {object}  response.Response{msg=string}  "关闭两步验证"
+// @Router    /user/disableTotp [post]
+func (b *BaseApi) DisableTotp(c *gin.Context) {
+	var req systemReq.TotpCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	user, err := userService.GetLoginUser(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("关闭失败", c)
+		return
+	}
+	if err := userTotpService.Disable(user, req.Code); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("关闭成功", c)
+}
+
+// ResetUserTotp
+// @Tags      SysUser
+// @Summary   管理员重置用户两步验证（如手机丢失），用户下次登录时按角色要求重新绑定
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "用户ID"
+// @Success   200   {object}  response.Response{msg=string}  "重置两步验证"
+// @Router    /user/resetUserTotp [post]
+func (b *BaseApi) ResetUserTotp(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := userTotpService.Reset(req.Uint()); err != nil {
+		global.GVA_LOG.Error("重置失败!", zap.Error(err))
+		response.FailWithMessage("重置失败", c)
+		return
+	}
+	global.GVA_LOG.Warn("管理员重置用户两步验证", zap.Uint("operator", utils.GetUserID(c)), zap.Int("userId", req.ID))
+	response.OkWithMessage("重置成功", c)
+}
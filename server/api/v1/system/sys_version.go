@@ -268,9 +268,9 @@ func (sysVersionApi *SysVersionApi) ExportVersion(c *gin.Context) {
 	}
 
 	// 获取选中的字典数据
-	var dictData []system.SysDictionary
+	var dictData []systemReq.VersionDictionary
 	if len(req.DictIds) > 0 {
-		dictData, err = sysVersionService.GetDictionariesByIds(ctx, req.DictIds)
+		dictData, err = sysVersionService.GetVersionDictionaries(ctx, req.DictIds)
 		if err != nil {
 			global.GVA_LOG.Error("获取字典数据失败!", zap.Error(err))
 			response.FailWithMessage("获取字典数据失败:"+err.Error(), c)
@@ -278,6 +278,35 @@ func (sysVersionApi *SysVersionApi) ExportVersion(c *gin.Context) {
 		}
 	}
 
+	// 获取选中角色的API权限与按钮权限
+	var casbinData []systemReq.VersionCasbinRule
+	var authorityBtnData []systemReq.VersionAuthorityBtn
+	if len(req.AuthorityIds) > 0 {
+		casbinData, err = sysVersionService.GetVersionCasbinRules(ctx, req.AuthorityIds)
+		if err != nil {
+			global.GVA_LOG.Error("获取API权限失败!", zap.Error(err))
+			response.FailWithMessage("获取API权限失败:"+err.Error(), c)
+			return
+		}
+		authorityBtnData, err = sysVersionService.GetVersionAuthorityBtns(ctx, req.AuthorityIds)
+		if err != nil {
+			global.GVA_LOG.Error("获取按钮权限失败!", zap.Error(err))
+			response.FailWithMessage("获取按钮权限失败:"+err.Error(), c)
+			return
+		}
+	}
+
+	// 获取选中的导出模板
+	var templateData []system.SysExportTemplate
+	if len(req.TemplateIds) > 0 {
+		templateData, err = sysVersionService.GetVersionExportTemplates(ctx, req.TemplateIds)
+		if err != nil {
+			global.GVA_LOG.Error("获取导出模板失败!", zap.Error(err))
+			response.FailWithMessage("获取导出模板失败:"+err.Error(), c)
+			return
+		}
+	}
+
 	// 处理菜单数据，构建递归的children结构
 	processedMenus := buildMenuTree(menuData)
 
@@ -293,34 +322,6 @@ func (sysVersionApi *SysVersionApi) ExportVersion(c *gin.Context) {
 		processedApis = append(processedApis, cleanApi)
 	}
 
-	// 处理字典数据，清除ID和时间戳字段，包含字典详情
-	processedDicts := make([]system.SysDictionary, 0, len(dictData))
-	for _, dict := range dictData {
-		cleanDict := system.SysDictionary{
-			Name:   dict.Name,
-			Type:   dict.Type,
-			Status: dict.Status,
-			Desc:   dict.Desc,
-		}
-		
-		// 处理字典详情数据，清除ID和时间戳字段
-		cleanDetails := make([]system.SysDictionaryDetail, 0, len(dict.SysDictionaryDetails))
-		for _, detail := range dict.SysDictionaryDetails {
-			cleanDetail := system.SysDictionaryDetail{
-				Label:  detail.Label,
-				Value:  detail.Value,
-				Extend: detail.Extend,
-				Status: detail.Status,
-				Sort:   detail.Sort,
-				// 不复制 ID, CreatedAt, UpdatedAt, SysDictionaryID
-			}
-			cleanDetails = append(cleanDetails, cleanDetail)
-		}
-		cleanDict.SysDictionaryDetails = cleanDetails
-		
-		processedDicts = append(processedDicts, cleanDict)
-	}
-
 	// 构建导出数据
 	exportData := systemRes.ExportVersionResponse{
 		Version: systemReq.VersionInfo{
@@ -329,9 +330,12 @@ func (sysVersionApi *SysVersionApi) ExportVersion(c *gin.Context) {
 			Description: req.Description,
 			ExportTime:  time.Now().Format("2006-01-02 15:04:05"),
 		},
-		Menus:        processedMenus,
-		Apis:         processedApis,
-		Dictionaries: processedDicts,
+		Menus:           processedMenus,
+		Apis:            processedApis,
+		Dictionaries:    dictData,
+		Casbin:          casbinData,
+		AuthorityBtns:   authorityBtnData,
+		ExportTemplates: templateData,
 	}
 
 	// 转换为JSON
@@ -342,6 +346,14 @@ func (sysVersionApi *SysVersionApi) ExportVersion(c *gin.Context) {
 		return
 	}
 
+	// 配置了签名密钥时为版本包签名
+	jsonData, err = sysVersionService.SignVersionPackage(jsonData)
+	if err != nil {
+		global.GVA_LOG.Error("版本包签名失败!", zap.Error(err))
+		response.FailWithMessage("版本包签名失败:"+err.Error(), c)
+		return
+	}
+
 	// 保存版本记录
 	version := system.SysVersion{
 		VersionName: utils.Pointer(req.VersionName),
@@ -416,71 +428,78 @@ func (sysVersionApi *SysVersionApi) DownloadVersionJson(c *gin.Context) {
 
 // ImportVersion 导入版本数据
 // @Tags SysVersion
-// @Summary 导入版本数据
+// @Summary 导入版本数据，已存在的数据按 strategy 与 resolutions 处理，全部成功或全部回滚
 // @Security ApiKeyAuth
 // @Accept application/json
 // @Produce application/json
 // @Param data body systemReq.ImportVersionRequest true "版本JSON数据"
-// @Success 200 {object} response.Response{msg=string} "导入成功"
+// @Success 200 {object} response.Response{data=systemRes.VersionImportResult,msg=string} "导入成功"
 // @Router /sysVersion/importVersion [post]
 func (sysVersionApi *SysVersionApi) ImportVersion(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	// 获取JSON数据
-	var importData systemReq.ImportVersionRequest
-	err := c.ShouldBindJSON(&importData)
+	// 签名基于原始内容校验，因此读取原始数据而不是直接绑定
+	data, err := c.GetRawData()
 	if err != nil {
-		response.FailWithMessage("解析JSON数据失败:"+err.Error(), c)
+		response.FailWithMessage("读取版本数据失败:"+err.Error(), c)
 		return
 	}
-
-	// 验证数据格式
-	if importData.VersionInfo.Name == "" || importData.VersionInfo.Code == "" {
-		response.FailWithMessage("版本信息格式错误", c)
+	result, err := sysVersionService.ImportVersion(ctx, data)
+	if err != nil {
+		global.GVA_LOG.Error("导入版本失败!", zap.Error(err))
+		response.FailWithMessage("导入失败: "+err.Error(), c)
 		return
 	}
+	response.OkWithDetailed(result, "导入成功", c)
+}
 
-	// 导入菜单数据
-	if len(importData.ExportMenu) > 0 {
-		if err := sysVersionService.ImportMenus(ctx, importData.ExportMenu); err != nil {
-			global.GVA_LOG.Error("导入菜单失败!", zap.Error(err))
-			response.FailWithMessage("导入菜单失败: "+err.Error(), c)
-			return
-		}
-	}
+// PreviewVersion 预览版本导入
+// @Tags SysVersion
+// @Summary 预览版本导入，返回每项数据与目标环境的差异及处理方式，不修改数据
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param data body systemReq.ImportVersionRequest true "版本JSON数据"
+// @Success 200 {object} response.Response{data=systemRes.VersionPreview,msg=string} "预览成功"
+// @Router /sysVersion/previewVersion [post]
+func (sysVersionApi *SysVersionApi) PreviewVersion(c *gin.Context) {
+	ctx := c.Request.Context()
 
-	// 导入API数据
-	if len(importData.ExportApi) > 0 {
-		if err := sysVersionService.ImportApis(importData.ExportApi); err != nil {
-			global.GVA_LOG.Error("导入API失败!", zap.Error(err))
-			response.FailWithMessage("导入API失败: "+err.Error(), c)
-			return
-		}
+	data, err := c.GetRawData()
+	if err != nil {
+		response.FailWithMessage("读取版本数据失败:"+err.Error(), c)
+		return
 	}
-
-	// 导入字典数据
-	if len(importData.ExportDictionary) > 0 {
-		if err := sysVersionService.ImportDictionaries(importData.ExportDictionary); err != nil {
-			global.GVA_LOG.Error("导入字典失败!", zap.Error(err))
-			response.FailWithMessage("导入字典失败: "+err.Error(), c)
-			return
-		}
+	preview, err := sysVersionService.PreviewVersion(ctx, data)
+	if err != nil {
+		global.GVA_LOG.Error("预览版本失败!", zap.Error(err))
+		response.FailWithMessage("预览失败: "+err.Error(), c)
+		return
 	}
+	response.OkWithDetailed(preview, "预览成功", c)
+}
 
-	// 创建导入记录
-	jsonData, _ := json.Marshal(importData)
-	version := system.SysVersion{
-		VersionName: utils.Pointer(importData.VersionInfo.Name),
-		VersionCode: utils.Pointer(fmt.Sprintf("%s_imported_%s", importData.VersionInfo.Code, time.Now().Format("20060102150405"))),
-		Description: utils.Pointer(fmt.Sprintf("导入版本: %s", importData.VersionInfo.Description)),
-		VersionData: utils.Pointer(string(jsonData)),
-	}
+// RollbackVersion 回滚版本导入
+// @Tags SysVersion
+// @Summary 将导入记录涉及的数据恢复到导入前
+// @Security ApiKeyAuth
+// @Accept application/json
+// @Produce application/json
+// @Param ID query string true "导入记录ID"
+// @Success 200 {object} response.Response{msg=string} "回滚成功"
+// @Router /sysVersion/rollbackVersion [post]
+func (sysVersionApi *SysVersionApi) RollbackVersion(c *gin.Context) {
+	ctx := c.Request.Context()
 
-	err = sysVersionService.CreateSysVersion(ctx, &version)
-	if err != nil {
-		global.GVA_LOG.Error("保存导入记录失败!", zap.Error(err))
-		// 这里不返回错误，因为数据已经导入成功
+	ID := c.Query("ID")
+	if ID == "" {
+		response.FailWithMessage("版本ID不能为空", c)
+		return
 	}
-
-	response.OkWithMessage("导入成功", c)
+	if err := sysVersionService.RollbackVersion(ctx, ID); err != nil {
+		global.GVA_LOG.Error("回滚失败!", zap.Error(err))
+		response.FailWithMessage("回滚失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("回滚成功", c)
 }
@@ -0,0 +1,5 @@
+package config
+
+type AuditLog struct {
+	HashKey string `mapstructure:"hash-key" json:"hash-key" yaml:"hash-key"` // 审计链哈希密钥，必须单独配置，为空时拒绝写入审计表；修改后历史记录将无法通过校验
+}
@@ -9,6 +9,24 @@ type Server struct {
 	Email     Email   `mapstructure:"email" json:"email" yaml:"email"`
 	System    System  `mapstructure:"system" json:"system" yaml:"system"`
 	Captcha   Captcha `mapstructure:"captcha" json:"captcha" yaml:"captcha"`
+	// 登录防爆破
+	LoginGuard LoginGuard `mapstructure:"login-guard" json:"login-guard" yaml:"login-guard"`
+	// 两步验证
+	Totp Totp `mapstructure:"totp" json:"totp" yaml:"totp"`
+	// 密码策略
+	PasswordPolicy PasswordPolicy `mapstructure:"password-policy" json:"password-policy" yaml:"password-policy"`
+	// 单点登录
+	Oidc Oidc `mapstructure:"oidc" json:"oidc" yaml:"oidc"`
+	// LDAP登录
+	Ldap Ldap `mapstructure:"ldap" json:"ldap" yaml:"ldap"`
+	// 操作记录
+	OperationRecord OperationRecord `mapstructure:"operation-record" json:"operation-record" yaml:"operation-record"`
+	// 审计日志
+	AuditLog AuditLog `mapstructure:"audit-log" json:"audit-log" yaml:"audit-log"`
+	// 版本包
+	VersionPackage VersionPackage `mapstructure:"version-package" json:"version-package" yaml:"version-package"`
+	// 数据库迁移
+	Migration Migration `mapstructure:"migration" json:"migration" yaml:"migration"`
 	// auto
 	AutoCode Autocode `mapstructure:"autocode" json:"autocode" yaml:"autocode"`
 	// gorm
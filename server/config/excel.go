@@ -1,5 +1,9 @@
 package config
 
 type Excel struct {
-	Dir string `mapstructure:"dir" json:"dir" yaml:"dir"`
+	Dir                string `mapstructure:"dir" json:"dir" yaml:"dir"`
+	Workers            int    `mapstructure:"workers" json:"workers" yaml:"workers"`                                        // 本实例同时执行的异步导出任务数，0代表使用默认值2
+	LinkExpire         string `mapstructure:"link-expire" json:"link-expire" yaml:"link-expire"`                            // 异步导出下载链接有效期，过期后删除文件
+	PublicUrl          string `mapstructure:"public-url" json:"public-url" yaml:"public-url"`                               // 服务对外访问地址，用于导出完成通知邮件中的下载链接，为空时不发送邮件
+	DownloadSigningKey string `mapstructure:"download-signing-key" json:"download-signing-key" yaml:"download-signing-key"` // 导出下载链接的签名密钥，未配置时不能创建异步导出
 }
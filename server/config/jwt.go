@@ -1,8 +1,9 @@
 package config
 
 type JWT struct {
-	SigningKey  string `mapstructure:"signing-key" json:"signing-key" yaml:"signing-key"`    // jwt签名
-	ExpiresTime string `mapstructure:"expires-time" json:"expires-time" yaml:"expires-time"` // 过期时间
-	BufferTime  string `mapstructure:"buffer-time" json:"buffer-time" yaml:"buffer-time"`    // 缓冲时间
-	Issuer      string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                   // 签发者
+	SigningKey         string `mapstructure:"signing-key" json:"signing-key" yaml:"signing-key"`                            // jwt签名
+	ExpiresTime        string `mapstructure:"expires-time" json:"expires-time" yaml:"expires-time"`                         // 访问令牌过期时间
+	BufferTime         string `mapstructure:"buffer-time" json:"buffer-time" yaml:"buffer-time"`                            // 缓冲时间
+	Issuer             string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                                           // 签发者
+	RefreshExpiresTime string `mapstructure:"refresh-expires-time" json:"refresh-expires-time" yaml:"refresh-expires-time"` // 刷新令牌过期时间，刷新令牌每次使用后轮换
 }
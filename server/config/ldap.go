@@ -0,0 +1,30 @@
+package config
+
+type Ldap struct {
+	Enable             bool               `mapstructure:"enable" json:"enable" yaml:"enable"`                                           // 是否开启LDAP登录
+	Url                string             `mapstructure:"url" json:"url" yaml:"url"`                                                    // 目录服务地址，ldap://host:389 或 ldaps://host:636
+	StartTLS           bool               `mapstructure:"start-tls" json:"start-tls" yaml:"start-tls"`                                  // ldap:// 连接后升级为TLS
+	InsecureSkipVerify bool               `mapstructure:"insecure-skip-verify" json:"insecure-skip-verify" yaml:"insecure-skip-verify"` // 跳过证书校验，仅用于测试环境
+	Timeout            int                `mapstructure:"timeout" json:"timeout" yaml:"timeout"`                                        // 连接与查询超时（秒）
+	BindDN             string             `mapstructure:"bind-dn" json:"bind-dn" yaml:"bind-dn"`                                        // 查询用户使用的服务账号
+	BindPassword       string             `mapstructure:"bind-password" json:"bind-password" yaml:"bind-password"`                      // 服务账号密码
+	BaseDN             string             `mapstructure:"base-dn" json:"base-dn" yaml:"base-dn"`                                        // 用户搜索起点
+	UserFilter         string             `mapstructure:"user-filter" json:"user-filter" yaml:"user-filter"`                            // 用户过滤器，{username} 替换为转义后的用户名
+	UsernameAttr       string             `mapstructure:"username-attr" json:"username-attr" yaml:"username-attr"`                      // 用户名属性
+	NickNameAttr       string             `mapstructure:"nick-name-attr" json:"nick-name-attr" yaml:"nick-name-attr"`                   // 昵称属性，为空不同步
+	EmailAttr          string             `mapstructure:"email-attr" json:"email-attr" yaml:"email-attr"`                               // 邮箱属性，为空不同步
+	PhoneAttr          string             `mapstructure:"phone-attr" json:"phone-attr" yaml:"phone-attr"`                               // 手机号属性，为空不同步
+	GroupAttr          string             `mapstructure:"group-attr" json:"group-attr" yaml:"group-attr"`                               // 用户条目上的组属性，如 memberOf
+	GroupBaseDN        string             `mapstructure:"group-base-dn" json:"group-base-dn" yaml:"group-base-dn"`                      // 组搜索起点，为空时使用 base-dn
+	GroupFilter        string             `mapstructure:"group-filter" json:"group-filter" yaml:"group-filter"`                         // 组过滤器，{dn} 替换为用户DN，为空不搜索组
+	GroupMapping       []LdapGroupMapping `mapstructure:"group-mapping" json:"group-mapping" yaml:"group-mapping"`                      // 组映射
+	DefaultAuthorityId uint               `mapstructure:"default-authority-id" json:"default-authority-id" yaml:"default-authority-id"` // 未匹配任何映射时的角色，0代表拒绝创建用户
+	AutoProvision      bool               `mapstructure:"auto-provision" json:"auto-provision" yaml:"auto-provision"`                   // 首次登录时自动创建用户
+	SyncRoles          bool               `mapstructure:"sync-roles" json:"sync-roles" yaml:"sync-roles"`                               // 每次登录时按映射同步用户角色
+	LocalUsers         []string           `mapstructure:"local-users" json:"local-users" yaml:"local-users"`                            // 始终使用本地密码登录的应急账号，目录不可用时仍可登录
+}
+
+type LdapGroupMapping struct {
+	Group       string `mapstructure:"group" json:"group" yaml:"group"`                      // 组DN或组名（cn），忽略大小写
+	AuthorityId uint   `mapstructure:"authority-id" json:"authority-id" yaml:"authority-id"` // 对应的角色ID
+}
@@ -0,0 +1,9 @@
+package config
+
+type LoginGuard struct {
+	MaxFailures     int    `mapstructure:"max-failures" json:"max-failures" yaml:"max-failures"`                // 账号连续密码错误达到该次数后锁定，0代表不锁定
+	LockDuration    string `mapstructure:"lock-duration" json:"lock-duration" yaml:"lock-duration"`             // 首次锁定时长，之后每次锁定时长翻倍
+	MaxLockDuration string `mapstructure:"max-lock-duration" json:"max-lock-duration" yaml:"max-lock-duration"` // 最长锁定时长
+	IpMaxFailures   int    `mapstructure:"ip-max-failures" json:"ip-max-failures" yaml:"ip-max-failures"`       // 同一IP在统计窗口内登录失败达到该次数后锁定，0代表不限制
+	Window          string `mapstructure:"window" json:"window" yaml:"window"`                                  // 失败次数统计窗口，IP锁定时长与之相同
+}
@@ -0,0 +1,5 @@
+package config
+
+type Migration struct {
+	AutoUp bool `mapstructure:"auto-up" json:"auto-up" yaml:"auto-up"` // 启动时自动执行待执行的迁移；多实例部署时建议关闭，改为发布时通过 -migrate up 执行
+}
@@ -0,0 +1,22 @@
+package config
+
+type Oidc struct {
+	Enable             bool              `mapstructure:"enable" json:"enable" yaml:"enable"`                                           // 是否开启单点登录
+	Issuer             string            `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                                           // 身份提供方地址，通过 /.well-known/openid-configuration 获取端点
+	ClientId           string            `mapstructure:"client-id" json:"client-id" yaml:"client-id"`                                  // 客户端ID
+	ClientSecret       string            `mapstructure:"client-secret" json:"client-secret" yaml:"client-secret"`                      // 客户端密钥，公开客户端可为空
+	RedirectUrl        string            `mapstructure:"redirect-url" json:"redirect-url" yaml:"redirect-url"`                         // 回调地址（前端回调页面）
+	Scopes             []string          `mapstructure:"scopes" json:"scopes" yaml:"scopes"`                                           // 申请的 scope，默认 openid profile email
+	UsernameClaim      string            `mapstructure:"username-claim" json:"username-claim" yaml:"username-claim"`                   // 用户名声明，默认 preferred_username
+	RolesClaim         string            `mapstructure:"roles-claim" json:"roles-claim" yaml:"roles-claim"`                            // 角色声明，支持点分路径如 realm_access.roles，默认 groups
+	RoleMapping        []OidcRoleMapping `mapstructure:"role-mapping" json:"role-mapping" yaml:"role-mapping"`                         // 角色映射
+	DefaultAuthorityId uint              `mapstructure:"default-authority-id" json:"default-authority-id" yaml:"default-authority-id"` // 未匹配任何映射时的角色，0代表拒绝登录
+	AutoProvision      bool              `mapstructure:"auto-provision" json:"auto-provision" yaml:"auto-provision"`                   // 首次登录时自动创建用户
+	LinkByEmail        bool              `mapstructure:"link-by-email" json:"link-by-email" yaml:"link-by-email"`                      // 按已验证邮箱自动关联已有用户
+	SyncRoles          bool              `mapstructure:"sync-roles" json:"sync-roles" yaml:"sync-roles"`                               // 每次登录时按映射同步用户角色
+}
+
+type OidcRoleMapping struct {
+	Claim       string `mapstructure:"claim" json:"claim" yaml:"claim"`                      // 角色声明中的值
+	AuthorityId uint   `mapstructure:"authority-id" json:"authority-id" yaml:"authority-id"` // 对应的角色ID
+}
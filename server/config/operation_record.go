@@ -0,0 +1,9 @@
+package config
+
+type OperationRecord struct {
+	Retention    string   `mapstructure:"retention" json:"retention" yaml:"retention"`             // 操作记录保留时长，如 2160h、90d，为空时默认90天
+	Archive      bool     `mapstructure:"archive" json:"archive" yaml:"archive"`                   // 清理前是否压缩归档为对象存储中的私有文件
+	Async        bool     `mapstructure:"async" json:"async" yaml:"async"`                         // 是否通过队列异步写入
+	QueueSize    int      `mapstructure:"queue-size" json:"queue-size" yaml:"queue-size"`          // 异步队列长度，队列满时改为同步写入，0代表使用默认值1024
+	RedactFields []string `mapstructure:"redact-fields" json:"redact-fields" yaml:"redact-fields"` // 额外需要脱敏的字段名，不区分大小写
+}
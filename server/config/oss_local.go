@@ -1,6 +1,7 @@
 package config
 
 type Local struct {
-	Path      string `mapstructure:"path" json:"path" yaml:"path"`                   // 本地文件访问路径
-	StorePath string `mapstructure:"store-path" json:"store-path" yaml:"store-path"` // 本地文件存储路径
+	Path        string `mapstructure:"path" json:"path" yaml:"path"`                         // 本地文件访问路径
+	StorePath   string `mapstructure:"store-path" json:"store-path" yaml:"store-path"`       // 本地文件存储路径
+	PrivatePath string `mapstructure:"private-path" json:"private-path" yaml:"private-path"` // 本地存储的私有文件目录，保存导出与归档文件，不对外提供静态访问
 }
@@ -0,0 +1,10 @@
+package config
+
+type PasswordPolicy struct {
+	MinLength          int  `mapstructure:"min-length" json:"min-length" yaml:"min-length"`                                  // 最小长度
+	MinCharClasses     int  `mapstructure:"min-char-classes" json:"min-char-classes" yaml:"min-char-classes"`                // 大写字母、小写字母、数字、符号中至少包含的种类数
+	CheckBreached      bool `mapstructure:"check-breached" json:"check-breached" yaml:"check-breached"`                      // 禁止使用内置弱密码库中的密码
+	HistoryCount       int  `mapstructure:"history-count" json:"history-count" yaml:"history-count"`                         // 不能与最近N次使用过的密码相同，0代表不限制
+	ExpireDays         int  `mapstructure:"expire-days" json:"expire-days" yaml:"expire-days"`                               // 密码有效天数，到期后登录时必须修改，0代表不过期
+	ForceChangeOnReset bool `mapstructure:"force-change-on-reset" json:"force-change-on-reset" yaml:"force-change-on-reset"` // 管理员重置密码后，用户下次登录时必须修改
+}
@@ -0,0 +1,5 @@
+package config
+
+type Totp struct {
+	EncryptionKey string `mapstructure:"encryption-key" json:"encryption-key" yaml:"encryption-key"` // 两步验证密钥的加密密钥，为空时无法启用两步验证；修改后已绑定的两步验证将无法使用
+}
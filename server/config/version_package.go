@@ -0,0 +1,6 @@
+package config
+
+type VersionPackage struct {
+	SignKey          string `mapstructure:"sign-key" json:"sign-key" yaml:"sign-key"`                            // 版本包签名密钥，需要互相导入的各环境配置相同的值；为空时导出的版本包不签名
+	RequireSignature bool   `mapstructure:"require-signature" json:"require-signature" yaml:"require-signature"` // 只允许导入签名有效的版本包
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/initialize"
@@ -28,6 +29,12 @@ func RunServer() {
 	if global.GVA_DB != nil {
 		system.LoadAll()
 	}
+	// 多实例部署时通过 Redis 广播同步各实例的jwt黑名单、参数与字典缓存
+	if global.GVA_REDIS != nil {
+		go system.SubscribeBlacklist(context.Background())
+		go system.SubscribeSysParams(context.Background())
+		go system.SubscribeDictionaries(context.Background())
+	}
 
 	Router := initialize.Routers()
 
@@ -35,8 +42,10 @@ func RunServer() {
 
 	fmt.Printf(`
 	当前版本:%s
-`, global.Version, address, address, global.GVA_CONFIG.MCP.SSEPath, address, global.GVA_CONFIG.MCP.MessagePath)
+`, global.Version)
 	initServer(address, Router, 10*time.Minute, 10*time.Minute)
+	// 写入异步队列中剩余的操作记录
+	system.OperationRecordServiceApp.FlushOperationRecords(5 * time.Second)
 }
 
 //欢迎使用 gin-vue-admin
@@ -13,14 +13,16 @@ const (
 	MER_XINGYI_KEY     = "xingyi"
 	MER_RICH_KEY       = "rich"
 
-	PAY_AMOUNT_USED_KEY = "pay_ammount_used"
-	PAY_SELECT_TASK_KEY = "pay_select_task"
+	PAY_AMOUNT_USED_KEY  = "pay_ammount_used"
+	PAY_SELECT_TASK_KEY  = "pay_select_task"
+	PAY_ORDER_EXPIRE_KEY = "pay_order_expire_queue" // 订单过期延迟队列（ZSET，score 为过期时间戳）
 
 	PAY_ORDER_STATE_PENDING = "pending"
 
 	MER_TYPE_XINGYI     = "0"
 	MER_TYPE_RICH       = "1"
 	MER_TYPE_XIANG_XIAN = "2"
+	MER_TYPE_SANDBOX    = "9" // 沙箱商户，使用模拟渠道，收款保存在 Redis 中
 
 	REDIS_PAY_REQUEST_TOKEN  = "pay_request_token"
 	REDIS_PAY_REQUEST_COOKIE = "pay_request_cookie"
@@ -32,6 +34,9 @@ var (
 	MER_PAY_ORDER_FAILED   = int8Ptr(2)
 	MER_PAY_ORDER_CANCELED = int8Ptr(3)
 	MER_PAY_ORDER_REFUNDED = int8Ptr(4)
+
+	MER_PAY_SUSPENSE_PENDING = int8Ptr(0) // 挂账收款待处理
+	MER_PAY_SUSPENSE_MATCHED = int8Ptr(1) // 挂账收款已匹配订单
 )
 
 // 辅助函数
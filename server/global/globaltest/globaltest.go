@@ -0,0 +1,62 @@
+// Package globaltest 为依赖全局变量的测试准备独立的运行环境，测试结束后恢复原有的全局变量
+package globaltest
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"github.com/songzhibin97/gkit/cache/local_cache"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DB 打开一个独立的内存 SQLite 数据库并迁移 models，作为 global.GVA_DB 使用；
+// 同时使用空日志、不连接 Redis、使用新的本地黑名单缓存。
+// 测试结束时恢复 GVA_DB、GVA_LOG、GVA_REDIS、GVA_CONFIG 与 BlackCache，
+// 测试中对 global.GVA_CONFIG 的修改无需自行还原
+func DB(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存库每个连接相互独立，限制为单连接；不在测试结束时关闭，casbin 等单例仍可能持有该连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if len(models) > 0 {
+		if err = db.AutoMigrate(models...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldDB, oldLog, oldRedis, oldConfig, oldBlackCache := global.GVA_DB, global.GVA_LOG, global.GVA_REDIS, global.GVA_CONFIG, global.BlackCache
+	t.Cleanup(func() {
+		global.GVA_DB, global.GVA_LOG, global.GVA_REDIS, global.GVA_CONFIG, global.BlackCache = oldDB, oldLog, oldRedis, oldConfig, oldBlackCache
+	})
+	global.GVA_DB = db
+	global.GVA_LOG = zap.NewNop()
+	global.GVA_REDIS = nil
+	global.BlackCache = local_cache.NewCache()
+	return db
+}
+
+// Redis 启动一个内存 Redis 作为 global.GVA_REDIS，测试结束时关闭并恢复原有的客户端。
+// 与 DB 同时使用时需在 DB 之后调用
+func Redis(t testing.TB) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	oldRedis := global.GVA_REDIS
+	t.Cleanup(func() {
+		client.Close()
+		global.GVA_REDIS = oldRedis
+	})
+	global.GVA_REDIS = client
+	return mr
+}
@@ -53,7 +53,23 @@ func (e *ensureTables) MigrateTable(ctx context.Context) (context.Context, error
 		sysModel.Condition{},
 		sysModel.JoinTemplate{},
 		sysModel.SysParams{},
+		sysModel.SysParamsHistory{},
 		sysModel.SysVersion{},
+		sysModel.SysUserTotp{},
+		sysModel.SysUserSession{},
+		sysModel.SysRefreshToken{},
+		sysModel.SysLoginLog{},
+		sysModel.SysPasswordHistory{},
+		sysModel.SysUserOidc{},
+		sysModel.SysDataRule{},
+		sysModel.SysExportJob{},
+		sysModel.SysExportSchedule{},
+		sysModel.SysExportScheduleRun{},
+		sysModel.SysOperationArchive{},
+		sysModel.SysAuditLog{},
+		sysModel.SysAuditCheckpoint{},
+		sysModel.SysMigration{},
+		sysModel.SysTaskLease{},
 		adapter.CasbinRule{},
 
 		example.ExaFile{},
@@ -6,6 +6,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -56,7 +57,23 @@ func RegisterTables() {
 		system.Condition{},
 		system.JoinTemplate{},
 		system.SysParams{},
+		system.SysParamsHistory{},
 		system.SysVersion{},
+		system.SysUserTotp{},
+		system.SysUserSession{},
+		system.SysRefreshToken{},
+		system.SysLoginLog{},
+		system.SysPasswordHistory{},
+		system.SysUserOidc{},
+		system.SysDataRule{},
+		system.SysExportJob{},
+		system.SysExportSchedule{},
+		system.SysExportScheduleRun{},
+		system.SysOperationArchive{},
+		system.SysAuditLog{},
+		system.SysAuditCheckpoint{},
+		system.SysMigration{},
+		system.SysTaskLease{},
 
 		example.ExaFile{},
 		example.ExaCustomer{},
@@ -75,5 +92,17 @@ func RegisterTables() {
 		global.GVA_LOG.Error("register biz_table failed", zap.Error(err))
 		os.Exit(0)
 	}
+	operationRecordFulltext(db)
 	global.GVA_LOG.Info("register table success")
 }
+
+// operationRecordFulltext MySQL 下为操作记录的请求与响应内容建立 ngram 全文索引，失败时搜索退回 LIKE
+func operationRecordFulltext(db *gorm.DB) {
+	if db.Dialector.Name() != "mysql" || db.Migrator().HasIndex(&system.SysOperationRecord{}, systemService.OperationRecordFulltextIndex) {
+		return
+	}
+	err := db.Exec("CREATE FULLTEXT INDEX " + systemService.OperationRecordFulltextIndex + " ON sys_operation_records (body, resp) WITH PARSER ngram").Error
+	if err != nil {
+		global.GVA_LOG.Warn("create operation record fulltext index failed", zap.Error(err))
+	}
+}
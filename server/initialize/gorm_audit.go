@@ -0,0 +1,40 @@
+package initialize
+
+import (
+	adapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
+	sysModel "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"gorm.io/gorm"
+)
+
+// RegisterAuditLog 注册敏感数据变更审计
+// 登记的模型在通过 GORM 新建、更新、删除时，在同一事务中追加带哈希链的审计记录
+// 操作人取自请求上下文，未使用 WithContext(ctx) 的语句记录为 0，见 middleware.WithSysUserID
+func RegisterAuditLog() {
+	db := global.GVA_DB
+	if db == nil {
+		return
+	}
+	if global.GVA_CONFIG.AuditLog.HashKey == "" {
+		global.GVA_LOG.Error("未配置 audit-log.hash-key，审计表的写入将被拒绝")
+	}
+	// 权限相关的表：接口权限、角色菜单与角色按钮，关联表没有主键，以整行内容标识记录
+	system.RegisterAuditModel(example.MerUser{}, sysModel.SysUser{}, sysModel.SysAuthority{},
+		adapter.CasbinRule{}, sysModel.SysAuthorityMenu{})
+	// 角色按钮表没有 TableName 方法，表名按当前数据库的命名规则（前缀、单复数）解析
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&sysModel.SysAuthorityBtn{}); err == nil {
+		system.RegisterAuditTable(stmt.Schema.Table)
+	}
+
+	audit := system.AuditLogServiceApp
+	// 在所有权过滤之后读取变更前的数据，保证与实际影响的行一致
+	db.Callback().Update().Before("gorm:update").After("owner:filter").Register("audit:before", audit.BeforeChange)
+	db.Callback().Delete().Before("gorm:delete").After("owner:filter").Register("audit:before", audit.BeforeChange)
+	// 在提交前写入，与业务数据同时提交或回滚
+	db.Callback().Create().Before("gorm:commit_or_rollback_transaction").Register("audit:create", audit.AfterCreate)
+	db.Callback().Update().Before("gorm:commit_or_rollback_transaction").Register("audit:update", audit.AfterUpdate)
+	db.Callback().Delete().Before("gorm:commit_or_rollback_transaction").Register("audit:delete", audit.AfterDelete)
+}
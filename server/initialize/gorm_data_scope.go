@@ -0,0 +1,44 @@
+package initialize
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+)
+
+// registerDataScopes 登记参与行级数据权限的资源，新增业务表后在此处声明可配置规则的字段
+func registerDataScopes() {
+	owner := system.DataScopeField{Name: "owner", Label: "管理用户", Column: "sys_user_id"}
+	merType := system.DataScopeField{Name: "merType", Label: "商户类型", Column: "mer_type"}
+	merchant := system.DataScopeField{Name: "merchant", Label: "商户", Column: "mer_id"}
+
+	system.RegisterDataScope(system.DataScopeResource{
+		Name:   example.MerUser{}.TableName(),
+		Label:  "商户",
+		Fields: []system.DataScopeField{owner, merType, {Name: "merchant", Label: "商户", Column: "id"}},
+	})
+	system.RegisterDataScope(system.DataScopeResource{
+		Name:   example.MerPayOrder{}.TableName(),
+		Label:  "支付订单",
+		Fields: []system.DataScopeField{owner, merType, merchant},
+	})
+	system.RegisterDataScope(system.DataScopeResource{
+		Name:   example.MerPaySuspense{}.TableName(),
+		Label:  "挂账收款",
+		Fields: []system.DataScopeField{owner, merType, merchant},
+	})
+	// 媒体库按附件分类树授权，可限定角色只能查看某些分类及其子分类下的文件
+	system.RegisterDataScope(system.DataScopeResource{
+		Name:  example.ExaFileUploadAndDownload{}.TableName(),
+		Label: "媒体库文件",
+		Fields: []system.DataScopeField{{Name: "category", Label: "分类", Column: "class_id", Tree: &system.DataScopeTree{
+			Table:        example.ExaAttachmentCategory{}.TableName(),
+			IdColumn:     "id",
+			ParentColumn: "pid",
+		}}},
+	})
+	system.RegisterDataScope(system.DataScopeResource{
+		Name:   example.RiskRuleHit{}.TableName(),
+		Label:  "风控命中记录",
+		Fields: []system.DataScopeField{owner, merchant},
+	})
+}
@@ -2,17 +2,21 @@ package initialize
 
 import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"gorm.io/gorm"
 )
 
-// RegisterOwnerFilter 注册基于请求上下文中的 sys_user_id 的自动所有权过滤与写入
-// - Query/Update/Delete：自动追加 Where("sys_user_id = ?")
+// RegisterOwnerFilter 注册基于请求上下文中用户的数据权限过滤与写入
+// - Query/Update/Delete：角色在该表上配置了行级规则时追加规则条件，否则自动追加 Where("sys_user_id = ?")
 // - Create：在模型包含 SysUserId 字段的情况下，自动写入 sys_user_id
+// 仅对使用 WithContext(ctx) 且上下文中带有用户的语句生效，见 middleware.WithSysUserID
 func RegisterOwnerFilter() {
 	db := global.GVA_DB
 	if db == nil {
 		return
 	}
+	registerDataScopes()
 
 	// 在查询/更新/删除前追加过滤条件
 	db.Callback().Query().Before("gorm:query").Register("owner:filter", func(tx *gorm.DB) {
@@ -35,17 +39,18 @@ func addOwnerFilter(tx *gorm.DB) {
 	if tx == nil || tx.Statement == nil || tx.Statement.Schema == nil {
 		return
 	}
-	v := tx.Statement.Context.Value("sys_user_id")
-	var uid int64
-	switch vv := v.(type) {
-	case uint:
-		uid = int64(vv)
-	case int64:
-		uid = vv
-	default:
+	ctx := tx.Statement.Context
+	uid := utils.ContextUserID(ctx)
+	if uid == 0 || utils.DataScopeSkipped(ctx) {
 		return
 	}
-	if uid == 0 {
+	// 角色配置了行级规则时由规则决定可见范围，替代默认的所有权过滤
+	applied, err := system.DataRuleServiceApp.ApplyDataScope(tx)
+	if err != nil {
+		_ = tx.AddError(err)
+		return
+	}
+	if applied {
 		return
 	}
 	if _, ok := tx.Statement.Schema.FieldsByDBName["sys_user_id"]; !ok {
@@ -58,21 +63,12 @@ func setOwnerOnCreate(tx *gorm.DB) {
 	if tx == nil || tx.Statement == nil || tx.Statement.Schema == nil {
 		return
 	}
-	v := tx.Statement.Context.Value("sys_user_id")
-	var uid int64
-	switch vv := v.(type) {
-	case uint:
-		uid = int64(vv)
-	case int64:
-		uid = vv
-	default:
-		return
-	}
+	uid := utils.ContextUserID(tx.Statement.Context)
 	if uid == 0 {
 		return
 	}
 	// 仅当模型包含 SysUserId 字段时才写入
 	if f := tx.Statement.Schema.LookUpField("SysUserId"); f != nil {
-		tx.Statement.SetColumn("SysUserId", uid, true)
+		tx.Statement.SetColumn("SysUserId", int64(uid), true)
 	}
 }
@@ -0,0 +1,203 @@
+package initialize
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
+	sysModel "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+type scopedOrder struct {
+	ID        uint
+	SysUserId uint
+	Region    string
+}
+
+func (scopedOrder) TableName() string { return "test_scoped_orders" }
+
+// setupOwnerFilter 在内存库上注册与线上相同的回调，测试数据：
+//   - 角色 100 可查看角色 200 的数据；用户 1 属于 100，用户 2 属于 200，用户 3 属于 300
+//   - 附件分类树: 1 -> 2 -> 3, 1 -> 4, 5 独立；文件 n 位于分类 n
+func setupOwnerFilter(t *testing.T) {
+	t.Helper()
+	db := globaltest.DB(t, &sysModel.SysUser{}, &sysModel.SysAuthority{}, &sysModel.SysUserAuthority{}, &sysModel.SysDataRule{},
+		&scopedOrder{}, &example.ExaAttachmentCategory{}, &example.ExaFileUploadAndDownload{})
+	global.GVA_CONFIG.System.UseStrictAuth = false
+	RegisterOwnerFilter()
+	system.RegisterDataScope(system.DataScopeResource{Name: "test_scoped_orders", Label: "测试订单", Fields: []system.DataScopeField{
+		{Name: "owner", Label: "创建人", Column: "sys_user_id"},
+		{Name: "region", Label: "区域", Column: "region"},
+	}})
+
+	db.Create(&[]sysModel.SysAuthority{{AuthorityId: 100, AuthorityName: "主管"}, {AuthorityId: 200, AuthorityName: "员工"}, {AuthorityId: 300, AuthorityName: "访客"}})
+	if err := db.Model(&sysModel.SysAuthority{AuthorityId: 100}).Association("DataAuthorityId").
+		Append(&sysModel.SysAuthority{AuthorityId: 200}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]sysModel.SysUser{
+		{GVA_MODEL: global.GVA_MODEL{ID: 1}, Username: "lead", AuthorityId: 100},
+		{GVA_MODEL: global.GVA_MODEL{ID: 2}, Username: "staff", AuthorityId: 200},
+		{GVA_MODEL: global.GVA_MODEL{ID: 3}, Username: "other", AuthorityId: 300},
+	})
+	db.Create(&[]sysModel.SysUserAuthority{{SysUserId: 1, SysAuthorityAuthorityId: 100}, {SysUserId: 2, SysAuthorityAuthorityId: 200}})
+	db.Create(&[]scopedOrder{
+		{ID: 1, SysUserId: 1, Region: "east"},
+		{ID: 2, SysUserId: 2, Region: "west"},
+		{ID: 3, SysUserId: 3, Region: "east"},
+		{ID: 4, SysUserId: 3, Region: "north"},
+	})
+	db.Create(&[]example.ExaAttachmentCategory{
+		{GVA_MODEL: global.GVA_MODEL{ID: 1}, Name: "根"},
+		{GVA_MODEL: global.GVA_MODEL{ID: 2}, Name: "图片", Pid: 1},
+		{GVA_MODEL: global.GVA_MODEL{ID: 3}, Name: "头像", Pid: 2},
+		{GVA_MODEL: global.GVA_MODEL{ID: 4}, Name: "文档", Pid: 1},
+		{GVA_MODEL: global.GVA_MODEL{ID: 5}, Name: "其他"},
+	})
+	for i := 1; i <= 5; i++ {
+		db.Create(&example.ExaFileUploadAndDownload{GVA_MODEL: global.GVA_MODEL{ID: uint(i)}, Name: "file", ClassId: i})
+	}
+	// 规则缓存是进程级的，清除其他测试留下的规则
+	for _, id := range []uint{100, 200, 300} {
+		setRules(t, id)
+	}
+}
+
+func setRules(t *testing.T, authorityId uint, rules ...sysModel.SysDataRule) {
+	t.Helper()
+	if err := system.DataRuleServiceApp.SetDataRules(888, authorityId, rules); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func userContext(userId, authorityId uint) context.Context {
+	ctx := context.WithValue(context.Background(), utils.ContextUserIDKey, userId)
+	if authorityId != 0 {
+		ctx = context.WithValue(ctx, utils.ContextAuthorityIDKey, authorityId)
+	}
+	return ctx
+}
+
+func visibleIds(t *testing.T, ctx context.Context, model interface{}) []uint {
+	t.Helper()
+	var ids []uint
+	if err := global.GVA_DB.WithContext(ctx).Model(model).Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func assertIds(t *testing.T, name string, got []uint, want ...uint) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %v, want %v", name, got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestOwnerFilter_DataRules(t *testing.T) {
+	setupOwnerFilter(t)
+	orders := func(userId, authorityId uint) []uint {
+		return visibleIds(t, userContext(userId, authorityId), &scopedOrder{})
+	}
+
+	// 未配置规则时回退到所有权过滤
+	assertIds(t, "owner fallback", orders(3, 300), 3, 4)
+
+	setRules(t, 300, sysModel.SysDataRule{Resource: "test_scoped_orders", Field: "region", Operator: sysModel.DataRuleIn, Values: []string{"east"}})
+	assertIds(t, "in", orders(3, 300), 1, 3)
+
+	setRules(t, 300, sysModel.SysDataRule{Resource: "test_scoped_orders", Field: "region", Operator: sysModel.DataRuleNotIn, Values: []string{"east"}})
+	assertIds(t, "not_in", orders(3, 300), 2, 4)
+
+	setRules(t, 300, sysModel.SysDataRule{Resource: "test_scoped_orders", Field: "owner", Operator: sysModel.DataRuleSelf})
+	assertIds(t, "self", orders(3, 300), 3, 4)
+
+	// 多条规则同时满足
+	setRules(t, 300,
+		sysModel.SysDataRule{Resource: "test_scoped_orders", Field: "region", Operator: sysModel.DataRuleIn, Values: []string{"east", "north"}},
+		sysModel.SysDataRule{Resource: "test_scoped_orders", Field: "owner", Operator: sysModel.DataRuleSelf},
+	)
+	assertIds(t, "in and self", orders(3, 300), 3, 4)
+
+	// 上下文中没有角色时按用户当前角色查找规则
+	assertIds(t, "authority lookup", orders(3, 0), 3, 4)
+
+	// 跳过数据权限的内部查询不受限制
+	assertIds(t, "skipped", visibleIds(t, utils.WithoutDataScope(userContext(3, 300)), &scopedOrder{}), 1, 2, 3, 4)
+
+	// 规则同样作用于更新
+	err := global.GVA_DB.WithContext(userContext(3, 300)).Model(&scopedOrder{}).Where("1 = 1").Update("region", "south").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changed int64
+	global.GVA_DB.Model(&scopedOrder{}).Where("region = ?", "south").Count(&changed)
+	if changed != 2 {
+		t.Fatalf("updated rows = %d, want 2", changed)
+	}
+}
+
+func TestOwnerFilter_SelfOrSubordinates(t *testing.T) {
+	setupOwnerFilter(t)
+	setRules(t, 100, sysModel.SysDataRule{Resource: "test_scoped_orders", Field: "owner", Operator: sysModel.DataRuleSelfOrSubordinates})
+
+	assertIds(t, "lead", visibleIds(t, userContext(1, 100), &scopedOrder{}), 1, 2)
+	// 员工角色没有规则，仍只看到自己的数据
+	assertIds(t, "staff", visibleIds(t, userContext(2, 200), &scopedOrder{}), 2)
+}
+
+func TestOwnerFilter_FileCategorySubtree(t *testing.T) {
+	setupOwnerFilter(t)
+	files := func() []uint {
+		return visibleIds(t, userContext(3, 300), &example.ExaFileUploadAndDownload{})
+	}
+
+	// 媒体库文件没有归属字段，未配置规则时不受限制
+	assertIds(t, "no rule", files(), 1, 2, 3, 4, 5)
+
+	setRules(t, 300, sysModel.SysDataRule{Resource: "exa_file_upload_and_downloads", Field: "category", Operator: sysModel.DataRuleSubtree, Values: []string{"2"}})
+	assertIds(t, "subtree", files(), 2, 3)
+
+	setRules(t, 300, sysModel.SysDataRule{Resource: "exa_file_upload_and_downloads", Field: "category", Operator: sysModel.DataRuleSubtree, Values: []string{"1"}})
+	assertIds(t, "root subtree", files(), 1, 2, 3, 4)
+
+	// 子树根节点不存在时只匹配根节点本身
+	setRules(t, 300, sysModel.SysDataRule{Resource: "exa_file_upload_and_downloads", Field: "category", Operator: sysModel.DataRuleSubtree, Values: []string{"99"}})
+	assertIds(t, "missing root", files())
+}
+
+func TestOwnerFilter_FailClosed(t *testing.T) {
+	setupOwnerFilter(t)
+
+	// 直接写入引用了已失效字段或未知类型的规则（缓存未命中的角色），查询应返回空而不是放开全部数据
+	global.GVA_DB.Create(&sysModel.SysAuthority{AuthorityId: 301, AuthorityName: "失效字段"})
+	global.GVA_DB.Create(&sysModel.SysDataRule{AuthorityId: 301, Resource: "test_scoped_orders", Field: "removed", Operator: sysModel.DataRuleIn, Values: []string{"x"}})
+	assertIds(t, "unknown field", visibleIds(t, userContext(3, 301), &scopedOrder{}))
+
+	global.GVA_DB.Create(&sysModel.SysAuthority{AuthorityId: 302, AuthorityName: "未知类型"})
+	global.GVA_DB.Create(&sysModel.SysDataRule{AuthorityId: 302, Resource: "test_scoped_orders", Field: "region", Operator: "like", Values: []string{"e%"}})
+	assertIds(t, "unknown operator", visibleIds(t, userContext(3, 302), &scopedOrder{}))
+}
+
+func TestOwnerFilter_RequestCache(t *testing.T) {
+	setupOwnerFilter(t)
+	setRules(t, 100, sysModel.SysDataRule{Resource: "test_scoped_orders", Field: "owner", Operator: sysModel.DataRuleSelfOrSubordinates})
+
+	// 同一请求内的多条语句只查询一次用户角色与下属，期间的变更在下一个请求生效
+	ctx := utils.WithRequestCache(userContext(1, 0))
+	assertIds(t, "first statement", visibleIds(t, ctx, &scopedOrder{}), 1, 2)
+	global.GVA_DB.Create(&sysModel.SysUserAuthority{SysUserId: 3, SysAuthorityAuthorityId: 200})
+	assertIds(t, "cached statement", visibleIds(t, ctx, &scopedOrder{}), 1, 2)
+	assertIds(t, "next request", visibleIds(t, utils.WithRequestCache(userContext(1, 0)), &scopedOrder{}), 1, 2, 3, 4)
+}
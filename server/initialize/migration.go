@@ -0,0 +1,65 @@
+package initialize
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"go.uber.org/zap"
+)
+
+// Migrate 开启 migration.auto-up 时在启动时执行待执行的数据库迁移，失败时退出
+func Migrate() {
+	if global.GVA_DB == nil || !global.GVA_CONFIG.Migration.AutoUp {
+		return
+	}
+	applied, err := systemService.MigrationServiceApp.Migrate(context.Background(), "")
+	if err != nil {
+		global.GVA_LOG.Error("migrate failed", zap.Strings("applied", applied), zap.Error(err))
+		os.Exit(1)
+	}
+	if len(applied) > 0 {
+		global.GVA_LOG.Info("migrate success", zap.Strings("applied", applied))
+	}
+}
+
+// MigrationCommand 执行命令行的 -migrate 参数：up 执行迁移，down 回滚迁移，status 查看迁移状态
+func MigrationCommand(cmd string, target string, steps int) error {
+	ctx := context.Background()
+	switch cmd {
+	case "up":
+		applied, err := systemService.MigrationServiceApp.Migrate(ctx, target)
+		for _, v := range applied {
+			fmt.Println("applied:", v)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("没有待执行的迁移")
+		}
+		return err
+	case "down":
+		rolledBack, err := systemService.MigrationServiceApp.RollbackMigrations(ctx, steps, target)
+		for _, v := range rolledBack {
+			fmt.Println("rolled back:", v)
+		}
+		return err
+	case "status":
+		list, err := systemService.MigrationServiceApp.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tBATCH\tAPPLIED AT")
+		for _, item := range list {
+			var batch, appliedAt string
+			if item.AppliedAt != nil {
+				batch, appliedAt = fmt.Sprint(item.Batch), item.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Version, item.Name, item.Status, batch, appliedAt)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("未知的迁移命令 %s，仅支持 up、down、status", cmd)
+}
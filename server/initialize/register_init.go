@@ -2,6 +2,7 @@ package initialize
 
 import (
 	_ "github.com/flipped-aurora/gin-vue-admin/server/source/example"
+	_ "github.com/flipped-aurora/gin-vue-admin/server/source/migration"
 	_ "github.com/flipped-aurora/gin-vue-admin/server/source/system"
 )
 
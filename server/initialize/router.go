@@ -94,6 +94,7 @@ func Routers() *gin.Engine {
 		systemRouter.InitMenuRouter(PrivateGroup)                           // 注册menu路由
 		systemRouter.InitSystemRouter(PrivateGroup)                         // system相关路由
 		systemRouter.InitSysVersionRouter(PrivateGroup)                     // 发版相关路由
+		systemRouter.InitSysMigrationRouter(PrivateGroup)                   // 数据库迁移
 		systemRouter.InitCasbinRouter(PrivateGroup)                         // 权限相关路由
 		systemRouter.InitAutoCodeRouter(PrivateGroup, PublicGroup)          // 创建自动化代码
 		systemRouter.InitAuthorityRouter(PrivateGroup)                      // 注册角色路由
@@ -18,5 +18,7 @@ func initBizRouter(routers ...*gin.RouterGroup) {
 		exampleRouter.InitMerUserRouter(privateGroup, publicGroup)
 		exampleRouter.InitSysUserConfigRouter(privateGroup, publicGroup) // 占位方法，保证文件可以正确加载，避免go空变量检测报错，请勿删除。
 		exampleRouter.InitMerPayOrderRouter(privateGroup, publicGroup)
+		exampleRouter.InitMerPaySuspenseRouter(privateGroup)
+		exampleRouter.InitRiskRuleHitRouter(privateGroup)
 	}
 }
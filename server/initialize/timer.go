@@ -2,11 +2,14 @@ package initialize
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/task"
 
 	"github.com/robfig/cron/v3"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
 )
 
 func Timer() {
@@ -19,7 +22,88 @@ func Timer() {
 			if err != nil {
 				fmt.Println("timer error:", err)
 			}
-		}, "定时清理数据库【日志，黑名单】内容", option...)
+		}, "定时清理数据库【日志】内容", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 按保留时长清理并归档操作记录，多实例部署时只由领取到租约的实例执行
+		_, err = global.GVA_Timer.AddTaskByFunc("ArchiveOperationRecord", "@daily", func() {
+			if ok, err := task.TryLease(global.GVA_DB, "ArchiveOperationRecord", time.Hour); err != nil || !ok {
+				if err != nil {
+					fmt.Println("timer error:", err)
+				}
+				return
+			}
+			if err := task.ArchiveOperationRecords(global.GVA_DB); err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时清理并归档操作记录", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 清理已过期的jwt黑名单
+		_, err = global.GVA_Timer.AddTaskByFunc("ClearJwtBlacklist", "@hourly", func() {
+			if _, err := system.JwtServiceApp.PruneExpired(); err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时清理已过期的jwt黑名单", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 清理已过期的刷新令牌与会话
+		_, err = global.GVA_Timer.AddTaskByFunc("ClearUserSession", "@daily", func() {
+			if err := system.UserSessionServiceApp.PruneExpired(); err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时清理已过期的刷新令牌与登录会话", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 领取并执行异步导出任务
+		_, err = global.GVA_Timer.AddTaskByFunc("ExportJob", "@every 5s", system.ExportJobServiceApp.PollExportJobs, "异步导出任务", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 清理下载链接已过期的导出文件
+		_, err = global.GVA_Timer.AddTaskByFunc("ClearExportJob", "@hourly", func() {
+			if err := system.ExportJobServiceApp.PruneExpired(); err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时清理已过期的导出文件", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 注册定时报表，并定期同步其他实例上的修改
+		if err := system.ExportScheduleServiceApp.SyncExportSchedules(); err != nil {
+			fmt.Println("timer error:", err)
+		}
+		_, err = global.GVA_Timer.AddTaskByFunc("SyncExportSchedule", "@every 1m", func() {
+			if err := system.ExportScheduleServiceApp.SyncExportSchedules(); err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "同步定时报表计划", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 每天保存审计链校验点，链尾记录被删除时校验可以发现；多实例部署时只由领取到租约的实例执行
+		_, err = global.GVA_Timer.AddTaskByFunc("AuditCheckpoint", "@daily", func() {
+			if ok, err := task.TryLease(global.GVA_DB, "AuditCheckpoint", time.Hour); err != nil || !ok {
+				if err != nil {
+					fmt.Println("timer error:", err)
+				}
+				return
+			}
+			if _, err := system.AuditLogServiceApp.CreateAuditCheckpoint(); err != nil {
+				fmt.Println("timer error:", err)
+			}
+		}, "定时保存审计链校验点", option...)
 		if err != nil {
 			fmt.Println("add timer error:", err)
 		}
@@ -28,6 +112,9 @@ func Timer() {
 		task.InitHealthChecker()
 		task.StartHealthCheckTask()
 
+		// 订单过期延迟队列
+		task.StartOrderExpireTask()
+
 		// 其他定时任务定在这里 参考上方使用方法
 
 		//_, err := global.GVA_Timer.AddTaskByFunc("定时任务标识", "corn表达式", func() {
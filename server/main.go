@@ -1,6 +1,10 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/core"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/initialize"
@@ -13,6 +17,13 @@ import (
 //go:generate go mod tidy
 //go:generate go mod download
 
+// 数据库迁移命令，在 core.Viper() 中与 -c 一起解析
+var (
+	migrateCmd    = flag.String("migrate", "", "执行数据库迁移后退出: up|down|status")
+	migrateTarget = flag.String("migrate-target", "", "up 时执行到该版本（含），down 时回滚该版本之后的全部迁移")
+	migrateSteps  = flag.Int("migrate-steps", 1, "down 且未指定 -migrate-target 时回滚的迁移个数")
+)
+
 // 这部分 @Tag 设置用于排序, 需要排序的接口请按照下面的格式添加
 // swag init 对 @Tag 只会从入口文件解析, 默认 main.go
 // 也可通过 --generalInfo flag 指定其他文件
@@ -30,6 +41,15 @@ import (
 func main() {
 	// 初始化系统
 	initializeSystem()
+	if *migrateCmd != "" {
+		if err := initialize.MigrationCommand(*migrateCmd, *migrateTarget, *migrateSteps); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	// 执行待执行的数据库迁移
+	initialize.Migrate()
 	// 运行服务器
 	core.RunServer()
 }
@@ -44,6 +64,8 @@ func initializeSystem() {
 	global.GVA_DB = initialize.Gorm() // gorm连接数据库
 	// 注册基于上下文的所有权过滤插件
 	initialize.RegisterOwnerFilter()
+	// 注册敏感数据变更审计
+	initialize.RegisterAuditLog()
 	initialize.Timer()
 	initialize.DBList()
 	initialize.SetupHandlers() // 注册全局函数
@@ -24,7 +24,8 @@ func CasbinHandler() gin.HandlerFunc {
 		e := utils.GetCasbin() // 判断策略中是否存在
 		success, _ := e.Enforce(sub, obj, act)
 		if !success {
-			response.FailWithDetailed(gin.H{}, "权限不足", c)
+			// 返回参与校验的角色、路径与方法，便于在权限模拟中排查缺少的策略
+			response.FailWithDetailed(gin.H{"authorityId": waitUse.AuthorityId, "path": obj, "method": act}, "权限不足", c)
 			c.Abort()
 			return
 		}
@@ -2,11 +2,8 @@ package middleware
 
 import (
 	"errors"
-	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
-	"github.com/golang-jwt/jwt/v5"
-	"strconv"
-	"time"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/gin-gonic/gin"
@@ -43,36 +40,16 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		// 已登录用户被管理员禁用 需要使该用户的jwt失效 此处比较消耗性能 如果需要 请自行打开
-		// 用户被删除的逻辑 需要优化 此处比较消耗性能 如果需要 请自行打开
-
-		//if user, err := userService.FindUserByUuid(claims.UUID.String()); err != nil || user.Enable == 2 {
-		//	_ = jwtService.JsonInBlacklist(system.JwtBlacklist{Jwt: token})
-		//	response.FailWithDetailed(gin.H{"reload": true}, err.Error(), c)
-		//	c.Abort()
-		//}
-		c.Set("claims", claims)
-		if claims.ExpiresAt.Unix()-time.Now().Unix() < claims.BufferTime {
-			dr, _ := utils.ParseDuration(global.GVA_CONFIG.JWT.ExpiresTime)
-			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(dr))
-			newToken, _ := j.CreateTokenByOldToken(token, *claims)
-			newClaims, _ := j.ParseToken(newToken)
-			c.Header("new-token", newToken)
-			c.Header("new-expires-at", strconv.FormatInt(newClaims.ExpiresAt.Unix(), 10))
-			utils.SetToken(c, newToken, int(dr.Seconds()))
-			if global.GVA_CONFIG.System.UseMultipoint {
-				// 记录新的活跃jwt
-				_ = utils.SetRedisJWT(newToken, newClaims.Username)
-			}
+		// 用户被冻结、删除、强制下线或角色变更时，通过会话登记表与会话版本使已签发的令牌失效
+		if err := system.UserSessionServiceApp.CheckSession(c.Request.Context(), claims); err != nil {
+			response.NoAuth(err.Error(), c)
+			utils.ClearToken(c)
+			c.Abort()
+			return
 		}
+		c.Set("claims", claims)
+		// 访问令牌到期后不再自动续期，由客户端通过 /base/refresh 使用刷新令牌换取新令牌
 		c.Next()
-
-		if newToken, exists := c.Get("new-token"); exists {
-			c.Header("new-token", newToken.(string))
-		}
-		if newExpiresAt, exists := c.Get("new-expires-at"); exists {
-			c.Header("new-expires-at", newExpiresAt.(string))
-		}
 	}
 }
 
@@ -83,6 +60,5 @@ func JWTAuth() gin.HandlerFunc {
 //@return: bool
 
 func isBlacklist(jwt string) bool {
-	_, ok := global.BlackCache.Get(jwt)
-	return ok
+	return system.JwtServiceApp.IsBlacklist(jwt)
 }
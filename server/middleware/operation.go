@@ -15,6 +15,7 @@ import (
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -72,14 +73,17 @@ func OperationRecord() gin.HandlerFunc {
 			UserID: userId,
 		}
 
+		redactFields := global.GVA_CONFIG.OperationRecord.RedactFields
 		// 上传文件时候 中间件日志进行裁断操作
 		if strings.Contains(c.GetHeader("Content-Type"), "multipart/form-data") {
 			record.Body = "[文件]"
 		} else {
 			if len(body) > bufferSize {
 				record.Body = "[超出记录长度]"
+			} else if strings.Contains(c.GetHeader("Content-Type"), "application/x-www-form-urlencoded") {
+				record.Body = utils.RedactForm(string(body), redactFields)
 			} else {
-				record.Body = string(body)
+				record.Body = utils.RedactJSON(string(body), redactFields)
 			}
 		}
 
@@ -96,7 +100,7 @@ func OperationRecord() gin.HandlerFunc {
 		record.ErrorMessage = c.Errors.ByType(gin.ErrorTypePrivate).String()
 		record.Status = c.Writer.Status()
 		record.Latency = latency
-		record.Resp = writer.body.String()
+		record.Resp = utils.RedactJSON(writer.body.String(), redactFields)
 
 		if strings.Contains(c.Writer.Header().Get("Pragma"), "public") ||
 			strings.Contains(c.Writer.Header().Get("Expires"), "0") ||
@@ -112,9 +116,7 @@ func OperationRecord() gin.HandlerFunc {
 				record.Body = "超出记录长度"
 			}
 		}
-		if err := global.GVA_DB.Create(&record).Error; err != nil {
-			global.GVA_LOG.Error("create operation record error:", zap.Error(err))
-		}
+		systemService.OperationRecordServiceApp.CreateSysOperationRecord(record)
 	}
 }
 
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
@@ -47,6 +48,7 @@ func PermanentTokenAuth() gin.HandlerFunc {
 		c.Set("sys_user_id", permanentToken.UserID)
 		c.Set("token_type", "permanent")
 		c.Set("permanent_token", permanentToken)
+		c.Set("sandbox", permanentToken.Sandbox)
 
 		global.GVA_LOG.Info("api token验证成功",
 			zap.Uint("sys_user_id", permanentToken.UserID),
@@ -96,6 +98,7 @@ func PermanentTokenOrJWT() gin.HandlerFunc {
 			c.Set("user_id", permanentToken.UserID)
 			c.Set("token_type", "permanent")
 			c.Set("permanent_token", permanentToken)
+			c.Set("sandbox", permanentToken.Sandbox)
 
 			global.GVA_LOG.Info("永久token验证成功",
 				zap.Uint("user_id", permanentToken.UserID))
@@ -140,6 +143,22 @@ func IsPermanentToken(c *gin.Context) bool {
 	return exists && tokenType == "permanent"
 }
 
+// IsSandboxRequest 检查当前请求是否使用沙箱永久token
+func IsSandboxRequest(c *gin.Context) bool {
+	return c.GetBool("sandbox")
+}
+
+// GetRequestKeyID 获取调用方密钥标识，用于按密钥统计调用频率
+// 永久token取其摘要，避免明文token出现在计数键和日志中；JWT请求使用用户ID
+func GetRequestKeyID(c *gin.Context) string {
+	if value, exists := c.Get("permanent_token"); exists {
+		if permanentToken, ok := value.(*utils.PermanentToken); ok && permanentToken != nil {
+			return "perm:" + utils.MD5V([]byte(permanentToken.Token))[:16]
+		}
+	}
+	return fmt.Sprintf("user:%d", utils.GetUserID(c))
+}
+
 // GetUserIDFromTokenOrJWT 统一获取用户ID，支持永久token和JWT
 func GetUserIDFromTokenOrJWT(c *gin.Context) uint {
 	// 首先尝试从永久token中获取用户ID
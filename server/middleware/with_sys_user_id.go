@@ -6,16 +6,28 @@ import (
     "github.com/gin-gonic/gin"
 )
 
-// WithSysUserID 从 JWT claims 中提取用户ID，注入到 gin.Context 和 Request.Context
+// WithSysUserID 从 JWT claims 中提取用户ID与角色ID，注入到 gin.Context 和 Request.Context
 // 在使用该中间件的路由中，后续的处理函数可以通过 utils.GetUserID(c) 获取，
-// 同时也可以从 request.Context 中通过 key "sys_user_id" 获取。
+// 同时也可以从 request.Context 中通过 utils.ContextUserID / utils.ContextAuthorityID 获取。
+// 永久token请求没有 claims，使用 PermanentTokenAuth 写入的 sys_user_id，角色由数据权限回调按用户查询。
 func WithSysUserID() gin.HandlerFunc {
     return func(c *gin.Context) {
         userID := utils.GetUserID(c)
+        authorityID := utils.GetUserAuthorityId(c)
+        if userID == 0 {
+            if v, ok := c.Get(utils.ContextUserIDKey); ok {
+                userID, _ = v.(uint)
+            }
+            authorityID = 0
+        }
         // 注入到 gin.Context（已有 GetUserID 基于 claims，可直接使用）
         // 额外注入到 Request.Context，方便 service 层通过 ctx 访问
-        ctx := context.WithValue(c.Request.Context(), "sys_user_id", userID)
+        ctx := context.WithValue(c.Request.Context(), utils.ContextUserIDKey, userID)
+        ctx = utils.WithRequestCache(ctx)
+        if authorityID != 0 {
+            ctx = context.WithValue(ctx, utils.ContextAuthorityIDKey, authorityID)
+        }
         c.Request = c.Request.WithContext(ctx)
         c.Next()
     }
-}
\ No newline at end of file
+}
@@ -2,6 +2,7 @@
 package example
 
 import (
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/shopspring/decimal"
 	"time"
 )
@@ -22,9 +23,38 @@ type MerPayOrder struct {
 	UpdateTime     *time.Time       `json:"updateTime" form:"updateTime" gorm:"comment:更新时间;column:update_time;autoUpdateTime"` //更新时间
 	Remarks        *string          `json:"remarks" form:"remarks" gorm:"comment:订单备注;column:remarks;size:255;"`                //订单备注
 	MerType        *string          `json:"merType" form:"merType" gorm:"comment:商户类型;column:mer_type;size:255;"`               //商户类型
+	Expires        *int64           `json:"expires" form:"expires" gorm:"comment:过期时间(秒);column:expires;"`                      //过期时间(秒)
+	Currency       *string          `json:"currency" form:"currency" gorm:"comment:币种;column:currency;size:3;default:CNY;"`     //币种
+	TradeNo        *string          `json:"tradeNo" form:"tradeNo" gorm:"index;comment:渠道流水号;column:trade_no;size:128;"`        //渠道流水号
+	CallbackUrl    *string          `json:"-" form:"-" gorm:"comment:回调地址;column:callback_url;size:512;"`                       //回调地址
 }
 
 // TableName merPayOrder表 MerPayOrder自定义表名 mer_pay_order
 func (MerPayOrder) TableName() string {
 	return "mer_pay_order"
 }
+
+// CurrencyCode 返回订单币种，历史订单未记录币种时为默认币种
+func (m *MerPayOrder) CurrencyCode() string {
+	if m.Currency == nil {
+		return utils.DefaultCurrency
+	}
+	return utils.NormalizeCurrency(*m.Currency)
+}
+
+// ExpireAt 返回订单过期时间，未记录创建时间时返回零值
+func (m *MerPayOrder) ExpireAt() time.Time {
+	if m.CreateTime == nil {
+		return time.Time{}
+	}
+	expires := int64(300) // 默认5分钟
+	if m.Expires != nil {
+		expires = *m.Expires
+	}
+	return m.CreateTime.Add(time.Duration(expires) * time.Second)
+}
+
+// IsSandbox 是否为沙箱订单
+func (m *MerPayOrder) IsSandbox() bool {
+	return m.MerType != nil && *m.MerType == MerTypeSandbox
+}
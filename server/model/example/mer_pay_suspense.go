@@ -0,0 +1,56 @@
+package example
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// MerPaySuspense 挂账收款：渠道已到账但未能自动匹配到订单的收款，等待人工处理
+type MerPaySuspense struct {
+	Id             *int64           `json:"id" form:"id" gorm:"primarykey;column:id;"`                                                       //id字段
+	SysUserId      *int64           `json:"sysUserId" form:"sysUserId" gorm:"index;comment:管理ID;column:sys_user_id;"`                        //管理ID
+	MerId          *int64           `json:"merId" form:"merId" gorm:"uniqueIndex:idx_mer_trade;comment:商户id;column:mer_id;"`                 //商户id
+	MerType        *string          `json:"merType" form:"merType" gorm:"comment:商户类型;column:mer_type;size:255;"`                            //商户类型
+	TradeNo        *string          `json:"tradeNo" form:"tradeNo" gorm:"uniqueIndex:idx_mer_trade;comment:渠道流水号;column:trade_no;size:128;"` //渠道流水号
+	Payer          *string          `json:"payer" form:"payer" gorm:"comment:付款人标识;column:payer;size:255;"`
+	Amount         *decimal.Decimal `json:"amount" form:"amount" gorm:"type:decimal(10,2);comment:到账金额;column:amount;"`           //到账金额
+	Currency       *string          `json:"currency" form:"currency" gorm:"comment:币种;column:currency;size:3;default:CNY;"`       //币种
+	PayTime        *time.Time       `json:"payTime" form:"payTime" gorm:"comment:支付时间;column:pay_time;"`                          //支付时间
+	State          *int8            `json:"state" form:"state" gorm:"index;comment:处理状态(0:待处理 1:已匹配);column:state;default:0;"`    //处理状态
+	MatchedOrderId *int64           `json:"matchedOrderId" form:"matchedOrderId" gorm:"comment:匹配的订单ID;column:matched_order_id;"` //匹配的订单ID
+	CreateTime     *time.Time       `json:"createTime" form:"createTime" gorm:"comment:创建时间;column:create_time;autoCreateTime"`   //创建时间
+	UpdateTime     *time.Time       `json:"updateTime" form:"updateTime" gorm:"comment:更新时间;column:update_time;autoUpdateTime"`   //更新时间
+}
+
+// CurrencyCode 返回挂账收款币种
+func (m *MerPaySuspense) CurrencyCode() string {
+	if m.Currency == nil {
+		return utils.DefaultCurrency
+	}
+	return utils.NormalizeCurrency(*m.Currency)
+}
+
+// TableName 挂账收款表
+func (MerPaySuspense) TableName() string {
+	return "mer_pay_suspense"
+}
+
+// MerPayMatchLog 人工匹配记录，每次将挂账收款关联到订单时写入一条，用于审计
+type MerPayMatchLog struct {
+	Id         *int64           `json:"id" form:"id" gorm:"primarykey;column:id;"`                                          //id字段
+	SuspenseId *int64           `json:"suspenseId" form:"suspenseId" gorm:"index;comment:挂账收款ID;column:suspense_id;"`       //挂账收款ID
+	OrderId    *int64           `json:"orderId" form:"orderId" gorm:"index;comment:订单ID;column:order_id;"`                  //订单ID
+	PrevState  *int8            `json:"prevState" form:"prevState" gorm:"comment:匹配前订单状态;column:prev_state;"`               //匹配前订单状态
+	TradeNo    *string          `json:"tradeNo" form:"tradeNo" gorm:"comment:渠道流水号;column:trade_no;size:128;"`              //渠道流水号
+	Amount     *decimal.Decimal `json:"amount" form:"amount" gorm:"type:decimal(10,2);comment:到账金额;column:amount;"`         //到账金额
+	OperatorId *int64           `json:"operatorId" form:"operatorId" gorm:"index;comment:操作人ID;column:operator_id;"`        //操作人ID
+	OperatorIp *string          `json:"operatorIp" form:"operatorIp" gorm:"comment:操作人IP;column:operator_ip;size:64;"`      //操作人IP
+	Remarks    *string          `json:"remarks" form:"remarks" gorm:"comment:匹配说明;column:remarks;size:255;"`                //匹配说明
+	CreateTime *time.Time       `json:"createTime" form:"createTime" gorm:"comment:创建时间;column:create_time;autoCreateTime"` //创建时间
+}
+
+// TableName 人工匹配记录表
+func (MerPayMatchLog) TableName() string {
+	return "mer_pay_match_log"
+}
@@ -4,6 +4,8 @@ package example
 import (
 	"errors"
 	"fmt"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"strings"
 	"time"
@@ -21,11 +23,12 @@ type MerUser struct {
 	QrCode           *string `json:"qrCode" form:"qrCode" gorm:"type:MEDIUMTEXT;comment:收款码;column:qr_code"`                                   // 收款码
 	Key              *string `json:"key" form:"key" gorm:"comment:请求密钥;column:key;size:255;"`                                                  //请求密钥
 	IsDel            *string `json:"isDel" form:"isDel" gorm:"comment:是否删除(1: 删除 0:未删除);column:is_del;size:255;default:0;"`                    //是否删除(1: 删除 0:未删除)
-	MaxAmount        *int64  `json:"maxAmount" form:"maxAmount" gorm:"index;comment:最大金额;column:max_amount;default:1000;"`                     //最大金额
-	MinAmount        *int64  `json:"minAmount" form:"minAmount" gorm:"index;comment:最小金额;column:min_amount;default:1;"`                        //最小金额
-	MaxDecimalAmount *int32  `json:"maxDecimalAmount" form:"maxDecimalAmount" gorm:"index;comment:最大金额;column:max_decimal_amount;default:99;"` //最大金额
-	MinDecimalAmount *int32  `json:"minDecimalAmount" form:"minDecimalAmount" gorm:"index;comment:最小金额;column:min_decimal_amount;default:1;"`  //最小金额
-	MerName          *string `json:"merName" form:"merName" gorm:"comment:商户名称;column:mer_name;size:255;"`                                     //商户名称
+	MaxAmount        *decimal.Decimal `json:"maxAmount" form:"maxAmount" gorm:"index;type:decimal(10,2);comment:最大金额;column:max_amount;default:1000;"`             //最大金额
+	MinAmount        *decimal.Decimal `json:"minAmount" form:"minAmount" gorm:"index;type:decimal(10,2);comment:最小金额;column:min_amount;default:1;"`                //最小金额
+	MaxDecimalAmount *int32           `json:"maxDecimalAmount" form:"maxDecimalAmount" gorm:"index;comment:最大尾数(最小货币单位);column:max_decimal_amount;default:99;"` //最大尾数(最小货币单位)
+	MinDecimalAmount *int32           `json:"minDecimalAmount" form:"minDecimalAmount" gorm:"index;comment:最小尾数(最小货币单位);column:min_decimal_amount;default:1;"`  //最小尾数(最小货币单位)
+	Currency         *string          `json:"currency" form:"currency" gorm:"comment:收款币种;column:currency;size:3;default:CNY;"`                                   //收款币种
+	MerName          *string          `json:"merName" form:"merName" gorm:"comment:商户名称;column:mer_name;size:255;"`                                             //商户名称
 
 	CreateTime *time.Time `json:"createTime" form:"createTime" gorm:"comment:创建时间;column:create_time;autoCreateTime;"` //创建时间
 	UpdateTime *time.Time `json:"updateTime" form:"updateTime" gorm:"comment:更新时间;column:update_time;autoUpdateTime;"` //更新时间
@@ -40,12 +43,19 @@ func (MerUser) TableName() string {
 const (
 	MerTypeXingYi     = "0"
 	MerTypeFuZhangGui = "1"
+	MerTypeSandbox    = "9"
 )
 
 // AllowedMerTypes 持有可用的枚举值，便于后续扩展
 var AllowedMerTypes = map[string]string{
 	MerTypeXingYi:     "星驿",
 	MerTypeFuZhangGui: "富掌柜",
+	MerTypeSandbox:    "沙箱",
+}
+
+// IsSandbox 是否为沙箱商户
+func (m *MerUser) IsSandbox() bool {
+	return m.MerType != nil && *m.MerType == MerTypeSandbox
 }
 
 // RegisterMerType 用于新增允许的枚举值
@@ -70,10 +80,49 @@ func (m *MerUser) validateMerType() error {
 	return errors.New("invalid mer_type, allowed: " + strings.Join(opts, ", "))
 }
 
+// validateAmountRange 校验币种与金额上下限，金额必须符合币种的最小货币单位精度
+func (m *MerUser) validateAmountRange() error {
+	currency := ""
+	if m.Currency != nil {
+		if _, err := utils.LookupCurrency(*m.Currency); err != nil {
+			return err
+		}
+		currency = *m.Currency
+	}
+	if m.MinAmount != nil {
+		if err := utils.ValidateAmount(*m.MinAmount, currency); err != nil {
+			return fmt.Errorf("最小金额不合法: %w", err)
+		}
+	}
+	if m.MaxAmount != nil {
+		if err := utils.ValidateAmount(*m.MaxAmount, currency); err != nil {
+			return fmt.Errorf("最大金额不合法: %w", err)
+		}
+	}
+	if m.MinAmount != nil && m.MaxAmount != nil && m.MinAmount.GreaterThan(*m.MaxAmount) {
+		return errors.New("最小金额不能大于最大金额")
+	}
+	return nil
+}
+
+// CurrencyCode 返回商户收款币种，未配置时为默认币种
+func (m *MerUser) CurrencyCode() string {
+	if m.Currency == nil {
+		return utils.DefaultCurrency
+	}
+	return utils.NormalizeCurrency(*m.Currency)
+}
+
 func (m *MerUser) BeforeCreate(tx *gorm.DB) (err error) {
-	return m.validateMerType()
+	if err = m.validateMerType(); err != nil {
+		return err
+	}
+	return m.validateAmountRange()
 }
 
 func (m *MerUser) BeforeUpdate(tx *gorm.DB) (err error) {
-	return m.validateMerType()
+	if err = m.validateMerType(); err != nil {
+		return err
+	}
+	return m.validateAmountRange()
 }
@@ -10,7 +10,8 @@ type MerPayOrderSearch struct {
 	MerName         *string     `json:"merName" form:"merName"`
 	State           *bool       `json:"state" form:"state"`
 	RequestAmmount  *string     `json:"requestAmmount" form:"requestAmmount"`
-	Ammount         *float64    `json:"ammount" form:"ammount"`
+	Ammount         *string     `json:"ammount" form:"ammount"`
+	Currency        *string     `json:"currency" form:"currency"`
 	PayTimeRange    []time.Time `json:"payTimeRange" form:"payTimeRange[]"`
 	CreateTimeRange []time.Time `json:"createTimeRange" form:"createTimeRange[]"`
 	UpdateTimeRange []time.Time `json:"updateTimeRange" form:"updateTimeRange[]"`
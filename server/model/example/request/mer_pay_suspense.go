@@ -0,0 +1,21 @@
+package request
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"time"
+)
+
+type MerPaySuspenseSearch struct {
+	MerId        *int64      `json:"merId" form:"merId"`
+	TradeNo      *string     `json:"tradeNo" form:"tradeNo"`
+	State        *int8       `json:"state" form:"state"`
+	PayTimeRange []time.Time `json:"payTimeRange" form:"payTimeRange[]"`
+	request.PageInfo
+}
+
+// MatchSuspenseReq 人工将挂账收款匹配到订单
+type MatchSuspenseReq struct {
+	SuspenseId int64  `json:"suspenseId" binding:"required"` // 挂账收款ID
+	OrderId    int64  `json:"orderId" binding:"required"`    // 订单ID
+	Remarks    string `json:"remarks" binding:"required"`    // 匹配说明
+}
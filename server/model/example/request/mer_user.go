@@ -2,6 +2,7 @@ package request
 
 import (
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/shopspring/decimal"
 	"time"
 )
 
@@ -14,7 +15,14 @@ type MerUserSearch struct {
 }
 
 type PaymentQrCodeResponse struct {
-	CallBack   *string    `json:"qrcodeCode"`
-	Amount     *float64   `json:"amount"`
-	CreateTime *time.Time `json:"createTime"`
+	CallBack   *string          `json:"qrcodeCode"`
+	Amount     *decimal.Decimal `json:"amount"`
+	CreateTime *time.Time       `json:"createTime"`
+}
+
+// SandboxPayReq 沙箱模拟支付请求
+type SandboxPayReq struct {
+	MerId  int64  `json:"merId" binding:"required"`  // 沙箱商户ID
+	Amount string `json:"amount" binding:"required"` // 支付金额，如 5.23
+	Payer  string `json:"payer"`                     // 付款人标识，可选
 }
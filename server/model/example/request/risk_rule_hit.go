@@ -0,0 +1,14 @@
+package request
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"time"
+)
+
+type RiskRuleHitSearch struct {
+	Rule            *string     `json:"rule" form:"rule"`
+	Subject         *string     `json:"subject" form:"subject"`
+	MerId           *int64      `json:"merId" form:"merId"`
+	CreateTimeRange []time.Time `json:"createTimeRange" form:"createTimeRange[]"`
+	request.PageInfo
+}
@@ -16,15 +16,17 @@ type MerUserListItem struct {
 	Remarks          *string    `json:"remarks" gorm:"column:remarks"`
 	QrCode           *string    `json:"qrCode" gorm:"column:qr_code"`
 	Password         *string    `json:"password" gorm:"column:password"`
-	MaxAmount        *int64     `json:"maxAmount" form:"maxAmount"`
-	MinAmount        *int64     `json:"minAmount" form:"minAmount"`
-	MaxDecimalAmount *int32     `json:"maxDecimalAmount" form:"maxDecimalAmount"`
-	MinDecimalAmount *int32     `json:"minDecimalAmount" form:"minDecimalAmount"`
+	MaxAmount        *decimal.Decimal `json:"maxAmount" form:"maxAmount"`
+	MinAmount        *decimal.Decimal `json:"minAmount" form:"minAmount"`
+	MaxDecimalAmount *int32           `json:"maxDecimalAmount" form:"maxDecimalAmount"`
+	MinDecimalAmount *int32           `json:"minDecimalAmount" form:"minDecimalAmount"`
+	Currency         *string          `json:"currency" gorm:"column:currency"`
 }
 
 type PaymentQrCodeResponse struct {
 	QrcodeCode *string          `json:"qrcodeCode"`
 	Amount     *decimal.Decimal `json:"amount"`
+	Currency   *string          `json:"currency"`
 	CreateTime *string          `json:"createTime"`
 	OrderId    *string          `json:"orderId"`
 	UniqueId   *int64           `json:"uniqueId"`
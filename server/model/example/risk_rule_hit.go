@@ -0,0 +1,30 @@
+package example
+
+import (
+	"time"
+)
+
+// 风控规则名称
+const (
+	RiskRuleIpVelocity    = "ip_velocity"    // 单个IP下单频率
+	RiskRuleKeyVelocity   = "key_velocity"   // 单个密钥下单频率
+	RiskRulePendingLimit  = "pending_limit"  // 商户待支付订单数
+	RiskRuleAmountBlocked = "amount_blocked" // 金额黑名单
+	RiskRulePayerBlocked  = "payer_blocked"  // 拉黑付款人
+)
+
+// RiskRuleHit 风控规则命中记录
+type RiskRuleHit struct {
+	Id         *int64     `json:"id" form:"id" gorm:"primarykey;column:id;"`                                                //id字段
+	SysUserId  *int64     `json:"sysUserId" form:"sysUserId" gorm:"index;comment:管理ID;column:sys_user_id;"`                 //管理ID
+	Rule       *string    `json:"rule" form:"rule" gorm:"index;comment:命中规则;column:rule;size:64;"`                          //命中规则
+	Subject    *string    `json:"subject" form:"subject" gorm:"comment:命中对象(IP/密钥/商户/付款人);column:subject;size:255;"`        //命中对象
+	MerId      *int64     `json:"merId" form:"merId" gorm:"comment:商户id;column:mer_id;"`                                    //商户id
+	Detail     *string    `json:"detail" form:"detail" gorm:"comment:命中详情;column:detail;size:512;"`                         //命中详情
+	CreateTime *time.Time `json:"createTime" form:"createTime" gorm:"index;comment:创建时间;column:create_time;autoCreateTime"` //创建时间
+}
+
+// TableName 风控规则命中记录表
+func (RiskRuleHit) TableName() string {
+	return "risk_rule_hit"
+}
@@ -1,10 +1,13 @@
 package request
 
+import "github.com/shopspring/decimal"
+
 type PayQrcodeParms struct {
-	MerId       *int64 `json:"merId"`
-	PayAmmount  int64  `json:"payAmmount"`
-	OrderId     string `json:"orderId"`
-	CreateTime  string `json:"createTime"`
-	Expires     int64  `json:"expires"`
-	CallbackUrl string `json:"callbackUrl"` // 回调URL
+	MerId       *int64          `json:"merId"`
+	PayAmmount  decimal.Decimal `json:"payAmmount"` // 支付金额（主货币单位），支持 5、"5.00" 等写法，精度不得超过币种最小单位
+	Currency    string          `json:"currency"`   // 币种，ISO 4217 代码，为空时默认 CNY
+	OrderId     string          `json:"orderId"`
+	CreateTime  string          `json:"createTime"`
+	Expires     int64           `json:"expires"`
+	CallbackUrl string          `json:"callbackUrl"` // 回调URL
 }
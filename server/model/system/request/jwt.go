@@ -13,9 +13,11 @@ type CustomClaims struct {
 }
 
 type BaseClaims struct {
-	UUID        uuid.UUID
-	ID          uint
-	Username    string
-	NickName    string
-	AuthorityId uint
+	UUID           uuid.UUID
+	ID             uint
+	Username       string
+	NickName       string
+	AuthorityId    uint
+	SessionId      string // 登录会话（刷新令牌族）ID，用于会话列表与吊销
+	SessionVersion int64  // 签发时的用户会话版本，低于当前版本的令牌失效
 }
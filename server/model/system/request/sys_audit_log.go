@@ -0,0 +1,24 @@
+package request
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+type SysAuditLogSearch struct {
+	Table          string     `json:"table" form:"table"`
+	RecordID       string     `json:"recordID" form:"recordID"`
+	Action         string     `json:"action" form:"action"`
+	UserID         uint       `json:"userID" form:"userID"`
+	StartCreatedAt *time.Time `json:"startCreatedAt" form:"startCreatedAt"`
+	EndCreatedAt   *time.Time `json:"endCreatedAt" form:"endCreatedAt"`
+	request.PageInfo
+}
+
+// AuditCheckpoint 校验时使用的外部保存的校验点，Seq 为 0 时只使用库中保存的校验点
+type AuditCheckpoint struct {
+	Seq       uint64 `json:"seq" form:"seq"`
+	Hash      string `json:"hash" form:"hash"`
+	Signature string `json:"signature" form:"signature"`
+}
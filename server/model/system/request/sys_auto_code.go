@@ -47,6 +47,7 @@ type AutoCode struct {
 	HasSearchTimer      bool                   `json:"-"`
 	HasArray            bool                   `json:"-"`
 	HasExcel            bool                   `json:"-"`
+	HasDictLabel        bool                   `json:"-"`
 }
 
 type DataSource struct {
@@ -135,6 +136,9 @@ func (r *AutoCode) Pretreatment() error {
 		if r.Fields[i].DictType != "" {
 			dict[r.Fields[i].DictType] = ""
 		}
+		if r.Fields[i].HasDictLabel() {
+			r.HasDictLabel = true
+		}
 		if r.Fields[i].Sort {
 			r.NeedSort = true
 		}
@@ -248,6 +252,18 @@ type AutoCodeField struct {
 	FieldIndexType  string      `json:"fieldIndexType"`  // 索引类型
 }
 
+// HasDictLabel 字段是否生成字典翻译列，多值与文件类字段由前端按字典展示
+func (f *AutoCodeField) HasDictLabel() bool {
+	if f.DictType == "" {
+		return false
+	}
+	switch f.FieldType {
+	case "file", "pictures", "picture", "video", "array", "json", "richtext":
+		return false
+	}
+	return true
+}
+
 type AutoFunc struct {
 	Package         string `json:"package"`
 	FuncName        string `json:"funcName"`        // 方法名称
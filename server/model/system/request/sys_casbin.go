@@ -25,3 +25,10 @@ func DefaultCasbin() []CasbinInfo {
 		{Path: "/sysDictionary/findSysDictionary", Method: "GET"},
 	}
 }
+
+// CasbinSimulate 权限模拟请求
+type CasbinSimulate struct {
+	AuthorityId uint   `json:"authorityId"` // 角色ID
+	Path        string `json:"path"`        // 请求路径，可带路由前缀与查询参数
+	Method      string `json:"method"`      // 请求方法
+}
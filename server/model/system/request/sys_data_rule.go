@@ -0,0 +1,9 @@
+package request
+
+import "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+
+// SetDataRules 替换角色的行级数据权限规则
+type SetDataRules struct {
+	AuthorityId uint                 `json:"authorityId"` // 角色ID
+	Rules       []system.SysDataRule `json:"rules"`       // 规则列表，为空代表清除
+}
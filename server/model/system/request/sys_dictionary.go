@@ -0,0 +1,8 @@
+package request
+
+// DictionaryLookup 批量翻译字典值
+// Values 的键为字典 type，值为需要翻译的字典值，为空时返回该字典全部字典值的展示值
+type DictionaryLookup struct {
+	Locale string              `json:"locale"` // 语言标记，为空时使用请求头 Accept-Language
+	Values map[string][]string `json:"values" binding:"required"`
+}
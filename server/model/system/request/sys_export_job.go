@@ -0,0 +1,8 @@
+package request
+
+// CreateExportJob 创建异步导出任务
+type CreateExportJob struct {
+	TemplateID string `json:"templateID" form:"templateID"` // 模板标识
+	Params     string `json:"params" form:"params"`         // 导出参数，与同步导出的 params 相同
+	Format     string `json:"format" form:"format"`         // 导出格式 xlsx/csv/jsonl，默认 xlsx
+}
@@ -0,0 +1,15 @@
+package request
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+type SysExportScheduleSearch struct {
+	TemplateID string `json:"templateID" form:"templateID"`
+	request.PageInfo
+}
+
+type SysExportScheduleRunSearch struct {
+	ScheduleID uint `json:"scheduleID" form:"scheduleID" binding:"required"`
+	request.PageInfo
+}
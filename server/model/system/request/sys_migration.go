@@ -0,0 +1,12 @@
+package request
+
+// MigrateRequest 执行迁移，Target 为空时执行全部待执行的迁移，否则执行到该版本（含）为止
+type MigrateRequest struct {
+	Target string `json:"target" form:"target"`
+}
+
+// RollbackMigrationRequest 回滚迁移，Target 不为空时回滚该版本之后（不含）的全部迁移，否则按 Steps 回滚最近执行的迁移，默认 1 个
+type RollbackMigrationRequest struct {
+	Steps  int    `json:"steps" form:"steps"`
+	Target string `json:"target" form:"target"`
+}
@@ -1,11 +1,16 @@
 package request
 
 import (
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 )
 
 type SysOperationRecordSearch struct {
 	system.SysOperationRecord
+	StartCreatedAt *time.Time `json:"startCreatedAt" form:"startCreatedAt"`
+	EndCreatedAt   *time.Time `json:"endCreatedAt" form:"endCreatedAt"`
+	Keyword        string     `json:"keyword" form:"keyword"` // 在请求与响应内容中搜索
 	request.PageInfo
 }
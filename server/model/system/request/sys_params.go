@@ -12,3 +12,9 @@ type SysParamsSearch struct {
 	Key            string     `json:"key" form:"key" `
 	request.PageInfo
 }
+
+// SysParamsRollback 回滚参数到指定版本
+type SysParamsRollback struct {
+	ID      uint `json:"ID" binding:"required"`      // 参数ID
+	Version int  `json:"version" binding:"required"` // 目标版本
+}
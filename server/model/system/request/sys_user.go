@@ -35,7 +35,7 @@ type ChangePasswordReq struct {
 
 type ResetPassword struct {
 	ID       uint   `json:"ID" form:"ID"`
-	Password string `json:"password" form:"password" gorm:"comment:用户登录密码"` // 用户登录密码
+	Password string `json:"password" form:"password" gorm:"comment:用户登录密码"` // 用户登录密码，为空时生成随机密码
 }
 
 // SetUserAuth Modify user's auth structure
@@ -67,3 +67,66 @@ type GetUserList struct {
 	Phone    string `json:"phone" form:"phone"`
 	Email    string `json:"email" form:"email"`
 }
+
+// LoginTotp 两步验证登录第二步
+type LoginTotp struct {
+	TotpToken string `json:"totpToken"` // 第一步返回的登录凭证
+	Code      string `json:"code"`      // 动态码或恢复码
+}
+
+// TotpCode 提交两步验证动态码
+type TotpCode struct {
+	Code string `json:"code"` // 动态码或恢复码
+}
+
+// SetRequire2FA 设置角色是否强制两步验证
+type SetRequire2FA struct {
+	AuthorityId uint `json:"authorityId"` // 角色ID
+	Require2FA  bool `json:"require2FA"`  // 是否强制
+}
+
+// ChangeExpiredPassword 登录时修改过期或被重置的密码
+type ChangeExpiredPassword struct {
+	Username    string `json:"username"`    // 用户名
+	Password    string `json:"password"`    // 当前密码
+	NewPassword string `json:"newPassword"` // 新密码
+	Captcha     string `json:"captcha"`     // 验证码
+	CaptchaId   string `json:"captchaId"`   // 验证码ID
+}
+
+// OidcCallback 单点登录回调参数
+type OidcCallback struct {
+	Code  string `json:"code"`  // 授权码
+	State string `json:"state"` // 发起授权时生成的 state
+}
+
+// RefreshToken 刷新访问令牌
+type RefreshToken struct {
+	RefreshToken string `json:"refreshToken"` // 刷新令牌
+}
+
+// RevokeSession 下线指定会话
+type RevokeSession struct {
+	SessionId string `json:"sessionId"` // 会话ID
+}
+
+// KillUserSession 管理员强制下线用户会话
+type KillUserSession struct {
+	ID        uint   `json:"ID"`        // 用户ID
+	SessionId string `json:"sessionId"` // 会话ID，为空时下线全部会话
+}
+
+// UnlockLogin 管理员解除登录锁定，ID 与 Ip 至少填写一项
+type UnlockLogin struct {
+	ID uint   `json:"ID"` // 用户ID
+	Ip string `json:"ip"` // 被限制的IP
+}
+
+// SysLoginLogSearch 登录记录查询
+type SysLoginLogSearch struct {
+	UserId   uint   `json:"userId" form:"userId"`     // 用户ID
+	Username string `json:"username" form:"username"` // 登录用户名
+	Ip       string `json:"ip" form:"ip"`             // 登录IP
+	Success  *bool  `json:"success" form:"success"`   // 是否成功，不传则查询全部
+	common.PageInfo
+}
@@ -15,26 +15,54 @@ type SysVersionSearch struct {
 
 // ExportVersionRequest 导出版本请求结构体
 type ExportVersionRequest struct {
-	VersionName string `json:"versionName" binding:"required"` // 版本名称
-	VersionCode string `json:"versionCode" binding:"required"` // 版本号
-	Description string `json:"description"`                    // 版本描述
-	MenuIds     []uint `json:"menuIds"`                        // 选中的菜单ID列表
-	ApiIds      []uint `json:"apiIds"`                         // 选中的API ID列表
-	DictIds     []uint `json:"dictIds"`                        // 选中的字典ID列表
+	VersionName  string `json:"versionName" binding:"required"` // 版本名称
+	VersionCode  string `json:"versionCode" binding:"required"` // 版本号
+	Description  string `json:"description"`                    // 版本描述
+	MenuIds      []uint `json:"menuIds"`                        // 选中的菜单ID列表
+	ApiIds       []uint `json:"apiIds"`                         // 选中的API ID列表
+	DictIds      []uint `json:"dictIds"`                        // 选中的字典ID列表
+	AuthorityIds []uint `json:"authorityIds"`                   // 选中的角色ID列表，导出其API权限与按钮权限
+	TemplateIds  []uint `json:"templateIds"`                    // 选中的导出模板ID列表
 }
 
-// ImportVersionRequest 导入版本请求结构体
+// ImportVersionRequest 导入版本请求结构体，即版本包内容加上冲突处理方式
 type ImportVersionRequest struct {
-	VersionInfo      VersionInfo            `json:"version" binding:"required"` // 版本信息
-	ExportMenu       []system.SysBaseMenu   `json:"menus"`                      // 菜单数据，直接复用SysBaseMenu
-	ExportApi        []system.SysApi        `json:"apis"`                       // API数据，直接复用SysApi
-	ExportDictionary []system.SysDictionary `json:"dictionaries"`               // 字典数据，直接复用SysDictionary
+	VersionInfo        VersionInfo                `json:"version" binding:"required"` // 版本信息
+	ExportMenu         []system.SysBaseMenu       `json:"menus"`                      // 菜单数据，直接复用SysBaseMenu
+	ExportApi          []system.SysApi            `json:"apis"`                       // API数据，直接复用SysApi
+	ExportDictionary   []VersionDictionary        `json:"dictionaries"`               // 字典数据
+	ExportCasbin       []VersionCasbinRule        `json:"casbin"`                     // API权限
+	ExportAuthorityBtn []VersionAuthorityBtn      `json:"authorityBtns"`              // 按钮权限
+	ExportTemplate     []system.SysExportTemplate `json:"exportTemplates"`            // 导出模板，直接复用SysExportTemplate
+	Signature          string                     `json:"signature"`                  // 版本包签名
+	Strategy           string                     `json:"strategy"`                   // 已存在数据的默认处理方式 skip/overwrite/rename，默认skip
+	Resolutions        map[string]string          `json:"resolutions"`                // 单项处理方式，键为预览结果中的 kind:key
+}
+
+// VersionDictionary 版本包中的字典，字典项按层级放在 details 中；兼容旧版本包中扁平的 sysDictionaryDetails
+type VersionDictionary struct {
+	system.SysDictionaryTransfer
+	SysDictionaryDetails []system.SysDictionaryDetail `json:"sysDictionaryDetails,omitempty"`
+}
+
+// VersionCasbinRule 版本包中的API权限
+type VersionCasbinRule struct {
+	AuthorityId string `json:"authorityId"` // 角色ID
+	Path        string `json:"path"`        // api路径
+	Method      string `json:"method"`      // 方法
+}
+
+// VersionAuthorityBtn 版本包中的按钮权限，菜单与按钮按名称对应
+type VersionAuthorityBtn struct {
+	AuthorityId uint   `json:"authorityId"` // 角色ID
+	MenuName    string `json:"menuName"`    // 菜单路由name
+	BtnName     string `json:"btnName"`     // 按钮关键key
 }
 
 // VersionInfo 版本信息结构体
 type VersionInfo struct {
-	Name        string `json:"name" binding:"required"`        // 版本名称
-	Code        string `json:"code" binding:"required"`        // 版本号
-	Description string `json:"description"`                    // 版本描述
-	ExportTime  string `json:"exportTime"`                     // 导出时间
+	Name        string `json:"name" binding:"required"` // 版本名称
+	Code        string `json:"code" binding:"required"` // 版本号
+	Description string `json:"description"`             // 版本描述
+	ExportTime  string `json:"exportTime"`              // 导出时间
 }
@@ -0,0 +1,18 @@
+package response
+
+// AuditProblem 校验审计链时发现的问题
+type AuditProblem struct {
+	Seq     uint64 `json:"seq"`
+	Problem string `json:"problem"`
+}
+
+// AuditVerifyResult 审计链校验结果
+// 删除链尾记录无法通过链本身发现，校验时与库中保存及外部传入的校验点比对，CheckpointSeq 为比对过的最大校验点序号
+type AuditVerifyResult struct {
+	Valid         bool           `json:"valid"`
+	Total         int64          `json:"total"`
+	HeadSeq       uint64         `json:"headSeq"`
+	HeadHash      string         `json:"headHash"`
+	CheckpointSeq uint64         `json:"checkpointSeq"`
+	Problems      []AuditProblem `json:"problems"`
+}
@@ -1,9 +1,47 @@
 package response
 
 import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
 )
 
 type PolicyPathResponse struct {
 	Paths []request.CasbinInfo `json:"paths"`
 }
+
+// CasbinPolicyMatch 角色策略与请求的逐项比对结果
+type CasbinPolicyMatch struct {
+	Path          string `json:"path"`
+	Method        string `json:"method"`
+	PathMatched   bool   `json:"pathMatched"`
+	MethodMatched bool   `json:"methodMatched"`
+}
+
+// CasbinAllowedAuthority 拥有该接口权限的角色
+type CasbinAllowedAuthority struct {
+	AuthorityId   uint               `json:"authorityId"`
+	AuthorityName string             `json:"authorityName"`
+	Policy        request.CasbinInfo `json:"policy"`
+}
+
+// CasbinSimulateResponse 权限模拟结果
+type CasbinSimulateResponse struct {
+	AuthorityId        uint                     `json:"authorityId"`
+	Path               string                   `json:"path"`   // 去掉路由前缀后参与校验的路径
+	Method             string                   `json:"method"` // 参与校验的方法
+	Allowed            bool                     `json:"allowed"`
+	Reason             string                   `json:"reason"`
+	MatchedPolicy      *request.CasbinInfo      `json:"matchedPolicy"`      // 放行时命中的策略
+	UnmatchedPolicies  []CasbinPolicyMatch      `json:"unmatchedPolicies"`  // 该角色下路径或分组相近但未命中的策略
+	AllowedAuthorities []CasbinAllowedAuthority `json:"allowedAuthorities"` // 哪些角色可以访问
+	Api                *system.SysApi           `json:"api"`                // API管理中登记的接口，未登记为 null
+}
+
+// CasbinDiffResponse 更新角色api权限前的变更预览
+type CasbinDiffResponse struct {
+	AuthorityId  uint                 `json:"authorityId"`
+	Added        []request.CasbinInfo `json:"added"`
+	Removed      []request.CasbinInfo `json:"removed"`
+	Unchanged    int                  `json:"unchanged"`
+	NotInApiList []request.CasbinInfo `json:"notInApiList"` // 开启严格权限时会导致更新失败的接口
+}
@@ -0,0 +1,11 @@
+package response
+
+// DictionaryTreeNode 树形字典项，Label 为按请求语言解析后的展示值
+type DictionaryTreeNode struct {
+	ID       uint                  `json:"ID"`
+	Label    string                `json:"label"`
+	Value    string                `json:"value"`
+	Extend   string                `json:"extend"`
+	Sort     int                   `json:"sort"`
+	Children []*DictionaryTreeNode `json:"children,omitempty"`
+}
@@ -0,0 +1,9 @@
+package response
+
+import "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+
+// SysExportJobResponse 导出任务及其下载地址，任务完成且未过期时才有下载地址
+type SysExportJobResponse struct {
+	system.SysExportJob
+	DownloadUrl string `json:"downloadUrl"`
+}
@@ -0,0 +1,20 @@
+package response
+
+// ImportError 导入时被拒绝的行及原因，Row 为 Excel 中的行号
+type ImportError struct {
+	Row    int    `json:"row"`
+	Column string `json:"column"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// ImportResult 导入结果，试运行时只校验并统计将新增与更新的行数
+type ImportResult struct {
+	DryRun         bool          `json:"dryRun"`
+	Total          int           `json:"total"`   // 数据行数，不含空行
+	Created        int           `json:"created"` // 新增行数
+	Updated        int           `json:"updated"` // 按更新字段匹配到已有数据而更新的行数
+	Failed         int           `json:"failed"`  // 被拒绝的行数
+	Errors         []ImportError `json:"errors"`
+	ErrorReportUrl string        `json:"errorReportUrl"` // 错误报告下载地址，没有错误时为空
+}
@@ -0,0 +1,19 @@
+package response
+
+import "time"
+
+// 迁移状态
+const (
+	MigrationApplied = "applied" // 已执行
+	MigrationPending = "pending" // 待执行
+	MigrationMissing = "missing" // 已执行但当前程序中不存在，通常是切换到了旧版本程序
+)
+
+type MigrationStatus struct {
+	Version    string     `json:"version"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Reversible bool       `json:"reversible"` // 是否可回滚
+	Batch      int        `json:"batch,omitempty"`
+	AppliedAt  *time.Time `json:"appliedAt,omitempty"`
+}
@@ -9,7 +9,39 @@ type SysUserResponse struct {
 }
 
 type LoginResponse struct {
-	User      system.SysUser `json:"user"`
-	Token     string         `json:"token"`
-	ExpiresAt int64          `json:"expiresAt"`
+	User             system.SysUser `json:"user"`
+	Token            string         `json:"token"`
+	ExpiresAt        int64          `json:"expiresAt"`
+	RefreshToken     string         `json:"refreshToken"`     // 刷新令牌，仅可使用一次，刷新后返回新的刷新令牌
+	RefreshExpiresAt int64          `json:"refreshExpiresAt"` // 刷新令牌过期时间（毫秒）
+}
+
+// LoginTotpResponse 密码校验通过但需要两步验证时返回
+type LoginTotpResponse struct {
+	NeedTotp   bool   `json:"needTotp"`   // 需要输入动态码
+	NeedEnroll bool   `json:"needEnroll"` // 角色强制两步验证但用户尚未绑定，需先绑定
+	TotpToken  string `json:"totpToken"`  // 第二步提交时携带的登录凭证
+}
+
+// LoginPasswordResponse 密码校验通过但必须先修改密码时返回
+type LoginPasswordResponse struct {
+	NeedChangePassword bool `json:"needChangePassword"` // 需要通过 /base/changeExpiredPassword 修改密码后再登录
+	Expired            bool `json:"expired"`            // true 为密码已过期，false 为管理员重置后首次登录
+}
+
+// OidcAuthorizeResponse 单点登录跳转地址
+type OidcAuthorizeResponse struct {
+	Url string `json:"url"`
+}
+
+// ResetPasswordResponse 重置密码结果，未指定密码时返回生成的随机密码
+type ResetPasswordResponse struct {
+	Password string `json:"password,omitempty"`
+}
+
+// TotpStatusResponse 用户两步验证状态
+type TotpStatusResponse struct {
+	Enabled                bool `json:"enabled"`                // 是否已启用
+	Required               bool `json:"required"`               // 角色是否强制要求
+	RemainingRecoveryCodes int  `json:"remainingRecoveryCodes"` // 剩余恢复码数量
 }
@@ -5,10 +5,52 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
 )
 
-// ExportVersionResponse 导出版本响应结构体
+// ExportVersionResponse 导出版本响应结构体，签名在序列化后追加
 type ExportVersionResponse struct {
-	Version      request.VersionInfo    `json:"version"`      // 版本信息
-	Menus        []system.SysBaseMenu   `json:"menus"`        // 菜单数据，直接复用SysBaseMenu
-	Apis         []system.SysApi        `json:"apis"`         // API数据，直接复用SysApi
-	Dictionaries []system.SysDictionary `json:"dictionaries"` // 字典数据，直接复用SysDictionary
+	Version         request.VersionInfo           `json:"version"`         // 版本信息
+	Menus           []system.SysBaseMenu          `json:"menus"`           // 菜单数据，直接复用SysBaseMenu
+	Apis            []system.SysApi               `json:"apis"`            // API数据，直接复用SysApi
+	Dictionaries    []request.VersionDictionary   `json:"dictionaries"`    // 字典数据
+	Casbin          []request.VersionCasbinRule   `json:"casbin"`          // API权限
+	AuthorityBtns   []request.VersionAuthorityBtn `json:"authorityBtns"`   // 按钮权限
+	ExportTemplates []system.SysExportTemplate    `json:"exportTemplates"` // 导出模板
+}
+
+// 版本包中单项数据与目标环境的比较结果
+const (
+	VersionItemNew      = "new"      // 目标环境不存在
+	VersionItemSame     = "same"     // 与目标环境一致
+	VersionItemConflict = "conflict" // 目标环境已存在且内容不同
+	VersionItemInvalid  = "invalid"  // 无法导入，原因见 message
+)
+
+// VersionDiffItem 版本包中单项数据的比较结果与处理方式
+type VersionDiffItem struct {
+	Kind    string               `json:"kind"`              // menu/api/dictionary/casbin/authorityBtn/exportTemplate
+	Key     string               `json:"key"`               // 数据标识
+	Status  string               `json:"status"`            // new/same/conflict/invalid
+	Action  string               `json:"action"`            // create/skip/overwrite/rename
+	Target  string               `json:"target,omitempty"`  // 重命名后的标识
+	Changes []VersionFieldChange `json:"changes,omitempty"` // 冲突的字段
+	Message string               `json:"message,omitempty"`
+}
+
+// VersionFieldChange 冲突字段在目标环境与版本包中的值
+type VersionFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// VersionPreview 导入预览
+type VersionPreview struct {
+	Signed  bool              `json:"signed"`  // 版本包签名有效
+	Summary map[string]int    `json:"summary"` // 各处理方式的数量
+	Items   []VersionDiffItem `json:"items"`
+}
+
+// VersionImportResult 导入结果，VersionID 为导入记录，可用于回滚
+type VersionImportResult struct {
+	VersionPreview
+	VersionID uint `json:"versionID"`
 }
@@ -0,0 +1,47 @@
+package system
+
+import (
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// SysAuditLog 敏感数据变更审计记录，只允许追加
+// 每条记录的 Hash 由上一条的 Hash 与本条内容计算得到，删除或修改任意一条都会使校验失败
+type SysAuditLog struct {
+	ID          uint      `json:"ID" gorm:"primarykey"`
+	Seq         uint64    `json:"seq" gorm:"uniqueIndex;not null;comment:链上序号"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"index;comment:变更时间"`
+	Table       string    `json:"table" gorm:"column:table_name;index:idx_audit_record;size:64;comment:表名"`
+	RecordID    string    `json:"recordID" gorm:"index:idx_audit_record;size:64;comment:记录主键"`
+	Action      string    `json:"action" gorm:"size:16;comment:操作 create/update/delete"`
+	UserID      uint      `json:"userID" gorm:"index;comment:操作用户ID，0 表示无请求上下文"`
+	AuthorityID uint      `json:"authorityID" gorm:"comment:操作用户角色ID"`
+	Before      string    `json:"before" gorm:"type:text;comment:变更前数据"`
+	After       string    `json:"after" gorm:"type:text;comment:变更后数据"`
+	Diff        string    `json:"diff" gorm:"type:text;comment:变更字段"`
+	PrevHash    string    `json:"prevHash" gorm:"size:64;comment:上一条记录的哈希"`
+	Hash        string    `json:"hash" gorm:"size:64;comment:本条记录的哈希"`
+}
+
+func (SysAuditLog) TableName() string {
+	return "sys_audit_logs"
+}
+
+// SysAuditCheckpoint 审计链校验点，记录某一时刻的链尾序号与哈希并签名
+// 直接删除链尾记录无法通过链本身发现，校验时链中对应序号的哈希必须与校验点一致，且链尾不能低于校验点
+type SysAuditCheckpoint struct {
+	ID        uint      `json:"ID" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"comment:创建时间"`
+	Seq       uint64    `json:"seq" gorm:"uniqueIndex;not null;comment:链尾序号"`
+	Hash      string    `json:"hash" gorm:"size:64;comment:链尾哈希"`
+	Signature string    `json:"signature" gorm:"size:64;comment:校验点签名"`
+}
+
+func (SysAuditCheckpoint) TableName() string {
+	return "sys_audit_checkpoints"
+}
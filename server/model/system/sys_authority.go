@@ -15,7 +15,8 @@ type SysAuthority struct {
 	Children        []SysAuthority  `json:"children" gorm:"-"`
 	SysBaseMenus    []SysBaseMenu   `json:"menus" gorm:"many2many:sys_authority_menus;"`
 	Users           []SysUser       `json:"-" gorm:"many2many:sys_user_authority;"`
-	DefaultRouter   string          `json:"defaultRouter" gorm:"comment:默认菜单;default:dashboard"` // 默认菜单(默认dashboard)
+	DefaultRouter   string          `json:"defaultRouter" gorm:"comment:默认菜单;default:dashboard"`                 // 默认菜单(默认dashboard)
+	Require2FA      bool            `json:"require2FA" gorm:"column:require_2fa;default:false;comment:是否强制两步验证"` // 该角色用户登录时必须通过两步验证
 }
 
 func (SysAuthority) TableName() string {
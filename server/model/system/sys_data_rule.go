@@ -0,0 +1,29 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 行级数据权限规则类型
+const (
+	DataRuleIn                 = "in"                   // 字段值在给定集合中
+	DataRuleNotIn              = "not_in"               // 字段值不在给定集合中
+	DataRuleSelf               = "self"                 // 字段为当前用户ID
+	DataRuleSelfOrSubordinates = "self_or_subordinates" // 字段为当前用户或下属的用户ID，下属为角色资源权限中所列角色下的用户
+	DataRuleSubtree            = "subtree"              // 字段值位于树形数据中以给定节点为根的子树内（含根节点）
+)
+
+// SysDataRule 角色在某个资源上的行级数据权限规则，同一角色同一资源的多条规则同时生效（AND）
+type SysDataRule struct {
+	global.GVA_MODEL
+	AuthorityId uint     `json:"authorityId" gorm:"index:idx_data_rule_scope;comment:角色ID"`
+	Resource    string   `json:"resource" gorm:"size:64;index:idx_data_rule_scope;comment:资源标识(表名)"`
+	Field       string   `json:"field" gorm:"size:64;comment:规则字段"`
+	Operator    string   `json:"operator" gorm:"size:32;comment:规则类型"`
+	Values      []string `json:"values" gorm:"serializer:json;type:text;column:rule_values;comment:规则取值"`
+	Remark      string   `json:"remark" gorm:"size:255;comment:备注"`
+}
+
+func (SysDataRule) TableName() string {
+	return "sys_data_rules"
+}
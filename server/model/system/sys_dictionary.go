@@ -18,3 +18,23 @@ type SysDictionary struct {
 func (SysDictionary) TableName() string {
 	return "sys_dictionaries"
 }
+
+// SysDictionaryTransfer 字典导入导出格式（JSON/YAML），字典项按层级嵌套
+type SysDictionaryTransfer struct {
+	Name    string                      `json:"name" yaml:"name"`
+	Type    string                      `json:"type" yaml:"type"`
+	Status  *bool                       `json:"status,omitempty" yaml:"status,omitempty"`
+	Desc    string                      `json:"desc,omitempty" yaml:"desc,omitempty"`
+	Details []SysDictionaryTransferItem `json:"details" yaml:"details"`
+}
+
+// SysDictionaryTransferItem 导入导出的字典项，Children 为下级字典项
+type SysDictionaryTransferItem struct {
+	Label    string                      `json:"label" yaml:"label"`
+	Value    string                      `json:"value" yaml:"value"`
+	Extend   string                      `json:"extend,omitempty" yaml:"extend,omitempty"`
+	Status   *bool                       `json:"status,omitempty" yaml:"status,omitempty"`
+	Sort     int                         `json:"sort,omitempty" yaml:"sort,omitempty"`
+	Labels   map[string]string           `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Children []SysDictionaryTransferItem `json:"children,omitempty" yaml:"children,omitempty"`
+}
@@ -3,17 +3,20 @@ package system
 
 import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common"
 )
 
 // 如果含有time.Time 请自行import time包
 type SysDictionaryDetail struct {
 	global.GVA_MODEL
-	Label           string `json:"label" form:"label" gorm:"column:label;comment:展示值"`                                  // 展示值
-	Value           string `json:"value" form:"value" gorm:"column:value;comment:字典值"`                                  // 字典值
-	Extend          string `json:"extend" form:"extend" gorm:"column:extend;comment:扩展值"`                               // 扩展值
-	Status          *bool  `json:"status" form:"status" gorm:"column:status;comment:启用状态"`                              // 启用状态
-	Sort            int    `json:"sort" form:"sort" gorm:"column:sort;comment:排序标记"`                                    // 排序标记
-	SysDictionaryID int    `json:"sysDictionaryID" form:"sysDictionaryID" gorm:"column:sys_dictionary_id;comment:关联标记"` // 关联标记
+	Label           string         `json:"label" form:"label" gorm:"column:label;comment:展示值"`                                  // 展示值
+	Value           string         `json:"value" form:"value" gorm:"column:value;comment:字典值"`                                  // 字典值
+	Extend          string         `json:"extend" form:"extend" gorm:"column:extend;comment:扩展值"`                               // 扩展值
+	Status          *bool          `json:"status" form:"status" gorm:"column:status;comment:启用状态"`                              // 启用状态
+	Sort            int            `json:"sort" form:"sort" gorm:"column:sort;comment:排序标记"`                                    // 排序标记
+	SysDictionaryID int            `json:"sysDictionaryID" form:"sysDictionaryID" gorm:"column:sys_dictionary_id;comment:关联标记"` // 关联标记
+	ParentID        uint           `json:"parentID" form:"parentID" gorm:"column:parent_id;index;default:0;comment:父级字典项ID"`    // 父级字典项ID，0为顶级
+	Labels          common.JSONMap `json:"labels" form:"-" gorm:"column:labels;type:text;comment:多语言展示值"`                       // 各语言的展示值，键为语言标记（如 en、en-US），未配置的语言使用 Label
 }
 
 func (SysDictionaryDetail) TableName() string {
@@ -0,0 +1,45 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 导出格式
+const (
+	ExportFormatXlsx  = "xlsx"
+	ExportFormatCsv   = "csv"
+	ExportFormatJsonl = "jsonl"
+)
+
+// 异步导出任务状态
+const (
+	ExportJobPending = "pending" // 排队中
+	ExportJobRunning = "running" // 导出中
+	ExportJobSuccess = "success" // 已完成，可下载
+	ExportJobFailed  = "failed"  // 失败
+	ExportJobExpired = "expired" // 下载链接已过期，文件已删除
+)
+
+// SysExportJob 异步导出任务
+type SysExportJob struct {
+	global.GVA_MODEL
+	SysUserId  uint       `json:"sysUserId" form:"sysUserId" gorm:"index;comment:创建人"`
+	TemplateID string     `json:"templateID" form:"templateID" gorm:"size:191;comment:模板标识"`
+	Name       string     `json:"name" form:"name" gorm:"size:191;comment:导出文件名"`
+	Format     string     `json:"format" form:"format" gorm:"size:16;comment:导出格式"`
+	Params     string     `json:"params" form:"params" gorm:"type:text;comment:导出参数"`
+	Status     string     `json:"status" form:"status" gorm:"index;size:16;comment:任务状态"`
+	Total      int64      `json:"total" form:"total" gorm:"comment:预计行数，-1代表未知"`
+	Rows       int64      `json:"rows" form:"rows" gorm:"comment:已导出行数"`
+	Size       int64      `json:"size" form:"size" gorm:"comment:文件大小"`
+	FileKey    string     `json:"-" gorm:"size:255;comment:私有文件的存储key"`
+	Error      string     `json:"error" form:"error" gorm:"size:512;comment:失败原因"`
+	FinishedAt *time.Time `json:"finishedAt" form:"finishedAt" gorm:"comment:完成时间"`
+	ExpiresAt  *time.Time `json:"expiresAt" form:"expiresAt" gorm:"index;comment:下载链接过期时间"`
+}
+
+func (SysExportJob) TableName() string {
+	return "sys_export_jobs"
+}
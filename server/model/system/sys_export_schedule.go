@@ -0,0 +1,59 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 定时报表投递方式
+const (
+	ExportDeliveryEmail = "email" // 以附件发送给收件人
+	ExportDeliveryOss   = "oss"   // 以私有对象保存到配置的对象存储，填写收件人时发送带签名下载链接的通知
+)
+
+// 定时报表触发方式
+const (
+	ExportTriggerCron   = "cron"
+	ExportTriggerManual = "manual"
+)
+
+// SysExportSchedule 导出模板的定时投递计划
+type SysExportSchedule struct {
+	global.GVA_MODEL
+	Name       string     `json:"name" form:"name" gorm:"size:191;comment:计划名称"`
+	TemplateID string     `json:"templateID" form:"templateID" gorm:"index;size:191;comment:模板标识"`
+	Spec       string     `json:"spec" form:"spec" gorm:"size:64;comment:cron表达式，支持秒"`
+	Params     string     `json:"params" form:"params" gorm:"type:text;comment:固定的导出参数"`
+	Format     string     `json:"format" form:"format" gorm:"size:16;comment:导出格式"`
+	Delivery   string     `json:"delivery" form:"delivery" gorm:"size:16;comment:投递方式"`
+	Recipients string     `json:"recipients" form:"recipients" gorm:"size:1024;comment:收件人，多个以逗号分隔"`
+	AlertTo    string     `json:"alertTo" form:"alertTo" gorm:"size:1024;comment:失败告警收件人，为空时发送到邮件插件配置的告警邮箱"`
+	Enabled    *bool      `json:"enabled" form:"enabled" gorm:"default:true;comment:是否启用"`
+	CreatedBy  uint       `json:"createdBy" form:"createdBy" gorm:"comment:创建人"`
+	LastRunAt  *time.Time `json:"lastRunAt" form:"lastRunAt" gorm:"comment:最近执行时间"`
+	LastStatus string     `json:"lastStatus" form:"lastStatus" gorm:"size:16;comment:最近执行结果"`
+}
+
+func (SysExportSchedule) TableName() string {
+	return "sys_export_schedules"
+}
+
+// SysExportScheduleRun 定时报表执行记录，同一计划的同一触发时间只会有一条，用于多实例去重
+type SysExportScheduleRun struct {
+	global.GVA_MODEL
+	ScheduleID uint       `json:"scheduleID" form:"scheduleID" gorm:"uniqueIndex:idx_export_schedule_fire;comment:计划ID"`
+	FireAt     time.Time  `json:"fireAt" form:"fireAt" gorm:"uniqueIndex:idx_export_schedule_fire;comment:触发时间"`
+	Trigger    string     `json:"trigger" form:"trigger" gorm:"size:16;comment:触发方式"`
+	Status     string     `json:"status" form:"status" gorm:"size:16;comment:执行状态"`
+	Rows       int64      `json:"rows" form:"rows" gorm:"comment:导出行数"`
+	Size       int64      `json:"size" form:"size" gorm:"comment:文件大小"`
+	FileName   string     `json:"fileName" form:"fileName" gorm:"size:255;comment:文件名"`
+	FileKey    string     `json:"-" gorm:"size:255;comment:私有文件的存储key"`
+	Error      string     `json:"error" form:"error" gorm:"size:512;comment:失败原因"`
+	FinishedAt *time.Time `json:"finishedAt" form:"finishedAt" gorm:"comment:完成时间"`
+}
+
+func (SysExportScheduleRun) TableName() string {
+	return "sys_export_schedule_runs"
+}
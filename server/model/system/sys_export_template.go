@@ -2,6 +2,10 @@
 package system
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 )
 
@@ -15,16 +19,48 @@ type SysExportTemplate struct {
 	TemplateInfo string         `json:"templateInfo" form:"templateInfo" gorm:"column:template_info;type:text;"` //模板信息
 	Limit        *int           `json:"limit" form:"limit" gorm:"column:limit;comment:导出限制"`
 	Order        string         `json:"order" form:"order" gorm:"column:order;comment:排序"`
+	ImportKey    string         `json:"importKey" form:"importKey" gorm:"column:import_key;comment:导入时按该字段更新已有数据，为空时只新增"`
+	ImportRules  string         `json:"importRules" form:"importRules" gorm:"column:import_rules;type:text;comment:导入校验规则"`
 	Conditions   []Condition    `json:"conditions" form:"conditions" gorm:"foreignKey:TemplateID;references:TemplateID;comment:条件"`
 	JoinTemplate []JoinTemplate `json:"joinTemplate" form:"joinTemplate" gorm:"foreignKey:TemplateID;references:TemplateID;comment:关联"`
 }
 
+// ImportRule 导入时单个字段的校验规则，未配置的项按数据库字段定义推断
+type ImportRule struct {
+	Required bool   `json:"required"` // 必填
+	Type     string `json:"type"`     // 值类型 string/int/float/bool/date/datetime
+	Dict     string `json:"dict"`     // 字典类型，取值须为字典中的值或展示值，展示值会转换为字典值
+	Length   int64  `json:"length"`   // 文本最大长度
+}
+
+// ParseImportRules 解析导入校验规则，键为模板信息中的字段
+func (t SysExportTemplate) ParseImportRules() (map[string]ImportRule, error) {
+	rules := make(map[string]ImportRule)
+	if strings.TrimSpace(t.ImportRules) == "" {
+		return rules, nil
+	}
+	if err := json.Unmarshal([]byte(t.ImportRules), &rules); err != nil {
+		return nil, fmt.Errorf("导入校验规则格式错误: %w", err)
+	}
+	return rules, nil
+}
+
 type JoinTemplate struct {
 	global.GVA_MODEL
-	TemplateID string `json:"templateID" form:"templateID" gorm:"column:template_id;comment:模板标识"`
-	JOINS      string `json:"joins" form:"joins" gorm:"column:joins;comment:关联"`
-	Table      string `json:"table" form:"table" gorm:"column:table;comment:关联表"`
-	ON         string `json:"on" form:"on" gorm:"column:on;comment:关联条件"`
+	TemplateID string   `json:"templateID" form:"templateID" gorm:"column:template_id;comment:模板标识"`
+	JOINS      string   `json:"joins" form:"joins" gorm:"column:joins;comment:关联"`
+	Table      string   `json:"table" form:"table" gorm:"column:table;comment:关联表"`
+	ON         string   `json:"on" form:"on" gorm:"column:on;comment:关联条件(已废弃)"` // 旧版文本关联条件，仅保留历史数据，由迁移转换为 JoinOn
+	JoinOn     []JoinOn `json:"joinOn" form:"joinOn" gorm:"column:join_on;serializer:json;type:text;comment:关联条件"`
+}
+
+// JoinOn 结构化的关联条件，左右两侧均为 表.字段，多个条件之间为 AND
+type JoinOn struct {
+	LeftTable   string `json:"leftTable"`
+	LeftColumn  string `json:"leftColumn"`
+	Operator    string `json:"operator"`
+	RightTable  string `json:"rightTable"`
+	RightColumn string `json:"rightColumn"`
 }
 
 func (JoinTemplate) TableName() string {
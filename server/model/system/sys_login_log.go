@@ -0,0 +1,20 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysLoginLog 登录记录，包括成功与失败的登录尝试
+type SysLoginLog struct {
+	global.GVA_MODEL
+	UserId    uint   `json:"userId" form:"userId" gorm:"index;comment:用户ID，用户名不存在时为0"`
+	Username  string `json:"username" form:"username" gorm:"index;size:191;comment:登录用户名"`
+	Ip        string `json:"ip" form:"ip" gorm:"index;size:64;comment:登录IP"`
+	UserAgent string `json:"userAgent" form:"userAgent" gorm:"size:512;comment:客户端UA"`
+	Success   bool   `json:"success" form:"success" gorm:"comment:是否登录成功"`
+	Reason    string `json:"reason" form:"reason" gorm:"size:128;comment:失败原因"`
+}
+
+func (SysLoginLog) TableName() string {
+	return "sys_login_logs"
+}
@@ -0,0 +1,16 @@
+package system
+
+import "time"
+
+// SysMigration 已执行的数据库迁移记录，回滚后删除对应记录
+type SysMigration struct {
+	ID        uint      `json:"ID" gorm:"primarykey"`
+	Version   string    `json:"version" gorm:"uniqueIndex;size:64;comment:迁移版本号"`
+	Name      string    `json:"name" gorm:"size:255;comment:迁移名称"`
+	Batch     int       `json:"batch" gorm:"index;comment:执行批次，同一次执行的迁移批次相同"`
+	AppliedAt time.Time `json:"appliedAt" gorm:"comment:执行时间"`
+}
+
+func (SysMigration) TableName() string {
+	return "sys_migrations"
+}
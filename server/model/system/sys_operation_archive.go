@@ -0,0 +1,24 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysOperationArchive 已归档到对象存储的操作记录文件，每个文件为 gzip 压缩的 JSON Lines
+type SysOperationArchive struct {
+	global.GVA_MODEL
+	FromID   uint      `json:"fromID" form:"fromID" gorm:"comment:起始记录ID"`
+	ToID     uint      `json:"toID" form:"toID" gorm:"comment:结束记录ID"`
+	FromTime time.Time `json:"fromTime" form:"fromTime" gorm:"index;comment:最早记录时间"`
+	ToTime   time.Time `json:"toTime" form:"toTime" gorm:"index;comment:最晚记录时间"`
+	Count    int       `json:"count" form:"count" gorm:"comment:记录条数"`
+	Size     int64     `json:"size" form:"size" gorm:"comment:文件大小"`
+	Name     string    `json:"name" form:"name" gorm:"size:255;comment:文件名"`
+	FileKey  string    `json:"-" gorm:"size:255;comment:私有文件的存储key"`
+}
+
+func (SysOperationArchive) TableName() string {
+	return "sys_operation_archives"
+}
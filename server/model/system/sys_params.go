@@ -5,16 +5,54 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 )
 
+// 参数类型
+const (
+	SysParamTypeString   = "string"
+	SysParamTypeInt      = "int"
+	SysParamTypeBool     = "bool"
+	SysParamTypeJSON     = "json"
+	SysParamTypeDuration = "duration"
+)
+
 // 参数 结构体  SysParams
 type SysParams struct {
 	global.GVA_MODEL
-	Name  string `json:"name" form:"name" gorm:"column:name;comment:参数名称;" binding:"required"`   //参数名称
-	Key   string `json:"key" form:"key" gorm:"column:key;comment:参数键;" binding:"required"`       //参数键
-	Value string `json:"value" form:"value" gorm:"column:value;comment:参数值;" binding:"required"` //参数值
-	Desc  string `json:"desc" form:"desc" gorm:"column:desc;comment:参数说明;"`                      //参数说明
+	Name    string `json:"name" form:"name" gorm:"column:name;comment:参数名称;" binding:"required"`                                   //参数名称
+	Key     string `json:"key" form:"key" gorm:"column:key;comment:参数键;" binding:"required"`                                       //参数键
+	Value   string `json:"value" form:"value" gorm:"column:value;comment:参数值;" binding:"required"`                                 //参数值
+	Desc    string `json:"desc" form:"desc" gorm:"column:desc;comment:参数说明;"`                                                      //参数说明
+	Type    string `json:"type" form:"type" gorm:"column:type;size:16;default:string;comment:参数类型 string/int/bool/json/duration;"` //参数类型
+	Schema  string `json:"schema" form:"schema" gorm:"column:schema;type:text;comment:校验规则;"`                                      //校验规则，见 SysParamSchema
+	Version int    `json:"version" form:"version" gorm:"column:version;default:1;comment:版本号;"`                                    //版本号，每次修改加一
 }
 
 // TableName 参数 SysParams自定义表名 sys_params
 func (SysParams) TableName() string {
 	return "sys_params"
 }
+
+// SysParamSchema 参数校验规则，以 JSON 保存在 Schema 字段中
+// Min/Max 对 int 为数值、对 duration 为时长（如 5s、10m）；Enum、Pattern 用于 string；Required 为 json 对象必须包含的字段
+type SysParamSchema struct {
+	Min      string   `json:"min,omitempty"`
+	Max      string   `json:"max,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Required []string `json:"required,omitempty"`
+}
+
+// SysParamsHistory 参数变更历史，保存每个版本的完整内容，用于回滚
+type SysParamsHistory struct {
+	global.GVA_MODEL
+	ParamID uint   `json:"paramID" gorm:"index;comment:参数ID"`
+	Key     string `json:"key" gorm:"size:191;comment:参数键"`
+	Version int    `json:"version" gorm:"comment:版本号"`
+	Action  string `json:"action" gorm:"size:16;comment:操作 create/update/delete/rollback"`
+	Type    string `json:"type" gorm:"size:16;comment:参数类型"`
+	Schema  string `json:"schema" gorm:"type:text;comment:校验规则"`
+	Value   string `json:"value" gorm:"type:text;comment:参数值"`
+}
+
+func (SysParamsHistory) TableName() string {
+	return "sys_params_histories"
+}
@@ -0,0 +1,15 @@
+package system
+
+import "time"
+
+// SysPasswordHistory 用户历史密码摘要，用于禁止重复使用最近的密码
+type SysPasswordHistory struct {
+	ID        uint      `gorm:"primarykey"`
+	UserId    uint      `gorm:"index;comment:用户ID"`
+	Password  string    `gorm:"comment:密码摘要(bcrypt)"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (SysPasswordHistory) TableName() string {
+	return "sys_password_histories"
+}
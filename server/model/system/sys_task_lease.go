@@ -0,0 +1,14 @@
+package system
+
+import "time"
+
+// SysTaskLease 定时任务租约，多实例部署时同一任务在租约到期前只由领取到租约的实例执行
+type SysTaskLease struct {
+	Name      string    `json:"name" gorm:"primarykey;size:64;comment:任务名称"`
+	Holder    string    `json:"holder" gorm:"size:128;comment:持有租约的实例"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"index;comment:租约到期时间"`
+}
+
+func (SysTaskLease) TableName() string {
+	return "sys_task_leases"
+}
@@ -0,0 +1,21 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysUserOidc 用户与身份提供方账号的绑定关系，以 issuer + sub 唯一标识外部账号
+type SysUserOidc struct {
+	global.GVA_MODEL
+	UserId      uint       `json:"userId" gorm:"index;comment:用户ID"`
+	Issuer      string     `json:"issuer" gorm:"size:255;uniqueIndex:idx_oidc_subject;comment:身份提供方"`
+	Subject     string     `json:"subject" gorm:"size:255;uniqueIndex:idx_oidc_subject;comment:外部账号标识(sub)"`
+	Email       string     `json:"email" gorm:"size:255;comment:外部账号邮箱"`
+	LastLoginAt *time.Time `json:"lastLoginAt" gorm:"comment:最近一次单点登录时间"`
+}
+
+func (SysUserOidc) TableName() string {
+	return "sys_user_oidcs"
+}
@@ -0,0 +1,38 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysUserSession 登录会话，每次登录（每台设备）一个会话，会话内的刷新令牌组成一个令牌族
+type SysUserSession struct {
+	global.GVA_MODEL
+	SessionId    string     `json:"sessionId" gorm:"uniqueIndex;size:64;comment:会话ID"` // 会话ID，写入访问令牌
+	UserId       uint       `json:"userId" gorm:"index;comment:用户ID"`                  // 用户ID
+	UserAgent    string     `json:"userAgent" gorm:"size:512;comment:登录设备"`            // 登录设备
+	Ip           string     `json:"ip" gorm:"size:64;comment:最近访问IP"`                  // 最近一次登录或刷新的IP
+	LastActiveAt time.Time  `json:"lastActiveAt" gorm:"comment:最近活跃时间"`                // 最近一次登录或刷新的时间
+	ExpiresAt    time.Time  `json:"expiresAt" gorm:"index;comment:过期时间"`               // 当前刷新令牌的过期时间
+	RevokedAt    *time.Time `json:"revokedAt" gorm:"comment:吊销时间"`                     // 吊销时间，为空表示有效
+	RevokeReason string     `json:"revokeReason" gorm:"size:64;comment:吊销原因"`          // logout/revoked/reuse 等
+}
+
+func (SysUserSession) TableName() string {
+	return "sys_user_sessions"
+}
+
+// SysRefreshToken 刷新令牌，只保存摘要；每次刷新后旧令牌标记为已使用并签发新令牌
+type SysRefreshToken struct {
+	ID        uint       `gorm:"primarykey"`
+	SessionId string     `gorm:"index;size:64;comment:所属会话ID"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;comment:令牌摘要"`
+	ExpiresAt time.Time  `gorm:"comment:过期时间"`
+	UsedAt    *time.Time `gorm:"comment:轮换时间"` // 已轮换的令牌再次出现视为被盗用
+	CreatedAt time.Time
+}
+
+func (SysRefreshToken) TableName() string {
+	return "sys_refresh_tokens"
+}
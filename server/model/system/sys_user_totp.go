@@ -0,0 +1,22 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysUserTotp 用户两步验证（TOTP）绑定信息
+type SysUserTotp struct {
+	global.GVA_MODEL
+	UserId        uint       `json:"userId" gorm:"uniqueIndex;comment:用户ID"`     // 用户ID
+	Secret        string     `json:"-" gorm:"type:text;comment:加密后的TOTP密钥"`      // AES-GCM 加密后的密钥
+	RecoveryCodes string     `json:"-" gorm:"type:text;comment:恢复码摘要"`           // 未使用恢复码的 SHA256 摘要，JSON 数组
+	Enabled       bool       `json:"enabled" gorm:"default:false;comment:是否已启用"` // 是否已启用，绑定未确认前为 false
+	LastStep      int64      `json:"-" gorm:"default:0;comment:最近一次通过校验的周期序号"`   // 防止动态码重放
+	EnabledAt     *time.Time `json:"enabledAt" gorm:"comment:启用时间"`              // 启用时间
+}
+
+func (SysUserTotp) TableName() string {
+	return "sys_user_totps"
+}
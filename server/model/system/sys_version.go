@@ -2,16 +2,27 @@
 package system
 
 import (
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 )
 
+// 版本包导入时已存在的数据的处理方式
+const (
+	VersionStrategySkip      = "skip"      // 保留目标环境的数据
+	VersionStrategyOverwrite = "overwrite" // 用版本包中的数据覆盖
+	VersionStrategyRename    = "rename"    // 以版本号为后缀重命名后新建，API、权限等无法重命名的数据按跳过处理
+)
+
 // 版本管理 结构体  SysVersion
 type SysVersion struct {
 	global.GVA_MODEL
-	VersionName *string `json:"versionName" form:"versionName" gorm:"comment:版本名称;column:version_name;size:255;" binding:"required"`                           //版本名称
-	VersionCode *string `json:"versionCode" form:"versionCode" gorm:"comment:版本号;column:version_code;size:100;" binding:"required"`                            //版本号
-	Description *string `json:"description" form:"description" gorm:"comment:版本描述;column:description;size:500;"`                                               //版本描述
-	VersionData *string `json:"versionData" form:"versionData" gorm:"comment:版本数据JSON;column:version_data;type:text;"` //版本数据
+	VersionName  *string    `json:"versionName" form:"versionName" gorm:"comment:版本名称;column:version_name;size:255;" binding:"required"` //版本名称
+	VersionCode  *string    `json:"versionCode" form:"versionCode" gorm:"comment:版本号;column:version_code;size:100;" binding:"required"`  //版本号
+	Description  *string    `json:"description" form:"description" gorm:"comment:版本描述;column:description;size:500;"`                     //版本描述
+	VersionData  *string    `json:"versionData" form:"versionData" gorm:"comment:版本数据JSON;column:version_data;type:text;"`               //版本数据
+	RollbackData *string    `json:"-" gorm:"comment:导入前数据快照;column:rollback_data;type:text;"`                                            //导入前数据快照，仅导入记录有值
+	RolledBackAt *time.Time `json:"rolledBackAt" form:"rolledBackAt" gorm:"comment:回滚时间;column:rolled_back_at;"`                         //回滚时间
 }
 
 // TableName 版本管理 SysVersion自定义表名 sys_versions
@@ -3,7 +3,10 @@ package utils
 import (
 	"crypto/tls"
 	"fmt"
+	"io"
+	"mime"
 	"net/smtp"
+	"path/filepath"
 	"strings"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/email/global"
@@ -22,6 +25,23 @@ func Email(To, subject string, body string) error {
 	return send(to, subject, body)
 }
 
+// Attachment 邮件附件
+type Attachment struct {
+	Name        string    // 附件文件名
+	ContentType string    // 为空时按文件名推断
+	Content     io.Reader // 附件内容
+}
+
+//@function: EmailWithAttachments
+//@description: 发送带附件的邮件，多个收件人以逗号分隔
+//@param: To string, subject string, body string, attachments ...Attachment
+//@return: error
+
+func EmailWithAttachments(To, subject string, body string, attachments ...Attachment) error {
+	to := strings.Split(To, ",")
+	return send(to, subject, body, attachments...)
+}
+
 //@author: [SliverHorn](https://github.com/SliverHorn)
 //@function: ErrorToEmail
 //@description: 给email中间件错误发送邮件到指定邮箱
@@ -53,7 +73,7 @@ func EmailTest(subject string, body string) error {
 //@param: subject string, body string
 //@return: error
 
-func send(to []string, subject string, body string) error {
+func send(to []string, subject string, body string, attachments ...Attachment) error {
 	from := global.GlobalConfig.From
 	nickname := global.GlobalConfig.Nickname
 	secret := global.GlobalConfig.Secret
@@ -77,6 +97,15 @@ func send(to []string, subject string, body string) error {
 	e.To = to
 	e.Subject = subject
 	e.HTML = []byte(body)
+	for _, a := range attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(a.Name))
+		}
+		if _, err := e.Attach(a.Content, a.Name, contentType); err != nil {
+			return err
+		}
+	}
 	var err error
 	hostAddr := fmt.Sprintf("%s:%d", host, port)
 	if isSSL {
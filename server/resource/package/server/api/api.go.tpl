@@ -156,6 +156,9 @@ func ({{.Abbreviation}}Api *{{.StructName}}Api) Find{{.StructName}}(c *gin.Conte
 		response.FailWithMessage("查询失败:" + err.Error(), c)
 		return
 	}
+    {{- if .HasDictLabel }}
+    {{.Abbreviation}}Service.Translate{{.StructName}}Dict(c.GetHeader("Accept-Language"), &re{{.Abbreviation}})
+    {{- end }}
 	response.OkWithData(re{{.Abbreviation}}, c)
 }
 
@@ -178,6 +181,9 @@ func ({{.Abbreviation}}Api *{{.StructName}}Api) Get{{.StructName}}List(c *gin.Co
         response.FailWithMessage("获取失败:" + err.Error(), c)
         return
     }
+    {{- if .HasDictLabel }}
+    {{.Abbreviation}}Service.Translate{{.StructName}}Dict(c.GetHeader("Accept-Language"), list...)
+    {{- end }}
     response.OkWithDetailed(list, "获取成功", c)
 }
 {{- else }}
@@ -206,6 +212,11 @@ func ({{.Abbreviation}}Api *{{.StructName}}Api) Get{{.StructName}}List(c *gin.Co
         response.FailWithMessage("获取失败:" + err.Error(), c)
         return
     }
+    {{- if .HasDictLabel }}
+    for i := range list {
+        {{.Abbreviation}}Service.Translate{{.StructName}}Dict(c.GetHeader("Accept-Language"), &list[i])
+    }
+    {{- end }}
     response.OkWithDetailed(response.PageResult{
         List:     list,
         Total:    total,
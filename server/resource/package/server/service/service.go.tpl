@@ -48,6 +48,10 @@ import (
     {{- if .AutoCreateResource }}
     "gorm.io/gorm"
     {{- end}}
+    {{- if .HasDictLabel }}
+    "fmt"
+    systemService "{{.Module}}/service/system"
+    {{- end }}
 {{- end }}
 )
 
@@ -186,6 +190,29 @@ func ({{.Abbreviation}}Service *{{.StructName}}Service)Get{{.StructName}}InfoLis
 	return  {{.Abbreviation}}s, total, err
 }
 
+{{- end }}
+{{- if .HasDictLabel }}
+
+// Translate{{.StructName}}Dict 按语言填充{{.Description}}字典字段的翻译，未配置对应语言时使用默认展示值
+// Author [yourname](https://github.com/yourname)
+func ({{.Abbreviation}}Service *{{.StructName}}Service) Translate{{.StructName}}Dict(locale string, items ...*{{.Package}}.{{.StructName}}) {
+	for _, item := range items {
+	{{- range .Fields}}
+	{{- if .HasDictLabel }}
+	{{- if eq .FieldType "enum" }}
+		item.{{.FieldName}}Label = systemService.DictionaryDetailServiceApp.TranslateDictionaryValue("{{.DictType}}", fmt.Sprint(item.{{.FieldName}}), locale)
+	{{- else }}
+		if item.{{.FieldName}} != nil {
+			item.{{.FieldName}}Label = systemService.DictionaryDetailServiceApp.TranslateDictionaryValue("{{.DictType}}", fmt.Sprint(*item.{{.FieldName}}), locale)
+		}
+	{{- end }}
+	{{- end }}
+	{{- end }}
+	{{- if .IsTree }}
+		{{.Abbreviation}}Service.Translate{{.StructName}}Dict(locale, item.Children...)
+	{{- end }}
+	}
+}
 {{- end }}
 
 {{- if .HasDataSource }}
@@ -156,6 +156,9 @@ func (a *{{.Abbreviation}}) Find{{.StructName}}(c *gin.Context) {
 		response.FailWithMessage("查询失败:" + err.Error(), c)
 		return
 	}
+    {{- if .HasDictLabel }}
+    service{{ .StructName }}.Translate{{.StructName}}Dict(c.GetHeader("Accept-Language"), &re{{.Abbreviation}})
+    {{- end }}
     response.OkWithData(re{{.Abbreviation}}, c)
 }
 
@@ -178,6 +181,9 @@ func (a *{{.Abbreviation}}) Get{{.StructName}}List(c *gin.Context) {
         response.FailWithMessage("获取失败:" + err.Error(), c)
         return
     }
+    {{- if .HasDictLabel }}
+    service{{ .StructName }}.Translate{{.StructName}}Dict(c.GetHeader("Accept-Language"), list...)
+    {{- end }}
     response.OkWithDetailed(list, "获取成功", c)
 }
 {{- else }}
@@ -206,6 +212,11 @@ func (a *{{.Abbreviation}}) Get{{.StructName}}List(c *gin.Context) {
         response.FailWithMessage("获取失败:" + err.Error(), c)
         return
     }
+    {{- if .HasDictLabel }}
+    for i := range list {
+        service{{ .StructName }}.Translate{{.StructName}}Dict(c.GetHeader("Accept-Language"), &list[i])
+    }
+    {{- end }}
     response.OkWithDetailed(response.PageResult{
         List:     list,
         Total:    total,
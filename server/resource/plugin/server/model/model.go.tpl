@@ -2,6 +2,9 @@
 // 在结构体中新增如下字段
 {{- range .Fields}}
   {{ GenerateField . }}
+  {{- if .HasDictLabel }}
+  {{.FieldName}}Label  string `json:"{{.FieldJson}}Label" form:"-" gorm:"-"` //{{.FieldDesc}}字典翻译
+  {{- end }}
 {{- end }}
 
 {{ else }}
@@ -29,6 +32,9 @@ type {{.StructName}} struct {
 {{- end }}
 {{- range .Fields}}
   {{ GenerateField . }}
+  {{- if .HasDictLabel }}
+  {{.FieldName}}Label  string `json:"{{.FieldJson}}Label" form:"-" gorm:"-"` //{{.FieldDesc}}字典翻译
+  {{- end }}
 {{- end }}
     {{- if .AutoCreateResource }}
     CreatedBy  uint   `gorm:"column:created_by;comment:创建者"`
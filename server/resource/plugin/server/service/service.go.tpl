@@ -46,6 +46,10 @@ import (
 {{- if .IsTree }}
     "{{.Module}}/utils"
 {{- end }}
+{{- if .HasDictLabel }}
+    "fmt"
+    systemService "{{.Module}}/service/system"
+{{- end }}
 {{- end }}
 )
 
@@ -192,6 +196,29 @@ func (s *{{.Abbreviation}}) Get{{.StructName}}InfoList(ctx context.Context, info
 	return  {{.Abbreviation}}s, total, err
 }
 {{- end }}
+{{- if .HasDictLabel }}
+
+// Translate{{.StructName}}Dict 按语言填充{{.Description}}字典字段的翻译，未配置对应语言时使用默认展示值
+// Author [yourname](https://github.com/yourname)
+func (s *{{.Abbreviation}}) Translate{{.StructName}}Dict(locale string, items ...*model.{{.StructName}}) {
+	for _, item := range items {
+	{{- range .Fields}}
+	{{- if .HasDictLabel }}
+	{{- if eq .FieldType "enum" }}
+		item.{{.FieldName}}Label = systemService.DictionaryDetailServiceApp.TranslateDictionaryValue("{{.DictType}}", fmt.Sprint(item.{{.FieldName}}), locale)
+	{{- else }}
+		if item.{{.FieldName}} != nil {
+			item.{{.FieldName}}Label = systemService.DictionaryDetailServiceApp.TranslateDictionaryValue("{{.DictType}}", fmt.Sprint(*item.{{.FieldName}}), locale)
+		}
+	{{- end }}
+	{{- end }}
+	{{- end }}
+	{{- if .IsTree }}
+		s.Translate{{.StructName}}Dict(locale, item.Children...)
+	{{- end }}
+	}
+}
+{{- end }}
 {{- if .HasDataSource }}
 func (s *{{.Abbreviation}})Get{{.StructName}}DataSource(ctx context.Context) (res map[string][]map[string]any, err error) {
 	res = make(map[string][]map[string]any)
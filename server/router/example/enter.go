@@ -11,6 +11,8 @@ type RouterGroup struct {
 	MerUserRouter
 	SysUserConfigRouter
 	MerPayOrderRouter
+	MerPaySuspenseRouter
+	RiskRuleHitRouter
 }
 
 var (
@@ -20,4 +22,6 @@ var (
 	merUserApi                  = api.ApiGroupApp.ExampleApiGroup.MerUserApi
 	sysUserConfigApi            = api.ApiGroupApp.ExampleApiGroup.SysUserConfigApi
 	merPayOrderApi              = api.ApiGroupApp.ExampleApiGroup.MerPayOrderApi
+	merPaySuspenseApi           = api.ApiGroupApp.ExampleApiGroup.MerPaySuspenseApi
+	riskRuleHitApi              = api.ApiGroupApp.ExampleApiGroup.RiskRuleHitApi
 )
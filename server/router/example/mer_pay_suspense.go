@@ -0,0 +1,21 @@
+package example
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type MerPaySuspenseRouter struct{}
+
+// InitMerPaySuspenseRouter 初始化 挂账收款 路由信息
+func (s *MerPaySuspenseRouter) InitMerPaySuspenseRouter(Router *gin.RouterGroup) {
+	merPaySuspenseRouter := Router.Group("merPaySuspense").Use(middleware.OperationRecord()).Use(middleware.WithSysUserID())
+	merPaySuspenseRouterWithoutRecord := Router.Group("merPaySuspense").Use(middleware.WithSysUserID())
+	{
+		merPaySuspenseRouter.POST("matchMerPaySuspense", merPaySuspenseApi.MatchMerPaySuspense) // 人工匹配挂账收款
+	}
+	{
+		merPaySuspenseRouterWithoutRecord.GET("getMerPaySuspenseList", merPaySuspenseApi.GetMerPaySuspenseList) // 获取挂账收款列表
+		merPaySuspenseRouterWithoutRecord.GET("getMerPayMatchLogs", merPaySuspenseApi.GetMerPayMatchLogs)       // 获取订单的人工匹配记录
+	}
+}
@@ -22,6 +22,7 @@ func (s *MerUserRouter) InitMerUserRouter(Router *gin.RouterGroup, PublicRouter
 		merUserRouter.PUT("updateMerUser", merUserApi.UpdateMerUser)                    // 更新merUser表
 		merUserRouter.POST("generatePermanentToken", merUserApi.GeneratePermanentToken) // 生成永久token
 		merUserRouter.POST("revokePermanentToken", merUserApi.RevokePermanentToken)     // 撤销永久token
+		merUserRouter.POST("sandboxPay", merUserApi.SandboxPay)                         // 沙箱模拟支付
 	}
 	{
 		merUserRouterWithoutRecord.GET("findMerUser", merUserApi.FindMerUser)               // 根据ID获取merUser表
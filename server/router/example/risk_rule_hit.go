@@ -0,0 +1,16 @@
+package example
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type RiskRuleHitRouter struct{}
+
+// InitRiskRuleHitRouter 初始化 风控命中记录 路由信息
+func (s *RiskRuleHitRouter) InitRiskRuleHitRouter(Router *gin.RouterGroup) {
+	riskRuleHitRouterWithoutRecord := Router.Group("riskRuleHit").Use(middleware.WithSysUserID())
+	{
+		riskRuleHitRouterWithoutRecord.GET("getRiskRuleHitList", riskRuleHitApi.GetRiskRuleHitList) // 获取风控命中记录列表
+	}
+}
@@ -16,8 +16,12 @@ func (s *AuthorityRouter) InitAuthorityRouter(Router *gin.RouterGroup) {
 		authorityRouter.PUT("updateAuthority", authorityApi.UpdateAuthority)    // 更新角色
 		authorityRouter.POST("copyAuthority", authorityApi.CopyAuthority)       // 拷贝角色
 		authorityRouter.POST("setDataAuthority", authorityApi.SetDataAuthority) // 设置角色资源权限
+		authorityRouter.POST("setRequire2FA", authorityApi.SetRequire2FA)       // 设置角色是否强制两步验证
+		authorityRouter.POST("setDataRules", authorityApi.SetDataRules)         // 设置角色行级数据权限规则
 	}
 	{
-		authorityRouterWithoutRecord.POST("getAuthorityList", authorityApi.GetAuthorityList) // 获取角色列表
+		authorityRouterWithoutRecord.POST("getAuthorityList", authorityApi.GetAuthorityList)          // 获取角色列表
+		authorityRouterWithoutRecord.GET("getDataRules", authorityApi.GetDataRules)                   // 获取角色行级数据权限规则
+		authorityRouterWithoutRecord.GET("getDataScopeResources", authorityApi.GetDataScopeResources) // 获取可配置行级数据权限的资源
 	}
 }
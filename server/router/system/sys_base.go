@@ -11,6 +11,12 @@ func (s *BaseRouter) InitBaseRouter(Router *gin.RouterGroup) (R gin.IRoutes) {
 	{
 		baseRouter.POST("login", baseApi.Login)
 		baseRouter.POST("captcha", baseApi.Captcha)
+		baseRouter.POST("loginTotp", baseApi.LoginTotp)                         // 两步验证登录
+		baseRouter.POST("totpEnroll", baseApi.TotpEnroll)                       // 登录时绑定两步验证
+		baseRouter.POST("refresh", baseApi.RefreshToken)                        // 刷新访问令牌
+		baseRouter.POST("changeExpiredPassword", baseApi.ChangeExpiredPassword) // 登录时修改过期密码
+		baseRouter.GET("oidcAuthorize", baseApi.OidcAuthorize)                  // 获取单点登录跳转地址
+		baseRouter.POST("oidcCallback", baseApi.OidcCallback)                   // 单点登录回调
 	}
 	return baseRouter
 }
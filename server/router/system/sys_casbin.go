@@ -15,5 +15,7 @@ func (s *CasbinRouter) InitCasbinRouter(Router *gin.RouterGroup) {
 	}
 	{
 		casbinRouterWithoutRecord.POST("getPolicyPathByAuthorityId", casbinApi.GetPolicyPathByAuthorityId)
+		casbinRouterWithoutRecord.POST("simulateCasbin", casbinApi.SimulateCasbin)
+		casbinRouterWithoutRecord.POST("diffCasbin", casbinApi.DiffCasbin)
 	}
 }
@@ -18,5 +18,7 @@ func (s *DictionaryDetailRouter) InitSysDictionaryDetailRouter(Router *gin.Route
 	{
 		dictionaryDetailRouterWithoutRecord.GET("findSysDictionaryDetail", dictionaryDetailApi.FindSysDictionaryDetail)       // 根据ID获取SysDictionaryDetail
 		dictionaryDetailRouterWithoutRecord.GET("getSysDictionaryDetailList", dictionaryDetailApi.GetSysDictionaryDetailList) // 获取SysDictionaryDetail列表
+		dictionaryDetailRouterWithoutRecord.GET("getDictionaryTree", dictionaryDetailApi.GetDictionaryTree)                   // 按层级获取字典项
+		dictionaryDetailRouterWithoutRecord.POST("lookupDictionaries", dictionaryDetailApi.LookupDictionaries)                // 批量翻译字典值
 	}
 }
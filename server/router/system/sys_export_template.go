@@ -20,15 +20,28 @@ func (s *SysExportTemplateRouter) InitSysExportTemplateRouter(Router *gin.Router
 		sysExportTemplateRouter.DELETE("deleteSysExportTemplateByIds", exportTemplateApi.DeleteSysExportTemplateByIds) // 批量删除导出模板
 		sysExportTemplateRouter.PUT("updateSysExportTemplate", exportTemplateApi.UpdateSysExportTemplate)              // 更新导出模板
 		sysExportTemplateRouter.POST("importExcel", exportTemplateApi.ImportExcel)                                     // 导入excel模板数据
+		sysExportTemplateRouter.POST("createExportJob", exportTemplateApi.CreateExportJob)                             // 创建异步导出任务
+		sysExportTemplateRouter.POST("createExportSchedule", exportTemplateApi.CreateExportSchedule)                   // 创建定时报表
+		sysExportTemplateRouter.PUT("updateExportSchedule", exportTemplateApi.UpdateExportSchedule)                    // 更新定时报表
+		sysExportTemplateRouter.DELETE("deleteExportSchedule", exportTemplateApi.DeleteExportSchedule)                 // 删除定时报表
+		sysExportTemplateRouter.POST("runExportSchedule", exportTemplateApi.RunExportSchedule)                         // 立即执行定时报表
 	}
 	{
-		sysExportTemplateRouterWithoutRecord.GET("findSysExportTemplate", exportTemplateApi.FindSysExportTemplate)       // 根据ID获取导出模板
-		sysExportTemplateRouterWithoutRecord.GET("getSysExportTemplateList", exportTemplateApi.GetSysExportTemplateList) // 获取导出模板列表
-		sysExportTemplateRouterWithoutRecord.GET("exportExcel", exportTemplateApi.ExportExcel)                           // 获取导出token
-		sysExportTemplateRouterWithoutRecord.GET("exportTemplate", exportTemplateApi.ExportTemplate)                     // 导出表格模板
+		sysExportTemplateRouterWithoutRecord.GET("findSysExportTemplate", exportTemplateApi.FindSysExportTemplate)         // 根据ID获取导出模板
+		sysExportTemplateRouterWithoutRecord.GET("getSysExportTemplateList", exportTemplateApi.GetSysExportTemplateList)   // 获取导出模板列表
+		sysExportTemplateRouterWithoutRecord.GET("exportExcel", exportTemplateApi.ExportExcel)                             // 获取导出token
+		sysExportTemplateRouterWithoutRecord.GET("exportTemplate", exportTemplateApi.ExportTemplate)                       // 导出表格模板
+		sysExportTemplateRouterWithoutRecord.GET("getExportJobList", exportTemplateApi.GetExportJobList)                   // 获取我的导出任务
+		sysExportTemplateRouterWithoutRecord.GET("findExportJob", exportTemplateApi.FindExportJob)                         // 获取导出任务进度
+		sysExportTemplateRouterWithoutRecord.GET("getExportScheduleList", exportTemplateApi.GetExportScheduleList)         // 获取定时报表列表
+		sysExportTemplateRouterWithoutRecord.GET("getExportScheduleRunList", exportTemplateApi.GetExportScheduleRunList)   // 获取定时报表执行记录
+		sysExportTemplateRouterWithoutRecord.GET("downloadExportScheduleRun", exportTemplateApi.DownloadExportScheduleRun) // 下载定时报表文件
 	}
 	{
-		sysExportTemplateRouterWithoutAuth.GET("exportExcelByToken", exportTemplateApi.ExportExcelByToken)       // 通过token导出表格
-		sysExportTemplateRouterWithoutAuth.GET("exportTemplateByToken", exportTemplateApi.ExportTemplateByToken) // 通过token导出模板
+		sysExportTemplateRouterWithoutAuth.GET("exportExcelByToken", exportTemplateApi.ExportExcelByToken)                 // 通过token导出表格
+		sysExportTemplateRouterWithoutAuth.GET("exportTemplateByToken", exportTemplateApi.ExportTemplateByToken)           // 通过token导出模板
+		sysExportTemplateRouterWithoutAuth.GET("downloadExportJob", exportTemplateApi.DownloadExportJob)                   // 通过签名链接下载异步导出文件
+		sysExportTemplateRouterWithoutAuth.GET("downloadExportScheduleFile", exportTemplateApi.DownloadExportScheduleFile) // 通过签名链接下载定时报表文件
+		sysExportTemplateRouterWithoutAuth.GET("importErrorReportByToken", exportTemplateApi.ImportErrorReportByToken)     // 通过token下载导入错误报告
 	}
 }
@@ -0,0 +1,21 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type SysMigrationRouter struct{}
+
+// InitSysMigrationRouter 初始化 数据库迁移 路由信息
+func (s *SysMigrationRouter) InitSysMigrationRouter(Router *gin.RouterGroup) {
+	sysMigrationRouter := Router.Group("sysMigration").Use(middleware.OperationRecord())
+	sysMigrationRouterWithoutRecord := Router.Group("sysMigration")
+	{
+		sysMigrationRouter.POST("migrate", sysMigrationApi.Migrate)                     // 执行迁移
+		sysMigrationRouter.POST("rollbackMigration", sysMigrationApi.RollbackMigration) // 回滚迁移
+	}
+	{
+		sysMigrationRouterWithoutRecord.GET("getMigrationStatus", sysMigrationApi.GetMigrationStatus) // 获取迁移状态
+	}
+}
@@ -13,6 +13,15 @@ func (s *OperationRecordRouter) InitSysOperationRecordRouter(Router *gin.RouterG
 		operationRecordRouter.DELETE("deleteSysOperationRecordByIds", operationRecordApi.DeleteSysOperationRecordByIds) // 批量删除SysOperationRecord
 		operationRecordRouter.GET("findSysOperationRecord", operationRecordApi.FindSysOperationRecord)                  // 根据ID获取SysOperationRecord
 		operationRecordRouter.GET("getSysOperationRecordList", operationRecordApi.GetSysOperationRecordList)            // 获取SysOperationRecord列表
+		operationRecordRouter.GET("getOperationArchiveList", operationRecordApi.GetOperationArchiveList)                // 获取操作记录归档文件列表
+		operationRecordRouter.GET("downloadOperationArchive", operationRecordApi.DownloadOperationArchive)              // 下载操作记录归档文件
 
 	}
+	// 审计记录只读，不提供修改与删除接口
+	auditLogRouter := Router.Group("sysAuditLog")
+	{
+		auditLogRouter.GET("getAuditLogList", operationRecordApi.GetAuditLogList)              // 分页获取审计记录
+		auditLogRouter.GET("verifyAuditLogs", operationRecordApi.VerifyAuditLogs)              // 校验审计链
+		auditLogRouter.POST("createAuditCheckpoint", operationRecordApi.CreateAuditCheckpoint) // 创建审计链校验点
+	}
 }
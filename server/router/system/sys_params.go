@@ -16,10 +16,12 @@ func (s *SysParamsRouter) InitSysParamsRouter(Router *gin.RouterGroup, PublicRou
 		sysParamsRouter.DELETE("deleteSysParams", sysParamsApi.DeleteSysParams)           // 删除参数
 		sysParamsRouter.DELETE("deleteSysParamsByIds", sysParamsApi.DeleteSysParamsByIds) // 批量删除参数
 		sysParamsRouter.PUT("updateSysParams", sysParamsApi.UpdateSysParams)              // 更新参数
+		sysParamsRouter.POST("rollbackSysParams", sysParamsApi.RollbackSysParams)         // 回滚参数到指定版本
 	}
 	{
-		sysParamsRouterWithoutRecord.GET("findSysParams", sysParamsApi.FindSysParams)       // 根据ID获取参数
-		sysParamsRouterWithoutRecord.GET("getSysParamsList", sysParamsApi.GetSysParamsList) // 获取参数列表
-		sysParamsRouterWithoutRecord.GET("getSysParam", sysParamsApi.GetSysParam)           // 根据Key获取参数
+		sysParamsRouterWithoutRecord.GET("findSysParams", sysParamsApi.FindSysParams)             // 根据ID获取参数
+		sysParamsRouterWithoutRecord.GET("getSysParamsList", sysParamsApi.GetSysParamsList)       // 获取参数列表
+		sysParamsRouterWithoutRecord.GET("getSysParam", sysParamsApi.GetSysParam)                 // 根据Key获取参数
+		sysParamsRouterWithoutRecord.GET("getSysParamsHistory", sysParamsApi.GetSysParamsHistory) // 获取参数变更历史
 	}
 }
@@ -20,9 +20,25 @@ func (s *UserRouter) InitUserRouter(Router *gin.RouterGroup) {
 		userRouter.POST("setUserAuthorities", baseApi.SetUserAuthorities) // 设置用户权限组
 		userRouter.POST("resetPassword", baseApi.ResetPassword)           // 重置用户密码
 		userRouter.PUT("setSelfSetting", baseApi.SetSelfSetting)          // 用户界面配置
+		userRouter.POST("resetUserTotp", baseApi.ResetUserTotp)           // 管理员重置用户两步验证
+		userRouter.POST("revokeSession", baseApi.RevokeSession)           // 下线登录设备
+		userRouter.POST("killUserSession", baseApi.KillUserSession)       // 管理员强制下线用户会话
+		userRouter.POST("unlockLogin", baseApi.UnlockLogin)               // 管理员解除登录锁定
+		userRouter.POST("oidcUnbind", baseApi.OidcUnbind)                 // 解除外部账号绑定
 	}
 	{
-		userRouterWithoutRecord.POST("getUserList", baseApi.GetUserList) // 分页获取用户列表
-		userRouterWithoutRecord.GET("getUserInfo", baseApi.GetUserInfo)  // 获取自身信息
+		userRouterWithoutRecord.POST("getUserList", baseApi.GetUserList)           // 分页获取用户列表
+		userRouterWithoutRecord.GET("getUserInfo", baseApi.GetUserInfo)            // 获取自身信息
+		userRouterWithoutRecord.GET("getTotpStatus", baseApi.GetTotpStatus)        // 获取两步验证状态
+		userRouterWithoutRecord.GET("getSessions", baseApi.GetSessions)            // 获取登录设备列表
+		userRouterWithoutRecord.POST("getUserSessions", baseApi.GetUserSessions)   // 管理员查看用户在线会话
+		userRouterWithoutRecord.POST("getLoginLogList", baseApi.GetLoginLogList)   // 分页获取登录记录
+		userRouterWithoutRecord.GET("getOidcBindings", baseApi.GetOidcBindings)    // 获取绑定的外部账号
+		userRouterWithoutRecord.POST("oidcBind", baseApi.OidcBind)                 // 获取绑定外部账号的跳转地址
+		userRouterWithoutRecord.POST("oidcBindCallback", baseApi.OidcBindCallback) // 绑定外部账号的回调
+		// 请求或响应包含明文密钥、验证码与恢复码，不记录操作日志
+		userRouterWithoutRecord.POST("setupTotp", baseApi.SetupTotp)     // 生成两步验证绑定信息
+		userRouterWithoutRecord.POST("enableTotp", baseApi.EnableTotp)   // 启用两步验证
+		userRouterWithoutRecord.POST("disableTotp", baseApi.DisableTotp) // 关闭两步验证
 	}
 }
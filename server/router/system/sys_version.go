@@ -16,10 +16,12 @@ func (s *SysVersionRouter) InitSysVersionRouter(Router *gin.RouterGroup) {
 		sysVersionRouter.DELETE("deleteSysVersionByIds", sysVersionApi.DeleteSysVersionByIds) // 批量删除版本管理
 		sysVersionRouter.POST("exportVersion", sysVersionApi.ExportVersion)                   // 导出版本数据
 		sysVersionRouter.POST("importVersion", sysVersionApi.ImportVersion)                   // 导入版本数据
+		sysVersionRouter.POST("rollbackVersion", sysVersionApi.RollbackVersion)               // 回滚版本导入
 	}
 	{
 		sysVersionRouterWithoutRecord.GET("findSysVersion", sysVersionApi.FindSysVersion)           // 根据ID获取版本管理
 		sysVersionRouterWithoutRecord.GET("getSysVersionList", sysVersionApi.GetSysVersionList)     // 获取版本管理列表
 		sysVersionRouterWithoutRecord.GET("downloadVersionJson", sysVersionApi.DownloadVersionJson) // 下载版本JSON数据
+		sysVersionRouterWithoutRecord.POST("previewVersion", sysVersionApi.PreviewVersion)          // 预览版本导入
 	}
 }
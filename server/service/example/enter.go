@@ -7,4 +7,6 @@ type ServiceGroup struct {
 	MerUserService
 	SysUserConfigService
 	MerPayOrderService
+	MerPaySuspenseService
+	RiskRuleService
 }
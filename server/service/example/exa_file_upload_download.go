@@ -1,6 +1,7 @@
 package example
 
 import (
+	"context"
 	"errors"
 	"mime/multipart"
 	"strings"
@@ -62,13 +63,13 @@ func (e *FileUploadAndDownloadService) EditFileName(file example.ExaFileUploadAn
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: GetFileRecordInfoList
 //@description: 分页获取数据
-//@param: info request.ExaAttachmentCategorySearch
+//@param: ctx context.Context, info request.ExaAttachmentCategorySearch
 //@return: list interface{}, total int64, err error
 
-func (e *FileUploadAndDownloadService) GetFileRecordInfoList(info request.ExaAttachmentCategorySearch) (list []example.ExaFileUploadAndDownload, total int64, err error) {
+func (e *FileUploadAndDownloadService) GetFileRecordInfoList(ctx context.Context, info request.ExaAttachmentCategorySearch) (list []example.ExaFileUploadAndDownload, total int64, err error) {
 	limit := info.PageSize
 	offset := info.PageSize * (info.Page - 1)
-	db := global.GVA_DB.Model(&example.ExaFileUploadAndDownload{})
+	db := global.GVA_DB.WithContext(ctx).Model(&example.ExaFileUploadAndDownload{})
 
 	if len(info.Keyword) > 0 {
 		db = db.Where("name LIKE ?", "%"+info.Keyword+"%")
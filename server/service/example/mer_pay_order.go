@@ -2,9 +2,13 @@ package example
 
 import (
 	"context"
+	"fmt"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
 	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/shopspring/decimal"
+	"time"
 )
 
 type MerPayOrderService struct{}
@@ -37,6 +41,38 @@ func (merPayOrderService *MerPayOrderService) UpdateMerPayOrder(ctx context.Cont
 	return err
 }
 
+// ExpireMerPayOrder 将待支付订单标记为失败
+// 仅当订单仍为待支付状态时才会更新，返回值表示本次调用是否完成了状态变更，
+// 多个实例并发处理同一订单时只有一个会返回 true
+func (merPayOrderService *MerPayOrderService) ExpireMerPayOrder(ctx context.Context, id int64) (expired bool, err error) {
+	result := global.GVA_DB.WithContext(ctx).Model(&example.MerPayOrder{}).
+		Where("id = ? AND state = ?", id, *global.MER_PAY_ORDER_PENDING).
+		Update("state", *global.MER_PAY_ORDER_FAILED)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CancelMerPayOrder 将待支付订单标记为已取消
+// 仅当订单仍为待支付状态时才会更新，已被监控任务确认支付或已超时的订单不会被取消
+func (merPayOrderService *MerPayOrderService) CancelMerPayOrder(ctx context.Context, id int64) (canceled bool, err error) {
+	result := global.GVA_DB.WithContext(ctx).Model(&example.MerPayOrder{}).
+		Where("id = ? AND state = ?", id, *global.MER_PAY_ORDER_PENDING).
+		Update("state", *global.MER_PAY_ORDER_CANCELED)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkMerPayOrderPaid 将订单标记为已支付并记录渠道流水号
+// 待支付与已超时（宽限期内到账）的订单都可以转为已支付，返回值表示本次调用是否完成了状态变更
+func (merPayOrderService *MerPayOrderService) MarkMerPayOrderPaid(ctx context.Context, id int64, payTime time.Time, tradeNo string) (paid bool, err error) {
+	result := global.GVA_DB.WithContext(ctx).Model(&example.MerPayOrder{}).
+		Where("id = ? AND state IN ?", id, []int8{*global.MER_PAY_ORDER_PENDING, *global.MER_PAY_ORDER_FAILED}).
+		Updates(map[string]interface{}{
+			"state":    *global.MER_PAY_ORDER_PAID,
+			"pay_time": payTime,
+			"trade_no": tradeNo,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
 // GetMerPayOrder 根据id获取merPayOrder表记录
 // Author [yourname](https://github.com/yourname)
 func (merPayOrderService *MerPayOrderService) GetMerPayOrder(ctx context.Context, id int64) (merPayOrder example.MerPayOrder, err error) {
@@ -44,6 +80,12 @@ func (merPayOrderService *MerPayOrderService) GetMerPayOrder(ctx context.Context
 	return
 }
 
+// GetScopedMerPayOrder 按请求上下文中用户的数据权限获取订单，不在权限范围内时返回 gorm.ErrRecordNotFound
+func (merPayOrderService *MerPayOrderService) GetScopedMerPayOrder(ctx context.Context, id int64) (merPayOrder example.MerPayOrder, err error) {
+	err = global.GVA_DB.WithContext(ctx).Where("id = ?", id).First(&merPayOrder).Error
+	return
+}
+
 // GetMerPayOrderByInfo 获取merPayOrder表记录
 // Author [yourname](https://github.com/yourname)
 func (merPayOrderService *MerPayOrderService) GetMerPayOrderByInfo(ctx context.Context, info example.MerPayOrder) (merPayOrder example.MerPayOrder, err error) {
@@ -88,8 +130,15 @@ func (merPayOrderService *MerPayOrderService) GetMerPayOrderInfoList(ctx context
 	if info.RequestAmmount != nil && *info.RequestAmmount != "" {
 		db = db.Where("request_ammount = ?", *info.RequestAmmount)
 	}
-	if info.Ammount != nil {
-		db = db.Where("ammount = ?", *info.Ammount)
+	if info.Ammount != nil && *info.Ammount != "" {
+		ammount, err := decimal.NewFromString(*info.Ammount)
+		if err != nil {
+			return nil, 0, fmt.Errorf("金额格式错误: %w", err)
+		}
+		db = db.Where("ammount = ?", ammount)
+	}
+	if info.Currency != nil && *info.Currency != "" {
+		db = db.Where("currency = ?", utils.NormalizeCurrency(*info.Currency))
 	}
 	if len(info.PayTimeRange) == 2 {
 		db = db.Where("pay_time BETWEEN ? AND ? ", info.PayTimeRange[0], info.PayTimeRange[1])
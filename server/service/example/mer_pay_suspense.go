@@ -0,0 +1,145 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
+	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MerPaySuspenseService struct{}
+
+// RecordSuspense 记录一笔未匹配的渠道收款，同一商户的同一流水号只记录一次
+func (merPaySuspenseService *MerPaySuspenseService) RecordSuspense(ctx context.Context, suspense *example.MerPaySuspense) (err error) {
+	err = global.GVA_DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(suspense).Error
+	return err
+}
+
+// IsTradeKnown 渠道流水号是否已关联订单或已记录为挂账收款
+func (merPaySuspenseService *MerPaySuspenseService) IsTradeKnown(ctx context.Context, merId int64, tradeNo string) (bool, error) {
+	var count int64
+	err := global.GVA_DB.WithContext(ctx).Model(&example.MerPayOrder{}).Where("mer_id = ? AND trade_no = ?", merId, tradeNo).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = global.GVA_DB.WithContext(ctx).Model(&example.MerPaySuspense{}).Where("mer_id = ? AND trade_no = ?", merId, tradeNo).Count(&count).Error
+	return count > 0, err
+}
+
+// GetMerPaySuspense 根据id获取挂账收款
+func (merPaySuspenseService *MerPaySuspenseService) GetMerPaySuspense(ctx context.Context, id int64) (suspense example.MerPaySuspense, err error) {
+	err = global.GVA_DB.WithContext(ctx).Where("id = ?", id).First(&suspense).Error
+	return
+}
+
+// GetMerPaySuspenseInfoList 分页获取挂账收款
+func (merPaySuspenseService *MerPaySuspenseService) GetMerPaySuspenseInfoList(ctx context.Context, info exampleReq.MerPaySuspenseSearch) (list []example.MerPaySuspense, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.WithContext(ctx).Model(&example.MerPaySuspense{})
+	if info.MerId != nil {
+		db = db.Where("mer_id = ?", *info.MerId)
+	}
+	if info.TradeNo != nil && *info.TradeNo != "" {
+		db = db.Where("trade_no LIKE ?", "%"+*info.TradeNo+"%")
+	}
+	if info.State != nil {
+		db = db.Where("state = ?", *info.State)
+	}
+	if len(info.PayTimeRange) == 2 {
+		db = db.Where("pay_time BETWEEN ? AND ? ", info.PayTimeRange[0], info.PayTimeRange[1])
+	}
+	err = db.Count(&total).Error
+	if err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+// GetMerPayMatchLogs 获取订单的人工匹配记录
+func (merPaySuspenseService *MerPaySuspenseService) GetMerPayMatchLogs(ctx context.Context, orderId int64) (list []example.MerPayMatchLog, err error) {
+	err = global.GVA_DB.WithContext(ctx).Where("order_id = ?", orderId).Order("id desc").Find(&list).Error
+	return
+}
+
+// MatchSuspense 将挂账收款匹配到失败订单
+// 在同一事务中完成挂账收款核销、订单转为已支付和写入匹配记录，任一步骤条件不满足则整体回滚
+func (merPaySuspenseService *MerPaySuspenseService) MatchSuspense(ctx context.Context, req exampleReq.MatchSuspenseReq, operatorId int64, operatorIp string) (order example.MerPayOrder, err error) {
+	err = global.GVA_DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var suspense example.MerPaySuspense
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", req.SuspenseId).First(&suspense).Error; err != nil {
+			return errors.New("挂账收款不存在")
+		}
+		if suspense.State == nil || *suspense.State != *global.MER_PAY_SUSPENSE_PENDING {
+			return errors.New("挂账收款已处理")
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", req.OrderId).First(&order).Error; err != nil {
+			return errors.New("订单不存在")
+		}
+		if order.State == nil || *order.State != *global.MER_PAY_ORDER_FAILED {
+			return errors.New("只能匹配已失败的订单")
+		}
+		if order.MerId == nil || suspense.MerId == nil || *order.MerId != *suspense.MerId {
+			return errors.New("挂账收款与订单不属于同一商户")
+		}
+		if order.CurrencyCode() != suspense.CurrencyCode() {
+			return errors.New("挂账收款与订单币种不一致")
+		}
+		if order.RequestAmmount == nil || suspense.Amount == nil || !order.RequestAmmount.Equal(*suspense.Amount) {
+			return errors.New("挂账收款金额与订单金额不一致")
+		}
+
+		result := tx.Model(&example.MerPaySuspense{}).
+			Where("id = ? AND state = ?", req.SuspenseId, *global.MER_PAY_SUSPENSE_PENDING).
+			Updates(map[string]interface{}{"state": *global.MER_PAY_SUSPENSE_MATCHED, "matched_order_id": req.OrderId})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("挂账收款已处理")
+		}
+
+		payTime := time.Now()
+		if suspense.PayTime != nil {
+			payTime = *suspense.PayTime
+		}
+		result = tx.Model(&example.MerPayOrder{}).
+			Where("id = ? AND state = ?", req.OrderId, *global.MER_PAY_ORDER_FAILED).
+			Updates(map[string]interface{}{"state": *global.MER_PAY_ORDER_PAID, "pay_time": payTime, "trade_no": *suspense.TradeNo})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("订单状态已变更")
+		}
+
+		matchLog := example.MerPayMatchLog{
+			SuspenseId: suspense.Id,
+			OrderId:    order.Id,
+			PrevState:  order.State,
+			TradeNo:    suspense.TradeNo,
+			Amount:     suspense.Amount,
+			OperatorId: &operatorId,
+			OperatorIp: &operatorIp,
+			Remarks:    &req.Remarks,
+		}
+		if err := tx.Create(&matchLog).Error; err != nil {
+			return err
+		}
+
+		order.State = global.MER_PAY_ORDER_PAID
+		order.PayTime = &payTime
+		order.TradeNo = suspense.TradeNo
+		return nil
+	})
+	return order, err
+}
@@ -7,6 +7,7 @@ import (
 	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
 	exampleRes "github.com/flipped-aurora/gin-vue-admin/server/model/example/response"
 	payReq "github.com/flipped-aurora/gin-vue-admin/server/model/pay/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 )
 
 type MerUserService struct{}
@@ -50,13 +51,20 @@ func (merUserService *MerUserService) GetMerUser(ctx context.Context, id string)
 	return
 }
 
-// GetMerUser 根据id获取merUser表记录
+// GetNomalMerUser 获取可用于收款的merUser表记录
+// sandbox 为 true 时只返回沙箱商户，否则只返回正式商户；只返回与请求币种一致的商户
 // Author [yourname](https://github.com/yourname)
-func (merUserService *MerUserService) GetNomalMerUser(ctx context.Context, params payReq.PayQrcodeParms, userID uint) (list []example.MerUser, err error) {
+func (merUserService *MerUserService) GetNomalMerUser(ctx context.Context, params payReq.PayQrcodeParms, userID uint, sandbox bool) (list []example.MerUser, err error) {
 	db := global.GVA_DB.WithContext(ctx).Model(&example.MerUser{})
 	if params.MerId != nil {
 		db = db.Where("id = ?", *params.MerId)
 	}
+	if sandbox {
+		db = db.Where("mer_type = ?", example.MerTypeSandbox)
+	} else {
+		db = db.Where("mer_type <> ?", example.MerTypeSandbox)
+	}
+	db = db.Where("currency = ?", utils.NormalizeCurrency(params.Currency))
 	err = db.Where("state = ? AND is_del = ? AND sys_user_id = ?", 1, 0, userID).Find(&list).Error
 	return
 }
@@ -0,0 +1,215 @@
+package example
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
+	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	// riskVelocityKey 下单频率计数键前缀：risk_velocity:sysUserId:维度:标识:窗口
+	riskVelocityKey = "risk_velocity"
+	// riskVelocityWindow 下单频率统计窗口
+	riskVelocityWindow = time.Minute
+
+	// 用户未配置风控项时使用的默认值，与 GetDefaultConfigs 保持一致
+	defaultRiskIpOrderLimit     = 30
+	defaultRiskKeyOrderLimit    = 120
+	defaultRiskMaxPendingOrders = 50
+)
+
+type RiskRuleService struct{}
+
+// RiskRejectError 请求被风控规则拒绝
+type RiskRejectError struct {
+	Rule    string
+	Message string
+}
+
+func (e *RiskRejectError) Error() string {
+	return e.Message
+}
+
+// OrderRiskContext 下单风控检查所需的请求信息
+type OrderRiskContext struct {
+	SysUserId int64
+	ClientIp  string
+	KeyId     string // 调用方密钥标识，永久token为token摘要，JWT为用户ID
+	Amount    decimal.Decimal
+	Currency  string
+}
+
+// CheckOrderRequest 在选取商户与占用金额之前校验下单请求：IP与密钥下单频率
+func (riskRuleService *RiskRuleService) CheckOrderRequest(ctx context.Context, config SysUserConfig, rc OrderRiskContext) error {
+	ipLimit := parseRiskLimit(config.RiskIpOrderLimit, defaultRiskIpOrderLimit)
+	if err := riskRuleService.checkVelocity(ctx, rc.SysUserId, example.RiskRuleIpVelocity, "ip", rc.ClientIp, ipLimit); err != nil {
+		return err
+	}
+	keyLimit := parseRiskLimit(config.RiskKeyOrderLimit, defaultRiskKeyOrderLimit)
+	return riskRuleService.checkVelocity(ctx, rc.SysUserId, example.RiskRuleKeyVelocity, "key", rc.KeyId, keyLimit)
+}
+
+// AmountBlacklist 解析用户配置的金额黑名单，分配订单金额时跳过命中的金额
+// 配置错误时视为未配置，不阻断下单，保存配置时已做校验
+func (riskRuleService *RiskRuleService) AmountBlacklist(config SysUserConfig, sysUserId int64) []utils.AmountPattern {
+	if strings.TrimSpace(config.RiskAmountBlacklist) == "" {
+		return nil
+	}
+	patterns, err := utils.ParseAmountPatterns(config.RiskAmountBlacklist)
+	if err != nil {
+		global.GVA_LOG.Error("解析金额黑名单失败", zap.Int64("sysUserId", sysUserId), zap.Error(err))
+		return nil
+	}
+	return patterns
+}
+
+// RejectBlockedAmount 请求金额附近未被占用的金额全部命中金额黑名单时拒绝下单并记录命中
+func (riskRuleService *RiskRuleService) RejectBlockedAmount(ctx context.Context, rc OrderRiskContext, pattern utils.AmountPattern) error {
+	amount := utils.FormatAmount(rc.Amount, rc.Currency)
+	riskRuleService.RecordHit(ctx, example.RiskRuleHit{
+		SysUserId: &rc.SysUserId,
+		Rule:      strPtr(example.RiskRuleAmountBlocked),
+		Subject:   &amount,
+		Detail:    strPtr(fmt.Sprintf("可分配金额均命中金额规则 %s，请求IP %s", pattern, rc.ClientIp)),
+	})
+	return &RiskRejectError{Rule: example.RiskRuleAmountBlocked, Message: "该金额暂不支持下单"}
+}
+
+// CheckMerchantPending 校验商户当前待支付订单数是否已达上限
+func (riskRuleService *RiskRuleService) CheckMerchantPending(ctx context.Context, config SysUserConfig, sysUserId, merId int64) error {
+	limit := parseRiskLimit(config.RiskMaxPendingOrders, defaultRiskMaxPendingOrders)
+	if limit <= 0 {
+		return nil
+	}
+	var pending int64
+	err := global.GVA_DB.WithContext(ctx).Model(&example.MerPayOrder{}).
+		Where("mer_id = ? AND state = ?", merId, *global.MER_PAY_ORDER_PENDING).
+		Count(&pending).Error
+	if err != nil {
+		return err
+	}
+	if pending < limit {
+		return nil
+	}
+	riskRuleService.RecordHit(ctx, example.RiskRuleHit{
+		SysUserId: &sysUserId,
+		Rule:      strPtr(example.RiskRulePendingLimit),
+		Subject:   strPtr(strconv.FormatInt(merId, 10)),
+		MerId:     &merId,
+		Detail:    strPtr(fmt.Sprintf("待支付订单数 %d 已达上限 %d", pending, limit)),
+	})
+	return &RiskRejectError{Rule: example.RiskRulePendingLimit, Message: "商户待支付订单过多，请稍后重试"}
+}
+
+// IsPayerBlocked 判断渠道流水中的付款人是否在拉黑列表中
+func (riskRuleService *RiskRuleService) IsPayerBlocked(config SysUserConfig, payer string) bool {
+	payer = strings.TrimSpace(payer)
+	if payer == "" {
+		return false
+	}
+	for _, blocked := range strings.FieldsFunc(config.RiskBlockedPayers, func(r rune) bool {
+		return r == ',' || r == '，' || r == '\n' || r == ';'
+	}) {
+		if strings.EqualFold(strings.TrimSpace(blocked), payer) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordHit 记录风控规则命中，写入失败只记录日志
+func (riskRuleService *RiskRuleService) RecordHit(ctx context.Context, hit example.RiskRuleHit) {
+	fields := []zap.Field{zap.Stringp("rule", hit.Rule), zap.Stringp("subject", hit.Subject), zap.Stringp("detail", hit.Detail)}
+	if hit.SysUserId != nil {
+		fields = append(fields, zap.Int64("sysUserId", *hit.SysUserId))
+	}
+	global.GVA_LOG.Warn("命中风控规则", fields...)
+	if err := global.GVA_DB.WithContext(ctx).Create(&hit).Error; err != nil {
+		global.GVA_LOG.Error("记录风控命中失败", zap.Error(err))
+	}
+}
+
+// GetRiskRuleHitInfoList 分页获取风控命中记录
+func (riskRuleService *RiskRuleService) GetRiskRuleHitInfoList(ctx context.Context, info exampleReq.RiskRuleHitSearch) (list []example.RiskRuleHit, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.WithContext(ctx).Model(&example.RiskRuleHit{})
+	if info.Rule != nil && *info.Rule != "" {
+		db = db.Where("rule = ?", *info.Rule)
+	}
+	if info.Subject != nil && *info.Subject != "" {
+		db = db.Where("subject LIKE ?", "%"+*info.Subject+"%")
+	}
+	if info.MerId != nil {
+		db = db.Where("mer_id = ?", *info.MerId)
+	}
+	if len(info.CreateTimeRange) == 2 {
+		db = db.Where("create_time BETWEEN ? AND ? ", info.CreateTimeRange[0], info.CreateTimeRange[1])
+	}
+	err = db.Count(&total).Error
+	if err != nil {
+		return
+	}
+	if limit != 0 {
+		db = db.Limit(limit).Offset(offset)
+	}
+	err = db.Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+// checkVelocity 按固定窗口统计下单次数，超过上限时拒绝并记录命中
+// Redis 异常时放行，避免风控组件故障导致无法下单
+func (riskRuleService *RiskRuleService) checkVelocity(ctx context.Context, sysUserId int64, rule, dimension, subject string, limit int64) error {
+	if limit <= 0 || subject == "" {
+		return nil
+	}
+	window := time.Now().Unix() / int64(riskVelocityWindow/time.Second)
+	key := fmt.Sprintf("%s:%d:%s:%s:%d", riskVelocityKey, sysUserId, dimension, subject, window)
+	count, err := global.GVA_REDIS.Incr(ctx, key).Result()
+	if err != nil {
+		global.GVA_LOG.Error("风控计数失败", zap.String("key", key), zap.Error(err))
+		return nil
+	}
+	if count == 1 {
+		global.GVA_REDIS.Expire(ctx, key, 2*riskVelocityWindow)
+	}
+	if count <= limit {
+		return nil
+	}
+	// 同一窗口内只记录首次超限，避免攻击时大量写入命中记录
+	if count == limit+1 {
+		riskRuleService.RecordHit(ctx, example.RiskRuleHit{
+			SysUserId: &sysUserId,
+			Rule:      &rule,
+			Subject:   &subject,
+			Detail:    strPtr(fmt.Sprintf("%s 内下单次数超过上限 %d", riskVelocityWindow, limit)),
+		})
+	}
+	return &RiskRejectError{Rule: rule, Message: "下单过于频繁，请稍后重试"}
+}
+
+// parseRiskLimit 解析风控上限配置，未配置或格式错误时使用默认值
+func parseRiskLimit(value string, fallback int64) int64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		return fallback
+	}
+	return limit
+}
+
+func strPtr(s string) *string {
+	return &s
+}
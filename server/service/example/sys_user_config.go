@@ -7,9 +7,12 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
 	exampleReq "github.com/flipped-aurora/gin-vue-admin/server/model/example/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -41,6 +44,41 @@ func (sysUserConfigService *SysUserConfigService) GetDefaultConfigs() []DefaultC
 			Status: false,
 			FormId: 1,
 		},
+		{
+			Name:   "risk_ip_order_limit",
+			Title:  "单个IP每分钟最多下单数(0为不限制)",
+			Value:  "30",
+			Status: false,
+			FormId: 2,
+		},
+		{
+			Name:   "risk_key_order_limit",
+			Title:  "单个密钥每分钟最多下单数(0为不限制)",
+			Value:  "120",
+			Status: false,
+			FormId: 2,
+		},
+		{
+			Name:   "risk_max_pending_orders",
+			Title:  "单个商户最多同时待支付订单数(0为不限制)",
+			Value:  "50",
+			Status: false,
+			FormId: 2,
+		},
+		{
+			Name:   "risk_amount_blacklist",
+			Title:  "金额黑名单(逗号分隔，支持 88.88、100-200、*.99)",
+			Value:  "",
+			Status: false,
+			FormId: 2,
+		},
+		{
+			Name:   "risk_blocked_payers",
+			Title:  "拉黑付款人(逗号分隔，匹配渠道流水中的付款人标识)",
+			Value:  "",
+			Status: false,
+			FormId: 2,
+		},
 	}
 }
 
@@ -101,6 +139,9 @@ func (sysUserConfigService *SysUserConfigService) CreateSysUserConfig(ctx contex
 		if err := sysUserConfigService.validateConfigName(*sysUserConfig.Name); err != nil {
 			return err
 		}
+		if err := validateConfigValue(*sysUserConfig.Name, sysUserConfig.Value); err != nil {
+			return err
+		}
 	}
 	
 	db := global.GVA_DB.WithContext(ctx)
@@ -132,6 +173,9 @@ func (sysUserConfigService *SysUserConfigService) UpdateSysUserConfig(ctx contex
 		if err := sysUserConfigService.validateConfigName(*sysUserConfig.Name); err != nil {
 			return err
 		}
+		if err := validateConfigValue(*sysUserConfig.Name, sysUserConfig.Value); err != nil {
+			return err
+		}
 	}
 	
 	db := global.GVA_DB.WithContext(ctx).Model(&example.SysUserConfig{})
@@ -261,8 +305,13 @@ func (sysUserConfigService *SysUserConfigService) GetConfigBySysUserID(ctx conte
 }
 
 type SysUserConfig struct {
-	AllowRequestUrl string `json:"allow_request_url"`
-	EncryptKey      string `json:"encrypt_key"`
+	AllowRequestUrl      string `json:"allow_request_url"`
+	EncryptKey           string `json:"encrypt_key"`
+	RiskIpOrderLimit     string `json:"risk_ip_order_limit"`
+	RiskKeyOrderLimit    string `json:"risk_key_order_limit"`
+	RiskMaxPendingOrders string `json:"risk_max_pending_orders"`
+	RiskAmountBlacklist  string `json:"risk_amount_blacklist"`
+	RiskBlockedPayers    string `json:"risk_blocked_payers"`
 }
 
 // GetSysUserConfigPublic 获取公共配置（form_id=0）
@@ -288,3 +337,22 @@ func (sysUserConfigService *SysUserConfigService) validateConfigName(configName
 	
 	return fmt.Errorf("配置项 '%s' 不在允许的配置列表中，只允许以下配置项: %v", configName, allowedNames)
 }
+
+// validateConfigValue 校验风控配置项的取值格式，避免错误配置在下单时才暴露
+func validateConfigValue(configName string, value *string) error {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil
+	}
+	switch configName {
+	case "risk_ip_order_limit", "risk_key_order_limit", "risk_max_pending_orders":
+		limit, err := strconv.ParseInt(strings.TrimSpace(*value), 10, 64)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("配置项 '%s' 必须为非负整数", configName)
+		}
+	case "risk_amount_blacklist":
+		if _, err := utils.ParseAmountPatterns(*value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -18,6 +18,15 @@ type ServiceGroup struct {
 	SysExportTemplateService
 	SysParamsService
 	SysVersionService
+	UserTotpService
+	UserSessionService
+	LoginGuardService
+	OidcService
+	DataRuleService
+	ExportJobService
+	ExportScheduleService
+	AuditLogService
+	MigrationService
 	AutoCodePlugin   autoCodePlugin
 	AutoCodePackage  autoCodePackage
 	AutoCodeHistory  autoCodeHistory
@@ -2,17 +2,40 @@ package system
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+const (
+	// jwtBlacklistKey Redis 中黑名单键前缀，键为 jwt 的 SHA256 摘要，过期时间等于 token 剩余有效期
+	jwtBlacklistKey = "jwt_blacklist"
+	// jwtBlacklistChannel 黑名单变更广播频道，各实例收到后写入本地缓存
+	jwtBlacklistChannel = "jwt_blacklist_channel"
 )
 
 type JwtService struct{}
 
 var JwtServiceApp = new(JwtService)
 
+// jwtBlacklistMessage 黑名单广播消息
+type jwtBlacklistMessage struct {
+	Hash      string `json:"hash"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: JsonInBlacklist
 //@description: 拉黑jwt
@@ -20,12 +43,39 @@ var JwtServiceApp = new(JwtService)
 //@return: err error
 
 func (jwtService *JwtService) JsonInBlacklist(jwtList system.JwtBlacklist) (err error) {
+	expiresAt := jwtExpiresAt(jwtList.Jwt)
+	if !expiresAt.After(time.Now()) {
+		// 已过期的 token 无需拉黑
+		return nil
+	}
+	jwtList.ExpiresAt = &expiresAt
 	err = global.GVA_DB.Create(&jwtList).Error
 	if err != nil {
 		return
 	}
-	global.BlackCache.SetDefault(jwtList.Jwt, struct{}{})
-	return
+	hash := jwtHash(jwtList.Jwt)
+	setLocalBlacklist(hash, expiresAt)
+	if global.GVA_REDIS == nil {
+		return
+	}
+	// 已写入数据库，Redis 失败时重启或重新订阅后仍会从数据库补齐，这里只记录日志
+	ctx := context.Background()
+	if err := global.GVA_REDIS.Set(ctx, jwtBlacklistKey+":"+hash, 1, time.Until(expiresAt)).Err(); err != nil {
+		global.GVA_LOG.Error("jwt黑名单写入redis失败!", zap.Error(err))
+		return nil
+	}
+	msg, _ := json.Marshal(jwtBlacklistMessage{Hash: hash, ExpiresAt: expiresAt.Unix()})
+	if err := global.GVA_REDIS.Publish(ctx, jwtBlacklistChannel, msg).Err(); err != nil {
+		global.GVA_LOG.Error("jwt黑名单广播失败!", zap.Error(err))
+	}
+	return nil
+}
+
+// IsBlacklist 判断 jwt 是否已被拉黑，只查本地缓存
+// 本地缓存在启动时从数据库加载，运行中由广播更新，订阅断开重连后从 Redis 全量同步
+func (jwtService *JwtService) IsBlacklist(token string) bool {
+	_, ok := global.BlackCache.Get(jwtHash(token))
+	return ok
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -39,14 +89,117 @@ func (jwtService *JwtService) GetRedisJWT(userName string) (redisJWT string, err
 	return redisJWT, err
 }
 
+// PruneExpired 删除 token 已过期的黑名单记录
+// 旧数据没有过期时间，按创建时间超过刷新令牌有效期（token 最长有效期）清理
+func (jwtService *JwtService) PruneExpired() (int64, error) {
+	now := time.Now()
+	db := global.GVA_DB.Unscoped().Where("expires_at < ?", now)
+	if dr, err := utils.ParseDuration(global.GVA_CONFIG.JWT.RefreshExpiresTime); err == nil {
+		db = db.Or("expires_at IS NULL AND created_at < ?", now.Add(-dr))
+	}
+	result := db.Delete(&system.JwtBlacklist{})
+	return result.RowsAffected, result.Error
+}
+
+// LoadAll 启动时将数据库中未过期的黑名单加载到本地缓存，启用 Redis 时同步补齐 Redis
 func LoadAll() {
-	var data []string
-	err := global.GVA_DB.Model(&system.JwtBlacklist{}).Select("jwt").Find(&data).Error
+	var data []system.JwtBlacklist
+	err := global.GVA_DB.Model(&system.JwtBlacklist{}).Select("jwt", "expires_at", "created_at").
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).Find(&data).Error
 	if err != nil {
 		global.GVA_LOG.Error("加载数据库jwt黑名单失败!", zap.Error(err))
 		return
 	}
-	for i := 0; i < len(data); i++ {
-		global.BlackCache.SetDefault(data[i], struct{}{})
-	} // jwt黑名单 加入 BlackCache 中
+	ctx := context.Background()
+	for i := range data {
+		expiresAt := jwtExpiresAt(data[i].Jwt)
+		if data[i].ExpiresAt != nil {
+			expiresAt = *data[i].ExpiresAt
+		}
+		if !expiresAt.After(time.Now()) {
+			continue
+		}
+		hash := jwtHash(data[i].Jwt)
+		setLocalBlacklist(hash, expiresAt)
+		if global.GVA_REDIS != nil {
+			global.GVA_REDIS.SetNX(ctx, jwtBlacklistKey+":"+hash, 1, time.Until(expiresAt))
+		}
+	}
+}
+
+// SubscribeBlacklist 订阅黑名单广播，收到其他实例的拉黑消息后写入本地缓存
+// 每次（重新）订阅成功时从 Redis 全量同步一次，补齐断线期间漏收的消息；ctx 取消时退出
+func SubscribeBlacklist(ctx context.Context) {
+	if global.GVA_REDIS == nil {
+		return
+	}
+	pubsub := global.GVA_REDIS.Subscribe(ctx, jwtBlacklistChannel)
+	defer pubsub.Close()
+	// Receive 阻塞读取时不响应 ctx 取消，关闭连接使其返回
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			global.GVA_LOG.Error("jwt黑名单订阅异常!", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				syncBlacklistFromRedis(ctx)
+			}
+		case *redis.Message:
+			var payload jwtBlacklistMessage
+			if err := json.Unmarshal([]byte(m.Payload), &payload); err != nil || payload.Hash == "" {
+				global.GVA_LOG.Error("jwt黑名单广播消息格式错误!", zap.String("payload", m.Payload))
+				continue
+			}
+			setLocalBlacklist(payload.Hash, time.Unix(payload.ExpiresAt, 0))
+		}
+	}
+}
+
+// syncBlacklistFromRedis 扫描 Redis 中的黑名单写入本地缓存
+func syncBlacklistFromRedis(ctx context.Context) {
+	iter := global.GVA_REDIS.Scan(ctx, 0, jwtBlacklistKey+":*", 500).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		ttl, err := global.GVA_REDIS.PTTL(ctx, key).Result()
+		if err != nil || ttl <= 0 {
+			continue
+		}
+		setLocalBlacklist(strings.TrimPrefix(key, jwtBlacklistKey+":"), time.Now().Add(ttl))
+	}
+	if err := iter.Err(); err != nil && !errors.Is(err, context.Canceled) {
+		global.GVA_LOG.Error("同步redis jwt黑名单失败!", zap.Error(err))
+	}
+}
+
+func setLocalBlacklist(hash string, expiresAt time.Time) {
+	if ttl := time.Until(expiresAt); ttl > 0 {
+		global.BlackCache.Set(hash, struct{}{}, ttl)
+	}
+}
+
+// jwtHash 黑名单在缓存中以摘要为键，避免在 Redis 与广播中传递完整 token
+func jwtHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// jwtExpiresAt 读取 token 的过期时间，无法解析时按配置的最长有效期计算
+func jwtExpiresAt(token string) time.Time {
+	var claims systemReq.CustomClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err == nil && claims.ExpiresAt != nil {
+		return claims.ExpiresAt.Time
+	}
+	dr, _ := utils.ParseDuration(global.GVA_CONFIG.JWT.ExpiresTime)
+	return time.Now().Add(dr)
 }
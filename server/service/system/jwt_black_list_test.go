@@ -0,0 +1,107 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+)
+
+func Test_jwtExpiresAt(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	claims := request.CustomClaims{RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(exp)}}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := jwtExpiresAt(token); !got.Equal(exp) {
+		t.Errorf("jwtExpiresAt() = %v, want %v", got, exp)
+	}
+}
+
+func Test_jwtHash(t *testing.T) {
+	if jwtHash("a") == jwtHash("b") || len(jwtHash("a")) != 64 {
+		t.Error("jwtHash 应返回64位十六进制摘要且不同输入结果不同")
+	}
+}
+
+func TestSubscribeBlacklist_CrossInstance(t *testing.T) {
+	globaltest.DB(t, &system.JwtBlacklist{})
+	globaltest.Redis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// 订阅前其他实例已拉黑的 token，订阅成功时从 Redis 同步
+	if err := global.GVA_REDIS.Set(ctx, jwtBlacklistKey+":"+jwtHash("token-a"), 1, time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if JwtServiceApp.IsBlacklist("token-a") {
+		t.Fatal("IsBlacklist should only check the local cache")
+	}
+	go func() {
+		defer close(done)
+		SubscribeBlacklist(ctx)
+	}()
+	waitBlacklisted(t, "token-a")
+
+	// 订阅期间其他实例拉黑的 token 通过广播写入本地缓存
+	msg, _ := json.Marshal(jwtBlacklistMessage{Hash: jwtHash("token-b"), ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err := global.GVA_REDIS.Publish(ctx, jwtBlacklistChannel, msg).Err(); err != nil {
+		t.Fatal(err)
+	}
+	waitBlacklisted(t, "token-b")
+	if JwtServiceApp.IsBlacklist("token-c") {
+		t.Fatal("token-c should not be blacklisted")
+	}
+}
+
+func waitBlacklisted(t *testing.T, token string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !JwtServiceApp.IsBlacklist(token) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not blacklisted", token)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJwtService_PruneExpired(t *testing.T) {
+	db := globaltest.DB(t, &system.JwtBlacklist{})
+	global.GVA_CONFIG.JWT.RefreshExpiresTime = "72h"
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	rows := []system.JwtBlacklist{
+		{Jwt: "expired", ExpiresAt: &past},
+		{Jwt: "valid", ExpiresAt: &future},
+		{Jwt: "legacy-old"},
+		{Jwt: "legacy-new"},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 旧数据没有过期时间，按创建时间是否超过刷新令牌有效期清理
+	db.Model(&rows[2]).Update("created_at", now.Add(-73*time.Hour))
+	db.Model(&rows[3]).Update("created_at", now.Add(-71*time.Hour))
+
+	n, err := JwtServiceApp.PruneExpired()
+	if err != nil || n != 2 {
+		t.Fatalf("PruneExpired() = %d, %v", n, err)
+	}
+	var left []string
+	db.Model(&system.JwtBlacklist{}).Order("id").Pluck("jwt", &left)
+	if len(left) != 2 || left[0] != "valid" || left[1] != "legacy-new" {
+		t.Fatalf("left = %v", left)
+	}
+}
@@ -0,0 +1,558 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// auditBeforeKey 更新/删除前捕获的数据在语句实例中的键
+	auditBeforeKey = "audit:before"
+	// auditValueLimit 单个字段 JSON 超过该长度时只记录摘要，避免大字段撑满审计表
+	auditValueLimit = 1024
+	// auditVerifyBatch 校验时每批读取的记录数
+	auditVerifyBatch = 500
+	// auditProblemLimit 校验结果最多返回的问题数
+	auditProblemLimit = 100
+)
+
+var (
+	ErrAuditLogImmutable = errors.New("审计记录只允许追加，不能修改或删除")
+	ErrAuditHashKey      = errors.New("未配置审计链哈希密钥 audit-log.hash-key")
+	ErrAuditChainInvalid = errors.New("审计链校验未通过，不能创建校验点")
+)
+
+var (
+	auditMu     sync.RWMutex
+	auditTables = make(map[string]struct{})
+)
+
+// RegisterAuditModel 登记需要审计的模型，按表名匹配，未登记的表不记录
+func RegisterAuditModel(models ...schema.Tabler) {
+	tables := make([]string, 0, len(models))
+	for _, m := range models {
+		tables = append(tables, m.TableName())
+	}
+	RegisterAuditTable(tables...)
+}
+
+// RegisterAuditTable 按表名登记需要审计的表，用于表名由命名规则生成、没有 TableName 方法的模型
+func RegisterAuditTable(tables ...string) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	for _, table := range tables {
+		auditTables[table] = struct{}{}
+	}
+}
+
+func auditEnabled(stmt *gorm.Statement) bool {
+	if stmt == nil || stmt.Schema == nil {
+		return false
+	}
+	auditMu.RLock()
+	defer auditMu.RUnlock()
+	_, ok := auditTables[stmt.Table]
+	return ok
+}
+
+type AuditLogService struct{}
+
+var AuditLogServiceApp = new(AuditLogService)
+
+// auditRow 一行数据的快照，ID 为主键的字符串形式
+type auditRow struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// auditChange 单个字段的变更
+type auditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// BeforeChange 在更新/删除前读取将被修改的行，审计表本身拒绝更新与删除
+// 只有通过 GORM 执行的语句会被记录，db.Exec 等原生 SQL 不经过该回调，需由链校验发现
+func (auditLogService *AuditLogService) BeforeChange(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return
+	}
+	if table := tx.Statement.Table; table == (system.SysAuditLog{}).TableName() || table == (system.SysAuditCheckpoint{}).TableName() {
+		_ = tx.AddError(ErrAuditLogImmutable)
+		return
+	}
+	if !auditEnabled(tx.Statement) {
+		return
+	}
+	rows, err := auditLogService.captureRows(tx)
+	if err != nil {
+		_ = tx.AddError(fmt.Errorf("读取审计数据失败: %w", err))
+		return
+	}
+	tx.InstanceSet(auditBeforeKey, rows)
+}
+
+// AfterCreate 记录新建的行
+func (auditLogService *AuditLogService) AfterCreate(tx *gorm.DB) {
+	stmt := tx.Statement
+	if tx.Error != nil || !auditEnabled(stmt) || tx.RowsAffected == 0 {
+		return
+	}
+	rows := auditRows(stmt, stmt.ReflectValue)
+	entries := make([]system.SysAuditLog, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, system.SysAuditLog{
+			RecordID: row.ID,
+			Action:   system.AuditActionCreate,
+			After:    auditJSON(auditSnapshot(row.Values)),
+		})
+	}
+	auditLogService.appendEntries(tx, entries)
+}
+
+// AfterUpdate 重新读取更新后的行并记录字段差异，没有实际变化的行不记录
+func (auditLogService *AuditLogService) AfterUpdate(tx *gorm.DB) {
+	stmt := tx.Statement
+	if tx.Error != nil || !auditEnabled(stmt) || tx.RowsAffected == 0 {
+		return
+	}
+	before, _ := tx.InstanceGet(auditBeforeKey)
+	rows, _ := before.([]auditRow)
+	if len(rows) == 0 {
+		return
+	}
+	// 没有主键的表（如角色菜单、角色按钮关联表）无法重新读取更新后的行，只记录变更前的数据
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		entries := make([]system.SysAuditLog, 0, len(rows))
+		for _, row := range rows {
+			entries = append(entries, system.SysAuditLog{
+				RecordID: row.ID,
+				Action:   system.AuditActionUpdate,
+				Before:   auditJSON(auditSnapshot(row.Values)),
+			})
+		}
+		auditLogService.appendEntries(tx, entries)
+		return
+	}
+	ids := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.Values[stmt.Schema.PrioritizedPrimaryField.DBName])
+	}
+	after := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	err := auditLogService.query(tx).Unscoped().
+		Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: stmt.Schema.PrioritizedPrimaryField.DBName}, Values: ids}).
+		Find(after.Interface()).Error
+	if err != nil {
+		_ = tx.AddError(fmt.Errorf("读取审计数据失败: %w", err))
+		return
+	}
+	afterRows := make(map[string]auditRow)
+	for _, row := range auditRows(stmt, after.Elem()) {
+		afterRows[row.ID] = row
+	}
+
+	entries := make([]system.SysAuditLog, 0, len(rows))
+	for _, row := range rows {
+		changed, ok := afterRows[row.ID]
+		if !ok {
+			continue
+		}
+		diff := auditDiff(stmt.Schema, row.Values, changed.Values)
+		if len(diff) == 0 {
+			continue
+		}
+		entries = append(entries, system.SysAuditLog{
+			RecordID: row.ID,
+			Action:   system.AuditActionUpdate,
+			Before:   auditJSON(auditSnapshot(row.Values)),
+			After:    auditJSON(auditSnapshot(changed.Values)),
+			Diff:     auditJSON(diff),
+		})
+	}
+	auditLogService.appendEntries(tx, entries)
+}
+
+// AfterDelete 记录被删除的行，软删除同样记为删除
+func (auditLogService *AuditLogService) AfterDelete(tx *gorm.DB) {
+	if tx.Error != nil || !auditEnabled(tx.Statement) || tx.RowsAffected == 0 {
+		return
+	}
+	before, _ := tx.InstanceGet(auditBeforeKey)
+	rows, _ := before.([]auditRow)
+	entries := make([]system.SysAuditLog, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, system.SysAuditLog{
+			RecordID: row.ID,
+			Action:   system.AuditActionDelete,
+			Before:   auditJSON(auditSnapshot(row.Values)),
+		})
+	}
+	auditLogService.appendEntries(tx, entries)
+}
+
+// query 返回与当前语句共用连接（事务）且不做数据权限过滤的查询
+func (auditLogService *AuditLogService) query(tx *gorm.DB) *gorm.DB {
+	ctx := tx.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tx.Session(&gorm.Session{NewDB: true, SkipHooks: true, Context: utils.WithoutDataScope(ctx)}).Table(tx.Statement.Table)
+}
+
+// captureRows 按当前语句的条件与模型主键读取将被影响的行
+func (auditLogService *AuditLogService) captureRows(tx *gorm.DB) ([]auditRow, error) {
+	stmt := tx.Statement
+	query := auditLogService.query(tx)
+	if stmt.Unscoped {
+		query = query.Unscoped()
+	}
+	conditions := false
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			query = query.Clauses(clause.Where{Exprs: append([]clause.Expression(nil), where.Exprs...)})
+			conditions = true
+		}
+	}
+	var ids []interface{}
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk != nil {
+		for _, row := range auditRows(stmt, stmt.ReflectValue) {
+			if row.ID != "" {
+				ids = append(ids, row.Values[pk.DBName])
+			}
+		}
+	}
+	if len(ids) > 0 {
+		query = query.Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: ids})
+		conditions = true
+	}
+	// 没有任何条件的语句会被 GORM 拒绝，除非显式允许全表更新
+	if !conditions && !stmt.AllowGlobalUpdate {
+		return nil, nil
+	}
+	rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if err := query.Find(rows.Interface()).Error; err != nil {
+		return nil, err
+	}
+	return auditRows(stmt, rows.Elem()), nil
+}
+
+// appendEntries 在当前语句的事务中追加审计记录，失败时回滚业务写入
+func (auditLogService *AuditLogService) appendEntries(tx *gorm.DB, entries []system.SysAuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	ctx := tx.Statement.Context
+	userID, authorityID := utils.ContextUserID(ctx), utils.ContextAuthorityID(ctx)
+	now := time.Now().Truncate(time.Second)
+	for i := range entries {
+		entries[i].Table = tx.Statement.Table
+		entries[i].UserID = userID
+		entries[i].AuthorityID = authorityID
+		entries[i].CreatedAt = now
+	}
+	db := tx.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+	if err := appendAuditLogs(db, entries); err != nil {
+		_ = tx.AddError(fmt.Errorf("写入审计记录失败: %w", err))
+	}
+}
+
+// appendAuditLogs 锁定链尾后依次计算序号与哈希并写入，未配置哈希密钥时拒绝写入
+func appendAuditLogs(db *gorm.DB, entries []system.SysAuditLog) error {
+	key, err := auditHashKey()
+	if err != nil {
+		return err
+	}
+	var last system.SysAuditLog
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).Order("seq desc").Limit(1).Find(&last).Error
+	if err != nil {
+		return err
+	}
+	seq, prev := last.Seq, last.Hash
+	for i := range entries {
+		seq++
+		entries[i].Seq = seq
+		entries[i].PrevHash = prev
+		entries[i].Hash = auditHash(key, entries[i])
+		prev = entries[i].Hash
+	}
+	return db.Create(&entries).Error
+}
+
+// auditHashKey 审计链的 HMAC 密钥，必须单独配置，不与 JWT 等其他用途共用
+func auditHashKey() ([]byte, error) {
+	key := global.GVA_CONFIG.AuditLog.HashKey
+	if key == "" {
+		return nil, ErrAuditHashKey
+	}
+	return []byte(key), nil
+}
+
+// auditCheckpointSignature 校验点签名，与记录哈希使用同一密钥，带前缀区分用途
+func auditCheckpointSignature(key []byte, seq uint64, hash string) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "checkpoint|%d|%s", seq, hash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// auditHash 计算记录的哈希，文本字段带长度前缀避免拼接歧义
+func auditHash(key []byte, entry system.SysAuditLog) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d|%d|%d|%d|", entry.Seq, entry.CreatedAt.Unix(), entry.UserID, entry.AuthorityID)
+	for _, part := range []string{entry.PrevHash, entry.Table, entry.RecordID, entry.Action, entry.Before, entry.After, entry.Diff} {
+		fmt.Fprintf(mac, "%d:%s|", len(part), part)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// auditRows 将结构体或切片转换为按列名索引的快照
+func auditRows(stmt *gorm.Statement, value reflect.Value) []auditRow {
+	value = reflect.Indirect(value)
+	var rows []auditRow
+	switch value.Kind() {
+	case reflect.Struct:
+		rows = append(rows, auditRowOf(stmt, value))
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if elem := reflect.Indirect(value.Index(i)); elem.Kind() == reflect.Struct {
+				rows = append(rows, auditRowOf(stmt, elem))
+			}
+		}
+	}
+	return rows
+}
+
+func auditRowOf(stmt *gorm.Statement, value reflect.Value) auditRow {
+	row := auditRow{Values: make(map[string]interface{}, len(stmt.Schema.Fields))}
+	for _, f := range stmt.Schema.Fields {
+		if f.DBName == "" || !f.Readable {
+			continue
+		}
+		v, zero := f.ValueOf(stmt.Context, value)
+		row.Values[f.DBName] = v
+		if f == stmt.Schema.PrioritizedPrimaryField && !zero {
+			row.ID = fmt.Sprint(reflect.Indirect(reflect.ValueOf(v)).Interface())
+		}
+	}
+	// 没有主键时以全部字段的值标识记录，如 sys_base_menu_id=1,sys_authority_authority_id=888
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		parts := make([]string, 0, len(stmt.Schema.DBNames))
+		for _, name := range stmt.Schema.DBNames {
+			if v, ok := row.Values[name]; ok {
+				parts = append(parts, fmt.Sprintf("%s=%v", name, reflect.Indirect(reflect.ValueOf(v)).Interface()))
+			}
+		}
+		row.ID = strings.Join(parts, ",")
+	}
+	return row
+}
+
+// auditDiff 比较前后快照，自动维护的更新时间不计入差异，敏感字段只记录发生了变化
+func auditDiff(s *schema.Schema, before, after map[string]interface{}) map[string]auditChange {
+	diff := make(map[string]auditChange)
+	for _, f := range s.Fields {
+		if f.DBName == "" || f.AutoUpdateTime > 0 {
+			continue
+		}
+		b, _ := json.Marshal(before[f.DBName])
+		a, _ := json.Marshal(after[f.DBName])
+		if bytes.Equal(a, b) {
+			continue
+		}
+		diff[f.DBName] = auditChange{Before: auditValue(f.DBName, before[f.DBName]), After: auditValue(f.DBName, after[f.DBName])}
+	}
+	return diff
+}
+
+func auditSnapshot(values map[string]interface{}) map[string]interface{} {
+	snapshot := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		snapshot[k] = auditValue(k, v)
+	}
+	return snapshot
+}
+
+// auditValue 敏感字段脱敏，过长的值替换为摘要
+func auditValue(name string, v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" || string(data) == `""` {
+		return v
+	}
+	if utils.IsSensitiveField(name, global.GVA_CONFIG.OperationRecord.RedactFields) {
+		return utils.RedactedValue
+	}
+	if len(data) > auditValueLimit {
+		return fmt.Sprintf("sha256:%x (%d bytes)", sha256.Sum256(data), len(data))
+	}
+	return v
+}
+
+func auditJSON(v interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return ""
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+//@function: GetAuditLogList
+//@description: 分页获取审计记录，按序号倒序
+//@param: info systemReq.SysAuditLogSearch
+//@return: list []system.SysAuditLog, total int64, err error
+
+func (auditLogService *AuditLogService) GetAuditLogList(info systemReq.SysAuditLogSearch) (list []system.SysAuditLog, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&system.SysAuditLog{})
+	if info.Table != "" {
+		db = db.Where("table_name = ?", info.Table)
+	}
+	if info.RecordID != "" {
+		db = db.Where("record_id = ?", info.RecordID)
+	}
+	if info.Action != "" {
+		db = db.Where("action = ?", info.Action)
+	}
+	if info.UserID != 0 {
+		db = db.Where("user_id = ?", info.UserID)
+	}
+	if info.StartCreatedAt != nil && info.EndCreatedAt != nil {
+		db = db.Where("created_at BETWEEN ? AND ?", info.StartCreatedAt, info.EndCreatedAt)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Limit(limit).Offset(offset).Order("seq desc").Find(&list).Error
+	return list, total, err
+}
+
+//@function: CreateAuditCheckpoint
+//@description: 校验审计链后保存当前链尾的校验点，返回的校验点应另行保存到系统之外，校验时传入以发现链尾被截断
+//@return: checkpoint system.SysAuditCheckpoint, err error
+
+func (auditLogService *AuditLogService) CreateAuditCheckpoint() (checkpoint system.SysAuditCheckpoint, err error) {
+	key, err := auditHashKey()
+	if err != nil {
+		return checkpoint, err
+	}
+	result, err := auditLogService.VerifyAuditLogs(nil)
+	if err != nil {
+		return checkpoint, err
+	}
+	if !result.Valid {
+		return checkpoint, ErrAuditChainInvalid
+	}
+	if result.HeadSeq == 0 {
+		return checkpoint, errors.New("审计链为空，无需创建校验点")
+	}
+	err = global.GVA_DB.Where("seq = ?", result.HeadSeq).First(&checkpoint).Error
+	if err == nil {
+		return checkpoint, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return checkpoint, err
+	}
+	// 其他实例或请求同时为同一链尾创建校验点时不重复写入，返回已有的校验点
+	checkpoint = system.SysAuditCheckpoint{Seq: result.HeadSeq, Hash: result.HeadHash, Signature: auditCheckpointSignature(key, result.HeadSeq, result.HeadHash)}
+	res := global.GVA_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&checkpoint)
+	if res.Error != nil || res.RowsAffected == 1 {
+		return checkpoint, res.Error
+	}
+	checkpoint = system.SysAuditCheckpoint{}
+	err = global.GVA_DB.Where("seq = ?", result.HeadSeq).First(&checkpoint).Error
+	return checkpoint, err
+}
+
+//@function: VerifyAuditLogs
+//@description: 按序号遍历审计链，检查序号缺失、链接断开与内容被修改，并与库中保存的校验点及外部传入的校验点比对
+//@param: anchor *systemReq.AuditCheckpoint 外部保存的校验点，可为空
+//@return: result systemRes.AuditVerifyResult, err error
+
+func (auditLogService *AuditLogService) VerifyAuditLogs(anchor *systemReq.AuditCheckpoint) (result systemRes.AuditVerifyResult, err error) {
+	key, err := auditHashKey()
+	if err != nil {
+		return result, err
+	}
+	result.Problems = []systemRes.AuditProblem{}
+	report := func(seq uint64, format string, args ...interface{}) {
+		if len(result.Problems) < auditProblemLimit {
+			result.Problems = append(result.Problems, systemRes.AuditProblem{Seq: seq, Problem: fmt.Sprintf(format, args...)})
+		}
+		result.Valid = false
+	}
+	result.Valid = true
+
+	var stored []system.SysAuditCheckpoint
+	if err = global.GVA_DB.Order("seq").Find(&stored).Error; err != nil {
+		return
+	}
+	if anchor != nil && anchor.Seq > 0 {
+		stored = append(stored, system.SysAuditCheckpoint{Seq: anchor.Seq, Hash: anchor.Hash, Signature: anchor.Signature})
+	}
+	checkpoints := make(map[uint64][]string)
+	for _, c := range stored {
+		if !hmac.Equal([]byte(c.Signature), []byte(auditCheckpointSignature(key, c.Seq, c.Hash))) {
+			report(c.Seq, "校验点签名不一致")
+			continue
+		}
+		checkpoints[c.Seq] = append(checkpoints[c.Seq], c.Hash)
+		if c.Seq > result.CheckpointSeq {
+			result.CheckpointSeq = c.Seq
+		}
+	}
+
+	var expected uint64 = 1
+	prevHash := ""
+	for {
+		var batch []system.SysAuditLog
+		if err = global.GVA_DB.Where("seq >= ?", expected).Order("seq").Limit(auditVerifyBatch).Find(&batch).Error; err != nil {
+			return
+		}
+		for _, entry := range batch {
+			if entry.Seq != expected {
+				report(entry.Seq, "缺少序号 %d-%d 的记录", expected, entry.Seq-1)
+			} else if entry.PrevHash != prevHash {
+				report(entry.Seq, "与上一条记录的哈希不一致")
+			}
+			if auditHash(key, entry) != entry.Hash {
+				report(entry.Seq, "记录内容与哈希不一致")
+			}
+			for _, hash := range checkpoints[entry.Seq] {
+				if hash != entry.Hash {
+					report(entry.Seq, "与校验点记录的哈希不一致")
+				}
+			}
+			result.Total++
+			expected, prevHash = entry.Seq+1, entry.Hash
+			result.HeadSeq, result.HeadHash = entry.Seq, entry.Hash
+		}
+		if len(batch) < auditVerifyBatch {
+			break
+		}
+	}
+	if result.HeadSeq < result.CheckpointSeq {
+		report(result.CheckpointSeq, "链尾序号 %d 低于校验点序号 %d，链尾记录已被删除", result.HeadSeq, result.CheckpointSeq)
+	}
+	return
+}
@@ -0,0 +1,208 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func setupAuditLog(t *testing.T) context.Context {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysAuditLog{}, &system.SysAuditCheckpoint{}, &system.SysUser{}, &system.SysAuthority{}, &system.SysAuthorityMenu{})
+	global.GVA_CONFIG.AuditLog.HashKey = "test-audit-key"
+
+	// 与 initialize.RegisterAuditLog 相同的注册方式
+	RegisterAuditModel(system.SysUser{}, system.SysAuthority{}, system.SysAuthorityMenu{})
+	audit := AuditLogServiceApp
+	db.Callback().Update().Before("gorm:update").Register("audit:before", audit.BeforeChange)
+	db.Callback().Delete().Before("gorm:delete").Register("audit:before", audit.BeforeChange)
+	db.Callback().Create().Before("gorm:commit_or_rollback_transaction").Register("audit:create", audit.AfterCreate)
+	db.Callback().Update().Before("gorm:commit_or_rollback_transaction").Register("audit:update", audit.AfterUpdate)
+	db.Callback().Delete().Before("gorm:commit_or_rollback_transaction").Register("audit:delete", audit.AfterDelete)
+
+	ctx := context.WithValue(context.Background(), utils.ContextUserIDKey, uint(7))
+	return context.WithValue(ctx, utils.ContextAuthorityIDKey, uint(888))
+}
+
+func auditLogs(t *testing.T) []system.SysAuditLog {
+	t.Helper()
+	var logs []system.SysAuditLog
+	if err := global.GVA_DB.Order("seq").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	return logs
+}
+
+func TestAuditLog_RecordsChanges(t *testing.T) {
+	ctx := setupAuditLog(t)
+	db := global.GVA_DB.WithContext(ctx)
+
+	user := system.SysUser{Username: "alice", Password: "hashed-1", NickName: "Alice"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&system.SysUser{}).Where("id = ?", user.ID).Updates(map[string]interface{}{"password": "hashed-2", "nick_name": "Alicia"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 值没有变化的更新不记录
+	if err := db.Model(&user).Update("nick_name", "Alicia").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&system.SysUser{}, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	logs := auditLogs(t)
+	if len(logs) != 3 {
+		t.Fatalf("got %d audit logs, want 3: %+v", len(logs), logs)
+	}
+	for i, action := range []string{system.AuditActionCreate, system.AuditActionUpdate, system.AuditActionDelete} {
+		l := logs[i]
+		if l.Action != action || l.Table != "sys_users" || l.RecordID != "1" || l.UserID != 7 || l.AuthorityID != 888 || l.Seq != uint64(i+1) {
+			t.Fatalf("log %d = %+v", i, l)
+		}
+		if strings.Contains(l.Before+l.After+l.Diff, "hashed-") {
+			t.Fatalf("password not redacted: %+v", l)
+		}
+	}
+	if logs[0].PrevHash != "" || logs[1].PrevHash != logs[0].Hash || logs[2].PrevHash != logs[1].Hash {
+		t.Fatal("entries are not chained")
+	}
+	if !strings.Contains(logs[1].Diff, `"password":{"before":"******","after":"******"}`) ||
+		!strings.Contains(logs[1].Diff, `"nick_name":{"before":"Alice","after":"Alicia"}`) || strings.Contains(logs[1].Diff, "updated_at") {
+		t.Fatalf("diff = %s", logs[1].Diff)
+	}
+
+	result, err := AuditLogServiceApp.VerifyAuditLogs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Total != 3 || result.HeadSeq != 3 || result.HeadHash != logs[2].Hash {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestAuditLog_DetectsTampering(t *testing.T) {
+	ctx := setupAuditLog(t)
+	db := global.GVA_DB.WithContext(ctx)
+	for i := 1; i <= 4; i++ {
+		if err := db.Create(&system.SysAuthority{AuthorityId: uint(i * 100), AuthorityName: "role"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logs := auditLogs(t)
+	if err := global.GVA_DB.Model(&logs[0]).Update("user_id", 1).Error; !errors.Is(err, ErrAuditLogImmutable) {
+		t.Fatalf("update err = %v", err)
+	}
+	if err := global.GVA_DB.Delete(&logs[0]).Error; !errors.Is(err, ErrAuditLogImmutable) {
+		t.Fatalf("delete err = %v", err)
+	}
+
+	// 绕过 GORM 直接修改数据库
+	global.GVA_DB.Exec("UPDATE sys_audit_logs SET user_id = 1 WHERE seq = 2")
+	global.GVA_DB.Exec("DELETE FROM sys_audit_logs WHERE seq = 3")
+	result, err := AuditLogServiceApp.VerifyAuditLogs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || len(result.Problems) != 2 || result.Problems[0].Seq != 2 || result.Problems[1].Seq != 4 {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestAuditLog_RequiresHashKey(t *testing.T) {
+	ctx := setupAuditLog(t)
+	global.GVA_CONFIG.AuditLog.HashKey = ""
+	global.GVA_CONFIG.JWT.SigningKey = "test-signing-key"
+
+	// 不再回退到 JWT 签名密钥，未配置时审计表的写入与校验都被拒绝
+	err := global.GVA_DB.WithContext(ctx).Create(&system.SysAuthority{AuthorityId: 100, AuthorityName: "role"}).Error
+	if !errors.Is(err, ErrAuditHashKey) {
+		t.Fatalf("create err = %v", err)
+	}
+	var count int64
+	global.GVA_DB.Model(&system.SysAuthority{}).Count(&count)
+	if count != 0 {
+		t.Fatal("business write must be rolled back")
+	}
+	if _, err = AuditLogServiceApp.VerifyAuditLogs(nil); !errors.Is(err, ErrAuditHashKey) {
+		t.Fatalf("verify err = %v", err)
+	}
+}
+
+func TestAuditLog_JoinTableWithoutPrimaryKey(t *testing.T) {
+	ctx := setupAuditLog(t)
+	db := global.GVA_DB.WithContext(ctx)
+	if err := db.Create(&[]system.SysAuthorityMenu{{MenuId: "1", AuthorityId: "888"}, {MenuId: "2", AuthorityId: "888"}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("sys_base_menu_id = ?", "2").Delete(&system.SysAuthorityMenu{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	logs := auditLogs(t)
+	if len(logs) != 3 || logs[2].Action != system.AuditActionDelete || logs[2].Table != "sys_authority_menus" ||
+		logs[2].RecordID != "sys_base_menu_id=2,sys_authority_authority_id=888" {
+		t.Fatalf("logs = %+v", logs)
+	}
+}
+
+func TestAuditLog_Checkpoint(t *testing.T) {
+	ctx := setupAuditLog(t)
+	db := global.GVA_DB.WithContext(ctx)
+	for i := 1; i <= 3; i++ {
+		if err := db.Create(&system.SysAuthority{AuthorityId: uint(i * 100), AuthorityName: "role"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkpoint, err := AuditLogServiceApp.CreateAuditCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Seq != 3 || checkpoint.Signature == "" {
+		t.Fatalf("checkpoint = %+v", checkpoint)
+	}
+	// 同一链尾再次创建时返回已有的校验点，不重复写入
+	again, err := AuditLogServiceApp.CreateAuditCheckpoint()
+	var checkpoints int64
+	global.GVA_DB.Model(&system.SysAuditCheckpoint{}).Count(&checkpoints)
+	if err != nil || again.ID != checkpoint.ID || checkpoints != 1 {
+		t.Fatalf("again = %+v, err = %v, checkpoints = %d", again, err, checkpoints)
+	}
+	exported := &systemReq.AuditCheckpoint{Seq: checkpoint.Seq, Hash: checkpoint.Hash, Signature: checkpoint.Signature}
+	if result, err := AuditLogServiceApp.VerifyAuditLogs(exported); err != nil || !result.Valid || result.CheckpointSeq != 3 {
+		t.Fatalf("result = %+v, %v", result, err)
+	}
+
+	// 截断链尾：链本身仍然连续，由库中保存的校验点发现
+	global.GVA_DB.Exec("DELETE FROM sys_audit_logs WHERE seq = 3")
+	result, err := AuditLogServiceApp.VerifyAuditLogs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || result.HeadSeq != 2 || len(result.Problems) != 1 || !strings.Contains(result.Problems[0].Problem, "链尾") {
+		t.Fatalf("result = %+v", result)
+	}
+
+	// 校验点也被删除时，只能由外部保存的校验点发现
+	global.GVA_DB.Exec("DELETE FROM sys_audit_checkpoints")
+	if result, _ = AuditLogServiceApp.VerifyAuditLogs(nil); !result.Valid {
+		t.Fatalf("result = %+v", result)
+	}
+	if result, _ = AuditLogServiceApp.VerifyAuditLogs(exported); result.Valid {
+		t.Fatal("exported checkpoint must detect the truncated chain")
+	}
+
+	// 伪造的校验点签名不一致
+	forged := &systemReq.AuditCheckpoint{Seq: 2, Hash: "forged", Signature: checkpoint.Signature}
+	if result, _ = AuditLogServiceApp.VerifyAuditLogs(forged); result.Valid || result.Problems[0].Problem != "校验点签名不一致" {
+		t.Fatalf("result = %+v", result)
+	}
+}
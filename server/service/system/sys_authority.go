@@ -122,6 +122,22 @@ func (authorityService *AuthorityService) UpdateAuthority(auth system.SysAuthori
 	return auth, err
 }
 
+// SetRequire2FA 设置角色是否强制两步验证
+func (authorityService *AuthorityService) SetRequire2FA(authorityId uint, require bool) error {
+	result := global.GVA_DB.Model(&system.SysAuthority{}).Where("authority_id = ?", authorityId).Update("require_2fa", require)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		global.GVA_DB.Model(&system.SysAuthority{}).Where("authority_id = ?", authorityId).Count(&count)
+		if count == 0 {
+			return errors.New("该角色不存在")
+		}
+	}
+	return nil
+}
+
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: DeleteAuthority
 //@description: 删除角色
@@ -166,6 +182,9 @@ func (authorityService *AuthorityService) DeleteAuthority(auth *system.SysAuthor
 		if err = tx.Where("authority_id = ?", auth.AuthorityId).Delete(&[]system.SysAuthorityBtn{}).Error; err != nil {
 			return err
 		}
+		if err = DataRuleServiceApp.DeleteAuthorityDataRules(tx, auth.AuthorityId); err != nil {
+			return err
+		}
 
 		authorityId := strconv.Itoa(int(auth.AuthorityId))
 
@@ -30,37 +30,20 @@ func (casbinService *CasbinService) UpdateCasbin(adminAuthorityID, AuthorityID u
 		return err
 	}
 
-	if global.GVA_CONFIG.System.UseStrictAuth {
-		apis, e := ApiServiceApp.GetAllApis(adminAuthorityID)
-		if e != nil {
-			return e
-		}
-
-		for i := range casbinInfos {
-			hasApi := false
-			for j := range apis {
-				if apis[j].Path == casbinInfos[i].Path && apis[j].Method == casbinInfos[i].Method {
-					hasApi = true
-					break
-				}
-			}
-			if !hasApi {
-				return errors.New("存在api不在权限列表中")
-			}
-		}
+	missing, err := casbinService.apisNotAllowed(adminAuthorityID, casbinInfos)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return errors.New("存在api不在权限列表中")
 	}
 
 	authorityId := strconv.Itoa(int(AuthorityID))
 	casbinService.ClearCasbin(0, authorityId)
 	rules := [][]string{}
 	//做权限去重处理
-	deduplicateMap := make(map[string]bool)
-	for _, v := range casbinInfos {
-		key := authorityId + v.Path + v.Method
-		if _, ok := deduplicateMap[key]; !ok {
-			deduplicateMap[key] = true
-			rules = append(rules, []string{authorityId, v.Path, v.Method})
-		}
+	for _, v := range deduplicateCasbinInfos(casbinInfos) {
+		rules = append(rules, []string{authorityId, v.Path, v.Method})
 	}
 	if len(rules) == 0 {
 		return nil
@@ -0,0 +1,232 @@
+package system
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/casbin/casbin/v2/util"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+// maxUnmatchedPolicies 模拟结果中最多返回的相近策略数
+const maxUnmatchedPolicies = 20
+
+// SimulateCasbin 按 CasbinHandler 相同的方式校验角色能否访问接口，并说明放行或拒绝的原因
+func (casbinService *CasbinService) SimulateCasbin(authorityID uint, path, method string) (result response.CasbinSimulateResponse, err error) {
+	obj, act := normalizeCasbinRequest(path, method)
+	sub := strconv.Itoa(int(authorityID))
+	result = response.CasbinSimulateResponse{AuthorityId: authorityID, Path: obj, Method: act}
+
+	e := utils.GetCasbin()
+	allowed, explain, err := e.EnforceEx(sub, obj, act)
+	if err != nil {
+		return result, err
+	}
+	result.Allowed = allowed
+	if allowed && len(explain) >= 3 {
+		result.MatchedPolicy = &request.CasbinInfo{Path: explain[1], Method: explain[2]}
+	}
+
+	// 逐条比对该角色的策略，列出路径命中但方法不同、或同一分组下同方法的策略
+	own, err := e.GetFilteredPolicy(0, sub)
+	if err != nil {
+		return result, err
+	}
+	group := casbinPathGroup(obj)
+	pathMatchedOtherMethods := make([]string, 0)
+	for _, p := range own {
+		match := response.CasbinPolicyMatch{
+			Path:          p[1],
+			Method:        p[2],
+			PathMatched:   util.KeyMatch2(obj, p[1]),
+			MethodMatched: p[2] == act,
+		}
+		if match.PathMatched && match.MethodMatched {
+			continue
+		}
+		if match.PathMatched {
+			pathMatchedOtherMethods = append(pathMatchedOtherMethods, p[2])
+		} else if !match.MethodMatched || casbinPathGroup(p[1]) != group {
+			continue
+		}
+		if len(result.UnmatchedPolicies) < maxUnmatchedPolicies {
+			result.UnmatchedPolicies = append(result.UnmatchedPolicies, match)
+		}
+	}
+
+	if result.AllowedAuthorities, err = casbinService.allowedAuthorities(obj, act); err != nil {
+		return result, err
+	}
+	var apis []system.SysApi
+	if err = global.GVA_DB.Where("method = ?", act).Find(&apis).Error; err != nil {
+		return result, err
+	}
+	for i := range apis {
+		if util.KeyMatch2(obj, apis[i].Path) {
+			result.Api = &apis[i]
+			break
+		}
+	}
+	result.Reason = casbinSimulateReason(result, pathMatchedOtherMethods)
+	return result, nil
+}
+
+// DiffCasbin 预览 UpdateCasbin 将要产生的策略变更，不做任何修改
+func (casbinService *CasbinService) DiffCasbin(adminAuthorityID, AuthorityID uint, casbinInfos []request.CasbinInfo) (diff response.CasbinDiffResponse, err error) {
+	if err = AuthorityServiceApp.CheckAuthorityIDAuth(adminAuthorityID, AuthorityID); err != nil {
+		return diff, err
+	}
+	diff.AuthorityId = AuthorityID
+	if diff.NotInApiList, err = casbinService.apisNotAllowed(adminAuthorityID, casbinInfos); err != nil {
+		return diff, err
+	}
+	existing := casbinService.GetPolicyPathByAuthorityId(AuthorityID)
+	current := make(map[request.CasbinInfo]bool, len(existing))
+	for _, v := range existing {
+		current[v] = true
+	}
+	for _, v := range deduplicateCasbinInfos(casbinInfos) {
+		if current[v] {
+			diff.Unchanged++
+			delete(current, v)
+			continue
+		}
+		diff.Added = append(diff.Added, v)
+	}
+	for _, v := range existing {
+		if current[v] {
+			diff.Removed = append(diff.Removed, v)
+		}
+	}
+	return diff, nil
+}
+
+// allowedAuthorities 找出策略可以放行该请求的所有角色
+func (casbinService *CasbinService) allowedAuthorities(obj, act string) ([]response.CasbinAllowedAuthority, error) {
+	policies, err := utils.GetCasbin().GetFilteredPolicy(2, act)
+	if err != nil {
+		return nil, err
+	}
+	var list []response.CasbinAllowedAuthority
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, p := range policies {
+		if !util.KeyMatch2(obj, p[1]) {
+			continue
+		}
+		id, err := strconv.ParseUint(p[0], 10, 64)
+		if err != nil || seen[uint(id)] {
+			continue
+		}
+		seen[uint(id)] = true
+		ids = append(ids, uint(id))
+		list = append(list, response.CasbinAllowedAuthority{AuthorityId: uint(id), Policy: request.CasbinInfo{Path: p[1], Method: p[2]}})
+	}
+	if len(ids) == 0 {
+		return list, nil
+	}
+	var authorities []system.SysAuthority
+	if err = global.GVA_DB.Select("authority_id", "authority_name").Where("authority_id in ?", ids).Find(&authorities).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(authorities))
+	for _, a := range authorities {
+		names[a.AuthorityId] = a.AuthorityName
+	}
+	for i := range list {
+		list[i].AuthorityName = names[list[i].AuthorityId]
+	}
+	return list, nil
+}
+
+// apisNotAllowed 开启严格权限时，返回不在管理员可分配 api 列表中的接口
+func (casbinService *CasbinService) apisNotAllowed(adminAuthorityID uint, casbinInfos []request.CasbinInfo) ([]request.CasbinInfo, error) {
+	if !global.GVA_CONFIG.System.UseStrictAuth {
+		return nil, nil
+	}
+	apis, err := ApiServiceApp.GetAllApis(adminAuthorityID)
+	if err != nil {
+		return nil, err
+	}
+	var missing []request.CasbinInfo
+	for i := range casbinInfos {
+		hasApi := false
+		for j := range apis {
+			if apis[j].Path == casbinInfos[i].Path && apis[j].Method == casbinInfos[i].Method {
+				hasApi = true
+				break
+			}
+		}
+		if !hasApi {
+			missing = append(missing, casbinInfos[i])
+		}
+	}
+	return missing, nil
+}
+
+// deduplicateCasbinInfos 按路径与方法去重，保持原有顺序
+func deduplicateCasbinInfos(casbinInfos []request.CasbinInfo) []request.CasbinInfo {
+	seen := make(map[request.CasbinInfo]bool, len(casbinInfos))
+	list := make([]request.CasbinInfo, 0, len(casbinInfos))
+	for _, v := range casbinInfos {
+		if !seen[v] {
+			seen[v] = true
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// normalizeCasbinRequest 与 CasbinHandler 一致：去掉查询参数与路由前缀，方法转为大写
+func normalizeCasbinRequest(path, method string) (string, string) {
+	if u, err := url.Parse(path); err == nil {
+		path = u.Path
+	}
+	path = strings.TrimPrefix(path, global.GVA_CONFIG.System.RouterPrefix)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path, strings.ToUpper(strings.TrimSpace(method))
+}
+
+// casbinPathGroup 路径的第一段，如 /user/getUserList -> user
+func casbinPathGroup(path string) string {
+	group, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return group
+}
+
+func casbinSimulateReason(result response.CasbinSimulateResponse, pathMatchedOtherMethods []string) string {
+	if result.Allowed {
+		if result.MatchedPolicy == nil {
+			return "允许访问"
+		}
+		return fmt.Sprintf("允许访问：命中策略 %d %s %s", result.AuthorityId, result.MatchedPolicy.Path, result.MatchedPolicy.Method)
+	}
+	var reason string
+	switch {
+	case len(pathMatchedOtherMethods) > 0:
+		reason = fmt.Sprintf("权限不足：角色拥有该路径的 %s 权限，但没有 %s 权限", strings.Join(pathMatchedOtherMethods, "/"), result.Method)
+	case result.Api == nil:
+		reason = "权限不足：该接口未在API管理中登记，需先登记后再分配给角色"
+	default:
+		reason = fmt.Sprintf("权限不足：角色未分配接口「%s」", result.Api.Description)
+	}
+	if len(result.AllowedAuthorities) == 0 {
+		return reason + "；当前没有任何角色拥有该权限"
+	}
+	names := make([]string, 0, len(result.AllowedAuthorities))
+	for _, a := range result.AllowedAuthorities {
+		if a.AuthorityName != "" {
+			names = append(names, fmt.Sprintf("%s(%d)", a.AuthorityName, a.AuthorityId))
+		} else {
+			names = append(names, strconv.Itoa(int(a.AuthorityId)))
+		}
+	}
+	return reason + "；以下角色拥有该权限：" + strings.Join(names, "、")
+}
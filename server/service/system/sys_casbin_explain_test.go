@@ -0,0 +1,102 @@
+package system
+
+import (
+	"strings"
+	"testing"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func setupCasbinSimulate(t *testing.T) {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysAuthority{}, &system.SysApi{}, &gormadapter.CasbinRule{})
+	db.Create(&[]system.SysAuthority{{AuthorityId: 888, AuthorityName: "管理员"}, {AuthorityId: 9528, AuthorityName: "测试角色"}})
+	db.Create(&[]system.SysApi{
+		{Path: "/user/getUserList", Method: "POST", ApiGroup: "系统用户", Description: "获取用户列表"},
+		{Path: "/user/deleteUser", Method: "DELETE", ApiGroup: "系统用户", Description: "删除用户"},
+		{Path: "/user/:id", Method: "GET", ApiGroup: "系统用户", Description: "用户详情"},
+	})
+	global.GVA_CONFIG.System.RouterPrefix = "/api"
+	global.GVA_CONFIG.System.UseStrictAuth = false
+
+	e := utils.GetCasbin()
+	e.ClearPolicy()
+	if _, err := e.AddPolicies([][]string{
+		{"888", "/user/getUserList", "POST"},
+		{"888", "/user/deleteUser", "DELETE"},
+		{"888", "/user/:id", "GET"},
+		{"9528", "/user/getUserList", "GET"},
+		{"9528", "/user/setSelfInfo", "PUT"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCasbinService_SimulateCasbin(t *testing.T) {
+	setupCasbinSimulate(t)
+	s := &CasbinService{}
+
+	result, err := s.SimulateCasbin(888, "/api/user/42?tab=1", "get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || result.Path != "/user/42" || result.Method != "GET" ||
+		result.MatchedPolicy == nil || result.MatchedPolicy.Path != "/user/:id" || result.Api == nil {
+		t.Fatalf("应命中 /user/:id 策略: %+v", result)
+	}
+
+	// 路径有权限但方法不同
+	result, err = s.SimulateCasbin(9528, "/user/getUserList", "POST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || len(result.UnmatchedPolicies) != 1 || !result.UnmatchedPolicies[0].PathMatched {
+		t.Fatalf("应拒绝并列出方法不同的策略: %+v", result)
+	}
+	if len(result.AllowedAuthorities) != 1 || result.AllowedAuthorities[0].AuthorityName != "管理员" {
+		t.Fatalf("应列出拥有该权限的角色: %+v", result.AllowedAuthorities)
+	}
+	if !strings.Contains(result.Reason, "GET") || !strings.Contains(result.Reason, "管理员(888)") {
+		t.Fatalf("拒绝原因不完整: %s", result.Reason)
+	}
+
+	// 接口未登记且无人拥有权限
+	result, err = s.SimulateCasbin(9528, "/user/unknown", "PUT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.Api != nil || len(result.AllowedAuthorities) != 0 {
+		t.Fatalf("未登记接口应被拒绝: %+v", result)
+	}
+	// 同分组同方法的策略作为相近策略返回
+	if len(result.UnmatchedPolicies) != 1 || result.UnmatchedPolicies[0].Path != "/user/setSelfInfo" {
+		t.Fatalf("相近策略不符合预期: %+v", result.UnmatchedPolicies)
+	}
+}
+
+func TestCasbinService_DiffCasbin(t *testing.T) {
+	setupCasbinSimulate(t)
+	s := &CasbinService{}
+	diff, err := s.DiffCasbin(888, 9528, []request.CasbinInfo{
+		{Path: "/user/getUserList", Method: "GET"},
+		{Path: "/user/getUserList", Method: "GET"},
+		{Path: "/user/deleteUser", Method: "DELETE"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Unchanged != 1 || len(diff.Added) != 1 || diff.Added[0].Path != "/user/deleteUser" ||
+		len(diff.Removed) != 1 || diff.Removed[0].Path != "/user/setSelfInfo" {
+		t.Fatalf("变更预览不符合预期: %+v", diff)
+	}
+	// 预览不修改策略
+	if paths := s.GetPolicyPathByAuthorityId(9528); len(paths) != 2 {
+		t.Fatalf("预览后策略数 = %d, want 2", len(paths))
+	}
+}
@@ -0,0 +1,351 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// dataRuleCacheTTL 规则缓存时长，多实例部署时其他实例最迟在该时长后生效
+	dataRuleCacheTTL = time.Minute
+	// maxDataScopeTreeDepth 子树展开的最大层数，防止树形数据成环时死循环
+	maxDataScopeTreeDepth = 32
+)
+
+// DataScopeTree 树形数据的表结构，用于 subtree 规则
+type DataScopeTree struct {
+	Table        string `json:"table"`
+	IdColumn     string `json:"idColumn"`
+	ParentColumn string `json:"parentColumn"`
+}
+
+// DataScopeField 资源上可配置规则的字段
+type DataScopeField struct {
+	Name   string         `json:"name"`   // 规则中引用的字段标识
+	Label  string         `json:"label"`  // 展示名称
+	Column string         `json:"column"` // 对应的数据库列
+	Tree   *DataScopeTree `json:"tree"`   // 字段引用树形数据时配置，支持 subtree 规则
+}
+
+// DataScopeResource 参与行级数据权限的资源，Name 为表名
+type DataScopeResource struct {
+	Name   string           `json:"name"`
+	Label  string           `json:"label"`
+	Fields []DataScopeField `json:"fields"`
+}
+
+func (r DataScopeResource) field(name string) (DataScopeField, bool) {
+	for _, f := range r.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return DataScopeField{}, false
+}
+
+var (
+	dataScopeMu        sync.RWMutex
+	dataScopeResources = make(map[string]DataScopeResource)
+
+	dataRuleCache struct {
+		sync.Mutex
+		rules    map[uint][]system.SysDataRule
+		loadedAt map[uint]time.Time
+	}
+)
+
+// RegisterDataScope 登记参与行级数据权限的资源，未登记的表不会应用规则
+func RegisterDataScope(resource DataScopeResource) {
+	dataScopeMu.Lock()
+	defer dataScopeMu.Unlock()
+	dataScopeResources[resource.Name] = resource
+}
+
+type DataRuleService struct{}
+
+var DataRuleServiceApp = new(DataRuleService)
+
+// GetResources 获取已登记的资源及可用字段，供配置页面使用
+func (dataRuleService *DataRuleService) GetResources() []DataScopeResource {
+	dataScopeMu.RLock()
+	defer dataScopeMu.RUnlock()
+	list := make([]DataScopeResource, 0, len(dataScopeResources))
+	for _, r := range dataScopeResources {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// GetDataRules 获取角色的全部规则
+func (dataRuleService *DataRuleService) GetDataRules(authorityId uint) (list []system.SysDataRule, err error) {
+	err = global.GVA_DB.Where("authority_id = ?", authorityId).Order("resource, id").Find(&list).Error
+	return list, err
+}
+
+// SetDataRules 替换角色的全部规则，规则必须引用已登记的资源与字段
+func (dataRuleService *DataRuleService) SetDataRules(adminAuthorityID, authorityId uint, rules []system.SysDataRule) error {
+	if err := AuthorityServiceApp.CheckAuthorityIDAuth(adminAuthorityID, authorityId); err != nil {
+		return err
+	}
+	for i := range rules {
+		if err := validateDataRule(rules[i]); err != nil {
+			return fmt.Errorf("第%d条规则: %w", i+1, err)
+		}
+		rules[i].ID = 0
+		rules[i].AuthorityId = authorityId
+	}
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("authority_id = ?", authorityId).Delete(&system.SysDataRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+	if err != nil {
+		return err
+	}
+	invalidateDataRules(authorityId)
+	return nil
+}
+
+// DeleteAuthorityDataRules 删除角色时清理其规则
+func (dataRuleService *DataRuleService) DeleteAuthorityDataRules(tx *gorm.DB, authorityId uint) error {
+	if err := tx.Unscoped().Where("authority_id = ?", authorityId).Delete(&system.SysDataRule{}).Error; err != nil {
+		return err
+	}
+	invalidateDataRules(authorityId)
+	return nil
+}
+
+// ApplyDataScope GORM 回调中调用：按上下文中用户的角色为语句追加行级规则
+// 角色在该表上没有规则时返回 false，由调用方回退到默认的所有权过滤
+func (dataRuleService *DataRuleService) ApplyDataScope(tx *gorm.DB) (bool, error) {
+	ctx := tx.Statement.Context
+	userId := utils.ContextUserID(ctx)
+	if userId == 0 || utils.DataScopeSkipped(ctx) {
+		return false, nil
+	}
+	dataScopeMu.RLock()
+	resource, ok := dataScopeResources[tx.Statement.Schema.Table]
+	dataScopeMu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	authorityId := utils.ContextAuthorityID(ctx)
+	if authorityId == 0 {
+		var err error
+		if authorityId, err = userAuthorityId(ctx, userId); err != nil {
+			return false, err
+		}
+	}
+	rules, err := loadDataRules(authorityId)
+	if err != nil {
+		return false, err
+	}
+	applied := false
+	for _, rule := range rules {
+		if rule.Resource != resource.Name {
+			continue
+		}
+		applied = true
+		expr, err := dataRuleCondition(ctx, resource, rule, authorityId, userId)
+		if err != nil {
+			return true, err
+		}
+		tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{expr}})
+	}
+	return applied, nil
+}
+
+// dataRuleCondition 将单条规则转换为查询条件，无法解析的规则按拒绝处理
+func dataRuleCondition(ctx context.Context, resource DataScopeResource, rule system.SysDataRule, authorityId, userId uint) (clause.Expression, error) {
+	deny := clause.Expr{SQL: "1 = 0"}
+	field, ok := resource.field(rule.Field)
+	if !ok {
+		global.GVA_LOG.Warn(fmt.Sprintf("数据权限规则引用了未登记的字段 %s.%s，按拒绝处理", rule.Resource, rule.Field))
+		return deny, nil
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: field.Column}
+	switch rule.Operator {
+	case system.DataRuleIn:
+		if len(rule.Values) == 0 {
+			return deny, nil
+		}
+		return clause.IN{Column: column, Values: stringValues(rule.Values)}, nil
+	case system.DataRuleNotIn:
+		if len(rule.Values) == 0 {
+			return clause.Expr{SQL: "1 = 1"}, nil
+		}
+		return clause.Not(clause.IN{Column: column, Values: stringValues(rule.Values)}), nil
+	case system.DataRuleSelf:
+		return clause.Eq{Column: column, Value: userId}, nil
+	case system.DataRuleSelfOrSubordinates:
+		ids, err := subordinateUserIds(ctx, authorityId)
+		if err != nil {
+			return nil, err
+		}
+		values := []interface{}{userId}
+		for _, id := range ids {
+			if id != userId {
+				values = append(values, id)
+			}
+		}
+		return clause.IN{Column: column, Values: values}, nil
+	case system.DataRuleSubtree:
+		if field.Tree == nil {
+			return deny, nil
+		}
+		ids, err := subtreeIds(ctx, *field.Tree, rule.Values)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return deny, nil
+		}
+		return clause.IN{Column: column, Values: ids}, nil
+	}
+	global.GVA_LOG.Warn(fmt.Sprintf("未知的数据权限规则类型 %s，按拒绝处理", rule.Operator))
+	return deny, nil
+}
+
+func validateDataRule(rule system.SysDataRule) error {
+	dataScopeMu.RLock()
+	resource, ok := dataScopeResources[rule.Resource]
+	dataScopeMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("资源 %s 未登记", rule.Resource)
+	}
+	field, ok := resource.field(rule.Field)
+	if !ok {
+		return fmt.Errorf("资源 %s 没有字段 %s", rule.Resource, rule.Field)
+	}
+	switch rule.Operator {
+	case system.DataRuleIn, system.DataRuleNotIn:
+		if len(rule.Values) == 0 {
+			return errors.New("请填写规则取值")
+		}
+	case system.DataRuleSelf, system.DataRuleSelfOrSubordinates:
+	case system.DataRuleSubtree:
+		if field.Tree == nil {
+			return fmt.Errorf("字段 %s 不是树形数据，不支持子树规则", rule.Field)
+		}
+		if len(rule.Values) == 0 {
+			return errors.New("请选择子树的根节点")
+		}
+	default:
+		return fmt.Errorf("不支持的规则类型 %s", rule.Operator)
+	}
+	return nil
+}
+
+// loadDataRules 读取角色规则，带短时缓存
+func loadDataRules(authorityId uint) ([]system.SysDataRule, error) {
+	dataRuleCache.Lock()
+	defer dataRuleCache.Unlock()
+	if dataRuleCache.rules == nil {
+		dataRuleCache.rules = make(map[uint][]system.SysDataRule)
+		dataRuleCache.loadedAt = make(map[uint]time.Time)
+	}
+	if rules, ok := dataRuleCache.rules[authorityId]; ok && time.Since(dataRuleCache.loadedAt[authorityId]) < dataRuleCacheTTL {
+		return rules, nil
+	}
+	var rules []system.SysDataRule
+	if err := global.GVA_DB.Where("authority_id = ?", authorityId).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	dataRuleCache.rules[authorityId] = rules
+	dataRuleCache.loadedAt[authorityId] = time.Now()
+	return rules, nil
+}
+
+func invalidateDataRules(authorityId uint) {
+	dataRuleCache.Lock()
+	defer dataRuleCache.Unlock()
+	delete(dataRuleCache.rules, authorityId)
+}
+
+// userAuthorityId 没有 claims 的请求（如永久token）按用户查询当前角色，同一请求内只查询一次
+func userAuthorityId(ctx context.Context, userId uint) (uint, error) {
+	return utils.RequestCached(ctx, fmt.Sprintf("data_scope:authority:%d", userId), func() (uint, error) {
+		var user system.SysUser
+		if err := global.GVA_DB.Select("authority_id").Where("id = ?", userId).First(&user).Error; err != nil {
+			return 0, err
+		}
+		return user.AuthorityId, nil
+	})
+}
+
+// subordinateUserIds 角色资源权限中所列角色下的全部用户，同一请求内只查询一次
+func subordinateUserIds(ctx context.Context, authorityId uint) ([]uint, error) {
+	return utils.RequestCached(ctx, fmt.Sprintf("data_scope:subordinates:%d", authorityId), func() ([]uint, error) {
+		var authority system.SysAuthority
+		err := global.GVA_DB.Preload("DataAuthorityId").Where("authority_id = ?", authorityId).First(&authority).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(authority.DataAuthorityId) == 0 {
+			return nil, nil
+		}
+		authorityIds := make([]uint, 0, len(authority.DataAuthorityId))
+		for _, a := range authority.DataAuthorityId {
+			authorityIds = append(authorityIds, a.AuthorityId)
+		}
+		var ids []uint
+		err = global.GVA_DB.Model(&system.SysUserAuthority{}).Distinct("sys_user_id").
+			Where("sys_authority_authority_id in ?", authorityIds).Pluck("sys_user_id", &ids).Error
+		return ids, err
+	})
+}
+
+// subtreeIds 从根节点逐层向下展开，返回子树内全部节点ID（含根节点）
+func subtreeIds(ctx context.Context, tree DataScopeTree, roots []string) ([]interface{}, error) {
+	db := global.GVA_DB.WithContext(utils.WithoutDataScope(ctx))
+	seen := make(map[string]bool)
+	var ids []interface{}
+	frontier := make([]string, 0, len(roots))
+	for _, r := range roots {
+		if !seen[r] {
+			seen[r] = true
+			frontier = append(frontier, r)
+			ids = append(ids, r)
+		}
+	}
+	for depth := 0; len(frontier) > 0 && depth < maxDataScopeTreeDepth; depth++ {
+		var children []string
+		err := db.Table(tree.Table).Where(clause.IN{Column: clause.Column{Name: tree.ParentColumn}, Values: stringValues(frontier)}).
+			Pluck(tree.IdColumn, &children).Error
+		if err != nil {
+			return nil, err
+		}
+		frontier = frontier[:0]
+		for _, c := range children {
+			if !seen[c] {
+				seen[c] = true
+				frontier = append(frontier, c)
+				ids = append(ids, c)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func stringValues(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func setupDataRule(t *testing.T) {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysAuthority{}, &system.SysDataRule{})
+	global.GVA_CONFIG.System.UseStrictAuth = false
+	db.Create(&system.SysAuthority{AuthorityId: 300, AuthorityName: "访客"})
+	RegisterDataScope(DataScopeResource{Name: "test_scoped_orders", Label: "测试订单", Fields: []DataScopeField{
+		{Name: "owner", Label: "创建人", Column: "sys_user_id"},
+		{Name: "region", Label: "区域", Column: "region"},
+		{Name: "dept", Label: "部门", Column: "dept_id", Tree: &DataScopeTree{Table: "test_scoped_depts", IdColumn: "id", ParentColumn: "parent_id"}},
+	}})
+}
+
+func setRules(t *testing.T, authorityId uint, rules ...system.SysDataRule) {
+	t.Helper()
+	if err := DataRuleServiceApp.SetDataRules(888, authorityId, rules); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDataRuleService_SetDataRulesValidation(t *testing.T) {
+	setupDataRule(t)
+	cases := []struct {
+		name string
+		rule system.SysDataRule
+	}{
+		{"unknown resource", system.SysDataRule{Resource: "sys_users", Field: "owner", Operator: system.DataRuleSelf}},
+		{"unknown field", system.SysDataRule{Resource: "test_scoped_orders", Field: "amount", Operator: system.DataRuleSelf}},
+		{"unknown operator", system.SysDataRule{Resource: "test_scoped_orders", Field: "region", Operator: "like", Values: []string{"e%"}}},
+		{"empty values", system.SysDataRule{Resource: "test_scoped_orders", Field: "region", Operator: system.DataRuleIn}},
+		{"subtree on flat field", system.SysDataRule{Resource: "test_scoped_orders", Field: "region", Operator: system.DataRuleSubtree, Values: []string{"east"}}},
+	}
+	for _, c := range cases {
+		if err := DataRuleServiceApp.SetDataRules(888, 300, []system.SysDataRule{c.rule}); err == nil {
+			t.Errorf("%s: expected validation error", c.name)
+		}
+	}
+
+	setRules(t, 300, system.SysDataRule{Resource: "test_scoped_orders", Field: "owner", Operator: system.DataRuleSelf})
+	// 整体替换：再次保存后只保留新规则
+	setRules(t, 300, system.SysDataRule{Resource: "test_scoped_orders", Field: "region", Operator: system.DataRuleIn, Values: []string{"west"}})
+	rules, err := DataRuleServiceApp.GetDataRules(300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Field != "region" || rules[0].Values[0] != "west" {
+		t.Fatalf("rules not replaced: %+v", rules)
+	}
+}
@@ -22,8 +22,11 @@ func (dictionaryService *DictionaryService) CreateSysDictionary(sysDictionary sy
 	if (!errors.Is(global.GVA_DB.First(&system.SysDictionary{}, "type = ?", sysDictionary.Type).Error, gorm.ErrRecordNotFound)) {
 		return errors.New("存在相同的type，不允许创建")
 	}
-	err = global.GVA_DB.Create(&sysDictionary).Error
-	return err
+	if err = global.GVA_DB.Create(&sysDictionary).Error; err != nil {
+		return err
+	}
+	invalidateDictionaries()
+	return nil
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -46,9 +49,10 @@ func (dictionaryService *DictionaryService) DeleteSysDictionary(sysDictionary sy
 	}
 
 	if sysDictionary.SysDictionaryDetails != nil {
-		return global.GVA_DB.Where("sys_dictionary_id=?", sysDictionary.ID).Delete(sysDictionary.SysDictionaryDetails).Error
+		err = global.GVA_DB.Where("sys_dictionary_id=?", sysDictionary.ID).Delete(sysDictionary.SysDictionaryDetails).Error
 	}
-	return
+	invalidateDictionaries()
+	return err
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -75,8 +79,11 @@ func (dictionaryService *DictionaryService) UpdateSysDictionary(sysDictionary *s
 			return errors.New("存在相同的type，不允许创建")
 		}
 	}
-	err = global.GVA_DB.Model(&dict).Updates(sysDictionaryMap).Error
-	return err
+	if err = global.GVA_DB.Model(&dict).Updates(sysDictionaryMap).Error; err != nil {
+		return err
+	}
+	invalidateDictionaries()
+	return nil
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
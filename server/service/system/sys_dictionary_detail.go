@@ -1,6 +1,8 @@
 package system
 
 import (
+	"errors"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
@@ -17,8 +19,14 @@ type DictionaryDetailService struct{}
 var DictionaryDetailServiceApp = new(DictionaryDetailService)
 
 func (dictionaryDetailService *DictionaryDetailService) CreateSysDictionaryDetail(sysDictionaryDetail system.SysDictionaryDetail) (err error) {
-	err = global.GVA_DB.Create(&sysDictionaryDetail).Error
-	return err
+	if err = validateDictionaryDetail(global.GVA_DB, &sysDictionaryDetail); err != nil {
+		return err
+	}
+	if err = global.GVA_DB.Create(&sysDictionaryDetail).Error; err != nil {
+		return err
+	}
+	invalidateDictionaries()
+	return nil
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -28,8 +36,18 @@ func (dictionaryDetailService *DictionaryDetailService) CreateSysDictionaryDetai
 //@return: err error
 
 func (dictionaryDetailService *DictionaryDetailService) DeleteSysDictionaryDetail(sysDictionaryDetail system.SysDictionaryDetail) (err error) {
-	err = global.GVA_DB.Delete(&sysDictionaryDetail).Error
-	return err
+	var children int64
+	if err = global.GVA_DB.Model(&system.SysDictionaryDetail{}).Where("parent_id = ?", sysDictionaryDetail.ID).Count(&children).Error; err != nil {
+		return err
+	}
+	if children > 0 {
+		return errors.New("请先删除下级字典项")
+	}
+	if err = global.GVA_DB.Delete(&sysDictionaryDetail).Error; err != nil {
+		return err
+	}
+	invalidateDictionaries()
+	return nil
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -39,8 +57,14 @@ func (dictionaryDetailService *DictionaryDetailService) DeleteSysDictionaryDetai
 //@return: err error
 
 func (dictionaryDetailService *DictionaryDetailService) UpdateSysDictionaryDetail(sysDictionaryDetail *system.SysDictionaryDetail) (err error) {
-	err = global.GVA_DB.Save(sysDictionaryDetail).Error
-	return err
+	if err = validateDictionaryDetail(global.GVA_DB, sysDictionaryDetail); err != nil {
+		return err
+	}
+	if err = global.GVA_DB.Save(sysDictionaryDetail).Error; err != nil {
+		return err
+	}
+	invalidateDictionaries()
+	return nil
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -0,0 +1,280 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// sysDictionaryChannel 字典变更广播频道，各实例收到后清空本地字典缓存
+	sysDictionaryChannel = "sys_dictionary_channel"
+	// sysDictionaryCacheTTL 本地缓存有效期，兜底订阅断开期间漏收的广播
+	sysDictionaryCacheTTL = 5 * time.Minute
+	// dictionaryMaxDepth 字典项允许的最大层级，防止错误数据形成环
+	dictionaryMaxDepth = 32
+)
+
+// dictionaryCacheEntry 缓存的启用字典，details 按 sort、id 排序，不存在或已停用时 found 为 false
+type dictionaryCacheEntry struct {
+	found    bool
+	details  []system.SysDictionaryDetail
+	byValue  map[string]*system.SysDictionaryDetail
+	loadedAt time.Time
+}
+
+// dictionaryCache 字典本地缓存，generation 在每次清空时加一，避免并发读取把已失效的数据写回缓存
+var dictionaryCache = struct {
+	sync.RWMutex
+	entries    map[string]*dictionaryCacheEntry
+	generation uint64
+}{entries: make(map[string]*dictionaryCacheEntry)}
+
+// cachedDictionary 读取启用的字典及其启用的字典项
+func cachedDictionary(t string) (*dictionaryCacheEntry, error) {
+	dictionaryCache.RLock()
+	entry, ok := dictionaryCache.entries[t]
+	generation := dictionaryCache.generation
+	dictionaryCache.RUnlock()
+	if ok && time.Since(entry.loadedAt) <= sysDictionaryCacheTTL {
+		return entry, nil
+	}
+
+	entry = &dictionaryCacheEntry{loadedAt: time.Now(), byValue: make(map[string]*system.SysDictionaryDetail)}
+	var dict system.SysDictionary
+	err := global.GVA_DB.Where("type = ? AND status = ?", t, true).First(&dict).Error
+	switch {
+	case err == nil:
+		entry.found = true
+		err = global.GVA_DB.Where("sys_dictionary_id = ? AND status = ?", dict.ID, true).Order("sort").Order("id").Find(&entry.details).Error
+		if err != nil {
+			return nil, err
+		}
+		for i := range entry.details {
+			// 同一字典中存在重复的字典值时以排序靠前的为准
+			if _, ok := entry.byValue[entry.details[i].Value]; !ok {
+				entry.byValue[entry.details[i].Value] = &entry.details[i]
+			}
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	dictionaryCache.Lock()
+	if dictionaryCache.generation == generation {
+		dictionaryCache.entries[t] = entry
+	}
+	dictionaryCache.Unlock()
+	return entry, nil
+}
+
+// invalidateDictionaries 字典或字典项变更后清空本地缓存并通知其他实例
+func invalidateDictionaries() {
+	clearDictionaryCache()
+	if global.GVA_REDIS == nil {
+		return
+	}
+	// 本地缓存有过期时间兜底，广播失败只记录日志
+	if err := global.GVA_REDIS.Publish(context.Background(), sysDictionaryChannel, "*").Err(); err != nil {
+		global.GVA_LOG.Error("字典变更广播失败!", zap.Error(err))
+	}
+}
+
+func clearDictionaryCache() {
+	dictionaryCache.Lock()
+	dictionaryCache.entries = make(map[string]*dictionaryCacheEntry)
+	dictionaryCache.generation++
+	dictionaryCache.Unlock()
+}
+
+// SubscribeDictionaries 订阅字典变更广播，收到后清空本地缓存
+// 每次（重新）订阅成功时同样清空，避免断线期间漏收的变更；ctx 取消时退出
+func SubscribeDictionaries(ctx context.Context) {
+	if global.GVA_REDIS == nil {
+		return
+	}
+	pubsub := global.GVA_REDIS.Subscribe(ctx, sysDictionaryChannel)
+	defer pubsub.Close()
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			global.GVA_LOG.Error("字典变更订阅异常!", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				clearDictionaryCache()
+			}
+		case *redis.Message:
+			clearDictionaryCache()
+		}
+	}
+}
+
+// NormalizeLocale 取语言标记，支持 Accept-Language 格式（如 "en-US,en;q=0.9" 返回 "en-US"）
+func NormalizeLocale(locale string) string {
+	if i := strings.IndexByte(locale, ','); i >= 0 {
+		locale = locale[:i]
+	}
+	if i := strings.IndexByte(locale, ';'); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.TrimSpace(locale)
+}
+
+// localizedLabel 按语言取展示值，依次匹配完整语言标记与主语言（en-US -> en），都没有时使用默认展示值
+func localizedLabel(detail *system.SysDictionaryDetail, locale string) string {
+	if locale == "" || len(detail.Labels) == 0 {
+		return detail.Label
+	}
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	for _, candidate := range candidates {
+		for key, v := range detail.Labels {
+			if label, ok := v.(string); ok && label != "" && strings.EqualFold(key, candidate) {
+				return label
+			}
+		}
+	}
+	return detail.Label
+}
+
+//@function: LookupDictionaries
+//@description: 批量翻译字典值，未知的字典或字典值不返回，由调用方回退显示原值
+//@param: locale string, values map[string][]string
+//@return: result map[string]map[string]string, err error
+
+func (dictionaryDetailService *DictionaryDetailService) LookupDictionaries(locale string, values map[string][]string) (result map[string]map[string]string, err error) {
+	locale = NormalizeLocale(locale)
+	result = make(map[string]map[string]string, len(values))
+	for t, list := range values {
+		entry, err := cachedDictionary(t)
+		if err != nil {
+			return nil, err
+		}
+		labels := make(map[string]string)
+		result[t] = labels
+		if !entry.found {
+			continue
+		}
+		if len(list) == 0 {
+			for i := range entry.details {
+				if _, ok := labels[entry.details[i].Value]; !ok {
+					labels[entry.details[i].Value] = localizedLabel(&entry.details[i], locale)
+				}
+			}
+			continue
+		}
+		for _, value := range list {
+			if detail, ok := entry.byValue[value]; ok {
+				labels[value] = localizedLabel(detail, locale)
+			}
+		}
+	}
+	return result, nil
+}
+
+// TranslateDictionaryValue 翻译单个字典值，供业务代码与自动生成的代码使用，找不到时返回原值
+func (dictionaryDetailService *DictionaryDetailService) TranslateDictionaryValue(t, value, locale string) string {
+	entry, err := cachedDictionary(t)
+	if err != nil || !entry.found {
+		return value
+	}
+	if detail, ok := entry.byValue[value]; ok {
+		return localizedLabel(detail, NormalizeLocale(locale))
+	}
+	return value
+}
+
+//@function: GetDictionaryTree
+//@description: 按层级获取启用的字典项，上级已停用的字典项不返回
+//@param: t string, locale string
+//@return: tree []*systemRes.DictionaryTreeNode, err error
+
+func (dictionaryDetailService *DictionaryDetailService) GetDictionaryTree(t, locale string) (tree []*systemRes.DictionaryTreeNode, err error) {
+	entry, err := cachedDictionary(t)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.found {
+		return nil, gorm.ErrRecordNotFound
+	}
+	locale = NormalizeLocale(locale)
+	nodes := make(map[uint]*systemRes.DictionaryTreeNode, len(entry.details))
+	for i := range entry.details {
+		d := &entry.details[i]
+		nodes[d.ID] = &systemRes.DictionaryTreeNode{ID: d.ID, Label: localizedLabel(d, locale), Value: d.Value, Extend: d.Extend, Sort: d.Sort}
+	}
+	tree = []*systemRes.DictionaryTreeNode{}
+	// details 已按排序读取，按顺序挂载即可保持同级顺序
+	for i := range entry.details {
+		d := &entry.details[i]
+		if d.ParentID == 0 {
+			tree = append(tree, nodes[d.ID])
+		} else if parent, ok := nodes[d.ParentID]; ok {
+			parent.Children = append(parent.Children, nodes[d.ID])
+		}
+	}
+	return tree, nil
+}
+
+// validateDictionaryDetail 校验多语言展示值与上级字典项，上级必须属于同一字典且不能形成环
+func validateDictionaryDetail(db *gorm.DB, detail *system.SysDictionaryDetail) error {
+	for key, v := range detail.Labels {
+		if _, ok := v.(string); !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("多语言展示值 %q 必须为字符串", key)
+		}
+	}
+	if detail.ParentID == 0 {
+		return nil
+	}
+	parentID := detail.ParentID
+	for depth := 0; parentID != 0; depth++ {
+		if detail.ID != 0 && parentID == detail.ID {
+			return errors.New("不能将字典项自身或其下级设为上级")
+		}
+		if depth >= dictionaryMaxDepth {
+			return fmt.Errorf("字典项层级不能超过 %d 级", dictionaryMaxDepth)
+		}
+		var parent system.SysDictionaryDetail
+		if err := db.Select("id", "parent_id", "sys_dictionary_id").First(&parent, parentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("上级字典项不存在")
+			}
+			return err
+		}
+		if parent.SysDictionaryID != detail.SysDictionaryID {
+			return errors.New("上级字典项必须属于同一字典")
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// sortDictionaryDetails 按 sort、id 排序
+func sortDictionaryDetails(details []system.SysDictionaryDetail) {
+	sort.SliceStable(details, func(i, j int) bool {
+		if details[i].Sort != details[j].Sort {
+			return details[i].Sort < details[j].Sort
+		}
+		return details[i].ID < details[j].ID
+	})
+}
@@ -0,0 +1,180 @@
+package system
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func setupDictionaries(t *testing.T) uint {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysDictionary{}, &system.SysDictionaryDetail{})
+	clearDictionaryCache()
+
+	enabled := true
+	if err := DictionaryServiceApp.CreateSysDictionary(system.SysDictionary{Name: "地区", Type: "region", Status: &enabled}); err != nil {
+		t.Fatal(err)
+	}
+	var dict system.SysDictionary
+	db.First(&dict, "type = ?", "region")
+	return dict.ID
+}
+
+func createDetail(t *testing.T, detail system.SysDictionaryDetail) uint {
+	t.Helper()
+	enabled := true
+	detail.Status = &enabled
+	if err := global.GVA_DB.Create(&detail).Error; err != nil {
+		t.Fatal(err)
+	}
+	return detail.ID
+}
+
+func TestDictionary_TreeAndLocale(t *testing.T) {
+	dictID := int(setupDictionaries(t))
+	cn := createDetail(t, system.SysDictionaryDetail{Label: "中国", Value: "CN", Sort: 1, SysDictionaryID: dictID, Labels: common.JSONMap{"en": "China"}})
+	createDetail(t, system.SysDictionaryDetail{Label: "北京", Value: "BJ", Sort: 2, SysDictionaryID: dictID, ParentID: cn, Labels: common.JSONMap{"en-US": "Beijing"}})
+	createDetail(t, system.SysDictionaryDetail{Label: "上海", Value: "SH", Sort: 1, SysDictionaryID: dictID, ParentID: cn})
+	createDetail(t, system.SysDictionaryDetail{Label: "日本", Value: "JP", Sort: 2, SysDictionaryID: dictID})
+
+	tree, err := DictionaryDetailServiceApp.GetDictionaryTree("region", "en-US,en;q=0.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 2 || tree[0].Label != "China" || tree[1].Value != "JP" {
+		t.Fatalf("tree = %+v", tree)
+	}
+	children := tree[0].Children
+	if len(children) != 2 || children[0].Value != "SH" || children[0].Label != "上海" || children[1].Label != "Beijing" {
+		t.Fatalf("children = %+v %+v", children[0], children[1])
+	}
+
+	result, err := DictionaryDetailServiceApp.LookupDictionaries("en-GB", map[string][]string{"region": {"CN", "BJ", "XX"}, "missing": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result["region"]; len(got) != 2 || got["CN"] != "China" || got["BJ"] != "北京" {
+		t.Fatalf("region = %v", got)
+	}
+	if len(result["missing"]) != 0 {
+		t.Fatalf("missing = %v", result["missing"])
+	}
+	if got := DictionaryDetailServiceApp.TranslateDictionaryValue("region", "XX", "en"); got != "XX" {
+		t.Fatalf("unknown value translated to %q", got)
+	}
+}
+
+func TestDictionary_CacheInvalidation(t *testing.T) {
+	dictID := int(setupDictionaries(t))
+	createDetail(t, system.SysDictionaryDetail{Label: "男", Value: "1", SysDictionaryID: dictID})
+	if got := DictionaryDetailServiceApp.TranslateDictionaryValue("region", "1", ""); got != "男" {
+		t.Fatalf("got %q", got)
+	}
+
+	// 绕过服务直接写库时命中缓存，通过服务修改后缓存失效
+	global.GVA_DB.Model(&system.SysDictionaryDetail{}).Where("value = ?", "1").Update("label", "Male")
+	if got := DictionaryDetailServiceApp.TranslateDictionaryValue("region", "1", ""); got != "男" {
+		t.Fatalf("expected cached label, got %q", got)
+	}
+	detail, err := DictionaryDetailServiceApp.GetDictionaryInfoByValue(uint(dictID), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	detail.Label = "男性"
+	if err = DictionaryDetailServiceApp.UpdateSysDictionaryDetail(&detail); err != nil {
+		t.Fatal(err)
+	}
+	if got := DictionaryDetailServiceApp.TranslateDictionaryValue("region", "1", ""); got != "男性" {
+		t.Fatalf("got %q after update", got)
+	}
+}
+
+func TestDictionary_RejectsInvalidParent(t *testing.T) {
+	dictID := int(setupDictionaries(t))
+	enabled := true
+	if err := DictionaryServiceApp.CreateSysDictionary(system.SysDictionary{Name: "其他", Type: "other", Status: &enabled}); err != nil {
+		t.Fatal(err)
+	}
+	a := createDetail(t, system.SysDictionaryDetail{Label: "A", Value: "a", SysDictionaryID: dictID})
+	b := createDetail(t, system.SysDictionaryDetail{Label: "B", Value: "b", SysDictionaryID: dictID, ParentID: a})
+	other := createDetail(t, system.SysDictionaryDetail{Label: "O", Value: "o", SysDictionaryID: dictID + 1})
+
+	detail, _ := DictionaryDetailServiceApp.GetSysDictionaryDetail(a)
+	detail.ParentID = b
+	if err := DictionaryDetailServiceApp.UpdateSysDictionaryDetail(&detail); err == nil {
+		t.Fatal("expected cycle to be rejected")
+	}
+	if err := DictionaryDetailServiceApp.CreateSysDictionaryDetail(system.SysDictionaryDetail{Label: "C", Value: "c", SysDictionaryID: dictID, ParentID: other}); err == nil {
+		t.Fatal("expected parent from another dictionary to be rejected")
+	}
+	if err := DictionaryDetailServiceApp.CreateSysDictionaryDetail(system.SysDictionaryDetail{Label: "C", Value: "c", SysDictionaryID: dictID, Labels: common.JSONMap{"en": 1}}); err == nil {
+		t.Fatal("expected non-string label to be rejected")
+	}
+	if err := DictionaryDetailServiceApp.DeleteSysDictionaryDetail(system.SysDictionaryDetail{GVA_MODEL: global.GVA_MODEL{ID: a}}); err == nil {
+		t.Fatal("expected delete of item with children to be rejected")
+	}
+}
+
+func TestDictionary_ImportExport(t *testing.T) {
+	dictID := int(setupDictionaries(t))
+	cn := createDetail(t, system.SysDictionaryDetail{Label: "中国", Value: "CN", SysDictionaryID: dictID, Labels: common.JSONMap{"en": "China"}})
+	createDetail(t, system.SysDictionaryDetail{Label: "北京", Value: "BJ", SysDictionaryID: dictID, ParentID: cn})
+
+	for _, format := range []string{"json", "yaml"} {
+		data, err := DictionaryServiceApp.ExportSysDictionaries(nil, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = DictionaryServiceApp.ImportSysDictionaries(data, format, false); err == nil || !strings.Contains(err.Error(), "已存在") {
+			t.Fatalf("%s: expected existing dictionary to be rejected, got %v", format, err)
+		}
+		count, err := DictionaryServiceApp.ImportSysDictionaries(data, format, true)
+		if err != nil || count != 1 {
+			t.Fatalf("%s: count = %d, err = %v", format, count, err)
+		}
+		tree, err := DictionaryDetailServiceApp.GetDictionaryTree("region", "en")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tree) != 1 || tree[0].Label != "China" || len(tree[0].Children) != 1 || tree[0].Children[0].Value != "BJ" {
+			t.Fatalf("%s: tree = %+v", format, tree)
+		}
+		// 按字典值更新，已有字典项保留 ID，不产生软删除的记录
+		if tree[0].ID != cn {
+			t.Fatalf("%s: detail id changed from %d to %d", format, cn, tree[0].ID)
+		}
+	}
+
+	changed := `{"type":"region","name":"地区","details":[{"label":"中华人民共和国","value":"CN","children":[{"label":"上海","value":"SH"}]}]}`
+	if _, err := DictionaryServiceApp.ImportSysDictionaries([]byte(changed), "json", true); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := DictionaryDetailServiceApp.GetDictionaryTree("region", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 1 || tree[0].ID != cn || tree[0].Label != "中华人民共和国" || len(tree[0].Children) != 1 || tree[0].Children[0].Value != "SH" {
+		t.Fatalf("tree = %+v", tree)
+	}
+	var deleted int64
+	global.GVA_DB.Unscoped().Model(&system.SysDictionaryDetail{}).Where("deleted_at IS NOT NULL").Count(&deleted)
+	if deleted != 1 {
+		t.Fatalf("soft deleted = %d, want only the removed value", deleted)
+	}
+
+	single := `{"type":"gender","name":"性别","details":[{"label":"男","value":"1"},{"label":"女","value":"1"}]}`
+	if _, err := DictionaryServiceApp.ImportSysDictionaries([]byte(single), "json", false); err == nil {
+		t.Fatal("expected duplicate values to be rejected")
+	}
+	single = strings.Replace(single, `"女","value":"1"`, `"女","value":"2"`, 1)
+	if _, err := DictionaryServiceApp.ImportSysDictionaries([]byte(single), "json", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := DictionaryDetailServiceApp.TranslateDictionaryValue("gender", "2", ""); got != "女" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,316 @@
+package system
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const (
+	DictionaryFormatJSON = "json"
+	DictionaryFormatYAML = "yaml"
+)
+
+// dictionaryFormat 规范化导入导出格式，yml 视为 yaml，为空时使用 json
+func dictionaryFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "", DictionaryFormatJSON:
+		return DictionaryFormatJSON, nil
+	case DictionaryFormatYAML, "yml":
+		return DictionaryFormatYAML, nil
+	}
+	return "", fmt.Errorf("不支持的格式 %s，仅支持 json、yaml", format)
+}
+
+//@function: ExportSysDictionaries
+//@description: 导出字典及其全部字典项（含停用项），未指定ID时导出全部字典
+//@param: ids []uint, format string
+//@return: data []byte, err error
+
+func (dictionaryService *DictionaryService) ExportSysDictionaries(ids []uint, format string) (data []byte, err error) {
+	if format, err = dictionaryFormat(format); err != nil {
+		return nil, err
+	}
+	var dictionaries []system.SysDictionary
+	db := global.GVA_DB.Preload("SysDictionaryDetails").Order("id")
+	if len(ids) > 0 {
+		db = db.Where("id in ?", ids)
+	}
+	if err = db.Find(&dictionaries).Error; err != nil {
+		return nil, err
+	}
+	list := make([]system.SysDictionaryTransfer, 0, len(dictionaries))
+	for _, dict := range dictionaries {
+		list = append(list, system.SysDictionaryTransfer{
+			Name:    dict.Name,
+			Type:    dict.Type,
+			Status:  dict.Status,
+			Desc:    dict.Desc,
+			Details: dictionaryTransferItems(dict.SysDictionaryDetails),
+		})
+	}
+	if format == DictionaryFormatYAML {
+		return yaml.Marshal(list)
+	}
+	return json.MarshalIndent(list, "", "  ")
+}
+
+// dictionaryTransferItems 将扁平的字典项组装为嵌套结构，上级不存在的字典项作为顶级导出
+func dictionaryTransferItems(details []system.SysDictionaryDetail) []system.SysDictionaryTransferItem {
+	sortDictionaryDetails(details)
+	ids := make(map[uint]struct{}, len(details))
+	children := make(map[uint][]system.SysDictionaryDetail)
+	for _, d := range details {
+		ids[d.ID] = struct{}{}
+	}
+	var roots []system.SysDictionaryDetail
+	for _, d := range details {
+		if _, ok := ids[d.ParentID]; ok && d.ParentID != d.ID {
+			children[d.ParentID] = append(children[d.ParentID], d)
+		} else {
+			roots = append(roots, d)
+		}
+	}
+	var build func(list []system.SysDictionaryDetail, depth int) []system.SysDictionaryTransferItem
+	build = func(list []system.SysDictionaryDetail, depth int) []system.SysDictionaryTransferItem {
+		items := make([]system.SysDictionaryTransferItem, 0, len(list))
+		for _, d := range list {
+			item := system.SysDictionaryTransferItem{Label: d.Label, Value: d.Value, Extend: d.Extend, Status: d.Status, Sort: d.Sort, Labels: dictionaryLabels(d.Labels)}
+			if depth < dictionaryMaxDepth {
+				item.Children = build(children[d.ID], depth+1)
+			}
+			items = append(items, item)
+		}
+		return items
+	}
+	return build(roots, 1)
+}
+
+// dictionaryLabels 将字典项的多语言展示值转换为导出格式
+func dictionaryLabels(labels common.JSONMap) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+//@function: ImportSysDictionaries
+//@description: 导入字典，已存在相同 type 的字典时 overwrite 为 true 则按字典值更新字典项，否则拒绝导入；全部成功或全部回滚
+//@param: data []byte, format string, overwrite bool
+//@return: count int, err error
+
+func (dictionaryService *DictionaryService) ImportSysDictionaries(data []byte, format string, overwrite bool) (count int, err error) {
+	if format, err = dictionaryFormat(format); err != nil {
+		return 0, err
+	}
+	list, err := parseDictionaryTransfer(data, format)
+	if err != nil {
+		return 0, err
+	}
+	if len(list) == 0 {
+		return 0, errors.New("文件中没有字典")
+	}
+	types := make(map[string]struct{}, len(list))
+	for i := range list {
+		if err = validateDictionaryTransfer(&list[i]); err != nil {
+			return 0, err
+		}
+		if _, ok := types[list[i].Type]; ok {
+			return 0, fmt.Errorf("字典 %s 重复", list[i].Type)
+		}
+		types[list[i].Type] = struct{}{}
+	}
+
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		for _, item := range list {
+			dict := system.SysDictionary{Name: item.Name, Type: item.Type, Status: item.Status, Desc: item.Desc}
+			var existing system.SysDictionary
+			err := tx.Where("type = ?", item.Type).First(&existing).Error
+			switch {
+			case err == nil:
+				if !overwrite {
+					return fmt.Errorf("字典 %s 已存在", item.Type)
+				}
+				dict.ID = existing.ID
+				if err = tx.Model(&existing).Updates(map[string]interface{}{"name": dict.Name, "status": dict.Status, "desc": dict.Desc}).Error; err != nil {
+					return err
+				}
+				if err = upsertDictionaryTransferItems(tx, int(dict.ID), item.Details); err != nil {
+					return fmt.Errorf("字典 %s: %w", item.Type, err)
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err = tx.Create(&dict).Error; err != nil {
+					return err
+				}
+				if err = createDictionaryTransferItems(tx, int(dict.ID), 0, item.Details); err != nil {
+					return fmt.Errorf("字典 %s: %w", item.Type, err)
+				}
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	invalidateDictionaries()
+	return len(list), nil
+}
+
+// parseDictionaryTransfer 解析导入文件，既支持字典数组也支持单个字典
+func parseDictionaryTransfer(data []byte, format string) (list []system.SysDictionaryTransfer, err error) {
+	trimmed := bytes.TrimSpace(data)
+	if format == DictionaryFormatJSON {
+		if len(trimmed) > 0 && trimmed[0] == '{' {
+			var single system.SysDictionaryTransfer
+			err = json.Unmarshal(trimmed, &single)
+			list = append(list, single)
+		} else {
+			err = json.Unmarshal(trimmed, &list)
+		}
+	} else {
+		var node yaml.Node
+		if err = yaml.Unmarshal(trimmed, &node); err == nil && len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+			var single system.SysDictionaryTransfer
+			err = node.Decode(&single)
+			list = append(list, single)
+		} else if err == nil {
+			err = node.Decode(&list)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("文件格式错误: %w", err)
+	}
+	return list, nil
+}
+
+// validateDictionaryTransfer 校验导入的字典，同一字典中的字典值不能重复，未填写状态时视为启用
+func validateDictionaryTransfer(dict *system.SysDictionaryTransfer) error {
+	dict.Type = strings.TrimSpace(dict.Type)
+	if dict.Type == "" {
+		return errors.New("字典 type 不能为空")
+	}
+	if dict.Name == "" {
+		dict.Name = dict.Type
+	}
+	if dict.Status == nil {
+		enabled := true
+		dict.Status = &enabled
+	}
+	values := make(map[string]struct{})
+	var check func(items []system.SysDictionaryTransferItem, depth int) error
+	check = func(items []system.SysDictionaryTransferItem, depth int) error {
+		if depth > dictionaryMaxDepth {
+			return fmt.Errorf("字典 %s 的层级不能超过 %d 级", dict.Type, dictionaryMaxDepth)
+		}
+		for i := range items {
+			if _, ok := values[items[i].Value]; ok {
+				return fmt.Errorf("字典 %s 中的字典值 %s 重复", dict.Type, items[i].Value)
+			}
+			values[items[i].Value] = struct{}{}
+			if items[i].Status == nil {
+				enabled := true
+				items[i].Status = &enabled
+			}
+			if err := check(items[i].Children, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(dict.Details, 1)
+}
+
+func createDictionaryTransferItems(tx *gorm.DB, dictionaryID int, parentID uint, items []system.SysDictionaryTransferItem) error {
+	for _, item := range items {
+		detail := dictionaryTransferDetail(item, dictionaryID, parentID)
+		if err := tx.Create(&detail).Error; err != nil {
+			return err
+		}
+		if err := createDictionaryTransferItems(tx, dictionaryID, detail.ID, item.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertDictionaryTransferItems 按字典值覆盖已有字典的字典项：已存在的更新内容与层级并保留 ID，
+// 不存在的新增，文件中没有的删除
+func upsertDictionaryTransferItems(tx *gorm.DB, dictionaryID int, items []system.SysDictionaryTransferItem) error {
+	var details []system.SysDictionaryDetail
+	if err := tx.Where("sys_dictionary_id = ?", dictionaryID).Order("id").Find(&details).Error; err != nil {
+		return err
+	}
+	existing := make(map[string]system.SysDictionaryDetail, len(details))
+	for _, d := range details {
+		if _, ok := existing[d.Value]; !ok {
+			existing[d.Value] = d
+		}
+	}
+	kept := make(map[uint]struct{}, len(details))
+	var upsert func(parentID uint, items []system.SysDictionaryTransferItem) error
+	upsert = func(parentID uint, items []system.SysDictionaryTransferItem) error {
+		for _, item := range items {
+			detail := dictionaryTransferDetail(item, dictionaryID, parentID)
+			if old, ok := existing[item.Value]; ok {
+				detail.ID = old.ID
+				err := tx.Model(&old).Select("label", "extend", "status", "sort", "parent_id", "labels").Updates(&detail).Error
+				if err != nil {
+					return err
+				}
+			} else if err := tx.Create(&detail).Error; err != nil {
+				return err
+			}
+			kept[detail.ID] = struct{}{}
+			if err := upsert(detail.ID, item.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := upsert(0, items); err != nil {
+		return err
+	}
+	var removed []uint
+	for _, d := range details {
+		if _, ok := kept[d.ID]; !ok {
+			removed = append(removed, d.ID)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return tx.Delete(&system.SysDictionaryDetail{}, removed).Error
+}
+
+func dictionaryTransferDetail(item system.SysDictionaryTransferItem, dictionaryID int, parentID uint) system.SysDictionaryDetail {
+	detail := system.SysDictionaryDetail{
+		Label:           item.Label,
+		Value:           item.Value,
+		Extend:          item.Extend,
+		Status:          item.Status,
+		Sort:            item.Sort,
+		SysDictionaryID: dictionaryID,
+		ParentID:        parentID,
+	}
+	if len(item.Labels) > 0 {
+		detail.Labels = make(common.JSONMap, len(item.Labels))
+		for k, v := range item.Labels {
+			detail.Labels[k] = v
+		}
+	}
+	return detail
+}
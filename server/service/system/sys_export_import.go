@@ -0,0 +1,487 @@
+package system
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 导入值类型
+const (
+	importTypeString   = "string"
+	importTypeInt      = "int"
+	importTypeFloat    = "float"
+	importTypeBool     = "bool"
+	importTypeDate     = "date"
+	importTypeDatetime = "datetime"
+)
+
+// importKeyBatch 按更新字段查询已有数据时每批的取值个数
+const importKeyBatch = 500
+
+var importTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	time.RFC3339,
+}
+
+// importColumn 模板中可导入的字段及其校验规则
+type importColumn struct {
+	title    string
+	column   string
+	index    int
+	required bool
+	kind     string
+	length   int64
+	dict     map[string]string // 字典值与展示值到字典值的映射
+}
+
+type importRow struct {
+	line   int
+	key    string
+	values map[string]interface{}
+}
+
+// ImportExcel 导入Excel，逐行校验后写入，被拒绝的行不影响其他行
+// dryRun 为 true 时只校验并统计，不写入数据
+// Author [piexlmax](https://github.com/piexlmax)
+func (sysExportTemplateService *SysExportTemplateService) ImportExcel(templateID string, file *multipart.FileHeader, dryRun bool) (result systemRes.ImportResult, err error) {
+	result.DryRun = dryRun
+	var template system.SysExportTemplate
+	err = global.GVA_DB.First(&template, "template_id = ?", templateID).Error
+	if err != nil {
+		return result, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return result, err
+	}
+	defer src.Close()
+
+	f, err := excelize.OpenReader(src)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	// 读取原始值，避免数字与日期被单元格格式改写
+	rows, err := f.GetRows("Sheet1", excelize.Options{RawCellValue: true})
+	if err != nil {
+		return result, err
+	}
+	if len(rows) < 2 {
+		return result, errors.New("Excel data is not enough.\nIt should contain title row and data")
+	}
+
+	db := global.GVA_DB
+	if template.DBName != "" {
+		db = global.MustGetGlobalDBByDBName(template.DBName)
+	}
+	if _, err = compileExportTemplate(db, template); err != nil {
+		return result, err
+	}
+	columns, keyColumn, err := sysExportTemplateService.importColumns(db, template, rows[0])
+	if err != nil {
+		return result, err
+	}
+
+	valid := make([]importRow, 0, len(rows)-1)
+	keyLines := make(map[string]int)
+	for i, row := range rows[1:] {
+		line := i + 2
+		if isBlankRow(row) {
+			continue
+		}
+		result.Total++
+		values, rowErrors := validateImportRow(columns, row, line)
+		r := importRow{line: line, values: values}
+		if keyColumn != nil && len(rowErrors) == 0 {
+			if v, ok := values[keyColumn.column]; !ok {
+				rowErrors = append(rowErrors, systemRes.ImportError{Row: line, Column: keyColumn.title, Reason: "更新字段不能为空"})
+			} else {
+				r.key = fmt.Sprint(v)
+				if first, dup := keyLines[r.key]; dup {
+					rowErrors = append(rowErrors, systemRes.ImportError{Row: line, Column: keyColumn.title, Value: r.key, Reason: fmt.Sprintf("与第%d行重复", first)})
+				} else {
+					keyLines[r.key] = line
+				}
+			}
+		}
+		if len(rowErrors) > 0 {
+			result.Failed++
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		valid = append(valid, r)
+	}
+
+	if dryRun {
+		existing := make(map[string]bool)
+		if keyColumn != nil {
+			if existing, err = existingImportKeys(db, template.TableName, keyColumn.column, valid); err != nil {
+				return result, err
+			}
+		}
+		for _, r := range valid {
+			if existing[r.key] {
+				result.Updated++
+			} else {
+				result.Created++
+			}
+		}
+		return result, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		existing := make(map[string]bool)
+		if keyColumn != nil {
+			var err error
+			if existing, err = existingImportKeys(tx, template.TableName, keyColumn.column, valid); err != nil {
+				return err
+			}
+		}
+		needCreated := tx.Migrator().HasColumn(template.TableName, "created_at")
+		needUpdated := tx.Migrator().HasColumn(template.TableName, "updated_at")
+		for _, r := range valid {
+			now := time.Now()
+			if needUpdated && r.values["updated_at"] == nil {
+				r.values["updated_at"] = now
+			}
+			// 每行使用保存点，写入失败只回滚该行
+			savePoint := fmt.Sprintf("import_row_%d", r.line)
+			if err := tx.SavePoint(savePoint).Error; err != nil {
+				return err
+			}
+			var err error
+			update := existing[r.key]
+			if update {
+				err = tx.Table(template.TableName).
+					Where(clause.Eq{Column: clause.Column{Name: keyColumn.column}, Value: r.values[keyColumn.column]}).
+					Updates(r.values).Error
+			} else {
+				if needCreated && r.values["created_at"] == nil {
+					r.values["created_at"] = now
+				}
+				err = tx.Table(template.TableName).Create(r.values).Error
+			}
+			if err != nil {
+				if rbErr := tx.RollbackTo(savePoint).Error; rbErr != nil {
+					return rbErr
+				}
+				result.Failed++
+				result.Errors = append(result.Errors, systemRes.ImportError{Row: r.line, Reason: "写入失败: " + err.Error()})
+				continue
+			}
+			if update {
+				result.Updated++
+			} else {
+				result.Created++
+				if keyColumn != nil {
+					existing[r.key] = true
+				}
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// importColumns 根据模板与表结构确定可导入的字段及校验规则，keyColumn 为更新字段
+func (sysExportTemplateService *SysExportTemplateService) importColumns(db *gorm.DB, template system.SysExportTemplate, header []string) (columns []importColumn, keyColumn *importColumn, err error) {
+	keys, err := utils.GetJSONKeys(template.TemplateInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	var templateInfoMap = make(map[string]string)
+	if err = json.Unmarshal([]byte(template.TemplateInfo), &templateInfoMap); err != nil {
+		return nil, nil, err
+	}
+	rules, err := template.ParseImportRules()
+	if err != nil {
+		return nil, nil, err
+	}
+	columnTypes, err := db.Migrator().ColumnTypes(template.TableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	typeMap := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, ct := range columnTypes {
+		typeMap[strings.ToLower(ct.Name())] = ct
+	}
+	titleIndex := make(map[string]int, len(header))
+	for i, title := range header {
+		titleIndex[strings.TrimSpace(title)] = i
+	}
+
+	for _, key := range keys {
+		name := strings.Trim(strings.ReplaceAll(key, "`", ""), `"`)
+		if table, column, ok := strings.Cut(name, "."); ok {
+			// 关联表的字段不导入
+			if table != template.TableName {
+				continue
+			}
+			name = column
+		}
+		ct, ok := typeMap[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		rule := rules[key]
+		c := importColumn{title: templateInfoMap[key], column: ct.Name(), required: rule.Required, kind: rule.Type, length: rule.Length}
+		if c.kind == "" {
+			c.kind = importKindOf(ct.DatabaseTypeName())
+		}
+		if !c.required {
+			c.required = columnRequired(ct)
+		}
+		if c.kind == importTypeString && c.length == 0 {
+			if l, ok := ct.Length(); ok && l > 0 && l < math.MaxInt32 {
+				c.length = l
+			}
+		}
+		if rule.Dict != "" {
+			if c.dict, err = importDictionary(rule.Dict); err != nil {
+				return nil, nil, err
+			}
+		}
+		idx, inFile := titleIndex[c.title]
+		if !inFile {
+			if c.required {
+				return nil, nil, fmt.Errorf("Excel缺少必填列: %s", c.title)
+			}
+			continue
+		}
+		c.index = idx
+		columns = append(columns, c)
+	}
+
+	if template.ImportKey != "" {
+		for i := range columns {
+			if strings.EqualFold(columns[i].column, template.ImportKey) {
+				keyColumn = &columns[i]
+				break
+			}
+		}
+		if keyColumn == nil {
+			return nil, nil, fmt.Errorf("更新字段 %s 必须包含在导入的列中", template.ImportKey)
+		}
+	}
+	return columns, keyColumn, nil
+}
+
+// columnRequired 非空、无默认值且非主键的字段必填，时间戳字段由导入自动填充
+func columnRequired(ct gorm.ColumnType) bool {
+	switch strings.ToLower(ct.Name()) {
+	case "created_at", "updated_at", "deleted_at":
+		return false
+	}
+	nullable, ok := ct.Nullable()
+	if !ok || nullable {
+		return false
+	}
+	if pk, _ := ct.PrimaryKey(); pk {
+		return false
+	}
+	if _, hasDefault := ct.DefaultValue(); hasDefault {
+		return false
+	}
+	return true
+}
+
+func importKindOf(databaseType string) string {
+	t := strings.ToLower(databaseType)
+	switch {
+	case strings.Contains(t, "bool"):
+		return importTypeBool
+	case strings.Contains(t, "int"):
+		return importTypeInt
+	case strings.Contains(t, "decimal"), strings.Contains(t, "numeric"), strings.Contains(t, "float"),
+		strings.Contains(t, "double"), strings.Contains(t, "real"), strings.Contains(t, "money"):
+		return importTypeFloat
+	case strings.Contains(t, "datetime"), strings.Contains(t, "timestamp"):
+		return importTypeDatetime
+	case t == "date":
+		return importTypeDate
+	}
+	return importTypeString
+}
+
+func importDictionary(dictType string) (map[string]string, error) {
+	var dict system.SysDictionary
+	err := global.GVA_DB.Preload("SysDictionaryDetails", "status = ? OR status IS NULL", true).
+		Where("type = ?", dictType).First(&dict).Error
+	if err != nil {
+		return nil, fmt.Errorf("导入校验规则引用的字典 %s 不存在", dictType)
+	}
+	values := make(map[string]string, len(dict.SysDictionaryDetails)*2)
+	for _, d := range dict.SysDictionaryDetails {
+		values[d.Label] = d.Value
+	}
+	// 字典值优先于同名的展示值
+	for _, d := range dict.SysDictionaryDetails {
+		values[d.Value] = d.Value
+	}
+	return values, nil
+}
+
+// validateImportRow 校验并转换一行数据，空单元格不写入，由数据库使用默认值
+func validateImportRow(columns []importColumn, row []string, line int) (map[string]interface{}, []systemRes.ImportError) {
+	values := make(map[string]interface{}, len(columns))
+	var rowErrors []systemRes.ImportError
+	for _, c := range columns {
+		raw := ""
+		if c.index < len(row) {
+			raw = strings.TrimSpace(row[c.index])
+		}
+		if raw == "" {
+			if c.required {
+				rowErrors = append(rowErrors, systemRes.ImportError{Row: line, Column: c.title, Reason: "不能为空"})
+			}
+			continue
+		}
+		if c.dict != nil {
+			v, ok := c.dict[raw]
+			if !ok {
+				rowErrors = append(rowErrors, systemRes.ImportError{Row: line, Column: c.title, Value: raw, Reason: "不是字典中的可选值"})
+				continue
+			}
+			raw = v
+		}
+		v, reason := convertImportValue(c, raw)
+		if reason != "" {
+			rowErrors = append(rowErrors, systemRes.ImportError{Row: line, Column: c.title, Value: raw, Reason: reason})
+			continue
+		}
+		values[c.column] = v
+	}
+	return values, rowErrors
+}
+
+func convertImportValue(c importColumn, raw string) (interface{}, string) {
+	switch c.kind {
+	case importTypeInt:
+		if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return i, ""
+		}
+		// Excel 中的整数可能以 1.0 的形式保存
+		if f, err := strconv.ParseFloat(raw, 64); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return int64(f), ""
+		}
+		return nil, "不是有效的整数"
+	case importTypeFloat:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, "不是有效的数字"
+		}
+		// 保留原始文本，避免小数经过浮点转换后丢失精度
+		return raw, ""
+	case importTypeBool:
+		switch strings.ToLower(raw) {
+		case "1", "true", "yes", "y", "是":
+			return true, ""
+		case "0", "false", "no", "n", "否":
+			return false, ""
+		}
+		return nil, "不是有效的布尔值"
+	case importTypeDate, importTypeDatetime:
+		t, ok := parseImportTime(raw)
+		if !ok {
+			return nil, "不是有效的日期"
+		}
+		if c.kind == importTypeDate {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		}
+		return t, ""
+	}
+	if c.length > 0 && int64(utf8.RuneCountInString(raw)) > c.length {
+		return nil, fmt.Sprintf("长度超过%d", c.length)
+	}
+	return raw, ""
+}
+
+// parseImportTime 支持常见的日期文本与 Excel 日期序列号
+func parseImportTime(raw string) (time.Time, bool) {
+	for _, layout := range importTimeLayouts {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil && f > 0 {
+		if t, err := excelize.ExcelDateToTime(f, false); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// existingImportKeys 查询更新字段取值中已存在于表中的部分
+func existingImportKeys(db *gorm.DB, table, column string, rows []importRow) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for start := 0; start < len(rows); start += importKeyBatch {
+		end := min(start+importKeyBatch, len(rows))
+		values := make([]interface{}, 0, end-start)
+		for _, r := range rows[start:end] {
+			values = append(values, r.values[column])
+		}
+		var found []string
+		err := db.Table(table).Where(clause.IN{Column: clause.Column{Name: column}, Values: values}).
+			Pluck(column, &found).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range found {
+			existing[k] = true
+		}
+	}
+	return existing, nil
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ImportErrorWorkbook 生成导入错误报告
+func (sysExportTemplateService *SysExportTemplateService) ImportErrorWorkbook(importErrors []systemRes.ImportError) (*bytes.Buffer, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	sw, err := f.NewStreamWriter("Sheet1")
+	if err != nil {
+		return nil, err
+	}
+	if err = sw.SetRow("A1", []interface{}{"行号", "列", "值", "原因"}); err != nil {
+		return nil, err
+	}
+	for i, e := range importErrors {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err = sw.SetRow(cell, []interface{}{e.Row, e.Column, e.Value, e.Reason}); err != nil {
+			return nil, err
+		}
+	}
+	if err = sw.Flush(); err != nil {
+		return nil, err
+	}
+	return f.WriteToBuffer()
+}
@@ -0,0 +1,163 @@
+package system
+
+import (
+	"mime/multipart"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/upload"
+)
+
+type importProduct struct {
+	ID        uint
+	Sku       string `gorm:"size:16;not null;uniqueIndex"`
+	Name      string `gorm:"size:8"`
+	Stock     int64
+	Price     float64
+	Status    string
+	OnSale    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (importProduct) TableName() string { return "test_import_products" }
+
+func setupImport(t *testing.T, importKey string) {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysExportTemplate{}, &system.SysDictionary{}, &system.SysDictionaryDetail{}, &importProduct{})
+
+	enabled := true
+	db.Create(&system.SysDictionary{Name: "商品状态", Type: "product_status", Status: &enabled, SysDictionaryDetails: []system.SysDictionaryDetail{
+		{Label: "上架", Value: "on", Status: &enabled},
+		{Label: "下架", Value: "off", Status: &enabled},
+	}})
+	db.Create(&system.SysExportTemplate{
+		Name:         "商品",
+		TableName:    "test_import_products",
+		TemplateID:   "products",
+		TemplateInfo: `{"sku":"编码","name":"名称","stock":"库存","price":"价格","status":"状态","on_sale":"上架日期"}`,
+		ImportKey:    importKey,
+		ImportRules:  `{"status":{"required":true,"dict":"product_status"},"on_sale":{"type":"date"},"name":{"length":8}}`,
+	})
+	db.Create(&importProduct{Sku: "A-1", Name: "旧名称", Stock: 1, Status: "off"})
+}
+
+func importFile(t *testing.T, rows [][]interface{}) *multipart.FileHeader {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "import.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	header, cleanup, err := upload.FileHeaderFromPath(path, "import.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cleanup)
+	return header
+}
+
+var importRows = [][]interface{}{
+	{"编码", "名称", "库存", "价格", "状态", "上架日期"},
+	{"A-1", "新名称", 10, 9.5, "上架", "2024-05-01"},
+	{"A-2", "商品二", "abc", 1, "on", ""},
+	{"", "缺编码", 1, 1, "on", ""},
+	{"A-3", "名称太长超过八个字符", 1, 1, "on", ""},
+	{"A-4", "商品四", 4, 4, "未知", ""},
+	{},
+	{"A-5", "商品五", 5, "5.25", "下架", 45292},
+}
+
+func TestSysExportTemplateService_ImportDryRun(t *testing.T) {
+	setupImport(t, "sku")
+	s := &SysExportTemplateService{}
+	result, err := s.ImportExcel("products", importFile(t, importRows), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 6 || result.Failed != 4 || result.Updated != 1 || result.Created != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	reasons := map[int]string{}
+	for _, e := range result.Errors {
+		reasons[e.Row] = e.Column + ":" + e.Reason
+	}
+	want := map[int]string{3: "库存:不是有效的整数", 4: "编码:不能为空", 5: "名称:长度超过8", 6: "状态:不是字典中的可选值"}
+	for row, reason := range want {
+		if reasons[row] != reason {
+			t.Errorf("row %d: got %q, want %q", row, reasons[row], reason)
+		}
+	}
+	var count int64
+	global.GVA_DB.Model(&importProduct{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("dry run must not write, count = %d", count)
+	}
+
+	report, err := s.ImportErrorWorkbook(result.Errors)
+	if err != nil || report.Len() == 0 {
+		t.Fatalf("error workbook: %v", err)
+	}
+}
+
+func TestSysExportTemplateService_ImportUpsert(t *testing.T) {
+	setupImport(t, "sku")
+	s := &SysExportTemplateService{}
+	result, err := s.ImportExcel("products", importFile(t, importRows), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 1 || result.Created != 1 || result.Failed != 4 {
+		t.Fatalf("result = %+v", result)
+	}
+	var updated, created importProduct
+	global.GVA_DB.First(&updated, "sku = ?", "A-1")
+	if updated.Name != "新名称" || updated.Stock != 10 || updated.Status != "on" || updated.OnSale == nil {
+		t.Fatalf("updated row = %+v", updated)
+	}
+	global.GVA_DB.First(&created, "sku = ?", "A-5")
+	if created.Price != 5.25 || created.Status != "off" || created.OnSale == nil || created.OnSale.Format("2006-01-02") != "2024-01-01" {
+		t.Fatalf("created row = %+v", created)
+	}
+}
+
+func TestSysExportTemplateService_ImportInsertOnlyRejectsDuplicates(t *testing.T) {
+	setupImport(t, "")
+	s := &SysExportTemplateService{}
+	result, err := s.ImportExcel("products", importFile(t, [][]interface{}{
+		{"编码", "名称", "状态"},
+		{"B-1", "一", "on"},
+		{"A-1", "与已有数据冲突", "on"},
+		{"B-2", "二", "on"},
+	}), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 唯一索引冲突只回滚该行
+	if result.Created != 2 || result.Failed != 1 || len(result.Errors) != 1 || result.Errors[0].Row != 3 {
+		t.Fatalf("result = %+v", result)
+	}
+	var count int64
+	global.GVA_DB.Model(&importProduct{}).Count(&count)
+	if count != 3 {
+		t.Fatalf("count = %d, want 3", count)
+	}
+
+	_, err = s.ImportExcel("products", importFile(t, [][]interface{}{{"名称"}, {"缺少必填列"}}), false)
+	if err == nil {
+		t.Fatal("missing required column must be rejected")
+	}
+}
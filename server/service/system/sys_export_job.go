@@ -0,0 +1,353 @@
+package system
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	emailGlobal "github.com/flipped-aurora/gin-vue-admin/server/plugin/email/global"
+	emailUtils "github.com/flipped-aurora/gin-vue-admin/server/plugin/email/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/upload"
+	"go.uber.org/zap"
+)
+
+const (
+	// exportJobLease 运行中的任务超过该时长未上报进度，视为所在实例已退出，可被其他实例重新领取
+	exportJobLease = 5 * time.Minute
+	// exportJobHeartbeat 运行中任务上报进度的间隔，需明显小于租约时长
+	exportJobHeartbeat = 5 * time.Second
+	// defaultExportWorkers 未配置时本实例同时执行的任务数
+	defaultExportWorkers = 2
+	// defaultExportLinkExpire 未配置时下载链接的有效期
+	defaultExportLinkExpire = 24 * time.Hour
+)
+
+var ErrExportLinkExpired = errors.New("下载链接无效或已过期")
+
+var (
+	exportJobSlotsOnce sync.Once
+	exportJobSlots     chan struct{}
+)
+
+type ExportJobService struct{}
+
+var ExportJobServiceApp = new(ExportJobService)
+
+// CreateExportJob 校验导出参数并创建排队中的任务，由 PollExportJobs 领取执行
+func (exportJobService *ExportJobService) CreateExportJob(userId uint, templateID, params, format string) (job system.SysExportJob, err error) {
+	if format, err = NormalizeExportFormat(format); err != nil {
+		return job, err
+	}
+	query, err := SysExportTemplateServiceApp.PrepareExport(templateID, url.Values{"params": {params}})
+	if err != nil {
+		return job, err
+	}
+	// 没有签名密钥时无法生成下载链接，不创建任务
+	if _, err = exportDownloadKey(); err != nil {
+		return job, err
+	}
+	job = system.SysExportJob{
+		SysUserId:  userId,
+		TemplateID: templateID,
+		Name:       query.Name,
+		Format:     format,
+		Params:     params,
+		Status:     system.ExportJobPending,
+		Total:      -1,
+	}
+	if err = global.GVA_DB.Create(&job).Error; err != nil {
+		return job, err
+	}
+	// 本实例有空闲时立即开始，无需等待下一次轮询
+	go exportJobService.PollExportJobs()
+	return job, nil
+}
+
+// GetExportJob 获取用户自己的导出任务
+func (exportJobService *ExportJobService) GetExportJob(userId, id uint) (job system.SysExportJob, err error) {
+	err = global.GVA_DB.Where("id = ? AND sys_user_id = ?", id, userId).First(&job).Error
+	return job, err
+}
+
+// GetExportJobList 分页获取用户自己的导出任务
+func (exportJobService *ExportJobService) GetExportJobList(userId uint, info request.PageInfo) (list []system.SysExportJob, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&system.SysExportJob{}).Where("sys_user_id = ?", userId)
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id desc").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// PollExportJobs 领取排队中或租约已过期的任务并在后台执行，由定时器定期调用
+// 多实例部署时通过条件更新领取任务，同一任务只会被一个实例执行
+func (exportJobService *ExportJobService) PollExportJobs() {
+	if global.GVA_DB == nil {
+		return
+	}
+	exportJobSlotsOnce.Do(func() {
+		workers := global.GVA_CONFIG.Excel.Workers
+		if workers <= 0 {
+			workers = defaultExportWorkers
+		}
+		exportJobSlots = make(chan struct{}, workers)
+	})
+	free := cap(exportJobSlots) - len(exportJobSlots)
+	if free <= 0 {
+		return
+	}
+	var ids []uint
+	err := global.GVA_DB.Model(&system.SysExportJob{}).
+		Where("status = ? OR (status = ? AND updated_at < ?)", system.ExportJobPending, system.ExportJobRunning, time.Now().Add(-exportJobLease)).
+		Order("id").Limit(free).Pluck("id", &ids).Error
+	if err != nil {
+		global.GVA_LOG.Error("查询导出任务失败", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		select {
+		case exportJobSlots <- struct{}{}:
+		default:
+			return
+		}
+		if !exportJobService.claim(id) {
+			<-exportJobSlots
+			continue
+		}
+		go func(id uint) {
+			defer func() { <-exportJobSlots }()
+			exportJobService.run(id)
+		}(id)
+	}
+}
+
+func (exportJobService *ExportJobService) claim(id uint) bool {
+	res := global.GVA_DB.Model(&system.SysExportJob{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", id, system.ExportJobPending, system.ExportJobRunning, time.Now().Add(-exportJobLease)).
+		Updates(map[string]interface{}{"status": system.ExportJobRunning, "rows": 0, "error": ""})
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+func (exportJobService *ExportJobService) run(id uint) {
+	var job system.SysExportJob
+	if err := global.GVA_DB.First(&job, id).Error; err != nil {
+		global.GVA_LOG.Error("读取导出任务失败", zap.Uint("id", id), zap.Error(err))
+		return
+	}
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("导出异常: %v", r)
+			}
+		}()
+		return exportJobService.export(&job)
+	}()
+	if err != nil {
+		global.GVA_LOG.Error("导出任务失败", zap.Uint("id", id), zap.Error(err))
+		msg := err.Error()
+		if r := []rune(msg); len(r) > 160 {
+			msg = string(r[:160])
+		}
+		job.Status, job.Error = system.ExportJobFailed, msg
+		global.GVA_DB.Model(&job).Updates(map[string]interface{}{"status": job.Status, "error": job.Error})
+	}
+	exportJobService.notify(&job)
+}
+
+// export 先写入本地临时文件，完成后转存为对象存储中的私有对象，只能通过签名链接下载
+func (exportJobService *ExportJobService) export(job *system.SysExportJob) error {
+	query, err := SysExportTemplateServiceApp.PrepareExport(job.TemplateID, url.Values{"params": {job.Params}})
+	if err != nil {
+		return err
+	}
+	job.Total = query.Count()
+	global.GVA_DB.Model(job).Update("total", job.Total)
+
+	tmp, err := os.CreateTemp("", "gva-export-*."+job.Format)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// 定期上报进度，同时作为租约心跳
+	var written atomic.Int64
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(exportJobHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				global.GVA_DB.Model(&system.SysExportJob{}).Where("id = ?", job.ID).Update("rows", written.Load())
+			}
+		}
+	}()
+	rows, err := query.Write(tmp, job.Format, written.Store)
+	close(done)
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	key, err := upload.SavePrivate("export", "."+job.Format, tmp, size)
+	if err != nil {
+		return fmt.Errorf("保存导出文件失败: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(exportLinkExpire())
+	job.Status, job.Rows, job.Size, job.FileKey = system.ExportJobSuccess, rows, size, key
+	job.FinishedAt, job.ExpiresAt = &now, &expiresAt
+	return global.GVA_DB.Model(job).Updates(map[string]interface{}{
+		"status":      job.Status,
+		"rows":        job.Rows,
+		"size":        job.Size,
+		"file_key":    job.FileKey,
+		"finished_at": job.FinishedAt,
+		"expires_at":  job.ExpiresAt,
+	}).Error
+}
+
+// notify 配置了邮件插件与对外地址时，向任务创建人发送导出结果
+func (exportJobService *ExportJobService) notify(job *system.SysExportJob) {
+	baseUrl := strings.TrimSuffix(global.GVA_CONFIG.Excel.PublicUrl, "/")
+	if baseUrl == "" || emailGlobal.GlobalConfig.Host == "" {
+		return
+	}
+	var user system.SysUser
+	if err := global.GVA_DB.Select("email").Where("id = ?", job.SysUserId).First(&user).Error; err != nil || user.Email == "" {
+		return
+	}
+	name := html.EscapeString(job.Name)
+	var subject, body string
+	if job.Status == system.ExportJobSuccess {
+		link := baseUrl + global.GVA_CONFIG.System.RouterPrefix + exportJobService.DownloadUrl(job)
+		subject = fmt.Sprintf("导出完成：%s", job.Name)
+		body = fmt.Sprintf(`<p>%s 已导出 %d 行。</p><p><a href="%s">点击下载</a>，链接将于 %s 失效。</p>`,
+			name, job.Rows, html.EscapeString(link), job.ExpiresAt.Format("2006-01-02 15:04:05"))
+	} else {
+		subject = fmt.Sprintf("导出失败：%s", job.Name)
+		body = fmt.Sprintf("<p>%s 导出失败：%s</p>", name, html.EscapeString(job.Error))
+	}
+	if err := emailUtils.Email(user.Email, subject, body); err != nil {
+		global.GVA_LOG.Warn("发送导出通知失败", zap.Uint("id", job.ID), zap.Error(err))
+	}
+}
+
+// DownloadUrl 已完成且未过期任务的下载地址，链接与任务同时过期
+func (exportJobService *ExportJobService) DownloadUrl(job *system.SysExportJob) string {
+	if job.Status != system.ExportJobSuccess || job.ExpiresAt == nil || time.Now().After(*job.ExpiresAt) {
+		return ""
+	}
+	payload := fmt.Sprintf("%d.%d", job.ID, job.ExpiresAt.Unix())
+	signature, err := exportDownloadSignature(payload)
+	if err != nil {
+		return ""
+	}
+	return "/sysExportTemplate/downloadExportJob?token=" + url.QueryEscape(payload+"."+signature)
+}
+
+// ResolveDownload 校验下载令牌并返回对应的任务
+func (exportJobService *ExportJobService) ResolveDownload(token string) (job system.SysExportJob, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return job, ErrExportLinkExpired
+	}
+	payload := parts[0] + "." + parts[1]
+	signature, err := exportDownloadSignature(payload)
+	if err != nil || !hmac.Equal([]byte(parts[2]), []byte(signature)) {
+		return job, ErrExportLinkExpired
+	}
+	id, err1 := strconv.ParseUint(parts[0], 10, 64)
+	exp, err2 := strconv.ParseInt(parts[1], 10, 64)
+	if err1 != nil || err2 != nil || time.Now().Unix() > exp {
+		return job, ErrExportLinkExpired
+	}
+	if err = global.GVA_DB.First(&job, id).Error; err != nil {
+		return job, ErrExportLinkExpired
+	}
+	if job.Status != system.ExportJobSuccess || job.ExpiresAt == nil || job.ExpiresAt.Unix() != exp {
+		return job, ErrExportLinkExpired
+	}
+	return job, nil
+}
+
+// PruneExpired 删除下载链接已过期任务的文件
+func (exportJobService *ExportJobService) PruneExpired() error {
+	var jobs []system.SysExportJob
+	err := global.GVA_DB.Where("status = ? AND expires_at < ?", system.ExportJobSuccess, time.Now()).Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return err
+	}
+	for i := range jobs {
+		if jobs[i].FileKey != "" {
+			if err = upload.DeletePrivate(jobs[i].FileKey); err != nil {
+				global.GVA_LOG.Warn("删除过期导出文件失败", zap.Uint("id", jobs[i].ID), zap.Error(err))
+			}
+		}
+		if err = global.GVA_DB.Model(&jobs[i]).Updates(map[string]interface{}{
+			"status":   system.ExportJobExpired,
+			"file_key": "",
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportFileName 下载时使用的文件名
+func ExportFileName(job *system.SysExportJob) string {
+	return job.Name + "." + job.Format
+}
+
+func exportLinkExpire() time.Duration {
+	if d, err := utils.ParseDuration(global.GVA_CONFIG.Excel.LinkExpire); err == nil && d > 0 {
+		return d
+	}
+	return defaultExportLinkExpire
+}
+
+// exportDownloadKey 下载链接的签名密钥，必须单独配置，不与其他用途共用
+func exportDownloadKey() ([]byte, error) {
+	key := global.GVA_CONFIG.Excel.DownloadSigningKey
+	if key == "" {
+		return nil, errors.New("未配置导出下载签名密钥 excel.download-signing-key")
+	}
+	return []byte(key), nil
+}
+
+func exportDownloadSignature(payload string) (string, error) {
+	key, err := exportDownloadKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
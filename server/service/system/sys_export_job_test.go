@@ -0,0 +1,214 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/upload"
+)
+
+type exportRow struct {
+	ID     uint
+	Name   string
+	Code   string
+	Amount float64
+}
+
+func (exportRow) TableName() string { return "test_export_rows" }
+
+func setupExport(t *testing.T, rows int) {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysExportTemplate{}, &system.Condition{}, &system.JoinTemplate{},
+		&system.SysExportJob{}, &system.SysUser{}, &exportRow{})
+	global.GVA_CONFIG.System.OssType = "local"
+	global.GVA_CONFIG.Local.StorePath = t.TempDir()
+	global.GVA_CONFIG.Local.Path = "uploads/file"
+	global.GVA_CONFIG.Local.PrivatePath = t.TempDir()
+	global.GVA_CONFIG.Excel.DownloadSigningKey = "test-download-key"
+
+	db.Create(&system.SysExportTemplate{
+		Name:         "订单",
+		TableName:    "test_export_rows",
+		TemplateID:   "rows",
+		TemplateInfo: `{"id":"编号","name":"名称","code":"编码","amount":"金额"}`,
+		Order:        "id asc",
+		Conditions:   []system.Condition{{TemplateID: "rows", From: "name", Column: "name", Operator: "LIKE"}},
+	})
+	list := make([]exportRow, 0, rows)
+	for i := 1; i <= rows; i++ {
+		list = append(list, exportRow{Name: fmt.Sprintf("row-%d", i), Code: fmt.Sprintf("%06d", i), Amount: float64(i) / 4})
+	}
+	if len(list) > 0 {
+		db.CreateInBatches(&list, 500)
+	}
+}
+
+func TestExportQuery_WriteFormats(t *testing.T) {
+	setupExport(t, 3)
+	query, err := SysExportTemplateServiceApp.PrepareExport("rows", url.Values{"params": {"name=row-2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := query.Count(); n != 1 {
+		t.Fatalf("count = %d, want 1", n)
+	}
+
+	var csvBuf bytes.Buffer
+	if _, err = query.Write(&csvBuf, system.ExportFormatCsv, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "\xEF\xBB\xBF编号,名称,编码,金额\n2,row-2,000002,0.5\n"
+	if csvBuf.String() != want {
+		t.Fatalf("csv = %q, want %q", csvBuf.String(), want)
+	}
+
+	var jsonBuf bytes.Buffer
+	if _, err = query.Write(&jsonBuf, system.ExportFormatJsonl, nil); err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err = json.Unmarshal(bytes.TrimSpace(jsonBuf.Bytes()), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["name"] != "row-2" || record["code"] != "000002" {
+		t.Fatalf("jsonl record = %v", record)
+	}
+
+	var xlsxBuf bytes.Buffer
+	if _, err = query.Write(&xlsxBuf, system.ExportFormatXlsx, nil); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&xlsxBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][1] != "名称" || rows[1][2] != "000002" {
+		t.Fatalf("xlsx rows = %v", rows)
+	}
+	// 前导零的编码保持文本，金额保持数值
+	if typ, _ := f.GetCellType("Sheet1", "C2"); typ == excelize.CellTypeNumber {
+		t.Fatal("code with leading zeros must stay text")
+	}
+}
+
+func TestExportQuery_RejectsUnknownOrder(t *testing.T) {
+	setupExport(t, 0)
+	_, err := SysExportTemplateServiceApp.PrepareExport("rows", url.Values{"params": {"order=name;drop table x"}})
+	if err == nil {
+		t.Fatal("expected order validation error")
+	}
+}
+
+func TestExportJobService_RunAndDownload(t *testing.T) {
+	setupExport(t, 1200)
+	s := &ExportJobService{}
+
+	// 未配置专用签名密钥时拒绝创建任务
+	global.GVA_CONFIG.Excel.DownloadSigningKey = ""
+	if _, err := s.CreateExportJob(1, "rows", "", "csv"); err == nil {
+		t.Fatal("export job without download signing key must be rejected")
+	}
+	global.GVA_CONFIG.Excel.DownloadSigningKey = "test-download-key"
+
+	job, err := s.CreateExportJob(1, "rows", "", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		s.PollExportJobs()
+		if err = global.GVA_DB.First(&job, job.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == system.ExportJobSuccess || job.Status == system.ExportJobFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job not finished, status %s", job.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if job.Status != system.ExportJobSuccess {
+		t.Fatalf("job failed: %s", job.Error)
+	}
+	if job.Rows != 1200 || job.Total != 1200 {
+		t.Fatalf("rows = %d total = %d", job.Rows, job.Total)
+	}
+
+	// 导出文件保存在私有目录，公开访问的上传目录中没有任何文件
+	if entries, _ := os.ReadDir(global.GVA_CONFIG.Local.StorePath); len(entries) != 0 {
+		t.Fatalf("export must not be written to the public store path: %v", entries)
+	}
+	if _, err := upload.OpenPrivate("../" + filepath.Base(global.GVA_CONFIG.Local.StorePath)); err == nil {
+		t.Fatal("private key escaping the private path must be rejected")
+	}
+	file, err := upload.OpenPrivate(job.FileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+	file.Close()
+	if lines != 1201 {
+		t.Fatalf("csv lines = %d, want 1201", lines)
+	}
+
+	// 下载链接可解析，篡改或过期后失效
+	link := s.DownloadUrl(&job)
+	u, err := url.Parse(link)
+	if err != nil || link == "" {
+		t.Fatalf("download url %q: %v", link, err)
+	}
+	token := u.Query().Get("token")
+	if got, err := s.ResolveDownload(token); err != nil || got.ID != job.ID {
+		t.Fatalf("resolve download: %v", err)
+	}
+	if _, err = s.ResolveDownload(strings.Replace(token, fmt.Sprintf("%d.", job.ID), fmt.Sprintf("%d.", job.ID+1), 1)); err == nil {
+		t.Fatal("tampered token must be rejected")
+	}
+	// 签名不使用 JWT 密钥，更换下载签名密钥后旧链接失效
+	global.GVA_CONFIG.Excel.DownloadSigningKey = "rotated-download-key"
+	if _, err = s.ResolveDownload(token); err == nil {
+		t.Fatal("token signed with the old key must be rejected")
+	}
+	global.GVA_CONFIG.Excel.DownloadSigningKey = "test-download-key"
+
+	past := time.Now().Add(-time.Minute)
+	global.GVA_DB.Model(&job).Update("expires_at", past)
+	if err = s.PruneExpired(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = upload.OpenPrivate(job.FileKey); !errors.Is(err, upload.ErrPrivateFileNotFound) {
+		t.Fatal("expired export file should be deleted")
+	}
+	if _, err = s.ResolveDownload(token); err == nil {
+		t.Fatal("expired link must be rejected")
+	}
+	global.GVA_DB.First(&job, job.ID)
+	if job.Status != system.ExportJobExpired || s.DownloadUrl(&job) != "" {
+		t.Fatalf("job status = %s", job.Status)
+	}
+}
@@ -0,0 +1,456 @@
+package system
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	emailGlobal "github.com/flipped-aurora/gin-vue-admin/server/plugin/email/global"
+	emailUtils "github.com/flipped-aurora/gin-vue-admin/server/plugin/email/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/upload"
+)
+
+const (
+	// exportScheduleCron 定时报表在 GVA_Timer 中的 cron 名称，每个计划是其中一个任务
+	exportScheduleCron = "ExportSchedule"
+	// exportScheduleMinInterval 两次触发的最小间隔，多实例按触发时间去重依赖该间隔
+	exportScheduleMinInterval = time.Minute
+	// exportAttachmentLimit 邮件附件大小上限，超过时需改用对象存储投递
+	exportAttachmentLimit = 10 << 20
+)
+
+// exportScheduleParser 与 GVA_Timer 使用 cron.WithSeconds 时的解析规则一致
+var exportScheduleParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// exportScheduleMail 发送定时报表与失败告警邮件
+var exportScheduleMail = func(to, subject, body string, attachments ...emailUtils.Attachment) error {
+	if emailGlobal.GlobalConfig.Host == "" {
+		return errors.New("未配置邮件服务")
+	}
+	return emailUtils.EmailWithAttachments(to, subject, body, attachments...)
+}
+
+// exportScheduleTasks 本实例已注册到定时器的计划及其 cron 表达式
+var exportScheduleTasks = struct {
+	sync.Mutex
+	specs map[uint]string
+}{specs: make(map[uint]string)}
+
+type ExportScheduleService struct{}
+
+var ExportScheduleServiceApp = new(ExportScheduleService)
+
+// CreateExportSchedule 创建定时报表计划并注册到定时器
+func (exportScheduleService *ExportScheduleService) CreateExportSchedule(schedule *system.SysExportSchedule) error {
+	if err := exportScheduleService.validate(schedule); err != nil {
+		return err
+	}
+	if err := global.GVA_DB.Create(schedule).Error; err != nil {
+		return err
+	}
+	exportScheduleService.register(schedule)
+	return nil
+}
+
+// UpdateExportSchedule 更新定时报表计划，cron 表达式或启用状态变化时重新注册
+func (exportScheduleService *ExportScheduleService) UpdateExportSchedule(schedule *system.SysExportSchedule) error {
+	if err := exportScheduleService.validate(schedule); err != nil {
+		return err
+	}
+	err := global.GVA_DB.Model(&system.SysExportSchedule{}).Where("id = ?", schedule.ID).
+		Select("name", "template_id", "spec", "params", "format", "delivery", "recipients", "alert_to", "enabled").
+		Updates(schedule).Error
+	if err != nil {
+		return err
+	}
+	if err = global.GVA_DB.First(schedule, schedule.ID).Error; err != nil {
+		return err
+	}
+	exportScheduleService.register(schedule)
+	return nil
+}
+
+// DeleteExportSchedule 删除定时报表计划，执行记录保留
+func (exportScheduleService *ExportScheduleService) DeleteExportSchedule(id uint) error {
+	if err := global.GVA_DB.Delete(&system.SysExportSchedule{}, id).Error; err != nil {
+		return err
+	}
+	exportScheduleService.unregister(id)
+	return nil
+}
+
+// GetExportSchedule 根据id获取定时报表计划
+func (exportScheduleService *ExportScheduleService) GetExportSchedule(id uint) (schedule system.SysExportSchedule, err error) {
+	err = global.GVA_DB.First(&schedule, id).Error
+	return
+}
+
+// GetExportScheduleList 分页获取定时报表计划
+func (exportScheduleService *ExportScheduleService) GetExportScheduleList(info systemReq.SysExportScheduleSearch) (list []system.SysExportSchedule, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&system.SysExportSchedule{})
+	if info.TemplateID != "" {
+		db = db.Where("template_id = ?", info.TemplateID)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id desc").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// GetExportScheduleRunList 分页获取计划的执行记录
+func (exportScheduleService *ExportScheduleService) GetExportScheduleRunList(info systemReq.SysExportScheduleRunSearch) (list []system.SysExportScheduleRun, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&system.SysExportScheduleRun{}).Where("schedule_id = ?", info.ScheduleID)
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id desc").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// GetExportScheduleRun 获取执行记录，用于下载保存到对象存储的文件
+func (exportScheduleService *ExportScheduleService) GetExportScheduleRun(id uint) (run system.SysExportScheduleRun, err error) {
+	err = global.GVA_DB.First(&run, id).Error
+	return
+}
+
+// RunExportSchedule 立即执行一次计划，结果写入执行记录
+func (exportScheduleService *ExportScheduleService) RunExportSchedule(id uint) (run system.SysExportScheduleRun, err error) {
+	schedule, err := exportScheduleService.GetExportSchedule(id)
+	if err != nil {
+		return run, err
+	}
+	run = system.SysExportScheduleRun{ScheduleID: id, FireAt: time.Now(), Trigger: system.ExportTriggerManual, Status: system.ExportJobRunning}
+	if err = global.GVA_DB.Create(&run).Error; err != nil {
+		return run, err
+	}
+	running := run
+	go exportScheduleService.execute(&schedule, &running)
+	return run, nil
+}
+
+// SyncExportSchedules 按数据库中的计划注册或移除本实例的定时任务
+// 启动时调用一次，之后定期调用，使其他实例上的修改在本实例生效
+func (exportScheduleService *ExportScheduleService) SyncExportSchedules() error {
+	if global.GVA_DB == nil {
+		return nil
+	}
+	var schedules []system.SysExportSchedule
+	if err := global.GVA_DB.Where("enabled = ?", true).Find(&schedules).Error; err != nil {
+		return err
+	}
+	enabled := make(map[uint]bool, len(schedules))
+	for i := range schedules {
+		enabled[schedules[i].ID] = true
+		exportScheduleTasks.Lock()
+		spec, ok := exportScheduleTasks.specs[schedules[i].ID]
+		exportScheduleTasks.Unlock()
+		if !ok || spec != schedules[i].Spec {
+			exportScheduleService.register(&schedules[i])
+		}
+	}
+	exportScheduleTasks.Lock()
+	var stale []uint
+	for id := range exportScheduleTasks.specs {
+		if !enabled[id] {
+			stale = append(stale, id)
+		}
+	}
+	exportScheduleTasks.Unlock()
+	for _, id := range stale {
+		exportScheduleService.unregister(id)
+	}
+	return nil
+}
+
+func (exportScheduleService *ExportScheduleService) validate(schedule *system.SysExportSchedule) (err error) {
+	schedule.Spec = strings.TrimSpace(schedule.Spec)
+	if _, err = parseExportScheduleSpec(schedule.Spec); err != nil {
+		return err
+	}
+	if schedule.Format, err = NormalizeExportFormat(schedule.Format); err != nil {
+		return err
+	}
+	switch schedule.Delivery {
+	case "":
+		schedule.Delivery = system.ExportDeliveryEmail
+	case system.ExportDeliveryEmail, system.ExportDeliveryOss:
+	default:
+		return fmt.Errorf("不支持的投递方式 %s", schedule.Delivery)
+	}
+	if schedule.Recipients, err = normalizeEmailList(schedule.Recipients); err != nil {
+		return err
+	}
+	if schedule.Delivery == system.ExportDeliveryEmail && schedule.Recipients == "" {
+		return errors.New("邮件投递需要填写收件人")
+	}
+	if schedule.AlertTo, err = normalizeEmailList(schedule.AlertTo); err != nil {
+		return err
+	}
+	query, err := SysExportTemplateServiceApp.PrepareExport(schedule.TemplateID, url.Values{"params": {schedule.Params}})
+	if err != nil {
+		return err
+	}
+	if schedule.Name = strings.TrimSpace(schedule.Name); schedule.Name == "" {
+		schedule.Name = query.Name
+	}
+	if schedule.Enabled == nil {
+		enabled := true
+		schedule.Enabled = &enabled
+	}
+	return nil
+}
+
+// parseExportScheduleSpec 解析 cron 表达式并限制最小触发间隔
+func parseExportScheduleSpec(spec string) (cron.Schedule, error) {
+	schedule, err := exportScheduleParser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("cron表达式 %s 不合法: %w", spec, err)
+	}
+	next := schedule.Next(time.Now())
+	for i := 0; i < 5; i++ {
+		after := schedule.Next(next)
+		if after.Sub(next) < exportScheduleMinInterval {
+			return nil, fmt.Errorf("cron表达式 %s 触发过于频繁，间隔不能小于 %s", spec, exportScheduleMinInterval)
+		}
+		next = after
+	}
+	return schedule, nil
+}
+
+// normalizeEmailList 校验以逗号分隔的邮箱列表并去除空白
+func normalizeEmailList(list string) (string, error) {
+	var addresses []string
+	for _, item := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ';' }) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		addr, err := mail.ParseAddress(item)
+		if err != nil {
+			return "", fmt.Errorf("邮箱 %s 格式错误", item)
+		}
+		addresses = append(addresses, addr.Address)
+	}
+	return strings.Join(addresses, ","), nil
+}
+
+func exportScheduleTaskName(id uint) string {
+	return "export_schedule_" + strconv.FormatUint(uint64(id), 10)
+}
+
+func (exportScheduleService *ExportScheduleService) register(schedule *system.SysExportSchedule) {
+	exportScheduleService.unregister(schedule.ID)
+	if schedule.Enabled == nil || !*schedule.Enabled {
+		return
+	}
+	id := schedule.ID
+	_, err := global.GVA_Timer.AddTaskByFunc(exportScheduleCron, schedule.Spec, func() {
+		exportScheduleService.fire(id)
+	}, exportScheduleTaskName(id), cron.WithSeconds())
+	if err != nil {
+		global.GVA_LOG.Error("注册定时报表失败", zap.Uint("id", id), zap.Error(err))
+		return
+	}
+	exportScheduleTasks.Lock()
+	exportScheduleTasks.specs[id] = schedule.Spec
+	exportScheduleTasks.Unlock()
+}
+
+func (exportScheduleService *ExportScheduleService) unregister(id uint) {
+	exportScheduleTasks.Lock()
+	defer exportScheduleTasks.Unlock()
+	if _, ok := exportScheduleTasks.specs[id]; !ok {
+		return
+	}
+	global.GVA_Timer.RemoveTaskByName(exportScheduleCron, exportScheduleTaskName(id))
+	delete(exportScheduleTasks.specs, id)
+}
+
+// fire 定时器触发时调用，多个实例同时触发时只有写入执行记录成功的实例会执行
+func (exportScheduleService *ExportScheduleService) fire(id uint) {
+	var schedule system.SysExportSchedule
+	if err := global.GVA_DB.First(&schedule, id).Error; err != nil || schedule.Enabled == nil || !*schedule.Enabled {
+		exportScheduleService.unregister(id)
+		return
+	}
+	spec, err := parseExportScheduleSpec(schedule.Spec)
+	if err != nil {
+		return
+	}
+	// 以计划的触发时间作为去重键，不受各实例时钟的细微偏差影响
+	now := time.Now()
+	fireAt := spec.Next(now.Add(-exportScheduleMinInterval / 2))
+	if d := fireAt.Sub(now); d > exportScheduleMinInterval/2 || d < -exportScheduleMinInterval/2 {
+		// 计划已在其他实例上修改，按新的表达式重新注册
+		exportScheduleService.register(&schedule)
+		return
+	}
+	run := system.SysExportScheduleRun{ScheduleID: id, FireAt: fireAt, Trigger: system.ExportTriggerCron, Status: system.ExportJobRunning}
+	if err = global.GVA_DB.Create(&run).Error; err != nil {
+		// 其他实例已执行本次触发
+		return
+	}
+	exportScheduleService.execute(&schedule, &run)
+}
+
+func (exportScheduleService *ExportScheduleService) execute(schedule *system.SysExportSchedule, run *system.SysExportScheduleRun) {
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("导出异常: %v", r)
+			}
+		}()
+		return exportScheduleService.deliver(schedule, run)
+	}()
+	now := time.Now()
+	run.Status, run.FinishedAt = system.ExportJobSuccess, &now
+	if err != nil {
+		global.GVA_LOG.Error("定时报表执行失败", zap.Uint("schedule", schedule.ID), zap.Error(err))
+		msg := err.Error()
+		if r := []rune(msg); len(r) > 160 {
+			msg = string(r[:160])
+		}
+		run.Status, run.Error = system.ExportJobFailed, msg
+		exportScheduleService.alert(schedule, run)
+	}
+	global.GVA_DB.Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"rows":        run.Rows,
+		"size":        run.Size,
+		"file_name":   run.FileName,
+		"file_key":    run.FileKey,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+	})
+	global.GVA_DB.Model(&system.SysExportSchedule{}).Where("id = ?", schedule.ID).
+		Updates(map[string]interface{}{"last_run_at": run.FireAt, "last_status": run.Status})
+}
+
+// deliver 生成报表文件后按投递方式发送邮件附件或以私有对象上传到配置的对象存储
+func (exportScheduleService *ExportScheduleService) deliver(schedule *system.SysExportSchedule, run *system.SysExportScheduleRun) error {
+	query, err := SysExportTemplateServiceApp.PrepareExport(schedule.TemplateID, url.Values{"params": {schedule.Params}})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "gva-schedule-*."+schedule.Format)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if run.Rows, err = query.Write(tmp, schedule.Format, nil); err != nil {
+		return err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+	run.Size = info.Size()
+	run.FileName = fmt.Sprintf("%s_%s.%s", query.Name, run.FireAt.Format("20060102_1504"), schedule.Format)
+	subject := fmt.Sprintf("%s %s", schedule.Name, run.FireAt.Format("2006-01-02"))
+
+	if schedule.Delivery == system.ExportDeliveryOss {
+		if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if run.FileKey, err = upload.SavePrivate("schedule", "."+schedule.Format, tmp, run.Size); err != nil {
+			return fmt.Errorf("保存报表文件失败: %w", err)
+		}
+		if schedule.Recipients == "" {
+			return nil
+		}
+		body := fmt.Sprintf("<p>%s 已生成，共 %d 行，可在定时报表的执行记录中下载。</p>", html.EscapeString(schedule.Name), run.Rows)
+		if baseUrl := strings.TrimSuffix(global.GVA_CONFIG.Excel.PublicUrl, "/"); baseUrl != "" {
+			expiresAt := time.Now().Add(exportLinkExpire())
+			if link, err := exportScheduleService.DownloadUrl(run, expiresAt); err == nil {
+				link = baseUrl + global.GVA_CONFIG.System.RouterPrefix + link
+				body += fmt.Sprintf(`<p><a href="%s">点击下载</a>，链接将于 %s 失效。</p>`, html.EscapeString(link), expiresAt.Format("2006-01-02 15:04:05"))
+			}
+		}
+		if err = exportScheduleMail(schedule.Recipients, subject, body); err != nil {
+			return fmt.Errorf("发送通知邮件失败: %w", err)
+		}
+		return nil
+	}
+
+	if run.Size > exportAttachmentLimit {
+		return fmt.Errorf("报表大小 %d 字节超过邮件附件上限，请改用对象存储投递", run.Size)
+	}
+	if _, err = tmp.Seek(0, 0); err != nil {
+		return err
+	}
+	body := fmt.Sprintf("<p>%s 共 %d 行，详见附件。</p>", html.EscapeString(schedule.Name), run.Rows)
+	attachment := emailUtils.Attachment{Name: run.FileName, ContentType: ExportContentType(schedule.Format), Content: tmp}
+	if err = exportScheduleMail(schedule.Recipients, subject, body, attachment); err != nil {
+		return fmt.Errorf("发送报表邮件失败: %w", err)
+	}
+	return nil
+}
+
+// DownloadUrl 执行记录文件的签名下载地址，用于通知邮件中免登录下载
+func (exportScheduleService *ExportScheduleService) DownloadUrl(run *system.SysExportScheduleRun, expiresAt time.Time) (string, error) {
+	payload := fmt.Sprintf("%d.%d", run.ID, expiresAt.Unix())
+	signature, err := exportDownloadSignature("schedule." + payload)
+	if err != nil {
+		return "", err
+	}
+	return "/sysExportTemplate/downloadExportScheduleFile?token=" + url.QueryEscape(payload+"."+signature), nil
+}
+
+// ResolveDownload 校验下载令牌并返回对应的执行记录，签名内容与导出任务区分，令牌不能互用
+func (exportScheduleService *ExportScheduleService) ResolveDownload(token string) (run system.SysExportScheduleRun, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return run, ErrExportLinkExpired
+	}
+	signature, err := exportDownloadSignature("schedule." + parts[0] + "." + parts[1])
+	if err != nil || !hmac.Equal([]byte(parts[2]), []byte(signature)) {
+		return run, ErrExportLinkExpired
+	}
+	id, err1 := strconv.ParseUint(parts[0], 10, 64)
+	exp, err2 := strconv.ParseInt(parts[1], 10, 64)
+	if err1 != nil || err2 != nil || time.Now().Unix() > exp {
+		return run, ErrExportLinkExpired
+	}
+	if err = global.GVA_DB.First(&run, id).Error; err != nil || run.FileKey == "" {
+		return run, ErrExportLinkExpired
+	}
+	return run, nil
+}
+
+// alert 执行失败时向告警收件人发送邮件，未填写时使用邮件插件配置的告警邮箱
+func (exportScheduleService *ExportScheduleService) alert(schedule *system.SysExportSchedule, run *system.SysExportScheduleRun) {
+	to := schedule.AlertTo
+	if to == "" {
+		to = strings.Trim(emailGlobal.GlobalConfig.To, ",")
+	}
+	if to == "" {
+		return
+	}
+	subject := fmt.Sprintf("定时报表执行失败：%s", schedule.Name)
+	body := fmt.Sprintf("<p>%s 在 %s 的执行失败：%s</p>", html.EscapeString(schedule.Name),
+		run.FireAt.Format("2006-01-02 15:04:05"), html.EscapeString(run.Error))
+	if err := exportScheduleMail(to, subject, body); err != nil {
+		global.GVA_LOG.Warn("发送定时报表告警失败", zap.Uint("schedule", schedule.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,185 @@
+package system
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	emailUtils "github.com/flipped-aurora/gin-vue-admin/server/plugin/email/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/upload"
+)
+
+type sentMail struct {
+	to, subject, attachment, content string
+}
+
+func setupExportSchedule(t *testing.T, failAttachments bool) *[]sentMail {
+	t.Helper()
+	setupExport(t, 3)
+	if err := global.GVA_DB.AutoMigrate(&system.SysExportSchedule{}, &system.SysExportScheduleRun{}); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	sent := &[]sentMail{}
+	original := exportScheduleMail
+	exportScheduleMail = func(to, subject, body string, attachments ...emailUtils.Attachment) error {
+		mu.Lock()
+		defer mu.Unlock()
+		m := sentMail{to: to, subject: subject}
+		if len(attachments) > 0 {
+			if failAttachments {
+				return errors.New("smtp unavailable")
+			}
+			content, _ := io.ReadAll(attachments[0].Content)
+			m.attachment, m.content = attachments[0].Name, string(content)
+		}
+		*sent = append(*sent, m)
+		return nil
+	}
+	t.Cleanup(func() { exportScheduleMail = original })
+	return sent
+}
+
+func TestExportScheduleService_Validate(t *testing.T) {
+	setupExportSchedule(t, false)
+	s := &ExportScheduleService{}
+	cases := map[string]system.SysExportSchedule{
+		"bad spec":       {TemplateID: "rows", Spec: "every morning", Recipients: "a@example.com"},
+		"too frequent":   {TemplateID: "rows", Spec: "*/10 * * * * *", Recipients: "a@example.com"},
+		"bad recipient":  {TemplateID: "rows", Spec: "0 0 8 * * *", Recipients: "finance"},
+		"no recipient":   {TemplateID: "rows", Spec: "0 0 8 * * *"},
+		"bad delivery":   {TemplateID: "rows", Spec: "0 0 8 * * *", Recipients: "a@example.com", Delivery: "ftp"},
+		"bad template":   {TemplateID: "missing", Spec: "0 0 8 * * *", Recipients: "a@example.com"},
+		"bad parameters": {TemplateID: "rows", Spec: "0 0 8 * * *", Recipients: "a@example.com", Params: "order=id;drop"},
+	}
+	for name, schedule := range cases {
+		if err := s.CreateExportSchedule(&schedule); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	schedule := system.SysExportSchedule{TemplateID: "rows", Spec: "0 0 8 * * *", Recipients: " a@example.com ; Finance <b@example.com>", Format: "csv"}
+	if err := s.CreateExportSchedule(&schedule); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.unregister(schedule.ID) })
+	if schedule.Recipients != "a@example.com,b@example.com" || schedule.Name != "订单" || schedule.Delivery != system.ExportDeliveryEmail {
+		t.Fatalf("schedule = %+v", schedule)
+	}
+	if _, ok := global.GVA_Timer.FindTask(exportScheduleCron, exportScheduleTaskName(schedule.ID)); !ok {
+		t.Fatal("schedule should be registered")
+	}
+	disabled := false
+	schedule.Enabled = &disabled
+	if err := s.UpdateExportSchedule(&schedule); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := global.GVA_Timer.FindTask(exportScheduleCron, exportScheduleTaskName(schedule.ID)); ok {
+		t.Fatal("disabled schedule should be removed from timer")
+	}
+}
+
+func TestExportScheduleService_FireEmailsOncePerTrigger(t *testing.T) {
+	sent := setupExportSchedule(t, false)
+	s := &ExportScheduleService{}
+	enabled := true
+	schedule := system.SysExportSchedule{Name: "每日订单", TemplateID: "rows", Spec: "0 * * * * *", Params: "name=row-2",
+		Format: "csv", Delivery: system.ExportDeliveryEmail, Recipients: "finance@example.com", Enabled: &enabled}
+	global.GVA_DB.Create(&schedule)
+
+	// 模拟两个实例同时触发
+	s.fire(schedule.ID)
+	s.fire(schedule.ID)
+
+	var runs []system.SysExportScheduleRun
+	global.GVA_DB.Find(&runs, "schedule_id = ?", schedule.ID)
+	if len(runs) != 1 || runs[0].Status != system.ExportJobSuccess || runs[0].Rows != 1 {
+		t.Fatalf("runs = %+v", runs)
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d mails, want 1", len(*sent))
+	}
+	m := (*sent)[0]
+	if m.to != "finance@example.com" || m.content != "\xEF\xBB\xBF编号,名称,编码,金额\n2,row-2,000002,0.5\n" || filepath.Ext(m.attachment) != ".csv" {
+		t.Fatalf("mail = %+v", m)
+	}
+	global.GVA_DB.First(&schedule, schedule.ID)
+	if schedule.LastStatus != system.ExportJobSuccess || schedule.LastRunAt == nil {
+		t.Fatalf("schedule = %+v", schedule)
+	}
+}
+
+func TestExportScheduleService_FailureAlertAndOss(t *testing.T) {
+	sent := setupExportSchedule(t, true)
+	s := &ExportScheduleService{}
+	enabled := true
+	schedule := system.SysExportSchedule{Name: "每日订单", TemplateID: "rows", Spec: "0 0 8 * * *", Format: "xlsx",
+		Delivery: system.ExportDeliveryEmail, Recipients: "finance@example.com", AlertTo: "ops@example.com", Enabled: &enabled}
+	global.GVA_DB.Create(&schedule)
+
+	run := waitExportScheduleRun(t, s, schedule.ID)
+	if run.Status != system.ExportJobFailed || run.Error == "" {
+		t.Fatalf("run = %+v", run)
+	}
+	if len(*sent) != 1 || (*sent)[0].to != "ops@example.com" {
+		t.Fatalf("alert mails = %+v", *sent)
+	}
+
+	global.GVA_DB.Model(&schedule).Updates(map[string]interface{}{"delivery": system.ExportDeliveryOss, "recipients": ""})
+	run = waitExportScheduleRun(t, s, schedule.ID)
+	if run.Status != system.ExportJobSuccess || run.FileKey == "" || run.Rows != 3 {
+		t.Fatalf("run = %+v", run)
+	}
+	// 报表以私有对象保存，公开的上传目录中没有文件
+	body, err := upload.OpenPrivate(run.FileKey)
+	if err != nil {
+		t.Fatalf("stored report: %v", err)
+	}
+	body.Close()
+	if _, err := os.Stat(filepath.Join(global.GVA_CONFIG.Local.StorePath, run.FileKey)); err == nil {
+		t.Fatal("report must not be stored in the public upload path")
+	}
+
+	link, err := s.DownloadUrl(&run, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := url.QueryUnescape(strings.TrimPrefix(link, "/sysExportTemplate/downloadExportScheduleFile?token="))
+	if got, err := s.ResolveDownload(token); err != nil || got.ID != run.ID {
+		t.Fatalf("resolve = %+v, %v", got, err)
+	}
+	// 定时报表的令牌不能用于下载导出任务，过期令牌被拒绝
+	if _, err = ExportJobServiceApp.ResolveDownload(token); err == nil {
+		t.Fatal("schedule token must not resolve an export job")
+	}
+	expired, _ := s.DownloadUrl(&run, time.Now().Add(-time.Minute))
+	token, _ = url.QueryUnescape(strings.TrimPrefix(expired, "/sysExportTemplate/downloadExportScheduleFile?token="))
+	if _, err = s.ResolveDownload(token); err == nil {
+		t.Fatal("expired token must be rejected")
+	}
+}
+
+func waitExportScheduleRun(t *testing.T, s *ExportScheduleService, id uint) system.SysExportScheduleRun {
+	t.Helper()
+	run, err := s.RunExportSchedule(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for run.Status == system.ExportJobRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("run not finished")
+		}
+		time.Sleep(20 * time.Millisecond)
+		global.GVA_DB.First(&run, run.ID)
+	}
+	return run
+}
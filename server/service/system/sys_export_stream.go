@@ -0,0 +1,239 @@
+package system
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// ErrExportTooManyRows 超出 xlsx 单个工作表的行数上限
+var ErrExportTooManyRows = errors.New("导出行数超出Excel上限，请缩小范围或改用CSV格式导出")
+
+// ExportContentType 导出格式对应的 Content-Type
+func ExportContentType(format string) string {
+	switch format {
+	case system.ExportFormatCsv:
+		return "text/csv; charset=utf-8"
+	case system.ExportFormatJsonl:
+		return "application/x-ndjson; charset=utf-8"
+	}
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// NormalizeExportFormat 校验导出格式，为空时默认 xlsx
+func NormalizeExportFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return system.ExportFormatXlsx, nil
+	case system.ExportFormatXlsx, system.ExportFormatCsv, system.ExportFormatJsonl:
+		return format, nil
+	}
+	return "", fmt.Errorf("不支持的导出格式 %s", format)
+}
+
+// Count 统计导出行数，用于展示进度；部分数据库不支持带排序的子查询，失败时返回 -1
+func (q *ExportQuery) Count() int64 {
+	var total int64
+	if err := q.base.Table("(?) AS export_count", q.db.Session(&gorm.Session{})).Count(&total).Error; err != nil {
+		return -1
+	}
+	return total
+}
+
+// Write 逐行读取查询结果写入 w，progress 不为空时每写入一行回调一次已写入的行数
+func (q *ExportQuery) Write(w io.Writer, format string, progress func(rows int64)) (int64, error) {
+	var ew exportWriter
+	switch format {
+	case system.ExportFormatCsv:
+		ew = newCsvExportWriter(w)
+	case system.ExportFormatJsonl:
+		ew = &jsonlExportWriter{w: bufio.NewWriter(w), keys: q.Keys}
+	default:
+		f := excelize.NewFile()
+		defer f.Close()
+		ew = &xlsxExportWriter{w: w, file: f}
+	}
+	if err := ew.WriteHeader(q.Titles); err != nil {
+		return 0, err
+	}
+
+	rows, err := q.db.Session(&gorm.Session{}).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var count int64
+	values := make([]interface{}, len(q.Keys))
+	for rows.Next() {
+		record := make(map[string]interface{}, len(q.Keys))
+		if err = q.base.ScanRows(rows, &record); err != nil {
+			return count, err
+		}
+		for i, key := range q.Keys {
+			values[i] = record[key]
+		}
+		if err = ew.WriteRow(values); err != nil {
+			return count, err
+		}
+		count++
+		if progress != nil {
+			progress(count)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return count, err
+	}
+	return count, ew.Close()
+}
+
+type exportWriter interface {
+	WriteHeader(titles []string) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// exportCellString 单元格的文本形式，时间统一格式化，空值输出空串
+func exportCellString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05")
+	case []byte:
+		return string(val)
+	case string:
+		return val
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// xlsxExportWriter 使用 StreamWriter 按行写入，超出内存缓冲的数据由 excelize 暂存在磁盘
+type xlsxExportWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (x *xlsxExportWriter) WriteHeader(titles []string) (err error) {
+	if x.stream, err = x.file.NewStreamWriter("Sheet1"); err != nil {
+		return err
+	}
+	header := make([]interface{}, len(titles))
+	for i, t := range titles {
+		header[i] = t
+	}
+	return x.setRow(header)
+}
+
+func (x *xlsxExportWriter) WriteRow(values []interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		cells[i] = xlsxCellValue(v)
+	}
+	return x.setRow(cells)
+}
+
+func (x *xlsxExportWriter) setRow(cells []interface{}) error {
+	if x.row >= excelize.TotalRows {
+		return ErrExportTooManyRows
+	}
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxExportWriter) Close() error {
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
+
+// xlsxCellValue 数值保持数值类型；文本中的数字仅在能无损还原时转换，避免丢失前导零或长编号的精度
+func xlsxCellValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return val
+	}
+	s := exportCellString(v)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s && len(s) <= 15 {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == s && len(s) <= 15 {
+		return f
+	}
+	return s
+}
+
+type csvExportWriter struct {
+	w   *csv.Writer
+	row []string
+}
+
+func newCsvExportWriter(w io.Writer) *csvExportWriter {
+	// 写入 BOM，Excel 打开时才能正确识别 UTF-8 中文
+	_, _ = io.WriteString(w, "\xEF\xBB\xBF")
+	return &csvExportWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvExportWriter) WriteHeader(titles []string) error {
+	return c.w.Write(titles)
+}
+
+func (c *csvExportWriter) WriteRow(values []interface{}) error {
+	c.row = c.row[:0]
+	for _, v := range values {
+		c.row = append(c.row, exportCellString(v))
+	}
+	return c.w.Write(c.row)
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlExportWriter 每行一个 JSON 对象，以结果集字段名为键，不输出表头
+type jsonlExportWriter struct {
+	w    *bufio.Writer
+	keys []string
+}
+
+func (j *jsonlExportWriter) WriteHeader([]string) error {
+	return nil
+}
+
+func (j *jsonlExportWriter) WriteRow(values []interface{}) error {
+	record := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		record[j.keys[i]] = v
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = j.w.Write(line); err != nil {
+		return err
+	}
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlExportWriter) Close() error {
+	return j.w.Flush()
+}
@@ -3,13 +3,9 @@ package system
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"mime/multipart"
 	"net/url"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
@@ -18,6 +14,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/xuri/excelize/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SysExportTemplateService struct {
@@ -28,6 +25,9 @@ var SysExportTemplateServiceApp = new(SysExportTemplateService)
 // CreateSysExportTemplate 创建导出模板记录
 // Author [piexlmax](https://github.com/piexlmax)
 func (sysExportTemplateService *SysExportTemplateService) CreateSysExportTemplate(sysExportTemplate *system.SysExportTemplate) (err error) {
+	if err = sysExportTemplateService.ValidateExportTemplate(*sysExportTemplate); err != nil {
+		return err
+	}
 	err = global.GVA_DB.Create(sysExportTemplate).Error
 	return err
 }
@@ -49,6 +49,9 @@ func (sysExportTemplateService *SysExportTemplateService) DeleteSysExportTemplat
 // UpdateSysExportTemplate 更新导出模板记录
 // Author [piexlmax](https://github.com/piexlmax)
 func (sysExportTemplateService *SysExportTemplateService) UpdateSysExportTemplate(sysExportTemplate system.SysExportTemplate) (err error) {
+	if err = sysExportTemplateService.ValidateExportTemplate(sysExportTemplate); err != nil {
+		return err
+	}
 	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
 		conditions := sysExportTemplate.Conditions
 		e := tx.Delete(&[]system.Condition{}, "template_id = ?", sysExportTemplate.TemplateID).Error
@@ -125,100 +128,59 @@ func (sysExportTemplateService *SysExportTemplateService) GetSysExportTemplateIn
 	return sysExportTemplates, total, err
 }
 
-// ExportExcel 导出Excel
+// ExportQuery 已完成校验的导出查询，由 Write 按行流式读取并写出
+type ExportQuery struct {
+	Name   string   // 模板名称，用作导出文件名
+	Titles []string // 表头
+	Keys   []string // 表头对应的结果集字段
+	db     *gorm.DB
+	base   *gorm.DB // 未附加查询条件的连接，用于统计与扫描
+}
+
+// PrepareExport 根据模板与请求参数构建导出查询，参数不合法时返回错误
 // Author [piexlmax](https://github.com/piexlmax)
-func (sysExportTemplateService *SysExportTemplateService) ExportExcel(templateID string, values url.Values) (file *bytes.Buffer, name string, err error) {
+func (sysExportTemplateService *SysExportTemplateService) PrepareExport(templateID string, values url.Values) (*ExportQuery, error) {
 	var params = values.Get("params")
 	paramsValues, err := url.ParseQuery(params)
 	if err != nil {
-		return nil, "", fmt.Errorf("解析 params 参数失败: %v", err)
+		return nil, fmt.Errorf("解析 params 参数失败: %v", err)
 	}
 	var template system.SysExportTemplate
 	err = global.GVA_DB.Preload("Conditions").Preload("JoinTemplate").First(&template, "template_id = ?", templateID).Error
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
-	f := excelize.NewFile()
-	defer func() {
-		if err := f.Close(); err != nil {
-			fmt.Println(err)
-		}
-	}()
-	// Create a new sheet.
-	index, err := f.NewSheet("Sheet1")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	var templateInfoMap = make(map[string]string)
-	columns, err := utils.GetJSONKeys(template.TemplateInfo)
-	if err != nil {
-		return nil, "", err
-	}
-	err = json.Unmarshal([]byte(template.TemplateInfo), &templateInfoMap)
-	if err != nil {
-		return nil, "", err
-	}
-	var tableTitle []string
-	var selectKeyFmt []string
-	for _, key := range columns {
-		selectKeyFmt = append(selectKeyFmt, key)
-		tableTitle = append(tableTitle, templateInfoMap[key])
-	}
-
-	selects := strings.Join(selectKeyFmt, ", ")
-	var tableMap []map[string]interface{}
 	db := global.GVA_DB
 	if template.DBName != "" {
 		db = global.MustGetGlobalDBByDBName(template.DBName)
 	}
-
-	if len(template.JoinTemplate) > 0 {
-		for _, join := range template.JoinTemplate {
-			db = db.Joins(join.JOINS + " " + join.Table + " ON " + join.ON)
-		}
+	plan, err := compileExportTemplate(db, template)
+	if err != nil {
+		return nil, err
 	}
-
-	db = db.Select(selects).Table(template.TableName)
-
-	filterDeleted := false
-
-	filterParam := paramsValues.Get("filterDeleted")
-	if filterParam == "true" {
-		filterDeleted = true
+	query := &ExportQuery{Name: template.Name, Titles: plan.Titles, base: db}
+	for _, s := range plan.Selects {
+		query.Keys = append(query.Keys, s.Key())
 	}
 
-	if filterDeleted {
-		// 自动过滤主表的软删除
-		db = db.Where(fmt.Sprintf("%s.deleted_at IS NULL", template.TableName))
+	db = plan.apply(db)
 
-		// 过滤关联表的软删除(如果有)
-		if len(template.JoinTemplate) > 0 {
-			for _, join := range template.JoinTemplate {
-				// 检查关联表是否有deleted_at字段
-				hasDeletedAt := sysExportTemplateService.hasDeletedAtColumn(join.Table)
-				if hasDeletedAt {
-					db = db.Where(fmt.Sprintf("%s.deleted_at IS NULL", join.Table))
-				}
+	if paramsValues.Get("filterDeleted") == "true" {
+		// 过滤主表与关联表的软删除，仅处理存在 deleted_at 字段的表
+		tables := []string{template.TableName}
+		for _, join := range plan.Joins {
+			tables = append(tables, join.Table)
+		}
+		for _, table := range tables {
+			if plan.hasColumn(table, "deleted_at") {
+				db = db.Where(db.Statement.Quote(clause.Column{Table: table, Name: "deleted_at"}) + " IS NULL")
 			}
 		}
 	}
 
-	if len(template.Conditions) > 0 {
-		for _, condition := range template.Conditions {
-			sql := fmt.Sprintf("%s %s ?", condition.Column, condition.Operator)
-			value := paramsValues.Get(condition.From)
-
-			if condition.Operator == "IN" || condition.Operator == "NOT IN" {
-				sql = fmt.Sprintf("%s %s (?)", condition.Column, condition.Operator)
-			}
-
-			if value != "" {
-				if condition.Operator == "LIKE" {
-					value = "%" + value + "%"
-				}
-				db = db.Where(sql, value)
-			}
+	for _, condition := range plan.Conditions {
+		if value := paramsValues.Get(condition.From); value != "" {
+			db = condition.where(db, value)
 		}
 	}
 	// 通过参数传入limit
@@ -243,101 +205,20 @@ func (sysExportTemplateService *SysExportTemplateService) ExportExcel(templateID
 		}
 	}
 
-	// 获取当前表的所有字段
-	table := template.TableName
-	orderColumns, err := db.Migrator().ColumnTypes(table)
-	if err != nil {
-		return nil, "", err
-	}
-
-	// 创建一个 map 来存储字段名
-	fields := make(map[string]bool)
-
-	for _, column := range orderColumns {
-		fields[column.Name()] = true
-	}
-
-	// 通过参数传入order
+	// 通过参数传入order，没有入参时使用模板的默认排序
 	order := paramsValues.Get("order")
-
-	if order == "" && template.Order != "" {
-		// 如果没有order入参，这里会使用模板的默认排序
+	if order == "" {
 		order = template.Order
 	}
-
 	if order != "" {
-		checkOrderArr := strings.Split(order, " ")
-		orderStr := ""
-		// 检查请求的排序字段是否在字段列表中
-		if _, ok := fields[checkOrderArr[0]]; !ok {
-			return nil, "", fmt.Errorf("order by %s is not in the fields", order)
+		orderBy, err := plan.orderBy(order)
+		if err != nil {
+			return nil, err
 		}
-		orderStr = checkOrderArr[0]
-		if len(checkOrderArr) > 1 {
-			if checkOrderArr[1] != "asc" && checkOrderArr[1] != "desc" {
-				return nil, "", fmt.Errorf("order by %s is not secure", order)
-			}
-			orderStr = orderStr + " " + checkOrderArr[1]
-		}
-		db = db.Order(orderStr)
+		db = db.Order(orderBy)
 	}
-
-	err = db.Debug().Find(&tableMap).Error
-	if err != nil {
-		return nil, "", err
-	}
-	var rows [][]string
-	rows = append(rows, tableTitle)
-	for _, exTable := range tableMap {
-		var row []string
-		for _, column := range columns {
-			column = strings.ReplaceAll(column, "\"", "")
-			column = strings.ReplaceAll(column, "`", "")
-			if len(template.JoinTemplate) > 0 {
-				columnAs := strings.Split(column, " as ")
-				if len(columnAs) > 1 {
-					column = strings.TrimSpace(strings.Split(column, " as ")[1])
-				} else {
-					columnArr := strings.Split(column, ".")
-					if len(columnArr) > 1 {
-						column = strings.Split(column, ".")[1]
-					}
-				}
-			}
-			// 需要对时间类型特殊处理
-			if t, ok := exTable[column].(time.Time); ok {
-				row = append(row, t.Format("2006-01-02 15:04:05"))
-			} else {
-				row = append(row, fmt.Sprintf("%v", exTable[column]))
-			}
-		}
-		rows = append(rows, row)
-	}
-	for i, row := range rows {
-		for j, colCell := range row {
-			cell := fmt.Sprintf("%s%d", getColumnName(j+1), i+1)
-
- 			var sErr error
- 			if v, err := strconv.ParseFloat(colCell, 64); err == nil {
- 			    sErr = f.SetCellValue("Sheet1", cell, v)
- 			} else if v, err := strconv.ParseInt(colCell, 10, 64); err == nil {
- 			    sErr = f.SetCellValue("Sheet1", cell, v)
- 			} else {
- 			    sErr = f.SetCellValue("Sheet1", cell, colCell)
- 			}
-
-			if sErr != nil {
-				return nil, "", sErr
-			}
-		}
-	}
-	f.SetActiveSheet(index)
-	file, err = f.WriteToBuffer()
-	if err != nil {
-		return nil, "", err
-	}
-
-	return file, template.Name, nil
+	query.db = db
+	return query, nil
 }
 
 // ExportTemplate 导出Excel模板
@@ -388,91 +269,6 @@ func (sysExportTemplateService *SysExportTemplateService) ExportTemplate(templat
 	return file, template.Name, nil
 }
 
-// 辅助函数：检查表是否有deleted_at列
-func (s *SysExportTemplateService) hasDeletedAtColumn(tableName string) bool {
-	var count int64
-	global.GVA_DB.Raw("SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_NAME = ? AND COLUMN_NAME = 'deleted_at'", tableName).Count(&count)
-	return count > 0
-}
-
-// ImportExcel 导入Excel
-// Author [piexlmax](https://github.com/piexlmax)
-func (sysExportTemplateService *SysExportTemplateService) ImportExcel(templateID string, file *multipart.FileHeader) (err error) {
-	var template system.SysExportTemplate
-	err = global.GVA_DB.First(&template, "template_id = ?", templateID).Error
-	if err != nil {
-		return err
-	}
-
-	src, err := file.Open()
-	if err != nil {
-		return err
-	}
-	defer src.Close()
-
-	f, err := excelize.OpenReader(src)
-	if err != nil {
-		return err
-	}
-
-	rows, err := f.GetRows("Sheet1")
-	if err != nil {
-		return err
-	}
-	if len(rows) < 2 {
-		return errors.New("Excel data is not enough.\nIt should contain title row and data")
-	}
-
-	var templateInfoMap = make(map[string]string)
-	err = json.Unmarshal([]byte(template.TemplateInfo), &templateInfoMap)
-	if err != nil {
-		return err
-	}
-
-	var titleKeyMap = make(map[string]string)
-	for key, title := range templateInfoMap {
-		titleKeyMap[title] = key
-	}
-
-	db := global.GVA_DB
-	if template.DBName != "" {
-		db = global.MustGetGlobalDBByDBName(template.DBName)
-	}
-
-	return db.Transaction(func(tx *gorm.DB) error {
-		excelTitle := rows[0]
-		for i, str := range excelTitle {
-			excelTitle[i] = strings.TrimSpace(str)
-		}
-		values := rows[1:]
-		items := make([]map[string]interface{}, 0, len(values))
-		for _, row := range values {
-			var item = make(map[string]interface{})
-			for ii, value := range row {
-				if _, ok := titleKeyMap[excelTitle[ii]]; !ok {
-					continue // excel中多余的标题，在模板信息中没有对应的字段，因此key为空，必须跳过
-				}
-				key := titleKeyMap[excelTitle[ii]]
-				item[key] = value
-			}
-
-			needCreated := tx.Migrator().HasColumn(template.TableName, "created_at")
-			needUpdated := tx.Migrator().HasColumn(template.TableName, "updated_at")
-
-			if item["created_at"] == nil && needCreated {
-				item["created_at"] = time.Now()
-			}
-			if item["updated_at"] == nil && needUpdated {
-				item["updated_at"] = time.Now()
-			}
-
-			items = append(items, item)
-		}
-		cErr := tx.Table(template.TableName).CreateInBatches(&items, 1000).Error
-		return cErr
-	})
-}
-
 func getColumnName(n int) string {
 	columnName := ""
 	for n > 0 {
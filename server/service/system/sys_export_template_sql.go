@@ -0,0 +1,347 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 模板中的表名、字段名与别名只允许普通标识符，并且必须存在于数据库中
+var exportIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// exportJoinTypes 允许的关联方式
+var exportJoinTypes = map[string]string{
+	"JOIN":       "JOIN",
+	"INNER JOIN": "INNER JOIN",
+	"LEFT JOIN":  "LEFT JOIN",
+	"RIGHT JOIN": "RIGHT JOIN",
+}
+
+// exportOperators 允许的条件操作符
+var exportOperators = map[string]bool{
+	"=": true, "!=": true, "<>": true, ">": true, ">=": true, "<": true, "<=": true,
+	"LIKE": true, "IN": true, "NOT IN": true,
+}
+
+// exportJoinOperators 关联条件允许的比较符
+var exportJoinOperators = map[string]bool{
+	"=": true, "!=": true, "<>": true, ">": true, ">=": true, "<": true, "<=": true,
+}
+
+// exportSensitiveTables 保存凭据、会话或密钥的表，不允许出现在导出模板中
+var exportSensitiveTables = map[string]bool{
+	"sys_user_totps":         true,
+	"sys_user_sessions":      true,
+	"sys_refresh_tokens":     true,
+	"sys_password_histories": true,
+	"sys_user_oidcs":         true,
+	"jwt_blacklists":         true,
+}
+
+// exportSensitiveColumns 不允许导出、关联或作为条件的字段，键为 表.字段
+var exportSensitiveColumns = map[string]bool{
+	"sys_users.password": true,
+	"mer_user.password":  true,
+	"mer_user.key":       true,
+}
+
+// exportSecretColumn 字段名表明保存口令、密钥或令牌时默认视为敏感字段，新增的表无需登记即被拦截
+var exportSecretColumn = regexp.MustCompile(`(?i)(^|_)(password|passwd|pwd|secret|token|salt|key|credential)s?($|_)`)
+
+// exportAllowedColumns 名称与 exportSecretColumn 相符但不含敏感数据的字段，键为 表.字段
+var exportAllowedColumns = map[string]bool{
+	"sys_params.key":                    true,
+	"exa_file_upload_and_downloads.key": true,
+	"sys_export_templates.import_key":   true,
+}
+
+var exportSelectPattern = regexp.MustCompile(`(?i)^\s*([^\s]+)(?:\s+as\s+([^\s]+))?\s*$`)
+
+// exportColumn 带表名的字段引用
+type exportColumn struct {
+	Table  string
+	Column string
+	Alias  string
+}
+
+// Key 字段在结果集中的名称
+func (c exportColumn) Key() string {
+	if c.Alias != "" {
+		return c.Alias
+	}
+	return c.Column
+}
+
+type exportJoinOn struct {
+	Left     exportColumn
+	Operator string
+	Right    exportColumn
+}
+
+type exportJoin struct {
+	Type  string
+	Table string
+	On    []exportJoinOn
+}
+
+type exportCondition struct {
+	Column   exportColumn
+	Operator string
+	From     string
+}
+
+// exportPlan 校验后的模板结构，生成 SQL 时所有标识符均经过数据库方言转义
+type exportPlan struct {
+	Table      string
+	Selects    []exportColumn
+	Titles     []string
+	Joins      []exportJoin
+	Conditions []exportCondition
+	columns    map[string]map[string]bool
+}
+
+// ValidateExportTemplate 校验模板中的表、字段、关联与条件，保存模板前调用
+func (sysExportTemplateService *SysExportTemplateService) ValidateExportTemplate(template system.SysExportTemplate) error {
+	db := global.GVA_DB
+	if template.DBName != "" {
+		var ok bool
+		if db, ok = global.GVA_DBList[template.DBName]; !ok || db == nil {
+			return fmt.Errorf("数据库 %s 不存在", template.DBName)
+		}
+	}
+	plan, err := compileExportTemplate(db, template)
+	if err != nil {
+		return err
+	}
+	if template.Order != "" {
+		if _, err = plan.orderBy(template.Order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileExportTemplate 解析模板定义并对照真实表结构校验
+func compileExportTemplate(db *gorm.DB, template system.SysExportTemplate) (*exportPlan, error) {
+	plan := &exportPlan{Table: template.TableName, columns: make(map[string]map[string]bool)}
+	if err := plan.addTable(db, template.TableName); err != nil {
+		return nil, err
+	}
+	for _, join := range template.JoinTemplate {
+		joinType, ok := exportJoinTypes[strings.ToUpper(strings.Join(strings.Fields(join.JOINS), " "))]
+		if !ok {
+			return nil, fmt.Errorf("不支持的关联方式 %s", join.JOINS)
+		}
+		if err := plan.addTable(db, join.Table); err != nil {
+			return nil, err
+		}
+		if len(join.JoinOn) == 0 {
+			if join.ON != "" {
+				return nil, fmt.Errorf("关联表 %s 使用旧版文本关联条件，请重新配置关联条件", join.Table)
+			}
+			return nil, fmt.Errorf("关联表 %s 缺少关联条件", join.Table)
+		}
+		j := exportJoin{Type: joinType, Table: join.Table}
+		for _, on := range join.JoinOn {
+			operator := strings.TrimSpace(on.Operator)
+			if !exportJoinOperators[operator] {
+				return nil, fmt.Errorf("不支持的关联条件比较符 %s", on.Operator)
+			}
+			l, err := plan.tableColumn(on.LeftTable, on.LeftColumn)
+			if err != nil {
+				return nil, fmt.Errorf("关联条件不合法: %w", err)
+			}
+			r, err := plan.tableColumn(on.RightTable, on.RightColumn)
+			if err != nil {
+				return nil, fmt.Errorf("关联条件不合法: %w", err)
+			}
+			if l.Table != join.Table && r.Table != join.Table {
+				return nil, fmt.Errorf("关联条件必须引用关联表 %s 的字段", join.Table)
+			}
+			j.On = append(j.On, exportJoinOn{Left: l, Operator: operator, Right: r})
+		}
+		plan.Joins = append(plan.Joins, j)
+	}
+
+	keys, err := utils.GetJSONKeys(template.TemplateInfo)
+	if err != nil {
+		return nil, fmt.Errorf("模板信息格式错误: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("模板信息不能为空")
+	}
+	var titles map[string]string
+	if err = json.Unmarshal([]byte(template.TemplateInfo), &titles); err != nil {
+		return nil, fmt.Errorf("模板信息格式错误: %w", err)
+	}
+	for _, key := range keys {
+		m := exportSelectPattern.FindStringSubmatch(key)
+		if m == nil {
+			return nil, fmt.Errorf("模板字段 %s 格式错误", key)
+		}
+		col, err := plan.column(m[1], template.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if m[2] != "" {
+			alias := unquoteIdentifier(m[2])
+			if !exportIdentifier.MatchString(alias) {
+				return nil, fmt.Errorf("模板字段别名 %s 不合法", m[2])
+			}
+			col.Alias = alias
+		}
+		plan.Selects = append(plan.Selects, col)
+		plan.Titles = append(plan.Titles, titles[key])
+	}
+
+	for _, condition := range template.Conditions {
+		operator := strings.ToUpper(strings.Join(strings.Fields(condition.Operator), " "))
+		if !exportOperators[operator] {
+			return nil, fmt.Errorf("不支持的条件操作符 %s", condition.Operator)
+		}
+		col, err := plan.column(condition.Column, template.TableName)
+		if err != nil {
+			return nil, err
+		}
+		plan.Conditions = append(plan.Conditions, exportCondition{Column: col, Operator: operator, From: condition.From})
+	}
+	return plan, nil
+}
+
+func (p *exportPlan) addTable(db *gorm.DB, table string) error {
+	if !exportIdentifier.MatchString(table) {
+		return fmt.Errorf("表名 %s 不合法", table)
+	}
+	if exportSensitiveTables[strings.ToLower(table)] {
+		return fmt.Errorf("表 %s 包含敏感数据，不允许导出", table)
+	}
+	if _, ok := p.columns[table]; ok {
+		return nil
+	}
+	if !db.Migrator().HasTable(table) {
+		return fmt.Errorf("表 %s 不存在", table)
+	}
+	columnTypes, err := db.Migrator().ColumnTypes(table)
+	if err != nil {
+		return err
+	}
+	cols := make(map[string]bool, len(columnTypes))
+	for _, ct := range columnTypes {
+		cols[ct.Name()] = true
+	}
+	p.columns[table] = cols
+	return nil
+}
+
+// column 解析 字段 或 表.字段，未指定表时使用 defaultTable；表必须是主表或关联表
+func (p *exportPlan) column(ref, defaultTable string) (exportColumn, error) {
+	ref = strings.TrimSpace(ref)
+	table, name, qualified := strings.Cut(ref, ".")
+	if !qualified {
+		table, name = defaultTable, ref
+	}
+	return p.tableColumn(unquoteIdentifier(table), unquoteIdentifier(name))
+}
+
+// tableColumn 校验 表.字段：表必须是主表或关联表，字段必须存在且不是敏感字段
+func (p *exportPlan) tableColumn(table, name string) (exportColumn, error) {
+	table, name = strings.TrimSpace(table), strings.TrimSpace(name)
+	ref := table + "." + name
+	if table == "" {
+		return exportColumn{}, fmt.Errorf("字段 %s 需要指定表名", name)
+	}
+	cols, ok := p.columns[table]
+	if !ok {
+		return exportColumn{}, fmt.Errorf("字段 %s 引用的表不在模板中", ref)
+	}
+	if !exportIdentifier.MatchString(name) || !cols[name] {
+		return exportColumn{}, fmt.Errorf("字段 %s 不存在", ref)
+	}
+	if isExportSensitiveColumn(table, name) {
+		return exportColumn{}, fmt.Errorf("字段 %s 为敏感字段，不允许导出", ref)
+	}
+	return exportColumn{Table: table, Column: name}, nil
+}
+
+// isExportSensitiveColumn 字段是否登记为敏感字段，或名称表明保存凭据且未登记为可导出
+func isExportSensitiveColumn(table, column string) bool {
+	key := strings.ToLower(table + "." + column)
+	if exportSensitiveColumns[key] {
+		return true
+	}
+	return exportSecretColumn.MatchString(column) && !exportAllowedColumns[key]
+}
+
+// hasColumn 表中是否包含字段
+func (p *exportPlan) hasColumn(table, column string) bool {
+	return p.columns[table][column]
+}
+
+// orderBy 解析 "字段 [asc|desc]"，字段可带表名
+func (p *exportPlan) orderBy(order string) (clause.OrderByColumn, error) {
+	parts := strings.Fields(order)
+	if len(parts) == 0 || len(parts) > 2 {
+		return clause.OrderByColumn{}, fmt.Errorf("order by %s is not secure", order)
+	}
+	col, err := p.column(parts[0], p.Table)
+	if err != nil {
+		return clause.OrderByColumn{}, fmt.Errorf("order by %s is not in the fields", order)
+	}
+	desc := false
+	if len(parts) == 2 {
+		switch strings.ToLower(parts[1]) {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return clause.OrderByColumn{}, fmt.Errorf("order by %s is not secure", order)
+		}
+	}
+	return clause.OrderByColumn{Column: clause.Column{Table: col.Table, Name: col.Column}, Desc: desc}, nil
+}
+
+// apply 将模板结构转换为查询，标识符全部由方言转义，条件取值全部参数化
+func (p *exportPlan) apply(db *gorm.DB) *gorm.DB {
+	quote := db.Statement.Quote
+	selects := make([]string, 0, len(p.Selects))
+	for _, s := range p.Selects {
+		selects = append(selects, quote(clause.Column{Table: s.Table, Name: s.Column, Alias: s.Alias}))
+	}
+	db = db.Table(quote(clause.Table{Name: p.Table}))
+	for _, j := range p.Joins {
+		on := make([]string, 0, len(j.On))
+		for _, o := range j.On {
+			on = append(on, quote(clause.Column{Table: o.Left.Table, Name: o.Left.Column})+" "+o.Operator+" "+quote(clause.Column{Table: o.Right.Table, Name: o.Right.Column}))
+		}
+		db = db.Joins(j.Type + " " + quote(clause.Table{Name: j.Table}) + " ON " + strings.Join(on, " AND "))
+	}
+	return db.Select(strings.Join(selects, ", "))
+}
+
+// where 按条件追加参数化的查询条件，IN 类操作符的取值以英文逗号分隔
+func (c exportCondition) where(db *gorm.DB, value string) *gorm.DB {
+	column := db.Statement.Quote(clause.Column{Table: c.Column.Table, Name: c.Column.Column})
+	switch c.Operator {
+	case "IN", "NOT IN":
+		return db.Where(column+" "+c.Operator+" ?", strings.Split(value, ","))
+	case "LIKE":
+		return db.Where(column+" LIKE ?", "%"+value+"%")
+	}
+	return db.Where(column+" "+c.Operator+" ?", value)
+}
+
+func unquoteIdentifier(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '`' && s[len(s)-1] == '`' || s[0] == '"' && s[len(s)-1] == '"') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,203 @@
+package system
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+type exportOwner struct {
+	ID        uint
+	RowID     uint
+	Owner     string
+	AccessKey string
+	DeletedAt gorm.DeletedAt
+}
+
+func (exportOwner) TableName() string { return "test_export_owners" }
+
+func exportJoinTemplate() system.SysExportTemplate {
+	return system.SysExportTemplate{
+		Name:         "关联",
+		TableName:    "test_export_rows",
+		TemplateID:   "joined",
+		TemplateInfo: `{"test_export_rows.id":"编号","test_export_rows.name":"名称","test_export_owners.owner as owner_name":"负责人"}`,
+		Order:        "test_export_rows.id desc",
+		JoinTemplate: []system.JoinTemplate{{TemplateID: "joined", JOINS: "left join", Table: "test_export_owners", JoinOn: []system.JoinOn{
+			{LeftTable: "test_export_owners", LeftColumn: "row_id", Operator: "=", RightTable: "test_export_rows", RightColumn: "id"},
+		}}},
+		Conditions: []system.Condition{
+			{TemplateID: "joined", From: "owner", Column: "test_export_owners.owner", Operator: "="},
+			{TemplateID: "joined", From: "ids", Column: "id", Operator: "in"},
+		},
+	}
+}
+
+func setupExportJoin(t *testing.T) {
+	t.Helper()
+	setupExport(t, 3)
+	if err := global.GVA_DB.AutoMigrate(&exportOwner{}); err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.Create(&[]exportOwner{{RowID: 1, Owner: "alice"}, {RowID: 2, Owner: "bob"}, {RowID: 3, Owner: "carol"}})
+	global.GVA_DB.Delete(&exportOwner{}, "owner = ?", "carol")
+	if err := SysExportTemplateServiceApp.CreateSysExportTemplate(&system.SysExportTemplate{}); err == nil {
+		t.Fatal("empty template must be rejected")
+	}
+	template := exportJoinTemplate()
+	if err := SysExportTemplateServiceApp.CreateSysExportTemplate(&template); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportTemplate_JoinExport(t *testing.T) {
+	setupExportJoin(t)
+	query, err := SysExportTemplateServiceApp.PrepareExport("joined", url.Values{"params": {"ids=1,2,3&filterDeleted=true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err = query.Write(&buf, system.ExportFormatCsv, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 关联表中已软删除的行被过滤
+	want := "\xEF\xBB\xBF编号,名称,负责人\n2,row-2,bob\n1,row-1,alice\n"
+	if buf.String() != want {
+		t.Fatalf("csv = %q, want %q", buf.String(), want)
+	}
+
+	// 条件取值参数化，注入内容只作为普通值比较
+	query, err = SysExportTemplateServiceApp.PrepareExport("joined", url.Values{"params": {"owner=bob' OR '1'='1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := query.Count(); n != 0 {
+		t.Fatalf("count = %d, want 0", n)
+	}
+}
+
+func TestExportTemplate_RejectsInjection(t *testing.T) {
+	setupExportJoin(t)
+	cases := map[string]func(*system.SysExportTemplate){
+		"select expression": func(tp *system.SysExportTemplate) {
+			tp.TemplateInfo = `{"(select password from sys_users limit 1)":"密码"}`
+		},
+		"select unknown column": func(tp *system.SysExportTemplate) {
+			tp.TemplateInfo = `{"password":"密码"}`
+		},
+		"select foreign table": func(tp *system.SysExportTemplate) {
+			tp.TemplateInfo = `{"sys_users.password":"密码"}`
+		},
+		"alias": func(tp *system.SysExportTemplate) {
+			tp.TemplateInfo = `{"id as x,(select 1)":"编号"}`
+		},
+		"main table": func(tp *system.SysExportTemplate) {
+			tp.TableName = "test_export_rows; drop table sys_users"
+		},
+		"missing table": func(tp *system.SysExportTemplate) {
+			tp.TableName = "sys_not_exists"
+		},
+		"join type": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate[0].JOINS = "LEFT JOIN sys_users ON 1=1 LEFT JOIN"
+		},
+		"join table": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate[0].Table = "test_export_owners o"
+		},
+		"join on column": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate[0].JoinOn[0].RightColumn = "id OR 1=1"
+		},
+		"join on operator": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate[0].JoinOn[0].Operator = "= 1 OR 1 ="
+		},
+		"join on unrelated tables": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate[0].JoinOn[0].LeftTable = "test_export_rows"
+		},
+		"join on missing": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate[0].JoinOn = nil
+		},
+		"legacy join on text": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate[0].JoinOn = nil
+			tp.JoinTemplate[0].ON = "test_export_owners.row_id = test_export_rows.id"
+		},
+		"sensitive column": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate = append(tp.JoinTemplate, system.JoinTemplate{JOINS: "LEFT JOIN", Table: "sys_users", JoinOn: []system.JoinOn{
+				{LeftTable: "sys_users", LeftColumn: "id", Operator: "=", RightTable: "test_export_rows", RightColumn: "id"},
+			}})
+			tp.TemplateInfo = `{"id":"编号","sys_users.password":"密码"}`
+		},
+		"sensitive condition": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate = append(tp.JoinTemplate, system.JoinTemplate{JOINS: "LEFT JOIN", Table: "sys_users", JoinOn: []system.JoinOn{
+				{LeftTable: "sys_users", LeftColumn: "id", Operator: "=", RightTable: "test_export_rows", RightColumn: "id"},
+			}})
+			tp.Conditions[0].Column = "sys_users.password"
+		},
+		"sensitive table": func(tp *system.SysExportTemplate) {
+			tp.JoinTemplate[0].Table = "sys_user_totps"
+		},
+		"secret column by name": func(tp *system.SysExportTemplate) {
+			tp.TemplateInfo = `{"test_export_rows.id":"编号","test_export_owners.access_key":"密钥"}`
+		},
+		"condition operator": func(tp *system.SysExportTemplate) {
+			tp.Conditions[0].Operator = "= 1 OR 1 ="
+		},
+		"condition column": func(tp *system.SysExportTemplate) {
+			tp.Conditions[0].Column = "1=1 OR id"
+		},
+		"order": func(tp *system.SysExportTemplate) {
+			tp.Order = "id; drop table sys_users"
+		},
+		"order direction": func(tp *system.SysExportTemplate) {
+			tp.Order = "id desc, (select 1)"
+		},
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			template := exportJoinTemplate()
+			mutate(&template)
+			if err := SysExportTemplateServiceApp.ValidateExportTemplate(template); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+
+	// 绕过保存校验直接写入库中的模板，在导出时同样被拒绝
+	global.GVA_DB.Model(&system.JoinTemplate{}).Where("template_id = ?", "joined").
+		Update("join_on", `[{"leftTable":"test_export_owners","leftColumn":"row_id","operator":"= 1) UNION SELECT password FROM sys_users --","rightTable":"test_export_rows","rightColumn":"id"}]`)
+	if _, err := SysExportTemplateServiceApp.PrepareExport("joined", url.Values{}); err == nil || !strings.Contains(err.Error(), "关联条件") {
+		t.Fatalf("stored injection must be rejected, got %v", err)
+	}
+	for _, order := range []string{"name;drop table x", "test_export_owners.owner desc limit 1", "(select 1)"} {
+		if _, err := SysExportTemplateServiceApp.PrepareExport("rows", url.Values{"params": {"order=" + order}}); err == nil {
+			t.Fatalf("order %q must be rejected", order)
+		}
+	}
+}
+
+func TestExportTemplate_SensitiveColumns(t *testing.T) {
+	cases := map[string]bool{
+		"sys_users.password":                false,
+		"mer_user.password":                 false,
+		"mer_user.key":                      false,
+		"orders.api_token":                  false,
+		"orders.client_secret":              false,
+		"orders.pay_key":                    false,
+		"sys_params.key":                    true,
+		"exa_file_upload_and_downloads.key": true,
+		"sys_export_templates.import_key":   true,
+		"orders.monkey":                     true,
+		"orders.token_count_total":          false,
+		"mer_user.user_name":                true,
+	}
+	for ref, allowed := range cases {
+		table, column, _ := strings.Cut(ref, ".")
+		if got := !isExportSensitiveColumn(table, column); got != allowed {
+			t.Errorf("%s allowed = %v, want %v", ref, got, allowed)
+		}
+	}
+}
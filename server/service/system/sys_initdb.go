@@ -129,6 +129,10 @@ func (initDBService *InitDBService) InitDB(conf request.InitDB) (err error) {
 	if err = initHandler.InitData(ctx, initializers); err != nil {
 		return err
 	}
+	// 初始数据已是最新，已有的迁移无需再执行
+	if err = baselineMigrations(db); err != nil {
+		return err
+	}
 
 	if err = initHandler.WriteConfig(ctx); err != nil {
 		return err
@@ -30,6 +30,9 @@ func (h SqliteInitHandler) WriteConfig(ctx context.Context) error {
 	global.GVA_CONFIG.System.DbType = "sqlite"
 	global.GVA_CONFIG.Sqlite = c
 	global.GVA_CONFIG.JWT.SigningKey = uuid.New().String()
+	global.GVA_CONFIG.Totp.EncryptionKey = uuid.New().String()
+	global.GVA_CONFIG.Excel.DownloadSigningKey = uuid.New().String()
+	global.GVA_CONFIG.AuditLog.HashKey = uuid.New().String()
 	cs := utils.StructToMap(global.GVA_CONFIG)
 	for k, v := range cs {
 		global.GVA_VP.Set(k, v)
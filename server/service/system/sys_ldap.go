@@ -0,0 +1,324 @@
+package system
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/go-ldap/ldap/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLdapInvalidCredentials = errors.New("用户名不存在或者密码错误")
+	ErrLdapUnavailable        = errors.New("目录服务暂不可用，请稍后重试")
+	ErrLdapNotProvisioned     = errors.New("该账号尚未开通，请联系管理员")
+	ErrLdapNoAuthority        = errors.New("该账号未分配可用角色，请联系管理员")
+	ErrLdapManagedPassword    = errors.New("目录账号的密码由LDAP统一管理，请在目录服务中修改")
+)
+
+// LdapUser 目录中的用户信息
+type LdapUser struct {
+	DN       string
+	Username string
+	NickName string
+	Email    string
+	Phone    string
+	Groups   []string
+}
+
+type LdapService struct{}
+
+var LdapServiceApp = new(LdapService)
+
+// Enabled 是否开启LDAP登录
+func (ldapService *LdapService) Enabled() bool {
+	return global.GVA_CONFIG.Ldap.Enable
+}
+
+// IsDirectoryUser 用户是否通过目录认证：开启LDAP后除应急账号外的用户均由目录管理密码
+func (ldapService *LdapService) IsDirectoryUser(username string) bool {
+	if !ldapService.Enabled() {
+		return false
+	}
+	for _, local := range global.GVA_CONFIG.Ldap.LocalUsers {
+		if strings.EqualFold(local, username) {
+			return false
+		}
+	}
+	return true
+}
+
+// Login 通过目录认证用户，同步资料与角色后返回系统用户，首次登录时按配置自动创建
+func (ldapService *LdapService) Login(username, password string) (*system.SysUser, error) {
+	entry, err := ldapService.Authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+	// 目录中的用户名可能与输入不同（如大小写），不允许借此登录应急账号
+	if !ldapService.IsDirectoryUser(entry.Username) {
+		return nil, ErrLdapInvalidCredentials
+	}
+	authorityIds := mapLdapAuthorities(entry.Groups)
+
+	var user system.SysUser
+	err = global.GVA_DB.Select("id").Where("username = ?", entry.Username).First(&user).Error
+	switch {
+	case err == nil:
+		if err = syncLdapProfile(user.ID, entry); err != nil {
+			return nil, err
+		}
+		if global.GVA_CONFIG.Ldap.SyncRoles && len(authorityIds) > 0 {
+			if err = syncUserAuthorities(context.Background(), user.ID, authorityIds); err != nil {
+				return nil, err
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if user.ID, err = ldapService.provision(entry, authorityIds); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	return UserServiceApp.GetLoginUser(user.ID)
+}
+
+// Authenticate 服务账号绑定后搜索用户，再以用户DN和密码绑定校验，最后读取所属组
+func (ldapService *LdapService) Authenticate(username, password string) (*LdapUser, error) {
+	cfg := global.GVA_CONFIG.Ldap
+	// 空密码的绑定会被当作匿名绑定而成功，必须提前拒绝
+	if username == "" || password == "" {
+		return nil, ErrLdapInvalidCredentials
+	}
+	conn, err := dialLdap()
+	if err != nil {
+		global.GVA_LOG.Error("连接目录服务失败!", zap.Error(err))
+		return nil, ErrLdapUnavailable
+	}
+	defer conn.Close()
+
+	if cfg.BindDN != "" {
+		if err = conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			global.GVA_LOG.Error("目录服务账号绑定失败!", zap.Error(err))
+			return nil, ErrLdapUnavailable
+		}
+	}
+	userFilter := cfg.UserFilter
+	if userFilter == "" {
+		userFilter = "(uid={username})"
+	}
+	attrs := []string{ldapUsernameAttr()}
+	for _, a := range []string{cfg.NickNameAttr, cfg.EmailAttr, cfg.PhoneAttr, cfg.GroupAttr} {
+		if a != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	filter := strings.ReplaceAll(userFilter, "{username}", ldap.EscapeFilter(username))
+	result, err := conn.Search(ldap.NewSearchRequest(cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false, filter, attrs, nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		global.GVA_LOG.Error("搜索目录用户失败!", zap.Error(err))
+		return nil, ErrLdapUnavailable
+	}
+	var entries []*ldap.Entry
+	if result != nil {
+		entries = result.Entries
+	}
+	// 过滤器匹配到多个条目时无法确定身份，按认证失败处理
+	if len(entries) != 1 {
+		if len(entries) > 1 {
+			global.GVA_LOG.Warn("目录中存在多个匹配的用户，请检查 user-filter", zap.String("username", username))
+		}
+		return nil, ErrLdapInvalidCredentials
+	}
+	entry := entries[0]
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrLdapInvalidCredentials
+		}
+		global.GVA_LOG.Error("目录用户绑定失败!", zap.Error(err))
+		return nil, ErrLdapUnavailable
+	}
+
+	user := &LdapUser{
+		DN:       entry.DN,
+		Username: entry.GetAttributeValue(ldapUsernameAttr()),
+		Groups:   entry.GetAttributeValues(cfg.GroupAttr),
+	}
+	if user.Username == "" {
+		user.Username = username
+	}
+	if cfg.NickNameAttr != "" {
+		user.NickName = entry.GetAttributeValue(cfg.NickNameAttr)
+	}
+	if cfg.EmailAttr != "" {
+		user.Email = entry.GetAttributeValue(cfg.EmailAttr)
+	}
+	if cfg.PhoneAttr != "" {
+		user.Phone = entry.GetAttributeValue(cfg.PhoneAttr)
+	}
+	if cfg.GroupFilter != "" {
+		// 以服务账号身份搜索组，目录可能不允许普通用户读取组信息
+		if cfg.BindDN != "" {
+			if err = conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+				global.GVA_LOG.Error("目录服务账号绑定失败!", zap.Error(err))
+				return nil, ErrLdapUnavailable
+			}
+		}
+		groupBase := cfg.GroupBaseDN
+		if groupBase == "" {
+			groupBase = cfg.BaseDN
+		}
+		groupFilter := strings.ReplaceAll(cfg.GroupFilter, "{dn}", ldap.EscapeFilter(entry.DN))
+		groups, err := conn.Search(ldap.NewSearchRequest(groupBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, groupFilter, []string{"cn"}, nil))
+		if err != nil {
+			global.GVA_LOG.Error("搜索目录用户组失败!", zap.Error(err))
+			return nil, ErrLdapUnavailable
+		}
+		for _, g := range groups.Entries {
+			user.Groups = append(user.Groups, g.DN)
+		}
+	}
+	return user, nil
+}
+
+// provision 目录用户首次登录时创建系统用户，本地密码随机生成且不可用于登录
+func (ldapService *LdapService) provision(entry *LdapUser, authorityIds []uint) (uint, error) {
+	if !global.GVA_CONFIG.Ldap.AutoProvision {
+		return 0, ErrLdapNotProvisioned
+	}
+	if len(authorityIds) == 0 {
+		return 0, ErrLdapNoAuthority
+	}
+	password, err := utils.GeneratePassword(global.GVA_CONFIG.PasswordPolicy)
+	if err != nil {
+		return 0, err
+	}
+	nickName := entry.NickName
+	if nickName == "" {
+		nickName = entry.Username
+	}
+	authorities := make([]system.SysAuthority, 0, len(authorityIds))
+	for _, id := range authorityIds {
+		authorities = append(authorities, system.SysAuthority{AuthorityId: id})
+	}
+	user, err := UserServiceApp.Register(system.SysUser{
+		Username:    entry.Username,
+		NickName:    nickName,
+		Password:    password,
+		Email:       entry.Email,
+		Phone:       entry.Phone,
+		AuthorityId: authorityIds[0],
+		Authorities: authorities,
+		Enable:      1,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("创建目录用户失败: %w", err)
+	}
+	return user.ID, nil
+}
+
+// syncLdapProfile 用目录中的非空属性覆盖用户资料
+func syncLdapProfile(userId uint, entry *LdapUser) error {
+	updates := make(map[string]interface{})
+	if entry.NickName != "" {
+		updates["nick_name"] = entry.NickName
+	}
+	if entry.Email != "" {
+		updates["email"] = entry.Email
+	}
+	if entry.Phone != "" {
+		updates["phone"] = entry.Phone
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return global.GVA_DB.Model(&system.SysUser{}).Where("id = ?", userId).Updates(updates).Error
+}
+
+// mapLdapAuthorities 按配置将组映射为角色ID，组可以用完整DN或cn配置，未匹配时使用默认角色
+func mapLdapAuthorities(groups []string) []uint {
+	cfg := global.GVA_CONFIG.Ldap
+	names := make(map[string]struct{}, len(groups)*2)
+	for _, g := range groups {
+		g = strings.ToLower(strings.TrimSpace(g))
+		names[g] = struct{}{}
+		if cn := groupCommonName(g); cn != "" {
+			names[cn] = struct{}{}
+		}
+	}
+	var ids []uint
+	seen := make(map[uint]struct{})
+	for _, m := range cfg.GroupMapping {
+		if _, ok := names[strings.ToLower(strings.TrimSpace(m.Group))]; !ok || m.AuthorityId == 0 {
+			continue
+		}
+		if _, dup := seen[m.AuthorityId]; dup {
+			continue
+		}
+		seen[m.AuthorityId] = struct{}{}
+		ids = append(ids, m.AuthorityId)
+	}
+	if len(ids) == 0 && cfg.DefaultAuthorityId != 0 {
+		ids = append(ids, cfg.DefaultAuthorityId)
+	}
+	return ids
+}
+
+// groupCommonName 取组DN第一段的值，如 cn=ops,ou=groups,dc=example,dc=com -> ops
+func groupCommonName(dn string) string {
+	first, _, _ := strings.Cut(dn, ",")
+	_, value, ok := strings.Cut(first, "=")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// ldapConn 目录连接，由 *ldap.Conn 实现，测试时替换为内存目录
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// dialLdap 连接目录服务，ldap:// 按配置升级为TLS
+var dialLdap = func() (ldapConn, error) {
+	cfg := global.GVA_CONFIG.Ldap
+	u, err := url.Parse(cfg.Url)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: u.Hostname(), InsecureSkipVerify: cfg.InsecureSkipVerify}
+	conn, err := ldap.DialURL(cfg.Url, ldap.DialWithDialer(&net.Dialer{Timeout: timeout}), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+	if cfg.StartTLS && strings.EqualFold(u.Scheme, "ldap") {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func ldapUsernameAttr() string {
+	if attr := global.GVA_CONFIG.Ldap.UsernameAttr; attr != "" {
+		return attr
+	}
+	return "uid"
+}
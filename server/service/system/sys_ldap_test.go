@@ -0,0 +1,294 @@
+package system
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const ldapBaseDN = "dc=example,dc=com"
+
+// ldapEntry 内存目录中的条目，Password 为空的条目不能绑定
+type ldapEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// ldapDirectory 内存目录，替换 dialLdap 后服务通过 go-ldap 解析的过滤器在其中查询
+type ldapDirectory struct {
+	mu      sync.Mutex
+	entries map[string]ldapEntry
+	down    bool
+}
+
+// Add 添加或替换条目
+func (d *ldapDirectory) Add(entries ...ldapEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, e := range entries {
+		d.entries[strings.ToLower(e.DN)] = e
+	}
+}
+
+// Close 模拟目录服务不可用
+func (d *ldapDirectory) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.down = true
+}
+
+func (d *ldapDirectory) dial() (ldapConn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.down {
+		return nil, ldap.NewError(ldap.ErrorNetwork, errors.New("connection refused"))
+	}
+	return &ldapDirectoryConn{dir: d}, nil
+}
+
+type ldapDirectoryConn struct {
+	dir *ldapDirectory
+}
+
+func (c *ldapDirectoryConn) Bind(username, password string) error {
+	c.dir.mu.Lock()
+	defer c.dir.mu.Unlock()
+	e, ok := c.dir.entries[strings.ToLower(username)]
+	if !ok || password == "" || e.Password == "" || e.Password != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	return nil
+}
+
+func (c *ldapDirectoryConn) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	filter, err := ldap.CompileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	c.dir.mu.Lock()
+	defer c.dir.mu.Unlock()
+	base := strings.ToLower(req.BaseDN)
+	result := &ldap.SearchResult{}
+	for dn, e := range c.dir.entries {
+		if dn != base && !strings.HasSuffix(dn, ","+base) || !ldapMatch(filter, e.Attributes) {
+			continue
+		}
+		attrs := make(map[string][]string, len(req.Attributes))
+		for _, name := range req.Attributes {
+			if values, ok := ldapAttr(e.Attributes, name); ok {
+				attrs[name] = values
+			}
+		}
+		result.Entries = append(result.Entries, ldap.NewEntry(e.DN, attrs))
+	}
+	sort.Slice(result.Entries, func(i, j int) bool { return result.Entries[i].DN < result.Entries[j].DN })
+	if req.SizeLimit > 0 && len(result.Entries) > req.SizeLimit {
+		result.Entries = result.Entries[:req.SizeLimit]
+		return result, ldap.NewError(ldap.LDAPResultSizeLimitExceeded, errors.New("size limit exceeded"))
+	}
+	return result, nil
+}
+
+func (c *ldapDirectoryConn) Close() error {
+	return nil
+}
+
+// ldapMatch 按过滤器匹配条目，支持与、或、非、相等与存在判断，属性名和值忽略大小写
+func ldapMatch(filter *ber.Packet, attrs map[string][]string) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !ldapMatch(child, attrs) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if ldapMatch(child, attrs) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !ldapMatch(filter.Children[0], attrs)
+	case ldap.FilterPresent:
+		_, ok := ldapAttr(attrs, ber.DecodeString(filter.Data.Bytes()))
+		return ok
+	case ldap.FilterEqualityMatch:
+		values, _ := ldapAttr(attrs, ber.DecodeString(filter.Children[0].Data.Bytes()))
+		want := ber.DecodeString(filter.Children[1].Data.Bytes())
+		for _, v := range values {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ldapAttr(attrs map[string][]string, name string) ([]string, bool) {
+	for k, v := range attrs {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func setupLdap(t *testing.T) *ldapDirectory {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysUser{}, &system.SysAuthority{}, &system.SysPasswordHistory{})
+	db.Create(&[]system.SysAuthority{{AuthorityId: 888, AuthorityName: "admin"}, {AuthorityId: 9528, AuthorityName: "viewer"}})
+
+	dir := &ldapDirectory{entries: make(map[string]ldapEntry)}
+	dir.Add(
+		ldapEntry{DN: "cn=readonly," + ldapBaseDN, Password: "readonly-secret"},
+		ldapEntry{DN: "uid=alice,ou=people," + ldapBaseDN, Password: "Alice#Dir1", Attributes: map[string][]string{
+			"objectClass": {"person"}, "uid": {"alice"}, "displayName": {"Alice Liddell"},
+			"mail": {"alice@example.com"}, "telephoneNumber": {"13800000001"},
+			"memberOf": {"cn=gva-viewers,ou=groups," + ldapBaseDN},
+		}},
+		ldapEntry{DN: "cn=gva-admins,ou=groups," + ldapBaseDN, Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"}, "cn": {"gva-admins"}, "member": {"uid=bob,ou=people," + ldapBaseDN},
+		}},
+		ldapEntry{DN: "uid=bob,ou=people," + ldapBaseDN, Password: "Bob#Dir12", Attributes: map[string][]string{
+			"objectClass": {"person"}, "uid": {"bob"}, "displayName": {"Bob"},
+		}},
+	)
+	dial := dialLdap
+	dialLdap = dir.dial
+	t.Cleanup(func() { dialLdap = dial })
+	global.GVA_CONFIG.PasswordPolicy = config.PasswordPolicy{MinLength: 8, MinCharClasses: 3, ExpireDays: 90}
+	global.GVA_CONFIG.Ldap = config.Ldap{
+		Enable:       true,
+		Url:          "ldap://ldap.example.com",
+		Timeout:      2,
+		BindDN:       "cn=readonly," + ldapBaseDN,
+		BindPassword: "readonly-secret",
+		BaseDN:       ldapBaseDN,
+		UserFilter:   "(&(objectClass=person)(uid={username}))",
+		UsernameAttr: "uid",
+		NickNameAttr: "displayName",
+		EmailAttr:    "mail",
+		PhoneAttr:    "telephoneNumber",
+		GroupAttr:    "memberOf",
+		GroupMapping: []config.LdapGroupMapping{
+			{Group: "gva-admins", AuthorityId: 888},
+			{Group: "cn=gva-viewers,ou=groups," + ldapBaseDN, AuthorityId: 9528},
+		},
+		AutoProvision: true,
+		SyncRoles:     true,
+		LocalUsers:    []string{"admin"},
+	}
+	return dir
+}
+
+func TestLdapLogin_ProvisionAndSync(t *testing.T) {
+	dir := setupLdap(t)
+
+	user, err := UserServiceApp.Login(&system.SysUser{Username: "alice", Password: "Alice#Dir1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.NickName != "Alice Liddell" || user.Email != "alice@example.com" || user.Phone != "13800000001" || user.AuthorityId != 9528 {
+		t.Fatalf("自动创建的用户不符合预期: %+v", user)
+	}
+	// 目录用户不受本地密码有效期约束
+	if UserServiceApp.PasswordChangeRequired(user) {
+		t.Fatal("目录用户不应被要求修改本地密码")
+	}
+	if err = UserServiceApp.ChangePassword(&system.SysUser{GVA_MODEL: global.GVA_MODEL{ID: user.ID}}, "Another#Pass1"); !errors.Is(err, ErrLdapManagedPassword) {
+		t.Fatalf("目录用户修改本地密码应被拒绝, got %v", err)
+	}
+
+	// 目录中的资料与组变更后再次登录同步到系统用户
+	dir.Add(ldapEntry{DN: "uid=alice,ou=people," + ldapBaseDN, Password: "Alice#Dir1", Attributes: map[string][]string{
+		"objectClass": {"person"}, "uid": {"alice"}, "displayName": {"Alice L."}, "mail": {"alice@corp.example.com"},
+		"memberOf": {"cn=gva-viewers,ou=groups," + ldapBaseDN, "cn=gva-admins,ou=groups," + ldapBaseDN},
+	}})
+	again, err := UserServiceApp.Login(&system.SysUser{Username: "alice", Password: "Alice#Dir1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != user.ID || again.NickName != "Alice L." || again.Email != "alice@corp.example.com" || again.Phone != "13800000001" {
+		t.Fatalf("资料同步结果不符合预期: %+v", again)
+	}
+	if len(again.Authorities) != 2 {
+		t.Fatalf("角色同步后应有2个角色, got %d", len(again.Authorities))
+	}
+
+	if _, err = UserServiceApp.Login(&system.SysUser{Username: "alice", Password: "wrong"}); !errors.Is(err, ErrLdapInvalidCredentials) {
+		t.Fatalf("错误密码应返回 ErrLdapInvalidCredentials, got %v", err)
+	}
+	if _, err = UserServiceApp.Login(&system.SysUser{Username: "alice", Password: ""}); !errors.Is(err, ErrLdapInvalidCredentials) {
+		t.Fatalf("空密码应被拒绝, got %v", err)
+	}
+	if _, err = UserServiceApp.Login(&system.SysUser{Username: "*", Password: "Alice#Dir1"}); !errors.Is(err, ErrLdapInvalidCredentials) {
+		t.Fatalf("过滤器注入应被转义, got %v", err)
+	}
+}
+
+func TestLdapLogin_GroupSearchAndProvisionRules(t *testing.T) {
+	setupLdap(t)
+	global.GVA_CONFIG.Ldap.GroupFilter = "(&(objectClass=groupOfNames)(member={dn}))"
+
+	bob, err := UserServiceApp.Login(&system.SysUser{Username: "bob", Password: "Bob#Dir12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.AuthorityId != 888 {
+		t.Fatalf("通过组搜索应映射为管理员角色, got %d", bob.AuthorityId)
+	}
+
+	// 未匹配任何组且无默认角色时拒绝创建
+	global.GVA_CONFIG.Ldap.GroupMapping = nil
+	global.GVA_DB.Unscoped().Where("username = ?", "bob").Delete(&system.SysUser{})
+	if _, err = UserServiceApp.Login(&system.SysUser{Username: "bob", Password: "Bob#Dir12"}); !errors.Is(err, ErrLdapNoAuthority) {
+		t.Fatalf("无角色映射应返回 ErrLdapNoAuthority, got %v", err)
+	}
+	global.GVA_CONFIG.Ldap.AutoProvision = false
+	if _, err = UserServiceApp.Login(&system.SysUser{Username: "bob", Password: "Bob#Dir12"}); !errors.Is(err, ErrLdapNotProvisioned) {
+		t.Fatalf("关闭自动创建时应返回 ErrLdapNotProvisioned, got %v", err)
+	}
+}
+
+func TestLdapLogin_BreakGlassLocalAccount(t *testing.T) {
+	dir := setupLdap(t)
+	if _, err := UserServiceApp.Register(system.SysUser{Username: "admin", Password: "Break#Glass9", AuthorityId: 888}); err != nil {
+		t.Fatal(err)
+	}
+	// 以邮箱等其他属性匹配到同名目录账号时，不能借此登录应急账号
+	global.GVA_CONFIG.Ldap.UserFilter = "(&(objectClass=person)(|(uid={username})(mail={username})))"
+	dir.Add(ldapEntry{DN: "uid=admin,ou=people," + ldapBaseDN, Password: "Dir#Admin1", Attributes: map[string][]string{
+		"objectClass": {"person"}, "uid": {"admin"}, "mail": {"admin@example.com"},
+		"memberOf": {"cn=gva-admins,ou=groups," + ldapBaseDN},
+	}})
+	if _, err := UserServiceApp.Login(&system.SysUser{Username: "admin@example.com", Password: "Dir#Admin1"}); !errors.Is(err, ErrLdapInvalidCredentials) {
+		t.Fatalf("目录账号不应登录为应急账号, got %v", err)
+	}
+
+	// 目录不可用时应急账号仍可使用本地密码登录，目录用户提示服务不可用
+	dir.Close()
+	user, err := UserServiceApp.Login(&system.SysUser{Username: "admin", Password: "Break#Glass9"})
+	if err != nil || user.Username != "admin" {
+		t.Fatalf("应急账号应通过本地密码登录, user=%v err=%v", user, err)
+	}
+	if _, err = UserServiceApp.Login(&system.SysUser{Username: "alice", Password: "Alice#Dir1"}); !errors.Is(err, ErrLdapUnavailable) {
+		t.Fatalf("目录不可用时应返回 ErrLdapUnavailable, got %v", err)
+	}
+	if !utils.BcryptCheck("Break#Glass9", user.Password) {
+		t.Fatal("应急账号密码不应被修改")
+	}
+}
@@ -0,0 +1,286 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// loginFailKey 失败次数：login_fail:user:<username> / login_fail:ip:<ip>
+	loginFailKey = "login_fail"
+	// loginLockKey 锁定标记，过期时间即剩余锁定时长：login_lock:user:<username> / login_lock:ip:<ip>
+	loginLockKey = "login_lock"
+	// loginLockLevelKey 账号累计锁定次数，用于计算指数退避的锁定时长
+	loginLockLevelKey = "login_lock_level"
+
+	defaultLoginLockDuration    = 5 * time.Minute
+	defaultLoginMaxLockDuration = 24 * time.Hour
+	defaultLoginWindow          = 15 * time.Minute
+)
+
+// 登录失败原因
+const (
+	LoginReasonCaptcha  = "验证码错误"
+	LoginReasonPassword = "用户名不存在或者密码错误"
+	LoginReasonDisabled = "用户被禁止登录"
+	LoginReasonLocked   = "登录已锁定"
+	LoginReasonTotp     = "两步验证失败"
+	LoginReasonLdap     = "目录服务登录失败"
+)
+
+// LoginLockedError 账号或IP处于锁定期
+type LoginLockedError struct {
+	Remaining time.Duration
+	ByIp      bool
+}
+
+func (e *LoginLockedError) Error() string {
+	remaining := e.Remaining.Round(time.Second)
+	if e.ByIp {
+		return fmt.Sprintf("当前IP登录失败次数过多，请%s后再试", remaining)
+	}
+	return fmt.Sprintf("账号登录失败次数过多已被锁定，请%s后再试", remaining)
+}
+
+type LoginGuardService struct{}
+
+var LoginGuardServiceApp = new(LoginGuardService)
+
+// CheckLocked 校验账号与IP是否处于锁定期，锁定时返回 *LoginLockedError
+func (loginGuardService *LoginGuardService) CheckLocked(ctx context.Context, username, ip string) error {
+	store := loginGuardStore()
+	if ip != "" {
+		if ttl := store.ttl(ctx, guardKey(loginLockKey, "ip", ip)); ttl > 0 {
+			return &LoginLockedError{Remaining: ttl, ByIp: true}
+		}
+	}
+	if username != "" {
+		if ttl := store.ttl(ctx, guardKey(loginLockKey, "user", username)); ttl > 0 {
+			return &LoginLockedError{Remaining: ttl}
+		}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次密码错误，达到阈值时锁定账号或IP，返回本次触发的锁定时长（未锁定为0）
+// 账号的锁定时长按累计锁定次数指数增长，直至 max-lock-duration；登录成功或管理员解锁后重置
+func (loginGuardService *LoginGuardService) RecordFailure(ctx context.Context, username, ip string) time.Duration {
+	cfg := global.GVA_CONFIG.LoginGuard
+	store := loginGuardStore()
+	window := guardDuration(cfg.Window, defaultLoginWindow)
+	var locked time.Duration
+
+	if cfg.IpMaxFailures > 0 && ip != "" {
+		failKey := guardKey(loginFailKey, "ip", ip)
+		if store.incr(ctx, failKey, window) >= int64(cfg.IpMaxFailures) {
+			store.set(ctx, guardKey(loginLockKey, "ip", ip), window)
+			store.del(ctx, failKey)
+			locked = window
+			global.GVA_LOG.Warn("登录失败次数过多，锁定IP", zap.String("ip", ip), zap.Duration("duration", window))
+		}
+	}
+
+	if cfg.MaxFailures > 0 && username != "" {
+		failKey := guardKey(loginFailKey, "user", username)
+		maxLock := guardDuration(cfg.MaxLockDuration, defaultLoginMaxLockDuration)
+		if store.incr(ctx, failKey, window) >= int64(cfg.MaxFailures) {
+			level := store.incr(ctx, guardKey(loginLockLevelKey, "user", username), 2*maxLock)
+			d := lockBackoff(level, guardDuration(cfg.LockDuration, defaultLoginLockDuration), maxLock)
+			store.set(ctx, guardKey(loginLockKey, "user", username), d)
+			store.del(ctx, failKey)
+			if d > locked {
+				locked = d
+			}
+			global.GVA_LOG.Warn("登录失败次数过多，锁定账号",
+				zap.String("username", username), zap.String("ip", ip), zap.Int64("level", level), zap.Duration("duration", d))
+		}
+	}
+	return locked
+}
+
+// RecordSuccess 登录成功（全部登录因素校验通过）后清空账号的失败次数与退避等级
+func (loginGuardService *LoginGuardService) RecordSuccess(ctx context.Context, username string) {
+	loginGuardStore().del(ctx,
+		guardKey(loginFailKey, "user", username),
+		guardKey(loginLockLevelKey, "user", username))
+}
+
+// Unlock 管理员解锁账号，同时重置失败次数与退避等级
+func (loginGuardService *LoginGuardService) Unlock(ctx context.Context, username string) {
+	loginGuardStore().del(ctx,
+		guardKey(loginLockKey, "user", username),
+		guardKey(loginFailKey, "user", username),
+		guardKey(loginLockLevelKey, "user", username))
+}
+
+// UnlockIp 管理员解除IP限制
+func (loginGuardService *LoginGuardService) UnlockIp(ctx context.Context, ip string) {
+	loginGuardStore().del(ctx,
+		guardKey(loginLockKey, "ip", ip),
+		guardKey(loginFailKey, "ip", ip))
+}
+
+// LockRemaining 获取账号剩余锁定时长
+func (loginGuardService *LoginGuardService) LockRemaining(ctx context.Context, username string) time.Duration {
+	return loginGuardStore().ttl(ctx, guardKey(loginLockKey, "user", username))
+}
+
+// RecordLogin 写入登录记录，用户名存在时关联用户ID
+func (loginGuardService *LoginGuardService) RecordLogin(username, ip, userAgent string, success bool, reason string) {
+	var user system.SysUser
+	global.GVA_DB.Select("id").Where("username = ?", username).Limit(1).Find(&user)
+	err := global.GVA_DB.Create(&system.SysLoginLog{
+		UserId:    user.ID,
+		Username:  truncate(username, 191),
+		Ip:        ip,
+		UserAgent: truncate(userAgent, 512),
+		Success:   success,
+		Reason:    reason,
+	}).Error
+	if err != nil {
+		global.GVA_LOG.Error("写入登录记录失败!", zap.Error(err))
+	}
+}
+
+// GetLoginLogList 分页获取登录记录
+func (loginGuardService *LoginGuardService) GetLoginLogList(info systemReq.SysLoginLogSearch) (list []system.SysLoginLog, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&system.SysLoginLog{})
+	if info.UserId != 0 {
+		db = db.Where("user_id = ?", info.UserId)
+	}
+	if info.Username != "" {
+		db = db.Where("username = ?", info.Username)
+	}
+	if info.Ip != "" {
+		db = db.Where("ip = ?", info.Ip)
+	}
+	if info.Success != nil {
+		db = db.Where("success = ?", *info.Success)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id desc").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// lockBackoff 第 level 次锁定的时长：base * 2^(level-1)，不超过 max
+func lockBackoff(level int64, base, max time.Duration) time.Duration {
+	d := base
+	for i := int64(1); i < level && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+func guardKey(prefix, kind, subject string) string {
+	return prefix + ":" + kind + ":" + subject
+}
+
+func guardDuration(value string, fallback time.Duration) time.Duration {
+	if d, err := utils.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// loginGuardCounter 计数存储，启用 Redis 时多实例共享，否则退化为进程内缓存
+type loginGuardCounter interface {
+	incr(ctx context.Context, key string, ttl time.Duration) int64
+	ttl(ctx context.Context, key string) time.Duration
+	set(ctx context.Context, key string, ttl time.Duration)
+	del(ctx context.Context, keys ...string)
+}
+
+func loginGuardStore() loginGuardCounter {
+	if global.GVA_REDIS != nil {
+		return redisGuardCounter{}
+	}
+	return localGuardCounter{}
+}
+
+// redisGuardCounter Redis 故障时放行登录，只记录日志
+type redisGuardCounter struct{}
+
+func (redisGuardCounter) incr(ctx context.Context, key string, ttl time.Duration) int64 {
+	n, err := global.GVA_REDIS.Incr(ctx, key).Result()
+	if err != nil {
+		global.GVA_LOG.Error("登录计数失败!", zap.Error(err))
+		return 0
+	}
+	if n == 1 {
+		global.GVA_REDIS.Expire(ctx, key, ttl)
+	}
+	return n
+}
+
+func (redisGuardCounter) ttl(ctx context.Context, key string) time.Duration {
+	d, err := global.GVA_REDIS.PTTL(ctx, key).Result()
+	if err != nil {
+		global.GVA_LOG.Error("查询登录锁定状态失败!", zap.Error(err))
+		return 0
+	}
+	return d
+}
+
+func (redisGuardCounter) set(ctx context.Context, key string, ttl time.Duration) {
+	if err := global.GVA_REDIS.Set(ctx, key, 1, ttl).Err(); err != nil {
+		global.GVA_LOG.Error("写入登录锁定状态失败!", zap.Error(err))
+	}
+}
+
+func (redisGuardCounter) del(ctx context.Context, keys ...string) {
+	if err := global.GVA_REDIS.Del(ctx, keys...).Err(); err != nil {
+		global.GVA_LOG.Error("清除登录锁定状态失败!", zap.Error(err))
+	}
+}
+
+// localGuardCounter 进程内计数，仅适用于单实例部署
+type localGuardCounter struct{}
+
+var localGuardMu sync.Mutex
+
+func (localGuardCounter) incr(_ context.Context, key string, ttl time.Duration) int64 {
+	localGuardMu.Lock()
+	defer localGuardMu.Unlock()
+	v, expiresAt, ok := global.BlackCache.GetWithExpire(key)
+	n, _ := v.(int64)
+	if !ok || expiresAt.IsZero() {
+		global.BlackCache.Set(key, int64(1), ttl)
+		return 1
+	}
+	n++
+	global.BlackCache.Set(key, n, time.Until(expiresAt))
+	return n
+}
+
+func (localGuardCounter) ttl(_ context.Context, key string) time.Duration {
+	_, expiresAt, ok := global.BlackCache.GetWithExpire(key)
+	if !ok || expiresAt.IsZero() {
+		return 0
+	}
+	return time.Until(expiresAt)
+}
+
+func (localGuardCounter) set(_ context.Context, key string, ttl time.Duration) {
+	global.BlackCache.Set(key, int64(1), ttl)
+}
+
+func (localGuardCounter) del(_ context.Context, keys ...string) {
+	for _, key := range keys {
+		global.BlackCache.Delete(key)
+	}
+}
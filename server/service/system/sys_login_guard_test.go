@@ -0,0 +1,99 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/cache/local_cache"
+	"go.uber.org/zap"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+func Test_lockBackoff(t *testing.T) {
+	base, max := 5*time.Minute, time.Hour
+	tests := []struct {
+		level int64
+		want  time.Duration
+	}{
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{4, 40 * time.Minute},
+		{5, time.Hour},
+		{60, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := lockBackoff(tt.level, base, max); got != tt.want {
+			t.Errorf("lockBackoff(%d) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestLoginGuardService_LockAndUnlock(t *testing.T) {
+	global.GVA_REDIS = nil
+	global.GVA_LOG = zap.NewNop()
+	global.BlackCache = local_cache.NewCache()
+	global.GVA_CONFIG.LoginGuard = config.LoginGuard{
+		MaxFailures:     3,
+		LockDuration:    "1m",
+		MaxLockDuration: "1h",
+		IpMaxFailures:   100,
+		Window:          "15m",
+	}
+	s := &LoginGuardService{}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if locked := s.RecordFailure(ctx, "admin", "127.0.0.1"); locked != 0 {
+			t.Fatalf("第%d次失败不应锁定", i+1)
+		}
+	}
+	if locked := s.RecordFailure(ctx, "admin", "127.0.0.1"); locked != time.Minute {
+		t.Fatalf("达到阈值应锁定1分钟, got %v", locked)
+	}
+	var lockedErr *LoginLockedError
+	if err := s.CheckLocked(ctx, "admin", "127.0.0.2"); !errors.As(err, &lockedErr) || lockedErr.ByIp {
+		t.Fatalf("账号应处于锁定期, got %v", err)
+	}
+	if err := s.CheckLocked(ctx, "other", "127.0.0.1"); err != nil {
+		t.Fatalf("其他账号不应受影响, got %v", err)
+	}
+
+	// Unlock 同时重置退避等级，再次锁定按首次时长计算
+	s.Unlock(ctx, "admin")
+	if err := s.CheckLocked(ctx, "admin", "127.0.0.1"); err != nil {
+		t.Fatalf("解锁后应可登录, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		s.RecordFailure(ctx, "admin", "127.0.0.1")
+	}
+	if remaining := s.LockRemaining(ctx, "admin"); remaining <= 0 || remaining > time.Minute {
+		t.Fatalf("解锁重置等级后应重新按首次时长锁定, got %v", remaining)
+	}
+}
+
+func TestLoginGuardService_Backoff(t *testing.T) {
+	global.GVA_REDIS = nil
+	global.GVA_LOG = zap.NewNop()
+	global.BlackCache = local_cache.NewCache()
+	global.GVA_CONFIG.LoginGuard = config.LoginGuard{MaxFailures: 1, LockDuration: "1m", MaxLockDuration: "3m"}
+	s := &LoginGuardService{}
+	ctx := context.Background()
+
+	want := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute}
+	for i, w := range want {
+		// 锁定期满后再次失败，模拟到期：清除锁定标记但保留等级
+		global.BlackCache.Delete(guardKey(loginLockKey, "user", "admin"))
+		if got := s.RecordFailure(ctx, "admin", ""); got != w {
+			t.Errorf("第%d次锁定时长 = %v, want %v", i+1, got, w)
+		}
+	}
+	s.RecordSuccess(ctx, "admin")
+	global.BlackCache.Delete(guardKey(loginLockKey, "user", "admin"))
+	if got := s.RecordFailure(ctx, "admin", ""); got != time.Minute {
+		t.Errorf("登录成功后应重置退避等级, got %v", got)
+	}
+}
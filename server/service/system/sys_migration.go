@@ -0,0 +1,311 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Oracle 仅用于迁移的数据库类型判断，初始化数据库不支持 Oracle
+const Oracle = "oracle"
+
+// migrationLockName 多实例同时执行迁移时使用的数据库锁名称
+const migrationLockName = "gva_migration"
+
+var (
+	ErrMigrationNotFound     = errors.New("迁移版本不存在")
+	ErrMigrationIrreversible = errors.New("迁移不支持回滚")
+	ErrMigrationLocked       = errors.New("其他实例正在执行迁移，请稍后重试")
+)
+
+// Migration 提供 source/migration 使用的版本化迁移，按 Version 的字符串顺序执行，
+// Version 建议使用 yyyyMMddHHmmss 格式的时间戳。Up/Down 中只能使用传入的 tx 访问数据库
+type Migration struct {
+	Version string
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为空时不可回滚
+}
+
+var migrations map[string]Migration
+
+// RegisterMigration 注册迁移，版本号重复时 panic
+func RegisterMigration(m Migration) {
+	if migrations == nil {
+		migrations = map[string]Migration{}
+	}
+	if m.Version == "" || m.Up == nil {
+		panic(fmt.Sprintf("Invalid migration %s %s", m.Version, m.Name))
+	}
+	if _, existed := migrations[m.Version]; existed {
+		panic(fmt.Sprintf("Version conflict on migration %s", m.Version))
+	}
+	migrations[m.Version] = m
+}
+
+// registeredMigrations 按版本号排序的全部已注册迁移
+func registeredMigrations() []Migration {
+	list := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+// MigrationDialect 返回数据库类型：mysql、pgsql、sqlite、mssql、oracle
+func MigrationDialect(db *gorm.DB) string {
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		return Pgsql
+	case "sqlserver":
+		return Mssql
+	default:
+		return name
+	}
+}
+
+// ExecDialectSQL 执行当前数据库类型对应的 SQL，没有对应类型时使用 key 为空字符串的通用 SQL
+func ExecDialectSQL(tx *gorm.DB, statements map[string][]string) error {
+	dialect := MigrationDialect(tx)
+	list, ok := statements[dialect]
+	if !ok {
+		if list, ok = statements[""]; !ok {
+			return fmt.Errorf("迁移未提供 %s 数据库的 SQL", dialect)
+		}
+	}
+	for _, stmt := range list {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transactionalDDL MySQL 与 Oracle 的 DDL 会隐式提交事务，在这两种数据库上迁移不包裹事务，失败时可能只执行了一部分
+func transactionalDDL(dialect string) bool {
+	return dialect != Mysql && dialect != Oracle
+}
+
+type MigrationService struct{}
+
+var MigrationServiceApp = new(MigrationService)
+
+// migrationMu 同一进程内串行执行迁移，跨实例由 withMigrationLock 中的数据库锁保证
+var migrationMu sync.Mutex
+
+// withMigrationLock 在同一个数据库连接上持有迁移锁后执行 fc，SQLite 与 Oracle 只使用进程内的锁
+func withMigrationLock(ctx context.Context, fc func(conn *gorm.DB) error) error {
+	if global.GVA_DB == nil {
+		return errors.New("数据库未初始化")
+	}
+	migrationMu.Lock()
+	defer migrationMu.Unlock()
+	return global.GVA_DB.WithContext(ctx).Connection(func(pinned *gorm.DB) (err error) {
+		// Connection 返回的实例会累积查询条件，每次查询需要从新的会话开始
+		conn := pinned.Session(&gorm.Session{NewDB: true})
+		var acquired int
+		switch MigrationDialect(conn) {
+		case Mysql:
+			err = conn.Raw("SELECT GET_LOCK(?, 0)", migrationLockName).Scan(&acquired).Error
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", migrationLockName)
+		case Pgsql:
+			var ok bool
+			err = conn.Raw("SELECT pg_try_advisory_lock(hashtext(?))", migrationLockName).Scan(&ok).Error
+			if ok {
+				acquired = 1
+				defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", migrationLockName)
+			}
+		case Mssql:
+			err = conn.Raw("DECLARE @r int; EXEC @r = sp_getapplock @Resource = ?, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = 0; SELECT CASE WHEN @r >= 0 THEN 1 ELSE 0 END", migrationLockName).Scan(&acquired).Error
+			if acquired == 1 {
+				defer conn.Exec("EXEC sp_releaseapplock @Resource = ?, @LockOwner = 'Session'", migrationLockName)
+			}
+		default:
+			acquired = 1
+		}
+		if err != nil {
+			return err
+		}
+		if acquired != 1 {
+			return ErrMigrationLocked
+		}
+		if err = conn.AutoMigrate(&system.SysMigration{}); err != nil {
+			return err
+		}
+		return fc(conn)
+	})
+}
+
+// runMigrationStep 执行一个迁移步骤并更新迁移记录，支持事务性 DDL 的数据库上两者在同一事务中完成
+func runMigrationStep(conn *gorm.DB, step func(tx *gorm.DB) error, record func(tx *gorm.DB) error) error {
+	if transactionalDDL(MigrationDialect(conn)) {
+		return conn.Transaction(func(tx *gorm.DB) error {
+			if err := step(tx); err != nil {
+				return err
+			}
+			return record(tx)
+		})
+	}
+	if err := step(conn); err != nil {
+		return err
+	}
+	return record(conn)
+}
+
+func appliedMigrations(db *gorm.DB) (map[string]system.SysMigration, error) {
+	var records []system.SysMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]system.SysMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+//@function: MigrationStatus
+//@description: 获取全部迁移的执行状态，按版本号排序
+//@return: list []systemRes.MigrationStatus, err error
+
+func (migrationService *MigrationService) MigrationStatus(ctx context.Context) (list []systemRes.MigrationStatus, err error) {
+	if global.GVA_DB == nil {
+		return nil, errors.New("数据库未初始化")
+	}
+	db := global.GVA_DB.WithContext(ctx)
+	applied := map[string]system.SysMigration{}
+	if db.Migrator().HasTable(&system.SysMigration{}) {
+		if applied, err = appliedMigrations(db); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range registeredMigrations() {
+		item := systemRes.MigrationStatus{Version: m.Version, Name: m.Name, Status: systemRes.MigrationPending, Reversible: m.Down != nil}
+		if r, ok := applied[m.Version]; ok {
+			item.Status, item.Batch, item.AppliedAt = systemRes.MigrationApplied, r.Batch, &r.AppliedAt
+			delete(applied, m.Version)
+		}
+		list = append(list, item)
+	}
+	for _, r := range applied {
+		list = append(list, systemRes.MigrationStatus{Version: r.Version, Name: r.Name, Status: systemRes.MigrationMissing, Batch: r.Batch, AppliedAt: &r.AppliedAt})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+//@function: Migrate
+//@description: 按版本号顺序执行待执行的迁移，target 不为空时只执行到该版本（含），同一次执行的迁移记录为同一批次；出错时停止并返回已执行的版本
+//@param: target string
+//@return: applied []string, err error
+
+func (migrationService *MigrationService) Migrate(ctx context.Context, target string) (applied []string, err error) {
+	if target != "" {
+		if _, ok := migrations[target]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationNotFound, target)
+		}
+	}
+	err = withMigrationLock(ctx, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		var batch int
+		if err = conn.Model(&system.SysMigration{}).Select("COALESCE(MAX(batch), 0)").Scan(&batch).Error; err != nil {
+			return err
+		}
+		batch++
+		for _, m := range registeredMigrations() {
+			if target != "" && m.Version > target {
+				break
+			}
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			record := system.SysMigration{Version: m.Version, Name: m.Name, Batch: batch}
+			err = runMigrationStep(conn, m.Up, func(tx *gorm.DB) error {
+				record.AppliedAt = time.Now()
+				return tx.Create(&record).Error
+			})
+			if err != nil {
+				return fmt.Errorf("迁移 %s 执行失败: %w", m.Version, err)
+			}
+			global.GVA_LOG.Info("migration applied", zap.String("version", m.Version), zap.String("name", m.Name), zap.Int("batch", batch))
+			applied = append(applied, m.Version)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+//@function: RollbackMigrations
+//@description: 按执行顺序倒序回滚迁移，target 不为空时回滚该版本之后（不含）的全部迁移，否则回滚最近执行的 steps 个迁移；执行前检查全部迁移均可回滚
+//@param: steps int, target string
+//@return: rolledBack []string, err error
+
+func (migrationService *MigrationService) RollbackMigrations(ctx context.Context, steps int, target string) (rolledBack []string, err error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	err = withMigrationLock(ctx, func(conn *gorm.DB) error {
+		var records []system.SysMigration
+		db := conn.Order("batch desc, id desc")
+		if target != "" {
+			db = db.Where("version > ?", target)
+		} else {
+			db = db.Limit(steps)
+		}
+		if err := db.Find(&records).Error; err != nil {
+			return err
+		}
+		for _, r := range records {
+			m, ok := migrations[r.Version]
+			if !ok {
+				return fmt.Errorf("%w: %s 不在当前程序中", ErrMigrationNotFound, r.Version)
+			}
+			if m.Down == nil {
+				return fmt.Errorf("%w: %s", ErrMigrationIrreversible, r.Version)
+			}
+		}
+		for _, r := range records {
+			m := migrations[r.Version]
+			err := runMigrationStep(conn, m.Down, func(tx *gorm.DB) error {
+				return tx.Delete(&system.SysMigration{}, r.ID).Error
+			})
+			if err != nil {
+				return fmt.Errorf("迁移 %s 回滚失败: %w", r.Version, err)
+			}
+			global.GVA_LOG.Info("migration rolled back", zap.String("version", r.Version), zap.String("name", r.Name))
+			rolledBack = append(rolledBack, r.Version)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// baselineMigrations 将全部已注册的迁移记录为已执行但不执行，用于初始化数据库后，此时初始数据已是最新
+func baselineMigrations(db *gorm.DB) error {
+	if err := db.AutoMigrate(&system.SysMigration{}); err != nil {
+		return err
+	}
+	list := registeredMigrations()
+	if len(list) == 0 {
+		return nil
+	}
+	now := time.Now()
+	records := make([]system.SysMigration, 0, len(list))
+	for _, m := range list {
+		records = append(records, system.SysMigration{Version: m.Version, Name: m.Name, Batch: 1, AppliedAt: now})
+	}
+	return db.Create(&records).Error
+}
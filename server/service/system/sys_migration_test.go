@@ -0,0 +1,146 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"gorm.io/gorm"
+)
+
+var errMigrationTest = errors.New("boom")
+
+func init() {
+	RegisterMigration(Migration{
+		Version: "test-001",
+		Name:    "create widgets",
+		Up: func(tx *gorm.DB) error {
+			return ExecDialectSQL(tx, map[string][]string{
+				Sqlite: {"CREATE TABLE widgets (id integer primary key, name text)"},
+				"":     {"CREATE TABLE widgets (id int primary key, name varchar(64))"},
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("widgets")
+		},
+	})
+	RegisterMigration(Migration{
+		Version: "test-002",
+		Name:    "seed widgets",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO widgets (id, name) VALUES (1, 'a')").Error
+		},
+	})
+	RegisterMigration(Migration{
+		Version: "test-003",
+		Name:    "rename widget",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE widgets SET name = 'b' WHERE id = 1").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE widgets SET name = 'a' WHERE id = 1").Error
+		},
+	})
+	RegisterMigration(Migration{
+		Version: "test-004",
+		Name:    "broken",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE gadgets (id integer)").Error; err != nil {
+				return err
+			}
+			return errMigrationTest
+		},
+	})
+}
+
+func setupMigrations(t *testing.T) *gorm.DB {
+	t.Helper()
+	return globaltest.DB(t)
+}
+
+func migrationStatuses(t *testing.T) map[string]systemRes.MigrationStatus {
+	t.Helper()
+	list, err := MigrationServiceApp.MigrationStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]systemRes.MigrationStatus, len(list))
+	for _, item := range list {
+		out[item.Version] = item
+	}
+	return out
+}
+
+func TestMigration_UpStatusDown(t *testing.T) {
+	db := setupMigrations(t)
+	ctx := context.Background()
+
+	if status := migrationStatuses(t); len(status) != 4 || status["test-001"].Status != systemRes.MigrationPending || status["test-002"].Reversible {
+		t.Fatalf("initial status = %+v", status)
+	}
+	if _, err := MigrationServiceApp.Migrate(ctx, "test-999"); !errors.Is(err, ErrMigrationNotFound) {
+		t.Fatalf("unknown target err = %v", err)
+	}
+
+	applied, err := MigrationServiceApp.Migrate(ctx, "test-002")
+	if err != nil || !reflect.DeepEqual(applied, []string{"test-001", "test-002"}) {
+		t.Fatalf("applied = %v, err = %v", applied, err)
+	}
+	// 失败的迁移在事务中回滚，之前成功的迁移保留
+	applied, err = MigrationServiceApp.Migrate(ctx, "")
+	if !errors.Is(err, errMigrationTest) || !reflect.DeepEqual(applied, []string{"test-003"}) {
+		t.Fatalf("applied = %v, err = %v", applied, err)
+	}
+	if db.Migrator().HasTable("gadgets") {
+		t.Fatal("failed migration was not rolled back")
+	}
+	status := migrationStatuses(t)
+	if status["test-002"].Batch != 1 || status["test-003"].Batch != 2 || status["test-004"].Status != systemRes.MigrationPending {
+		t.Fatalf("status = %+v", status)
+	}
+
+	rolledBack, err := MigrationServiceApp.RollbackMigrations(ctx, 1, "")
+	if err != nil || !reflect.DeepEqual(rolledBack, []string{"test-003"}) {
+		t.Fatalf("rolledBack = %v, err = %v", rolledBack, err)
+	}
+	var name string
+	db.Raw("SELECT name FROM widgets WHERE id = 1").Scan(&name)
+	if name != "a" {
+		t.Fatalf("widget name = %q", name)
+	}
+	// 不可回滚的迁移阻止整个回滚，已检查的迁移不会被执行
+	if _, err = MigrationServiceApp.RollbackMigrations(ctx, 0, "test-000"); !errors.Is(err, ErrMigrationIrreversible) {
+		t.Fatalf("irreversible err = %v", err)
+	}
+	if !db.Migrator().HasTable("widgets") || migrationStatuses(t)["test-001"].Status != systemRes.MigrationApplied {
+		t.Fatal("rollback partially executed")
+	}
+
+	db.Create(&system.SysMigration{Version: "test-0025", Name: "removed", Batch: 3})
+	if status = migrationStatuses(t); status["test-0025"].Status != systemRes.MigrationMissing {
+		t.Fatalf("missing status = %+v", status["test-0025"])
+	}
+	if _, err = MigrationServiceApp.RollbackMigrations(ctx, 1, ""); !errors.Is(err, ErrMigrationNotFound) {
+		t.Fatalf("missing rollback err = %v", err)
+	}
+}
+
+func TestMigration_Baseline(t *testing.T) {
+	db := setupMigrations(t)
+	if err := baselineMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	for version, item := range migrationStatuses(t) {
+		if item.Status != systemRes.MigrationApplied {
+			t.Fatalf("%s = %+v", version, item)
+		}
+	}
+	applied, err := MigrationServiceApp.Migrate(context.Background(), "")
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("applied = %v, err = %v", applied, err)
+	}
+}
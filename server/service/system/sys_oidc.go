@@ -0,0 +1,361 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/oidc"
+	"gorm.io/gorm"
+)
+
+const (
+	// oidcStateKey 登录请求状态：oidc_state:<state>，值为 oidcState，一次性使用
+	oidcStateKey = "oidc_state"
+	// oidcStateTTL 从跳转身份提供方到回调的最长时长
+	oidcStateTTL = 10 * time.Minute
+
+	OidcPurposeLogin = "login" // 单点登录
+	OidcPurposeBind  = "bind"  // 已登录用户绑定外部账号
+)
+
+var (
+	ErrOidcDisabled      = errors.New("未开启单点登录")
+	ErrOidcStateInvalid  = errors.New("登录请求已过期，请重新发起单点登录")
+	ErrOidcUserNotLinked = errors.New("该账号尚未关联系统用户，请联系管理员或登录后在个人中心绑定")
+	ErrOidcNoAuthority   = errors.New("该账号未分配可用角色，请联系管理员")
+	ErrOidcAlreadyLinked = errors.New("该外部账号已绑定其他用户")
+)
+
+// oidcState 发起授权时保存的请求上下文
+type oidcState struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Purpose  string `json:"purpose"`
+	UserId   uint   `json:"userId"`
+}
+
+type OidcService struct{}
+
+var OidcServiceApp = new(OidcService)
+
+// oidcProviderCache discovery 结果缓存，issuer 变更后重新获取
+var oidcProviderCache struct {
+	sync.Mutex
+	provider *oidc.Provider
+	issuer   string
+}
+
+// AuthorizeURL 生成跳转身份提供方的授权地址，purpose 为 bind 时 userId 为当前登录用户
+func (oidcService *OidcService) AuthorizeURL(ctx context.Context, purpose string, userId uint) (string, error) {
+	provider, err := oidcService.getProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	state, err := oidc.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+	data, _ := json.Marshal(oidcState{Nonce: nonce, Verifier: verifier, Purpose: purpose, UserId: userId})
+	if err = saveOidcState(ctx, state, string(data)); err != nil {
+		return "", err
+	}
+	return provider.AuthCodeURL(state, nonce, challenge), nil
+}
+
+// Callback 处理单点登录回调：校验 state、以授权码换取并校验 ID Token，然后查找或创建用户
+func (oidcService *OidcService) Callback(ctx context.Context, code, state string) (*system.SysUser, error) {
+	provider, claims, _, err := oidcService.exchange(ctx, code, state, OidcPurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+	return oidcService.ResolveUser(ctx, provider.Issuer, claims)
+}
+
+// BindCallback 处理绑定回调，只能由发起绑定的登录用户完成，防止他人诱导回调把外部账号绑定到受害者
+func (oidcService *OidcService) BindCallback(ctx context.Context, userId uint, code, state string) error {
+	provider, claims, st, err := oidcService.exchange(ctx, code, state, OidcPurposeBind)
+	if err != nil {
+		return err
+	}
+	if st.UserId == 0 || st.UserId != userId {
+		return ErrOidcStateInvalid
+	}
+	return oidcService.Link(userId, provider.Issuer, claims)
+}
+
+// exchange 取出一次性的 state 并核对用途，以授权码换取并校验 ID Token
+func (oidcService *OidcService) exchange(ctx context.Context, code, state, purpose string) (*oidc.Provider, oidc.Claims, oidcState, error) {
+	var st oidcState
+	provider, err := oidcService.getProvider(ctx)
+	if err != nil {
+		return nil, nil, st, err
+	}
+	raw, ok := takeOidcState(ctx, state)
+	if !ok || code == "" {
+		return nil, nil, st, ErrOidcStateInvalid
+	}
+	if err = json.Unmarshal([]byte(raw), &st); err != nil || st.Purpose != purpose {
+		return nil, nil, st, ErrOidcStateInvalid
+	}
+	token, err := provider.Exchange(ctx, code, st.Verifier)
+	if err != nil {
+		return nil, nil, st, err
+	}
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, st.Nonce)
+	if err != nil {
+		return nil, nil, st, err
+	}
+	return provider, claims, st, nil
+}
+
+// ResolveUser 根据外部账号查找或创建系统用户：已绑定 -> 按已验证邮箱关联 -> 自动创建
+func (oidcService *OidcService) ResolveUser(ctx context.Context, issuer string, claims oidc.Claims) (*system.SysUser, error) {
+	cfg := global.GVA_CONFIG.Oidc
+	subject := claims.String("sub")
+	authorityIds := mapOidcAuthorities(claims)
+
+	var link system.SysUserOidc
+	err := global.GVA_DB.Where("issuer = ? AND subject = ?", issuer, subject).First(&link).Error
+	switch {
+	case err == nil:
+		if cfg.SyncRoles && len(authorityIds) > 0 {
+			if err = syncUserAuthorities(ctx, link.UserId, authorityIds); err != nil {
+				return nil, err
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		userId, err := oidcService.firstLogin(ctx, issuer, claims, authorityIds)
+		if err != nil {
+			return nil, err
+		}
+		link = system.SysUserOidc{UserId: userId}
+	default:
+		return nil, err
+	}
+
+	err = global.GVA_DB.Model(&system.SysUserOidc{}).Where("issuer = ? AND subject = ?", issuer, subject).
+		Updates(map[string]interface{}{"last_login_at": time.Now(), "email": claims.String("email")}).Error
+	if err != nil {
+		return nil, err
+	}
+	return UserServiceApp.GetLoginUser(link.UserId)
+}
+
+// firstLogin 外部账号首次登录，关联已有用户或自动创建
+func (oidcService *OidcService) firstLogin(ctx context.Context, issuer string, claims oidc.Claims, authorityIds []uint) (uint, error) {
+	cfg := global.GVA_CONFIG.Oidc
+	email := claims.String("email")
+	if cfg.LinkByEmail && email != "" && claims.Bool("email_verified") {
+		var users []system.SysUser
+		if err := global.GVA_DB.Select("id").Where("email = ?", email).Limit(2).Find(&users).Error; err != nil {
+			return 0, err
+		}
+		// 同一邮箱对应多个用户时无法确定归属，不自动关联
+		if len(users) == 1 {
+			if err := oidcService.Link(users[0].ID, issuer, claims); err != nil {
+				return 0, err
+			}
+			if cfg.SyncRoles && len(authorityIds) > 0 {
+				if err := syncUserAuthorities(ctx, users[0].ID, authorityIds); err != nil {
+					return 0, err
+				}
+			}
+			return users[0].ID, nil
+		}
+	}
+	if !cfg.AutoProvision {
+		return 0, ErrOidcUserNotLinked
+	}
+	if len(authorityIds) == 0 {
+		return 0, ErrOidcNoAuthority
+	}
+	username := claims.String(oidcUsernameClaim())
+	if username == "" {
+		username = claims.String("sub")
+	}
+	// 本地已存在同名用户时不自动接管，避免外部账号冒用本地账号
+	var count int64
+	if err := global.GVA_DB.Model(&system.SysUser{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, ErrOidcUserNotLinked
+	}
+	// 单点登录用户使用随机密码，不可通过密码登录
+	password, err := utils.GeneratePassword(global.GVA_CONFIG.PasswordPolicy)
+	if err != nil {
+		return 0, err
+	}
+	nickName := claims.String("name")
+	if nickName == "" {
+		nickName = username
+	}
+	authorities := make([]system.SysAuthority, 0, len(authorityIds))
+	for _, id := range authorityIds {
+		authorities = append(authorities, system.SysAuthority{AuthorityId: id})
+	}
+	user, err := UserServiceApp.Register(system.SysUser{
+		Username:    username,
+		NickName:    nickName,
+		Password:    password,
+		Email:       email,
+		AuthorityId: authorityIds[0],
+		Authorities: authorities,
+		Enable:      1,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("创建单点登录用户失败: %w", err)
+	}
+	if err = oidcService.Link(user.ID, issuer, claims); err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// Link 将外部账号绑定到用户
+func (oidcService *OidcService) Link(userId uint, issuer string, claims oidc.Claims) error {
+	if userId == 0 {
+		return ErrOidcStateInvalid
+	}
+	subject := claims.String("sub")
+	var existing system.SysUserOidc
+	err := global.GVA_DB.Where("issuer = ? AND subject = ?", issuer, subject).First(&existing).Error
+	if err == nil {
+		if existing.UserId == userId {
+			return nil
+		}
+		return ErrOidcAlreadyLinked
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return global.GVA_DB.Create(&system.SysUserOidc{
+		UserId:  userId,
+		Issuer:  issuer,
+		Subject: subject,
+		Email:   claims.String("email"),
+	}).Error
+}
+
+// GetBindings 获取用户绑定的外部账号
+func (oidcService *OidcService) GetBindings(userId uint) (list []system.SysUserOidc, err error) {
+	err = global.GVA_DB.Where("user_id = ?", userId).Order("id").Find(&list).Error
+	return list, err
+}
+
+// Unbind 解除绑定，物理删除以便之后重新绑定
+func (oidcService *OidcService) Unbind(userId, id uint) error {
+	result := global.GVA_DB.Unscoped().Where("id = ? AND user_id = ?", id, userId).Delete(&system.SysUserOidc{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("绑定不存在")
+	}
+	return nil
+}
+
+// getProvider 按配置懒加载身份提供方，issuer 变更后重新 discovery
+func (oidcService *OidcService) getProvider(ctx context.Context) (*oidc.Provider, error) {
+	cfg := global.GVA_CONFIG.Oidc
+	if !cfg.Enable {
+		return nil, ErrOidcDisabled
+	}
+	oidcProviderCache.Lock()
+	defer oidcProviderCache.Unlock()
+	if oidcProviderCache.provider != nil && oidcProviderCache.issuer == cfg.Issuer {
+		return oidcProviderCache.provider, nil
+	}
+	provider, err := oidc.Discover(ctx, oidc.Config{
+		Issuer:       cfg.Issuer,
+		ClientId:     cfg.ClientId,
+		ClientSecret: cfg.ClientSecret,
+		RedirectUrl:  cfg.RedirectUrl,
+		Scopes:       cfg.Scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	oidcProviderCache.provider, oidcProviderCache.issuer = provider, cfg.Issuer
+	return provider, nil
+}
+
+// mapOidcAuthorities 按配置将角色声明映射为角色ID，未匹配时使用默认角色
+func mapOidcAuthorities(claims oidc.Claims) []uint {
+	cfg := global.GVA_CONFIG.Oidc
+	rolesClaim := cfg.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "groups"
+	}
+	roles := make(map[string]struct{})
+	for _, role := range claims.Strings(rolesClaim) {
+		roles[role] = struct{}{}
+	}
+	var ids []uint
+	seen := make(map[uint]struct{})
+	for _, m := range cfg.RoleMapping {
+		if _, ok := roles[m.Claim]; !ok || m.AuthorityId == 0 {
+			continue
+		}
+		if _, dup := seen[m.AuthorityId]; dup {
+			continue
+		}
+		seen[m.AuthorityId] = struct{}{}
+		ids = append(ids, m.AuthorityId)
+	}
+	if len(ids) == 0 && cfg.DefaultAuthorityId != 0 {
+		ids = append(ids, cfg.DefaultAuthorityId)
+	}
+	return ids
+}
+
+func oidcUsernameClaim() string {
+	if claim := global.GVA_CONFIG.Oidc.UsernameClaim; claim != "" {
+		return claim
+	}
+	return "preferred_username"
+}
+
+// saveOidcState 启用 Redis 时多实例共享，否则保存在进程内缓存
+func saveOidcState(ctx context.Context, state, value string) error {
+	key := oidcStateKey + ":" + state
+	if global.GVA_REDIS != nil {
+		return global.GVA_REDIS.Set(ctx, key, value, oidcStateTTL).Err()
+	}
+	global.BlackCache.Set(key, value, oidcStateTTL)
+	return nil
+}
+
+// takeOidcState 读取并删除 state，保证每个授权请求只能回调一次
+func takeOidcState(ctx context.Context, state string) (string, bool) {
+	if state == "" {
+		return "", false
+	}
+	key := oidcStateKey + ":" + state
+	if global.GVA_REDIS != nil {
+		value, err := global.GVA_REDIS.GetDel(ctx, key).Result()
+		return value, err == nil
+	}
+	v, ok := global.BlackCache.Get(key)
+	if !ok {
+		return "", false
+	}
+	global.BlackCache.Delete(key)
+	value, ok := v.(string)
+	return value, ok
+}
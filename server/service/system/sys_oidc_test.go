@@ -0,0 +1,186 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/oidc/mockidp"
+)
+
+func setupOidc(t *testing.T) *mockidp.IdP {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysUser{}, &system.SysAuthority{}, &system.SysUserOidc{}, &system.SysPasswordHistory{})
+	db.Create(&[]system.SysAuthority{{AuthorityId: 888, AuthorityName: "admin"}, {AuthorityId: 9528, AuthorityName: "viewer"}})
+
+	idp, err := mockidp.New("gva-console", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+	global.GVA_CONFIG.PasswordPolicy = config.PasswordPolicy{MinLength: 8, MinCharClasses: 3}
+	global.GVA_CONFIG.Oidc = config.Oidc{
+		Enable:       true,
+		Issuer:       idp.Issuer(),
+		ClientId:     "gva-console",
+		ClientSecret: "s3cret",
+		RedirectUrl:  "http://console.local/oidc/callback",
+		RoleMapping: []config.OidcRoleMapping{
+			{Claim: "gva-admin", AuthorityId: 888},
+			{Claim: "gva-viewer", AuthorityId: 9528},
+		},
+		AutoProvision: true,
+		SyncRoles:     true,
+	}
+	return idp
+}
+
+// oidcAuthorize 获取授权地址并模拟用户在身份提供方登录，返回授权码与 state
+func oidcAuthorize(t *testing.T, idp *mockidp.IdP, purpose string, userId uint, user mockidp.User) (code, state string) {
+	t.Helper()
+	authURL, err := OidcServiceApp.AuthorizeURL(context.Background(), purpose, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err = idp.Authorize(authURL, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, state
+}
+
+// oidcLogin 走完整的单点登录授权码流程
+func oidcLogin(t *testing.T, idp *mockidp.IdP, user mockidp.User) (*system.SysUser, error) {
+	t.Helper()
+	code, state := oidcAuthorize(t, idp, OidcPurposeLogin, 0, user)
+	return OidcServiceApp.Callback(context.Background(), code, state)
+}
+
+// oidcBind 已登录用户走完整的绑定流程
+func oidcBind(t *testing.T, idp *mockidp.IdP, userId uint, user mockidp.User) error {
+	t.Helper()
+	code, state := oidcAuthorize(t, idp, OidcPurposeBind, userId, user)
+	return OidcServiceApp.BindCallback(context.Background(), userId, code, state)
+}
+
+func TestOidcService_ProvisionAndRoleSync(t *testing.T) {
+	idp := setupOidc(t)
+	alice := mockidp.User{Subject: "sub-alice", Username: "alice", Name: "Alice", Email: "alice@corp.example", Groups: []string{"gva-viewer"}}
+
+	user, err := oidcLogin(t, idp, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alice" || user.NickName != "Alice" || user.AuthorityId != 9528 {
+		t.Fatalf("自动创建的用户不符合预期: %+v", user)
+	}
+
+	// 角色未变化时不影响已签发的令牌
+	globaltest.Redis(t)
+	ctx := context.Background()
+	if _, err = oidcLogin(t, idp, alice); err != nil {
+		t.Fatal(err)
+	}
+	if v := UserSessionServiceApp.SessionVersion(ctx, user.UUID); v != 0 {
+		t.Fatalf("角色未变化时会话版本 = %d, want 0", v)
+	}
+
+	// 身份提供方中角色变更后再次登录，角色随之同步，且不会重复创建用户
+	alice.Groups = []string{"gva-admin", "gva-viewer"}
+	synced, err := oidcLogin(t, idp, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced.ID != user.ID || synced.AuthorityId != 9528 || len(synced.Authorities) != 2 {
+		t.Fatalf("角色同步结果不符合预期: authorityId=%d authorities=%d", synced.AuthorityId, len(synced.Authorities))
+	}
+	// 角色变化后递增会话版本，按旧角色签发的访问令牌失效
+	if v := UserSessionServiceApp.SessionVersion(ctx, user.UUID); v != 1 {
+		t.Fatalf("角色同步后会话版本 = %d, want 1", v)
+	}
+	var count int64
+	global.GVA_DB.Model(&system.SysUser{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("用户数 = %d, want 1", count)
+	}
+
+	// 未映射到任何角色且无默认角色时拒绝创建
+	_, err = oidcLogin(t, idp, mockidp.User{Subject: "sub-bob", Username: "bob"})
+	if !errors.Is(err, ErrOidcNoAuthority) {
+		t.Fatalf("无角色映射应返回 ErrOidcNoAuthority, got %v", err)
+	}
+}
+
+func TestOidcService_LinkExistingUser(t *testing.T) {
+	idp := setupOidc(t)
+	global.GVA_CONFIG.Oidc.AutoProvision = false
+	local, err := UserServiceApp.Register(system.SysUser{Username: "carol", Password: "Local#Pass1", Email: "carol@corp.example", AuthorityId: 888})
+	if err != nil {
+		t.Fatal(err)
+	}
+	carol := mockidp.User{Subject: "sub-carol", Username: "carol", Email: "carol@corp.example", EmailVerified: true}
+
+	// 同名本地用户不会被自动接管
+	if _, err = oidcLogin(t, idp, carol); !errors.Is(err, ErrOidcUserNotLinked) {
+		t.Fatalf("未绑定时应返回 ErrOidcUserNotLinked, got %v", err)
+	}
+
+	// 绑定回调只能由发起绑定的用户本人完成，也不能通过公开的登录回调完成
+	other, _ := UserServiceApp.Register(system.SysUser{Username: "dave", Password: "Local#Pass2", AuthorityId: 888})
+	code, state := oidcAuthorize(t, idp, OidcPurposeBind, local.ID, carol)
+	if err = OidcServiceApp.BindCallback(context.Background(), other.ID, code, state); !errors.Is(err, ErrOidcStateInvalid) {
+		t.Fatalf("其他用户完成绑定应被拒绝, got %v", err)
+	}
+	code, state = oidcAuthorize(t, idp, OidcPurposeBind, local.ID, carol)
+	if _, err = OidcServiceApp.Callback(context.Background(), code, state); !errors.Is(err, ErrOidcStateInvalid) {
+		t.Fatalf("登录回调不能完成绑定, got %v", err)
+	}
+
+	// 已登录用户主动绑定后即可单点登录
+	if err = oidcBind(t, idp, local.ID, carol); err != nil {
+		t.Fatal(err)
+	}
+	user, err := oidcLogin(t, idp, carol)
+	if err != nil || user.ID != local.ID {
+		t.Fatalf("绑定后应登录为本地用户, user=%v err=%v", user, err)
+	}
+
+	// 同一外部账号不能绑定到其他用户
+	if err = oidcBind(t, idp, other.ID, carol); !errors.Is(err, ErrOidcAlreadyLinked) {
+		t.Fatalf("重复绑定应返回 ErrOidcAlreadyLinked, got %v", err)
+	}
+
+	// 按已验证邮箱自动关联
+	global.GVA_CONFIG.Oidc.LinkByEmail = true
+	erin, _ := UserServiceApp.Register(system.SysUser{Username: "erin", Password: "Local#Pass3", Email: "erin@corp.example", AuthorityId: 888})
+	user, err = oidcLogin(t, idp, mockidp.User{Subject: "sub-erin", Username: "erin.x", Email: "erin@corp.example", EmailVerified: true})
+	if err != nil || user.ID != erin.ID {
+		t.Fatalf("应按邮箱关联已有用户, user=%v err=%v", user, err)
+	}
+}
+
+func TestOidcService_StateIsSingleUse(t *testing.T) {
+	idp := setupOidc(t)
+	s := OidcServiceApp
+	ctx := context.Background()
+	authURL, err := s.AuthorizeURL(ctx, OidcPurposeLogin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := mockidp.User{Subject: "sub-f", Username: "frank", Groups: []string{"gva-admin"}}
+	code, state, _ := idp.Authorize(authURL, user)
+	if _, err = s.Callback(ctx, code, "forged-state"); !errors.Is(err, ErrOidcStateInvalid) {
+		t.Fatalf("伪造的 state 应被拒绝, got %v", err)
+	}
+	if _, err = s.Callback(ctx, code, state); err != nil {
+		t.Fatal(err)
+	}
+	code, _, _ = idp.Authorize(authURL, user)
+	if _, err = s.Callback(ctx, code, state); !errors.Is(err, ErrOidcStateInvalid) {
+		t.Fatalf("state 重复使用应被拒绝, got %v", err)
+	}
+}
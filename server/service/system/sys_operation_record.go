@@ -1,21 +1,142 @@
 package system
 
 import (
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+type OperationRecordService struct{}
+
+var OperationRecordServiceApp = new(OperationRecordService)
+
+// OperationRecordFulltextIndex MySQL 下操作记录请求与响应内容的全文索引
+const OperationRecordFulltextIndex = "idx_operation_record_fulltext"
+
+const (
+	// defaultOperationQueueSize 未配置时异步写入队列的长度
+	defaultOperationQueueSize = 1024
+	// operationBatchSize 异步写入时单次批量插入的最大条数
+	operationBatchSize = 100
+	// operationFlushInterval 异步写入队列未满一批时的最长等待时间
+	operationFlushInterval = time.Second
 )
 
+// likeEscaper 转义 LIKE 中的通配符，配合 ESCAPE '!' 使用
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+var operationQueue struct {
+	once    sync.Once
+	records chan system.SysOperationRecord
+	flush   chan chan struct{}
+}
+
+var operationFulltext struct {
+	once sync.Once
+	ok   bool
+}
+
 //@author: [granty1](https://github.com/granty1)
 //@function: CreateSysOperationRecord
-//@description: 创建记录
+//@description: 创建记录，开启异步写入时进入队列批量写入，队列已满时同步写入
 //@param: sysOperationRecord model.SysOperationRecord
-//@return: err error
 
-type OperationRecordService struct{}
+func (operationRecordService *OperationRecordService) CreateSysOperationRecord(sysOperationRecord system.SysOperationRecord) {
+	if global.GVA_CONFIG.OperationRecord.Async {
+		operationQueue.once.Do(operationRecordService.startQueue)
+		select {
+		case operationQueue.records <- sysOperationRecord:
+			return
+		default:
+		}
+	}
+	if err := global.GVA_DB.Omit(clause.Associations).Create(&sysOperationRecord).Error; err != nil {
+		global.GVA_LOG.Error("create operation record error:", zap.Error(err))
+	}
+}
 
-var OperationRecordServiceApp = new(OperationRecordService)
+// FlushOperationRecords 写入异步队列中尚未保存的记录，服务关闭前调用
+func (operationRecordService *OperationRecordService) FlushOperationRecords(timeout time.Duration) {
+	if !global.GVA_CONFIG.OperationRecord.Async {
+		return
+	}
+	operationQueue.once.Do(operationRecordService.startQueue)
+	done := make(chan struct{})
+	select {
+	case operationQueue.flush <- done:
+	case <-time.After(timeout):
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+func (operationRecordService *OperationRecordService) startQueue() {
+	size := global.GVA_CONFIG.OperationRecord.QueueSize
+	if size <= 0 {
+		size = defaultOperationQueueSize
+	}
+	operationQueue.records = make(chan system.SysOperationRecord, size)
+	operationQueue.flush = make(chan chan struct{})
+	go func() {
+		batch := make([]system.SysOperationRecord, 0, operationBatchSize)
+		write := func() {
+			if len(batch) == 0 {
+				return
+			}
+			if err := global.GVA_DB.Omit(clause.Associations).CreateInBatches(&batch, operationBatchSize).Error; err != nil {
+				global.GVA_LOG.Error("create operation record error:", zap.Error(err), zap.Int("count", len(batch)))
+			}
+			batch = batch[:0]
+		}
+		ticker := time.NewTicker(operationFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case record := <-operationQueue.records:
+				if batch = append(batch, record); len(batch) >= operationBatchSize {
+					write()
+				}
+			case <-ticker.C:
+				write()
+			case done := <-operationQueue.flush:
+				for drained := false; !drained; {
+					select {
+					case record := <-operationQueue.records:
+						if batch = append(batch, record); len(batch) >= operationBatchSize {
+							write()
+						}
+					default:
+						drained = true
+					}
+				}
+				write()
+				close(done)
+			}
+		}
+	}()
+}
+
+// hasFulltextIndex MySQL 且已建立全文索引时使用 MATCH 搜索，其他数据库使用 LIKE
+func (operationRecordService *OperationRecordService) hasFulltextIndex() bool {
+	if global.GVA_DB == nil || global.GVA_DB.Dialector.Name() != "mysql" {
+		return false
+	}
+	operationFulltext.once.Do(func() {
+		operationFulltext.ok = global.GVA_DB.Migrator().HasIndex(&system.SysOperationRecord{}, OperationRecordFulltextIndex)
+	})
+	return operationFulltext.ok
+}
 
 //@author: [granty1](https://github.com/granty1)
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -74,6 +195,27 @@ func (operationRecordService *OperationRecordService) GetSysOperationRecordInfoL
 	if info.Status != 0 {
 		db = db.Where("status = ?", info.Status)
 	}
+	if info.UserID != 0 {
+		db = db.Where("user_id = ?", info.UserID)
+	}
+	if info.Ip != "" {
+		db = db.Where("ip = ?", info.Ip)
+	}
+	if info.StartCreatedAt != nil {
+		db = db.Where("created_at >= ?", info.StartCreatedAt)
+	}
+	if info.EndCreatedAt != nil {
+		db = db.Where("created_at <= ?", info.EndCreatedAt)
+	}
+	if keyword := strings.TrimSpace(info.Keyword); keyword != "" {
+		if utf8.RuneCountInString(keyword) >= 2 && operationRecordService.hasFulltextIndex() {
+			// ngram 全文索引按短语匹配
+			db = db.Where("MATCH(body, resp) AGAINST (? IN BOOLEAN MODE)", `"`+strings.ReplaceAll(keyword, `"`, "")+`"`)
+		} else {
+			like := "%" + likeEscaper.Replace(keyword) + "%"
+			db = db.Where("(body LIKE ? ESCAPE '!' OR resp LIKE ? ESCAPE '!')", like, like)
+		}
+	}
 	err = db.Count(&total).Error
 	if err != nil {
 		return
@@ -81,3 +223,21 @@ func (operationRecordService *OperationRecordService) GetSysOperationRecordInfoL
 	err = db.Order("id desc").Limit(limit).Offset(offset).Preload("User").Find(&sysOperationRecords).Error
 	return sysOperationRecords, total, err
 }
+
+// GetOperationArchiveList 分页获取操作记录归档文件
+func (operationRecordService *OperationRecordService) GetOperationArchiveList(info request.PageInfo) (list []system.SysOperationArchive, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := global.GVA_DB.Model(&system.SysOperationArchive{})
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id desc").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// GetOperationArchive 根据id获取操作记录归档文件
+func (operationRecordService *OperationRecordService) GetOperationArchive(id uint) (archive system.SysOperationArchive, err error) {
+	err = global.GVA_DB.First(&archive, id).Error
+	return
+}
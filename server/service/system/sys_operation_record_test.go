@@ -0,0 +1,77 @@
+package system
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+)
+
+func setupOperationRecord(t *testing.T) {
+	t.Helper()
+	globaltest.DB(t, &system.SysOperationRecord{}, &system.SysUser{})
+}
+
+func TestOperationRecordService_Search(t *testing.T) {
+	setupOperationRecord(t)
+	now := time.Now()
+	records := []system.SysOperationRecord{
+		{Ip: "10.0.0.1", Method: "POST", Path: "/user/admin_register", UserID: 1, Body: `{"userName":"alice"}`, Resp: `{"code":0}`},
+		{Ip: "10.0.0.2", Method: "PUT", Path: "/user/setUserInfo", UserID: 2, Body: `{"nickName":"100%"}`, Resp: `{"code":7,"msg":"失败"}`},
+		{Ip: "10.0.0.1", Method: "DELETE", Path: "/api/deleteApi", UserID: 1, Body: `{"id":3}`},
+	}
+	for i := range records {
+		records[i].CreatedAt = now.Add(time.Duration(i-3) * time.Hour)
+		global.GVA_DB.Create(&records[i])
+	}
+
+	search := func(info systemReq.SysOperationRecordSearch) int64 {
+		info.PageInfo = request.PageInfo{Page: 1, PageSize: 10}
+		_, total, err := OperationRecordServiceApp.GetSysOperationRecordInfoList(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+	start, end := now.Add(-150*time.Minute), now
+	cases := map[string]struct {
+		info systemReq.SysOperationRecordSearch
+		want int64
+	}{
+		"user":          {systemReq.SysOperationRecordSearch{SysOperationRecord: system.SysOperationRecord{UserID: 1}}, 2},
+		"ip":            {systemReq.SysOperationRecordSearch{SysOperationRecord: system.SysOperationRecord{Ip: "10.0.0.2"}}, 1},
+		"time range":    {systemReq.SysOperationRecordSearch{StartCreatedAt: &start, EndCreatedAt: &end}, 2},
+		"body keyword":  {systemReq.SysOperationRecordSearch{Keyword: "alice"}, 1},
+		"resp keyword":  {systemReq.SysOperationRecordSearch{Keyword: "失败"}, 1},
+		"literal %":     {systemReq.SysOperationRecordSearch{Keyword: "100%"}, 1},
+		"wildcard only": {systemReq.SysOperationRecordSearch{Keyword: "%"}, 1},
+		"combined":      {systemReq.SysOperationRecordSearch{SysOperationRecord: system.SysOperationRecord{UserID: 1}, Keyword: "id"}, 1},
+	}
+	for name, tt := range cases {
+		if got := search(tt.info); got != tt.want {
+			t.Errorf("%s: total = %d, want %d", name, got, tt.want)
+		}
+	}
+}
+
+func TestOperationRecordService_AsyncQueue(t *testing.T) {
+	setupOperationRecord(t)
+	global.GVA_CONFIG.OperationRecord.Async = true
+	global.GVA_CONFIG.OperationRecord.QueueSize = 8
+	t.Cleanup(func() { global.GVA_CONFIG.OperationRecord.Async = false })
+
+	// 超出队列长度的部分同步写入，不会丢失
+	for i := 0; i < 50; i++ {
+		OperationRecordServiceApp.CreateSysOperationRecord(system.SysOperationRecord{Method: "POST", Path: "/test", Status: 200})
+	}
+	OperationRecordServiceApp.FlushOperationRecords(5 * time.Second)
+	var count int64
+	global.GVA_DB.Model(&system.SysOperationRecord{}).Count(&count)
+	if count != 50 {
+		t.Fatalf("count = %d, want 50", count)
+	}
+}
@@ -1,41 +1,197 @@
 package system
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// sysParamsChannel 参数变更广播频道，消息为参数键，各实例收到后清除本地缓存
+	sysParamsChannel = "sys_params_channel"
+	// sysParamsCacheTTL 本地缓存有效期，兜底订阅断开期间漏收的广播
+	sysParamsCacheTTL = 5 * time.Minute
 )
 
+var ErrSysParamsConflict = errors.New("参数已被其他人修改，请刷新后重试")
+
 type SysParamsService struct{}
 
+var SysParamsServiceApp = new(SysParamsService)
+
+// sysParamsCacheEntry 本地缓存的参数，found 为 false 表示数据库中不存在该键
+type sysParamsCacheEntry struct {
+	param    system.SysParams
+	found    bool
+	loadedAt time.Time
+}
+
+// sysParamsCache 参数本地缓存，generation 在每次清除时加一，避免并发读取把已失效的值写回缓存
+var sysParamsCache = struct {
+	sync.RWMutex
+	entries    map[string]sysParamsCacheEntry
+	generation uint64
+}{entries: make(map[string]sysParamsCacheEntry)}
+
 // CreateSysParams 创建参数记录
 // Author [Mr.奇淼](https://github.com/pixelmaxQm)
 func (sysParamsService *SysParamsService) CreateSysParams(sysParams *system.SysParams) (err error) {
-	err = global.GVA_DB.Create(sysParams).Error
+	if err = ValidateSysParam(sysParams); err != nil {
+		return err
+	}
+	if err = sysParamsService.checkKey(sysParams.Key, 0); err != nil {
+		return err
+	}
+	sysParams.Version = 1
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sysParams).Error; err != nil {
+			return err
+		}
+		return createSysParamsHistory(tx, *sysParams, "create")
+	})
+	if err == nil {
+		invalidateSysParams(sysParams.Key)
+	}
 	return err
 }
 
 // DeleteSysParams 删除参数记录
 // Author [Mr.奇淼](https://github.com/pixelmaxQm)
 func (sysParamsService *SysParamsService) DeleteSysParams(ID string) (err error) {
-	err = global.GVA_DB.Delete(&system.SysParams{}, "id = ?", ID).Error
-	return err
+	return sysParamsService.DeleteSysParamsByIds([]string{ID})
 }
 
 // DeleteSysParamsByIds 批量删除参数记录
 // Author [Mr.奇淼](https://github.com/pixelmaxQm)
 func (sysParamsService *SysParamsService) DeleteSysParamsByIds(IDs []string) (err error) {
-	err = global.GVA_DB.Delete(&[]system.SysParams{}, "id in ?", IDs).Error
+	var list []system.SysParams
+	if err = global.GVA_DB.Where("id in ?", IDs).Find(&list).Error; err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(list))
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		for _, param := range list {
+			if err := tx.Delete(&system.SysParams{}, "id = ?", param.ID).Error; err != nil {
+				return err
+			}
+			param.Version++
+			if err := createSysParamsHistory(tx, param, "delete"); err != nil {
+				return err
+			}
+			keys = append(keys, param.Key)
+		}
+		return nil
+	})
+	if err == nil {
+		invalidateSysParams(keys...)
+	}
 	return err
 }
 
 // UpdateSysParams 更新参数记录
+// 请求中带有版本号时必须与当前版本一致，避免覆盖他人的修改
 // Author [Mr.奇淼](https://github.com/pixelmaxQm)
 func (sysParamsService *SysParamsService) UpdateSysParams(sysParams system.SysParams) (err error) {
-	err = global.GVA_DB.Model(&system.SysParams{}).Where("id = ?", sysParams.ID).Updates(&sysParams).Error
+	var old system.SysParams
+	if err = global.GVA_DB.Where("id = ?", sysParams.ID).First(&old).Error; err != nil {
+		return err
+	}
+	if sysParams.Version != 0 && sysParams.Version != old.Version {
+		return ErrSysParamsConflict
+	}
+	if err = ValidateSysParam(&sysParams); err != nil {
+		return err
+	}
+	if err = sysParamsService.checkKey(sysParams.Key, sysParams.ID); err != nil {
+		return err
+	}
+	return sysParamsService.saveVersion(old, sysParams, "update")
+}
+
+// RollbackSysParams 将参数恢复为历史版本的内容，回滚本身作为新版本记录
+func (sysParamsService *SysParamsService) RollbackSysParams(ID uint, version int) (err error) {
+	var old system.SysParams
+	if err = global.GVA_DB.Where("id = ?", ID).First(&old).Error; err != nil {
+		return err
+	}
+	var history system.SysParamsHistory
+	if err = global.GVA_DB.Where("param_id = ? AND version = ?", ID, version).First(&history).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("版本 %d 不存在", version)
+		}
+		return err
+	}
+	target := old
+	target.Type, target.Schema, target.Value = history.Type, history.Schema, history.Value
+	if err = ValidateSysParam(&target); err != nil {
+		return fmt.Errorf("版本 %d 不符合当前校验规则: %w", version, err)
+	}
+	return sysParamsService.saveVersion(old, target, "rollback")
+}
+
+// saveVersion 以乐观锁写入新版本并记录历史
+func (sysParamsService *SysParamsService) saveVersion(old, sysParams system.SysParams, action string) error {
+	sysParams.Version = old.Version + 1
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&system.SysParams{}).Where("id = ? AND version = ?", old.ID, old.Version).
+			Select("name", "key", "value", "desc", "type", "schema", "version").Updates(&sysParams)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSysParamsConflict
+		}
+		return createSysParamsHistory(tx, sysParams, action)
+	})
+	if err == nil {
+		invalidateSysParams(old.Key, sysParams.Key)
+	}
 	return err
 }
 
+// checkKey 参数键必须唯一
+func (sysParamsService *SysParamsService) checkKey(key string, excludeID uint) error {
+	var count int64
+	err := global.GVA_DB.Model(&system.SysParams{}).Where(&system.SysParams{Key: key}).Where("id <> ?", excludeID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("参数键 %s 已存在", key)
+	}
+	return nil
+}
+
+func createSysParamsHistory(tx *gorm.DB, param system.SysParams, action string) error {
+	return tx.Create(&system.SysParamsHistory{
+		ParamID: param.ID,
+		Key:     param.Key,
+		Version: param.Version,
+		Action:  action,
+		Type:    param.Type,
+		Schema:  param.Schema,
+		Value:   param.Value,
+	}).Error
+}
+
 // GetSysParams 根据ID获取参数记录
 // Author [Mr.奇淼](https://github.com/pixelmaxQm)
 func (sysParamsService *SysParamsService) GetSysParams(ID string) (sysParams system.SysParams, err error) {
@@ -43,6 +199,12 @@ func (sysParamsService *SysParamsService) GetSysParams(ID string) (sysParams sys
 	return
 }
 
+// GetSysParamsHistory 获取参数的变更历史，按版本倒序
+func (sysParamsService *SysParamsService) GetSysParamsHistory(ID uint) (list []system.SysParamsHistory, err error) {
+	err = global.GVA_DB.Where("param_id = ?", ID).Order("version desc").Find(&list).Error
+	return
+}
+
 // GetSysParamsInfoList 分页获取参数记录
 // Author [Mr.奇淼](https://github.com/pixelmaxQm)
 func (sysParamsService *SysParamsService) GetSysParamsInfoList(info systemReq.SysParamsSearch) (list []system.SysParams, total int64, err error) {
@@ -74,9 +236,260 @@ func (sysParamsService *SysParamsService) GetSysParamsInfoList(info systemReq.Sy
 	return sysParamss, total, err
 }
 
-// GetSysParam 根据key获取参数value
+// GetSysParam 根据key获取参数value，优先读取本地缓存
 // Author [Mr.奇淼](https://github.com/pixelmaxQm)
 func (sysParamsService *SysParamsService) GetSysParam(key string) (param system.SysParams, err error) {
-	err = global.GVA_DB.Where(system.SysParams{Key: key}).First(&param).Error
-	return
+	sysParamsCache.RLock()
+	entry, ok := sysParamsCache.entries[key]
+	generation := sysParamsCache.generation
+	sysParamsCache.RUnlock()
+	if !ok || time.Since(entry.loadedAt) > sysParamsCacheTTL {
+		entry = sysParamsCacheEntry{loadedAt: time.Now()}
+		err = global.GVA_DB.Where(system.SysParams{Key: key}).First(&entry.param).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return
+		}
+		entry.found = err == nil
+		sysParamsCache.Lock()
+		if sysParamsCache.generation == generation {
+			sysParamsCache.entries[key] = entry
+		}
+		sysParamsCache.Unlock()
+	}
+	if !entry.found {
+		return param, gorm.ErrRecordNotFound
+	}
+	return entry.param, nil
+}
+
+// GetParamString 读取参数值，不存在时返回默认值
+func (sysParamsService *SysParamsService) GetParamString(key, def string) string {
+	if value, ok := sysParamsService.typedValue(key, system.SysParamTypeString); ok {
+		return value
+	}
+	return def
+}
+
+// GetParamInt 读取 int 类型参数，不存在或类型不符时返回默认值
+func (sysParamsService *SysParamsService) GetParamInt(key string, def int64) int64 {
+	if value, ok := sysParamsService.typedValue(key, system.SysParamTypeInt); ok {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// GetParamBool 读取 bool 类型参数，不存在或类型不符时返回默认值
+func (sysParamsService *SysParamsService) GetParamBool(key string, def bool) bool {
+	if value, ok := sysParamsService.typedValue(key, system.SysParamTypeBool); ok {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// GetParamDuration 读取 duration 类型参数，不存在或类型不符时返回默认值
+func (sysParamsService *SysParamsService) GetParamDuration(key string, def time.Duration) time.Duration {
+	if value, ok := sysParamsService.typedValue(key, system.SysParamTypeDuration); ok {
+		if d, err := utils.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// GetParamJSON 将 json 类型参数解析到 out，参数不存在时返回 gorm.ErrRecordNotFound
+func (sysParamsService *SysParamsService) GetParamJSON(key string, out interface{}) error {
+	param, err := sysParamsService.GetSysParam(key)
+	if err != nil {
+		return err
+	}
+	if param.Type != system.SysParamTypeJSON {
+		return fmt.Errorf("参数 %s 的类型为 %s，不是 json", key, param.Type)
+	}
+	return json.Unmarshal([]byte(param.Value), out)
+}
+
+// typedValue 读取指定类型的参数值，旧数据没有类型时视为 string
+func (sysParamsService *SysParamsService) typedValue(key, typ string) (string, bool) {
+	param, err := sysParamsService.GetSysParam(key)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			global.GVA_LOG.Error("读取参数失败!", zap.String("key", key), zap.Error(err))
+		}
+		return "", false
+	}
+	paramType := param.Type
+	if paramType == "" {
+		paramType = system.SysParamTypeString
+	}
+	if paramType != typ && typ != system.SysParamTypeString {
+		global.GVA_LOG.Warn("参数类型不符，使用默认值", zap.String("key", key), zap.String("type", paramType), zap.String("want", typ))
+		return "", false
+	}
+	return strings.TrimSpace(param.Value), true
+}
+
+// ValidateSysParam 校验参数类型、校验规则以及参数值，未指定类型时按 string 处理
+func ValidateSysParam(param *system.SysParams) error {
+	if param.Type == "" {
+		param.Type = system.SysParamTypeString
+	}
+	var schema system.SysParamSchema
+	if strings.TrimSpace(param.Schema) != "" {
+		decoder := json.NewDecoder(strings.NewReader(param.Schema))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&schema); err != nil {
+			return fmt.Errorf("校验规则格式错误: %w", err)
+		}
+	}
+	value := param.Value
+	if param.Type != system.SysParamTypeString && param.Type != system.SysParamTypeJSON {
+		value = strings.TrimSpace(value)
+	}
+	if param.Type != system.SysParamTypeString && (len(schema.Enum) > 0 || schema.Pattern != "") {
+		return fmt.Errorf("%s 类型不支持 enum、pattern 规则", param.Type)
+	}
+	if param.Type != system.SysParamTypeInt && param.Type != system.SysParamTypeDuration && (schema.Min != "" || schema.Max != "") {
+		return fmt.Errorf("%s 类型不支持 min、max 规则", param.Type)
+	}
+	if param.Type != system.SysParamTypeJSON && len(schema.Required) > 0 {
+		return fmt.Errorf("%s 类型不支持 required 规则", param.Type)
+	}
+
+	switch param.Type {
+	case system.SysParamTypeString:
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, value) {
+			return fmt.Errorf("参数值必须为 %s 之一", strings.Join(schema.Enum, "、"))
+		}
+		if schema.Pattern != "" {
+			re, err := regexp.Compile(schema.Pattern)
+			if err != nil {
+				return fmt.Errorf("pattern 不合法: %w", err)
+			}
+			if !re.MatchString(value) {
+				return fmt.Errorf("参数值不匹配 %s", schema.Pattern)
+			}
+		}
+	case system.SysParamTypeInt:
+		return checkSysParamRange(value, schema, func(s string) (float64, error) {
+			n, err := strconv.ParseInt(s, 10, 64)
+			return float64(n), err
+		}, "整数")
+	case system.SysParamTypeDuration:
+		return checkSysParamRange(value, schema, func(s string) (float64, error) {
+			d, err := utils.ParseDuration(s)
+			return float64(d), err
+		}, "时长（如 30s、5m、1d）")
+	case system.SysParamTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.New("参数值必须为 true 或 false")
+		}
+	case system.SysParamTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return errors.New("参数值不是合法的 JSON")
+		}
+		if len(schema.Required) > 0 {
+			var object map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(value), &object); err != nil {
+				return errors.New("参数值必须为 JSON 对象")
+			}
+			for _, field := range schema.Required {
+				if _, ok := object[field]; !ok {
+					return fmt.Errorf("参数值缺少字段 %s", field)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("不支持的参数类型 %s", param.Type)
+	}
+	param.Value = value
+	return nil
+}
+
+// checkSysParamRange 解析参数值并检查 min、max
+func checkSysParamRange(value string, schema system.SysParamSchema, parse func(string) (float64, error), label string) error {
+	v, err := parse(value)
+	if err != nil {
+		return fmt.Errorf("参数值必须为%s", label)
+	}
+	if schema.Min != "" {
+		min, err := parse(schema.Min)
+		if err != nil {
+			return fmt.Errorf("min 必须为%s", label)
+		}
+		if v < min {
+			return fmt.Errorf("参数值不能小于 %s", schema.Min)
+		}
+	}
+	if schema.Max != "" {
+		max, err := parse(schema.Max)
+		if err != nil {
+			return fmt.Errorf("max 必须为%s", label)
+		}
+		if v > max {
+			return fmt.Errorf("参数值不能大于 %s", schema.Max)
+		}
+	}
+	return nil
+}
+
+// invalidateSysParams 清除本地缓存并通知其他实例
+func invalidateSysParams(keys ...string) {
+	clearSysParamsCache(keys...)
+	if global.GVA_REDIS == nil {
+		return
+	}
+	ctx := context.Background()
+	for _, key := range keys {
+		// 本地缓存有过期时间兜底，广播失败只记录日志
+		if err := global.GVA_REDIS.Publish(ctx, sysParamsChannel, key).Err(); err != nil {
+			global.GVA_LOG.Error("参数变更广播失败!", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// SubscribeSysParams 订阅参数变更广播，收到后清除本地缓存
+// 每次（重新）订阅成功时清空全部缓存，避免断线期间漏收的变更；ctx 取消时退出
+func SubscribeSysParams(ctx context.Context) {
+	if global.GVA_REDIS == nil {
+		return
+	}
+	pubsub := global.GVA_REDIS.Subscribe(ctx, sysParamsChannel)
+	defer pubsub.Close()
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			global.GVA_LOG.Error("参数变更订阅异常!", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				clearSysParamsCache()
+			}
+		case *redis.Message:
+			clearSysParamsCache(m.Payload)
+		}
+	}
+}
+
+// clearSysParamsCache 清除指定键的本地缓存，未指定时清空全部
+func clearSysParamsCache(keys ...string) {
+	sysParamsCache.Lock()
+	defer sysParamsCache.Unlock()
+	sysParamsCache.generation++
+	if len(keys) == 0 {
+		sysParamsCache.entries = make(map[string]sysParamsCacheEntry)
+		return
+	}
+	for _, key := range keys {
+		delete(sysParamsCache.entries, key)
+	}
 }
@@ -0,0 +1,134 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"gorm.io/gorm"
+)
+
+func setupSysParams(t *testing.T) *SysParamsService {
+	t.Helper()
+	globaltest.DB(t, &system.SysParams{}, &system.SysParamsHistory{})
+	clearSysParamsCache()
+	return &SysParamsService{}
+}
+
+func TestValidateSysParam(t *testing.T) {
+	cases := []struct {
+		param system.SysParams
+		ok    bool
+	}{
+		{system.SysParams{Value: "anything"}, true},
+		{system.SysParams{Type: "int", Value: " 42 ", Schema: `{"min":"1","max":"100"}`}, true},
+		{system.SysParams{Type: "int", Value: "0", Schema: `{"min":"1"}`}, false},
+		{system.SysParams{Type: "int", Value: "1.5"}, false},
+		{system.SysParams{Type: "bool", Value: "true"}, true},
+		{system.SysParams{Type: "bool", Value: "yes"}, false},
+		{system.SysParams{Type: "duration", Value: "1d", Schema: `{"max":"2d"}`}, true},
+		{system.SysParams{Type: "duration", Value: "2s", Schema: `{"min":"5s"}`}, false},
+		{system.SysParams{Type: "json", Value: `{"a":1}`, Schema: `{"required":["a"]}`}, true},
+		{system.SysParams{Type: "json", Value: `{"b":1}`, Schema: `{"required":["a"]}`}, false},
+		{system.SysParams{Type: "json", Value: `{"a":`}, false},
+		{system.SysParams{Value: "blue", Schema: `{"enum":["red","green"]}`}, false},
+		{system.SysParams{Value: "abc", Schema: `{"pattern":"^[a-z]+$"}`}, true},
+		{system.SysParams{Type: "bool", Value: "true", Schema: `{"min":"1"}`}, false},
+		{system.SysParams{Type: "int", Value: "1", Schema: `{"minimum":1}`}, false},
+		{system.SysParams{Type: "float", Value: "1"}, false},
+	}
+	for i, c := range cases {
+		err := ValidateSysParam(&c.param)
+		if (err == nil) != c.ok {
+			t.Errorf("case %d: err = %v, want ok = %v", i, err, c.ok)
+		}
+	}
+}
+
+func TestSysParams_TypedAccessorsAndCache(t *testing.T) {
+	s := setupSysParams(t)
+	param := system.SysParams{Name: "轮询间隔", Key: "poll", Type: "duration", Value: "30s", Schema: `{"min":"5s"}`}
+	if err := s.CreateSysParams(&param); err != nil {
+		t.Fatal(err)
+	}
+	if d := s.GetParamDuration("poll", time.Minute); d != 30*time.Second {
+		t.Fatalf("duration = %v", d)
+	}
+	// 类型不符与不存在时返回默认值
+	if n := s.GetParamInt("poll", 7); n != 7 {
+		t.Fatalf("int = %d", n)
+	}
+	if n := s.GetParamInt("missing", 7); n != 7 {
+		t.Fatalf("int = %d", n)
+	}
+
+	// 绕过服务直接修改数据库时缓存仍返回旧值，通过服务修改后立即生效
+	global.GVA_DB.Model(&system.SysParams{}).Where("id = ?", param.ID).Update("value", "45s")
+	if d := s.GetParamDuration("poll", time.Minute); d != 30*time.Second {
+		t.Fatalf("cached duration = %v", d)
+	}
+	param.Value = "10s"
+	if err := s.UpdateSysParams(param); err != nil {
+		t.Fatal(err)
+	}
+	if d := s.GetParamDuration("poll", time.Minute); d != 10*time.Second {
+		t.Fatalf("duration after update = %v", d)
+	}
+
+	param.Value, param.Version = "1s", 2
+	if err := s.UpdateSysParams(param); err == nil || errors.Is(err, ErrSysParamsConflict) {
+		t.Fatal("value below min should be rejected")
+	}
+	duplicate := system.SysParams{Name: "重复", Key: "poll", Value: "x"}
+	if err := s.CreateSysParams(&duplicate); err == nil {
+		t.Fatal("duplicate key should be rejected")
+	}
+	if err := s.DeleteSysParams("1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetSysParam("poll"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSysParams_HistoryAndRollback(t *testing.T) {
+	s := setupSysParams(t)
+	param := system.SysParams{Name: "开关", Key: "feature", Type: "bool", Value: "false"}
+	if err := s.CreateSysParams(&param); err != nil {
+		t.Fatal(err)
+	}
+	stale := param
+	param.Value = "true"
+	if err := s.UpdateSysParams(param); err != nil {
+		t.Fatal(err)
+	}
+	stale.Value = "false"
+	if err := s.UpdateSysParams(stale); !errors.Is(err, ErrSysParamsConflict) {
+		t.Fatalf("stale update err = %v", err)
+	}
+	if !s.GetParamBool("feature", false) {
+		t.Fatal("feature should be enabled")
+	}
+
+	if err := s.RollbackSysParams(param.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if s.GetParamBool("feature", true) {
+		t.Fatal("feature should be disabled after rollback")
+	}
+	if err := s.RollbackSysParams(param.ID, 9); err == nil {
+		t.Fatal("rollback to missing version should fail")
+	}
+
+	history, err := s.GetSysParamsHistory(param.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[0].Version != 3 || history[0].Action != "rollback" || history[0].Value != "false" ||
+		history[1].Action != "update" || history[2].Action != "create" {
+		t.Fatalf("history = %+v", history)
+	}
+}
@@ -0,0 +1,87 @@
+package system
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"gorm.io/gorm"
+)
+
+// PasswordChangeRequired 登录时是否必须先修改密码：管理员重置后首次登录，或密码已超过有效天数
+func (userService *UserService) PasswordChangeRequired(user *system.SysUser) bool {
+	// 目录用户的密码有效期由目录服务管理
+	if LdapServiceApp.IsDirectoryUser(user.Username) {
+		return false
+	}
+	if user.MustChangePassword {
+		return true
+	}
+	expireDays := global.GVA_CONFIG.PasswordPolicy.ExpireDays
+	if expireDays <= 0 {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > time.Duration(expireDays)*24*time.Hour
+}
+
+// checkNewPassword 校验新密码是否符合策略且未在最近 history-count 次中使用过，userId 为0时只校验策略
+func (userService *UserService) checkNewPassword(userId uint, username, password string) error {
+	policy := global.GVA_CONFIG.PasswordPolicy
+	if err := utils.CheckPasswordStrength(policy, username, password); err != nil {
+		return err
+	}
+	if userId == 0 || policy.HistoryCount <= 0 {
+		return nil
+	}
+	var hashes []string
+	err := global.GVA_DB.Model(&system.SysPasswordHistory{}).Where("user_id = ?", userId).
+		Order("id desc").Limit(policy.HistoryCount).Pluck("password", &hashes).Error
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if utils.BcryptCheck(password, hash) {
+			return fmt.Errorf("%w: 不能与最近%d次使用过的密码相同", utils.ErrPasswordPolicy, policy.HistoryCount)
+		}
+	}
+	return nil
+}
+
+// savePassword 更新用户密码并写入历史，历史记录只保留策略要求的条数
+func savePassword(tx *gorm.DB, userId uint, hash string, mustChange bool) error {
+	now := time.Now()
+	err := tx.Model(&system.SysUser{}).Where("id = ?", userId).Updates(map[string]interface{}{
+		"password":             hash,
+		"password_changed_at":  now,
+		"must_change_password": mustChange,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return recordPasswordHistory(tx, userId, hash)
+}
+
+func recordPasswordHistory(tx *gorm.DB, userId uint, hash string) error {
+	if err := tx.Create(&system.SysPasswordHistory{UserId: userId, Password: hash}).Error; err != nil {
+		return err
+	}
+	keep := global.GVA_CONFIG.PasswordPolicy.HistoryCount
+	if keep < 1 {
+		keep = 1
+	}
+	var ids []uint
+	if err := tx.Model(&system.SysPasswordHistory{}).Where("user_id = ?", userId).
+		Order("id desc").Offset(keep).Limit(1000).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Where("id IN ?", ids).Delete(&system.SysPasswordHistory{}).Error
+}
@@ -0,0 +1,101 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func setupPasswordDB(t *testing.T) {
+	t.Helper()
+	globaltest.DB(t, &system.SysUser{}, &system.SysPasswordHistory{})
+	global.GVA_CONFIG.PasswordPolicy = config.PasswordPolicy{
+		MinLength:          8,
+		MinCharClasses:     3,
+		CheckBreached:      true,
+		HistoryCount:       2,
+		ForceChangeOnReset: true,
+	}
+}
+
+func TestUserService_PasswordHistory(t *testing.T) {
+	setupPasswordDB(t)
+	s := &UserService{}
+
+	if _, err := s.Register(system.SysUser{Username: "alice", Password: "Admin@123"}); !errors.Is(err, utils.ErrPasswordPolicy) {
+		t.Fatalf("弱密码注册应被拒绝, got %v", err)
+	}
+	user, err := s.Register(system.SysUser{Username: "alice", Password: "First#Pass1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change := func(old, new string) error {
+		return s.ChangePassword(&system.SysUser{GVA_MODEL: global.GVA_MODEL{ID: user.ID}, Password: old}, new)
+	}
+	if err = change("wrong", "Second#Pass2"); !errors.Is(err, ErrOldPasswordMismatch) {
+		t.Fatalf("原密码错误应返回 ErrOldPasswordMismatch, got %v", err)
+	}
+	if err = change("First#Pass1", "Second#Pass2"); err != nil {
+		t.Fatal(err)
+	}
+	if err = change("Second#Pass2", "First#Pass1"); !errors.Is(err, utils.ErrPasswordPolicy) {
+		t.Fatalf("最近使用过的密码应被拒绝, got %v", err)
+	}
+	if err = change("Second#Pass2", "Third#Pass3"); err != nil {
+		t.Fatal(err)
+	}
+	// 只保留最近2次，最早的密码可以再次使用
+	if err = change("Third#Pass3", "First#Pass1"); err != nil {
+		t.Fatalf("超出历史条数的密码应允许使用, got %v", err)
+	}
+	var count int64
+	global.GVA_DB.Model(&system.SysPasswordHistory{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 2 {
+		t.Fatalf("历史记录条数 = %d, want 2", count)
+	}
+}
+
+func TestUserService_ResetPasswordForcesChange(t *testing.T) {
+	setupPasswordDB(t)
+	s := &UserService{}
+	user, err := s.Register(system.SysUser{Username: "bob", Password: "Initial#Pwd1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := s.ResetPassword(user.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generated == "" || generated == "123456" {
+		t.Fatalf("未指定密码时应生成随机密码, got %q", generated)
+	}
+	var stored system.SysUser
+	global.GVA_DB.First(&stored, user.ID)
+	if !utils.BcryptCheck(generated, stored.Password) || !s.PasswordChangeRequired(&stored) {
+		t.Fatal("重置后应保存新密码并要求下次登录修改")
+	}
+
+	if err = s.ChangePassword(&system.SysUser{GVA_MODEL: global.GVA_MODEL{ID: user.ID}, Password: generated}, "Changed#Pwd2"); err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.First(&stored, user.ID)
+	if s.PasswordChangeRequired(&stored) {
+		t.Fatal("修改密码后不应再要求修改")
+	}
+
+	// 密码有效期
+	global.GVA_CONFIG.PasswordPolicy.ExpireDays = 30
+	old := time.Now().AddDate(0, 0, -31)
+	stored.PasswordChangedAt = &old
+	if !s.PasswordChangeRequired(&stored) {
+		t.Fatal("超过有效天数的密码应要求修改")
+	}
+}
@@ -0,0 +1,282 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// sessionRegistryKey 会话登记表（HASH）：user_session:<uuid>，field 为会话ID，value 为 SessionEntry
+	sessionRegistryKey = "user_session"
+	// sessionSeenKey 会话最近活跃时间（HASH）：user_session_seen:<uuid>，field 为会话ID，value 为时间戳
+	sessionSeenKey = "user_session_seen"
+	// sessionVersionKey 用户会话版本：user_session_version:<uuid>，每次强制下线或权限变更时递增
+	// 不设置过期时间，避免版本归零后旧令牌重新生效；未启用 Redis 时使用 sys_users.session_version
+	sessionVersionKey = "user_session_version"
+	// sessionSeenInterval 同一会话最近活跃时间的最小写入间隔
+	sessionSeenInterval = time.Minute
+)
+
+var ErrSessionRevoked = errors.New("登录状态已失效，请重新登录")
+
+// SessionEntry 会话登记信息
+type SessionEntry struct {
+	SessionId string    `json:"sessionId"`
+	UserAgent string    `json:"userAgent"`
+	Ip        string    `json:"ip"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Current   bool      `json:"current"`
+}
+
+// SessionVersion 获取用户当前会话版本，签发访问令牌时写入 claims
+func (userSessionService *UserSessionService) SessionVersion(ctx context.Context, userUUID uuid.UUID) int64 {
+	if global.GVA_REDIS == nil {
+		var user system.SysUser
+		err := global.GVA_DB.WithContext(utils.WithoutDataScope(ctx)).Select("session_version").Where("uuid = ?", userUUID).First(&user).Error
+		if err != nil {
+			global.GVA_LOG.Error("获取会话版本失败!", zap.Error(err))
+		}
+		return user.SessionVersion
+	}
+	version, err := global.GVA_REDIS.Get(ctx, sessionKey(sessionVersionKey, userUUID)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		global.GVA_LOG.Error("获取会话版本失败!", zap.Error(err))
+	}
+	return version
+}
+
+// RegisterSession 登记或更新会话（登录、刷新时调用）
+func (userSessionService *UserSessionService) RegisterSession(ctx context.Context, userUUID uuid.UUID, session system.SysUserSession) {
+	if global.GVA_REDIS == nil {
+		return
+	}
+	entry, _ := json.Marshal(SessionEntry{
+		SessionId: session.SessionId,
+		UserAgent: session.UserAgent,
+		Ip:        session.Ip,
+		CreatedAt: session.CreatedAt,
+	})
+	registryKey := sessionKey(sessionRegistryKey, userUUID)
+	seenKey := sessionKey(sessionSeenKey, userUUID)
+	pipe := global.GVA_REDIS.TxPipeline()
+	pipe.HSet(ctx, registryKey, session.SessionId, entry)
+	pipe.HSet(ctx, seenKey, session.SessionId, time.Now().Unix())
+	pipe.Expire(ctx, registryKey, refreshExpires())
+	pipe.Expire(ctx, seenKey, refreshExpires())
+	if _, err := pipe.Exec(ctx); err != nil {
+		global.GVA_LOG.Error("登记会话失败!", zap.Error(err))
+	}
+}
+
+// CheckSession 每个请求校验访问令牌所属会话：会话版本不低于当前版本且会话未被下线
+// 正常情况下只有一次 Redis 往返；登记表中缺失会话时回查数据库，覆盖 Redis 数据丢失的情况；
+// 未启用 Redis 时直接查询数据库
+func (userSessionService *UserSessionService) CheckSession(ctx context.Context, claims *systemReq.CustomClaims) error {
+	if global.GVA_REDIS == nil {
+		return checkSessionInDB(ctx, claims)
+	}
+	registryKey := sessionKey(sessionRegistryKey, claims.UUID)
+	pipe := global.GVA_REDIS.Pipeline()
+	versionCmd := pipe.Get(ctx, sessionKey(sessionVersionKey, claims.UUID))
+	var existsCmd *redis.BoolCmd
+	if claims.SessionId != "" {
+		existsCmd = pipe.HExists(ctx, registryKey, claims.SessionId)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		// Redis 故障时放行，访问令牌有效期较短且刷新时仍会校验数据库中的会话状态
+		global.GVA_LOG.Error("校验会话失败!", zap.Error(err))
+		return nil
+	}
+	if version, _ := versionCmd.Int64(); version > claims.SessionVersion {
+		return ErrSessionRevoked
+	}
+	if existsCmd == nil {
+		return nil
+	}
+	if !existsCmd.Val() {
+		var session system.SysUserSession
+		err := global.GVA_DB.WithContext(ctx).
+			Where("session_id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", claims.SessionId, claims.BaseClaims.ID, time.Now()).
+			First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionRevoked
+		}
+		if err != nil {
+			global.GVA_LOG.Error("查询会话失败!", zap.Error(err))
+			return nil
+		}
+		userSessionService.RegisterSession(ctx, claims.UUID, session)
+		return nil
+	}
+	userSessionService.touchSession(ctx, claims.UUID, claims.SessionId)
+	return nil
+}
+
+// checkSessionInDB 未启用 Redis 时按数据库中的会话版本与会话状态校验
+func checkSessionInDB(ctx context.Context, claims *systemReq.CustomClaims) error {
+	var user system.SysUser
+	err := global.GVA_DB.WithContext(utils.WithoutDataScope(ctx)).Select("session_version").Where("id = ?", claims.BaseClaims.ID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		global.GVA_LOG.Error("查询会话版本失败!", zap.Error(err))
+		return nil
+	}
+	if user.SessionVersion > claims.SessionVersion {
+		return ErrSessionRevoked
+	}
+	if claims.SessionId == "" {
+		return nil
+	}
+	var count int64
+	err = global.GVA_DB.WithContext(ctx).Model(&system.SysUserSession{}).
+		Where("session_id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", claims.SessionId, claims.BaseClaims.ID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		global.GVA_LOG.Error("查询会话失败!", zap.Error(err))
+		return nil
+	}
+	if count == 0 {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// ListSessions 获取用户的在线会话，currentSessionId 对应的会话标记为当前会话
+func (userSessionService *UserSessionService) ListSessions(ctx context.Context, userId uint, currentSessionId string) ([]SessionEntry, error) {
+	if global.GVA_REDIS == nil {
+		sessions, err := userSessionService.GetUserSessions(userId)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]SessionEntry, 0, len(sessions))
+		for _, s := range sessions {
+			list = append(list, SessionEntry{
+				SessionId: s.SessionId, UserAgent: s.UserAgent, Ip: s.Ip,
+				CreatedAt: s.CreatedAt, LastSeen: s.LastActiveAt, Current: s.SessionId == currentSessionId,
+			})
+		}
+		return list, nil
+	}
+
+	userUUID, err := userUUIDByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := global.GVA_REDIS.HGetAll(ctx, sessionKey(sessionRegistryKey, userUUID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	seen, err := global.GVA_REDIS.HGetAll(ctx, sessionKey(sessionSeenKey, userUUID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]SessionEntry, 0, len(entries))
+	for sessionId, raw := range entries {
+		var entry SessionEntry
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			continue
+		}
+		if ts, err := strconv.ParseInt(seen[sessionId], 10, 64); err == nil {
+			entry.LastSeen = time.Unix(ts, 0)
+		}
+		entry.Current = sessionId == currentSessionId
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastSeen.After(list[j].LastSeen) })
+	return list, nil
+}
+
+// KillSession 强制下线用户的某个会话
+func (userSessionService *UserSessionService) KillSession(ctx context.Context, userId uint, sessionId string) error {
+	return userSessionService.RevokeUserSession(userId, sessionId)
+}
+
+// KillAllSessions 强制下线用户的全部会话：吊销刷新令牌、清空登记表并递增会话版本使已签发的访问令牌立即失效
+func (userSessionService *UserSessionService) KillAllSessions(ctx context.Context, userId uint, reason string) error {
+	if err := userSessionService.RevokeUserSessions(userId, "", reason); err != nil {
+		return err
+	}
+	_, err := userSessionService.BumpSessionVersion(ctx, userId)
+	return err
+}
+
+// BumpSessionVersion 递增会话版本，用户已签发的访问令牌全部失效；会话本身保留，客户端刷新后按最新权限重新签发
+func (userSessionService *UserSessionService) BumpSessionVersion(ctx context.Context, userId uint) (int64, error) {
+	if global.GVA_REDIS == nil {
+		var user system.SysUser
+		err := global.GVA_DB.WithContext(utils.WithoutDataScope(ctx)).Transaction(func(tx *gorm.DB) error {
+			result := tx.Unscoped().Model(&system.SysUser{}).Where("id = ?", userId).
+				UpdateColumn("session_version", gorm.Expr("session_version + 1"))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			return tx.Unscoped().Select("session_version").Where("id = ?", userId).First(&user).Error
+		})
+		return user.SessionVersion, err
+	}
+	userUUID, err := userUUIDByID(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	return global.GVA_REDIS.Incr(ctx, sessionKey(sessionVersionKey, userUUID)).Result()
+}
+
+// unregisterSessions 从登记表中移除会话
+func unregisterSessions(ctx context.Context, userId uint, sessionIds ...string) {
+	if global.GVA_REDIS == nil || len(sessionIds) == 0 {
+		return
+	}
+	userUUID, err := userUUIDByID(ctx, userId)
+	if err != nil {
+		global.GVA_LOG.Error("移除会话登记失败!", zap.Error(err))
+		return
+	}
+	pipe := global.GVA_REDIS.Pipeline()
+	pipe.HDel(ctx, sessionKey(sessionRegistryKey, userUUID), sessionIds...)
+	pipe.HDel(ctx, sessionKey(sessionSeenKey, userUUID), sessionIds...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		global.GVA_LOG.Error("移除会话登记失败!", zap.Error(err))
+	}
+}
+
+// touchSession 更新会话最近活跃时间，通过本地缓存限制写入频率
+func (userSessionService *UserSessionService) touchSession(ctx context.Context, userUUID uuid.UUID, sessionId string) {
+	cacheKey := "session_seen:" + sessionId
+	if _, ok := global.BlackCache.Get(cacheKey); ok {
+		return
+	}
+	global.BlackCache.Set(cacheKey, struct{}{}, sessionSeenInterval)
+	global.GVA_REDIS.HSet(ctx, sessionKey(sessionSeenKey, userUUID), sessionId, time.Now().Unix())
+}
+
+// userUUIDByID 按用户ID查询UUID，已删除的用户同样需要清理会话
+// 管理员操作其他用户的会话时同样需要查到目标用户，不做数据权限过滤
+func userUUIDByID(ctx context.Context, userId uint) (uuid.UUID, error) {
+	var user system.SysUser
+	err := global.GVA_DB.WithContext(utils.WithoutDataScope(ctx)).Unscoped().Select("uuid").Where("id = ?", userId).First(&user).Error
+	return user.UUID, err
+}
+
+func sessionKey(prefix string, userUUID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s", prefix, userUUID.String())
+}
@@ -0,0 +1,158 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/google/uuid"
+)
+
+func setupSessionRegistry(t *testing.T) system.SysUser {
+	t.Helper()
+	globaltest.DB(t, &system.SysUser{}, &system.SysUserSession{}, &system.SysRefreshToken{})
+	globaltest.Redis(t)
+	user := system.SysUser{UUID: uuid.New(), Username: "session-user"}
+	if err := global.GVA_DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// loginSession 模拟登录：创建会话并登记，返回该会话签发的访问令牌 claims
+func loginSession(t *testing.T, user system.SysUser) *systemReq.CustomClaims {
+	t.Helper()
+	ctx := context.Background()
+	s := UserSessionServiceApp
+	session, _, err := s.CreateSession(user.ID, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.RegisterSession(ctx, user.UUID, session)
+	return &systemReq.CustomClaims{BaseClaims: systemReq.BaseClaims{
+		UUID: user.UUID, ID: user.ID, SessionId: session.SessionId, SessionVersion: s.SessionVersion(ctx, user.UUID),
+	}}
+}
+
+func TestUserSessionService_CheckSession(t *testing.T) {
+	user := setupSessionRegistry(t)
+	ctx := context.Background()
+	s := UserSessionServiceApp
+	claims := loginSession(t, user)
+
+	if err := s.CheckSession(ctx, claims); err != nil {
+		t.Fatalf("登记的会话应通过校验: %v", err)
+	}
+
+	// 登记表丢失时回查数据库并重新登记
+	global.GVA_REDIS.Del(ctx, sessionKey(sessionRegistryKey, user.UUID))
+	if err := s.CheckSession(ctx, claims); err != nil {
+		t.Fatalf("数据库中有效的会话应通过校验: %v", err)
+	}
+	if ok, _ := global.GVA_REDIS.HExists(ctx, sessionKey(sessionRegistryKey, user.UUID), claims.SessionId).Result(); !ok {
+		t.Fatal("回查数据库后应重新登记会话")
+	}
+
+	// 已下线的会话
+	if err := s.RevokeUserSession(user.ID, claims.SessionId); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckSession(ctx, claims); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("已下线的会话应返回 ErrSessionRevoked, got %v", err)
+	}
+}
+
+func TestUserSessionService_BumpSessionVersion(t *testing.T) {
+	user := setupSessionRegistry(t)
+	ctx := context.Background()
+	s := UserSessionServiceApp
+	claims := loginSession(t, user)
+
+	version, err := s.BumpSessionVersion(ctx, user.ID)
+	if err != nil || version != claims.SessionVersion+1 {
+		t.Fatalf("BumpSessionVersion() = %d, %v", version, err)
+	}
+	if err = s.CheckSession(ctx, claims); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("旧版本的访问令牌应失效, got %v", err)
+	}
+	// 会话保留，刷新后按新版本签发的令牌可以继续使用
+	claims.SessionVersion = version
+	if err = s.CheckSession(ctx, claims); err != nil {
+		t.Fatalf("新版本的访问令牌应通过校验: %v", err)
+	}
+
+	if _, err = s.BumpSessionVersion(ctx, user.ID+100); err == nil {
+		t.Fatal("不存在的用户应返回错误")
+	}
+}
+
+func TestUserSessionService_WithoutRedis(t *testing.T) {
+	user := setupSessionRegistry(t)
+	global.GVA_REDIS = nil
+	ctx := context.Background()
+	s := UserSessionServiceApp
+	first := loginSession(t, user)
+	second := loginSession(t, user)
+
+	if err := s.CheckSession(ctx, first); err != nil {
+		t.Fatalf("有效的会话应通过校验: %v", err)
+	}
+	// 未启用 Redis 时会话版本保存在数据库中
+	version, err := s.BumpSessionVersion(ctx, user.ID)
+	if err != nil || version != first.SessionVersion+1 {
+		t.Fatalf("BumpSessionVersion() = %d, %v", version, err)
+	}
+	if err = s.CheckSession(ctx, first); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("旧版本的访问令牌应失效, got %v", err)
+	}
+	if got := s.SessionVersion(ctx, user.UUID); got != version {
+		t.Fatalf("SessionVersion() = %d, want %d", got, version)
+	}
+	if _, err = s.BumpSessionVersion(ctx, user.ID+100); err == nil {
+		t.Fatal("不存在的用户应返回错误")
+	}
+
+	second.SessionVersion = s.SessionVersion(ctx, user.UUID)
+	if err = s.CheckSession(ctx, second); err != nil {
+		t.Fatalf("新版本的访问令牌应通过校验: %v", err)
+	}
+	if err = s.KillAllSessions(ctx, user.ID, SessionRevokeKill); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CheckSession(ctx, second); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("强制下线后访问令牌应失效, got %v", err)
+	}
+}
+
+func TestUserSessionService_KillAllSessions(t *testing.T) {
+	user := setupSessionRegistry(t)
+	ctx := context.Background()
+	s := UserSessionServiceApp
+	first := loginSession(t, user)
+	second := loginSession(t, user)
+
+	if err := s.KillAllSessions(ctx, user.ID, SessionRevokeKill); err != nil {
+		t.Fatal(err)
+	}
+	for _, claims := range []*systemReq.CustomClaims{first, second} {
+		if err := s.CheckSession(ctx, claims); !errors.Is(err, ErrSessionRevoked) {
+			t.Fatalf("强制下线后访问令牌应失效, got %v", err)
+		}
+	}
+	if n, _ := global.GVA_REDIS.HLen(ctx, sessionKey(sessionRegistryKey, user.UUID)).Result(); n != 0 {
+		t.Fatalf("登记表应清空, 剩余 %d", n)
+	}
+	var revoked int64
+	global.GVA_DB.Model(&system.SysUserSession{}).Where("user_id = ? AND revoke_reason = ?", user.ID, SessionRevokeKill).Count(&revoked)
+	if revoked != 2 {
+		t.Fatalf("数据库中应吊销 2 个会话, got %d", revoked)
+	}
+	sessions, err := s.ListSessions(ctx, user.ID, "")
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("ListSessions() = %v, %v", sessions, err)
+	}
+}
@@ -1,8 +1,10 @@
 package system
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common"
@@ -23,6 +25,8 @@ import (
 
 type UserService struct{}
 
+var ErrOldPasswordMismatch = errors.New("原密码错误")
+
 var UserServiceApp = new(UserService)
 
 func (userService *UserService) Register(u system.SysUser) (userInter system.SysUser, err error) {
@@ -30,10 +34,20 @@ func (userService *UserService) Register(u system.SysUser) (userInter system.Sys
 	if !errors.Is(global.GVA_DB.Where("username = ?", u.Username).First(&user).Error, gorm.ErrRecordNotFound) { // 判断用户名是否注册
 		return userInter, errors.New("用户名已注册")
 	}
+	if err = userService.checkNewPassword(0, u.Username, u.Password); err != nil {
+		return userInter, err
+	}
 	// 否则 附加uuid 密码hash加密 注册
+	now := time.Now()
 	u.Password = utils.BcryptHash(u.Password)
 	u.UUID = uuid.New()
-	err = global.GVA_DB.Create(&u).Error
+	u.PasswordChangedAt = &now
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
+		return recordPasswordHistory(tx, u.ID, u.Password)
+	})
 	if err != nil {
 		return userInter, err
 	}
@@ -52,6 +66,10 @@ func (userService *UserService) Login(u *system.SysUser) (userInter *system.SysU
 	if nil == global.GVA_DB {
 		return nil, fmt.Errorf("db not init")
 	}
+	// 开启LDAP后除应急账号外均由目录认证
+	if LdapServiceApp.IsDirectoryUser(u.Username) {
+		return LdapServiceApp.Login(u.Username, u.Password)
+	}
 
 	var user system.SysUser
 	err = global.GVA_DB.Where("username = ?", u.Username).Preload("Authorities").Preload("Authority").First(&user).Error
@@ -72,16 +90,25 @@ func (userService *UserService) Login(u *system.SysUser) (userInter *system.SysU
 
 func (userService *UserService) ChangePassword(u *system.SysUser, newPassword string) (err error) {
 	var user system.SysUser
-	err = global.GVA_DB.Select("id, password").Where("id = ?", u.ID).First(&user).Error
+	err = global.GVA_DB.Select("id, username, password").Where("id = ?", u.ID).First(&user).Error
 	if err != nil {
 		return err
 	}
+	if LdapServiceApp.IsDirectoryUser(user.Username) {
+		return ErrLdapManagedPassword
+	}
 	if ok := utils.BcryptCheck(u.Password, user.Password); !ok {
-		return errors.New("原密码错误")
+		return ErrOldPasswordMismatch
 	}
-	pwd := utils.BcryptHash(newPassword)
-	err = global.GVA_DB.Model(&user).Update("password", pwd).Error
-	return err
+	if utils.BcryptCheck(newPassword, user.Password) {
+		return fmt.Errorf("%w: 新密码不能与当前密码相同", utils.ErrPasswordPolicy)
+	}
+	if err = userService.checkNewPassword(user.ID, user.Username, newPassword); err != nil {
+		return err
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		return savePassword(tx, user.ID, utils.BcryptHash(newPassword), false)
+	})
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -207,6 +234,82 @@ func (userService *UserService) SetUserAuthorities(adminAuthorityID, id uint, au
 	})
 }
 
+// AuthoritiesChanged 判断设置角色后用户的角色集合或主角色（authorityIds[0]）是否会发生变化
+func (userService *UserService) AuthoritiesChanged(id uint, authorityIds []uint) (bool, error) {
+	if len(authorityIds) == 0 {
+		return false, nil
+	}
+	var user system.SysUser
+	if err := global.GVA_DB.Select("authority_id").Where("id = ?", id).First(&user).Error; err != nil {
+		return false, err
+	}
+	if user.AuthorityId != authorityIds[0] {
+		return true, nil
+	}
+	var current []uint
+	err := global.GVA_DB.Model(&system.SysUserAuthority{}).Where("sys_user_id = ?", id).Pluck("sys_authority_authority_id", &current).Error
+	if err != nil {
+		return false, err
+	}
+	return !sameAuthoritySet(current, authorityIds), nil
+}
+
+// syncUserAuthorities 将用户角色替换为外部身份源（单点登录、LDAP）的映射结果，当前角色不在其中时切换为第一个；
+// 角色有变化时递增会话版本，使按旧角色签发的访问令牌失效
+func syncUserAuthorities(ctx context.Context, userId uint, authorityIds []uint) error {
+	changed := false
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var user system.SysUser
+		if err := tx.Select("id", "authority_id").Where("id = ?", userId).First(&user).Error; err != nil {
+			return err
+		}
+		var current []uint
+		if err := tx.Model(&system.SysUserAuthority{}).Where("sys_user_id = ?", userId).Pluck("sys_authority_authority_id", &current).Error; err != nil {
+			return err
+		}
+		keep := slices.Contains(authorityIds, user.AuthorityId)
+		if keep && sameAuthoritySet(current, authorityIds) {
+			return nil
+		}
+		changed = true
+		if err := tx.Delete(&[]system.SysUserAuthority{}, "sys_user_id = ?", userId).Error; err != nil {
+			return err
+		}
+		rows := make([]system.SysUserAuthority, 0, len(authorityIds))
+		for _, id := range authorityIds {
+			rows = append(rows, system.SysUserAuthority{SysUserId: userId, SysAuthorityAuthorityId: id})
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+		if keep {
+			return nil
+		}
+		return tx.Model(&system.SysUser{}).Where("id = ?", userId).Update("authority_id", authorityIds[0]).Error
+	})
+	if err != nil || !changed {
+		return err
+	}
+	_, err = UserSessionServiceApp.BumpSessionVersion(ctx, userId)
+	return err
+}
+
+// sameAuthoritySet 判断两组角色ID是否相同（忽略顺序和重复）
+func sameAuthoritySet(current, authorityIds []uint) bool {
+	want := make(map[uint]bool, len(authorityIds))
+	for _, v := range authorityIds {
+		want[v] = true
+	}
+	have := make(map[uint]bool, len(current))
+	for _, v := range current {
+		if !want[v] {
+			return false
+		}
+		have[v] = true
+	}
+	return len(have) == len(want)
+}
+
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: DeleteUser
 //@description: 删除用户
@@ -296,6 +399,17 @@ func (userService *UserService) FindUserById(id int) (user *system.SysUser, err
 	return &u, err
 }
 
+// GetLoginUser 按ID加载登录所需的用户信息（角色及默认路由），用于两步验证通过后签发token
+func (userService *UserService) GetLoginUser(id uint) (*system.SysUser, error) {
+	var user system.SysUser
+	err := global.GVA_DB.Where("id = ?", id).Preload("Authorities").Preload("Authority").First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	MenuServiceApp.UserAuthorityDefaultRouter(&user)
+	return &user, nil
+}
+
 //@author: [SliverHorn](https://github.com/SliverHorn)
 //@function: FindUserByUuid
 //@description: 通过uuid获取用户信息
@@ -314,11 +428,26 @@ func (userService *UserService) FindUserByUuid(uuid string) (user *system.SysUse
 
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: ResetPassword
-//@description: 修改用户密码
-//@param: ID uint
-//@return: err error
+//@description: 重置用户密码，未指定密码时生成随机密码，按策略要求用户下次登录时修改
+//@param: ID uint, password string
+//@return: newPassword string, err error
 
-func (userService *UserService) ResetPassword(ID uint, password string) (err error) {
-	err = global.GVA_DB.Model(&system.SysUser{}).Where("id = ?", ID).Update("password", utils.BcryptHash(password)).Error
-	return err
+func (userService *UserService) ResetPassword(ID uint, password string) (newPassword string, err error) {
+	var user system.SysUser
+	if err = global.GVA_DB.Select("id, username").Where("id = ?", ID).First(&user).Error; err != nil {
+		return "", err
+	}
+	// 未指定密码时生成满足策略的随机密码，不再使用固定的默认密码
+	if password == "" {
+		if password, err = utils.GeneratePassword(global.GVA_CONFIG.PasswordPolicy); err != nil {
+			return "", err
+		}
+	}
+	if err = userService.checkNewPassword(user.ID, user.Username, password); err != nil {
+		return "", err
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		return savePassword(tx, user.ID, utils.BcryptHash(password), global.GVA_CONFIG.PasswordPolicy.ForceChangeOnReset)
+	})
+	return password, err
 }
@@ -0,0 +1,223 @@
+package system
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultRefreshExpires 未配置 refresh-expires-time 时刷新令牌的有效期
+	defaultRefreshExpires = 7 * 24 * time.Hour
+
+	SessionRevokeLogout = "logout"  // 用户退出登录
+	SessionRevokeManual = "revoked" // 用户或管理员手动下线
+	SessionRevokeReuse  = "reuse"   // 已轮换的刷新令牌被再次使用，整族吊销
+	SessionRevokeKill   = "killed"  // 管理员强制下线、冻结或删除用户
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("刷新令牌无效或已过期，请重新登录")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，为保障安全该会话已下线，请重新登录")
+)
+
+type UserSessionService struct{}
+
+var UserSessionServiceApp = new(UserSessionService)
+
+// CreateSession 登录成功后创建会话并签发第一个刷新令牌
+func (userSessionService *UserSessionService) CreateSession(userId uint, userAgent, ip string) (session system.SysUserSession, refreshToken string, err error) {
+	now := time.Now()
+	session = system.SysUserSession{
+		SessionId:    uuid.NewString(),
+		UserId:       userId,
+		UserAgent:    truncate(userAgent, 512),
+		Ip:           ip,
+		LastActiveAt: now,
+		ExpiresAt:    now.Add(refreshExpires()),
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		refreshToken, err = issueRefreshToken(tx, session.SessionId, session.ExpiresAt)
+		return err
+	})
+	return session, refreshToken, err
+}
+
+// Refresh 使用刷新令牌换取新令牌，返回会话信息与新的刷新令牌
+// 旧令牌通过条件更新标记为已使用，同一令牌并发或重复提交时只有一次成功，其余视为盗用并吊销整个会话
+func (userSessionService *UserSessionService) Refresh(refreshToken, userAgent, ip string) (session system.SysUserSession, newToken string, err error) {
+	var token system.SysRefreshToken
+	if err = global.GVA_DB.Where("token_hash = ?", hashRefreshToken(refreshToken)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, "", ErrRefreshTokenInvalid
+		}
+		return session, "", err
+	}
+	if err = global.GVA_DB.Where("session_id = ?", token.SessionId).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, "", ErrRefreshTokenInvalid
+		}
+		return session, "", err
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || !token.ExpiresAt.After(now) {
+		return session, "", ErrRefreshTokenInvalid
+	}
+
+	result := global.GVA_DB.Model(&system.SysRefreshToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return session, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		global.GVA_LOG.Warn("检测到刷新令牌重复使用，吊销会话",
+			zap.Uint("userId", session.UserId), zap.String("sessionId", session.SessionId), zap.String("ip", ip))
+		if err := userSessionService.RevokeSession(session.SessionId, SessionRevokeReuse); err != nil {
+			global.GVA_LOG.Error("吊销会话失败!", zap.Error(err))
+		}
+		return session, "", ErrRefreshTokenReused
+	}
+
+	session.ExpiresAt = now.Add(refreshExpires())
+	session.LastActiveAt = now
+	session.Ip = ip
+	session.UserAgent = truncate(userAgent, 512)
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&system.SysUserSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+			"expires_at":     session.ExpiresAt,
+			"last_active_at": session.LastActiveAt,
+			"ip":             session.Ip,
+			"user_agent":     session.UserAgent,
+		}).Error; err != nil {
+			return err
+		}
+		newToken, err = issueRefreshToken(tx, session.SessionId, session.ExpiresAt)
+		return err
+	})
+	return session, newToken, err
+}
+
+// GetUserSessions 获取用户当前有效的会话
+func (userSessionService *UserSessionService) GetUserSessions(userId uint) (list []system.SysUserSession, err error) {
+	err = global.GVA_DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("last_active_at desc").Find(&list).Error
+	return list, err
+}
+
+// RevokeUserSession 吊销用户的某个会话
+func (userSessionService *UserSessionService) RevokeUserSession(userId uint, sessionId string) error {
+	result := global.GVA_DB.Model(&system.SysUserSession{}).
+		Where("session_id = ? AND user_id = ? AND revoked_at IS NULL", sessionId, userId).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": SessionRevokeManual})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("会话不存在或已下线")
+	}
+	unregisterSessions(context.Background(), userId, sessionId)
+	return nil
+}
+
+// RevokeSession 吊销会话，会话内所有刷新令牌随之失效
+func (userSessionService *UserSessionService) RevokeSession(sessionId, reason string) error {
+	var session system.SysUserSession
+	if err := global.GVA_DB.Select("user_id").Where("session_id = ?", sessionId).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	err := global.GVA_DB.Model(&system.SysUserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionId).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason}).Error
+	if err != nil {
+		return err
+	}
+	unregisterSessions(context.Background(), session.UserId, sessionId)
+	return nil
+}
+
+// RevokeUserSessions 吊销用户除 exceptSessionId 外的全部会话
+func (userSessionService *UserSessionService) RevokeUserSessions(userId uint, exceptSessionId, reason string) error {
+	db := global.GVA_DB.Model(&system.SysUserSession{}).Where("user_id = ? AND revoked_at IS NULL", userId)
+	if exceptSessionId != "" {
+		db = db.Where("session_id <> ?", exceptSessionId)
+	}
+	var sessionIds []string
+	if err := db.Session(&gorm.Session{}).Pluck("session_id", &sessionIds).Error; err != nil {
+		return err
+	}
+	if len(sessionIds) == 0 {
+		return nil
+	}
+	err := global.GVA_DB.Model(&system.SysUserSession{}).
+		Where("session_id IN ? AND revoked_at IS NULL", sessionIds).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason}).Error
+	if err != nil {
+		return err
+	}
+	unregisterSessions(context.Background(), userId, sessionIds...)
+	return nil
+}
+
+// PruneExpired 清理已过期的刷新令牌，以及过期或吊销超过一个有效期的会话
+func (userSessionService *UserSessionService) PruneExpired() error {
+	now := time.Now()
+	if err := global.GVA_DB.Where("expires_at < ?", now).Delete(&system.SysRefreshToken{}).Error; err != nil {
+		return err
+	}
+	before := now.Add(-refreshExpires())
+	return global.GVA_DB.Unscoped().
+		Where("expires_at < ?", before).
+		Or("revoked_at < ?", before).
+		Delete(&system.SysUserSession{}).Error
+}
+
+// issueRefreshToken 生成随机刷新令牌并保存摘要
+func issueRefreshToken(tx *gorm.DB, sessionId string, expiresAt time.Time) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	err := tx.Create(&system.SysRefreshToken{
+		SessionId: sessionId,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: expiresAt,
+	}).Error
+	return token, err
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func refreshExpires() time.Duration {
+	if dr, err := utils.ParseDuration(global.GVA_CONFIG.JWT.RefreshExpiresTime); err == nil && dr > 0 {
+		return dr
+	}
+	return defaultRefreshExpires
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package system
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func setupSessionDB(t *testing.T) {
+	t.Helper()
+	globaltest.DB(t, &system.SysUserSession{}, &system.SysRefreshToken{})
+}
+
+func TestUserSessionService_RefreshRotation(t *testing.T) {
+	setupSessionDB(t)
+	s := &UserSessionService{}
+
+	session, first, err := s.CreateSession(1, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := s.Refresh(first, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("首次刷新应成功: %v", err)
+	}
+	if second == first {
+		t.Fatal("刷新后应返回新的刷新令牌")
+	}
+
+	// 已轮换的令牌再次使用：判定为盗用，整族吊销
+	if _, _, err = s.Refresh(first, "attacker", "10.0.0.1"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("重复使用旧令牌应返回 ErrRefreshTokenReused, got %v", err)
+	}
+	if _, _, err = s.Refresh(second, "test-agent", "127.0.0.1"); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("会话吊销后最新令牌也应失效, got %v", err)
+	}
+
+	var revoked system.SysUserSession
+	global.GVA_DB.Where("session_id = ?", session.SessionId).First(&revoked)
+	if revoked.RevokedAt == nil || revoked.RevokeReason != SessionRevokeReuse {
+		t.Fatalf("会话应以 reuse 原因吊销, got %+v", revoked)
+	}
+}
+
+func TestUserSessionService_RevokeUserSession(t *testing.T) {
+	setupSessionDB(t)
+	s := &UserSessionService{}
+
+	a, _, _ := s.CreateSession(1, "a", "127.0.0.1")
+	_, tokenB, _ := s.CreateSession(1, "b", "127.0.0.1")
+	if err := s.RevokeUserSession(2, a.SessionId); err == nil {
+		t.Fatal("不能下线其他用户的会话")
+	}
+	if err := s.RevokeUserSessions(1, a.SessionId, SessionRevokeManual); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Refresh(tokenB, "b", "127.0.0.1"); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("被下线会话的刷新令牌应失效, got %v", err)
+	}
+	list, _ := s.GetUserSessions(1)
+	if len(list) != 1 || list[0].SessionId != a.SessionId {
+		t.Fatalf("应只剩当前会话, got %d", len(list))
+	}
+}
@@ -0,0 +1,338 @@
+package system
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// totpLoginChallengeKey 两步验证登录挑战键前缀，值为用户ID
+	totpLoginChallengeKey = "totp_login"
+	// totpLoginChallengeTTL 密码校验通过后输入动态码的有效时长
+	totpLoginChallengeTTL = 5 * time.Minute
+	// totpLoginMaxAttempts 单个登录挑战允许的动态码错误次数，超过后需重新输入密码
+	totpLoginMaxAttempts = 5
+	// totpRecoveryCodeCount 每次绑定生成的恢复码数量
+	totpRecoveryCodeCount = 10
+)
+
+var (
+	ErrTotpNotEnabled      = errors.New("未启用两步验证")
+	ErrTotpAlreadyEnabled  = errors.New("已启用两步验证，请先关闭后再重新绑定")
+	ErrTotpInvalidCode     = errors.New("两步验证码错误")
+	ErrTotpChallengeExpire = errors.New("登录已过期，请重新输入用户名和密码")
+)
+
+type UserTotpService struct{}
+
+// TotpSetup 绑定时返回给用户的信息，恢复码只在此时以明文出现
+type TotpSetup struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// IsRequired 用户的任一角色开启了强制两步验证
+func (userTotpService *UserTotpService) IsRequired(user *system.SysUser) bool {
+	if user.Authority.Require2FA {
+		return true
+	}
+	for _, authority := range user.Authorities {
+		if authority.Require2FA {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEnabled 用户是否已完成两步验证绑定
+func (userTotpService *UserTotpService) IsEnabled(userId uint) (bool, error) {
+	var count int64
+	err := global.GVA_DB.Model(&system.SysUserTotp{}).Where("user_id = ? AND enabled = ?", userId, true).Count(&count).Error
+	return count > 0, err
+}
+
+// Setup 生成新的密钥与恢复码，保存为未启用状态，用户输入动态码确认后才生效
+func (userTotpService *UserTotpService) Setup(user *system.SysUser) (setup TotpSetup, err error) {
+	var record system.SysUserTotp
+	err = global.GVA_DB.Where("user_id = ?", user.ID).First(&record).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return setup, err
+	}
+	if record.Enabled {
+		return setup, ErrTotpAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTotpSecret()
+	if err != nil {
+		return setup, err
+	}
+	encrypted, err := encryptTotpSecret(secret)
+	if err != nil {
+		return setup, err
+	}
+	codes, err := utils.GenerateRecoveryCodes(totpRecoveryCodeCount)
+	if err != nil {
+		return setup, err
+	}
+	hashed, err := hashRecoveryCodes(codes)
+	if err != nil {
+		return setup, err
+	}
+
+	record.UserId = user.ID
+	record.Secret = encrypted
+	record.RecoveryCodes = hashed
+	record.LastStep = 0
+	if err = global.GVA_DB.Save(&record).Error; err != nil {
+		return setup, err
+	}
+	return TotpSetup{
+		Secret:        secret,
+		URI:           utils.TotpProvisioningURI(secret, global.GVA_CONFIG.JWT.Issuer, user.Username),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// Enable 校验动态码后启用两步验证
+func (userTotpService *UserTotpService) Enable(userId uint, code string) error {
+	var record system.SysUserTotp
+	if err := global.GVA_DB.Where("user_id = ?", userId).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("请先获取绑定二维码")
+		}
+		return err
+	}
+	if record.Enabled {
+		return ErrTotpAlreadyEnabled
+	}
+	secret, err := decryptTotpSecret(record.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := utils.ValidateTotp(secret, code, time.Now())
+	if !ok {
+		return ErrTotpInvalidCode
+	}
+	now := time.Now()
+	return global.GVA_DB.Model(&record).Updates(map[string]interface{}{
+		"enabled":    true,
+		"enabled_at": &now,
+		"last_step":  step,
+	}).Error
+}
+
+// Verify 校验动态码或恢复码，返回是否使用了恢复码
+// 动态码与恢复码均通过条件更新消费，并发请求中同一个码只会成功一次
+func (userTotpService *UserTotpService) Verify(userId uint, code string) (usedRecovery bool, err error) {
+	var record system.SysUserTotp
+	if err = global.GVA_DB.Where("user_id = ? AND enabled = ?", userId, true).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrTotpNotEnabled
+		}
+		return false, err
+	}
+	secret, err := decryptTotpSecret(record.Secret)
+	if err != nil {
+		return false, err
+	}
+
+	if step, ok := utils.ValidateTotp(secret, code, time.Now()); ok {
+		result := global.GVA_DB.Model(&system.SysUserTotp{}).
+			Where("id = ? AND last_step < ?", record.ID, step).
+			Update("last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return false, ErrTotpInvalidCode
+		}
+		return false, nil
+	}
+
+	var hashes []string
+	if record.RecoveryCodes != "" {
+		if err = json.Unmarshal([]byte(record.RecoveryCodes), &hashes); err != nil {
+			return false, err
+		}
+	}
+	idx := slices.Index(hashes, utils.HashRecoveryCode(code))
+	if idx < 0 {
+		return false, ErrTotpInvalidCode
+	}
+	remaining, err := json.Marshal(slices.Delete(hashes, idx, idx+1))
+	if err != nil {
+		return false, err
+	}
+	result := global.GVA_DB.Model(&system.SysUserTotp{}).
+		Where("id = ? AND recovery_codes = ?", record.ID, record.RecoveryCodes).
+		Update("recovery_codes", string(remaining))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, ErrTotpInvalidCode
+	}
+	return true, nil
+}
+
+// Disable 用户自行关闭两步验证，角色强制要求时不允许关闭
+func (userTotpService *UserTotpService) Disable(user *system.SysUser, code string) error {
+	if userTotpService.IsRequired(user) {
+		return errors.New("当前角色要求必须启用两步验证")
+	}
+	if _, err := userTotpService.Verify(user.ID, code); err != nil {
+		return err
+	}
+	return userTotpService.Reset(user.ID)
+}
+
+// Reset 删除用户的两步验证绑定，用于用户关闭或管理员重置（如手机丢失）
+func (userTotpService *UserTotpService) Reset(userId uint) error {
+	return global.GVA_DB.Unscoped().Where("user_id = ?", userId).Delete(&system.SysUserTotp{}).Error
+}
+
+// RemainingRecoveryCodes 未使用的恢复码数量
+func (userTotpService *UserTotpService) RemainingRecoveryCodes(userId uint) (int, error) {
+	var record system.SysUserTotp
+	if err := global.GVA_DB.Select("recovery_codes").Where("user_id = ?", userId).First(&record).Error; err != nil {
+		return 0, err
+	}
+	var hashes []string
+	if record.RecoveryCodes != "" {
+		if err := json.Unmarshal([]byte(record.RecoveryCodes), &hashes); err != nil {
+			return 0, err
+		}
+	}
+	return len(hashes), nil
+}
+
+// CreateLoginChallenge 密码校验通过后创建登录挑战，返回的凭证用于第二步提交动态码。
+// 启用 Redis 时多实例共享，否则保存在进程内缓存
+func (userTotpService *UserTotpService) CreateLoginChallenge(ctx context.Context, userId uint) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	key := fmt.Sprintf("%s:%s", totpLoginChallengeKey, token)
+	if global.GVA_REDIS == nil {
+		global.BlackCache.Set(key, userId, totpLoginChallengeTTL)
+		return token, nil
+	}
+	if err := global.GVA_REDIS.Set(ctx, key, userId, totpLoginChallengeTTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CheckLoginChallenge 读取登录挑战对应的用户ID，并累计尝试次数，超过上限后挑战作废
+func (userTotpService *UserTotpService) CheckLoginChallenge(ctx context.Context, token string) (uint, error) {
+	if token == "" {
+		return 0, ErrTotpChallengeExpire
+	}
+	key := fmt.Sprintf("%s:%s", totpLoginChallengeKey, token)
+	if global.GVA_REDIS == nil {
+		v, ok := global.BlackCache.Get(key)
+		userId, _ := v.(uint)
+		if !ok || userId == 0 {
+			return 0, ErrTotpChallengeExpire
+		}
+		if (localGuardCounter{}).incr(ctx, key+":attempts", totpLoginChallengeTTL) > totpLoginMaxAttempts {
+			userTotpService.ClearLoginChallenge(ctx, token)
+			return 0, ErrTotpChallengeExpire
+		}
+		return userId, nil
+	}
+	userId, err := global.GVA_REDIS.Get(ctx, key).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrTotpChallengeExpire
+	}
+	if err != nil {
+		return 0, err
+	}
+	attemptsKey := key + ":attempts"
+	attempts, err := global.GVA_REDIS.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if attempts == 1 {
+		global.GVA_REDIS.Expire(ctx, attemptsKey, totpLoginChallengeTTL)
+	}
+	if attempts > totpLoginMaxAttempts {
+		userTotpService.ClearLoginChallenge(ctx, token)
+		return 0, ErrTotpChallengeExpire
+	}
+	return uint(userId), nil
+}
+
+// ClearLoginChallenge 登录完成或作废后删除挑战
+func (userTotpService *UserTotpService) ClearLoginChallenge(ctx context.Context, token string) {
+	key := fmt.Sprintf("%s:%s", totpLoginChallengeKey, token)
+	if global.GVA_REDIS == nil {
+		global.BlackCache.Delete(key)
+		global.BlackCache.Delete(key + ":attempts")
+		return
+	}
+	global.GVA_REDIS.Del(ctx, key, key+":attempts")
+}
+
+// totpEncryptionKey 由 totp.encryption-key 派生 AES-256 密钥，未配置时返回错误
+func totpEncryptionKey() ([]byte, error) {
+	if global.GVA_CONFIG.Totp.EncryptionKey == "" {
+		return nil, errors.New("未配置 totp.encryption-key，无法使用两步验证")
+	}
+	sum := sha256.Sum256([]byte(global.GVA_CONFIG.Totp.EncryptionKey))
+	return sum[:], nil
+}
+
+func encryptTotpSecret(secret string) (string, error) {
+	key, err := totpEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := utils.EncryptAESGCM(secret, key)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(encrypted)
+	return string(data), err
+}
+
+func decryptTotpSecret(stored string) (string, error) {
+	var encrypted utils.EncryptedData
+	if err := json.Unmarshal([]byte(stored), &encrypted); err != nil {
+		return "", err
+	}
+	key, err := totpEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	secret, err := utils.DecryptAESGCM(&encrypted, key)
+	if err != nil {
+		return "", fmt.Errorf("两步验证密钥解密失败: %w", err)
+	}
+	return secret, nil
+}
+
+func hashRecoveryCodes(codes []string) (string, error) {
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashRecoveryCode(code))
+	}
+	data, err := json.Marshal(hashes)
+	return string(data), err
+}
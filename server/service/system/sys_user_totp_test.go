@@ -0,0 +1,99 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func TestUserTotpService_LoginChallenge(t *testing.T) {
+	for _, withRedis := range []bool{false, true} {
+		name := "local"
+		if withRedis {
+			name = "redis"
+		}
+		t.Run(name, func(t *testing.T) {
+			globaltest.DB(t)
+			if withRedis {
+				globaltest.Redis(t)
+			}
+			s := &UserTotpService{}
+			ctx := context.Background()
+
+			token, err := s.CreateLoginChallenge(ctx, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < totpLoginMaxAttempts; i++ {
+				if userId, err := s.CheckLoginChallenge(ctx, token); err != nil || userId != 7 {
+					t.Fatalf("第%d次校验: userId = %d, err = %v", i+1, userId, err)
+				}
+			}
+			// 超过尝试次数后挑战作废
+			if _, err = s.CheckLoginChallenge(ctx, token); !errors.Is(err, ErrTotpChallengeExpire) {
+				t.Fatalf("超过尝试次数 err = %v", err)
+			}
+			if _, err = s.CheckLoginChallenge(ctx, token); !errors.Is(err, ErrTotpChallengeExpire) {
+				t.Fatalf("作废后 err = %v", err)
+			}
+
+			token, _ = s.CreateLoginChallenge(ctx, 8)
+			s.ClearLoginChallenge(ctx, token)
+			if _, err = s.CheckLoginChallenge(ctx, token); !errors.Is(err, ErrTotpChallengeExpire) {
+				t.Fatalf("清除后 err = %v", err)
+			}
+			if _, err = s.CheckLoginChallenge(ctx, ""); !errors.Is(err, ErrTotpChallengeExpire) {
+				t.Fatalf("空凭证 err = %v", err)
+			}
+		})
+	}
+}
+
+func TestUserTotpService_VerifyConsumesCodes(t *testing.T) {
+	globaltest.DB(t, &system.SysUserTotp{})
+	global.GVA_CONFIG.Totp.EncryptionKey = "totp-test-key"
+	s := &UserTotpService{}
+	user := &system.SysUser{GVA_MODEL: global.GVA_MODEL{ID: 1}, Username: "admin"}
+
+	setup, err := s.Setup(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Verify(user.ID, "000000"); !errors.Is(err, ErrTotpNotEnabled) {
+		t.Fatalf("未启用时 err = %v", err)
+	}
+	// 启用使用上一个时间步的动态码，登录时当前时间步的动态码仍可使用
+	previous, _ := utils.TotpCode(setup.Secret, utils.TotpStep(time.Now())-1)
+	if err = s.Enable(user.ID, previous); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Setup(user); !errors.Is(err, ErrTotpAlreadyEnabled) {
+		t.Fatalf("重复绑定 err = %v", err)
+	}
+
+	code, _ := utils.TotpCode(setup.Secret, utils.TotpStep(time.Now()))
+	if usedRecovery, err := s.Verify(user.ID, code); err != nil || usedRecovery {
+		t.Fatalf("动态码: usedRecovery = %v, err = %v", usedRecovery, err)
+	}
+	// 同一时间步的动态码不能重放
+	if _, err = s.Verify(user.ID, code); !errors.Is(err, ErrTotpInvalidCode) {
+		t.Fatalf("重放动态码 err = %v", err)
+	}
+
+	recovery := setup.RecoveryCodes[0]
+	if usedRecovery, err := s.Verify(user.ID, recovery); err != nil || !usedRecovery {
+		t.Fatalf("恢复码: usedRecovery = %v, err = %v", usedRecovery, err)
+	}
+	if _, err = s.Verify(user.ID, recovery); !errors.Is(err, ErrTotpInvalidCode) {
+		t.Fatalf("重复使用恢复码 err = %v", err)
+	}
+	if remaining, err := s.RemainingRecoveryCodes(user.ID); err != nil || remaining != totpRecoveryCodeCount-1 {
+		t.Fatalf("remaining = %d, err = %v", remaining, err)
+	}
+}
@@ -2,14 +2,18 @@ package system
 
 import (
 	"context"
+	"strconv"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
-	"gorm.io/gorm"
 )
 
 type SysVersionService struct{}
 
+var SysVersionServiceApp = new(SysVersionService)
+
 // CreateSysVersion 创建版本管理记录
 // Author [yourname](https://github.com/yourname)
 func (sysVersionService *SysVersionService) CreateSysVersion(ctx context.Context, sysVersion *system.SysVersion) (err error) {
@@ -86,145 +90,57 @@ func (sysVersionService *SysVersionService) GetApisByIds(ctx context.Context, id
 	return
 }
 
-// GetDictionariesByIds 根据ID列表获取字典数据
-func (sysVersionService *SysVersionService) GetDictionariesByIds(ctx context.Context, ids []uint) (dictionaries []system.SysDictionary, err error) {
-	err = global.GVA_DB.Where("id in ?", ids).Preload("SysDictionaryDetails").Find(&dictionaries).Error
-	return
-}
-
-// ImportMenus 导入菜单数据
-func (sysVersionService *SysVersionService) ImportMenus(ctx context.Context, menus []system.SysBaseMenu) error {
-	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
-		// 递归创建菜单
-		return sysVersionService.createMenusRecursively(tx, menus, 0)
-	})
+// GetVersionDictionaries 根据ID列表获取字典数据，字典项按层级组装
+func (sysVersionService *SysVersionService) GetVersionDictionaries(ctx context.Context, ids []uint) (dictionaries []systemReq.VersionDictionary, err error) {
+	var list []system.SysDictionary
+	if err = global.GVA_DB.WithContext(ctx).Where("id in ?", ids).Preload("SysDictionaryDetails").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for _, dict := range list {
+		dictionaries = append(dictionaries, systemReq.VersionDictionary{SysDictionaryTransfer: system.SysDictionaryTransfer{
+			Name:    dict.Name,
+			Type:    dict.Type,
+			Status:  dict.Status,
+			Desc:    dict.Desc,
+			Details: dictionaryTransferItems(dict.SysDictionaryDetails),
+		}})
+	}
+	return dictionaries, nil
 }
 
-// createMenusRecursively 递归创建菜单
-func (sysVersionService *SysVersionService) createMenusRecursively(tx *gorm.DB, menus []system.SysBaseMenu, parentId uint) error {
-	for _, menu := range menus {
-		// 检查菜单是否已存在
-		var existingMenu system.SysBaseMenu
-		if err := tx.Where("name = ? AND path = ?", menu.Name, menu.Path).First(&existingMenu).Error; err == nil {
-			// 菜单已存在，使用现有菜单ID继续处理子菜单
-			if len(menu.Children) > 0 {
-				if err := sysVersionService.createMenusRecursively(tx, menu.Children, existingMenu.ID); err != nil {
-					return err
-				}
-			}
-			continue
-		}
-
-		// 保存参数和按钮数据，稍后处理
-		parameters := menu.Parameters
-		menuBtns := menu.MenuBtn
-		children := menu.Children
-
-		// 创建新菜单（不包含关联数据）
-		newMenu := system.SysBaseMenu{
-			ParentId:  parentId,
-			Path:      menu.Path,
-			Name:      menu.Name,
-			Hidden:    menu.Hidden,
-			Component: menu.Component,
-			Sort:      menu.Sort,
-			Meta:      menu.Meta,
-		}
-
-		if err := tx.Create(&newMenu).Error; err != nil {
-			return err
-		}
-
-		// 创建参数
-		if len(parameters) > 0 {
-			for _, param := range parameters {
-				newParam := system.SysBaseMenuParameter{
-					SysBaseMenuID: newMenu.ID,
-					Type:          param.Type,
-					Key:           param.Key,
-					Value:         param.Value,
-				}
-				if err := tx.Create(&newParam).Error; err != nil {
-					return err
-				}
-			}
-		}
-
-		// 创建菜单按钮
-		if len(menuBtns) > 0 {
-			for _, btn := range menuBtns {
-				newBtn := system.SysBaseMenuBtn{
-					SysBaseMenuID: newMenu.ID,
-					Name:          btn.Name,
-					Desc:          btn.Desc,
-				}
-				if err := tx.Create(&newBtn).Error; err != nil {
-					return err
-				}
-			}
-		}
-
-		// 递归处理子菜单
-		if len(children) > 0 {
-			if err := sysVersionService.createMenusRecursively(tx, children, newMenu.ID); err != nil {
-				return err
-			}
-		}
+// GetVersionCasbinRules 获取角色的API权限
+func (sysVersionService *SysVersionService) GetVersionCasbinRules(ctx context.Context, authorityIds []uint) (rules []systemReq.VersionCasbinRule, err error) {
+	ids := make([]string, 0, len(authorityIds))
+	for _, id := range authorityIds {
+		ids = append(ids, strconv.FormatUint(uint64(id), 10))
+	}
+	var list []gormadapter.CasbinRule
+	err = global.GVA_DB.WithContext(ctx).Where("ptype = ? AND v0 in ?", "p", ids).Order("v0").Order("v1").Order("v2").Find(&list).Error
+	for _, rule := range list {
+		rules = append(rules, systemReq.VersionCasbinRule{AuthorityId: rule.V0, Path: rule.V1, Method: rule.V2})
 	}
-	return nil
+	return rules, err
 }
 
-// ImportApis 导入API数据
-func (sysVersionService *SysVersionService) ImportApis(apis []system.SysApi) error {
-	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
-		for _, api := range apis {
-			// 检查API是否已存在
-			var existingApi system.SysApi
-			if err := tx.Where("path = ? AND method = ?", api.Path, api.Method).First(&existingApi).Error; err == nil {
-				// API已存在，跳过
-				continue
-			}
-
-			// 创建新API
-			newApi := system.SysApi{
-				Path:        api.Path,
-				Description: api.Description,
-				ApiGroup:    api.ApiGroup,
-				Method:      api.Method,
-			}
-
-			if err := tx.Create(&newApi).Error; err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+// GetVersionAuthorityBtns 获取角色的按钮权限，菜单与按钮以名称表示
+func (sysVersionService *SysVersionService) GetVersionAuthorityBtns(ctx context.Context, authorityIds []uint) (btns []systemReq.VersionAuthorityBtn, err error) {
+	err = global.GVA_DB.WithContext(ctx).Table("sys_authority_btns").
+		Select("sys_authority_btns.authority_id AS authority_id, sys_base_menus.name AS menu_name, sys_base_menu_btns.name AS btn_name").
+		Joins("JOIN sys_base_menus ON sys_base_menus.id = sys_authority_btns.sys_menu_id AND sys_base_menus.deleted_at IS NULL").
+		Joins("JOIN sys_base_menu_btns ON sys_base_menu_btns.id = sys_authority_btns.sys_base_menu_btn_id AND sys_base_menu_btns.deleted_at IS NULL").
+		Where("sys_authority_btns.authority_id in ?", authorityIds).
+		Order("sys_authority_btns.authority_id").Order("sys_base_menus.name").Order("sys_base_menu_btns.name").
+		Scan(&btns).Error
+	return
 }
 
-// ImportDictionaries 导入字典数据
-func (sysVersionService *SysVersionService) ImportDictionaries(dictionaries []system.SysDictionary) error {
-	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
-		for _, dict := range dictionaries {
-			// 检查字典是否已存在
-			var existingDict system.SysDictionary
-			if err := tx.Where("type = ?", dict.Type).First(&existingDict).Error; err == nil {
-				// 字典已存在，跳过
-				continue
-			}
-
-			// 创建新字典
-			newDict := system.SysDictionary{
-				Name:                 dict.Name,
-				Type:                 dict.Type,
-				Status:               dict.Status,
-				Desc:                 dict.Desc,
-				SysDictionaryDetails: dict.SysDictionaryDetails,
-			}
-
-			if err := tx.Create(&newDict).Error; err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+// GetVersionExportTemplates 根据ID列表获取导出模板，清除ID与时间字段
+func (sysVersionService *SysVersionService) GetVersionExportTemplates(ctx context.Context, ids []uint) (templates []system.SysExportTemplate, err error) {
+	if err = global.GVA_DB.WithContext(ctx).Where("id in ?", ids).Preload("Conditions").Preload("JoinTemplate").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		templates[i] = cleanExportTemplate(templates[i], templates[i].TemplateID)
+	}
+	return templates, nil
 }
@@ -0,0 +1,1027 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 版本包中的数据类型，用于预览结果与单项处理方式的键
+const (
+	versionKindMenu           = "menu"
+	versionKindMenuBtn        = "menuBtn"
+	versionKindApi            = "api"
+	versionKindDictionary     = "dictionary"
+	versionKindCasbin         = "casbin"
+	versionKindAuthorityBtn   = "authorityBtn"
+	versionKindExportTemplate = "exportTemplate"
+
+	versionActionCreate = "create"
+)
+
+var (
+	ErrVersionSignature  = errors.New("版本包签名无效，文件可能被修改或来自其他环境")
+	ErrVersionUnsigned   = errors.New("只允许导入已签名的版本包")
+	ErrVersionNoRollback = errors.New("该版本不是导入记录，无法回滚")
+	ErrVersionRolledBack = errors.New("该版本已回滚")
+
+	// errVersionPreview 预览在事务中执行完整导入后以该错误回滚
+	errVersionPreview = errors.New("version preview")
+)
+
+// versionPackageKeys 参与签名的版本包字段，导入选项与签名本身不参与
+var versionPackageKeys = []string{"version", "menus", "apis", "dictionaries", "casbin", "authorityBtns", "exportTemplates"}
+
+// versionOptionKeys 版本包中不参与签名的字段
+var versionOptionKeys = []string{"signature", "strategy", "resolutions"}
+
+// decodeVersionPackage 按原样解析版本包，数字保留原文以免签名因浮点转换而变化
+func decodeVersionPackage(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("解析版本包失败: %w", err)
+	}
+	return raw, nil
+}
+
+// versionSignature 计算版本包签名：取版本包字段按键排序序列化后做 HMAC-SHA256，不受字段顺序与缩进影响
+func versionSignature(raw map[string]interface{}, key string) (string, error) {
+	payload := make(map[string]interface{}, len(versionPackageKeys))
+	for _, k := range versionPackageKeys {
+		if v, ok := raw[k]; ok && v != nil {
+			payload[k] = v
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// SignVersionPackage 为导出的版本包追加签名，未配置签名密钥时原样返回
+func (sysVersionService *SysVersionService) SignVersionPackage(data []byte) ([]byte, error) {
+	key := global.GVA_CONFIG.VersionPackage.SignKey
+	if key == "" {
+		return data, nil
+	}
+	raw, err := decodeVersionPackage(data)
+	if err != nil {
+		return nil, err
+	}
+	if raw["signature"], err = versionSignature(raw, key); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(raw, "", "  ")
+}
+
+// parseVersionPackage 校验签名并解析版本包，signed 表示签名有效
+func parseVersionPackage(data []byte) (pkg systemReq.ImportVersionRequest, signed bool, err error) {
+	raw, err := decodeVersionPackage(data)
+	if err != nil {
+		return pkg, false, err
+	}
+	// json.Unmarshal 匹配键名不区分大小写且后出现的生效，只接受已知的字段名，
+	// 避免在签名范围之外追加 "MENUS" 之类的键覆盖已签名的内容
+	for k := range raw {
+		if !slices.Contains(versionPackageKeys, k) && !slices.Contains(versionOptionKeys, k) {
+			return pkg, false, fmt.Errorf("版本包包含未知字段 %s", k)
+		}
+	}
+	conf := global.GVA_CONFIG.VersionPackage
+	if signature, _ := raw["signature"].(string); signature != "" {
+		if conf.SignKey == "" {
+			return pkg, false, errors.New("未配置版本包签名密钥，无法校验签名")
+		}
+		expected, err := versionSignature(raw, conf.SignKey)
+		if err != nil {
+			return pkg, false, err
+		}
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			return pkg, false, ErrVersionSignature
+		}
+		signed = true
+	} else if conf.RequireSignature {
+		return pkg, false, ErrVersionUnsigned
+	}
+	// 从校验过的字段重新序列化后解析，保证导入的内容与签名内容一致
+	canonical, err := json.Marshal(raw)
+	if err != nil {
+		return pkg, false, err
+	}
+	if err = json.Unmarshal(canonical, &pkg); err != nil {
+		return pkg, false, fmt.Errorf("解析版本包失败: %w", err)
+	}
+	if pkg.VersionInfo.Name == "" || pkg.VersionInfo.Code == "" {
+		return pkg, false, errors.New("版本信息格式错误")
+	}
+	return pkg, signed, nil
+}
+
+// versionRef 导入时新建的数据，回滚时删除
+type versionRef struct {
+	Kind string `json:"kind"`
+	ID   uint   `json:"id,omitempty"`
+	Key  string `json:"key,omitempty"`
+}
+
+// versionDictionarySnapshot 被覆盖前的字典
+type versionDictionarySnapshot struct {
+	ID uint `json:"id"`
+	system.SysDictionaryTransfer
+}
+
+// versionUndo 导入前的数据快照，回滚时先恢复被覆盖的数据，再倒序删除新建的数据
+type versionUndo struct {
+	Created         []versionRef                `json:"created"`
+	Menus           []system.SysBaseMenu        `json:"menus"`
+	Apis            []system.SysApi             `json:"apis"`
+	Dictionaries    []versionDictionarySnapshot `json:"dictionaries"`
+	ExportTemplates []system.SysExportTemplate  `json:"exportTemplates"`
+}
+
+// versionPromotion 一次版本包导入，预览与导入执行相同的流程
+type versionPromotion struct {
+	tx          *gorm.DB
+	strategy    string
+	resolutions map[string]string
+	suffix      string
+	// invalidTemplates 在事务外预先校验的导出模板，键为模板标识
+	invalidTemplates map[string]string
+
+	items               []systemRes.VersionDiffItem
+	undo                versionUndo
+	casbinChanged       bool
+	dictionariesChanged bool
+}
+
+func validVersionStrategy(strategy string) bool {
+	switch strategy {
+	case system.VersionStrategySkip, system.VersionStrategyOverwrite, system.VersionStrategyRename:
+		return true
+	}
+	return false
+}
+
+func newVersionPromotion(pkg *systemReq.ImportVersionRequest) (*versionPromotion, error) {
+	p := &versionPromotion{strategy: pkg.Strategy, resolutions: pkg.Resolutions, invalidTemplates: make(map[string]string)}
+	if p.strategy == "" {
+		p.strategy = system.VersionStrategySkip
+	}
+	if !validVersionStrategy(p.strategy) {
+		return nil, fmt.Errorf("不支持的处理方式 %s", p.strategy)
+	}
+	for key, action := range p.resolutions {
+		if !validVersionStrategy(action) {
+			return nil, fmt.Errorf("%s 的处理方式 %s 不支持", key, action)
+		}
+	}
+	p.suffix = "_" + strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, pkg.VersionInfo.Code)
+
+	// 模板校验会查询数据库结构，在事务外进行以免占用事务连接
+	for _, template := range pkg.ExportTemplate {
+		if template.TemplateID == "" {
+			continue
+		}
+		if err := SysExportTemplateServiceApp.ValidateExportTemplate(template); err != nil {
+			p.invalidTemplates[template.TemplateID] = err.Error()
+		}
+	}
+	return p, nil
+}
+
+func (p *versionPromotion) run(pkg *systemReq.ImportVersionRequest) error {
+	if err := p.importMenus(pkg.ExportMenu, 0, ""); err != nil {
+		return err
+	}
+	if err := p.importApis(pkg.ExportApi); err != nil {
+		return err
+	}
+	if err := p.importDictionaries(pkg.ExportDictionary); err != nil {
+		return err
+	}
+	if err := p.importExportTemplates(pkg.ExportTemplate); err != nil {
+		return err
+	}
+	if err := p.importCasbin(pkg.ExportCasbin); err != nil {
+		return err
+	}
+	// 按钮权限依赖菜单与按钮，最后处理
+	return p.importAuthorityBtns(pkg.ExportAuthorityBtn)
+}
+
+// refresh 导入或回滚提交后刷新权限与字典缓存
+func (p *versionPromotion) refresh() {
+	if p.casbinChanged {
+		if err := CasbinServiceApp.FreshCasbin(); err != nil {
+			global.GVA_LOG.Error("刷新casbin失败", zap.Error(err))
+		}
+	}
+	if p.dictionariesChanged {
+		invalidateDictionaries()
+	}
+}
+
+func (p *versionPromotion) preview(signed bool) systemRes.VersionPreview {
+	summary := make(map[string]int)
+	for _, item := range p.items {
+		summary[item.Action]++
+	}
+	return systemRes.VersionPreview{Signed: signed, Summary: summary, Items: p.items}
+}
+
+func (p *versionPromotion) created(kind string, id uint, key string) {
+	p.undo.Created = append(p.undo.Created, versionRef{Kind: kind, ID: id, Key: key})
+}
+
+// plan 比较目标环境与版本包中的字段，一致时跳过，不同时按单项或默认处理方式处理
+func (p *versionPromotion) plan(kind, key string, before, after map[string]interface{}) systemRes.VersionDiffItem {
+	item := systemRes.VersionDiffItem{Kind: kind, Key: key, Status: systemRes.VersionItemSame, Action: system.VersionStrategySkip}
+	fields := make([]string, 0, len(after))
+	for field := range after {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			item.Changes = append(item.Changes, systemRes.VersionFieldChange{Field: field, Before: before[field], After: after[field]})
+		}
+	}
+	if len(item.Changes) > 0 {
+		item.Status = systemRes.VersionItemConflict
+		item.Action = p.strategy
+		if action, ok := p.resolutions[kind+":"+key]; ok {
+			item.Action = action
+		}
+	}
+	return item
+}
+
+// rename 返回重命名后的标识，新标识同样已存在时改为跳过
+func (p *versionPromotion) rename(item *systemRes.VersionDiffItem, model interface{}, column string) (string, error) {
+	target := item.Key + p.suffix
+	var count int64
+	if err := p.tx.Model(model).Where(column+" = ?", target).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		item.Action = system.VersionStrategySkip
+		item.Message = fmt.Sprintf("重命名后的 %s 已存在，已跳过", target)
+		return "", nil
+	}
+	item.Target = target
+	return target, nil
+}
+
+// versionComparable 将值转换为 JSON 结构，使比较不受 nil 与空集合等差异影响
+func versionComparable(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out interface{}
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+func menuFields(menu system.SysBaseMenu, parent string) map[string]interface{} {
+	parameters := make([]string, 0, len(menu.Parameters))
+	for _, param := range menu.Parameters {
+		parameters = append(parameters, param.Type+":"+param.Key+"="+param.Value)
+	}
+	sort.Strings(parameters)
+	buttons := make([]string, 0, len(menu.MenuBtn))
+	for _, btn := range menu.MenuBtn {
+		buttons = append(buttons, btn.Name+":"+btn.Desc)
+	}
+	sort.Strings(buttons)
+	return map[string]interface{}{
+		"parent":     parent,
+		"path":       menu.Path,
+		"hidden":     menu.Hidden,
+		"component":  menu.Component,
+		"sort":       menu.Sort,
+		"meta":       menu.Meta,
+		"parameters": parameters,
+		"buttons":    buttons,
+	}
+}
+
+// menuColumns 覆盖或恢复菜单时更新的字段
+var menuColumns = []string{"parent_id", "path", "hidden", "component", "sort", "active_name", "keep_alive", "default_menu", "title", "icon", "close_tab", "transition_type"}
+
+// importMenus 按层级导入菜单，以路由 name 识别同一菜单；被跳过的菜单的子菜单挂在目标环境的菜单下
+func (p *versionPromotion) importMenus(menus []system.SysBaseMenu, parentID uint, parentName string) error {
+	for _, menu := range menus {
+		var existing system.SysBaseMenu
+		err := p.tx.Preload("Parameters").Preload("MenuBtn").Where("name = ?", menu.Name).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		id, name := existing.ID, menu.Name
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if id, err = p.createMenu(menu, menu.Name, menu.Path, parentID); err != nil {
+				return err
+			}
+			p.items = append(p.items, systemRes.VersionDiffItem{Kind: versionKindMenu, Key: menu.Name, Status: systemRes.VersionItemNew, Action: versionActionCreate})
+		} else {
+			var existingParent string
+			if existing.ParentId != 0 {
+				var parent system.SysBaseMenu
+				if err = p.tx.Select("name").First(&parent, existing.ParentId).Error; err == nil {
+					existingParent = parent.Name
+				}
+			}
+			item := p.plan(versionKindMenu, menu.Name, menuFields(existing, existingParent), menuFields(menu, parentName))
+			switch item.Action {
+			case system.VersionStrategyOverwrite:
+				p.undo.Menus = append(p.undo.Menus, existing)
+				menu.ParentId = parentID
+				if err = p.updateMenu(existing.ID, menu); err != nil {
+					return err
+				}
+			case system.VersionStrategyRename:
+				target, err := p.rename(&item, &system.SysBaseMenu{}, "name")
+				if err != nil {
+					return err
+				}
+				if target != "" {
+					if id, err = p.createMenu(menu, target, menu.Path+p.suffix, parentID); err != nil {
+						return err
+					}
+					name = target
+				}
+			}
+			p.items = append(p.items, item)
+		}
+		if err = p.importMenus(menu.Children, id, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *versionPromotion) createMenu(menu system.SysBaseMenu, name, path string, parentID uint) (uint, error) {
+	newMenu := system.SysBaseMenu{
+		ParentId:  parentID,
+		Path:      path,
+		Name:      name,
+		Hidden:    menu.Hidden,
+		Component: menu.Component,
+		Sort:      menu.Sort,
+		Meta:      menu.Meta,
+	}
+	if err := p.tx.Create(&newMenu).Error; err != nil {
+		return 0, err
+	}
+	p.created(versionKindMenu, newMenu.ID, name)
+	for _, param := range menu.Parameters {
+		newParam := system.SysBaseMenuParameter{SysBaseMenuID: newMenu.ID, Type: param.Type, Key: param.Key, Value: param.Value}
+		if err := p.tx.Create(&newParam).Error; err != nil {
+			return 0, err
+		}
+	}
+	for _, btn := range menu.MenuBtn {
+		newBtn := system.SysBaseMenuBtn{SysBaseMenuID: newMenu.ID, Name: btn.Name, Desc: btn.Desc}
+		if err := p.tx.Create(&newBtn).Error; err != nil {
+			return 0, err
+		}
+	}
+	return newMenu.ID, nil
+}
+
+// updateMenu 覆盖菜单字段与参数；按钮只新增与更新备注，不删除目标环境已有的按钮以保留按钮权限
+func (p *versionPromotion) updateMenu(id uint, menu system.SysBaseMenu) error {
+	if err := p.tx.Model(&system.SysBaseMenu{}).Where("id = ?", id).Select(menuColumns).Updates(&menu).Error; err != nil {
+		return err
+	}
+	if err := p.tx.Where("sys_base_menu_id = ?", id).Delete(&system.SysBaseMenuParameter{}).Error; err != nil {
+		return err
+	}
+	for _, param := range menu.Parameters {
+		newParam := system.SysBaseMenuParameter{SysBaseMenuID: id, Type: param.Type, Key: param.Key, Value: param.Value}
+		if err := p.tx.Create(&newParam).Error; err != nil {
+			return err
+		}
+	}
+	for _, btn := range menu.MenuBtn {
+		var existing system.SysBaseMenuBtn
+		err := p.tx.Where("sys_base_menu_id = ? AND name = ?", id, btn.Name).First(&existing).Error
+		switch {
+		case err == nil:
+			if existing.Desc != btn.Desc {
+				if err = p.tx.Model(&existing).Update("desc", btn.Desc).Error; err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			newBtn := system.SysBaseMenuBtn{SysBaseMenuID: id, Name: btn.Name, Desc: btn.Desc}
+			if err = p.tx.Create(&newBtn).Error; err != nil {
+				return err
+			}
+			p.created(versionKindMenuBtn, newBtn.ID, btn.Name)
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// importApis 导入API，以 method+path 识别；API 无法重命名，rename 按跳过处理
+func (p *versionPromotion) importApis(apis []system.SysApi) error {
+	for _, api := range apis {
+		key := api.Method + " " + api.Path
+		var existing system.SysApi
+		err := p.tx.Where("path = ? AND method = ?", api.Path, api.Method).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			newApi := system.SysApi{Path: api.Path, Description: api.Description, ApiGroup: api.ApiGroup, Method: api.Method}
+			if err = p.tx.Create(&newApi).Error; err != nil {
+				return err
+			}
+			p.created(versionKindApi, newApi.ID, key)
+			p.items = append(p.items, systemRes.VersionDiffItem{Kind: versionKindApi, Key: key, Status: systemRes.VersionItemNew, Action: versionActionCreate})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fields := func(a system.SysApi) map[string]interface{} {
+			return map[string]interface{}{"description": a.Description, "apiGroup": a.ApiGroup}
+		}
+		item := p.plan(versionKindApi, key, fields(existing), fields(api))
+		switch item.Action {
+		case system.VersionStrategyOverwrite:
+			p.undo.Apis = append(p.undo.Apis, existing)
+			if err = p.tx.Model(&existing).Updates(map[string]interface{}{"description": api.Description, "api_group": api.ApiGroup}).Error; err != nil {
+				return err
+			}
+		case system.VersionStrategyRename:
+			item.Action = system.VersionStrategySkip
+			item.Message = "API 不支持重命名，已跳过"
+		}
+		p.items = append(p.items, item)
+	}
+	return nil
+}
+
+// versionDictionary 取版本包中的字典，旧版本包的扁平字典项转换为顶级字典项
+func versionDictionary(d systemReq.VersionDictionary) system.SysDictionaryTransfer {
+	dict := d.SysDictionaryTransfer
+	if len(dict.Details) == 0 {
+		for _, detail := range d.SysDictionaryDetails {
+			dict.Details = append(dict.Details, system.SysDictionaryTransferItem{
+				Label:  detail.Label,
+				Value:  detail.Value,
+				Extend: detail.Extend,
+				Status: detail.Status,
+				Sort:   detail.Sort,
+				Labels: dictionaryLabels(detail.Labels),
+			})
+		}
+	}
+	return dict
+}
+
+func dictionaryFields(dict system.SysDictionaryTransfer) map[string]interface{} {
+	return map[string]interface{}{
+		"name":    dict.Name,
+		"status":  dict.Status == nil || *dict.Status,
+		"desc":    dict.Desc,
+		"details": versionComparable(dict.Details),
+	}
+}
+
+// importDictionaries 导入字典，以 type 识别；覆盖时替换全部字典项
+func (p *versionPromotion) importDictionaries(dictionaries []systemReq.VersionDictionary) error {
+	for _, d := range dictionaries {
+		dict := versionDictionary(d)
+		if err := validateDictionaryTransfer(&dict); err != nil {
+			p.items = append(p.items, systemRes.VersionDiffItem{Kind: versionKindDictionary, Key: dict.Type, Status: systemRes.VersionItemInvalid, Action: system.VersionStrategySkip, Message: err.Error()})
+			continue
+		}
+		var existing system.SysDictionary
+		err := p.tx.Preload("SysDictionaryDetails").Where("type = ?", dict.Type).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err = p.createDictionary(dict, dict.Type); err != nil {
+				return err
+			}
+			p.items = append(p.items, systemRes.VersionDiffItem{Kind: versionKindDictionary, Key: dict.Type, Status: systemRes.VersionItemNew, Action: versionActionCreate})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		before := system.SysDictionaryTransfer{
+			Name:    existing.Name,
+			Type:    existing.Type,
+			Status:  existing.Status,
+			Desc:    existing.Desc,
+			Details: dictionaryTransferItems(existing.SysDictionaryDetails),
+		}
+		item := p.plan(versionKindDictionary, dict.Type, dictionaryFields(before), dictionaryFields(dict))
+		switch item.Action {
+		case system.VersionStrategyOverwrite:
+			p.undo.Dictionaries = append(p.undo.Dictionaries, versionDictionarySnapshot{ID: existing.ID, SysDictionaryTransfer: before})
+			if err = replaceDictionary(p.tx, existing.ID, dict); err != nil {
+				return err
+			}
+			p.dictionariesChanged = true
+		case system.VersionStrategyRename:
+			target, err := p.rename(&item, &system.SysDictionary{}, "type")
+			if err != nil {
+				return err
+			}
+			if target != "" {
+				if err = p.createDictionary(dict, target); err != nil {
+					return err
+				}
+			}
+		}
+		p.items = append(p.items, item)
+	}
+	return nil
+}
+
+func (p *versionPromotion) createDictionary(dict system.SysDictionaryTransfer, t string) error {
+	newDict := system.SysDictionary{Name: dict.Name, Type: t, Status: dict.Status, Desc: dict.Desc}
+	if err := p.tx.Create(&newDict).Error; err != nil {
+		return err
+	}
+	p.created(versionKindDictionary, newDict.ID, t)
+	p.dictionariesChanged = true
+	return createDictionaryTransferItems(p.tx, int(newDict.ID), 0, dict.Details)
+}
+
+// replaceDictionary 更新字典并替换其全部字典项
+func replaceDictionary(tx *gorm.DB, id uint, dict system.SysDictionaryTransfer) error {
+	if err := tx.Model(&system.SysDictionary{}).Where("id = ?", id).Updates(map[string]interface{}{"name": dict.Name, "status": dict.Status, "desc": dict.Desc}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("sys_dictionary_id = ?", id).Delete(&system.SysDictionaryDetail{}).Error; err != nil {
+		return err
+	}
+	return createDictionaryTransferItems(tx, int(id), 0, dict.Details)
+}
+
+// cleanExportTemplate 复制导出模板，清除ID与时间字段并使用指定的模板标识
+func cleanExportTemplate(template system.SysExportTemplate, templateID string) system.SysExportTemplate {
+	clean := system.SysExportTemplate{
+		DBName:       template.DBName,
+		Name:         template.Name,
+		TableName:    template.TableName,
+		TemplateID:   templateID,
+		TemplateInfo: template.TemplateInfo,
+		Limit:        template.Limit,
+		Order:        template.Order,
+		ImportKey:    template.ImportKey,
+		ImportRules:  template.ImportRules,
+	}
+	for _, condition := range template.Conditions {
+		clean.Conditions = append(clean.Conditions, system.Condition{TemplateID: templateID, From: condition.From, Column: condition.Column, Operator: condition.Operator})
+	}
+	for _, join := range template.JoinTemplate {
+		clean.JoinTemplate = append(clean.JoinTemplate, system.JoinTemplate{TemplateID: templateID, JOINS: join.JOINS, Table: join.Table, ON: join.ON, JoinOn: join.JoinOn})
+	}
+	return clean
+}
+
+func exportTemplateFields(template system.SysExportTemplate) map[string]interface{} {
+	conditions := make([]string, 0, len(template.Conditions))
+	for _, condition := range template.Conditions {
+		conditions = append(conditions, condition.From+" "+condition.Column+" "+condition.Operator)
+	}
+	sort.Strings(conditions)
+	joins := make([]string, 0, len(template.JoinTemplate))
+	for _, join := range template.JoinTemplate {
+		on := make([]string, 0, len(join.JoinOn))
+		for _, o := range join.JoinOn {
+			on = append(on, o.LeftTable+"."+o.LeftColumn+" "+o.Operator+" "+o.RightTable+"."+o.RightColumn)
+		}
+		joins = append(joins, join.JOINS+" "+join.Table+" ON "+join.ON+" "+strings.Join(on, " AND "))
+	}
+	sort.Strings(joins)
+	var limit interface{}
+	if template.Limit != nil {
+		limit = *template.Limit
+	}
+	return map[string]interface{}{
+		"name":         template.Name,
+		"dbName":       template.DBName,
+		"tableName":    template.TableName,
+		"templateInfo": template.TemplateInfo,
+		"limit":        limit,
+		"order":        template.Order,
+		"importKey":    template.ImportKey,
+		"importRules":  template.ImportRules,
+		"conditions":   conditions,
+		"joins":        joins,
+	}
+}
+
+// importExportTemplates 导入导出模板，以模板标识识别；未通过结构校验的模板不导入
+func (p *versionPromotion) importExportTemplates(templates []system.SysExportTemplate) error {
+	for _, template := range templates {
+		key := template.TemplateID
+		if key == "" {
+			p.items = append(p.items, systemRes.VersionDiffItem{Kind: versionKindExportTemplate, Key: template.Name, Status: systemRes.VersionItemInvalid, Action: system.VersionStrategySkip, Message: "模板标识不能为空"})
+			continue
+		}
+		if msg, ok := p.invalidTemplates[key]; ok {
+			p.items = append(p.items, systemRes.VersionDiffItem{Kind: versionKindExportTemplate, Key: key, Status: systemRes.VersionItemInvalid, Action: system.VersionStrategySkip, Message: msg})
+			continue
+		}
+		var existing system.SysExportTemplate
+		err := p.tx.Preload("Conditions").Preload("JoinTemplate").Where("template_id = ?", key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err = p.createExportTemplate(template, key); err != nil {
+				return err
+			}
+			p.items = append(p.items, systemRes.VersionDiffItem{Kind: versionKindExportTemplate, Key: key, Status: systemRes.VersionItemNew, Action: versionActionCreate})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		item := p.plan(versionKindExportTemplate, key, exportTemplateFields(existing), exportTemplateFields(template))
+		switch item.Action {
+		case system.VersionStrategyOverwrite:
+			p.undo.ExportTemplates = append(p.undo.ExportTemplates, existing)
+			if err = replaceExportTemplate(p.tx, existing.ID, cleanExportTemplate(template, key)); err != nil {
+				return err
+			}
+		case system.VersionStrategyRename:
+			target, err := p.rename(&item, &system.SysExportTemplate{}, "template_id")
+			if err != nil {
+				return err
+			}
+			if target != "" {
+				if err = p.createExportTemplate(template, target); err != nil {
+					return err
+				}
+			}
+		}
+		p.items = append(p.items, item)
+	}
+	return nil
+}
+
+func (p *versionPromotion) createExportTemplate(template system.SysExportTemplate, templateID string) error {
+	newTemplate := cleanExportTemplate(template, templateID)
+	if err := p.tx.Create(&newTemplate).Error; err != nil {
+		return err
+	}
+	p.created(versionKindExportTemplate, newTemplate.ID, templateID)
+	return nil
+}
+
+// replaceExportTemplate 更新导出模板并替换其条件与关联
+func replaceExportTemplate(tx *gorm.DB, id uint, template system.SysExportTemplate) error {
+	err := tx.Model(&system.SysExportTemplate{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"db_name":       template.DBName,
+		"name":          template.Name,
+		"table_name":    template.TableName,
+		"template_info": template.TemplateInfo,
+		"limit":         template.Limit,
+		"order":         template.Order,
+		"import_key":    template.ImportKey,
+		"import_rules":  template.ImportRules,
+	}).Error
+	if err != nil {
+		return err
+	}
+	if err = tx.Delete(&[]system.Condition{}, "template_id = ?", template.TemplateID).Error; err != nil {
+		return err
+	}
+	if err = tx.Delete(&[]system.JoinTemplate{}, "template_id = ?", template.TemplateID).Error; err != nil {
+		return err
+	}
+	for _, condition := range template.Conditions {
+		condition.ID, condition.TemplateID = 0, template.TemplateID
+		if err = tx.Create(&condition).Error; err != nil {
+			return err
+		}
+	}
+	for _, join := range template.JoinTemplate {
+		join.ID, join.TemplateID = 0, template.TemplateID
+		if err = tx.Create(&join).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *versionPromotion) authorityExists(authorityID string) (bool, error) {
+	var count int64
+	err := p.tx.Model(&system.SysAuthority{}).Where("authority_id = ?", authorityID).Count(&count).Error
+	return count > 0, err
+}
+
+// importCasbin 导入API权限，只新增目标环境缺少的权限，不删除已有权限
+func (p *versionPromotion) importCasbin(rules []systemReq.VersionCasbinRule) error {
+	for _, rule := range rules {
+		item := systemRes.VersionDiffItem{Kind: versionKindCasbin, Key: rule.AuthorityId + " " + rule.Method + " " + rule.Path, Status: systemRes.VersionItemSame, Action: system.VersionStrategySkip}
+		ok, err := p.authorityExists(rule.AuthorityId)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			item.Status, item.Message = systemRes.VersionItemInvalid, "角色不存在"
+			p.items = append(p.items, item)
+			continue
+		}
+		var count int64
+		if err = p.tx.Model(&gormadapter.CasbinRule{}).Where("ptype = ? AND v0 = ? AND v1 = ? AND v2 = ?", "p", rule.AuthorityId, rule.Path, rule.Method).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			newRule := gormadapter.CasbinRule{Ptype: "p", V0: rule.AuthorityId, V1: rule.Path, V2: rule.Method}
+			if err = p.tx.Create(&newRule).Error; err != nil {
+				return err
+			}
+			p.created(versionKindCasbin, newRule.ID, item.Key)
+			p.casbinChanged = true
+			item.Status, item.Action = systemRes.VersionItemNew, versionActionCreate
+		}
+		p.items = append(p.items, item)
+	}
+	return nil
+}
+
+// importAuthorityBtns 导入按钮权限，菜单或按钮在目标环境不存在时跳过
+func (p *versionPromotion) importAuthorityBtns(btns []systemReq.VersionAuthorityBtn) error {
+	for _, btn := range btns {
+		item := systemRes.VersionDiffItem{Kind: versionKindAuthorityBtn, Key: fmt.Sprintf("%d %s %s", btn.AuthorityId, btn.MenuName, btn.BtnName), Status: systemRes.VersionItemSame, Action: system.VersionStrategySkip}
+		ok, err := p.authorityExists(strconv.FormatUint(uint64(btn.AuthorityId), 10))
+		if err != nil {
+			return err
+		}
+		var menu system.SysBaseMenu
+		var menuBtn system.SysBaseMenuBtn
+		switch {
+		case !ok:
+			item.Message = "角色不存在"
+		case p.tx.Where("name = ?", btn.MenuName).First(&menu).Error != nil:
+			item.Message = "菜单不存在"
+		case p.tx.Where("sys_base_menu_id = ? AND name = ?", menu.ID, btn.BtnName).First(&menuBtn).Error != nil:
+			item.Message = "按钮不存在"
+		}
+		if item.Message != "" {
+			item.Status = systemRes.VersionItemInvalid
+			p.items = append(p.items, item)
+			continue
+		}
+		var count int64
+		if err = p.tx.Model(&system.SysAuthorityBtn{}).Where("authority_id = ? AND sys_menu_id = ? AND sys_base_menu_btn_id = ?", btn.AuthorityId, menu.ID, menuBtn.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err = p.tx.Create(&system.SysAuthorityBtn{AuthorityId: btn.AuthorityId, SysMenuID: menu.ID, SysBaseMenuBtnID: menuBtn.ID}).Error; err != nil {
+				return err
+			}
+			p.created(versionKindAuthorityBtn, 0, fmt.Sprintf("%d:%d:%d", btn.AuthorityId, menu.ID, menuBtn.ID))
+			item.Status, item.Action = systemRes.VersionItemNew, versionActionCreate
+		}
+		p.items = append(p.items, item)
+	}
+	return nil
+}
+
+//@function: PreviewVersion
+//@description: 预览版本包导入结果：在事务中执行完整导入后回滚，返回每项数据的比较结果与处理方式
+//@param: ctx context.Context, data []byte
+//@return: preview systemRes.VersionPreview, err error
+
+func (sysVersionService *SysVersionService) PreviewVersion(ctx context.Context, data []byte) (preview systemRes.VersionPreview, err error) {
+	pkg, signed, err := parseVersionPackage(data)
+	if err != nil {
+		return preview, err
+	}
+	p, err := newVersionPromotion(&pkg)
+	if err != nil {
+		return preview, err
+	}
+	err = global.GVA_DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		p.tx = tx
+		if err := p.run(&pkg); err != nil {
+			return err
+		}
+		return errVersionPreview
+	})
+	if !errors.Is(err, errVersionPreview) {
+		return preview, err
+	}
+	return p.preview(signed), nil
+}
+
+//@function: ImportVersion
+//@description: 导入版本包，全部成功或全部回滚；导入记录保存导入前的数据快照，可通过 RollbackVersion 恢复
+//@param: ctx context.Context, data []byte
+//@return: result systemRes.VersionImportResult, err error
+
+func (sysVersionService *SysVersionService) ImportVersion(ctx context.Context, data []byte) (result systemRes.VersionImportResult, err error) {
+	pkg, signed, err := parseVersionPackage(data)
+	if err != nil {
+		return result, err
+	}
+	p, err := newVersionPromotion(&pkg)
+	if err != nil {
+		return result, err
+	}
+	err = global.GVA_DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		p.tx = tx
+		if err := p.run(&pkg); err != nil {
+			return err
+		}
+		undo, err := json.Marshal(p.undo)
+		if err != nil {
+			return err
+		}
+		version := system.SysVersion{
+			VersionName:  utils.Pointer(pkg.VersionInfo.Name),
+			VersionCode:  utils.Pointer(fmt.Sprintf("%s_imported_%s", pkg.VersionInfo.Code, time.Now().Format("20060102150405"))),
+			Description:  utils.Pointer(fmt.Sprintf("导入版本: %s", pkg.VersionInfo.Description)),
+			VersionData:  utils.Pointer(string(data)),
+			RollbackData: utils.Pointer(string(undo)),
+		}
+		if err = tx.Create(&version).Error; err != nil {
+			return err
+		}
+		result.VersionID = version.ID
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	p.refresh()
+	result.VersionPreview = p.preview(signed)
+	return result, nil
+}
+
+//@function: RollbackVersion
+//@description: 将导入记录涉及的数据恢复到导入前：恢复被覆盖的数据并删除导入时新建的数据，之后对这些数据的修改会被覆盖
+//@param: ctx context.Context, ID string
+//@return: err error
+
+func (sysVersionService *SysVersionService) RollbackVersion(ctx context.Context, ID string) (err error) {
+	var version system.SysVersion
+	if err = global.GVA_DB.WithContext(ctx).Where("id = ?", ID).First(&version).Error; err != nil {
+		return err
+	}
+	if version.RollbackData == nil || *version.RollbackData == "" {
+		return ErrVersionNoRollback
+	}
+	if version.RolledBackAt != nil {
+		return ErrVersionRolledBack
+	}
+	var undo versionUndo
+	if err = json.Unmarshal([]byte(*version.RollbackData), &undo); err != nil {
+		return fmt.Errorf("导入前数据快照损坏: %w", err)
+	}
+	p := &versionPromotion{}
+	err = global.GVA_DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		p.tx = tx
+		if err := p.restore(undo); err != nil {
+			return err
+		}
+		for i := len(undo.Created) - 1; i >= 0; i-- {
+			if err := p.remove(undo.Created[i]); err != nil {
+				return err
+			}
+		}
+		// 条件带上 rolled_back_at 为空，避免并发回滚重复执行
+		res := tx.Model(&system.SysVersion{}).Where("id = ? AND rolled_back_at IS NULL", version.ID).Update("rolled_back_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrVersionRolledBack
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.refresh()
+	return nil
+}
+
+// restore 恢复被覆盖的数据
+func (p *versionPromotion) restore(undo versionUndo) error {
+	for _, menu := range undo.Menus {
+		if err := p.tx.Model(&system.SysBaseMenu{}).Where("id = ?", menu.ID).Select(menuColumns).Updates(&menu).Error; err != nil {
+			return err
+		}
+		if err := p.tx.Where("sys_base_menu_id = ?", menu.ID).Delete(&system.SysBaseMenuParameter{}).Error; err != nil {
+			return err
+		}
+		for _, param := range menu.Parameters {
+			newParam := system.SysBaseMenuParameter{SysBaseMenuID: menu.ID, Type: param.Type, Key: param.Key, Value: param.Value}
+			if err := p.tx.Create(&newParam).Error; err != nil {
+				return err
+			}
+		}
+		for _, btn := range menu.MenuBtn {
+			if err := p.tx.Model(&system.SysBaseMenuBtn{}).Where("id = ?", btn.ID).Update("desc", btn.Desc).Error; err != nil {
+				return err
+			}
+		}
+	}
+	for _, api := range undo.Apis {
+		if err := p.tx.Model(&system.SysApi{}).Where("id = ?", api.ID).Updates(map[string]interface{}{"description": api.Description, "api_group": api.ApiGroup}).Error; err != nil {
+			return err
+		}
+	}
+	for _, dict := range undo.Dictionaries {
+		if err := replaceDictionary(p.tx, dict.ID, dict.SysDictionaryTransfer); err != nil {
+			return err
+		}
+		p.dictionariesChanged = true
+	}
+	for _, template := range undo.ExportTemplates {
+		if err := replaceExportTemplate(p.tx, template.ID, cleanExportTemplate(template, template.TemplateID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove 删除导入时新建的数据
+func (p *versionPromotion) remove(ref versionRef) error {
+	tx := p.tx
+	switch ref.Kind {
+	case versionKindMenu:
+		if err := tx.Delete(&system.SysBaseMenuParameter{}, "sys_base_menu_id = ?", ref.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&system.SysBaseMenuBtn{}, "sys_base_menu_id = ?", ref.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&system.SysAuthorityBtn{}, "sys_menu_id = ?", ref.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&system.SysAuthorityMenu{}, "sys_base_menu_id = ?", ref.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&system.SysBaseMenu{}, "id = ?", ref.ID).Error
+	case versionKindMenuBtn:
+		if err := tx.Delete(&system.SysAuthorityBtn{}, "sys_base_menu_btn_id = ?", ref.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&system.SysBaseMenuBtn{}, "id = ?", ref.ID).Error
+	case versionKindApi:
+		return tx.Delete(&system.SysApi{}, "id = ?", ref.ID).Error
+	case versionKindDictionary:
+		p.dictionariesChanged = true
+		if err := tx.Delete(&system.SysDictionaryDetail{}, "sys_dictionary_id = ?", ref.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&system.SysDictionary{}, "id = ?", ref.ID).Error
+	case versionKindExportTemplate:
+		if err := tx.Delete(&[]system.Condition{}, "template_id = ?", ref.Key).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&[]system.JoinTemplate{}, "template_id = ?", ref.Key).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&system.SysExportTemplate{}, "id = ?", ref.ID).Error
+	case versionKindCasbin:
+		p.casbinChanged = true
+		return tx.Delete(&gormadapter.CasbinRule{}, "id = ?", ref.ID).Error
+	case versionKindAuthorityBtn:
+		var authorityID, menuID, btnID uint
+		if _, err := fmt.Sscanf(ref.Key, "%d:%d:%d", &authorityID, &menuID, &btnID); err != nil {
+			return fmt.Errorf("按钮权限快照格式错误: %s", ref.Key)
+		}
+		return tx.Delete(&system.SysAuthorityBtn{}, "authority_id = ? AND sys_menu_id = ? AND sys_base_menu_btn_id = ?", authorityID, menuID, btnID).Error
+	}
+	return fmt.Errorf("未知的数据类型 %s", ref.Kind)
+}
@@ -0,0 +1,207 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+)
+
+func setupVersionPromotion(t *testing.T) {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysAuthority{}, &system.SysBaseMenu{}, &system.SysBaseMenuParameter{}, &system.SysBaseMenuBtn{},
+		&system.SysAuthorityBtn{}, &system.SysApi{}, &system.SysDictionary{}, &system.SysDictionaryDetail{},
+		&system.SysExportTemplate{}, &system.Condition{}, &system.JoinTemplate{}, &system.SysVersion{}, &gormadapter.CasbinRule{})
+	clearDictionaryCache()
+
+	// 目标环境已有的数据
+	enabled := true
+	db.Create(&system.SysAuthority{AuthorityId: 888, AuthorityName: "admin"})
+	db.Create(&system.SysBaseMenu{Name: "report", Path: "report", Component: "view/report.vue", Meta: system.Meta{Title: "报表"}})
+	db.Create(&system.SysApi{Path: "/report/list", Method: "GET", ApiGroup: "报表", Description: "报表列表"})
+	db.Create(&system.SysDictionary{Name: "性别", Type: "gender", Status: &enabled})
+}
+
+func versionPackage(t *testing.T, strategy string, resolutions map[string]string) []byte {
+	t.Helper()
+	pkg := systemRes.ExportVersionResponse{
+		Version: systemReq.VersionInfo{Name: "发布", Code: "v2"},
+		Menus: []system.SysBaseMenu{{
+			Name: "report", Path: "report", Component: "view/report/index.vue", Meta: system.Meta{Title: "报表中心"},
+			MenuBtn:  []system.SysBaseMenuBtn{{Name: "export", Desc: "导出"}},
+			Children: []system.SysBaseMenu{{Name: "daily", Path: "daily", Component: "view/report/daily.vue", Meta: system.Meta{Title: "日报"}}},
+		}},
+		Apis: []system.SysApi{
+			{Path: "/report/list", Method: "GET", ApiGroup: "报表", Description: "报表列表"},
+			{Path: "/report/export", Method: "POST", ApiGroup: "报表", Description: "导出报表"},
+		},
+		Dictionaries: []systemReq.VersionDictionary{{
+			// 旧版本包中的扁平字典项
+			SysDictionaryTransfer: system.SysDictionaryTransfer{Name: "性别", Type: "gender"},
+			SysDictionaryDetails:  []system.SysDictionaryDetail{{Label: "男", Value: "1"}, {Label: "女", Value: "2"}},
+		}},
+		Casbin: []systemReq.VersionCasbinRule{
+			{AuthorityId: "888", Path: "/report/export", Method: "POST"},
+			{AuthorityId: "9528", Path: "/report/export", Method: "POST"},
+		},
+		AuthorityBtns: []systemReq.VersionAuthorityBtn{{AuthorityId: 888, MenuName: "report", BtnName: "export"}},
+		ExportTemplates: []system.SysExportTemplate{
+			{Name: "API", TableName: "sys_apis", TemplateID: "apis", TemplateInfo: `{"path":"路径","method":"方法"}`},
+			{Name: "bad", TableName: "sys_apis", TemplateID: "bad", TemplateInfo: `{"(select 1)":"x"}`},
+		},
+	}
+	data, err := json.Marshal(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	_ = json.Unmarshal(data, &raw)
+	raw["strategy"] = strategy
+	raw["resolutions"] = resolutions
+	data, _ = json.Marshal(raw)
+	return data
+}
+
+func diffItem(items []systemRes.VersionDiffItem, kind, key string) systemRes.VersionDiffItem {
+	for _, item := range items {
+		if item.Kind == kind && item.Key == key {
+			return item
+		}
+	}
+	return systemRes.VersionDiffItem{}
+}
+
+func TestVersionPromotion_PreviewImportRollback(t *testing.T) {
+	setupVersionPromotion(t)
+	ctx := context.Background()
+	data := versionPackage(t, system.VersionStrategyOverwrite, map[string]string{"dictionary:gender": system.VersionStrategyRename})
+
+	preview, err := SysVersionServiceApp.PreviewVersion(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	menu := diffItem(preview.Items, versionKindMenu, "report")
+	if menu.Status != systemRes.VersionItemConflict || menu.Action != system.VersionStrategyOverwrite || len(menu.Changes) != 3 {
+		t.Fatalf("menu = %+v", menu)
+	}
+	expected := map[string]string{
+		"menu:daily":                      versionActionCreate,
+		"api:GET /report/list":            system.VersionStrategySkip,
+		"api:POST /report/export":         versionActionCreate,
+		"dictionary:gender":               system.VersionStrategyRename,
+		"exportTemplate:apis":             versionActionCreate,
+		"exportTemplate:bad":              system.VersionStrategySkip,
+		"casbin:888 POST /report/export":  versionActionCreate,
+		"casbin:9528 POST /report/export": system.VersionStrategySkip,
+		"authorityBtn:888 report export":  versionActionCreate,
+	}
+	for key, action := range expected {
+		kind, k, _ := strings.Cut(key, ":")
+		if item := diffItem(preview.Items, kind, k); item.Action != action {
+			t.Errorf("%s = %+v, want action %s", key, item, action)
+		}
+	}
+	if item := diffItem(preview.Items, versionKindExportTemplate, "bad"); item.Status != systemRes.VersionItemInvalid {
+		t.Errorf("bad template = %+v", item)
+	}
+	// 预览不修改数据
+	var count int64
+	global.GVA_DB.Model(&system.SysBaseMenu{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("preview created menus: %d", count)
+	}
+
+	result, err := SysVersionServiceApp.ImportVersion(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != len(preview.Items) || result.VersionID == 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	var report, daily system.SysBaseMenu
+	global.GVA_DB.Preload("MenuBtn").First(&report, "name = ?", "report")
+	global.GVA_DB.First(&daily, "name = ?", "daily")
+	if report.Title != "报表中心" || len(report.MenuBtn) != 1 || daily.ParentId != report.ID {
+		t.Fatalf("report = %+v, daily = %+v", report, daily)
+	}
+	if got := DictionaryDetailServiceApp.TranslateDictionaryValue("gender_v2", "2", ""); got != "女" {
+		t.Fatalf("renamed dictionary value = %q", got)
+	}
+	global.GVA_DB.Model(&gormadapter.CasbinRule{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("casbin rules = %d", count)
+	}
+
+	if err = SysVersionServiceApp.RollbackVersion(ctx, strconv.Itoa(int(result.VersionID))); err != nil {
+		t.Fatal(err)
+	}
+	global.GVA_DB.Preload("MenuBtn").First(&report, "name = ?", "report")
+	if report.Title != "报表" || report.Component != "view/report.vue" || len(report.MenuBtn) != 0 {
+		t.Fatalf("report after rollback = %+v", report)
+	}
+	for _, model := range []interface{}{&system.SysApi{}, &system.SysDictionary{}, &system.SysBaseMenu{}} {
+		global.GVA_DB.Model(model).Count(&count)
+		if count != 1 {
+			t.Fatalf("%T count after rollback = %d", model, count)
+		}
+	}
+	for _, model := range []interface{}{&gormadapter.CasbinRule{}, &system.SysAuthorityBtn{}, &system.SysExportTemplate{}} {
+		global.GVA_DB.Model(model).Count(&count)
+		if count != 0 {
+			t.Fatalf("%T count after rollback = %d", model, count)
+		}
+	}
+	if err = SysVersionServiceApp.RollbackVersion(ctx, strconv.Itoa(int(result.VersionID))); !errors.Is(err, ErrVersionRolledBack) {
+		t.Fatalf("second rollback err = %v", err)
+	}
+}
+
+func TestVersionPromotion_Signature(t *testing.T) {
+	setupVersionPromotion(t)
+	ctx := context.Background()
+	data := versionPackage(t, "", nil)
+
+	global.GVA_CONFIG.VersionPackage.RequireSignature = true
+	if _, err := SysVersionServiceApp.PreviewVersion(ctx, data); !errors.Is(err, ErrVersionUnsigned) {
+		t.Fatalf("unsigned err = %v", err)
+	}
+
+	global.GVA_CONFIG.VersionPackage.SignKey = "promotion-key"
+	signed, err := SysVersionServiceApp.SignVersionPackage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 修改导入选项不影响签名
+	var raw map[string]interface{}
+	_ = json.Unmarshal(signed, &raw)
+	raw["strategy"] = system.VersionStrategyOverwrite
+	withOptions, _ := json.Marshal(raw)
+	preview, err := SysVersionServiceApp.PreviewVersion(ctx, withOptions)
+	if err != nil || !preview.Signed {
+		t.Fatalf("preview = %+v, err = %v", preview, err)
+	}
+
+	tampered := strings.Replace(string(signed), "/report/export", "/user/delete", 1)
+	if _, err = SysVersionServiceApp.PreviewVersion(ctx, []byte(tampered)); !errors.Is(err, ErrVersionSignature) {
+		t.Fatalf("tampered err = %v", err)
+	}
+	// 大小写不同的重复键不在签名范围内，但会覆盖解析结果
+	raw["APIS"] = []map[string]string{{"path": "/user/delete", "method": "DELETE", "apiGroup": "系统用户"}}
+	withDuplicate, _ := json.Marshal(raw)
+	if _, err = SysVersionServiceApp.PreviewVersion(ctx, withDuplicate); err == nil || !strings.Contains(err.Error(), "APIS") {
+		t.Fatalf("duplicate key err = %v", err)
+	}
+	global.GVA_CONFIG.VersionPackage.SignKey = "other-environment"
+	if _, err = SysVersionServiceApp.PreviewVersion(ctx, signed); !errors.Is(err, ErrVersionSignature) {
+		t.Fatalf("wrong key err = %v", err)
+	}
+}
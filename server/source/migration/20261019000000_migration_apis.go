@@ -0,0 +1,45 @@
+package migration
+
+import (
+	adapter "github.com/casbin/gorm-adapter/v3"
+	sysModel "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"gorm.io/gorm"
+)
+
+// 为已有数据库补充数据库迁移相关的 API 及超级管理员的权限
+var migrationApis = []sysModel.SysApi{
+	{ApiGroup: "数据库迁移", Method: "GET", Path: "/sysMigration/getMigrationStatus", Description: "获取迁移状态"},
+	{ApiGroup: "数据库迁移", Method: "POST", Path: "/sysMigration/migrate", Description: "执行迁移"},
+	{ApiGroup: "数据库迁移", Method: "POST", Path: "/sysMigration/rollbackMigration", Description: "回滚迁移"},
+}
+
+func init() {
+	system.RegisterMigration(system.Migration{
+		Version: "20261019000000",
+		Name:    "add migration apis",
+		Up: func(tx *gorm.DB) error {
+			for _, api := range migrationApis {
+				if err := tx.Where("path = ? AND method = ?", api.Path, api.Method).FirstOrCreate(&api).Error; err != nil {
+					return err
+				}
+				rule := adapter.CasbinRule{Ptype: "p", V0: "888", V1: api.Path, V2: api.Method}
+				if err := tx.Where(&rule).FirstOrCreate(&rule).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, api := range migrationApis {
+				if err := tx.Unscoped().Where("path = ? AND method = ?", api.Path, api.Method).Delete(&sysModel.SysApi{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("v1 = ? AND v2 = ?", api.Path, api.Method).Delete(&adapter.CasbinRule{}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
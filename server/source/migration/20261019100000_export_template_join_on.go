@@ -0,0 +1,61 @@
+package migration
+
+import (
+	"regexp"
+	"strings"
+
+	sysModel "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyJoinOnPattern 旧版导出模板关联条件中的单个 表.字段 = 表.字段
+var legacyJoinOnPattern = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\.([A-Za-z_][A-Za-z0-9_]*)\s*=\s*([A-Za-z_][A-Za-z0-9_]*)\.([A-Za-z_][A-Za-z0-9_]*)\s*$`)
+
+var legacyJoinAndPattern = regexp.MustCompile(`(?i)\s+and\s+`)
+
+// parseLegacyJoinOn 将旧版文本关联条件转换为结构化条件，无法识别时返回 false，
+// 这类模板保持原样，导出时会提示重新配置关联条件
+func parseLegacyJoinOn(on string) ([]sysModel.JoinOn, bool) {
+	var list []sysModel.JoinOn
+	for _, part := range legacyJoinAndPattern.Split(strings.TrimSpace(on), -1) {
+		m := legacyJoinOnPattern.FindStringSubmatch(strings.NewReplacer("`", "", `"`, "").Replace(part))
+		if m == nil {
+			return nil, false
+		}
+		list = append(list, sysModel.JoinOn{LeftTable: m[1], LeftColumn: m[2], Operator: "=", RightTable: m[3], RightColumn: m[4]})
+	}
+	return list, len(list) > 0
+}
+
+func init() {
+	system.RegisterMigration(system.Migration{
+		Version: "20261019100000",
+		Name:    "convert export template join conditions",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&sysModel.JoinTemplate{}, "JoinOn") {
+				if err := tx.Migrator().AddColumn(&sysModel.JoinTemplate{}, "JoinOn"); err != nil {
+					return err
+				}
+			}
+			var joins []sysModel.JoinTemplate
+			if err := tx.Where(clause.Neq{Column: clause.Column{Name: "on"}, Value: ""}).Find(&joins).Error; err != nil {
+				return err
+			}
+			for _, join := range joins {
+				if len(join.JoinOn) > 0 {
+					continue
+				}
+				on, ok := parseLegacyJoinOn(join.ON)
+				if !ok {
+					continue
+				}
+				if err := tx.Model(&join).Select("JoinOn").Updates(&sysModel.JoinTemplate{JoinOn: on}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
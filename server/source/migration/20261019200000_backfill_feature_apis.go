@@ -0,0 +1,161 @@
+package migration
+
+import (
+	adapter "github.com/casbin/gorm-adapter/v3"
+	sysModel "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"gorm.io/gorm"
+)
+
+// 为已有数据库补充 source/system 中新增的 API 及对应的角色权限，数据库迁移相关的 API 见 20261019000000。
+// 通过签名链接访问的导出文件下载接口无需鉴权，不录入 API
+var backfillApis = []sysModel.SysApi{
+	{ApiGroup: "系统用户", Method: "GET", Path: "/user/getTotpStatus", Description: "获取两步验证状态(必选)"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/setupTotp", Description: "生成两步验证绑定信息(必选)"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/enableTotp", Description: "启用两步验证(必选)"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/disableTotp", Description: "关闭两步验证(必选)"},
+	{ApiGroup: "系统用户", Method: "GET", Path: "/user/getSessions", Description: "获取登录设备列表(必选)"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/revokeSession", Description: "下线登录设备(必选)"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/getUserSessions", Description: "查看用户在线会话"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/killUserSession", Description: "强制下线用户会话"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/unlockLogin", Description: "解除登录锁定"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/getLoginLogList", Description: "分页获取登录记录"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/oidcBind", Description: "绑定外部账号"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/oidcBindCallback", Description: "绑定外部账号回调"},
+	{ApiGroup: "系统用户", Method: "GET", Path: "/user/getOidcBindings", Description: "获取绑定的外部账号"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/oidcUnbind", Description: "解除外部账号绑定"},
+	{ApiGroup: "系统用户", Method: "POST", Path: "/user/resetUserTotp", Description: "重置用户两步验证"},
+	{ApiGroup: "角色", Method: "POST", Path: "/authority/setRequire2FA", Description: "设置角色是否强制两步验证"},
+	{ApiGroup: "角色", Method: "POST", Path: "/authority/setDataRules", Description: "设置角色行级数据权限规则"},
+	{ApiGroup: "角色", Method: "GET", Path: "/authority/getDataRules", Description: "获取角色行级数据权限规则"},
+	{ApiGroup: "角色", Method: "GET", Path: "/authority/getDataScopeResources", Description: "获取可配置行级数据权限的资源"},
+	{ApiGroup: "casbin", Method: "POST", Path: "/casbin/simulateCasbin", Description: "模拟角色访问接口"},
+	{ApiGroup: "casbin", Method: "POST", Path: "/casbin/diffCasbin", Description: "预览角色api权限变更"},
+	{ApiGroup: "系统字典详情", Method: "GET", Path: "/sysDictionaryDetail/getDictionaryTree", Description: "按层级获取字典内容"},
+	{ApiGroup: "系统字典详情", Method: "POST", Path: "/sysDictionaryDetail/lookupDictionaries", Description: "批量翻译字典值"},
+	{ApiGroup: "系统字典", Method: "GET", Path: "/sysDictionary/exportSysDictionary", Description: "导出字典"},
+	{ApiGroup: "系统字典", Method: "POST", Path: "/sysDictionary/importSysDictionary", Description: "导入字典"},
+	{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/getOperationArchiveList", Description: "获取操作记录归档文件列表"},
+	{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/downloadOperationArchive", Description: "下载操作记录归档文件"},
+	{ApiGroup: "审计日志", Method: "GET", Path: "/sysAuditLog/getAuditLogList", Description: "分页获取审计记录"},
+	{ApiGroup: "审计日志", Method: "GET", Path: "/sysAuditLog/verifyAuditLogs", Description: "校验审计链"},
+	{ApiGroup: "审计日志", Method: "POST", Path: "/sysAuditLog/createAuditCheckpoint", Description: "创建审计链校验点"},
+	{ApiGroup: "导出模板", Method: "POST", Path: "/sysExportTemplate/createExportJob", Description: "创建异步导出任务"},
+	{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/getExportJobList", Description: "获取我的导出任务"},
+	{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/findExportJob", Description: "获取导出任务进度"},
+	{ApiGroup: "导出模板", Method: "POST", Path: "/sysExportTemplate/createExportSchedule", Description: "创建定时报表"},
+	{ApiGroup: "导出模板", Method: "PUT", Path: "/sysExportTemplate/updateExportSchedule", Description: "更新定时报表"},
+	{ApiGroup: "导出模板", Method: "DELETE", Path: "/sysExportTemplate/deleteExportSchedule", Description: "删除定时报表"},
+	{ApiGroup: "导出模板", Method: "POST", Path: "/sysExportTemplate/runExportSchedule", Description: "立即执行定时报表"},
+	{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/getExportScheduleList", Description: "获取定时报表列表"},
+	{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/getExportScheduleRunList", Description: "获取定时报表执行记录"},
+	{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/downloadExportScheduleRun", Description: "下载定时报表文件"},
+	{ApiGroup: "参数管理", Method: "GET", Path: "/sysParams/getSysParamsHistory", Description: "获取参数变更历史"},
+	{ApiGroup: "参数管理", Method: "POST", Path: "/sysParams/rollbackSysParams", Description: "回滚参数到指定版本"},
+	{ApiGroup: "版本控制", Method: "POST", Path: "/sysVersion/previewVersion", Description: "预览同步版本"},
+	{ApiGroup: "版本控制", Method: "POST", Path: "/sysVersion/rollbackVersion", Description: "回滚同步版本"},
+	{ApiGroup: "挂账收款", Method: "GET", Path: "/merPaySuspense/getMerPaySuspenseList", Description: "获取挂账收款列表"},
+	{ApiGroup: "挂账收款", Method: "POST", Path: "/merPaySuspense/matchMerPaySuspense", Description: "人工匹配挂账收款"},
+	{ApiGroup: "挂账收款", Method: "GET", Path: "/merPaySuspense/getMerPayMatchLogs", Description: "获取订单人工匹配记录"},
+	{ApiGroup: "风控", Method: "GET", Path: "/riskRuleHit/getRiskRuleHitList", Description: "获取风控命中记录列表"},
+}
+
+var backfillCasbinRules = []adapter.CasbinRule{
+	{Ptype: "p", V0: "888", V1: "/authority/setRequire2FA", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/authority/setDataRules", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/authority/getDataRules", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/authority/getDataScopeResources", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/user/getTotpStatus", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/user/setupTotp", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/enableTotp", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/disableTotp", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/getSessions", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/user/revokeSession", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/getUserSessions", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/killUserSession", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/unlockLogin", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/getLoginLogList", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/oidcBind", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/oidcBindCallback", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/getOidcBindings", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/user/oidcUnbind", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/user/resetUserTotp", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/casbin/simulateCasbin", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/casbin/diffCasbin", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/getDictionaryTree", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/lookupDictionaries", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysDictionary/exportSysDictionary", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysDictionary/importSysDictionary", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysOperationRecord/getOperationArchiveList", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysOperationRecord/downloadOperationArchive", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysAuditLog/getAuditLogList", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysAuditLog/verifyAuditLogs", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysAuditLog/createAuditCheckpoint", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/createExportJob", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/getExportJobList", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/findExportJob", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/createExportSchedule", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/updateExportSchedule", V2: "PUT"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/deleteExportSchedule", V2: "DELETE"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/runExportSchedule", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/getExportScheduleList", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/getExportScheduleRunList", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysExportTemplate/downloadExportScheduleRun", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysParams/getSysParamsHistory", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/sysParams/rollbackSysParams", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysVersion/previewVersion", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/sysVersion/rollbackVersion", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/merPaySuspense/getMerPaySuspenseList", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/merPaySuspense/matchMerPaySuspense", V2: "POST"},
+	{Ptype: "p", V0: "888", V1: "/merPaySuspense/getMerPayMatchLogs", V2: "GET"},
+	{Ptype: "p", V0: "888", V1: "/riskRuleHit/getRiskRuleHitList", V2: "GET"},
+
+	{Ptype: "p", V0: "8881", V1: "/user/getTotpStatus", V2: "GET"},
+	{Ptype: "p", V0: "8881", V1: "/user/setupTotp", V2: "POST"},
+	{Ptype: "p", V0: "8881", V1: "/user/enableTotp", V2: "POST"},
+	{Ptype: "p", V0: "8881", V1: "/user/disableTotp", V2: "POST"},
+	{Ptype: "p", V0: "8881", V1: "/user/getSessions", V2: "GET"},
+	{Ptype: "p", V0: "8881", V1: "/user/revokeSession", V2: "POST"},
+	{Ptype: "p", V0: "8881", V1: "/user/oidcBind", V2: "POST"},
+	{Ptype: "p", V0: "8881", V1: "/user/oidcBindCallback", V2: "POST"},
+	{Ptype: "p", V0: "8881", V1: "/user/getOidcBindings", V2: "GET"},
+	{Ptype: "p", V0: "8881", V1: "/user/oidcUnbind", V2: "POST"},
+	{Ptype: "p", V0: "8881", V1: "/merUser/sandboxPay", V2: "POST"},
+}
+
+func init() {
+	system.RegisterMigration(system.Migration{
+		Version: "20261019200000",
+		Name:    "backfill feature apis and casbin rules",
+		Up: func(tx *gorm.DB) error {
+			for _, api := range backfillApis {
+				if err := tx.Where("path = ? AND method = ?", api.Path, api.Method).FirstOrCreate(&api).Error; err != nil {
+					return err
+				}
+			}
+			for _, rule := range backfillCasbinRules {
+				if err := tx.Where(&rule).FirstOrCreate(&rule).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, api := range backfillApis {
+				if err := tx.Unscoped().Where("path = ? AND method = ?", api.Path, api.Method).Delete(&sysModel.SysApi{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("v1 = ? AND v2 = ?", api.Path, api.Method).Delete(&adapter.CasbinRule{}).Error; err != nil {
+					return err
+				}
+			}
+			// 授权给已有 API 的规则只删除本迁移添加的角色
+			for _, rule := range backfillCasbinRules {
+				if err := tx.Where(&rule).Delete(&adapter.CasbinRule{}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,5 @@
+// Package migration 存放版本化的数据库迁移，每个迁移一个文件，文件名以版本号开头，在 init() 中通过 system.RegisterMigration 注册。
+// 表结构的新增仍由 initialize.RegisterTables 的 AutoMigrate 完成，这里处理 AutoMigrate 无法完成的改动：
+// 已有数据的修正、初始数据的补充、列的重命名与删除等。新建的数据库会将已有迁移全部记为已执行，
+// 因此迁移中补充的初始数据也要同步添加到 source/system 中
+package migration
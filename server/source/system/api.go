@@ -57,6 +57,21 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "系统用户", Method: "POST", Path: "/user/setUserAuthority", Description: "修改用户角色(必选)"},
 		{ApiGroup: "系统用户", Method: "POST", Path: "/user/resetPassword", Description: "重置用户密码"},
 		{ApiGroup: "系统用户", Method: "PUT", Path: "/user/setSelfSetting", Description: "用户界面配置"},
+		{ApiGroup: "系统用户", Method: "GET", Path: "/user/getTotpStatus", Description: "获取两步验证状态(必选)"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/setupTotp", Description: "生成两步验证绑定信息(必选)"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/enableTotp", Description: "启用两步验证(必选)"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/disableTotp", Description: "关闭两步验证(必选)"},
+		{ApiGroup: "系统用户", Method: "GET", Path: "/user/getSessions", Description: "获取登录设备列表(必选)"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/revokeSession", Description: "下线登录设备(必选)"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/getUserSessions", Description: "查看用户在线会话"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/killUserSession", Description: "强制下线用户会话"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/unlockLogin", Description: "解除登录锁定"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/getLoginLogList", Description: "分页获取登录记录"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/oidcBind", Description: "绑定外部账号"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/oidcBindCallback", Description: "绑定外部账号回调"},
+		{ApiGroup: "系统用户", Method: "GET", Path: "/user/getOidcBindings", Description: "获取绑定的外部账号"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/oidcUnbind", Description: "解除外部账号绑定"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/resetUserTotp", Description: "重置用户两步验证"},
 
 		{ApiGroup: "api", Method: "POST", Path: "/api/createApi", Description: "创建api"},
 		{ApiGroup: "api", Method: "POST", Path: "/api/deleteApi", Description: "删除Api"},
@@ -76,9 +91,15 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "角色", Method: "PUT", Path: "/authority/updateAuthority", Description: "更新角色信息"},
 		{ApiGroup: "角色", Method: "POST", Path: "/authority/getAuthorityList", Description: "获取角色列表"},
 		{ApiGroup: "角色", Method: "POST", Path: "/authority/setDataAuthority", Description: "设置角色资源权限"},
+		{ApiGroup: "角色", Method: "POST", Path: "/authority/setRequire2FA", Description: "设置角色是否强制两步验证"},
+		{ApiGroup: "角色", Method: "POST", Path: "/authority/setDataRules", Description: "设置角色行级数据权限规则"},
+		{ApiGroup: "角色", Method: "GET", Path: "/authority/getDataRules", Description: "获取角色行级数据权限规则"},
+		{ApiGroup: "角色", Method: "GET", Path: "/authority/getDataScopeResources", Description: "获取可配置行级数据权限的资源"},
 
 		{ApiGroup: "casbin", Method: "POST", Path: "/casbin/updateCasbin", Description: "更改角色api权限"},
 		{ApiGroup: "casbin", Method: "POST", Path: "/casbin/getPolicyPathByAuthorityId", Description: "获取权限列表"},
+		{ApiGroup: "casbin", Method: "POST", Path: "/casbin/simulateCasbin", Description: "模拟角色访问接口"},
+		{ApiGroup: "casbin", Method: "POST", Path: "/casbin/diffCasbin", Description: "预览角色api权限变更"},
 
 		{ApiGroup: "菜单", Method: "POST", Path: "/menu/addBaseMenu", Description: "新增菜单"},
 		{ApiGroup: "菜单", Method: "POST", Path: "/menu/getMenu", Description: "获取菜单树(必选)"},
@@ -138,16 +159,25 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "系统字典详情", Method: "DELETE", Path: "/sysDictionaryDetail/deleteSysDictionaryDetail", Description: "删除字典内容"},
 		{ApiGroup: "系统字典详情", Method: "GET", Path: "/sysDictionaryDetail/findSysDictionaryDetail", Description: "根据ID获取字典内容"},
 		{ApiGroup: "系统字典详情", Method: "GET", Path: "/sysDictionaryDetail/getSysDictionaryDetailList", Description: "获取字典内容列表"},
+		{ApiGroup: "系统字典详情", Method: "GET", Path: "/sysDictionaryDetail/getDictionaryTree", Description: "按层级获取字典内容"},
+		{ApiGroup: "系统字典详情", Method: "POST", Path: "/sysDictionaryDetail/lookupDictionaries", Description: "批量翻译字典值"},
 
 		{ApiGroup: "系统字典", Method: "POST", Path: "/sysDictionary/createSysDictionary", Description: "新增字典"},
 		{ApiGroup: "系统字典", Method: "DELETE", Path: "/sysDictionary/deleteSysDictionary", Description: "删除字典"},
 		{ApiGroup: "系统字典", Method: "PUT", Path: "/sysDictionary/updateSysDictionary", Description: "更新字典"},
 		{ApiGroup: "系统字典", Method: "GET", Path: "/sysDictionary/findSysDictionary", Description: "根据ID获取字典（建议选择）"},
 		{ApiGroup: "系统字典", Method: "GET", Path: "/sysDictionary/getSysDictionaryList", Description: "获取字典列表"},
+		{ApiGroup: "系统字典", Method: "GET", Path: "/sysDictionary/exportSysDictionary", Description: "导出字典"},
+		{ApiGroup: "系统字典", Method: "POST", Path: "/sysDictionary/importSysDictionary", Description: "导入字典"},
 
 		{ApiGroup: "操作记录", Method: "POST", Path: "/sysOperationRecord/createSysOperationRecord", Description: "新增操作记录"},
 		{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/findSysOperationRecord", Description: "根据ID获取操作记录"},
 		{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/getSysOperationRecordList", Description: "获取操作记录列表"},
+		{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/getOperationArchiveList", Description: "获取操作记录归档文件列表"},
+		{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/downloadOperationArchive", Description: "下载操作记录归档文件"},
+		{ApiGroup: "审计日志", Method: "GET", Path: "/sysAuditLog/getAuditLogList", Description: "分页获取审计记录"},
+		{ApiGroup: "审计日志", Method: "GET", Path: "/sysAuditLog/verifyAuditLogs", Description: "校验审计链"},
+		{ApiGroup: "审计日志", Method: "POST", Path: "/sysAuditLog/createAuditCheckpoint", Description: "创建审计链校验点"},
 		{ApiGroup: "操作记录", Method: "DELETE", Path: "/sysOperationRecord/deleteSysOperationRecord", Description: "删除操作记录"},
 		{ApiGroup: "操作记录", Method: "DELETE", Path: "/sysOperationRecord/deleteSysOperationRecordByIds", Description: "批量删除操作历史"},
 
@@ -171,6 +201,16 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/exportExcel", Description: "导出Excel"},
 		{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/exportTemplate", Description: "下载模板"},
 		{ApiGroup: "导出模板", Method: "POST", Path: "/sysExportTemplate/importExcel", Description: "导入Excel"},
+		{ApiGroup: "导出模板", Method: "POST", Path: "/sysExportTemplate/createExportJob", Description: "创建异步导出任务"},
+		{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/getExportJobList", Description: "获取我的导出任务"},
+		{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/findExportJob", Description: "获取导出任务进度"},
+		{ApiGroup: "导出模板", Method: "POST", Path: "/sysExportTemplate/createExportSchedule", Description: "创建定时报表"},
+		{ApiGroup: "导出模板", Method: "PUT", Path: "/sysExportTemplate/updateExportSchedule", Description: "更新定时报表"},
+		{ApiGroup: "导出模板", Method: "DELETE", Path: "/sysExportTemplate/deleteExportSchedule", Description: "删除定时报表"},
+		{ApiGroup: "导出模板", Method: "POST", Path: "/sysExportTemplate/runExportSchedule", Description: "立即执行定时报表"},
+		{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/getExportScheduleList", Description: "获取定时报表列表"},
+		{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/getExportScheduleRunList", Description: "获取定时报表执行记录"},
+		{ApiGroup: "导出模板", Method: "GET", Path: "/sysExportTemplate/downloadExportScheduleRun", Description: "下载定时报表文件"},
 
 		{ApiGroup: "公告", Method: "POST", Path: "/info/createInfo", Description: "新建公告"},
 		{ApiGroup: "公告", Method: "DELETE", Path: "/info/deleteInfo", Description: "删除公告"},
@@ -186,6 +226,8 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "参数管理", Method: "GET", Path: "/sysParams/findSysParams", Description: "根据ID获取参数"},
 		{ApiGroup: "参数管理", Method: "GET", Path: "/sysParams/getSysParamsList", Description: "获取参数列表"},
 		{ApiGroup: "参数管理", Method: "GET", Path: "/sysParams/getSysParam", Description: "获取参数列表"},
+		{ApiGroup: "参数管理", Method: "GET", Path: "/sysParams/getSysParamsHistory", Description: "获取参数变更历史"},
+		{ApiGroup: "参数管理", Method: "POST", Path: "/sysParams/rollbackSysParams", Description: "回滚参数到指定版本"},
 		{ApiGroup: "媒体库分类", Method: "GET", Path: "/attachmentCategory/getCategoryList", Description: "分类列表"},
 		{ApiGroup: "媒体库分类", Method: "POST", Path: "/attachmentCategory/addCategory", Description: "添加/编辑分类"},
 		{ApiGroup: "媒体库分类", Method: "POST", Path: "/attachmentCategory/deleteCategory", Description: "删除分类"},
@@ -195,8 +237,19 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "版本控制", Method: "GET", Path: "/sysVersion/downloadVersionJson", Description: "下载版本json"},
 		{ApiGroup: "版本控制", Method: "POST", Path: "/sysVersion/exportVersion", Description: "创建版本"},
 		{ApiGroup: "版本控制", Method: "POST", Path: "/sysVersion/importVersion", Description: "同步版本"},
+		{ApiGroup: "版本控制", Method: "POST", Path: "/sysVersion/previewVersion", Description: "预览同步版本"},
+		{ApiGroup: "版本控制", Method: "POST", Path: "/sysVersion/rollbackVersion", Description: "回滚同步版本"},
 		{ApiGroup: "版本控制", Method: "DELETE", Path: "/sysVersion/deleteSysVersion", Description: "删除版本"},
 		{ApiGroup: "版本控制", Method: "DELETE", Path: "/sysVersion/deleteSysVersionByIds", Description: "批量删除版本"},
+
+		{ApiGroup: "数据库迁移", Method: "GET", Path: "/sysMigration/getMigrationStatus", Description: "获取迁移状态"},
+		{ApiGroup: "数据库迁移", Method: "POST", Path: "/sysMigration/migrate", Description: "执行迁移"},
+		{ApiGroup: "数据库迁移", Method: "POST", Path: "/sysMigration/rollbackMigration", Description: "回滚迁移"},
+
+		{ApiGroup: "挂账收款", Method: "GET", Path: "/merPaySuspense/getMerPaySuspenseList", Description: "获取挂账收款列表"},
+		{ApiGroup: "挂账收款", Method: "POST", Path: "/merPaySuspense/matchMerPaySuspense", Description: "人工匹配挂账收款"},
+		{ApiGroup: "挂账收款", Method: "GET", Path: "/merPaySuspense/getMerPayMatchLogs", Description: "获取订单人工匹配记录"},
+		{ApiGroup: "风控", Method: "GET", Path: "/riskRuleHit/getRiskRuleHitList", Description: "获取风控命中记录列表"},
 	}
 	if err := db.Create(&entities).Error; err != nil {
 		return ctx, errors.Wrap(err, sysModel.SysApi{}.TableName()+"表数据初始化失败!")
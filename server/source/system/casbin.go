@@ -65,6 +65,10 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/authority/deleteAuthority", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/authority/getAuthorityList", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/authority/setDataAuthority", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/authority/setRequire2FA", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/authority/setDataRules", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/authority/getDataRules", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/authority/getDataScopeResources", V2: "GET"},
 
 		{Ptype: "p", V0: "888", V1: "/menu/getMenu", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/menu/getMenuList", V2: "POST"},
@@ -86,6 +90,21 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/user/setUserAuthorities", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/user/resetPassword", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/user/setSelfSetting", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/user/getTotpStatus", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/user/setupTotp", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/enableTotp", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/disableTotp", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/getSessions", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/user/revokeSession", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/getUserSessions", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/killUserSession", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/unlockLogin", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/getLoginLogList", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/oidcBind", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/oidcBindCallback", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/getOidcBindings", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/user/oidcUnbind", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/resetUserTotp", V2: "POST"},
 
 		{Ptype: "p", V0: "888", V1: "/fileUploadAndDownload/findFile", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/fileUploadAndDownload/breakpointContinueFinish", V2: "POST"},
@@ -100,6 +119,8 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 
 		{Ptype: "p", V0: "888", V1: "/casbin/updateCasbin", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/casbin/getPolicyPathByAuthorityId", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/casbin/simulateCasbin", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/casbin/diffCasbin", V2: "POST"},
 
 		{Ptype: "p", V0: "888", V1: "/jwt/jsonInBlacklist", V2: "POST"},
 
@@ -139,17 +160,26 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/createSysDictionaryDetail", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/getSysDictionaryDetailList", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/deleteSysDictionaryDetail", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/getDictionaryTree", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/lookupDictionaries", V2: "POST"},
 
 		{Ptype: "p", V0: "888", V1: "/sysDictionary/findSysDictionary", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysDictionary/updateSysDictionary", V2: "PUT"},
 		{Ptype: "p", V0: "888", V1: "/sysDictionary/getSysDictionaryList", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysDictionary/createSysDictionary", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/sysDictionary/deleteSysDictionary", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/sysDictionary/exportSysDictionary", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysDictionary/importSysDictionary", V2: "POST"},
 
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/findSysOperationRecord", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/updateSysOperationRecord", V2: "PUT"},
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/createSysOperationRecord", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/getSysOperationRecordList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/getOperationArchiveList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/downloadOperationArchive", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysAuditLog/getAuditLogList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysAuditLog/verifyAuditLogs", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysAuditLog/createAuditCheckpoint", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/deleteSysOperationRecord", V2: "DELETE"},
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/deleteSysOperationRecordByIds", V2: "DELETE"},
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/deleteSysOperationRecordByIds", V2: "DELETE"},
@@ -174,6 +204,16 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/exportExcel", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/exportTemplate", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/importExcel", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/createExportJob", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/getExportJobList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/findExportJob", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/createExportSchedule", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/updateExportSchedule", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/deleteExportSchedule", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/runExportSchedule", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/getExportScheduleList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/getExportScheduleRunList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysExportTemplate/downloadExportScheduleRun", V2: "GET"},
 
 		{Ptype: "p", V0: "888", V1: "/info/createInfo", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/info/deleteInfo", V2: "DELETE"},
@@ -189,6 +229,8 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/sysParams/findSysParams", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysParams/getSysParamsList", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysParams/getSysParam", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysParams/getSysParamsHistory", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysParams/rollbackSysParams", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/attachmentCategory/getCategoryList", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/attachmentCategory/addCategory", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/attachmentCategory/deleteCategory", V2: "POST"},
@@ -198,9 +240,20 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/sysVersion/downloadVersionJson", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysVersion/exportVersion", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/sysVersion/importVersion", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysVersion/previewVersion", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysVersion/rollbackVersion", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/sysVersion/deleteSysVersion", V2: "DELETE"},
 		{Ptype: "p", V0: "888", V1: "/sysVersion/deleteSysVersionByIds", V2: "DELETE"},
 
+		{Ptype: "p", V0: "888", V1: "/sysMigration/getMigrationStatus", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysMigration/migrate", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysMigration/rollbackMigration", V2: "POST"},
+
+		{Ptype: "p", V0: "888", V1: "/merPaySuspense/getMerPaySuspenseList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/merPaySuspense/matchMerPaySuspense", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/merPaySuspense/getMerPayMatchLogs", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/riskRuleHit/getRiskRuleHitList", V2: "GET"},
+
 		{Ptype: "p", V0: "8881", V1: "/user/admin_register", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/api/createApi", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/api/getApiList", V2: "POST"},
@@ -222,6 +275,16 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "8881", V1: "/menu/updateBaseMenu", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/menu/getBaseMenuById", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/user/changePassword", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/getTotpStatus", V2: "GET"},
+		{Ptype: "p", V0: "8881", V1: "/user/setupTotp", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/enableTotp", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/disableTotp", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/getSessions", V2: "GET"},
+		{Ptype: "p", V0: "8881", V1: "/user/revokeSession", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/oidcBind", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/oidcBindCallback", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/getOidcBindings", V2: "GET"},
+		{Ptype: "p", V0: "8881", V1: "/user/oidcUnbind", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/user/getUserList", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/user/setUserAuthority", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/fileUploadAndDownload/upload", V2: "POST"},
@@ -248,6 +311,7 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "8881", V1: "/merUser/findMerUser", V2: "GET"},
 		{Ptype: "p", V0: "8881", V1: "/merUser/getMerUserList", V2: "GET"},
 		{Ptype: "p", V0: "8881", V1: "/merUser/getMerUserPublic", V2: "GET"},
+		{Ptype: "p", V0: "8881", V1: "/merUser/sandboxPay", V2: "POST"},
 
 		{Ptype: "p", V0: "9528", V1: "/user/admin_register", V2: "POST"},
 		{Ptype: "p", V0: "9528", V1: "/api/createApi", V2: "POST"},
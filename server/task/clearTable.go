@@ -18,16 +18,15 @@ import (
 func ClearTable(db *gorm.DB) error {
 	var ClearTableDetail []common.ClearDB
 
+	// sys_operation_records 按 operation-record 配置由 ArchiveOperationRecords 清理与归档
 	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
-		TableName:    "sys_operation_records",
+		TableName:    "sys_login_logs",
 		CompareField: "created_at",
 		Interval:     "2160h",
-	})
-
-	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
-		TableName:    "jwt_blacklists",
+	}, common.ClearDB{
+		TableName:    "sys_export_jobs",
 		CompareField: "created_at",
-		Interval:     "168h",
+		Interval:     "2160h",
 	})
 
 	if db == nil {
@@ -0,0 +1,35 @@
+package task
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// leaseHolder 本实例的租约持有者标识
+var leaseHolder = func() string {
+	host, _ := os.Hostname()
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b[:]))
+}()
+
+// TryLease 领取定时任务租约，返回是否领取成功
+// 与异步导出任务相同，通过条件更新领取，多实例部署时同一时间只有一个实例成功；
+// 租约在任务结束后不提前释放，各实例的定时器稍有偏差时不会在到期前重复执行
+func TryLease(db *gorm.DB, name string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&system.SysTaskLease{Name: name, ExpiresAt: now}).Error
+	if err != nil {
+		return false, err
+	}
+	res := db.Model(&system.SysTaskLease{}).Where("name = ? AND expires_at <= ?", name, now).
+		Updates(map[string]interface{}{"holder": leaseHolder, "expires_at": now.Add(ttl)})
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,29 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func TestTryLease(t *testing.T) {
+	db := globaltest.DB(t, &system.SysTaskLease{})
+
+	if ok, err := TryLease(db, "archive", time.Hour); err != nil || !ok {
+		t.Fatalf("first lease ok = %v, err = %v", ok, err)
+	}
+	// 租约到期前其他实例或下一次触发都领取失败
+	if ok, err := TryLease(db, "archive", time.Hour); err != nil || ok {
+		t.Fatalf("held lease ok = %v, err = %v", ok, err)
+	}
+	if ok, err := TryLease(db, "checkpoint", time.Hour); err != nil || !ok {
+		t.Fatalf("other task ok = %v, err = %v", ok, err)
+	}
+
+	db.Model(&system.SysTaskLease{}).Where("name = ?", "archive").Update("expires_at", time.Now().Add(-time.Second))
+	if ok, err := TryLease(db, "archive", time.Hour); err != nil || !ok {
+		t.Fatalf("expired lease ok = %v, err = %v", ok, err)
+	}
+}
@@ -0,0 +1,124 @@
+package task
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/upload"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultOperationRetention 未配置时操作记录的保留时长
+	defaultOperationRetention = 90 * 24 * time.Hour
+	// operationArchiveBatch 单个归档文件包含的最大记录数，同时也是每次删除的条数
+	operationArchiveBatch = 10000
+)
+
+//@function: ArchiveOperationRecords
+//@description: 清理超过保留时长的操作记录，开启归档时先压缩保存到对象存储，保存失败时不删除
+//@param: db *gorm.DB
+//@return: error
+
+func ArchiveOperationRecords(db *gorm.DB) error {
+	if db == nil {
+		return errors.New("db Cannot be empty")
+	}
+	retention := defaultOperationRetention
+	if cfg := global.GVA_CONFIG.OperationRecord.Retention; cfg != "" {
+		d, err := utils.ParseDuration(cfg)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("operation-record.retention %q 不合法", cfg)
+		}
+		retention = d
+	}
+	cutoff := time.Now().Add(-retention)
+	archive := global.GVA_CONFIG.OperationRecord.Archive
+
+	for {
+		var records []system.SysOperationRecord
+		err := db.Unscoped().Where("created_at < ?", cutoff).Order("id").Limit(operationArchiveBatch).Find(&records).Error
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if archive {
+			if err = archiveOperationRecords(db, records); err != nil {
+				return err
+			}
+		}
+		ids := make([]uint, len(records))
+		for i := range records {
+			ids[i] = records[i].ID
+		}
+		if err = db.Unscoped().Delete(&system.SysOperationRecord{}, ids).Error; err != nil {
+			return err
+		}
+		if len(records) < operationArchiveBatch {
+			return nil
+		}
+	}
+}
+
+// operationArchiveLine 归档文件中的一行，不包含未加载的关联用户
+type operationArchiveLine struct {
+	system.SysOperationRecord
+	User *struct{} `json:"user,omitempty"`
+}
+
+// archiveOperationRecords 将一批记录写为 gzip 压缩的 JSON Lines 并以私有对象保存到配置的对象存储，记录归档文件信息，
+// 文件名随机生成，只能通过需要鉴权的下载接口获取
+func archiveOperationRecords(db *gorm.DB, records []system.SysOperationRecord) error {
+	first, last := records[0], records[len(records)-1]
+	name := fmt.Sprintf("operation_records_%d-%d.jsonl.gz", first.ID, last.ID)
+
+	tmp, err := os.CreateTemp("", "gva-operation-*.jsonl.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	encoder := json.NewEncoder(gz)
+	encoder.SetEscapeHTML(false)
+	archived := system.SysOperationArchive{FromID: first.ID, ToID: last.ID, FromTime: first.CreatedAt, ToTime: first.CreatedAt, Count: len(records), Name: name}
+	for i := range records {
+		if err = encoder.Encode(operationArchiveLine{SysOperationRecord: records[i]}); err != nil {
+			return err
+		}
+		if records[i].CreatedAt.Before(archived.FromTime) {
+			archived.FromTime = records[i].CreatedAt
+		}
+		if records[i].CreatedAt.After(archived.ToTime) {
+			archived.ToTime = records[i].CreatedAt
+		}
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if archived.Size, err = tmp.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if archived.FileKey, err = upload.SavePrivate("operation", ".jsonl.gz", tmp, archived.Size); err != nil {
+		return fmt.Errorf("保存操作记录归档失败: %w", err)
+	}
+	if err = db.Create(&archived).Error; err != nil {
+		_ = upload.DeletePrivate(archived.FileKey)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package task
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/upload"
+)
+
+func setupOperationArchive(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := globaltest.DB(t, &system.SysOperationRecord{}, &system.SysOperationArchive{})
+	global.GVA_CONFIG.System.OssType = "local"
+	global.GVA_CONFIG.Local.StorePath = t.TempDir()
+	global.GVA_CONFIG.Local.Path = "uploads/file"
+	global.GVA_CONFIG.Local.PrivatePath = t.TempDir()
+
+	now := time.Now()
+	for i, age := range []time.Duration{100 * 24 * time.Hour, 95 * 24 * time.Hour, time.Hour} {
+		record := system.SysOperationRecord{Path: "/api/" + string(rune('a'+i)), Method: "POST", Status: 200}
+		record.CreatedAt = now.Add(-age)
+		db.Create(&record)
+	}
+	// 已软删除的过期记录同样清理
+	db.Delete(&system.SysOperationRecord{}, 1)
+	return db
+}
+
+func TestArchiveOperationRecords(t *testing.T) {
+	db := setupOperationArchive(t)
+	global.GVA_CONFIG.OperationRecord.Retention = "90d"
+	global.GVA_CONFIG.OperationRecord.Archive = true
+	t.Cleanup(func() { global.GVA_CONFIG.OperationRecord.Archive = false })
+
+	if err := ArchiveOperationRecords(db); err != nil {
+		t.Fatal(err)
+	}
+	var remaining []system.SysOperationRecord
+	db.Unscoped().Find(&remaining)
+	if len(remaining) != 1 || remaining[0].Path != "/api/c" {
+		t.Fatalf("remaining = %+v", remaining)
+	}
+
+	var archives []system.SysOperationArchive
+	db.Find(&archives)
+	if len(archives) != 1 || archives[0].Count != 2 || archives[0].FromID != 1 || archives[0].ToID != 2 {
+		t.Fatalf("archives = %+v", archives)
+	}
+	// 归档以私有对象保存，文件名不可预测，公开的上传目录中没有文件
+	if filepath.Base(archives[0].FileKey) == archives[0].Name {
+		t.Fatalf("archive key %s must not use the deterministic name", archives[0].FileKey)
+	}
+	if entries, _ := os.ReadDir(global.GVA_CONFIG.Local.StorePath); len(entries) != 0 {
+		t.Fatalf("public upload path must stay empty, got %d entries", len(entries))
+	}
+	f, err := upload.OpenPrivate(archives[0].FileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err = json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0]["path"] != "/api/a" || lines[0]["user"] != nil {
+		t.Fatalf("archived lines = %v", lines)
+	}
+}
+
+func TestArchiveOperationRecords_DeleteOnly(t *testing.T) {
+	db := setupOperationArchive(t)
+	global.GVA_CONFIG.OperationRecord.Retention = "96d"
+	if err := ArchiveOperationRecords(db); err != nil {
+		t.Fatal(err)
+	}
+	var count, archives int64
+	db.Unscoped().Model(&system.SysOperationRecord{}).Count(&count)
+	db.Model(&system.SysOperationArchive{}).Count(&archives)
+	if count != 2 || archives != 0 {
+		t.Fatalf("count = %d archives = %d", count, archives)
+	}
+
+	global.GVA_CONFIG.OperationRecord.Retention = "soon"
+	if err := ArchiveOperationRecords(db); err == nil {
+		t.Fatal("invalid retention must be rejected")
+	}
+}
@@ -0,0 +1,170 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
+	"github.com/flipped-aurora/gin-vue-admin/server/service"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// ORDER_EXPIRE_BATCH_SIZE 每次轮询最多领取的到期订单数
+	ORDER_EXPIRE_BATCH_SIZE = 100
+	// ORDER_EXPIRE_LEASE 领取后的租约时长，实例在租约内未处理完成（如进程退出）时订单会被其他实例重新领取
+	ORDER_EXPIRE_LEASE = 30 * time.Second
+	// LATE_PAYMENT_GRACE 订单超时后继续监控到账的宽限期，期间金额保持占用，到账时订单由失败转为已支付
+	LATE_PAYMENT_GRACE = 10 * time.Minute
+	// PAYMENT_SCAN_WINDOW 监控任务每次向渠道查询到账的最短回溯时长，用于发现无订单对应的到账
+	PAYMENT_SCAN_WINDOW = 10 * time.Minute
+)
+
+// claimExpiredOrdersScript 原子领取到期订单：取出 score 不大于当前时间的成员，并将其 score 推迟一个租约时长
+// 脚本在 Redis 中串行执行，同一订单在租约内只会被一个实例领取
+var claimExpiredOrdersScript = redis.NewScript(`
+local members = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, member in ipairs(members) do
+	redis.call('ZADD', KEYS[1], 'XX', ARGV[2], member)
+end
+return members
+`)
+
+// releaseSlotScript 仅当金额占用键仍属于该订单时才删除，避免误删已被新订单重新占用的金额
+var releaseSlotScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// ScheduleOrderExpire 将订单加入过期延迟队列，到达 expireAt 后由 PollExpiredOrders 处理
+func ScheduleOrderExpire(ctx context.Context, orderId int64, expireAt time.Time) error {
+	return global.GVA_REDIS.ZAdd(ctx, global.PAY_ORDER_EXPIRE_KEY, redis.Z{
+		Score:  float64(expireAt.Unix()),
+		Member: strconv.FormatInt(orderId, 10),
+	}).Err()
+}
+
+// CancelOrderExpire 订单已支付或已取消时将其移出过期延迟队列
+func CancelOrderExpire(ctx context.Context, orderId int64) error {
+	return global.GVA_REDIS.ZRem(ctx, global.PAY_ORDER_EXPIRE_KEY, strconv.FormatInt(orderId, 10)).Err()
+}
+
+// OrderSlotKey 返回订单占用的金额键：pay_ammount_used:merId:amount
+func OrderSlotKey(payOrder *example.MerPayOrder) (string, error) {
+	if payOrder.MerId == nil || payOrder.RequestAmmount == nil {
+		return "", fmt.Errorf("订单信息不完整: MerId或RequestAmmount为nil")
+	}
+	amountStr := utils.FormatAmount(*payOrder.RequestAmmount, payOrder.CurrencyCode())
+	return fmt.Sprintf("%s:%d:%s", global.PAY_AMOUNT_USED_KEY, *payOrder.MerId, amountStr), nil
+}
+
+// ReleaseOrderSlot 释放订单占用的金额
+func ReleaseOrderSlot(ctx context.Context, payOrder *example.MerPayOrder) error {
+	redisKey, err := OrderSlotKey(payOrder)
+	if err != nil {
+		return err
+	}
+	return releaseSlotScript.Run(ctx, global.GVA_REDIS, []string{redisKey}, strconv.FormatInt(*payOrder.Id, 10)).Err()
+}
+
+// PollExpiredOrders 领取并处理已到期的订单，由定时器每秒调用，多实例同时运行时通过租约保证不重复处理
+func PollExpiredOrders() {
+	pollExpiredOrders(context.Background(), time.Now())
+}
+
+// pollExpiredOrders 以 now 为当前时间领取并处理到期订单
+func pollExpiredOrders(ctx context.Context, now time.Time) {
+	members, err := claimExpiredOrdersScript.Run(ctx, global.GVA_REDIS,
+		[]string{global.PAY_ORDER_EXPIRE_KEY},
+		now.Unix(), now.Add(ORDER_EXPIRE_LEASE).Unix(), ORDER_EXPIRE_BATCH_SIZE,
+	).StringSlice()
+	if err != nil {
+		global.GVA_LOG.Error("领取过期订单失败", zap.Error(err))
+		return
+	}
+
+	for _, member := range members {
+		orderId, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			global.GVA_LOG.Error("解析过期订单ID失败", zap.String("member", member), zap.Error(err))
+			global.GVA_REDIS.ZRem(ctx, global.PAY_ORDER_EXPIRE_KEY, member)
+			continue
+		}
+		if err := expireOrder(ctx, orderId); err != nil {
+			// 保留在队列中，租约结束后重新领取
+			global.GVA_LOG.Error("处理过期订单失败", zap.Int64("orderId", orderId), zap.Error(err))
+			continue
+		}
+		if err := CancelOrderExpire(ctx, orderId); err != nil {
+			global.GVA_LOG.Error("移出过期队列失败", zap.Int64("orderId", orderId), zap.Error(err))
+		}
+	}
+}
+
+// expireOrder 将订单标记为失败并通知商户
+// 状态更新带有待支付条件，已支付或已取消的订单不会发送失败回调
+// 金额占用在宽限期内保留，由监控任务继续匹配迟到的支付，宽限期结束后释放
+func expireOrder(ctx context.Context, orderId int64) error {
+	payOrder, err := service.ServiceGroupApp.ExampleServiceGroup.GetMerPayOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	expired, err := service.ServiceGroupApp.ExampleServiceGroup.ExpireMerPayOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if !expired {
+		return nil
+	}
+
+	global.GVA_LOG.Info("订单已超时，标记为失败", zap.Int64("orderId", orderId))
+
+	if payOrder.CallbackUrl == nil || *payOrder.CallbackUrl == "" || payOrder.OrderId == nil {
+		return nil
+	}
+	callbackData := PaymentCallbackData{
+		OrderId:       *payOrder.OrderId,
+		TransactionId: orderId,
+		Currency:      payOrder.CurrencyCode(),
+		Status:        "failed",
+	}
+	if payOrder.RequestAmmount != nil {
+		callbackData.Amount = json.Number(utils.FormatAmount(*payOrder.RequestAmmount, callbackData.Currency))
+	}
+	if payOrder.MerType != nil {
+		callbackData.PaymentMethod = *payOrder.MerType
+	}
+
+	// 异步发送回调
+	callbackUrl := *payOrder.CallbackUrl
+	go func() {
+		if err := sendPaymentCallback(callbackUrl, callbackData); err != nil {
+			global.GVA_LOG.Error("订单超时回调发送失败",
+				zap.String("orderId", callbackData.OrderId),
+				zap.String("callbackUrl", callbackUrl),
+				zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// StartOrderExpireTask 启动订单过期轮询任务
+func StartOrderExpireTask() {
+	_, err := global.GVA_Timer.AddTaskByFunc("order_expire", "@every 1s", PollExpiredOrders, "订单过期延迟队列", cron.WithSeconds())
+	if err != nil {
+		global.GVA_LOG.Error("启动订单过期任务失败", zap.Error(err))
+		return
+	}
+	global.GVA_LOG.Info("订单过期任务启动成功")
+}
@@ -0,0 +1,213 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/global/globaltest"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
+	"github.com/flipped-aurora/gin-vue-admin/server/service"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+func setupOrderExpire(t *testing.T) {
+	t.Helper()
+	globaltest.DB(t, &example.MerPayOrder{})
+	globaltest.Redis(t)
+}
+
+func createExpireOrder(t *testing.T, id int64, callbackUrl string) {
+	t.Helper()
+	amount := decimal.RequireFromString("5.23")
+	orderId := "O" + strconv.FormatInt(id, 10)
+	order := example.MerPayOrder{Id: &id, OrderId: &orderId, RequestAmmount: &amount, State: global.MER_PAY_ORDER_PENDING}
+	if callbackUrl != "" {
+		order.CallbackUrl = &callbackUrl
+	}
+	if err := global.GVA_DB.Create(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func orderState(t *testing.T, id int64) int8 {
+	t.Helper()
+	var order example.MerPayOrder
+	if err := global.GVA_DB.First(&order, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return *order.State
+}
+
+// queueScore 返回订单在过期队列中的 score，不在队列中时 ok 为 false
+func queueScore(t *testing.T, id int64) (score float64, ok bool) {
+	t.Helper()
+	score, err := global.GVA_REDIS.ZScore(context.Background(), global.PAY_ORDER_EXPIRE_KEY, strconv.FormatInt(id, 10)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return score, true
+}
+
+func TestPollExpiredOrders_Claim(t *testing.T) {
+	setupOrderExpire(t)
+	ctx := context.Background()
+	now := time.Now()
+	createExpireOrder(t, 1, "")
+	createExpireOrder(t, 2, "")
+	for id, expireAt := range map[int64]time.Time{1: now.Add(-time.Second), 2: now.Add(time.Minute)} {
+		if err := ScheduleOrderExpire(ctx, id, expireAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pollExpiredOrders(ctx, now)
+	if orderState(t, 1) != *global.MER_PAY_ORDER_FAILED || orderState(t, 2) != *global.MER_PAY_ORDER_PENDING {
+		t.Fatalf("states = %d, %d", orderState(t, 1), orderState(t, 2))
+	}
+	// 处理完成的订单移出队列，未到期的订单保持原有的过期时间
+	if _, ok := queueScore(t, 1); ok {
+		t.Fatal("expired order still queued")
+	}
+	if score, ok := queueScore(t, 2); !ok || int64(score) != now.Add(time.Minute).Unix() {
+		t.Fatalf("pending order score = %v, %v", score, ok)
+	}
+}
+
+func TestPollExpiredOrders_RetryAfterLease(t *testing.T) {
+	setupOrderExpire(t)
+	ctx := context.Background()
+	now := time.Now()
+	// 订单尚未落库，处理失败后保留在队列中
+	if err := ScheduleOrderExpire(ctx, 3, now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	pollExpiredOrders(ctx, now)
+	score, ok := queueScore(t, 3)
+	if !ok || int64(score) != now.Add(ORDER_EXPIRE_LEASE).Unix() {
+		t.Fatalf("score after failure = %v, %v", score, ok)
+	}
+
+	createExpireOrder(t, 3, "")
+	// 租约内不会被再次领取
+	pollExpiredOrders(ctx, now.Add(ORDER_EXPIRE_LEASE-time.Second))
+	if orderState(t, 3) != *global.MER_PAY_ORDER_PENDING {
+		t.Fatal("order reclaimed within lease")
+	}
+	pollExpiredOrders(ctx, now.Add(ORDER_EXPIRE_LEASE))
+	if orderState(t, 3) != *global.MER_PAY_ORDER_FAILED {
+		t.Fatal("order not reclaimed after lease")
+	}
+	if _, ok = queueScore(t, 3); ok {
+		t.Fatal("retried order still queued")
+	}
+}
+
+func TestPollExpiredOrders_DuplicateDelivery(t *testing.T) {
+	setupOrderExpire(t)
+	ctx := context.Background()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	for id := int64(10); id < 20; id++ {
+		createExpireOrder(t, id, srv.URL)
+		if err := ScheduleOrderExpire(ctx, id, now.Add(-time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 多个实例同时领取时每个订单只会被领取一次
+	var (
+		mu      sync.Mutex
+		claimed = map[string]int{}
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			members, err := claimExpiredOrdersScript.Run(ctx, global.GVA_REDIS,
+				[]string{global.PAY_ORDER_EXPIRE_KEY},
+				now.Unix(), now.Add(ORDER_EXPIRE_LEASE).Unix(), 3,
+			).StringSlice()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, m := range members {
+				claimed[m]++
+			}
+		}()
+	}
+	wg.Wait()
+	if len(claimed) != 10 {
+		t.Fatalf("claimed %d orders, want 10", len(claimed))
+	}
+	for member, n := range claimed {
+		if n != 1 {
+			t.Fatalf("order %s claimed %d times", member, n)
+		}
+	}
+
+	// 同一订单重复投递（如租约过期后重新领取）时只发送一次失败回调
+	for i := 0; i < 2; i++ {
+		if err := expireOrder(ctx, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for hits.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("callbacks = %d, want 1", n)
+	}
+}
+
+func TestCancelMerPayOrder_OnlyPending(t *testing.T) {
+	setupOrderExpire(t)
+	ctx := context.Background()
+	orders := service.ServiceGroupApp.ExampleServiceGroup
+	createExpireOrder(t, 30, "")
+	createExpireOrder(t, 31, "")
+
+	// 已被监控任务确认支付的订单不能再取消
+	if paid, err := orders.MarkMerPayOrderPaid(ctx, 31, time.Now(), "T31"); err != nil || !paid {
+		t.Fatalf("paid = %v, err = %v", paid, err)
+	}
+	if canceled, err := orders.CancelMerPayOrder(ctx, 31); err != nil || canceled {
+		t.Fatalf("paid order canceled = %v, err = %v", canceled, err)
+	}
+	if state := orderState(t, 31); state != *global.MER_PAY_ORDER_PAID {
+		t.Fatalf("paid order state = %d", state)
+	}
+
+	if canceled, err := orders.CancelMerPayOrder(ctx, 30); err != nil || !canceled {
+		t.Fatalf("pending order canceled = %v, err = %v", canceled, err)
+	}
+	if canceled, _ := orders.CancelMerPayOrder(ctx, 30); canceled {
+		t.Fatal("order canceled twice")
+	}
+	if state := orderState(t, 30); state != *global.MER_PAY_ORDER_CANCELED {
+		t.Fatalf("canceled order state = %d", state)
+	}
+}
@@ -2,15 +2,19 @@ package task
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
 	"github.com/flipped-aurora/gin-vue-admin/server/service"
+	exampleService "github.com/flipped-aurora/gin-vue-admin/server/service/example"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/pay/sandbox"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils/pay/xianxiang"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils/pay/xingyi"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils/request"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,6 +23,15 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// PAY_MONITOR_POLL_INTERVAL_PARAM 到账查询间隔的系统参数键（duration 类型），修改后无需重启即生效
+	PAY_MONITOR_POLL_INTERVAL_PARAM = "pay.monitor.poll-interval"
+	// PAY_MONITOR_POLL_INTERVAL 未配置参数时的到账查询间隔
+	PAY_MONITOR_POLL_INTERVAL = 30 * time.Second
+	// PAY_MONITOR_TICK 定时器检查粒度，查询间隔按该粒度生效且不会小于该值
+	PAY_MONITOR_TICK = 5 * time.Second
+)
+
 // MerUserTaskManager 全局任务管理器，确保每个 meruser 只有一个定时任务
 type MerUserTaskManager struct {
 	tasks map[int64]*MerUserMonitorTask // key: merUserId, value: 任务实例
@@ -39,6 +52,8 @@ type MerUserMonitorTask struct {
 	CallBackUrl string        // 回调URL
 	MerType     string        // 商户类型
 	StartTime   time.Time     // 任务开始时间
+	pollMu      sync.Mutex    // 保护 lastPoll
+	lastPoll    time.Time     // 上次查询到账的时间
 }
 
 // StartMerUserTask 启动或获取 meruser 的监控任务
@@ -103,21 +118,54 @@ func (manager *MerUserTaskManager) GetMerUserTask(merUserId int64) (*MerUserMoni
 
 // start 启动 meruser 监控任务
 func (task *MerUserMonitorTask) start() {
-	// 添加到全局定时器，每30秒检查一次
-	global.GVA_Timer.AddTaskByFunc(task.TaskID, "@every 30s", task.execute, task.TaskID)
+	// 定时器按固定粒度触发，是否查询由参数中的查询间隔决定，修改间隔无需重启任务
+	global.GVA_Timer.AddTaskByFunc(task.TaskID, fmt.Sprintf("@every %s", PAY_MONITOR_TICK), task.poll, task.TaskID)
 
 	global.GVA_LOG.Info("MerUser 监控任务定时器已启动",
 		zap.String("taskID", task.TaskID),
 		zap.Int64("merUserId", task.MerUserId))
 }
 
-// execute 执行监控操作（每30秒执行一次）
+// ChannelPayment 渠道侧查询到的一笔到账记录
+type ChannelPayment struct {
+	TradeNo string          // 渠道流水号
+	Amount  decimal.Decimal // 到账金额
+	PayTime time.Time       // 到账时间
+	Payer   string          // 付款人标识，渠道未提供时为空
+}
+
+// poll 距上次查询超过查询间隔时执行监控操作
+func (task *MerUserMonitorTask) poll() {
+	interval := service.ServiceGroupApp.SystemServiceGroup.SysParamsService.GetParamDuration(PAY_MONITOR_POLL_INTERVAL_PARAM, PAY_MONITOR_POLL_INTERVAL)
+	if !task.due(time.Now(), interval) {
+		return
+	}
+	task.execute()
+}
+
+// due 判断是否到达查询时间，到达时记录本次查询时间
+func (task *MerUserMonitorTask) due(now time.Time, interval time.Duration) bool {
+	if interval < PAY_MONITOR_TICK {
+		interval = PAY_MONITOR_TICK
+	}
+	task.pollMu.Lock()
+	defer task.pollMu.Unlock()
+	// 预留半个粒度，避免定时器抖动导致每次都晚一个粒度
+	if !task.lastPoll.IsZero() && now.Sub(task.lastPoll) < interval-PAY_MONITOR_TICK/2 {
+		return false
+	}
+	task.lastPoll = now
+	return true
+}
+
+// execute 执行监控操作（默认每30秒执行一次，间隔见 PAY_MONITOR_POLL_INTERVAL_PARAM）
+// 每次从渠道查询一段时间内的到账记录，优先匹配占用中的订单（含超时宽限期内的失败订单），
+// 无法匹配且未记录过的到账写入挂账收款表，等待人工处理
 func (task *MerUserMonitorTask) execute() {
 	select {
 	case <-task.stopChan:
 		return
 	default:
-		// 监控该 meruser 的金额占用情况
 		ctx := context.Background()
 
 		// 获取商户用户信息
@@ -130,127 +178,264 @@ func (task *MerUserMonitorTask) execute() {
 			return
 		}
 
-		// 构建 Redis 键的模式，查找该用户的所有金额占用
-		keyPattern := fmt.Sprintf("%s:%d:*", global.PAY_AMOUNT_USED_KEY, task.MerUserId)
-
-		// 获取所有已占用的金额键
-		var occupiedKeys []string
-		iter := global.GVA_REDIS.Scan(ctx, 0, keyPattern, 100).Iterator()
-
-		for iter.Next(ctx) {
-			key := iter.Val()
-			occupiedKeys = append(occupiedKeys, key)
+		// 风控配置按商户所属用户读取
+		var riskConfig exampleService.SysUserConfig
+		if merUser.SysUserId != nil {
+			riskConfig, err = service.ServiceGroupApp.ExampleServiceGroup.SysUserConfigService.GetConfigBySysUserID(ctx, *merUser.SysUserId)
+			if err != nil {
+				global.GVA_LOG.Error("获取风控配置失败",
+					zap.String("taskID", task.TaskID),
+					zap.Int64("merUserId", task.MerUserId),
+					zap.Error(err))
+			}
 		}
 
-		if err := iter.Err(); err != nil {
-			global.GVA_LOG.Error("Redis SCAN 操作失败",
+		candidates, err := task.collectCandidates(ctx)
+		if err != nil {
+			global.GVA_LOG.Error("获取待匹配订单失败",
 				zap.String("taskID", task.TaskID),
 				zap.Int64("merUserId", task.MerUserId),
 				zap.Error(err))
 			return
 		}
 
-		if len(occupiedKeys) == 0 {
-			global.GVA_LOG.Debug("MerUser 当前无金额占用",
+		// 查询范围覆盖最早的待匹配订单，且不少于固定回溯时长，以便发现无订单对应的到账
+		endTime := time.Now()
+		startTime := endTime.Add(-PAYMENT_SCAN_WINDOW)
+		for _, payOrder := range candidates {
+			if payOrder.CreateTime != nil && payOrder.CreateTime.Before(startTime) {
+				startTime = *payOrder.CreateTime
+			}
+		}
+
+		payments, err := task.fetchPayments(ctx, &merUser, startTime, endTime)
+		if err != nil {
+			global.GVA_LOG.Error("查询渠道到账记录失败",
 				zap.String("taskID", task.TaskID),
-				zap.Int64("merUserId", task.MerUserId))
+				zap.Int64("merUserId", task.MerUserId),
+				zap.Error(err))
 			return
 		}
 
-		global.GVA_LOG.Info("MerUser 金额占用检查",
+		global.GVA_LOG.Debug("MerUser 到账检查",
 			zap.String("taskID", task.TaskID),
 			zap.Int64("merUserId", task.MerUserId),
-			zap.Int("occupiedCount", len(occupiedKeys)))
+			zap.Int("candidateCount", len(candidates)),
+			zap.Int("paymentCount", len(payments)))
 
-		// 检查每个占用的金额是否有对应的支付
-		for _, redisKey := range occupiedKeys {
-			// 从键中提取金额部分
-			parts := strings.Split(redisKey, ":")
-			if len(parts) < 3 {
-				continue
-			}
+		for _, payment := range payments {
+			candidates = task.processPayment(ctx, &merUser, riskConfig, payment, candidates)
+		}
+	}
+}
 
-			amountStr := parts[2]
+// collectCandidates 根据金额占用键获取待匹配的订单
+// 待支付订单已超时时补偿加入过期队列；失败订单超过宽限期后释放金额占用，不再参与匹配
+func (task *MerUserMonitorTask) collectCandidates(ctx context.Context) ([]*example.MerPayOrder, error) {
+	// 构建 Redis 键的模式，查找该用户的所有金额占用
+	keyPattern := fmt.Sprintf("%s:%d:*", global.PAY_AMOUNT_USED_KEY, task.MerUserId)
 
-			// 获取订单ID
-			orderIdStr, err := global.GVA_REDIS.Get(ctx, redisKey).Result()
-			if err != nil {
-				global.GVA_LOG.Error("获取订单ID失败",
-					zap.String("redisKey", redisKey),
-					zap.Error(err))
-				continue
-			}
+	var occupiedKeys []string
+	iter := global.GVA_REDIS.Scan(ctx, 0, keyPattern, 100).Iterator()
+	for iter.Next(ctx) {
+		occupiedKeys = append(occupiedKeys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("Redis SCAN 操作失败: %w", err)
+	}
 
-			orderId, err := strconv.ParseInt(orderIdStr, 10, 64)
-			if err != nil {
-				global.GVA_LOG.Error("解析订单ID失败",
-					zap.String("orderIdStr", orderIdStr),
-					zap.Error(err))
-				continue
-			}
+	now := time.Now()
+	candidates := make([]*example.MerPayOrder, 0, len(occupiedKeys))
+	for _, redisKey := range occupiedKeys {
+		// 获取订单ID
+		orderIdStr, err := global.GVA_REDIS.Get(ctx, redisKey).Result()
+		if err != nil {
+			global.GVA_LOG.Error("获取订单ID失败",
+				zap.String("redisKey", redisKey),
+				zap.Error(err))
+			continue
+		}
 
-			// 检查订单状态
-			payOrder, err := service.ServiceGroupApp.ExampleServiceGroup.GetMerPayOrder(ctx, orderId)
-			if err != nil {
-				global.GVA_LOG.Error("获取支付订单失败",
-					zap.Int64("orderId", orderId),
-					zap.Error(err))
-				continue
-			}
+		orderId, err := strconv.ParseInt(orderIdStr, 10, 64)
+		if err != nil {
+			global.GVA_LOG.Error("解析订单ID失败",
+				zap.String("orderIdStr", orderIdStr),
+				zap.Error(err))
+			continue
+		}
 
-			// 如果订单已支付或已失败，跳过
-			if payOrder.State != nil && (*payOrder.State == *global.MER_PAY_ORDER_PAID || *payOrder.State == *global.MER_PAY_ORDER_FAILED) {
-				continue
-			}
+		payOrder, err := service.ServiceGroupApp.ExampleServiceGroup.GetMerPayOrder(ctx, orderId)
+		if err != nil {
+			global.GVA_LOG.Error("获取支付订单失败",
+				zap.Int64("orderId", orderId),
+				zap.Error(err))
+			continue
+		}
 
-			// 检查订单是否超时
-			if payOrder.CreateTime != nil {
-				createTime := *payOrder.CreateTime
-				if payOrder.Expires != nil && time.Since(createTime) > time.Duration(*payOrder.Expires)*time.Second {
-					// 订单超时，标记为失败
-					err := service.ServiceGroupApp.ExampleServiceGroup.UpdateMerPayOrder(ctx, example.MerPayOrder{
-						Id:    &orderId,
-						State: global.MER_PAY_ORDER_FAILED,
-					})
-					if err != nil {
-						global.GVA_LOG.Error("更新超时订单状态失败",
-							zap.Int64("orderId", orderId),
-							zap.Error(err))
-					} else {
-						global.GVA_LOG.Info("订单已超时，标记为失败",
-							zap.Int64("orderId", orderId),
-							zap.String("amount", amountStr))
-					}
-					continue
+		expireAt := payOrder.ExpireAt()
+		switch {
+		case payOrder.State == nil || *payOrder.State == *global.MER_PAY_ORDER_PENDING:
+			// 已超时的订单交由过期延迟队列统一处理，这里只做补偿入队，防止下单时入队失败导致订单永久挂起
+			if !expireAt.IsZero() && now.After(expireAt) {
+				if err := ScheduleOrderExpire(ctx, orderId, expireAt); err != nil {
+					global.GVA_LOG.Error("超时订单补偿入队失败",
+						zap.Int64("orderId", orderId),
+						zap.Error(err))
 				}
 			}
-
-			// 检查是否有对应的支付
-			found := task.checkPaymentForAmount(ctx, merUser, amountStr, payOrder)
-			if found {
-				// 找到支付，处理支付成功
-				now := time.Now()
-				task.handlePaymentSuccess(ctx, now, task.MerType, orderId, amountStr, payOrder)
+		case *payOrder.State == *global.MER_PAY_ORDER_FAILED:
+			if now.After(expireAt.Add(LATE_PAYMENT_GRACE)) {
+				if err := ReleaseOrderSlot(ctx, &payOrder); err != nil {
+					global.GVA_LOG.Error("释放金额占用失败", zap.Int64("orderId", orderId), zap.Error(err))
+				}
+				continue
 			}
+		default:
+			continue
 		}
+		candidates = append(candidates, &payOrder)
 	}
+	return candidates, nil
 }
 
-// checkPaymentForAmount 检查指定金额是否有对应的支付
-func (task *MerUserMonitorTask) checkPaymentForAmount(ctx context.Context, merUser *example.MerUser, amountStr string, payOrder *example.MerPayOrder) bool {
-	if task.MerType == global.MER_TYPE_XINGYI {
-		return task.checkXingyiPayment(ctx, merUser, amountStr, payOrder)
-	} else if task.MerType == global.MER_TYPE_XIANG_XIAN {
-		return task.checkXianxiangPayment(ctx, merUser, amountStr, payOrder)
-	} else if task.MerType == global.MER_TYPE_RICH {
-		// 富掌柜支付检查逻辑
-		return false
+// processPayment 处理一笔渠道到账，返回去除已匹配订单后的候选列表
+// 拉黑付款人的到账不自动匹配订单，直接记入挂账收款等待人工处理
+func (task *MerUserMonitorTask) processPayment(ctx context.Context, merUser *example.MerUser, riskConfig exampleService.SysUserConfig, payment ChannelPayment, candidates []*example.MerPayOrder) []*example.MerPayOrder {
+	suspenseService := service.ServiceGroupApp.ExampleServiceGroup.MerPaySuspenseService
+	known, err := suspenseService.IsTradeKnown(ctx, task.MerUserId, payment.TradeNo)
+	if err != nil {
+		global.GVA_LOG.Error("查询渠道流水失败",
+			zap.String("taskID", task.TaskID),
+			zap.String("tradeNo", payment.TradeNo),
+			zap.Error(err))
+		return candidates
+	}
+	if known {
+		return candidates
+	}
+
+	riskService := service.ServiceGroupApp.ExampleServiceGroup.RiskRuleService
+	if riskService.IsPayerBlocked(riskConfig, payment.Payer) {
+		rule := example.RiskRulePayerBlocked
+		detail := fmt.Sprintf("渠道流水 %s 来自拉黑付款人，已转入挂账收款", payment.TradeNo)
+		riskService.RecordHit(ctx, example.RiskRuleHit{
+			SysUserId: merUser.SysUserId,
+			Rule:      &rule,
+			Subject:   &payment.Payer,
+			MerId:     &task.MerUserId,
+			Detail:    &detail,
+		})
+	} else if index := matchPayment(payment, candidates); index >= 0 {
+		payOrder := candidates[index]
+		paid, err := service.ServiceGroupApp.ExampleServiceGroup.MarkMerPayOrderPaid(ctx, *payOrder.Id, payment.PayTime, payment.TradeNo)
+		if err != nil {
+			global.GVA_LOG.Error("更新支付订单失败",
+				zap.String("taskID", task.TaskID),
+				zap.Int64("orderId", *payOrder.Id),
+				zap.Error(err))
+			return candidates
+		}
+		// 未更新说明订单已被其他实例或人工处理
+		if paid {
+			task.handlePaymentSuccess(ctx, payment, payOrder)
+		}
+		return append(candidates[:index], candidates[index+1:]...)
+	}
+
+	// 无法匹配订单，记入挂账收款
+	currency := merUser.CurrencyCode()
+	suspense := example.MerPaySuspense{
+		SysUserId: merUser.SysUserId,
+		MerId:     &task.MerUserId,
+		MerType:   merUser.MerType,
+		TradeNo:   &payment.TradeNo,
+		Payer:     &payment.Payer,
+		Amount:    &payment.Amount,
+		Currency:  &currency,
+		PayTime:   &payment.PayTime,
+		State:     global.MER_PAY_SUSPENSE_PENDING,
+	}
+	if err := suspenseService.RecordSuspense(ctx, &suspense); err != nil {
+		global.GVA_LOG.Error("记录挂账收款失败",
+			zap.String("taskID", task.TaskID),
+			zap.String("tradeNo", payment.TradeNo),
+			zap.Error(err))
+		return candidates
 	}
-	return false
+	global.GVA_LOG.Warn("到账无法匹配订单，已记入挂账收款",
+		zap.String("taskID", task.TaskID),
+		zap.Int64("merUserId", task.MerUserId),
+		zap.String("tradeNo", payment.TradeNo),
+		zap.String("amount", utils.FormatAmount(payment.Amount, currency)),
+		zap.Time("payTime", payment.PayTime))
+	return candidates
+}
+
+// matchPayment 在候选订单中查找金额一致、且到账时间落在订单有效期加宽限期内的订单，返回其下标，未找到返回 -1
+// 渠道到账时间只精确到秒，订单创建时间按秒截断后比较
+func matchPayment(payment ChannelPayment, candidates []*example.MerPayOrder) int {
+	for i, payOrder := range candidates {
+		if payOrder.RequestAmmount == nil || !payOrder.RequestAmmount.Equal(payment.Amount) {
+			continue
+		}
+		if payOrder.CreateTime == nil || payment.PayTime.Before(payOrder.CreateTime.Truncate(time.Second)) {
+			continue
+		}
+		if payment.PayTime.After(payOrder.ExpireAt().Add(LATE_PAYMENT_GRACE)) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// fetchPayments 按商户类型查询时间范围内的到账记录
+func (task *MerUserMonitorTask) fetchPayments(ctx context.Context, merUser *example.MerUser, startTime, endTime time.Time) ([]ChannelPayment, error) {
+	switch task.MerType {
+	case global.MER_TYPE_XINGYI:
+		return task.fetchXingyiPayments(ctx, merUser, startTime, endTime)
+	case global.MER_TYPE_XIANG_XIAN:
+		return task.fetchXianxiangPayments(ctx, merUser, startTime, endTime)
+	case global.MER_TYPE_SANDBOX:
+		return task.fetchSandboxPayments(ctx, startTime, endTime)
+	}
+	// 富掌柜暂未接入
+	return nil, nil
+}
+
+// newChannelPayment 构造到账记录，渠道未返回流水号时以时间和金额生成，保证同一笔到账的标识稳定
+func newChannelPayment(tradeNo string, amount decimal.Decimal, payTime time.Time) ChannelPayment {
+	if tradeNo == "" {
+		tradeNo = fmt.Sprintf("%s_%s", payTime.Format("20060102150405"), amount.String())
+	}
+	return ChannelPayment{TradeNo: tradeNo, Amount: amount, PayTime: payTime}
+}
+
+// inTimeRange 检查时间是否在指定范围内（包含边界）
+func inTimeRange(t, startTime, endTime time.Time) bool {
+	return !t.Before(startTime) && !t.After(endTime)
+}
+
+// fetchSandboxPayments 查询沙箱渠道到账，收款由测试或管理端通过 sandbox.Default.Pay 写入
+func (task *MerUserMonitorTask) fetchSandboxPayments(ctx context.Context, startTime, endTime time.Time) ([]ChannelPayment, error) {
+	list, err := sandbox.Default.List(ctx, task.MerUserId)
+	if err != nil {
+		return nil, err
+	}
+	var payments []ChannelPayment
+	for _, payment := range list {
+		if !inTimeRange(payment.PayTime, startTime.Truncate(time.Second), endTime) {
+			continue
+		}
+		channelPayment := newChannelPayment(payment.TradeNo, payment.Amount, payment.PayTime)
+		channelPayment.Payer = payment.Payer
+		payments = append(payments, channelPayment)
+	}
+	return payments, nil
 }
 
-// checkXingyiPayment 检查星驿付支付
-func (task *MerUserMonitorTask) checkXingyiPayment(ctx context.Context, merUser *example.MerUser, amountStr string, payOrder *example.MerPayOrder) bool {
+// fetchXingyiPayments 查询星驿付到账
+func (task *MerUserMonitorTask) fetchXingyiPayments(ctx context.Context, merUser *example.MerUser, startTime, endTime time.Time) ([]ChannelPayment, error) {
 	xingyiService := xingyi.NewService(nil, nil, xingyi.Cookies{})
 	merUserId := strconv.FormatInt(task.MerUserId, 10)
 
@@ -267,48 +452,48 @@ func (task *MerUserMonitorTask) checkXingyiPayment(ctx context.Context, merUser
 	if tokenData == "" || !xingyiService.CheckToken(tokenData, merUserId) {
 		newToken, success := task.refreshXingyiToken(ctx, merUser, xingyiService, merUserId, tokenKey)
 		if !success {
-			return false
+			return nil, fmt.Errorf("星驿付 token 获取失败")
 		}
 		tokenData = newToken
 	}
 
-	// 计算时间范围
-	var startTime, endTime time.Time
-	if payOrder.CreateTime != nil {
-		startTime = *payOrder.CreateTime
-		if payOrder.Expires != nil {
-			endTime = startTime.Add(time.Duration(*payOrder.Expires) * time.Second)
-		} else {
-			endTime = startTime.Add(5 * time.Minute) // 默认5分钟
-		}
-	} else {
-		startTime = time.Now().Add(-10 * time.Minute)
-		endTime = time.Now()
-	}
-
 	global.GVA_LOG.Info("查询星驿付支付列表",
 		zap.String("startTime", utils.FormatTime(startTime)),
-		zap.String("endTime", utils.FormatTime(endTime)),
-		zap.String("amount", amountStr))
+		zap.String("endTime", utils.FormatTime(endTime)))
 
 	body, err := xingyiService.GetPayList(tokenData, utils.FormatTime(startTime), utils.FormatTime(endTime), merUserId)
 	if err != nil {
-		global.GVA_LOG.Error("获取星驿付支付列表失败", zap.Error(err))
-		return false
+		return nil, fmt.Errorf("获取星驿付支付列表失败: %w", err)
 	}
-
-	// 检查是否有符合条件的订单
-	found, err := xingyiService.GetCheckResult(string(body), startTime, endTime, amountStr)
+	rows, err := xingyiService.ParsePayList(string(body))
 	if err != nil {
-		global.GVA_LOG.Error("检查星驿付支付结果失败", zap.Error(err))
-		return false
+		return nil, err
 	}
 
-	return found
+	payments := make([]ChannelPayment, 0, len(rows))
+	for _, row := range rows {
+		amount, err := decimal.NewFromString(strings.TrimSpace(row.RecTxamt))
+		if err != nil {
+			global.GVA_LOG.Warn("解析星驿付实收金额失败", zap.String("订单号", row.OrderNo), zap.Error(err))
+			continue
+		}
+		payTime, err := time.ParseInLocation("2006-01-02 15:04:05", row.OrderTime, time.Local)
+		if err != nil {
+			global.GVA_LOG.Warn("解析星驿付订单时间失败", zap.String("订单号", row.OrderNo), zap.Error(err))
+			continue
+		}
+		if !inTimeRange(payTime, startTime.Truncate(time.Second), endTime) {
+			continue
+		}
+		channelPayment := newChannelPayment(row.OrderNo, amount, payTime)
+		channelPayment.Payer = row.OpenId
+		payments = append(payments, channelPayment)
+	}
+	return payments, nil
 }
 
-// checkXianxiangPayment 检查先享后付支付
-func (task *MerUserMonitorTask) checkXianxiangPayment(ctx context.Context, merUser *example.MerUser, amountStr string, payOrder *example.MerPayOrder) bool {
+// fetchXianxiangPayments 查询先享后付到账，渠道按日期查询，跨天时逐日查询
+func (task *MerUserMonitorTask) fetchXianxiangPayments(ctx context.Context, merUser *example.MerUser, startTime, endTime time.Time) ([]ChannelPayment, error) {
 	xianxiangService := xianxiang.NewService(nil)
 	merUserId := fmt.Sprintf("%d", task.MerUserId)
 
@@ -325,48 +510,48 @@ func (task *MerUserMonitorTask) checkXianxiangPayment(ctx context.Context, merUs
 	if tokenData == "" || !xianxiangService.CheckToken(tokenData) {
 		newToken, success := task.refreshXianxiangToken(ctx, merUser, xianxiangService, merUserId, tokenKey)
 		if !success {
-			return false
+			return nil, fmt.Errorf("先享后付 token 获取失败")
 		}
 		tokenData = newToken
 	}
 
-	// 计算时间范围
-	var startTime time.Time
-	if payOrder.CreateTime != nil {
-		startTime = *payOrder.CreateTime
-	} else {
-		startTime = time.Now().Add(-10 * time.Minute)
-	}
+	var payments []ChannelPayment
+	lastDay := endTime.Format("2006-01-02")
+	for day := startTime; ; day = day.AddDate(0, 0, 1) {
+		// 先享后付使用日期格式：2025-10-13
+		orderTime := day.Format("2006-01-02")
+		global.GVA_LOG.Info("查询先享后付订单列表", zap.String("orderTime", orderTime))
 
-	// 先享后付使用日期格式：2025-10-13
-	orderTime := startTime.Format("2006-01-02")
-
-	global.GVA_LOG.Info("查询先享后付订单列表",
-		zap.String("orderTime", orderTime),
-		zap.String("amount", amountStr))
-
-	body, err := xianxiangService.GetOrderList(tokenData, orderTime)
-	if err != nil {
-		global.GVA_LOG.Error("获取先享后付订单列表失败", zap.Error(err))
-		return false
-	}
-
-	// 计算结束时间
-	var endTime time.Time
-	if payOrder.CreateTime != nil && payOrder.Expires != nil {
-		endTime = payOrder.CreateTime.Add(time.Duration(*payOrder.Expires) * time.Second)
-	} else {
-		endTime = startTime.Add(5 * time.Minute) // 默认5分钟
-	}
-
-	// 检查是否有符合条件的订单
-	found, _, err := xianxiangService.CheckPayment(string(body), startTime, endTime, amountStr)
-	if err != nil {
-		global.GVA_LOG.Error("检查先享后付支付结果失败", zap.Error(err))
-		return false
+		body, err := xianxiangService.GetOrderList(tokenData, orderTime)
+		if err != nil {
+			return nil, fmt.Errorf("获取先享后付订单列表失败: %w", err)
+		}
+		orders, err := xianxiangService.ParseOrderList(string(body))
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			timeStr := order.PayTime
+			if timeStr == "" {
+				timeStr = order.CreateAt
+			}
+			payTime, err := time.ParseInLocation("2006-01-02 15:04:05", timeStr, time.Local)
+			if err != nil {
+				global.GVA_LOG.Warn("解析先享后付订单时间失败", zap.String("订单号", order.OrderSn), zap.Error(err))
+				continue
+			}
+			if !inTimeRange(payTime, startTime.Truncate(time.Second), endTime) {
+				continue
+			}
+			channelPayment := newChannelPayment(order.OrderSn, order.RealAmount, payTime)
+			channelPayment.Payer = order.OpenId
+			payments = append(payments, channelPayment)
+		}
+		if orderTime >= lastDay {
+			break
+		}
 	}
-
-	return found
+	return payments, nil
 }
 
 // refreshXingyiToken 刷新星驿付 token
@@ -447,53 +632,76 @@ func (task *MerUserMonitorTask) refreshXianxiangToken(ctx context.Context, merUs
 	return "", false
 }
 
-// handlePaymentSuccess 处理支付成功的通用逻辑
-func (task *MerUserMonitorTask) handlePaymentSuccess(ctx context.Context, payTime time.Time, merType string, orderId int64, amountStr string, payOrder *example.MerPayOrder) {
-	// 更新订单状态为已支付
-	err := service.ServiceGroupApp.ExampleServiceGroup.UpdateMerPayOrder(ctx, example.MerPayOrder{
-		Id:      &orderId,
-		State:   global.MER_PAY_ORDER_PAID,
-		PayTime: &payTime,
-	})
-	if err != nil {
-		global.GVA_LOG.Error("更新支付订单失败",
-			zap.String("taskID", task.TaskID),
-			zap.String("paymentMethod", merType),
-			zap.Int64("orderId", orderId),
-			zap.Error(err))
-		return
-	}
+// handlePaymentSuccess 订单已转为已支付后的处理，宽限期内到账的订单同样会发送支付成功回调
+func (task *MerUserMonitorTask) handlePaymentSuccess(ctx context.Context, payment ChannelPayment, payOrder *example.MerPayOrder) {
+	late := payOrder.State != nil && *payOrder.State == *global.MER_PAY_ORDER_FAILED
+	payOrder.State = global.MER_PAY_ORDER_PAID
+	payOrder.PayTime = &payment.PayTime
+	payOrder.TradeNo = &payment.TradeNo
 
-	// 发送支付成功回调
-	if task.CallBackUrl != "" && payOrder.OrderId != nil {
-		amount, _ := strconv.ParseFloat(amountStr, 64)
-		callbackData := PaymentCallbackData{
-			OrderId:       *payOrder.OrderId,
-			Amount:        amount,
-			PayTime:       payTime,
-			PaymentMethod: merType,
-			Status:        "success",
-			TransactionId: orderId,
+	if task.MerType == global.MER_TYPE_SANDBOX {
+		if err := sandbox.Default.MarkMatched(ctx, task.MerUserId, payment.TradeNo); err != nil {
+			global.GVA_LOG.Error("标记沙箱收款已匹配失败", zap.String("taskID", task.TaskID), zap.Error(err))
 		}
-
-		// 异步发送回调
-		go func() {
-			err := sendPaymentCallback(task.CallBackUrl, callbackData)
-			if err != nil {
-				global.GVA_LOG.Error("支付回调发送失败",
-					zap.String("merType", merType),
-					zap.String("orderId", *payOrder.OrderId),
-					zap.String("callbackUrl", task.CallBackUrl),
-					zap.Error(err))
-			}
-		}()
 	}
 
+	NotifyOrderPaid(ctx, payOrder, task.CallBackUrl)
+
 	global.GVA_LOG.Info("找到匹配的支付订单",
 		zap.String("taskID", task.TaskID),
-		zap.String("merType", merType),
-		zap.String("amount", amountStr),
-		zap.Int64("orderId", orderId))
+		zap.String("merType", task.MerType),
+		zap.String("tradeNo", payment.TradeNo),
+		zap.String("amount", utils.FormatAmount(payment.Amount, payOrder.CurrencyCode())),
+		zap.Int64("orderId", *payOrder.Id),
+		zap.Bool("late", late))
+}
+
+// NotifyOrderPaid 订单转为已支付后移出过期队列、释放金额占用，并向商户发送支付成功回调
+// 订单未记录回调地址时使用 fallbackUrl
+func NotifyOrderPaid(ctx context.Context, payOrder *example.MerPayOrder, fallbackUrl string) {
+	if err := CancelOrderExpire(ctx, *payOrder.Id); err != nil {
+		global.GVA_LOG.Error("移出过期队列失败", zap.Int64("orderId", *payOrder.Id), zap.Error(err))
+	}
+	if err := ReleaseOrderSlot(ctx, payOrder); err != nil {
+		global.GVA_LOG.Error("释放金额占用失败", zap.Int64("orderId", *payOrder.Id), zap.Error(err))
+	}
+
+	callbackUrl := fallbackUrl
+	if payOrder.CallbackUrl != nil && *payOrder.CallbackUrl != "" {
+		callbackUrl = *payOrder.CallbackUrl
+	}
+	if callbackUrl == "" || payOrder.OrderId == nil {
+		return
+	}
+
+	currency := payOrder.CurrencyCode()
+	callbackData := PaymentCallbackData{
+		OrderId:       *payOrder.OrderId,
+		TransactionId: *payOrder.Id,
+		Currency:      currency,
+		Status:        "success",
+	}
+	if payOrder.RequestAmmount != nil {
+		callbackData.Amount = json.Number(utils.FormatAmount(*payOrder.RequestAmmount, currency))
+	}
+	if payOrder.PayTime != nil {
+		callbackData.PayTime = *payOrder.PayTime
+	}
+	if payOrder.MerType != nil {
+		callbackData.PaymentMethod = *payOrder.MerType
+	}
+
+	// 异步发送回调
+	go func() {
+		err := sendPaymentCallback(callbackUrl, callbackData)
+		if err != nil {
+			global.GVA_LOG.Error("支付回调发送失败",
+				zap.String("merType", callbackData.PaymentMethod),
+				zap.String("orderId", callbackData.OrderId),
+				zap.String("callbackUrl", callbackUrl),
+				zap.Error(err))
+		}
+	}()
 }
 
 // stop 停止 meruser 监控任务
@@ -524,7 +732,7 @@ type OrderMonitorTask struct {
 	MerUserId   int64         // 商户用户ID
 	CallBackUrl string        // 回调URL
 	OrderUniId  int64         // 订单唯一ID
-
+	Currency    string        // 订单币种
 }
 
 // NewOrderMonitorTask 创建新的订单监控任务
@@ -537,13 +745,17 @@ func NewOrderMonitorTask(userID uint,
 	startTime, endTime time.Time,
 	callbackUrl string,
 	orderUniId int64,
+	currency string,
 ) *OrderMonitorTask {
+	if currency == "" {
+		currency = utils.DefaultCurrency
+	}
 	return &OrderMonitorTask{
 		UserID:      userID,
 		Amount:      amount,
 		RedisKey:    redisKey,
 		TTL:         ttl,
-		TaskID:      fmt.Sprintf("order_monitor_%d_%s_%d", userID, amount, uuid.New().String()),
+		TaskID:      fmt.Sprintf("order_monitor_%d_%s_%s", userID, amount, uuid.New().String()),
 		stopChan:    make(chan struct{}),
 		MerType:     merType,
 		OrderId:     orderId,
@@ -552,6 +764,7 @@ func NewOrderMonitorTask(userID uint,
 		EndTime:     endTime,
 		CallBackUrl: callbackUrl,
 		OrderUniId:  orderUniId,
+		Currency:    utils.NormalizeCurrency(currency),
 	}
 }
 
@@ -560,8 +773,13 @@ func (task *OrderMonitorTask) Start() {
 	// 添加到全局定时器
 	global.GVA_Timer.AddTaskByFunc(task.TaskID, "@every 10s", task.execute, task.TaskID)
 
-	// 启动过期检查协程
-	go task.checkExpiration()
+	// 订单过期由延迟队列统一处理
+	if err := ScheduleOrderExpire(context.Background(), task.OrderUniId, task.EndTime); err != nil {
+		global.GVA_LOG.Error("订单加入过期队列失败",
+			zap.String("taskID", task.TaskID),
+			zap.Int64("orderUniId", task.OrderUniId),
+			zap.Error(err))
+	}
 
 	global.GVA_LOG.Info("订单监控任务已启动",
 		zap.String("taskID", task.TaskID),
@@ -576,6 +794,12 @@ func (task *OrderMonitorTask) execute() {
 	case <-task.stopChan:
 		return
 	default:
+		// 订单已过期，状态由过期队列更新，这里只停止监控
+		if time.Now().After(task.EndTime) {
+			task.Stop()
+			return
+		}
+
 		// 这里可以添加具体的监控操作
 		// 例如：检查订单状态、发送通知等
 		global.GVA_LOG.Info("执行订单监控检查",
@@ -865,43 +1089,6 @@ func (task *OrderMonitorTask) execute() {
 	}
 }
 
-// checkExpiration 检查任务是否过期
-func (task *OrderMonitorTask) checkExpiration() {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-task.stopChan:
-			return
-		case <-ticker.C:
-			if time.Since(task.StartTime) >= task.TTL {
-				// 超时处理：将订单状态设置为失败(state=2)
-				ctx := context.Background()
-				err := service.ServiceGroupApp.ExampleServiceGroup.UpdateMerPayOrder(ctx, example.MerPayOrder{
-					Id:    &task.OrderUniId,
-					State: global.MER_PAY_ORDER_FAILED,
-				})
-				if err != nil {
-					global.GVA_LOG.Error("更新订单状态为失败时出错",
-						zap.String("taskID", task.TaskID),
-						zap.Int64("orderUniId", task.OrderUniId),
-						zap.Error(err))
-				} else {
-					global.GVA_LOG.Info("订单监控超时，已将订单状态设置为失败",
-						zap.String("taskID", task.TaskID),
-						zap.Int64("orderUniId", task.OrderUniId),
-						zap.String("amount", task.Amount),
-						zap.Duration("超时时长", task.TTL))
-				}
-
-				task.Stop()
-				return
-			}
-		}
-	}
-}
-
 // Stop 停止订单监控任务
 func (task *OrderMonitorTask) Stop() {
 	task.once.Do(func() {
@@ -916,13 +1103,15 @@ func (task *OrderMonitorTask) Stop() {
 }
 
 // PaymentCallbackData 支付回调数据结构
+// Amount 使用 json.Number 按币种精度原样输出（如 5.10），保持数字格式的同时避免浮点误差
 type PaymentCallbackData struct {
-	OrderId       string    `json:"orderId"`       // 订单ID
-	TransactionId int64     `json:"transactionId"` // 交易ID
-	Amount        float64   `json:"amount"`        // 支付金额
-	PayTime       time.Time `json:"payTime"`       // 支付时间
-	PaymentMethod string    `json:"paymentMethod"` // 支付方式
-	Status        string    `json:"status"`        // 支付状态
+	OrderId       string      `json:"orderId"`       // 订单ID
+	TransactionId int64       `json:"transactionId"` // 交易ID
+	Amount        json.Number `json:"amount"`        // 支付金额
+	Currency      string      `json:"currency"`      // 币种
+	PayTime       time.Time   `json:"payTime"`       // 支付时间
+	PaymentMethod string      `json:"paymentMethod"` // 支付方式
+	Status        string      `json:"status"`        // 支付状态
 }
 
 // handlePaymentSuccess 处理支付成功的通用逻辑，包括更新订单状态和发送回调
@@ -942,12 +1131,25 @@ func (task *OrderMonitorTask) handlePaymentSuccess(ctx context.Context, payTime
 		return
 	}
 
+	if err := CancelOrderExpire(ctx, task.OrderUniId); err != nil {
+		global.GVA_LOG.Error("移出过期队列失败", zap.Int64("orderUniId", task.OrderUniId), zap.Error(err))
+	}
+
 	// 发送支付成功回调
 	if task.CallBackUrl != "" {
-		amount, _ := strconv.ParseFloat(task.Amount, 64)
+		amount, err := decimal.NewFromString(task.Amount)
+		if err != nil {
+			global.GVA_LOG.Error("解析支付金额失败",
+				zap.String("taskID", task.TaskID),
+				zap.String("amount", task.Amount),
+				zap.Error(err))
+			task.Stop()
+			return
+		}
 		callbackData := PaymentCallbackData{
 			OrderId:       task.OrderId,
-			Amount:        amount,
+			Amount:        json.Number(utils.FormatAmount(amount, task.Currency)),
+			Currency:      task.Currency,
 			PayTime:       payTime,
 			PaymentMethod: merType,
 			Status:        "success",
@@ -0,0 +1,83 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
+	"github.com/shopspring/decimal"
+)
+
+func newTestOrder(id int64, amount string, createTime time.Time, state int8) *example.MerPayOrder {
+	value := decimal.RequireFromString(amount)
+	expires := int64(300)
+	return &example.MerPayOrder{
+		Id:             &id,
+		RequestAmmount: &value,
+		CreateTime:     &createTime,
+		Expires:        &expires,
+		State:          &state,
+	}
+}
+
+func TestMatchPayment(t *testing.T) {
+	createTime := time.Date(2025, 10, 13, 15, 0, 0, 500_000_000, time.Local)
+	candidates := []*example.MerPayOrder{
+		newTestOrder(1, "5.23", createTime, 0),
+		newTestOrder(2, "5.24", createTime, 2),
+	}
+
+	tests := []struct {
+		name    string
+		amount  string
+		payTime time.Time
+		want    int
+	}{
+		{"pending order", "5.23", createTime.Add(time.Minute), 0},
+		{"same second as creation", "5.23", createTime.Truncate(time.Second), 0},
+		{"amount formatting", "5.2300", createTime.Add(time.Minute), 0},
+		{"late payment within grace", "5.24", createTime.Add(5*time.Minute + LATE_PAYMENT_GRACE), 1},
+		{"late payment after grace", "5.24", createTime.Add(6*time.Minute + LATE_PAYMENT_GRACE), -1},
+		{"before creation", "5.23", createTime.Add(-time.Minute), -1},
+		{"unknown amount", "5.25", createTime.Add(time.Minute), -1},
+	}
+	for _, tt := range tests {
+		payment := newChannelPayment("T1", decimal.RequireFromString(tt.amount), tt.payTime)
+		if got := matchPayment(payment, candidates); got != tt.want {
+			t.Errorf("%s: matchPayment = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewChannelPaymentTradeNo(t *testing.T) {
+	payTime := time.Date(2025, 10, 13, 15, 4, 5, 0, time.Local)
+	payment := newChannelPayment("", decimal.RequireFromString("5.10"), payTime)
+	if payment.TradeNo != "20251013150405_5.1" {
+		t.Errorf("TradeNo = %s", payment.TradeNo)
+	}
+	if newChannelPayment("X1", decimal.Zero, payTime).TradeNo != "X1" {
+		t.Errorf("channel trade no should be kept")
+	}
+}
+
+func TestMonitorTaskDue(t *testing.T) {
+	task := &MerUserMonitorTask{}
+	start := time.Now()
+	if !task.due(start, 30*time.Second) {
+		t.Fatal("first poll should run")
+	}
+	if task.due(start.Add(25*time.Second), 30*time.Second) {
+		t.Fatal("poll before interval should be skipped")
+	}
+	// 定时器晚到或提前不超过半个粒度时按时执行
+	if !task.due(start.Add(28*time.Second), 30*time.Second) {
+		t.Fatal("poll at interval should run")
+	}
+	// 缩短间隔后立即按新间隔执行，且不小于定时器粒度
+	if !task.due(start.Add(33*time.Second), time.Second) {
+		t.Fatal("poll after shortened interval should run")
+	}
+	if task.due(start.Add(35*time.Second), time.Second) {
+		t.Fatal("interval should not be shorter than the tick")
+	}
+}
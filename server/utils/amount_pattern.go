@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// AmountPattern 金额黑名单规则，支持三种写法：
+//   - 精确金额：88.88
+//   - 闭区间：100-200
+//   - 通配：*.88、1?.00，按币种精度格式化后的金额字符串匹配
+type AmountPattern struct {
+	raw      string
+	min, max *decimal.Decimal
+	glob     string
+}
+
+// String 返回规则原文
+func (p AmountPattern) String() string {
+	return p.raw
+}
+
+// ParseAmountPatterns 解析逗号或换行分隔的金额规则列表，空项会被忽略
+func ParseAmountPatterns(value string) ([]AmountPattern, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '，' || r == '\n' || r == ';'
+	})
+	patterns := make([]AmountPattern, 0, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		pattern, err := parseAmountPattern(field)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func parseAmountPattern(field string) (AmountPattern, error) {
+	pattern := AmountPattern{raw: field}
+	if strings.ContainsAny(field, "*?[") {
+		if _, err := path.Match(field, ""); err != nil {
+			return pattern, fmt.Errorf("金额规则 %s 格式错误: %w", field, err)
+		}
+		pattern.glob = field
+		return pattern, nil
+	}
+	if lower, upper, ok := strings.Cut(field, "-"); ok {
+		min, err := decimal.NewFromString(strings.TrimSpace(lower))
+		if err != nil {
+			return pattern, fmt.Errorf("金额规则 %s 格式错误: %w", field, err)
+		}
+		max, err := decimal.NewFromString(strings.TrimSpace(upper))
+		if err != nil {
+			return pattern, fmt.Errorf("金额规则 %s 格式错误: %w", field, err)
+		}
+		if min.GreaterThan(max) {
+			return pattern, fmt.Errorf("金额规则 %s 下限大于上限", field)
+		}
+		pattern.min, pattern.max = &min, &max
+		return pattern, nil
+	}
+	amount, err := decimal.NewFromString(field)
+	if err != nil {
+		return pattern, fmt.Errorf("金额规则 %s 格式错误: %w", field, err)
+	}
+	pattern.min, pattern.max = &amount, &amount
+	return pattern, nil
+}
+
+// Match 判断金额是否命中规则，通配规则使用 FormatAmount 的结果进行匹配
+func (p AmountPattern) Match(amount decimal.Decimal, currency string) bool {
+	if p.glob != "" {
+		matched, _ := path.Match(p.glob, FormatAmount(amount, currency))
+		return matched
+	}
+	return !amount.LessThan(*p.min) && !amount.GreaterThan(*p.max)
+}
+
+// MatchAmountPatterns 返回第一条命中的规则
+func MatchAmountPatterns(patterns []AmountPattern, amount decimal.Decimal, currency string) (AmountPattern, bool) {
+	for _, pattern := range patterns {
+		if pattern.Match(amount, currency) {
+			return pattern, true
+		}
+	}
+	return AmountPattern{}, false
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMatchAmountPatterns(t *testing.T) {
+	patterns, err := ParseAmountPatterns("88.88, 100-200，*.99\n1?.00")
+	if err != nil {
+		t.Fatalf("ParseAmountPatterns failed: %v", err)
+	}
+
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"88.88", "88.88"},
+		{"88.8800", "88.88"},
+		{"100", "100-200"},
+		{"200.00", "100-200"},
+		{"150.5", "100-200"},
+		{"5.99", "*.99"},
+		{"12", "1?.00"},
+		{"88.87", ""},
+		{"200.01", ""},
+		{"5.9", ""},
+	}
+	for _, tt := range tests {
+		pattern, ok := MatchAmountPatterns(patterns, decimal.RequireFromString(tt.amount), "CNY")
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%s unexpectedly matched %s", tt.amount, pattern)
+			}
+			continue
+		}
+		if !ok || pattern.String() != tt.want {
+			t.Errorf("%s matched %q, want %q", tt.amount, pattern.String(), tt.want)
+		}
+	}
+}
+
+func TestParseAmountPatternsInvalid(t *testing.T) {
+	for _, value := range []string{"abc", "200-100", "1-x", "[.00"} {
+		if _, err := ParseAmountPatterns(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+	patterns, err := ParseAmountPatterns(" , ")
+	if err != nil || len(patterns) != 0 {
+		t.Errorf("empty value should yield no patterns: %v %v", patterns, err)
+	}
+}
@@ -134,14 +134,17 @@ func GetUserName(c *gin.Context) string {
 	}
 }
 
-func LoginToken(user system.Login) (token string, claims systemReq.CustomClaims, err error) {
+// LoginToken 为登录会话签发访问令牌，sessionId 为刷新令牌所属会话，sessionVersion 为用户当前会话版本
+func LoginToken(user system.Login, sessionId string, sessionVersion int64) (token string, claims systemReq.CustomClaims, err error) {
 	j := NewJWT()
 	claims = j.CreateClaims(systemReq.BaseClaims{
-		UUID:        user.GetUUID(),
-		ID:          user.GetUserId(),
-		NickName:    user.GetNickname(),
-		Username:    user.GetUsername(),
-		AuthorityId: user.GetAuthorityId(),
+		UUID:           user.GetUUID(),
+		ID:             user.GetUserId(),
+		NickName:       user.GetNickname(),
+		Username:       user.GetUsername(),
+		AuthorityId:    user.GetAuthorityId(),
+		SessionId:      sessionId,
+		SessionVersion: sessionVersion,
 	})
 	token, err = j.CreateToken(claims)
 	return
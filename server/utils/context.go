@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// 请求上下文中的用户信息，由 middleware.WithSysUserID 写入，GORM 回调据此做所有权与行级数据权限过滤
+const (
+	ContextUserIDKey      = "sys_user_id"
+	ContextAuthorityIDKey = "sys_authority_id"
+	contextSkipDataScope  = "skip_data_scope"
+	contextRequestCache   = "request_cache"
+)
+
+// requestCache 请求级缓存，同一请求内的多条语句共享
+type requestCache struct {
+	sync.Mutex
+	values map[string]interface{}
+}
+
+// ContextUserID 读取上下文中的用户ID，不存在时返回0
+func ContextUserID(ctx context.Context) uint {
+	return contextUint(ctx, ContextUserIDKey)
+}
+
+// ContextAuthorityID 读取上下文中的角色ID，不存在时返回0
+func ContextAuthorityID(ctx context.Context) uint {
+	return contextUint(ctx, ContextAuthorityIDKey)
+}
+
+// WithoutDataScope 返回不做所有权与行级数据权限过滤的上下文，用于请求中需要读取全量数据的内部逻辑
+func WithoutDataScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextSkipDataScope, true)
+}
+
+// DataScopeSkipped 上下文是否已跳过数据权限过滤
+func DataScopeSkipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(contextSkipDataScope).(bool)
+	return skip
+}
+
+// WithRequestCache 返回携带请求级缓存的上下文，数据权限回调借此避免每条语句重复查询用户角色与下属
+func WithRequestCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextRequestCache, &requestCache{values: make(map[string]interface{})})
+}
+
+// RequestCached 读取请求级缓存，未命中时调用 load 并缓存成功的结果；上下文没有缓存时直接调用 load
+func RequestCached[T any](ctx context.Context, key string, load func() (T, error)) (T, error) {
+	var cache *requestCache
+	if ctx != nil {
+		cache, _ = ctx.Value(contextRequestCache).(*requestCache)
+	}
+	if cache == nil {
+		return load()
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	if v, ok := cache.values[key].(T); ok {
+		return v, nil
+	}
+	v, err := load()
+	if err == nil {
+		cache.values[key] = v
+	}
+	return v, err
+}
+
+func contextUint(ctx context.Context, key string) uint {
+	if ctx == nil {
+		return 0
+	}
+	switch v := ctx.Value(key).(type) {
+	case uint:
+		return v
+	case int64:
+		if v > 0 {
+			return uint(v)
+		}
+	case int:
+		if v > 0 {
+			return uint(v)
+		}
+	}
+	return 0
+}
@@ -34,11 +34,11 @@ func (j *JWT) CreateClaims(baseClaims request.BaseClaims) request.CustomClaims {
 	ep, _ := ParseDuration(global.GVA_CONFIG.JWT.ExpiresTime)
 	claims := request.CustomClaims{
 		BaseClaims: baseClaims,
-		BufferTime: int64(bf / time.Second), // 缓冲时间1天 缓冲时间内会获得新的token刷新令牌 此时一个用户会存在两个有效令牌 但是前端只留一个 另一个会丢失
+		BufferTime: int64(bf / time.Second), // 缓冲时间 仅保留兼容，访问令牌不再在缓冲期内自动续期，改由刷新令牌换取
 		RegisteredClaims: jwt.RegisteredClaims{
 			Audience:  jwt.ClaimStrings{"GVA"},                   // 受众
 			NotBefore: jwt.NewNumericDate(time.Now().Add(-1000)), // 签名生效时间
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ep)),    // 过期时间 7天  配置文件
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ep)),    // 访问令牌过期时间 配置文件
 			Issuer:    global.GVA_CONFIG.JWT.Issuer,              // 签名的发行者
 		},
 	}
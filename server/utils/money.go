@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCurrency 未指定币种时使用的默认币种
+const DefaultCurrency = "CNY"
+
+// Currency 币种定义，Exponent 为最小货币单位相对主单位的小数位数（如 CNY 为 2，表示 1 元 = 100 分）
+type Currency struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Exponent int32  `json:"exponent"`
+}
+
+// currencies 支持的币种，按 ISO 4217 代码索引
+var currencies = map[string]Currency{
+	"CNY": {Code: "CNY", Name: "人民币", Exponent: 2},
+	"HKD": {Code: "HKD", Name: "港币", Exponent: 2},
+	"USD": {Code: "USD", Name: "美元", Exponent: 2},
+	"EUR": {Code: "EUR", Name: "欧元", Exponent: 2},
+	"JPY": {Code: "JPY", Name: "日元", Exponent: 0},
+}
+
+// NormalizeCurrency 统一币种代码格式，空值返回默认币种
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// LookupCurrency 根据代码获取币种定义
+func LookupCurrency(code string) (Currency, error) {
+	currency, ok := currencies[NormalizeCurrency(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("不支持的币种: %s", code)
+	}
+	return currency, nil
+}
+
+// ValidateAmount 校验金额为正数，且小数位数不超过币种的最小货币单位
+func ValidateAmount(amount decimal.Decimal, code string) error {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return err
+	}
+	if !amount.IsPositive() {
+		return errors.New("金额必须大于0")
+	}
+	if !amount.Equal(amount.Truncate(currency.Exponent)) {
+		return fmt.Errorf("金额 %s 超出 %s 的最小货币单位精度", amount.String(), currency.Code)
+	}
+	return nil
+}
+
+// ToMinorUnits 将金额转换为最小货币单位（如元转分），精度超出币种单位时返回错误
+func ToMinorUnits(amount decimal.Decimal, code string) (int64, error) {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return 0, err
+	}
+	minor := amount.Shift(currency.Exponent)
+	if !minor.IsInteger() {
+		return 0, fmt.Errorf("金额 %s 超出 %s 的最小货币单位精度", amount.String(), currency.Code)
+	}
+	return minor.IntPart(), nil
+}
+
+// FromMinorUnits 将最小货币单位转换为金额（如分转元）
+func FromMinorUnits(minor int64, code string) (decimal.Decimal, error) {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.New(minor, -currency.Exponent), nil
+}
+
+// FormatAmount 按币种精度格式化金额，如 CNY 的 5.1 格式化为 "5.10"
+// 金额占用键、渠道对账等需要字符串比较的场景必须统一使用该格式
+func FormatAmount(amount decimal.Decimal, code string) string {
+	currency, err := LookupCurrency(code)
+	if err != nil {
+		return amount.String()
+	}
+	return amount.StringFixed(currency.Exponent)
+}
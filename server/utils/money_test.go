@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestValidateAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		wantErr  bool
+	}{
+		{"5.23", "CNY", false},
+		{"5", "", false},
+		{"5.231", "CNY", true},
+		{"0", "CNY", true},
+		{"-1", "CNY", true},
+		{"100", "JPY", false},
+		{"100.5", "JPY", true},
+		{"1.00", "XXX", true},
+	}
+	for _, tt := range tests {
+		err := ValidateAmount(decimal.RequireFromString(tt.amount), tt.currency)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAmount(%s, %s) error = %v, wantErr %v", tt.amount, tt.currency, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMinorUnits(t *testing.T) {
+	minor, err := ToMinorUnits(decimal.RequireFromString("19.99"), "CNY")
+	if err != nil || minor != 1999 {
+		t.Fatalf("ToMinorUnits = %d, %v; want 1999", minor, err)
+	}
+	if _, err := ToMinorUnits(decimal.RequireFromString("0.001"), "CNY"); err == nil {
+		t.Errorf("expected precision error")
+	}
+	amount, err := FromMinorUnits(1999, "CNY")
+	if err != nil || FormatAmount(amount, "CNY") != "19.99" {
+		t.Errorf("FromMinorUnits = %s, %v; want 19.99", amount, err)
+	}
+	amount, err = FromMinorUnits(500, "JPY")
+	if err != nil || FormatAmount(amount, "JPY") != "500" {
+		t.Errorf("FromMinorUnits = %s, %v; want 500", amount, err)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	if got := FormatAmount(decimal.RequireFromString("5.1"), "cny"); got != "5.10" {
+		t.Errorf("FormatAmount = %s; want 5.10", got)
+	}
+}
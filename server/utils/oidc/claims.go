@@ -0,0 +1,60 @@
+package oidc
+
+import "strings"
+
+// Claims ID Token 中的声明
+type Claims map[string]interface{}
+
+// Lookup 按点分路径读取声明，如 "realm_access.roles"
+func (c Claims) Lookup(path string) (interface{}, bool) {
+	var cur interface{} = map[string]interface{}(c)
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// String 读取字符串声明，不存在或类型不符时返回空串
+func (c Claims) String(path string) string {
+	v, _ := c.Lookup(path)
+	s, _ := v.(string)
+	return s
+}
+
+// Bool 读取布尔声明，兼容部分身份提供方以字符串 "true" 返回的情况
+func (c Claims) Bool(path string) bool {
+	v, _ := c.Lookup(path)
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
+
+// Strings 读取字符串数组声明，单个字符串按空格或逗号拆分
+func (c Claims) Strings(path string) []string {
+	v, _ := c.Lookup(path)
+	switch list := v.(type) {
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []string:
+		return list
+	case string:
+		return strings.FieldsFunc(list, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+	return nil
+}
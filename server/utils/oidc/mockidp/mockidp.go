@@ -0,0 +1,242 @@
+// Package mockidp 进程内的模拟 OIDC 身份提供方，用于测试与本地联调
+// 支持 discovery、授权码模式（强制 PKCE S256）、JWKS 与 RS256 签名的 ID Token
+package mockidp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/oidc"
+)
+
+const keyId = "mock-key-1"
+
+// User 模拟身份提供方中的用户
+type User struct {
+	Subject       string
+	Username      string
+	Name          string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+}
+
+type authRequest struct {
+	user          User
+	clientId      string
+	redirectUri   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+// IdP 模拟身份提供方
+type IdP struct {
+	Server       *httptest.Server
+	ClientId     string
+	ClientSecret string
+	// DefaultUser 浏览器直接访问授权端点时自动登录的用户
+	DefaultUser User
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]authRequest
+}
+
+// New 启动模拟身份提供方，使用完毕后调用 Close
+func New(clientId, clientSecret string) (*IdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	idp := &IdP{
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]authRequest),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorizeHandler)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+	idp.Server = httptest.NewServer(mux)
+	return idp, nil
+}
+
+// Issuer 身份提供方地址
+func (i *IdP) Issuer() string {
+	return i.Server.URL
+}
+
+// Close 关闭模拟服务
+func (i *IdP) Close() {
+	i.Server.Close()
+}
+
+// Authorize 模拟用户在授权地址完成登录，返回回调时携带的 code 与 state
+func (i *IdP) Authorize(authURL string, user User) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	if q.Get("response_type") != "code" {
+		return "", "", errors.New("unsupported response_type")
+	}
+	if q.Get("client_id") != i.ClientId {
+		return "", "", errors.New("unknown client_id")
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		return "", "", errors.New("PKCE S256 is required")
+	}
+	if code, err = oidc.RandomString(16); err != nil {
+		return "", "", err
+	}
+	i.mu.Lock()
+	i.codes[code] = authRequest{
+		user:          user,
+		clientId:      q.Get("client_id"),
+		redirectUri:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	i.mu.Unlock()
+	return code, q.Get("state"), nil
+}
+
+// SignIDToken 使用身份提供方的密钥签发任意声明，用于构造异常令牌的测试
+func (i *IdP) SignIDToken(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyId
+	return token.SignedString(i.key)
+}
+
+func (i *IdP) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                i.Issuer(),
+		"authorization_endpoint":                i.Issuer() + "/authorize",
+		"token_endpoint":                        i.Issuer() + "/token",
+		"jwks_uri":                              i.Issuer() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorizeHandler 浏览器联调：以 DefaultUser 身份直接重定向回客户端
+func (i *IdP) authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	code, state, err := i.Authorize(r.URL.String(), i.DefaultUser)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	q := redirect.Query()
+	q.Set("code", code)
+	q.Set("state", state)
+	redirect.RawQuery = q.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (i *IdP) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeError(w, "invalid_request")
+		return
+	}
+	clientId, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientId, _ = url.QueryUnescape(clientId)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientId, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientId != i.ClientId || clientSecret != i.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, "unsupported_grant_type")
+		return
+	}
+
+	// 授权码只能使用一次
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	req, found := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+	if !found || time.Now().After(req.expiresAt) || req.clientId != clientId ||
+		req.redirectUri != r.PostForm.Get("redirect_uri") ||
+		oidc.S256Challenge(r.PostForm.Get("code_verifier")) != req.codeChallenge {
+		writeError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	idToken, err := i.SignIDToken(jwt.MapClaims{
+		"iss":                i.Issuer(),
+		"aud":                i.ClientId,
+		"sub":                req.user.Subject,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              req.nonce,
+		"preferred_username": req.user.Username,
+		"name":               req.user.Name,
+		"email":              req.user.Email,
+		"email_verified":     req.user.EmailVerified,
+		"groups":             req.user.Groups,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": fmt.Sprintf("mock-access-%d", now.UnixNano()),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (i *IdP) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := i.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyId,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
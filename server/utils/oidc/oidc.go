@@ -0,0 +1,264 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval 遇到未知 kid 时重新拉取公钥的最小间隔，防止伪造 kid 触发大量请求
+const jwksRefreshInterval = time.Minute
+
+// Config 客户端配置
+type Config struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+	HTTPClient   *http.Client
+}
+
+// Provider 通过 discovery 获取端点的 OIDC 身份提供方
+type Provider struct {
+	cfg                   Config
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	keysFetched time.Time
+}
+
+// TokenResponse 令牌端点返回
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+}
+
+// Discover 读取 <issuer>/.well-known/openid-configuration
+func Discover(ctx context.Context, cfg Config) (*Provider, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	wellKnown := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{cfg: cfg}
+	if err = doJSON(cfg.HTTPClient, req, p); err != nil {
+		return nil, fmt.Errorf("获取OIDC配置失败: %w", err)
+	}
+	// 规范要求 discovery 中的 issuer 与配置完全一致，防止被其他身份提供方冒充
+	if strings.TrimSuffix(p.Issuer, "/") != strings.TrimSuffix(cfg.Issuer, "/") {
+		return nil, fmt.Errorf("OIDC issuer 不匹配: %s", p.Issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JwksUri == "" {
+		return nil, errors.New("OIDC配置缺少必要端点")
+	}
+	return p, nil
+}
+
+// AuthCodeURL 生成授权码模式（PKCE S256）的登录地址
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientId)
+	q.Set("redirect_uri", p.cfg.RedirectUrl)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// Exchange 使用授权码与 PKCE 校验码换取令牌
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectUrl)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.cfg.ClientId)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientId), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	var token TokenResponse
+	if err = doJSON(p.cfg.HTTPClient, req, &token); err != nil {
+		return nil, fmt.Errorf("授权码换取令牌失败: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("令牌响应中缺少 id_token")
+	}
+	return &token, nil
+}
+
+// VerifyIDToken 校验 ID Token 的签名、issuer、audience、有效期与 nonce，返回其中的声明
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(p.cfg.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID Token 校验失败: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, errors.New("ID Token nonce 不匹配")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("ID Token 缺少 sub")
+	}
+	return Claims(claims), nil
+}
+
+// publicKey 按 kid 查找签名公钥，未命中时刷新一次 JWKS
+func (p *Provider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.RLock()
+	key, ok := p.lookupKey(kid)
+	fresh := p.keys != nil && time.Since(p.keysFetched) < jwksRefreshInterval
+	p.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if fresh {
+		return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+	}
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if key, ok = p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+}
+
+// lookupKey 调用方需持有读锁；未指定 kid 且只有一个密钥时直接使用
+func (p *Provider) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *Provider) refreshKeys(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.JwksUri, nil)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err = doJSON(p.cfg.HTTPClient, req, &set); err != nil {
+		return fmt.Errorf("获取JWKS失败: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.keysFetched = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// NewPKCE 生成 PKCE 校验码与 S256 挑战码
+func NewPKCE() (verifier, challenge string, err error) {
+	if verifier, err = RandomString(32); err != nil {
+		return "", "", err
+	}
+	return verifier, S256Challenge(verifier), nil
+}
+
+// S256Challenge 计算 PKCE S256 挑战码
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RandomString 生成 URL 安全的随机字符串，n 为随机字节数
+func RandomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
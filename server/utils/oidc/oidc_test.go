@@ -0,0 +1,137 @@
+package oidc_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/oidc"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/oidc/mockidp"
+)
+
+func newProvider(t *testing.T) (*mockidp.IdP, *oidc.Provider) {
+	t.Helper()
+	idp, err := mockidp.New("gva-console", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+	p, err := oidc.Discover(context.Background(), oidc.Config{
+		Issuer:       idp.Issuer(),
+		ClientId:     "gva-console",
+		ClientSecret: "s3cret",
+		RedirectUrl:  "http://console.local/oidc/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idp, p
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	idp, p := newProvider(t)
+	ctx := context.Background()
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := mockidp.User{Subject: "u-1", Username: "alice", Email: "alice@example.com", Groups: []string{"ops", "dev"}}
+
+	code, state, err := idp.Authorize(p.AuthCodeURL("st-1", "n-1", challenge), user)
+	if err != nil || state != "st-1" {
+		t.Fatalf("Authorize() state=%q err=%v", state, err)
+	}
+	token, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := p.VerifyIDToken(ctx, token.IDToken, "n-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.String("sub") != "u-1" || claims.String("preferred_username") != "alice" {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+	if groups := claims.Strings("groups"); len(groups) != 2 || groups[0] != "ops" {
+		t.Errorf("Strings(groups) = %v", groups)
+	}
+
+	// 授权码只能使用一次
+	if _, err = p.Exchange(ctx, code, verifier); err == nil {
+		t.Error("授权码重复使用应失败")
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp, p := newProvider(t)
+	_, challenge, _ := oidc.NewPKCE()
+	code, _, err := idp.Authorize(p.AuthCodeURL("st", "n", challenge), mockidp.User{Subject: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Exchange(context.Background(), code, "wrong-verifier"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("PKCE 校验码错误应返回 invalid_grant, got %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejections(t *testing.T) {
+	idp, p := newProvider(t)
+	ctx := context.Background()
+	base := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": idp.Issuer(), "aud": "gva-console", "sub": "u-1", "nonce": "n-1",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+	}
+	cases := map[string]func(jwt.MapClaims){
+		"nonce 不匹配":   func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"audience 错误": func(c jwt.MapClaims) { c["aud"] = "another-client" },
+		"issuer 错误":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"已过期":         func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"缺少 sub":      func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+	for name, mutate := range cases {
+		claims := base()
+		mutate(claims)
+		raw, err := idp.SignIDToken(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = p.VerifyIDToken(ctx, raw, "n-1"); err == nil {
+			t.Errorf("%s: 应校验失败", name)
+		}
+	}
+
+	// 其他密钥签名的令牌
+	other, _ := mockidp.New("gva-console", "s3cret")
+	defer other.Close()
+	claims := base()
+	raw, _ := other.SignIDToken(claims)
+	if _, err := p.VerifyIDToken(ctx, raw, "n-1"); err == nil {
+		t.Error("非身份提供方密钥签名的令牌应校验失败")
+	}
+	raw, _ = idp.SignIDToken(base())
+	if _, err := p.VerifyIDToken(ctx, raw, "n-1"); err != nil {
+		t.Errorf("合法令牌校验失败: %v", err)
+	}
+}
+
+func TestClaimsLookup(t *testing.T) {
+	c := oidc.Claims{
+		"realm_access":   map[string]interface{}{"roles": []interface{}{"admin", "user"}},
+		"scope":          "openid profile",
+		"email_verified": "true",
+	}
+	if roles := c.Strings("realm_access.roles"); len(roles) != 2 || roles[1] != "user" {
+		t.Errorf("Strings(realm_access.roles) = %v", roles)
+	}
+	if scopes := c.Strings("scope"); len(scopes) != 2 {
+		t.Errorf("Strings(scope) = %v", scopes)
+	}
+	if !c.Bool("email_verified") || c.String("missing.path") != "" {
+		t.Error("Bool/String lookup mismatch")
+	}
+}
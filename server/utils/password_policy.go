@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+)
+
+// ErrPasswordPolicy 密码不符合安全策略，具体原因包装在错误信息中
+var ErrPasswordPolicy = errors.New("密码不符合安全策略")
+
+//go:embed passwords/breached.txt
+var breachedPasswordData string
+
+var (
+	breachedPasswords     map[string]struct{}
+	breachedPasswordsOnce sync.Once
+)
+
+// CheckPasswordStrength 按策略校验密码长度、字符种类，并排除包含用户名或位于弱密码库中的密码
+func CheckPasswordStrength(policy config.PasswordPolicy, username, password string) error {
+	if policy.MinLength > 0 && len([]rune(password)) < policy.MinLength {
+		return fmt.Errorf("%w: 长度不能少于%d位", ErrPasswordPolicy, policy.MinLength)
+	}
+	if policy.MinCharClasses > 0 && passwordCharClasses(password) < policy.MinCharClasses {
+		return fmt.Errorf("%w: 需包含大写字母、小写字母、数字、符号中的至少%d种", ErrPasswordPolicy, policy.MinCharClasses)
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return fmt.Errorf("%w: 不能包含用户名", ErrPasswordPolicy)
+	}
+	if policy.CheckBreached && IsBreachedPassword(password) {
+		return fmt.Errorf("%w: 该密码过于常见，请更换", ErrPasswordPolicy)
+	}
+	return nil
+}
+
+// IsBreachedPassword 判断密码是否位于内置弱密码库中，忽略大小写
+func IsBreachedPassword(password string) bool {
+	breachedPasswordsOnce.Do(func() {
+		breachedPasswords = make(map[string]struct{})
+		for _, line := range strings.Split(breachedPasswordData, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			breachedPasswords[strings.ToLower(line)] = struct{}{}
+		}
+	})
+	_, ok := breachedPasswords[strings.ToLower(password)]
+	return ok
+}
+
+// GeneratePassword 生成满足策略的随机密码，包含全部四类字符，长度不少于12位
+func GeneratePassword(policy config.PasswordPolicy) (string, error) {
+	const (
+		upper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+		lower  = "abcdefghijkmnpqrstuvwxyz"
+		digit  = "23456789"
+		symbol = "!@#$%^&*-_=+?"
+	)
+	length := policy.MinLength
+	if length < 12 {
+		length = 12
+	}
+	sets := []string{upper, lower, digit, symbol}
+	all := upper + lower + digit + symbol
+	buf := make([]byte, 0, length)
+	for i := 0; i < length; i++ {
+		charset := all
+		if i < len(sets) {
+			charset = sets[i]
+		}
+		c, err := randomChar(charset)
+		if err != nil {
+			return "", err
+		}
+		buf = append(buf, c)
+	}
+	// 打乱顺序，避免固定位置的字符种类
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		buf[i], buf[j.Int64()] = buf[j.Int64()], buf[i]
+	}
+	return string(buf), nil
+}
+
+func randomChar(charset string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+	if err != nil {
+		return 0, err
+	}
+	return charset[n.Int64()], nil
+}
+
+func passwordCharClasses(password string) int {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{hasUpper, hasLower, hasDigit, hasSymbol} {
+		if ok {
+			classes++
+		}
+	}
+	return classes
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+)
+
+func TestCheckPasswordStrength(t *testing.T) {
+	policy := config.PasswordPolicy{MinLength: 8, MinCharClasses: 3, CheckBreached: true}
+	cases := []struct {
+		password string
+		ok       bool
+	}{
+		{"Ab1!", false},        // 长度不足
+		{"abcdefgh1", false},   // 只有两类字符
+		{"Admin@123", false},   // 弱密码库
+		{"xAliceY9z!", false},  // 包含用户名
+		{"Tr0ub4dor&3", true},  // 满足全部要求
+		{"正确马电池Staple9", true}, // 中文按符号类计
+	}
+	for _, tt := range cases {
+		err := CheckPasswordStrength(policy, "alice", tt.password)
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckPasswordStrength(%q) = %v, want ok=%v", tt.password, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrPasswordPolicy) {
+			t.Errorf("CheckPasswordStrength(%q) 应返回 ErrPasswordPolicy, got %v", tt.password, err)
+		}
+	}
+}
+
+func TestGeneratePasswordSatisfiesPolicy(t *testing.T) {
+	policy := config.PasswordPolicy{MinLength: 16, MinCharClasses: 4, CheckBreached: true}
+	for i := 0; i < 50; i++ {
+		pwd, err := GeneratePassword(policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pwd) != 16 {
+			t.Fatalf("GeneratePassword 长度 = %d, want 16", len(pwd))
+		}
+		if err := CheckPasswordStrength(policy, "admin", pwd); err != nil {
+			t.Fatalf("GeneratePassword() = %q 不满足策略: %v", pwd, err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	redisSeqKey      = "sandbox:seq"
+	redisPaymentsKey = "sandbox:payments:" // 商户的模拟收款列表，值为 Payment 的 JSON
+	redisMatchedKey  = "sandbox:matched:"  // 商户已匹配的流水号集合
+	redisTTL         = 7 * 24 * time.Hour  // 模拟收款最后一次写入后保留的时间
+)
+
+// Payment 沙箱渠道中的一笔模拟收款
+type Payment struct {
+	TradeNo   string          `json:"tradeNo"`   // 模拟渠道流水号
+	MerUserId int64           `json:"merUserId"` // 收款商户ID
+	Amount    decimal.Decimal `json:"amount"`    // 实收金额
+	Payer     string          `json:"payer"`     // 付款人标识
+	PayTime   time.Time       `json:"payTime"`   // 支付时间
+	Matched   bool            `json:"matched"`   // 是否已被订单匹配
+}
+
+// Channel 模拟收单渠道
+// 测试或管理端通过 Pay 写入一笔收款，监控任务通过 CheckPayment 查询，
+// 与星驿、先享后付的"查询流水 -> 按金额与时间匹配"流程保持一致。
+// 配置了 Redis 时收款保存在 Redis 中，多实例部署下任一实例写入的收款都能被其他实例的监控任务查到；
+// 未配置 Redis 时保存在进程内，只适用于单实例
+type Channel struct {
+	mu       sync.Mutex
+	seq      int64
+	payments map[int64][]*Payment
+	client   func() redis.UniversalClient
+}
+
+// Default 全局沙箱渠道实例，使用 global.GVA_REDIS
+var Default = &Channel{
+	payments: make(map[int64][]*Payment),
+	client:   func() redis.UniversalClient { return global.GVA_REDIS },
+}
+
+// NewChannel 创建进程内的沙箱渠道
+func NewChannel() *Channel {
+	return &Channel{payments: make(map[int64][]*Payment)}
+}
+
+// NewRedisChannel 创建保存在 Redis 中的沙箱渠道
+func NewRedisChannel(client redis.UniversalClient) *Channel {
+	return &Channel{payments: make(map[int64][]*Payment), client: func() redis.UniversalClient { return client }}
+}
+
+func (c *Channel) redis() redis.UniversalClient {
+	if c.client == nil {
+		return nil
+	}
+	return c.client()
+}
+
+// Pay 模拟顾客向指定商户支付一笔金额
+func (c *Channel) Pay(ctx context.Context, merUserId int64, amount string, payTime time.Time) (*Payment, error) {
+	return c.PayFrom(ctx, merUserId, amount, "", payTime)
+}
+
+// PayFrom 模拟指定付款人向商户支付一笔金额，用于验证付款人拉黑规则
+func (c *Channel) PayFrom(ctx context.Context, merUserId int64, amount, payer string, payTime time.Time) (*Payment, error) {
+	if merUserId == 0 {
+		return nil, errors.New("商户ID不能为空")
+	}
+	value, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, fmt.Errorf("金额格式错误: %w", err)
+	}
+	if !value.IsPositive() {
+		return nil, errors.New("金额必须大于0")
+	}
+	if payTime.IsZero() {
+		payTime = time.Now()
+	}
+	payment := &Payment{
+		MerUserId: merUserId,
+		Amount:    value.Round(2),
+		Payer:     payer,
+		PayTime:   payTime,
+	}
+
+	if rdb := c.redis(); rdb != nil {
+		seq, err := rdb.Incr(ctx, redisSeqKey).Result()
+		if err != nil {
+			return nil, err
+		}
+		payment.TradeNo = tradeNo(payTime, seq)
+		data, err := json.Marshal(payment)
+		if err != nil {
+			return nil, err
+		}
+		key := redisPaymentsKey + strconv.FormatInt(merUserId, 10)
+		pipe := rdb.TxPipeline()
+		pipe.RPush(ctx, key, data)
+		pipe.Expire(ctx, key, redisTTL)
+		if _, err = pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+		return payment, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	payment.TradeNo = tradeNo(payTime, c.seq)
+	c.payments[merUserId] = append(c.payments[merUserId], payment)
+	return payment, nil
+}
+
+func tradeNo(payTime time.Time, seq int64) string {
+	return fmt.Sprintf("SANDBOX%s%06d", payTime.Format("20060102150405"), seq)
+}
+
+// CheckPayment 查找指定商户在时间范围内金额相等且未被匹配的收款
+// 找到后将其标记为已匹配，避免同一笔收款被多个订单重复确认
+func (c *Channel) CheckPayment(ctx context.Context, merUserId int64, startTime, endTime time.Time, amount string) (bool, *Payment, error) {
+	target, err := decimal.NewFromString(amount)
+	if err != nil {
+		return false, nil, fmt.Errorf("目标金额格式错误: %w", err)
+	}
+
+	if c.redis() != nil {
+		list, err := c.List(ctx, merUserId)
+		if err != nil {
+			return false, nil, err
+		}
+		for _, payment := range list {
+			if payment.Matched || !payment.Amount.Equal(target) {
+				continue
+			}
+			if payment.PayTime.Before(startTime) || payment.PayTime.After(endTime) {
+				continue
+			}
+			// 以加入已匹配集合是否成功作为认领结果，多个实例同时匹配时只有一个成功
+			claimed, err := c.claim(ctx, merUserId, payment.TradeNo)
+			if err != nil {
+				return false, nil, err
+			}
+			if claimed {
+				payment.Matched = true
+				return true, &payment, nil
+			}
+		}
+		return false, nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, payment := range c.payments[merUserId] {
+		if payment.Matched || !payment.Amount.Equal(target) {
+			continue
+		}
+		if payment.PayTime.Before(startTime) || payment.PayTime.After(endTime) {
+			continue
+		}
+		payment.Matched = true
+		matched := *payment
+		return true, &matched, nil
+	}
+	return false, nil, nil
+}
+
+// claim 将流水号加入已匹配集合，返回是否由本次调用加入
+func (c *Channel) claim(ctx context.Context, merUserId int64, tradeNo string) (bool, error) {
+	rdb := c.redis()
+	key := redisMatchedKey + strconv.FormatInt(merUserId, 10)
+	pipe := rdb.TxPipeline()
+	added := pipe.SAdd(ctx, key, tradeNo)
+	pipe.Expire(ctx, key, redisTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return added.Val() == 1, nil
+}
+
+// MarkMatched 将指定流水号的收款标记为已匹配
+func (c *Channel) MarkMatched(ctx context.Context, merUserId int64, tradeNo string) error {
+	if c.redis() != nil {
+		_, err := c.claim(ctx, merUserId, tradeNo)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, payment := range c.payments[merUserId] {
+		if payment.TradeNo == tradeNo {
+			payment.Matched = true
+			return nil
+		}
+	}
+	return nil
+}
+
+// List 返回指定商户的全部模拟收款
+func (c *Channel) List(ctx context.Context, merUserId int64) ([]Payment, error) {
+	if rdb := c.redis(); rdb != nil {
+		id := strconv.FormatInt(merUserId, 10)
+		items, err := rdb.LRange(ctx, redisPaymentsKey+id, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		matched, err := rdb.SMembers(ctx, redisMatchedKey+id).Result()
+		if err != nil {
+			return nil, err
+		}
+		matchedSet := make(map[string]bool, len(matched))
+		for _, tradeNo := range matched {
+			matchedSet[tradeNo] = true
+		}
+		list := make([]Payment, 0, len(items))
+		for _, item := range items {
+			var payment Payment
+			if err = json.Unmarshal([]byte(item), &payment); err != nil {
+				return nil, err
+			}
+			payment.Matched = matchedSet[payment.TradeNo]
+			list = append(list, payment)
+		}
+		return list, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]Payment, 0, len(c.payments[merUserId]))
+	for _, payment := range c.payments[merUserId] {
+		list = append(list, *payment)
+	}
+	return list, nil
+}
+
+// Reset 清空沙箱渠道中的全部收款
+func (c *Channel) Reset(ctx context.Context) error {
+	if rdb := c.redis(); rdb != nil {
+		iter := rdb.Scan(ctx, 0, "sandbox:*", 100).Iterator()
+		for iter.Next(ctx) {
+			if err := rdb.Del(ctx, iter.Val()).Err(); err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.payments = make(map[int64][]*Payment)
+	return nil
+}
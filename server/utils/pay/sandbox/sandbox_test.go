@@ -0,0 +1,123 @@
+package sandbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// forEachChannel 分别在进程内与 Redis 存储的渠道上运行测试
+func forEachChannel(t *testing.T, fn func(t *testing.T, channel *Channel)) {
+	t.Run("memory", func(t *testing.T) { fn(t, NewChannel()) })
+	t.Run("redis", func(t *testing.T) {
+		client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		t.Cleanup(func() { client.Close() })
+		fn(t, NewRedisChannel(client))
+	})
+}
+
+func TestChannelCheckPayment(t *testing.T) {
+	forEachChannel(t, func(t *testing.T, channel *Channel) {
+		ctx := context.Background()
+		start := time.Now().Add(-time.Minute)
+		end := time.Now().Add(time.Minute)
+
+		if _, err := channel.Pay(ctx, 1, "5.23", time.Now()); err != nil {
+			t.Fatalf("Pay failed: %v", err)
+		}
+
+		found, _, err := channel.CheckPayment(ctx, 1, start, end, "5.24")
+		if err != nil || found {
+			t.Fatalf("unexpected match for different amount: found=%v err=%v", found, err)
+		}
+		found, _, err = channel.CheckPayment(ctx, 2, start, end, "5.23")
+		if err != nil || found {
+			t.Fatalf("unexpected match for different merchant: found=%v err=%v", found, err)
+		}
+
+		found, payment, err := channel.CheckPayment(ctx, 1, start, end, "5.23")
+		if err != nil || !found || payment == nil {
+			t.Fatalf("expected match: found=%v err=%v", found, err)
+		}
+		if payment.Amount.StringFixed(2) != "5.23" {
+			t.Errorf("unexpected amount %s", payment.Amount)
+		}
+
+		// 同一笔收款只能被匹配一次
+		found, _, _ = channel.CheckPayment(ctx, 1, start, end, "5.23")
+		if found {
+			t.Errorf("payment matched twice")
+		}
+	})
+}
+
+func TestChannelCheckPaymentOutOfRange(t *testing.T) {
+	forEachChannel(t, func(t *testing.T, channel *Channel) {
+		ctx := context.Background()
+		payTime := time.Now()
+		if _, err := channel.Pay(ctx, 1, "10.01", payTime); err != nil {
+			t.Fatalf("Pay failed: %v", err)
+		}
+		found, _, err := channel.CheckPayment(ctx, 1, payTime.Add(time.Second), payTime.Add(time.Minute), "10.01")
+		if err != nil || found {
+			t.Errorf("payment before start time should not match: found=%v err=%v", found, err)
+		}
+	})
+}
+
+func TestChannelPayInvalid(t *testing.T) {
+	forEachChannel(t, func(t *testing.T, channel *Channel) {
+		ctx := context.Background()
+		for _, amount := range []string{"", "abc", "0", "-1.00"} {
+			if _, err := channel.Pay(ctx, 1, amount, time.Now()); err == nil {
+				t.Errorf("expected error for amount %q", amount)
+			}
+		}
+		if _, err := channel.Pay(ctx, 0, "1.00", time.Now()); err == nil {
+			t.Errorf("expected error for empty merchant")
+		}
+	})
+}
+
+func TestChannelPayFrom(t *testing.T) {
+	forEachChannel(t, func(t *testing.T, channel *Channel) {
+		ctx := context.Background()
+		payment, err := channel.PayFrom(ctx, 1, "3.00", "openid-1", time.Now())
+		if err != nil {
+			t.Fatalf("PayFrom failed: %v", err)
+		}
+		if payment.Payer != "openid-1" {
+			t.Errorf("unexpected payer %q", payment.Payer)
+		}
+		if err = channel.MarkMatched(ctx, 1, payment.TradeNo); err != nil {
+			t.Fatal(err)
+		}
+		if list, err := channel.List(ctx, 1); err != nil || len(list) != 1 || !list[0].Matched {
+			t.Errorf("payment should be marked matched: %+v", list)
+		}
+	})
+}
+
+func TestChannelRedisCrossInstance(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	clientA := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	clientB := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { clientA.Close(); clientB.Close() })
+	a, b := NewRedisChannel(clientA), NewRedisChannel(clientB)
+
+	// 实例 A 写入的收款由实例 B 的监控任务匹配，且只能匹配一次
+	if _, err := a.Pay(ctx, 1, "8.88", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	start, end := time.Now().Add(-time.Minute), time.Now().Add(time.Minute)
+	if found, _, err := b.CheckPayment(ctx, 1, start, end, "8.88"); err != nil || !found {
+		t.Fatalf("instance B should see the payment: found=%v err=%v", found, err)
+	}
+	if found, _, _ := a.CheckPayment(ctx, 1, start, end, "8.88"); found {
+		t.Errorf("payment matched twice across instances")
+	}
+}
@@ -46,6 +46,7 @@ type OrderItem struct {
 	UpdateAt     string          `json:"update_at"`
 	PayTime      string          `json:"pay_time"`
 	NotifyStatus int             `json:"notify_status"`
+	OpenId       string          `json:"openid"` // 付款人标识
 }
 
 // Service 服务结构体
@@ -250,26 +251,35 @@ func (s *Service) GetOrderList(token, orderTime string) ([]byte, error) {
 	return body, nil
 }
 
-// CheckPayment 检查支付结果
-func (s *Service) CheckPayment(body string, startTime, endTime time.Time, amount string) (bool, *OrderItem, error) {
+// ParseOrderList 解析订单列表响应，返回全部订单
+func (s *Service) ParseOrderList(body string) ([]OrderItem, error) {
 	// 解析响应
 	var response OrderListResponse
 	if err := json.Unmarshal([]byte(body), &response); err != nil {
 		logError("解析订单列表 JSON 失败",
 			"error", err,
 			"body", body)
-		return false, nil, fmt.Errorf("解析 JSON 失败: %w", err)
+		return nil, fmt.Errorf("解析 JSON 失败: %w", err)
 	}
 
 	if response.Code != 200 {
 		logWarn("订单列表查询失败",
 			"code", response.Code,
 			"msg", response.Msg)
-		return false, nil, fmt.Errorf("查询失败: %s", response.Msg)
+		return nil, fmt.Errorf("查询失败: %s", response.Msg)
+	}
+	return response.Data.Data, nil
+}
+
+// CheckPayment 检查支付结果
+func (s *Service) CheckPayment(body string, startTime, endTime time.Time, amount string) (bool, *OrderItem, error) {
+	orders, err := s.ParseOrderList(body)
+	if err != nil {
+		return false, nil, err
 	}
 
 	logInfo("开始检查订单列表",
-		"订单总数", len(response.Data.Data),
+		"订单总数", len(orders),
 		"目标金额", amount,
 		"开始时间", startTime,
 		"结束时间", endTime)
@@ -285,7 +295,7 @@ func (s *Service) CheckPayment(body string, startTime, endTime time.Time, amount
 	}
 
 	// 遍历订单列表进行检查
-	for i, order := range response.Data.Data {
+	for i, order := range orders {
 		logDebug("检查订单",
 			"索引", i,
 			"订单号", order.OrderSn,
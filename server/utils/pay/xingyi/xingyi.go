@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -28,6 +29,7 @@ type OrderRow struct {
 	OrderStatus string `json:"ORDER_STATUS"` // 订单状态
 	PayChannel  string `json:"PAY_CHANNEL"`  // 支付渠道
 	Txamt       string `json:"TXAMT"`        // 交易金额
+	OpenId      string `json:"OPEN_ID"`      // 付款人标识
 }
 
 // redisCookieKey 生成具体的 Redis 键名
@@ -432,20 +434,20 @@ func (s *Service) GetPayList(token string, startTime string, endTime string, mer
 	return body, nil
 }
 
-// GetCheckResult 检查支付列表中是否有符合条件的订单
-// 检查条件：1. ORDER_TIME 在 startTime 和 endTime 之间  2. amount 等于 REC_TXAMT
-func (s *Service) GetCheckResult(body string, startTime, endTime time.Time, amount string) (bool, error) {
+// ParsePayList 解析支付列表响应，返回全部订单行
+// 响应中缺少 ROWLIST 字段时视为无数据
+func (s *Service) ParsePayList(body string) ([]OrderRow, error) {
 	// 先检查 JSON 中是否包含 ROWLIST 字段
 	if !strings.Contains(body, "ROWLIST") {
 		global.GVA_LOG.Warn("JSON 数据中缺少 ROWLIST 字段", zap.String("body", body))
-		return false, nil
+		return nil, nil
 	}
 
 	// 解析 JSON 数据
 	var response PayListResponse
 	if err := json.Unmarshal([]byte(body), &response); err != nil {
 		global.GVA_LOG.Error("解析支付列表 JSON 失败", zap.Error(err), zap.String("body", body))
-		return false, fmt.Errorf("解析 JSON 失败: %w", err)
+		return nil, fmt.Errorf("解析 JSON 失败: %w", err)
 	}
 
 	// 检查响应状态
@@ -453,17 +455,27 @@ func (s *Service) GetCheckResult(body string, startTime, endTime time.Time, amou
 		global.GVA_LOG.Warn("支付列表查询失败",
 			zap.String("RSPCOD", response.RSPCOD),
 			zap.String("RSPMSG", response.RSPMSG))
-		return false, fmt.Errorf("查询失败: %s", response.RSPMSG)
+		return nil, fmt.Errorf("查询失败: %s", response.RSPMSG)
+	}
+	return response.ROWLIST, nil
+}
+
+// GetCheckResult 检查支付列表中是否有符合条件的订单
+// 检查条件：1. ORDER_TIME 在 startTime 和 endTime 之间  2. amount 等于 REC_TXAMT
+func (s *Service) GetCheckResult(body string, startTime, endTime time.Time, amount string) (bool, error) {
+	rows, err := s.ParsePayList(body)
+	if err != nil {
+		return false, err
 	}
 
 	global.GVA_LOG.Info("开始检查支付列表",
-		zap.Int("订单总数", len(response.ROWLIST)),
+		zap.Int("订单总数", len(rows)),
 		zap.String("目标金额", amount),
 		zap.Time("开始时间", startTime),
 		zap.Time("结束时间", endTime))
 
 	// 遍历订单列表进行检查
-	for i, order := range response.ROWLIST {
+	for i, order := range rows {
 		global.GVA_LOG.Debug("检查订单",
 			zap.Int("索引", i),
 			zap.String("订单号", order.OrderNo),
@@ -509,8 +521,16 @@ func (s *Service) GetCheckResult(body string, startTime, endTime time.Time, amou
 
 // checkAmount 检查金额是否匹配
 func (s *Service) checkAmount(recTxamt, targetAmount string) bool {
-	// 直接比较字符串
-	return recTxamt == targetAmount
+	// 按数值比较，避免 "5.1" 与 "5.10" 这类格式差异导致漏单
+	received, err := decimal.NewFromString(strings.TrimSpace(recTxamt))
+	if err != nil {
+		return false
+	}
+	target, err := decimal.NewFromString(strings.TrimSpace(targetAmount))
+	if err != nil {
+		return false
+	}
+	return received.Equal(target)
 }
 
 // checkTimeRange 检查时间是否在指定范围内
@@ -531,7 +551,7 @@ func (s *Service) checkTimeRange(orderTimeStr string, startTime, endTime time.Ti
 
 // GetPayList replicates mergeCodeAndCardTradeList2.mers query
 func (s *Service) CheckToken(token string, merId string) bool {
-	body, err := s.GetPayList(token, "20251008170435", "20251008170435", merId, Cookies{})
+	body, err := s.GetPayList(token, "20251008170435", "20251008170435", merId)
 	if err != nil {
 		global.GVA_LOG.Error("获取支付列表失败", zap.Error(err))
 		return false
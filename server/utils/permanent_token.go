@@ -19,8 +19,15 @@ type PermanentToken struct {
 	Token     string `json:"token"`
 	CreatedAt int64  `json:"created_at"`
 	IsActive  bool   `json:"is_active"`
+	Sandbox   bool   `json:"sandbox"` // 沙箱token，只能调用沙箱商户
 }
 
+// 永久token前缀，沙箱token使用独立前缀便于区分
+const (
+	permanentTokenPrefix = "perm_"
+	sandboxTokenPrefix   = "perm_sb_"
+)
+
 // RevokeAllUserPermanentTokens 删除用户的所有永久token
 func RevokeAllUserPermanentTokens(userID uint) error {
 	global.GVA_LOG.Info("开始删除用户所有永久token", zap.Uint("user_id", userID))
@@ -33,7 +40,29 @@ func RevokeAllUserPermanentTokens(userID uint) error {
 			zap.Error(err))
 		return err
 	}
-	
+	return revokePermanentTokens(userID, tokens)
+}
+
+// revokeUserPermanentTokensByMode 删除用户指定模式（沙箱/正式）的永久token，另一模式的token不受影响
+func revokeUserPermanentTokensByMode(userID uint, sandbox bool) error {
+	tokens, err := GetUserPermanentTokens(userID)
+	if err != nil {
+		global.GVA_LOG.Error("获取用户token列表失败",
+			zap.Uint("user_id", userID),
+			zap.Error(err))
+		return err
+	}
+	var matched []PermanentToken
+	for _, token := range tokens {
+		if token.Sandbox == sandbox {
+			matched = append(matched, token)
+		}
+	}
+	return revokePermanentTokens(userID, matched)
+}
+
+// revokePermanentTokens 从Redis中逐个删除给定的token
+func revokePermanentTokens(userID uint, tokens []PermanentToken) error {
 	global.GVA_LOG.Info("找到用户token", 
 		zap.Uint("user_id", userID),
 		zap.Int("total_tokens", len(tokens)))
@@ -73,9 +102,10 @@ func RevokeAllUserPermanentTokens(userID uint) error {
 	return nil
 }
 
-// GeneratePermanentToken 为用户生成永久token（每个用户只能有一个有效token）
-func GeneratePermanentToken(userID uint) (string, error) {
-	global.GVA_LOG.Info("开始为用户生成永久token", zap.Uint("user_id", userID))
+// GeneratePermanentToken 为用户生成永久token（每个用户在正式、沙箱模式下各只能有一个有效token）
+// sandbox 为 true 时生成沙箱token，沙箱token与正式token互相隔离
+func GeneratePermanentToken(userID uint, sandbox bool) (string, error) {
+	global.GVA_LOG.Info("开始为用户生成永久token", zap.Uint("user_id", userID), zap.Bool("sandbox", sandbox))
 	
 	// 首先获取用户现有的token数量
 	existingTokens, err := GetUserPermanentTokens(userID)
@@ -89,8 +119,8 @@ func GeneratePermanentToken(userID uint) (string, error) {
 			zap.Int("token_count", len(existingTokens)))
 	}
 	
-	// 撤销用户的所有现有token
-	err = RevokeAllUserPermanentTokens(userID)
+	// 撤销用户同一模式下的现有token
+	err = revokeUserPermanentTokensByMode(userID, sandbox)
 	if err != nil {
 		global.GVA_LOG.Error("撤销用户现有永久token失败", 
 			zap.Uint("user_id", userID),
@@ -116,7 +146,11 @@ func GeneratePermanentToken(userID uint) (string, error) {
 	token := hex.EncodeToString(hash[:])
 	
 	// 添加前缀以区分永久token
-	finalToken := fmt.Sprintf("perm_%s", token)
+	prefix := permanentTokenPrefix
+	if sandbox {
+		prefix = sandboxTokenPrefix
+	}
+	finalToken := prefix + token
 	
 	// 存储到Redis中，永久有效（设置一个很长的过期时间，比如10年）
 	tokenKey := fmt.Sprintf("permanent_token:%s", finalToken)
@@ -128,6 +162,7 @@ func GeneratePermanentToken(userID uint) (string, error) {
 		"token":      finalToken,
 		"created_at": timestamp,
 		"is_active":  true,
+		"sandbox":    sandbox,
 	}).Err()
 	
 	if err != nil {
@@ -173,7 +208,7 @@ func GeneratePermanentToken(userID uint) (string, error) {
 // ValidatePermanentToken 验证永久token
 func ValidatePermanentToken(token string) (*PermanentToken, error) {
 	// 检查token格式
-	if !strings.HasPrefix(token, "perm_") {
+	if !strings.HasPrefix(token, permanentTokenPrefix) {
 		return nil, fmt.Errorf("无效的永久token格式")
 	}
 	
@@ -210,6 +245,7 @@ func ValidatePermanentToken(token string) (*PermanentToken, error) {
 		Token:     token,
 		CreatedAt: createdAt,
 		IsActive:  true,
+		Sandbox:   tokenData["sandbox"] == "1",
 	}
 	
 	return permanentToken, nil
@@ -269,6 +305,7 @@ func GetUserPermanentTokens(userID uint) ([]PermanentToken, error) {
 				Token:     tokenData["token"],
 				CreatedAt: createdAt,
 				IsActive:  true, // 所有存在的token都是有效的
+				Sandbox:   tokenData["sandbox"] == "1",
 			})
 		}
 	}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// RedactedValue 脱敏后的占位内容
+const RedactedValue = "******"
+
+// sensitiveKeywords 字段名包含以下内容时脱敏，不区分大小写
+var sensitiveKeywords = []string{"password", "passwd", "pwd", "secret", "token", "credential", "authorization", "cookie"}
+
+// sensitiveKeyNames 以下列名称结尾的密钥类字段脱敏，比较时忽略大小写、下划线与中划线，
+// 不使用 key 后缀匹配，避免 primaryKey、importKey 等普通字段被误伤
+var sensitiveKeyNames = []string{"apikey", "accesskey", "privatekey", "secretkey", "signingkey", "encryptionkey", "appkey"}
+
+// IsSensitiveField 判断字段名是否需要脱敏
+func IsSensitiveField(name string, extra []string) bool {
+	lower := strings.ToLower(name)
+	for _, e := range extra {
+		if strings.EqualFold(name, e) {
+			return true
+		}
+	}
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(lower)
+	for _, k := range sensitiveKeyNames {
+		if strings.HasSuffix(normalized, k) {
+			return true
+		}
+	}
+	for _, k := range sensitiveKeywords {
+		if strings.Contains(lower, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactJSON 将 JSON 中敏感字段的值替换为占位内容，不是 JSON 或没有敏感字段时原样返回
+func RedactJSON(data string, extra []string) string {
+	trimmed := strings.TrimSpace(data)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return data
+	}
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return data
+	}
+	if !redactValue(v, extra) {
+		return data
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return data
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// RedactForm 将表单中敏感字段的值替换为占位内容，无法解析时原样返回
+func RedactForm(data string, extra []string) string {
+	values, err := url.ParseQuery(data)
+	if err != nil {
+		return data
+	}
+	redacted := false
+	for k := range values {
+		if IsSensitiveField(k, extra) {
+			values[k] = []string{RedactedValue}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return data
+	}
+	return values.Encode()
+}
+
+func redactValue(v interface{}, extra []string) bool {
+	redacted := false
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			// 只替换字符串与数字，布尔值与嵌套结构（如 primaryKey: true）不含敏感内容
+			switch item.(type) {
+			case string, json.Number:
+				if IsSensitiveField(k, extra) {
+					val[k] = RedactedValue
+					redacted = true
+					continue
+				}
+			}
+			if redactValue(item, extra) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if redactValue(item, extra) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
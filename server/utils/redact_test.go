@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestRedactJSON(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{`{"username":"admin","password":"123456"}`, `{"password":"******","username":"admin"}`},
+		{`{"data":{"token":"abc","user":{"nickName":"a"}}}`, `{"data":{"token":"******","user":{"nickName":"a"}}}`},
+		{`[{"accessKey":"k1","primaryKey":true,"clientSecret":42}]`, `[{"accessKey":"******","clientSecret":"******","primaryKey":true}]`},
+		{`{"phone":"138","idCard":"110"}`, `{"phone":"138","idCard":"110"}`},
+		{`{"x-api-key":"k1","secret_key":"k2","importKey":"code","key":"sys_config"}`, `{"importKey":"code","key":"sys_config","secret_key":"******","x-api-key":"******"}`},
+		{`not json <password>`, `not json <password>`},
+	}
+	for _, tt := range cases {
+		if got := RedactJSON(tt.in, nil); got != tt.want {
+			t.Errorf("RedactJSON(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	if got := RedactJSON(`{"idCard":"110","amount":1.10}`, []string{"IDCARD"}); got != `{"amount":1.10,"idCard":"******"}` {
+		t.Errorf("extra field: %s", got)
+	}
+}
+
+func TestRedactForm(t *testing.T) {
+	if got := RedactForm("username=admin&newPassword=secret", nil); got != "newPassword=%2A%2A%2A%2A%2A%2A&username=admin" {
+		t.Errorf("RedactForm = %s", got)
+	}
+	if got := RedactForm("a=1&b=2", nil); got != "a=1&b=2" {
+		t.Errorf("RedactForm should keep form without sensitive fields, got %s", got)
+	}
+}
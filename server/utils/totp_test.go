@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B的SHA1测试向量，密钥为ASCII "12345678901234567890"
+func TestTotpCodeRFCVectors(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, c := range cases {
+		got, err := TotpCode(secret, TotpStep(time.Unix(c.unix, 0)))
+		if err != nil {
+			t.Fatalf("TotpCode(%d) error: %v", c.unix, err)
+		}
+		if got != c.want {
+			t.Errorf("TotpCode(%d) = %s, want %s", c.unix, got, c.want)
+		}
+	}
+}
+
+func TestValidateTotp(t *testing.T) {
+	secret, err := GenerateTotpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	prev, _ := TotpCode(secret, TotpStep(now)-1)
+	if step, ok := ValidateTotp(secret, prev, now); !ok || step != TotpStep(now)-1 {
+		t.Errorf("上一周期动态码应在容忍范围内, step=%d ok=%v", step, ok)
+	}
+	old, _ := TotpCode(secret, TotpStep(now)-3)
+	if _, ok := ValidateTotp(secret, old, now); ok {
+		t.Error("超出容忍范围的动态码不应通过")
+	}
+	if _, ok := ValidateTotp(secret, "12345", now); ok {
+		t.Error("位数不正确的动态码不应通过")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+	code := codes[0]
+	if HashRecoveryCode(code) != HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))) {
+		t.Error("恢复码摘要应忽略大小写与分隔符")
+	}
+}
+
+func TestTotpProvisioningURI(t *testing.T) {
+	uri := TotpProvisioningURI("JBSWY3DPEHPK3PXP", "GVA", "admin")
+	if !strings.HasPrefix(uri, "otpauth://totp/GVA:admin?") || !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("unexpected uri: %s", uri)
+	}
+}
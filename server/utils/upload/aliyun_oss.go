@@ -2,7 +2,9 @@ package upload
 
 import (
 	"errors"
+	"io"
 	"mime/multipart"
+	"net/http"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -73,3 +75,33 @@ func NewBucket() (*oss.Bucket, error) {
 
 	return bucket, nil
 }
+
+// PutPrivate 以私有读写权限上传对象
+func (*AliyunOSS) PutPrivate(key string, src io.Reader, _ int64) error {
+	bucket, err := NewBucket()
+	if err != nil {
+		return err
+	}
+	return bucket.PutObject(privateObjectName(key), src, oss.ObjectACL(oss.ACLPrivate))
+}
+
+func (*AliyunOSS) GetPrivate(key string) (io.ReadCloser, error) {
+	bucket, err := NewBucket()
+	if err != nil {
+		return nil, err
+	}
+	body, err := bucket.GetObject(privateObjectName(key))
+	var serviceErr oss.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusNotFound {
+		return nil, ErrPrivateFileNotFound
+	}
+	return body, err
+}
+
+func (*AliyunOSS) DeletePrivate(key string) error {
+	bucket, err := NewBucket()
+	if err != nil {
+		return err
+	}
+	return bucket.DeleteObject(privateObjectName(key))
+}
@@ -3,12 +3,14 @@ package upload
 import (
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"time"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -95,3 +97,43 @@ func newSession() *session.Session {
 	})
 	return sess
 }
+
+// PutPrivate 以私有读写权限上传对象
+func (*AwsS3) PutPrivate(key string, src io.Reader, _ int64) error {
+	uploader := s3manager.NewUploader(newSession())
+	_, err := uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(global.GVA_CONFIG.AwsS3.Bucket),
+		Key:    aws.String(privateObjectName(key)),
+		ACL:    aws.String(s3.ObjectCannedACLPrivate),
+		Body:   src,
+	})
+	return err
+}
+
+func (*AwsS3) GetPrivate(key string) (io.ReadCloser, error) {
+	return getS3Private(s3.New(newSession()), global.GVA_CONFIG.AwsS3.Bucket, key)
+}
+
+func (*AwsS3) DeletePrivate(key string) error {
+	_, err := s3.New(newSession()).DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(global.GVA_CONFIG.AwsS3.Bucket),
+		Key:    aws.String(privateObjectName(key)),
+	})
+	return err
+}
+
+// getS3Private 读取 S3 兼容存储中的私有对象
+func getS3Private(svc *s3.S3, bucket, key string) (io.ReadCloser, error) {
+	output, err := svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(privateObjectName(key)),
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, ErrPrivateFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
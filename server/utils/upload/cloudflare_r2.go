@@ -3,6 +3,7 @@ package upload
 import (
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"time"
 
@@ -83,3 +84,26 @@ func (*CloudflareR2) newSession() *session.Session {
 		),
 	}))
 }
+
+// PutPrivate 上传私有文件，R2 存储桶默认不公开访问
+func (c *CloudflareR2) PutPrivate(key string, src io.Reader, _ int64) error {
+	uploader := s3manager.NewUploader(c.newSession())
+	_, err := uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(global.GVA_CONFIG.CloudflareR2.Bucket),
+		Key:    aws.String(privateObjectName(key)),
+		Body:   src,
+	})
+	return err
+}
+
+func (c *CloudflareR2) GetPrivate(key string) (io.ReadCloser, error) {
+	return getS3Private(s3.New(c.newSession()), global.GVA_CONFIG.CloudflareR2.Bucket, key)
+}
+
+func (c *CloudflareR2) DeletePrivate(key string) error {
+	_, err := s3.New(c.newSession()).DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(global.GVA_CONFIG.CloudflareR2.Bucket),
+		Key:    aws.String(privateObjectName(key)),
+	})
+	return err
+}
@@ -0,0 +1,44 @@
+package upload
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"os"
+)
+
+// FileHeaderFromPath 将服务端生成的本地文件包装为 multipart.FileHeader，以便复用 OSS.UploadFile
+// 文件内容以流的方式转存到 multipart 临时文件，不会整体读入内存；上传完成后需调用返回的 cleanup
+func FileHeaderFromPath(path, filename string) (*multipart.FileHeader, func(), error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		defer src.Close()
+		part, err := mw.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(part, src)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	// maxMemory 为 0 时文件部分全部写入磁盘临时文件
+	form, err := multipart.NewReader(pr, mw.Boundary()).ReadForm(0)
+	_ = pr.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { _ = form.RemoveAll() }
+	files := form.File["file"]
+	if len(files) == 0 {
+		cleanup()
+		return nil, nil, errors.New("文件包装失败")
+	}
+	return files[0], cleanup, nil
+}
@@ -97,3 +97,31 @@ func (m *Minio) DeleteFile(key string) error {
 	err := m.Client.RemoveObject(ctx, m.bucket, key, minio.RemoveObjectOptions{})
 	return err
 }
+
+// PutPrivate 上传私有文件，未设置公开读策略的对象不能匿名访问
+func (m *Minio) PutPrivate(key string, src io.Reader, size int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*10)
+	defer cancel()
+	_, err := m.Client.PutObject(ctx, m.bucket, privateObjectName(key), src, size, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	return err
+}
+
+func (m *Minio) GetPrivate(key string) (io.ReadCloser, error) {
+	object, err := m.Client.GetObject(context.Background(), m.bucket, privateObjectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject 不会立即请求，通过 Stat 确认对象存在
+	if _, err = object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrPrivateFileNotFound
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+func (m *Minio) DeletePrivate(key string) error {
+	return m.DeleteFile(privateObjectName(key))
+}
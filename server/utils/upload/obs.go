@@ -1,7 +1,9 @@
 package upload
 
 import (
+	"io"
 	"mime/multipart"
+	"net/http"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
@@ -67,3 +69,47 @@ func (o *Obs) DeleteFile(key string) error {
 	}
 	return nil
 }
+
+// PutPrivate 以私有读写权限上传对象
+func (o *Obs) PutPrivate(key string, src io.Reader, size int64) error {
+	client, err := NewHuaWeiObsClient()
+	if err != nil {
+		return errors.Wrap(err, "获取华为对象存储对象失败!")
+	}
+	input := &obs.PutObjectInput{
+		PutObjectBasicInput: obs.PutObjectBasicInput{
+			ObjectOperationInput: obs.ObjectOperationInput{
+				Bucket: global.GVA_CONFIG.HuaWeiObs.Bucket,
+				Key:    privateObjectName(key),
+				ACL:    obs.AclPrivate,
+			},
+			ContentLength: size,
+		},
+		Body: src,
+	}
+	_, err = client.PutObject(input)
+	return errors.Wrap(err, "文件上传失败!")
+}
+
+func (o *Obs) GetPrivate(key string) (io.ReadCloser, error) {
+	client, err := NewHuaWeiObsClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "获取华为对象存储对象失败!")
+	}
+	input := &obs.GetObjectInput{}
+	input.Bucket = global.GVA_CONFIG.HuaWeiObs.Bucket
+	input.Key = privateObjectName(key)
+	output, err := client.GetObject(input)
+	var obsErr obs.ObsError
+	if errors.As(err, &obsErr) && obsErr.StatusCode == http.StatusNotFound {
+		return nil, ErrPrivateFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (o *Obs) DeletePrivate(key string) error {
+	return o.DeleteFile(privateObjectName(key))
+}
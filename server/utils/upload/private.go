@@ -0,0 +1,180 @@
+package upload
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+const (
+	// defaultPrivatePath 未配置 local.private-path 时本地存储的私有文件目录
+	defaultPrivatePath = "private"
+	// privateObjectPrefix 对象存储中私有文件的前缀
+	privateObjectPrefix = "private"
+)
+
+var ErrPrivateFileNotFound = errors.New("文件不存在或已删除")
+
+// PrivateOSS 私有对象存储
+// 服务端生成的文件（异步导出、定时报表、日志归档）以私有对象保存在 system.oss-type 配置的存储中，
+// 不提供公开地址，由服务读取后经鉴权或签名的接口下载，多实例部署时任一实例都能读取
+type PrivateOSS interface {
+	PutPrivate(key string, src io.Reader, size int64) error
+	GetPrivate(key string) (io.ReadCloser, error)
+	DeletePrivate(key string) error
+}
+
+var (
+	_ PrivateOSS = (*Local)(nil)
+	_ PrivateOSS = (*Qiniu)(nil)
+	_ PrivateOSS = (*TencentCOS)(nil)
+	_ PrivateOSS = (*AliyunOSS)(nil)
+	_ PrivateOSS = (*Obs)(nil)
+	_ PrivateOSS = (*AwsS3)(nil)
+	_ PrivateOSS = (*CloudflareR2)(nil)
+	_ PrivateOSS = (*Minio)(nil)
+)
+
+// SavePrivate 将服务端生成的文件保存为私有对象，返回存储key，文件名随机生成
+func SavePrivate(category, ext string, src io.Reader, size int64) (key string, err error) {
+	storage, err := privateOss()
+	if err != nil {
+		return "", err
+	}
+	var b [16]byte
+	if _, err = rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	key = path.Join(category, time.Now().Format("200601"), hex.EncodeToString(b[:])+ext)
+	if err = storage.PutPrivate(key, src, size); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// OpenPrivate 读取私有对象，调用方负责关闭
+func OpenPrivate(key string) (io.ReadCloser, error) {
+	if !validPrivateKey(key) {
+		return nil, ErrPrivateFileNotFound
+	}
+	storage, err := privateOss()
+	if err != nil {
+		return nil, err
+	}
+	return storage.GetPrivate(key)
+}
+
+// DeletePrivate 删除私有对象，对象已不存在时不报错
+func DeletePrivate(key string) error {
+	if !validPrivateKey(key) {
+		return nil
+	}
+	storage, err := privateOss()
+	if err != nil {
+		return err
+	}
+	if err = storage.DeletePrivate(key); errors.Is(err, ErrPrivateFileNotFound) {
+		return nil
+	}
+	return err
+}
+
+func privateOss() (PrivateOSS, error) {
+	storage, ok := NewOss().(PrivateOSS)
+	if !ok {
+		return nil, fmt.Errorf("存储类型 %s 不支持私有文件", global.GVA_CONFIG.System.OssType)
+	}
+	return storage, nil
+}
+
+// validPrivateKey 拒绝空key与越出私有目录的key
+func validPrivateKey(key string) bool {
+	clean := path.Clean(key)
+	return key != "" && clean == key && !path.IsAbs(clean) && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// privateObjectName 私有文件在对象存储中的对象名
+func privateObjectName(key string) string {
+	return privateObjectPrefix + "/" + key
+}
+
+// PutPrivate 本地存储的私有文件保存在 local.private-path，不在静态文件路由下
+func (*Local) PutPrivate(key string, src io.Reader, _ int64) error {
+	root, err := privateRoot()
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(root, filepath.FromSlash(key))
+	if err = os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, src); err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(p)
+	}
+	return err
+}
+
+func (*Local) GetPrivate(key string) (io.ReadCloser, error) {
+	root, err := privateRoot()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrPrivateFileNotFound
+	}
+	return f, err
+}
+
+func (*Local) DeletePrivate(key string) error {
+	root, err := privateRoot()
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(root, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrPrivateFileNotFound
+	}
+	return err
+}
+
+// privateRoot 私有目录的绝对路径，不允许位于对外提供静态访问的 local.store-path 之内
+func privateRoot() (string, error) {
+	dir := global.GVA_CONFIG.Local.PrivatePath
+	if dir == "" {
+		dir = defaultPrivatePath
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if store := global.GVA_CONFIG.Local.StorePath; store != "" {
+		public, err := filepath.Abs(store)
+		if err != nil {
+			return "", err
+		}
+		if rel, err := filepath.Rel(public, root); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("local.private-path 不能位于公开访问的 local.store-path(%s) 之内", store)
+		}
+	}
+	return root, nil
+}
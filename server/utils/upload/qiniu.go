@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"net/http"
 	"time"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
@@ -94,3 +96,38 @@ func qiniuConfig() *storage.Config {
 	}
 	return &cfg
 }
+
+// PutPrivate 上传私有文件，七牛云按空间设置访问权限，配置的空间需为私有空间
+func (*Qiniu) PutPrivate(key string, src io.Reader, size int64) error {
+	putPolicy := storage.PutPolicy{Scope: global.GVA_CONFIG.Qiniu.Bucket}
+	mac := qbox.NewMac(global.GVA_CONFIG.Qiniu.AccessKey, global.GVA_CONFIG.Qiniu.SecretKey)
+	formUploader := storage.NewFormUploader(qiniuConfig())
+	ret := storage.PutRet{}
+	return formUploader.Put(context.Background(), &ret, putPolicy.UploadToken(mac), privateObjectName(key), src, size, nil)
+}
+
+// GetPrivate 通过短时有效的私有下载链接读取文件
+func (*Qiniu) GetPrivate(key string) (io.ReadCloser, error) {
+	mac := qbox.NewMac(global.GVA_CONFIG.Qiniu.AccessKey, global.GVA_CONFIG.Qiniu.SecretKey)
+	deadline := time.Now().Add(5 * time.Minute).Unix()
+	resp, err := http.Get(storage.MakePrivateURLv2(mac, global.GVA_CONFIG.Qiniu.ImgPath, privateObjectName(key), deadline))
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrPrivateFileNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("读取七牛云私有文件失败: %s", resp.Status)
+	}
+}
+
+func (*Qiniu) DeletePrivate(key string) error {
+	mac := qbox.NewMac(global.GVA_CONFIG.Qiniu.AccessKey, global.GVA_CONFIG.Qiniu.SecretKey)
+	bucketManager := storage.NewBucketManager(mac, qiniuConfig())
+	return bucketManager.Delete(global.GVA_CONFIG.Qiniu.Bucket, privateObjectName(key))
+}
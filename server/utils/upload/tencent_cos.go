@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -59,3 +60,32 @@ func NewClient() *cos.Client {
 	})
 	return client
 }
+
+// PutPrivate 以私有读写权限上传对象
+func (*TencentCOS) PutPrivate(key string, src io.Reader, size int64) error {
+	client := NewClient()
+	opt := &cos.ObjectPutOptions{
+		ACLHeaderOptions:       &cos.ACLHeaderOptions{XCosACL: "private"},
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{ContentLength: size},
+	}
+	_, err := client.Object.Put(context.Background(), privateObjectName(key), src, opt)
+	return err
+}
+
+func (*TencentCOS) GetPrivate(key string) (io.ReadCloser, error) {
+	client := NewClient()
+	resp, err := client.Object.Get(context.Background(), privateObjectName(key), nil)
+	if cos.IsNotFoundError(err) {
+		return nil, ErrPrivateFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (*TencentCOS) DeletePrivate(key string) error {
+	client := NewClient()
+	_, err := client.Object.Delete(context.Background(), privateObjectName(key))
+	return err
+}
@@ -16,22 +16,23 @@ var (
 		"MerType":          {NotEmpty()},
 		"MaxDecimalAmount": {Ge("1"), Le("99")}, // 1 <= 值 <= 99
 		"MinDecimalAmount": {Ge("1"), Le("99")}, // 1 <= 值 <= 99
-		"MaxAmount":        {Ge("1")},           // ≥1 (大于0)
-		"MinAmount":        {Ge("1")},           // ≥1 (大于0)
+		// MaxAmount/MinAmount 为 decimal 类型，由 MerUser 模型钩子按币种精度校验
 	}
 	PayQrcodeParmsVerify = Rules{
 		"MerId":       {OptionalPositiveNumber()}, // 商户ID可以为空，不为空时必须是大于0的数字
-		"PayAmmount":  {Ge("1")},                  // 支付金额必须大于等于1
+		// PayAmmount 为 decimal 类型，由 ValidateAmount 按币种精度校验
 		"OrderId":     {NotEmpty()},               // 订单ID不能为空
 		"CreateTime":  {NotEmpty()},               // 创建时间不能为空
 		"Expires":     {OptionalPositiveNumber()}, // 过期时间可以为空，不为空时必须是大于0的数字
 		"CallbackUrl": {NotEmpty()},               // 回调URL不能为空
 	}
-	AutoCodeVerify         = Rules{"Abbreviation": {NotEmpty()}, "StructName": {NotEmpty()}, "PackageName": {NotEmpty()}}
-	AutoPackageVerify      = Rules{"PackageName": {NotEmpty()}}
-	AuthorityVerify        = Rules{"AuthorityId": {NotEmpty()}, "AuthorityName": {NotEmpty()}}
-	AuthorityIdVerify      = Rules{"AuthorityId": {NotEmpty()}}
-	OldAuthorityVerify     = Rules{"OldAuthorityId": {NotEmpty()}}
-	ChangePasswordVerify   = Rules{"Password": {NotEmpty()}, "NewPassword": {NotEmpty()}}
-	SetUserAuthorityVerify = Rules{"AuthorityId": {NotEmpty()}}
+	AutoCodeVerify              = Rules{"Abbreviation": {NotEmpty()}, "StructName": {NotEmpty()}, "PackageName": {NotEmpty()}}
+	AutoPackageVerify           = Rules{"PackageName": {NotEmpty()}}
+	AuthorityVerify             = Rules{"AuthorityId": {NotEmpty()}, "AuthorityName": {NotEmpty()}}
+	AuthorityIdVerify           = Rules{"AuthorityId": {NotEmpty()}}
+	OldAuthorityVerify          = Rules{"OldAuthorityId": {NotEmpty()}}
+	ChangePasswordVerify        = Rules{"Password": {NotEmpty()}, "NewPassword": {NotEmpty()}}
+	ChangeExpiredPasswordVerify = Rules{"Username": {NotEmpty()}, "Password": {NotEmpty()}, "NewPassword": {NotEmpty()}}
+	SetUserAuthorityVerify      = Rules{"AuthorityId": {NotEmpty()}}
+	CasbinSimulateVerify        = Rules{"AuthorityId": {NotEmpty()}, "Path": {NotEmpty()}, "Method": {NotEmpty()}}
 )
@@ -22,6 +22,42 @@ export const captcha = () => {
   })
 }
 
+// @Summary 两步验证登录
+// @Produce  application/json
+// @Param data body {totpToken:"string",code:"string"}
+// @Router /base/loginTotp [post]
+export const loginTotp = (data) => {
+  return service({
+    url: '/base/loginTotp',
+    method: 'post',
+    data: data
+  })
+}
+
+// @Summary 登录时绑定两步验证（角色强制要求且尚未绑定）
+// @Produce  application/json
+// @Param data body {totpToken:"string"}
+// @Router /base/totpEnroll [post]
+export const totpEnroll = (data) => {
+  return service({
+    url: '/base/totpEnroll',
+    method: 'post',
+    data: data
+  })
+}
+
+// @Summary 登录时修改已过期或被管理员重置的密码
+// @Produce  application/json
+// @Param data body {username:"string",password:"string",newPassword:"string",captcha:"string",captchaId:"string"}
+// @Router /base/changeExpiredPassword [post]
+export const changeExpiredPassword = (data) => {
+  return service({
+    url: '/base/changeExpiredPassword',
+    method: 'post',
+    data: data
+  })
+}
+
 // @Summary 用户注册
 // @Produce  application/json
 // @Param data body {username:"string",password:"string"}
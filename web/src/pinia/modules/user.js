@@ -1,4 +1,9 @@
-import { login, getUserInfo } from '@/api/user'
+import {
+  login,
+  loginTotp,
+  changeExpiredPassword,
+  getUserInfo
+} from '@/api/user'
 import { jsonInBlacklist } from '@/api/jwt'
 import router from '@/router/index'
 import { ElLoading, ElMessage } from 'element-plus'
@@ -23,6 +28,7 @@ export const useUserStore = defineStore('user', () => {
   const token = useStorage('token', '')
   const xToken = useCookies('x-token')
   const currentToken = computed(() => token.value || xToken.value || '')
+  const refreshToken = useStorage('refreshToken', '')
 
   const setUserInfo = (val) => {
     userInfo.value = val
@@ -41,6 +47,10 @@ export const useUserStore = defineStore('user', () => {
     xToken.value = val
   }
 
+  const setRefreshToken = (val) => {
+    refreshToken.value = val
+  }
+
   const NeedInit = async () => {
     await ClearStorage()
     await router.push({ name: 'Init', replace: true })
@@ -60,49 +70,58 @@ export const useUserStore = defineStore('user', () => {
     }
     return res
   }
-  /* 登录*/
-  const LoginIn = async (loginInfo) => {
+  /* 登录成功后设置用户信息与令牌，加载路由并跳转首页*/
+  const finishLogin = async (data) => {
+    // 登陆成功，设置用户信息和权限相关信息
+    setUserInfo(data.user)
+    setToken(data.token)
+    setRefreshToken(data.refreshToken)
+
+    // 初始化路由信息
+    const routerStore = useRouterStore()
+    await routerStore.SetAsyncRouter()
+    const asyncRouters = routerStore.asyncRouters
+
+    // 注册到路由表里
+    asyncRouters.forEach((asyncRouter) => {
+      router.addRoute(asyncRouter)
+    })
+
+    if(router.currentRoute.value.query.redirect) {
+      await router.replace(router.currentRoute.value.query.redirect)
+      return true
+    }
+
+    if (!router.hasRoute(userInfo.value.authority.defaultRouter)) {
+      ElMessage.error('不存在可以登陆的首页，请联系管理员进行配置')
+    } else {
+      await router.replace({ name: userInfo.value.authority.defaultRouter })
+    }
+
+    const isWindows = /windows/i.test(navigator.userAgent)
+    window.localStorage.setItem('osType', isWindows ? 'WIN' : 'MAC')
+    return true
+  }
+
+  /* 执行一步登录请求：签发令牌时完成登录返回 true；
+     需要修改密码或两步验证时返回 { needChangePassword, expired } 或 { needTotp, needEnroll, totpToken }，由登录页继续下一步；失败返回 false*/
+  const loginStep = async (request, data) => {
     try {
       loadingInstance.value = ElLoading.service({
         fullscreen: true,
         text: '登录中，请稍候...'
       })
 
-      const res = await login(loginInfo)
+      const res = await request(data)
 
       if (res.code !== 0) {
         return false
       }
-      // 登陆成功，设置用户信息和权限相关信息
-      setUserInfo(res.data.user)
-      setToken(res.data.token)
-
-      // 初始化路由信息
-      const routerStore = useRouterStore()
-      await routerStore.SetAsyncRouter()
-      const asyncRouters = routerStore.asyncRouters
-
-      // 注册到路由表里
-      asyncRouters.forEach((asyncRouter) => {
-        router.addRoute(asyncRouter)
-      })
-
-      if(router.currentRoute.value.query.redirect) {
-        await router.replace(router.currentRoute.value.query.redirect)
-        return true
-      }
-
-      if (!router.hasRoute(userInfo.value.authority.defaultRouter)) {
-        ElMessage.error('不存在可以登陆的首页，请联系管理员进行配置')
-      } else {
-        await router.replace({ name: userInfo.value.authority.defaultRouter })
+      if (res.data?.needChangePassword || res.data?.needTotp) {
+        return res.data
       }
-
-      const isWindows = /windows/i.test(navigator.userAgent)
-      window.localStorage.setItem('osType', isWindows ? 'WIN' : 'MAC')
-
       // 全部操作均结束，关闭loading并返回
-      return true
+      return await finishLogin(res.data)
     } catch (error) {
       console.error('LoginIn error:', error)
       return false
@@ -110,6 +129,12 @@ export const useUserStore = defineStore('user', () => {
       loadingInstance.value?.close()
     }
   }
+  /* 登录*/
+  const LoginIn = (loginInfo) => loginStep(login, loginInfo)
+  /* 两步验证登录*/
+  const LoginTotp = (data) => loginStep(loginTotp, data)
+  /* 登录时修改过期密码，修改成功后继续登录*/
+  const ChangeExpiredPassword = (data) => loginStep(changeExpiredPassword, data)
   /* 登出*/
   const LoginOut = async () => {
     const res = await jsonInBlacklist()
@@ -134,17 +159,23 @@ export const useUserStore = defineStore('user', () => {
     // 清理所有相关的localStorage项
     localStorage.removeItem('originSetting')
     localStorage.removeItem('token')
+    localStorage.removeItem('refreshToken')
+    refreshToken.value = ''
   }
 
   return {
     userInfo,
     token: currentToken,
+    refreshToken,
     NeedInit,
     ResetUserInfo,
     GetUserInfo,
     LoginIn,
+    LoginTotp,
+    ChangeExpiredPassword,
     LoginOut,
     setToken,
+    setRefreshToken,
     loadingInstance,
     ClearStorage
   }
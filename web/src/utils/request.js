@@ -123,6 +123,32 @@ service.interceptors.request.use(
   }
 )
 
+// 访问令牌过期时使用刷新令牌换取新令牌，并发请求共用同一次刷新
+let refreshing = null
+const refreshAccessToken = () => {
+  const userStore = useUserStore()
+  if (!refreshing) {
+    refreshing = axios
+      .post(
+        import.meta.env.VITE_BASE_API + '/base/refresh',
+        { refreshToken: userStore.refreshToken },
+        { headers: { 'Content-Type': 'application/json' } }
+      )
+      .then((res) => {
+        if (res.data.code !== 0) {
+          throw new Error(res.data.msg)
+        }
+        userStore.setToken(res.data.data.token)
+        userStore.setRefreshToken(res.data.data.refreshToken)
+        return res.data.data.token
+      })
+      .finally(() => {
+        refreshing = null
+      })
+  }
+  return refreshing
+}
+
 function getErrorMessage(error) {
   return error.response?.data?.msg || '请求失败'
 }
@@ -171,6 +197,25 @@ service.interceptors.response.use(
 
     // HTTP 状态码错误
     if (error.response.status === 401) {
+      const userStore = useUserStore()
+      const config = error.config
+      if (
+        userStore.refreshToken &&
+        !config._retried &&
+        !config.url.endsWith('/base/refresh')
+      ) {
+        config._retried = true
+        return refreshAccessToken()
+          .then((token) => {
+            config.headers['x-token'] = token
+            return service(config)
+          })
+          .catch(() => {
+            userStore.ClearStorage()
+            router.push({ name: 'Login', replace: true })
+            return Promise.reject(error)
+          })
+      }
       emitter.emit('show-error', {
         code: '401',
         message: getErrorMessage(error),